@@ -1,28 +1,211 @@
 package api
 
 import (
+	"fmt"
 	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/bitnami-labs/charts-syncer/internal/schedule"
 )
 
 // Validate validates the config file is correct
 func (c *Config) Validate() error {
+	if s := c.GetSchedule(); s != "" {
+		if _, err := schedule.Parse(s); err != nil {
+			return errors.Errorf(`"schedule" should be a valid cron expression: %v`, err)
+		}
+	}
+	if len(c.GetSyncs()) > 0 {
+		return c.validateSyncs()
+	}
+	if len(c.GetTargets()) > 0 {
+		return c.validateTargets()
+	}
 	if repo := c.GetSource().GetRepo(); repo != nil {
 		if _, err := url.ParseRequestURI(repo.GetUrl()); err != nil {
 			return errors.Errorf(`"source.repo.url" should be a valid URL: %v`, err)
 		}
+		if err := validateBucketRepo("source.repo", repo); err != nil {
+			return err
+		}
+		if err := validateRateLimit("source.repo", repo); err != nil {
+			return err
+		}
+	}
+	for i, repo := range c.GetSource().GetAdditionalRepos() {
+		name := fmt.Sprintf("source.additionalRepos[%d]", i)
+		if c.GetSource().GetRepo() == nil {
+			return errors.Errorf(`"source.additionalRepos" requires "source.repo"`)
+		}
+		if _, err := url.ParseRequestURI(repo.GetUrl()); err != nil {
+			return errors.Errorf(`"%s.url" should be a valid URL: %v`, name, err)
+		}
+		if err := validateBucketRepo(name, repo); err != nil {
+			return err
+		}
+		if err := validateRateLimit(name, repo); err != nil {
+			return err
+		}
+		if sameRepo(repo, c.GetTarget().GetRepo()) {
+			return errors.Errorf(`"%s" and "target.repo" point at the same repository`, name)
+		}
 	}
 	if repo := c.GetTarget().GetRepo(); repo != nil {
 		switch k := repo.GetKind(); k {
-		case Kind_CHARTMUSEUM, Kind_HELM, Kind_HARBOR, Kind_OCI:
+		case Kind_CHARTMUSEUM, Kind_HELM, Kind_HARBOR, Kind_OCI, Kind_ARTIFACTORY, Kind_NEXUS:
 			if _, err := url.ParseRequestURI(repo.GetUrl()); err != nil {
 				return errors.Errorf(`"target.repo.url" should be a valid URL: %v`, err)
 			}
+		case Kind_GIT:
+			if repo.GetUrl() == "" {
+				return errors.Errorf(`"target.repo.url" should be the git remote of the repository`)
+			}
+			if u := repo.GetGit().GetPagesUrl(); u != "" {
+				if _, err := url.ParseRequestURI(u); err != nil {
+					return errors.Errorf(`"target.repo.git.pagesUrl" should be a valid URL: %v`, err)
+				}
+			}
 		case Kind_LOCAL:
+			// The charts would be written to the working directory otherwise
+			if repo.GetPath() == "" {
+				return errors.Errorf(`"target.repo.path" is required for a LOCAL "target.repo"`)
+			}
+			if u := repo.GetUrl(); u != "" && repo.GetGenerateIndex() {
+				if _, err := url.ParseRequestURI(u); err != nil {
+					return errors.Errorf(`"target.repo.url" should be a valid URL: %v`, err)
+				}
+			}
+		}
+		if err := validateBucketRepo("target.repo", repo); err != nil {
+			return err
+		}
+		if err := validateRateLimit("target.repo", repo); err != nil {
+			return err
+		}
+	}
+
+	if c.GetTarget().GetRepo().GetOciMediaTypes() != nil && c.GetTarget().GetRepo().GetKind() != Kind_OCI {
+		return errors.Errorf(`"target.repo.ociMediaTypes" requires an OCI "target.repo"`)
+	}
+	if c.GetTarget().GetRepo().GetGit() != nil && c.GetTarget().GetRepo().GetKind() != Kind_GIT {
+		return errors.Errorf(`"target.repo.git" requires a GIT "target.repo"`)
+	}
+
+	// Index-only
+	if c.GetIndexOnly() {
+		if k := c.GetSource().GetRepo().GetKind(); k != Kind_HELM && k != Kind_CHARTMUSEUM && k != Kind_HARBOR {
+			return errors.Errorf(`"indexOnly" requires a HELM, CHARTMUSEUM or HARBOR "source.repo"`)
+		}
+		if len(c.GetSource().GetAdditionalRepos()) > 0 {
+			return errors.Errorf(`"indexOnly" cannot be used with "source.additionalRepos"`)
+		}
+		if c.GetTarget().GetRepo().GetKind() != Kind_LOCAL {
+			return errors.Errorf(`"indexOnly" requires a LOCAL "target.repo"`)
+		}
+		// The charts are not repackaged
+		if c.GetRelocateContainerImages() || len(c.GetTransformations()) > 0 || c.GetVersionSuffix() != "" || c.GetRename() != nil {
+			return errors.Errorf(`"indexOnly" cannot be used with "relocateContainerImages", "transformations", "versionSuffix" or "rename"`)
+		}
+	}
+
+	// Harbor
+	if h := c.GetTarget().GetHarbor(); h != nil {
+		if k := c.GetTarget().GetRepo().GetKind(); k != Kind_HARBOR && k != Kind_OCI {
+			return errors.Errorf(`"target.harbor" requires a HARBOR or OCI "target.repo"`)
+		}
+		for i, r := range h.GetRetention() {
+			if r.GetLatestPushed() <= 0 && r.GetPushedWithinDays() <= 0 {
+				return errors.Errorf(`"target.harbor.retention[%d]" requires a positive "latestPushed" or "pushedWithinDays"`, i)
+			}
+		}
+	}
+
+	// Flux
+	if f := c.GetFlux(); f != nil {
+		if f.GetOutput() == "" {
+			return errors.Errorf(`"flux" "output" is required`)
+		}
+		if k := c.GetTarget().GetRepo().GetKind(); k == Kind_UNKNOWN || k == Kind_LOCAL {
+			return errors.Errorf(`"flux" requires a HELM, CHARTMUSEUM, HARBOR or OCI "target.repo"`)
+		}
+		if f.GetInterval() != "" {
+			if d, err := time.ParseDuration(f.GetInterval()); err != nil || d <= 0 {
+				return errors.Errorf(`"flux" "interval" %q is not a valid duration`, f.GetInterval())
+			}
+		}
+	}
+
+	// Argo CD
+	if a := c.GetArgocd(); a != nil {
+		if a.GetOutput() == "" {
+			return errors.Errorf(`"argocd" "output" is required`)
+		}
+		if k := c.GetTarget().GetRepo().GetKind(); k == Kind_UNKNOWN || k == Kind_LOCAL {
+			return errors.Errorf(`"argocd" requires a HELM, CHARTMUSEUM, HARBOR or OCI "target.repo"`)
+		}
+	}
+
+	// Syncing a repository onto itself would modify its charts in place
+	if sameRepo(c.GetSource().GetRepo(), c.GetTarget().GetRepo()) {
+		return errors.Errorf(`"source.repo" and "target.repo" point at the same repository`)
+	}
+	for i, m := range c.GetSource().GetRepo().GetMirrors() {
+		mirror := &Repo{Kind: c.GetSource().GetRepo().GetKind(), Url: m}
+		if sameRepo(mirror, c.GetTarget().GetRepo()) {
+			return errors.Errorf(`"source.repo.mirrors[%d]" and "target.repo" point at the same repository`, i)
+		}
+	}
+	if p := c.GetSource().GetIntermediateBundlesPath(); p != "" && samePath(p, c.GetTarget().GetIntermediateBundlesPath()) {
+		return errors.Errorf(`"source.intermediateBundlesPath" and "target.intermediateBundlesPath" point at the same directory`)
+	}
+	if r := c.GetSource().GetRepo(); r.GetKind() == Kind_LOCAL && samePath(r.GetPath(), c.GetTarget().GetIntermediateBundlesPath()) {
+		return errors.Errorf(`"source.repo" and "target.intermediateBundlesPath" point at the same directory`)
+	}
+	if r := c.GetTarget().GetRepo(); r.GetKind() == Kind_LOCAL && samePath(c.GetSource().GetIntermediateBundlesPath(), r.GetPath()) {
+		return errors.Errorf(`"source.intermediateBundlesPath" and "target.repo" point at the same directory`)
+	}
+
+	// Source verification
+	if v := c.GetSource().GetVerify(); v != nil {
+		if c.GetSource().GetRepo() == nil {
+			return errors.Errorf(`"source.verify" requires "source.repo"`)
+		}
+		if c.GetIndexOnly() {
+			return errors.Errorf(`"source.verify" is not supported by index-only syncs, the charts are not fetched`)
 		}
 	}
 
+	if c.GetForceOverwrite() && !c.GetCompareDigest() {
+		return errors.Errorf(`"forceOverwrite" requires "compareDigest"`)
+	}
+
+	// Bundle encryption
+	if e := c.GetTarget().GetBundleEncryption(); e != nil {
+		if c.GetTarget().GetIntermediateBundlesPath() == "" {
+			return errors.Errorf(`"target.bundleEncryption" requires "target.intermediateBundlesPath"`)
+		}
+		if (len(e.GetAgeRecipients()) > 0) == (e.GetGpgKeyring() != "") {
+			return errors.Errorf(`"target.bundleEncryption" requires either "ageRecipients" or "gpgKeyring"`)
+		}
+	}
+	if size := c.GetTarget().GetBundlePartSize(); size < 0 {
+		return errors.Errorf(`"target.bundlePartSize" must be positive`)
+	} else if size > 0 && c.GetTarget().GetIntermediateBundlesPath() == "" {
+		return errors.Errorf(`"target.bundlePartSize" requires "target.intermediateBundlesPath"`)
+	}
+	if c.GetSource().GetBundleDecryption() != nil && c.GetSource().GetIntermediateBundlesPath() == "" {
+		return errors.Errorf(`"source.bundleDecryption" requires "source.intermediateBundlesPath"`)
+	}
+
 	// Authentication
 	// Container images
 	if auth := c.GetSource().GetContainers().GetAuth(); auth != nil {
@@ -38,5 +221,457 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Provenance
+	if key := c.GetTarget().GetSigningKey(); key != nil {
+		if key.GetKeyring() == "" || key.GetName() == "" {
+			return errors.Errorf(`"target.signingKey" "keyring" and "name" are required`)
+		}
+	} else if c.GetProvenancePolicy() == ProvenancePolicy_PROVENANCE_REGENERATE {
+		if key := c.GetSigningKey(); key.GetKeyring() == "" || key.GetName() == "" {
+			return errors.Errorf(`"signingKey" "keyring" and "name" are required by the PROVENANCE_REGENERATE provenance policy`)
+		}
+	}
+
+	// Cosign
+	if cs := c.GetTarget().GetCosign(); cs != nil {
+		if c.GetTarget().GetRepo().GetKind() != Kind_OCI {
+			return errors.Errorf(`"target.cosign" is only supported by OCI target repositories`)
+		}
+		for _, f := range []struct{ name, url string }{{"fulcioUrl", cs.GetFulcioUrl()}, {"rekorUrl", cs.GetRekorUrl()}} {
+			if u, err := url.Parse(f.url); f.url != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+				return errors.Errorf(`"target.cosign.%s" %q must be an HTTP(S) URL`, f.name, f.url)
+			}
+		}
+	}
+
+	// Chart filters
+	for _, f := range []struct {
+		name    string
+		regexps []string
+	}{{"chartsRegex", c.GetChartsRegex()}, {"excludeChartsRegex", c.GetExcludeChartsRegex()}} {
+		for _, re := range f.regexps {
+			if _, err := regexp.Compile(re); err != nil {
+				return errors.Errorf(`"%s" %q is not a valid regular expression: %v`, f.name, re, err)
+			}
+		}
+	}
+	for _, pattern := range c.GetExcludeCharts() {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Errorf(`"excludeCharts" %q is not a valid glob pattern: %v`, pattern, err)
+		}
+	}
+	for name, f := range c.GetChartFilters() {
+		if v := f.GetVersions(); v != "" {
+			if _, err := semver.NewConstraint(v); err != nil {
+				return errors.Errorf(`"charts" versions %q of %q chart is not a valid semver constraint: %v`, v, name, err)
+			}
+		}
+	}
+
+	// Rename
+	for name, newName := range c.GetRename().GetCharts() {
+		if newName == "" || strings.ContainsAny(newName, "/ ") {
+			return errors.Errorf(`"rename.charts" name %q of %q chart is not valid`, newName, name)
+		}
+	}
+	if strings.ContainsAny(c.GetRename().GetPrefix(), "/ ") {
+		return errors.Errorf(`"rename.prefix" %q is not valid`, c.GetRename().GetPrefix())
+	}
+
+	// Attestation
+	if id := c.GetAttestation().GetBuilderId(); id != "" {
+		if u, err := url.Parse(id); err != nil || u.Scheme == "" {
+			return errors.Errorf(`"attestation.builderId" %q must be a URI`, id)
+		}
+	}
+
+	// SBOM scanning
+	if dt := c.GetSbom().GetDependencyTrack(); dt != nil {
+		if !c.GetSbom().GetEnabled() {
+			return errors.Errorf(`"sbom" "dependencyTrack" requires "sbom" "enabled"`)
+		}
+		if u, err := url.Parse(dt.GetUrl()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf(`"sbom" "dependencyTrack" "url" %q must be an http(s) URL`, dt.GetUrl())
+		}
+		switch dt.GetSeverityThreshold() {
+		case "", "LOW", "MEDIUM", "HIGH", "CRITICAL":
+		default:
+			return errors.Errorf(`"sbom" "dependencyTrack" "severityThreshold" %q must be LOW, MEDIUM, HIGH or CRITICAL`, dt.GetSeverityThreshold())
+		}
+		if dt.GetTimeout() != "" {
+			if d, err := time.ParseDuration(dt.GetTimeout()); err != nil || d <= 0 {
+				return errors.Errorf(`"sbom" "dependencyTrack" "timeout" %q is not a valid duration`, dt.GetTimeout())
+			}
+		}
+	}
+
+	// Verification webhook
+	if w := c.GetVerificationWebhook(); w != nil {
+		if u, err := url.Parse(w.GetUrl()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf(`"verificationWebhook" "url" %q must be an http(s) URL`, w.GetUrl())
+		}
+		if w.GetTimeout() != "" {
+			if d, err := time.ParseDuration(w.GetTimeout()); err != nil || d <= 0 {
+				return errors.Errorf(`"verificationWebhook" "timeout" %q is not a valid duration`, w.GetTimeout())
+			}
+		}
+	}
+
+	if t := c.GetTelemetry(); t.GetEnabled() {
+		if u, err := url.Parse(t.GetEndpoint()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf(`"telemetry" "endpoint" %q must be an http(s) URL`, t.GetEndpoint())
+		}
+		if t.GetTimeout() != "" {
+			if d, err := time.ParseDuration(t.GetTimeout()); err != nil || d <= 0 {
+				return errors.Errorf(`"telemetry" "timeout" %q is not a valid duration`, t.GetTimeout())
+			}
+		}
+	}
+
+	for i, n := range c.GetNotifications() {
+		if u, err := url.Parse(n.GetUrl()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf(`"notifications[%d]" "url" %q must be an http(s) URL`, i, n.GetUrl())
+		}
+		if n.GetTimeout() != "" {
+			if d, err := time.ParseDuration(n.GetTimeout()); err != nil || d <= 0 {
+				return errors.Errorf(`"notifications[%d]" "timeout" %q is not a valid duration`, i, n.GetTimeout())
+			}
+		}
+	}
+
+	// Retry policy
+	if r := c.GetRetry(); r != nil {
+		for _, f := range []struct{ name, value string }{{"initialBackoff", r.GetInitialBackoff()}, {"maxBackoff", r.GetMaxBackoff()}} {
+			if f.value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(f.value); err != nil || d <= 0 {
+				return errors.Errorf(`"retry" %q %q is not a valid duration`, f.name, f.value)
+			}
+		}
+		for _, code := range r.GetRetryOn() {
+			if code < 100 || code > 599 {
+				return errors.Errorf(`"retry" "retryOn" %d is not an HTTP status code`, code)
+			}
+		}
+	}
+
+	// State
+	if k := c.GetState().GetKubernetes(); k != nil {
+		if k.GetName() == "" {
+			return errors.Errorf(`"state.kubernetes" "name" is required`)
+		}
+		if k.GetLockTimeout() != "" {
+			if d, err := time.ParseDuration(k.GetLockTimeout()); err != nil || d <= 0 {
+				return errors.Errorf(`"state.kubernetes" "lockTimeout" %q is not a valid duration`, k.GetLockTimeout())
+			}
+		}
+	}
+	if o := c.GetState().GetObject(); o != nil {
+		switch o.GetKind() {
+		case Kind_S3, Kind_GCS, Kind_AZBLOB:
+		default:
+			return errors.Errorf(`"state.object" "kind" must be S3, GCS or AZBLOB`)
+		}
+		if err := validateBucketRepo("state.object", o); err != nil {
+			return err
+		}
+		if u, _ := url.Parse(o.GetUrl()); strings.Trim(u.Path, "/") == "" || strings.HasSuffix(u.Path, "/") {
+			return errors.Errorf(`"state.object.url" %q should include the key of the state object`, o.GetUrl())
+		}
+	}
+
+	// Ignore rules
+	for i, rule := range c.GetIgnore() {
+		if strings.Contains(rule, "**") {
+			return errors.Errorf(`"ignore[%d]" %q uses the double-star (**) syntax, which is not supported`, i, rule)
+		}
+		if _, err := filepath.Match(rule, "abc"); err != nil {
+			return errors.Errorf(`"ignore[%d]" %q is not valid: %v`, i, rule, err)
+		}
+	}
+
+	// The suffix is validated on its own, chart versions are checked once
+	// the charts are repackaged
+	if suffix := c.GetVersionSuffix(); suffix != "" {
+		if !strings.HasPrefix(suffix, "-") && !strings.HasPrefix(suffix, "+") {
+			return errors.Errorf(`"versionSuffix" must start with "-" (pre-release) or "+" (build metadata)`)
+		}
+		if _, err := semver.StrictNewVersion("0.0.0" + suffix); err != nil {
+			return errors.Errorf(`"versionSuffix" %q is not valid: %v`, suffix, err)
+		}
+	}
+
+	// Transformations
+	for i, t := range c.GetTransformations() {
+		if _, err := template.New("notice").Parse(t.GetNotice()); err != nil {
+			return errors.Errorf(`"transformations[%d].notice" is not a valid template: %v`, i, err)
+		}
+		for _, m := range t.GetMaintainers() {
+			if m.GetName() == "" {
+				return errors.Errorf(`"transformations[%d].maintainers" "name" is required`, i)
+			}
+		}
+		if icon := t.GetIcon(); icon != nil {
+			if u, err := url.Parse(icon.GetBaseUrl()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return errors.Errorf(`"transformations[%d].icon" "baseUrl" must be an http(s) URL`, i)
+			}
+		}
+		if t.GetReadme() != nil && t.GetReadme().GetTemplate() == "" {
+			return errors.Errorf(`"transformations[%d].readme" "template" is required`, i)
+		}
+		for _, r := range t.GetLinks().GetRewrites() {
+			if r.GetOld() == "" {
+				return errors.Errorf(`"transformations[%d].links.rewrites" "old" is required`, i)
+			}
+		}
+		for _, l := range append([]string{t.GetLinks().GetHome()}, t.GetLinks().GetAppendSources()...) {
+			if _, err := template.New("link").Parse(l); err != nil {
+				return errors.Errorf(`"transformations[%d].links" %q is not a valid template: %v`, i, l, err)
+			}
+		}
+		if e := t.GetExec(); e != nil {
+			if len(e.GetCommand()) == 0 {
+				return errors.Errorf(`"transformations[%d].exec" "command" is required`, i)
+			}
+			if e.GetTimeout() != "" {
+				if d, err := time.ParseDuration(e.GetTimeout()); err != nil || d <= 0 {
+					return errors.Errorf(`"transformations[%d].exec" "timeout" %q is not a valid duration`, i, e.GetTimeout())
+				}
+			}
+		}
+		for j, r := range t.GetDependencies() {
+			field := fmt.Sprintf("transformations[%d].dependencies[%d]", i, j)
+			if r.GetName() == "" {
+				return errors.Errorf(`%q "name" is required`, field)
+			}
+			switch a := r.GetAction().(type) {
+			case nil:
+				return errors.Errorf(`%q requires one of "remove" or "substitute"`, field)
+			case *DependencyRule_Substitute_:
+				if a.Substitute.GetName() == "" || a.Substitute.GetVersion() == "" || a.Substitute.GetRepository() == "" {
+					return errors.Errorf(`%q "substitute" "name", "version" and "repository" are required`, field)
+				}
+				// Relocated charts keep their vendored dependencies
+				if c.GetRelocateContainerImages() || c.GetSource().GetIntermediateBundlesPath() != "" {
+					return errors.Errorf(`%q "substitute" is not supported when relocating container images`, field)
+				}
+			}
+		}
+		for _, f := range t.GetFiles() {
+			if f.GetPath() == "" || f.GetTemplate() == "" {
+				return errors.Errorf(`"transformations[%d].files" "path" and "template" are required`, i)
+			}
+			if p := path.Clean(f.GetPath()); path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../") {
+				return errors.Errorf(`"transformations[%d].files" path %q must be relative to the chart root`, i, f.GetPath())
+			}
+		}
+		for _, p := range t.GetStrip() {
+			if _, err := path.Match(p, "abc"); err != nil {
+				return errors.Errorf(`"transformations[%d].strip" pattern %q is not valid: %v`, i, p, err)
+			}
+		}
+		if err := validateJSONPatch(fmt.Sprintf("transformations[%d].chartPatch", i), t.GetChartPatch()); err != nil {
+			return err
+		}
+		if err := validateJSONPatch(fmt.Sprintf("transformations[%d].valuesPatch", i), t.GetValuesPatch()); err != nil {
+			return err
+		}
+		for j, p := range t.GetValues() {
+			field := fmt.Sprintf("transformations[%d].values[%d]", i, j)
+			switch op := p.GetOp().(type) {
+			case nil:
+				return errors.Errorf(`%q requires one of "set", "delete" or "replace"`, field)
+			case *ValuesPatch_Replace_:
+				if op.Replace.GetOld() == "" {
+					return errors.Errorf(`%q "replace.old" is required`, field)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateBucketRepo validates the URL and options of a repository stored in a
+// bucket
+func validateBucketRepo(field string, repo *Repo) error {
+	if repo.GetS3() != nil && repo.GetKind() != Kind_S3 {
+		return errors.Errorf(`%q "s3" requires the S3 kind`, field)
+	}
+	if repo.GetGcs() != nil && repo.GetKind() != Kind_GCS {
+		return errors.Errorf(`%q "gcs" requires the GCS kind`, field)
+	}
+	if repo.GetAzblob() != nil && repo.GetKind() != Kind_AZBLOB {
+		return errors.Errorf(`%q "azblob" requires the AZBLOB kind`, field)
+	}
+	scheme, root, endpoint, options := "", "bucket", "", ""
+	switch repo.GetKind() {
+	case Kind_S3:
+		scheme, endpoint, options = "s3", repo.GetS3().GetEndpoint(), "s3"
+	case Kind_GCS:
+		scheme, endpoint, options = "gs", repo.GetGcs().GetEndpoint(), "gcs"
+	case Kind_AZBLOB:
+		scheme, root, endpoint, options = "azblob", "container", repo.GetAzblob().GetEndpoint(), "azblob"
+	default:
+		return nil
+	}
+	if u, err := url.Parse(repo.GetUrl()); err != nil || u.Scheme != scheme || u.Host == "" {
+		return errors.Errorf(`"%s.url" %q should be like %s://%s/prefix`, field, repo.GetUrl(), scheme, root)
+	}
+	if endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf(`"%s.%s" "endpoint" %q must be an http(s) URL`, field, options, endpoint)
+		}
+	}
+	return nil
+}
+
+// validateRateLimit validates the rate limit of a repository, only enforced
+// for the repositories reached through HTTP clients
+func validateRateLimit(field string, repo *Repo) error {
+	l := repo.GetRateLimit()
+	if l == nil {
+		return nil
+	}
+	switch repo.GetKind() {
+	case Kind_HELM, Kind_CHARTMUSEUM, Kind_HARBOR, Kind_ARTIFACTORY, Kind_NEXUS, Kind_OCI:
+	default:
+		return errors.Errorf(`"%s.rateLimit" is not supported by %s repositories`, field, repo.GetKind())
+	}
+	if l.GetRequestsPerSecond() <= 0 {
+		return errors.Errorf(`"%s.rateLimit" "requestsPerSecond" must be positive`, field)
+	}
+	return nil
+}
+
+// sameRepo returns whether two repositories resolve to the same location
+func sameRepo(a, b *Repo) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.GetKind() == Kind_LOCAL || b.GetKind() == Kind_LOCAL {
+		return a.GetKind() == b.GetKind() && samePath(a.GetPath(), b.GetPath())
+	}
+	ua, erra := normalizeRepoURL(a.GetUrl(), a.GetKind() == Kind_OCI)
+	ub, errb := normalizeRepoURL(b.GetUrl(), b.GetKind() == Kind_OCI)
+	return erra == nil && errb == nil && ua == ub
+}
+
+// normalizeRepoURL returns a canonical representation of a repository URL.
+//
+// The scheme and host are case-insensitive, default ports and trailing
+// slashes are ignored. OCI repositories are identified by host and path only,
+// since the same registry may be referenced with different schemes.
+func normalizeRepoURL(s string, oci bool) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	p := strings.TrimSuffix(path.Clean("/"+u.Path), "/")
+	if oci {
+		return host + p, nil
+	}
+	return scheme + "://" + host + p, nil
+}
+
+// samePath returns whether two local paths point at the same directory
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	pa, erra := filepath.Abs(a)
+	pb, errb := filepath.Abs(b)
+	return erra == nil && errb == nil && pa == pb
+}
+
+// validateJSONPatch validates the operations of a JSON patch
+func validateJSONPatch(field string, ops []*JSONPatchOperation) error {
+	for j, op := range ops {
+		if op.GetPath() == "" {
+			return errors.Errorf(`"%s[%d]" "path" is required`, field, j)
+		}
+		switch op.GetOp() {
+		case "add", "replace", "test":
+			if op.GetValue() == "" {
+				return errors.Errorf(`"%s[%d]" "value" is required for %q operations`, field, j, op.GetOp())
+			}
+		case "move", "copy":
+			if op.GetFrom() == "" {
+				return errors.Errorf(`"%s[%d]" "from" is required for %q operations`, field, j, op.GetOp())
+			}
+		case "remove":
+		default:
+			return errors.Errorf(`"%s[%d]" "op" %q is not valid`, field, j, op.GetOp())
+		}
+	}
+	return nil
+}
+
+// validateTargets validates a configuration with several targets, as the
+// configuration of each of them
+func (c *Config) validateTargets() error {
+	if c.GetTarget() != nil {
+		return errors.Errorf(`"target" and "targets" cannot be set at the same time`)
+	}
+	// They describe or record a single target
+	if c.GetState() != nil || c.GetFlux() != nil || c.GetArgocd() != nil {
+		return errors.Errorf(`"state", "flux" and "argocd" are not supported with "targets"`)
+	}
+	for i, t := range c.GetTargets() {
+		tc := proto.Clone(c).(*Config)
+		tc.Target, tc.Targets = t, nil
+		if err := tc.Validate(); err != nil {
+			return errors.Wrapf(err, `"targets[%d]"`, i)
+		}
+	}
+	return nil
+}
+
+// SyncConfigs returns the configuration of every sync: the syncs entries,
+// inheriting the other properties of the configuration, or the configuration
+// itself if it has none. The properties of the entries override the inherited
+// ones, lists are appended to them and objects are merged.
+func (c *Config) SyncConfigs() []*Config {
+	if len(c.GetSyncs()) == 0 {
+		return []*Config{c}
+	}
+	configs := make([]*Config, 0, len(c.GetSyncs()))
+	for _, entry := range c.GetSyncs() {
+		sc := proto.Clone(c).(*Config)
+		sc.Syncs = nil
+		proto.Merge(sc, entry)
+		configs = append(configs, sc)
+	}
+	return configs
+}
+
+// validateSyncs validates a configuration with several syncs, as the
+// configuration of each of them
+func (c *Config) validateSyncs() error {
+	// Inherited by every entry, they would sync or record the same
+	// repositories several times
+	if c.GetSource() != nil || c.GetTarget() != nil || len(c.GetTargets()) > 0 || c.GetState() != nil || c.GetFlux() != nil || c.GetArgocd() != nil {
+		return errors.Errorf(`"source", "target", "targets", "state", "flux" and "argocd" must be set in the "syncs" entries`)
+	}
+	for i, entry := range c.GetSyncs() {
+		if len(entry.GetSyncs()) > 0 {
+			return errors.Errorf(`"syncs[%d]" cannot have "syncs"`, i)
+		}
+		// The syncs entries run together
+		if entry.GetSchedule() != "" {
+			return errors.Errorf(`"syncs[%d]" cannot have "schedule", it should be set for the whole file`, i)
+		}
+	}
+	for i, sc := range c.SyncConfigs() {
+		if err := sc.Validate(); err != nil {
+			return errors.Wrapf(err, `"syncs[%d]"`, i)
+		}
+	}
 	return nil
 }
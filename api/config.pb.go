@@ -29,17 +29,37 @@ const (
 	Kind_HARBOR      Kind = 3
 	Kind_OCI         Kind = 4
 	Kind_LOCAL       Kind = 5
+	// Static repository in an Amazon S3 or S3-compatible bucket
+	Kind_S3 Kind = 6
+	// Static repository in a Google Cloud Storage bucket
+	Kind_GCS Kind = 7
+	// Static repository in an Azure Blob Storage container
+	Kind_AZBLOB Kind = 8
+	// JFrog Artifactory Helm repository, written through the Artifactory API
+	Kind_ARTIFACTORY Kind = 9
+	// Sonatype Nexus Repository 3 hosted Helm repository, written through the
+	// components API
+	Kind_NEXUS Kind = 10
+	// Static repository in a branch of a git repository, e.g. served by
+	// GitHub Pages
+	Kind_GIT Kind = 11
 )
 
 // Enum value maps for Kind.
 var (
 	Kind_name = map[int32]string{
-		0: "UNKNOWN",
-		1: "HELM",
-		2: "CHARTMUSEUM",
-		3: "HARBOR",
-		4: "OCI",
-		5: "LOCAL",
+		0:  "UNKNOWN",
+		1:  "HELM",
+		2:  "CHARTMUSEUM",
+		3:  "HARBOR",
+		4:  "OCI",
+		5:  "LOCAL",
+		6:  "S3",
+		7:  "GCS",
+		8:  "AZBLOB",
+		9:  "ARTIFACTORY",
+		10: "NEXUS",
+		11: "GIT",
 	}
 	Kind_value = map[string]int32{
 		"UNKNOWN":     0,
@@ -48,6 +68,12 @@ var (
 		"HARBOR":      3,
 		"OCI":         4,
 		"LOCAL":       5,
+		"S3":          6,
+		"GCS":         7,
+		"AZBLOB":      8,
+		"ARTIFACTORY": 9,
+		"NEXUS":       10,
+		"GIT":         11,
 	}
 )
 
@@ -78,6 +104,271 @@ func (Kind) EnumDescriptor() ([]byte, []int) {
 	return file_config_proto_rawDescGZIP(), []int{0}
 }
 
+// ConflictStrategy indicates how to proceed when the same chart version is
+// provided with different contents
+type ConflictStrategy int32
+
+const (
+	// Keep the chart version that was indexed first, from the first source
+	// repository providing it
+	ConflictStrategy_CONFLICT_FIRST_WINS ConflictStrategy = 0
+	// Keep the chart version from the source repository with the lowest
+	// `priority` value
+	ConflictStrategy_CONFLICT_PREFER_PRIORITY ConflictStrategy = 1
+	// Fail to index the chart version
+	ConflictStrategy_CONFLICT_FAIL ConflictStrategy = 2
+)
+
+// Enum value maps for ConflictStrategy.
+var (
+	ConflictStrategy_name = map[int32]string{
+		0: "CONFLICT_FIRST_WINS",
+		1: "CONFLICT_PREFER_PRIORITY",
+		2: "CONFLICT_FAIL",
+	}
+	ConflictStrategy_value = map[string]int32{
+		"CONFLICT_FIRST_WINS":      0,
+		"CONFLICT_PREFER_PRIORITY": 1,
+		"CONFLICT_FAIL":            2,
+	}
+)
+
+func (x ConflictStrategy) Enum() *ConflictStrategy {
+	p := new(ConflictStrategy)
+	*p = x
+	return p
+}
+
+func (x ConflictStrategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ConflictStrategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_proto_enumTypes[1].Descriptor()
+}
+
+func (ConflictStrategy) Type() protoreflect.EnumType {
+	return &file_config_proto_enumTypes[1]
+}
+
+func (x ConflictStrategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ConflictStrategy.Descriptor instead.
+func (ConflictStrategy) EnumDescriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{1}
+}
+
+// LintPolicy indicates how to proceed when a repackaged chart does not pass
+// the Helm linter
+type LintPolicy int32
+
+const (
+	// Log the linter errors and push the chart anyway
+	LintPolicy_LINT_WARN LintPolicy = 0
+	// Do not push the chart
+	LintPolicy_LINT_FAIL LintPolicy = 1
+	// Do not lint the repackaged charts
+	LintPolicy_LINT_SKIP LintPolicy = 2
+)
+
+// Enum value maps for LintPolicy.
+var (
+	LintPolicy_name = map[int32]string{
+		0: "LINT_WARN",
+		1: "LINT_FAIL",
+		2: "LINT_SKIP",
+	}
+	LintPolicy_value = map[string]int32{
+		"LINT_WARN": 0,
+		"LINT_FAIL": 1,
+		"LINT_SKIP": 2,
+	}
+)
+
+func (x LintPolicy) Enum() *LintPolicy {
+	p := new(LintPolicy)
+	*p = x
+	return p
+}
+
+func (x LintPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LintPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_proto_enumTypes[2].Descriptor()
+}
+
+func (LintPolicy) Type() protoreflect.EnumType {
+	return &file_config_proto_enumTypes[2]
+}
+
+func (x LintPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LintPolicy.Descriptor instead.
+func (LintPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{2}
+}
+
+// SbomFormat is the format of the software bills of materials
+type SbomFormat int32
+
+const (
+	// SPDX 2.3 JSON document
+	SbomFormat_SBOM_SPDX SbomFormat = 0
+	// CycloneDX 1.4 JSON BOM
+	SbomFormat_SBOM_CYCLONEDX SbomFormat = 1
+)
+
+// Enum value maps for SbomFormat.
+var (
+	SbomFormat_name = map[int32]string{
+		0: "SBOM_SPDX",
+		1: "SBOM_CYCLONEDX",
+	}
+	SbomFormat_value = map[string]int32{
+		"SBOM_SPDX":      0,
+		"SBOM_CYCLONEDX": 1,
+	}
+)
+
+func (x SbomFormat) Enum() *SbomFormat {
+	p := new(SbomFormat)
+	*p = x
+	return p
+}
+
+func (x SbomFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SbomFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_proto_enumTypes[3].Descriptor()
+}
+
+func (SbomFormat) Type() protoreflect.EnumType {
+	return &file_config_proto_enumTypes[3]
+}
+
+func (x SbomFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SbomFormat.Descriptor instead.
+func (SbomFormat) EnumDescriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{3}
+}
+
+// NotificationFormat is the payload of the notifications of the sync runs
+type NotificationFormat int32
+
+const (
+	// JSON object with the summary of the run
+	NotificationFormat_NOTIFICATION_WEBHOOK NotificationFormat = 0
+	// Slack-compatible incoming webhook message, with the summary as text.
+	// Mattermost and Rocket.Chat accept it too
+	NotificationFormat_NOTIFICATION_SLACK NotificationFormat = 1
+)
+
+// Enum value maps for NotificationFormat.
+var (
+	NotificationFormat_name = map[int32]string{
+		0: "NOTIFICATION_WEBHOOK",
+		1: "NOTIFICATION_SLACK",
+	}
+	NotificationFormat_value = map[string]int32{
+		"NOTIFICATION_WEBHOOK": 0,
+		"NOTIFICATION_SLACK":   1,
+	}
+)
+
+func (x NotificationFormat) Enum() *NotificationFormat {
+	p := new(NotificationFormat)
+	*p = x
+	return p
+}
+
+func (x NotificationFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NotificationFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_proto_enumTypes[4].Descriptor()
+}
+
+func (NotificationFormat) Type() protoreflect.EnumType {
+	return &file_config_proto_enumTypes[4]
+}
+
+func (x NotificationFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NotificationFormat.Descriptor instead.
+func (NotificationFormat) EnumDescriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{4}
+}
+
+// ProvenancePolicy indicates what to do with the provenance file of a chart
+// once charts-syncer has repackaged it, which invalidates the upstream
+// signature
+type ProvenancePolicy int32
+
+const (
+	// Do not push any provenance file
+	ProvenancePolicy_PROVENANCE_STRIP ProvenancePolicy = 0
+	// Push the upstream provenance file as it is and annotate the chart to
+	// note that the signature no longer matches
+	ProvenancePolicy_PROVENANCE_KEEP ProvenancePolicy = 1
+	// Sign the repackaged chart with the configured signing key
+	ProvenancePolicy_PROVENANCE_REGENERATE ProvenancePolicy = 2
+)
+
+// Enum value maps for ProvenancePolicy.
+var (
+	ProvenancePolicy_name = map[int32]string{
+		0: "PROVENANCE_STRIP",
+		1: "PROVENANCE_KEEP",
+		2: "PROVENANCE_REGENERATE",
+	}
+	ProvenancePolicy_value = map[string]int32{
+		"PROVENANCE_STRIP":      0,
+		"PROVENANCE_KEEP":       1,
+		"PROVENANCE_REGENERATE": 2,
+	}
+)
+
+func (x ProvenancePolicy) Enum() *ProvenancePolicy {
+	p := new(ProvenancePolicy)
+	*p = x
+	return p
+}
+
+func (x ProvenancePolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProvenancePolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_config_proto_enumTypes[5].Descriptor()
+}
+
+func (ProvenancePolicy) Type() protoreflect.EnumType {
+	return &file_config_proto_enumTypes[5]
+}
+
+func (x ProvenancePolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProvenancePolicy.Descriptor instead.
+func (ProvenancePolicy) EnumDescriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{5}
+}
+
 // Config file structure
 type Config struct {
 	state         protoimpl.MessageState
@@ -91,6 +382,86 @@ type Config struct {
 	// Opposite of charts property. It indicates the list of charts to skip during sync
 	SkipCharts              []string `protobuf:"bytes,5,rep,name=skip_charts,json=skipCharts,proto3" json:"skip_charts,omitempty"`
 	RelocateContainerImages bool     `protobuf:"varint,4,opt,name=relocate_container_images,json=relocateContainerImages,proto3" json:"relocate_container_images,omitempty"`
+	// How to proceed when the same chart version is provided with different contents
+	ConflictStrategy ConflictStrategy `protobuf:"varint,6,opt,name=conflict_strategy,json=conflictStrategy,proto3,enum=api.ConflictStrategy" json:"conflict_strategy,omitempty"`
+	// How to proceed when a repackaged chart does not pass the Helm linter
+	LintPolicy LintPolicy `protobuf:"varint,7,opt,name=lint_policy,json=lintPolicy,proto3,enum=api.LintPolicy" json:"lint_policy,omitempty"`
+	// What to do with the provenance files of the charts that are repackaged
+	ProvenancePolicy ProvenancePolicy `protobuf:"varint,8,opt,name=provenance_policy,json=provenancePolicy,proto3,enum=api.ProvenancePolicy" json:"provenance_policy,omitempty"`
+	// Key used to sign the repackaged charts. Required by the REGENERATE
+	// provenance policy
+	SigningKey *SigningKey `protobuf:"bytes,9,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`
+	// Changes made to the charts while they are repackaged, applied in order
+	Transformations []*Transformation `protobuf:"bytes,10,rep,name=transformations,proto3" json:"transformations,omitempty"`
+	// Suffix appended to the version of the charts pushed to the target, as
+	// pre-release ("-mirror") or build metadata ("+mirror.1")
+	VersionSuffix string `protobuf:"bytes,11,opt,name=version_suffix,json=versionSuffix,proto3" json:"version_suffix,omitempty"`
+	// Renames the charts pushed to the target
+	Rename *Rename `protobuf:"bytes,12,opt,name=rename,proto3" json:"rename,omitempty"`
+	// SLSA provenance attestations pushed along with the repackaged charts
+	Attestation *Attestation `protobuf:"bytes,13,opt,name=attestation,proto3" json:"attestation,omitempty"`
+	// Additional .helmignore rules applied when repackaging the charts, on
+	// top of the .helmignore file of each chart
+	Ignore []string `protobuf:"bytes,14,rep,name=ignore,proto3" json:"ignore,omitempty"`
+	// Store recording the synced charts, used to skip them in later runs
+	// and to detect upstream changes
+	State *State `protobuf:"bytes,15,opt,name=state,proto3" json:"state,omitempty"`
+	// Flux source manifest describing the target repository
+	Flux *Flux `protobuf:"bytes,16,opt,name=flux,proto3" json:"flux,omitempty"`
+	// Argo CD repository declaration describing the target repository
+	Argocd *ArgoCD `protobuf:"bytes,17,opt,name=argocd,proto3" json:"argocd,omitempty"`
+	// External service asked to allow every repackaged chart before it is
+	// pushed to the target
+	VerificationWebhook *VerificationWebhook `protobuf:"bytes,18,opt,name=verification_webhook,json=verificationWebhook,proto3" json:"verification_webhook,omitempty"`
+	// Software bills of materials pushed along with the synced charts
+	Sbom *Sbom `protobuf:"bytes,19,opt,name=sbom,proto3" json:"sbom,omitempty"`
+	// Only write the index.yaml file of the LOCAL target, with entries
+	// pointing at the charts in the source repository. No chart is copied
+	IndexOnly bool `protobuf:"varint,20,opt,name=index_only,json=indexOnly,proto3" json:"index_only,omitempty"`
+	// Anonymous usage reports sent after every run. Disabled by default
+	Telemetry *Telemetry `protobuf:"bytes,21,opt,name=telemetry,proto3" json:"telemetry,omitempty"`
+	// Number of charts synced concurrently. Charts are synced after their
+	// dependencies. Defaults to 1
+	Workers uint32 `protobuf:"varint,22,opt,name=workers,proto3" json:"workers,omitempty"`
+	// Retries of the requests to the repositories and registries failing with
+	// transient errors. Requests are not retried by default
+	Retry *RetryPolicy `protobuf:"bytes,23,opt,name=retry,proto3" json:"retry,omitempty"`
+	// Compare the digest of the charts already in the target, or recorded in
+	// the state store, with the source one, and sync the charts re-published
+	// upstream with different contents again
+	CompareDigest bool `protobuf:"varint,24,opt,name=compare_digest,json=compareDigest,proto3" json:"compare_digest,omitempty"`
+	// Delete the charts already in the target before pushing them again, for
+	// the repositories refusing to overwrite a chart version. Requires
+	// compare_digest
+	ForceOverwrite bool `protobuf:"varint,25,opt,name=force_overwrite,json=forceOverwrite,proto3" json:"force_overwrite,omitempty"`
+	// Filters of the versions synced, by chart name. They are usually set with
+	// the `name: constraint` entries of charts
+	ChartFilters map[string]*ChartFilter `protobuf:"bytes,26,rep,name=chart_filters,json=chartFilters,proto3" json:"chart_filters,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Regular expressions matching the whole name of the charts discovered in
+	// the source that are synced, in addition to the charts property
+	ChartsRegex []string `protobuf:"bytes,27,rep,name=charts_regex,json=chartsRegex,proto3" json:"charts_regex,omitempty"`
+	// Glob patterns of the names of the charts to skip, e.g. "*-operator"
+	ExcludeCharts []string `protobuf:"bytes,28,rep,name=exclude_charts,json=excludeCharts,proto3" json:"exclude_charts,omitempty"`
+	// Regular expressions matching the whole name of the charts to skip
+	ExcludeChartsRegex []string `protobuf:"bytes,29,rep,name=exclude_charts_regex,json=excludeChartsRegex,proto3" json:"exclude_charts_regex,omitempty"`
+	// Skip the pre-release versions of every chart, e.g. 1.2.3-beta.1
+	SkipPrereleases bool `protobuf:"varint,30,opt,name=skip_prereleases,json=skipPrereleases,proto3" json:"skip_prereleases,omitempty"`
+	// Only sync the N most recent versions of every chart. The
+	// --latest-version-only flag is equivalent to 1
+	LatestVersions uint32 `protobuf:"varint,31,opt,name=latest_versions,json=latestVersions,proto3" json:"latest_versions,omitempty"`
+	// Targets every chart is synced to in the same run, instead of a single
+	// target. The source charts are only downloaded once
+	Targets []*Target `protobuf:"bytes,32,rep,name=targets,proto3" json:"targets,omitempty"`
+	// Source and target pairs synced in the same run, one after the other.
+	// Each entry is configured like the whole file and inherits the other
+	// properties of the file, so they apply to every entry
+	Syncs []*Config `protobuf:"bytes,33,rep,name=syncs,proto3" json:"syncs,omitempty"`
+	// Cron expression of the runs of `sync --watch`, in the local time zone,
+	// e.g. "*/15 * * * *". The --interval flag takes precedence
+	Schedule string `protobuf:"bytes,34,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	// Endpoints notified when a sync run completes, e.g. to post the failed
+	// runs to a Slack channel
+	Notifications []*Notification `protobuf:"bytes,35,rep,name=notifications,proto3" json:"notifications,omitempty"`
 }
 
 func (x *Config) Reset() {
@@ -160,294 +531,248 @@ func (x *Config) GetRelocateContainerImages() bool {
 	return false
 }
 
-// SourceRepo contains the required information of the source chart repository
-type Source struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	// Types that are assignable to Spec:
-	//	*Source_Repo
-	//	*Source_IntermediateBundlesPath
-	Spec isSource_Spec `protobuf_oneof:"spec"`
-	// Ignored if the repo is an intermediate bundle since the images are inside the bundle
-	Containers *Containers `protobuf:"bytes,3,opt,name=containers,proto3" json:"containers,omitempty"`
+func (x *Config) GetConflictStrategy() ConflictStrategy {
+	if x != nil {
+		return x.ConflictStrategy
+	}
+	return ConflictStrategy_CONFLICT_FIRST_WINS
 }
 
-func (x *Source) Reset() {
-	*x = Source{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *Config) GetLintPolicy() LintPolicy {
+	if x != nil {
+		return x.LintPolicy
 	}
+	return LintPolicy_LINT_WARN
 }
 
-func (x *Source) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Config) GetProvenancePolicy() ProvenancePolicy {
+	if x != nil {
+		return x.ProvenancePolicy
+	}
+	return ProvenancePolicy_PROVENANCE_STRIP
 }
 
-func (*Source) ProtoMessage() {}
+func (x *Config) GetSigningKey() *SigningKey {
+	if x != nil {
+		return x.SigningKey
+	}
+	return nil
+}
 
-func (x *Source) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *Config) GetTransformations() []*Transformation {
+	if x != nil {
+		return x.Transformations
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use Source.ProtoReflect.Descriptor instead.
-func (*Source) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{1}
+func (x *Config) GetVersionSuffix() string {
+	if x != nil {
+		return x.VersionSuffix
+	}
+	return ""
 }
 
-func (m *Source) GetSpec() isSource_Spec {
-	if m != nil {
-		return m.Spec
+func (x *Config) GetRename() *Rename {
+	if x != nil {
+		return x.Rename
 	}
 	return nil
 }
 
-func (x *Source) GetRepo() *Repo {
-	if x, ok := x.GetSpec().(*Source_Repo); ok {
-		return x.Repo
+func (x *Config) GetAttestation() *Attestation {
+	if x != nil {
+		return x.Attestation
 	}
 	return nil
 }
 
-func (x *Source) GetIntermediateBundlesPath() string {
-	if x, ok := x.GetSpec().(*Source_IntermediateBundlesPath); ok {
-		return x.IntermediateBundlesPath
+func (x *Config) GetIgnore() []string {
+	if x != nil {
+		return x.Ignore
 	}
-	return ""
+	return nil
 }
 
-func (x *Source) GetContainers() *Containers {
+func (x *Config) GetState() *State {
 	if x != nil {
-		return x.Containers
+		return x.State
 	}
 	return nil
 }
 
-type isSource_Spec interface {
-	isSource_Spec()
+func (x *Config) GetFlux() *Flux {
+	if x != nil {
+		return x.Flux
+	}
+	return nil
 }
 
-type Source_Repo struct {
-	Repo *Repo `protobuf:"bytes,1,opt,name=repo,proto3,oneof"`
+func (x *Config) GetArgocd() *ArgoCD {
+	if x != nil {
+		return x.Argocd
+	}
+	return nil
 }
 
-type Source_IntermediateBundlesPath struct {
-	IntermediateBundlesPath string `protobuf:"bytes,2,opt,name=intermediate_bundles_path,json=intermediateBundlesPath,proto3,oneof"`
+func (x *Config) GetVerificationWebhook() *VerificationWebhook {
+	if x != nil {
+		return x.VerificationWebhook
+	}
+	return nil
 }
 
-func (*Source_Repo) isSource_Spec() {}
-
-func (*Source_IntermediateBundlesPath) isSource_Spec() {}
-
-type Containers struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	Auth *Containers_ContainerAuth `protobuf:"bytes,1,opt,name=auth,proto3" json:"auth,omitempty"`
+func (x *Config) GetSbom() *Sbom {
+	if x != nil {
+		return x.Sbom
+	}
+	return nil
 }
 
-func (x *Containers) Reset() {
-	*x = Containers{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[2]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *Config) GetIndexOnly() bool {
+	if x != nil {
+		return x.IndexOnly
 	}
+	return false
 }
 
-func (x *Containers) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Config) GetTelemetry() *Telemetry {
+	if x != nil {
+		return x.Telemetry
+	}
+	return nil
 }
 
-func (*Containers) ProtoMessage() {}
-
-func (x *Containers) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[2]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *Config) GetWorkers() uint32 {
+	if x != nil {
+		return x.Workers
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use Containers.ProtoReflect.Descriptor instead.
-func (*Containers) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{2}
+func (x *Config) GetRetry() *RetryPolicy {
+	if x != nil {
+		return x.Retry
+	}
+	return nil
 }
 
-func (x *Containers) GetAuth() *Containers_ContainerAuth {
+func (x *Config) GetCompareDigest() bool {
 	if x != nil {
-		return x.Auth
+		return x.CompareDigest
 	}
-	return nil
+	return false
 }
 
-// TargetRepo contains the required information of the target chart repository
-type Target struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	// Types that are assignable to Spec:
-	//	*Target_Repo
-	//	*Target_IntermediateBundlesPath
-	Spec                isTarget_Spec `protobuf_oneof:"spec"`
-	ContainerRegistry   string        `protobuf:"bytes,2,opt,name=container_registry,json=containerRegistry,proto3" json:"container_registry,omitempty"`
-	ContainerRepository string        `protobuf:"bytes,3,opt,name=container_repository,json=containerRepository,proto3" json:"container_repository,omitempty"`
-	RepoName            string        `protobuf:"bytes,4,opt,name=repo_name,json=repoName,proto3" json:"repo_name,omitempty"`
-	Containers          *Containers   `protobuf:"bytes,6,opt,name=containers,proto3" json:"containers,omitempty"`
-}
-
-func (x *Target) Reset() {
-	*x = Target{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[3]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *Config) GetForceOverwrite() bool {
+	if x != nil {
+		return x.ForceOverwrite
 	}
+	return false
 }
 
-func (x *Target) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*Target) ProtoMessage() {}
-
-func (x *Target) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[3]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *Config) GetChartFilters() map[string]*ChartFilter {
+	if x != nil {
+		return x.ChartFilters
 	}
-	return mi.MessageOf(x)
-}
-
-// Deprecated: Use Target.ProtoReflect.Descriptor instead.
-func (*Target) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{3}
+	return nil
 }
 
-func (m *Target) GetSpec() isTarget_Spec {
-	if m != nil {
-		return m.Spec
+func (x *Config) GetChartsRegex() []string {
+	if x != nil {
+		return x.ChartsRegex
 	}
 	return nil
 }
 
-func (x *Target) GetRepo() *Repo {
-	if x, ok := x.GetSpec().(*Target_Repo); ok {
-		return x.Repo
+func (x *Config) GetExcludeCharts() []string {
+	if x != nil {
+		return x.ExcludeCharts
 	}
 	return nil
 }
 
-func (x *Target) GetIntermediateBundlesPath() string {
-	if x, ok := x.GetSpec().(*Target_IntermediateBundlesPath); ok {
-		return x.IntermediateBundlesPath
+func (x *Config) GetExcludeChartsRegex() []string {
+	if x != nil {
+		return x.ExcludeChartsRegex
 	}
-	return ""
+	return nil
 }
 
-func (x *Target) GetContainerRegistry() string {
+func (x *Config) GetSkipPrereleases() bool {
 	if x != nil {
-		return x.ContainerRegistry
+		return x.SkipPrereleases
 	}
-	return ""
+	return false
 }
 
-func (x *Target) GetContainerRepository() string {
+func (x *Config) GetLatestVersions() uint32 {
 	if x != nil {
-		return x.ContainerRepository
+		return x.LatestVersions
 	}
-	return ""
+	return 0
 }
 
-func (x *Target) GetRepoName() string {
+func (x *Config) GetTargets() []*Target {
 	if x != nil {
-		return x.RepoName
+		return x.Targets
 	}
-	return ""
+	return nil
 }
 
-func (x *Target) GetContainers() *Containers {
+func (x *Config) GetSyncs() []*Config {
 	if x != nil {
-		return x.Containers
+		return x.Syncs
 	}
 	return nil
 }
 
-type isTarget_Spec interface {
-	isTarget_Spec()
-}
-
-type Target_Repo struct {
-	Repo *Repo `protobuf:"bytes,1,opt,name=repo,proto3,oneof"`
+func (x *Config) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
 }
 
-type Target_IntermediateBundlesPath struct {
-	IntermediateBundlesPath string `protobuf:"bytes,5,opt,name=intermediate_bundles_path,json=intermediateBundlesPath,proto3,oneof"`
+func (x *Config) GetNotifications() []*Notification {
+	if x != nil {
+		return x.Notifications
+	}
+	return nil
 }
 
-func (*Target_Repo) isTarget_Spec() {}
-
-func (*Target_IntermediateBundlesPath) isTarget_Spec() {}
-
-// Generic repo representation
-type Repo struct {
+// ChartFilter selects the versions of a chart that are synced
+type ChartFilter struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Url  string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
-	Kind Kind   `protobuf:"varint,2,opt,name=kind,proto3,enum=api.Kind" json:"kind,omitempty"`
-	Auth *Auth  `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
-	// The path where the repo stores charts. Useful for LOCAL kind only
-	Path string `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
-	// The OCI reference where the index of charts is located
-	// Example: my.oci.domain/index:latest
-	ChartsIndex string `protobuf:"bytes,5,opt,name=charts_index,json=chartsIndex,proto3" json:"charts_index,omitempty"`
-	// Whether to use a charts index to find charts
-	//
-	// Deprecated: Do not use.
-	UseChartsIndex     bool `protobuf:"varint,6,opt,name=use_charts_index,json=useChartsIndex,proto3" json:"use_charts_index,omitempty"`
-	DisableChartsIndex bool `protobuf:"varint,7,opt,name=disable_charts_index,json=disableChartsIndex,proto3" json:"disable_charts_index,omitempty"`
+	// Semver constraint the versions must match, e.g. ">=13.0.0 <15"
+	Versions string `protobuf:"bytes,1,opt,name=versions,proto3" json:"versions,omitempty"`
+	// Skip the pre-release versions of the chart, e.g. 1.2.3-beta.1
+	SkipPrereleases bool `protobuf:"varint,2,opt,name=skip_prereleases,json=skipPrereleases,proto3" json:"skip_prereleases,omitempty"`
+	// Only sync the N most recent versions of the chart, overriding the global
+	// latest_versions
+	LatestVersions uint32 `protobuf:"varint,3,opt,name=latest_versions,json=latestVersions,proto3" json:"latest_versions,omitempty"`
 }
 
-func (x *Repo) Reset() {
-	*x = Repo{}
+func (x *ChartFilter) Reset() {
+	*x = ChartFilter{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[4]
+		mi := &file_config_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Repo) String() string {
+func (x *ChartFilter) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Repo) ProtoMessage() {}
+func (*ChartFilter) ProtoMessage() {}
 
-func (x *Repo) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[4]
+func (x *ChartFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -458,88 +783,145 @@ func (x *Repo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Repo.ProtoReflect.Descriptor instead.
-func (*Repo) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use ChartFilter.ProtoReflect.Descriptor instead.
+func (*ChartFilter) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *Repo) GetUrl() string {
+func (x *ChartFilter) GetVersions() string {
 	if x != nil {
-		return x.Url
+		return x.Versions
 	}
 	return ""
 }
 
-func (x *Repo) GetKind() Kind {
+func (x *ChartFilter) GetSkipPrereleases() bool {
 	if x != nil {
-		return x.Kind
+		return x.SkipPrereleases
 	}
-	return Kind_UNKNOWN
+	return false
 }
 
-func (x *Repo) GetAuth() *Auth {
+func (x *ChartFilter) GetLatestVersions() uint32 {
 	if x != nil {
-		return x.Auth
+		return x.LatestVersions
 	}
-	return nil
+	return 0
 }
 
-func (x *Repo) GetPath() string {
+// RetryPolicy configures the retries of the HTTP requests failing with
+// transient errors, with exponential backoff. Throttled requests are retried
+// according to their Retry-After header anyway.
+type RetryPolicy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of attempts of a request, including the first one
+	MaxAttempts uint32 `protobuf:"varint,1,opt,name=max_attempts,json=maxAttempts,proto3" json:"max_attempts,omitempty"`
+	// Time waited before the first retry, e.g. 1s. It is doubled on every
+	// retry. Defaults to 1s
+	InitialBackoff string `protobuf:"bytes,2,opt,name=initial_backoff,json=initialBackoff,proto3" json:"initial_backoff,omitempty"`
+	// Maximum time waited before a retry, e.g. 1m. Defaults to 30s
+	MaxBackoff string `protobuf:"bytes,3,opt,name=max_backoff,json=maxBackoff,proto3" json:"max_backoff,omitempty"`
+	// HTTP status codes retried. Defaults to 500, 502, 503 and 504. Requests
+	// failing to reach the server are retried if they only read data
+	RetryOn []uint32 `protobuf:"varint,4,rep,packed,name=retry_on,json=retryOn,proto3" json:"retry_on,omitempty"`
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RetryPolicy) GetMaxAttempts() uint32 {
 	if x != nil {
-		return x.Path
+		return x.MaxAttempts
 	}
-	return ""
+	return 0
 }
 
-func (x *Repo) GetChartsIndex() string {
+func (x *RetryPolicy) GetInitialBackoff() string {
 	if x != nil {
-		return x.ChartsIndex
+		return x.InitialBackoff
 	}
 	return ""
 }
 
-// Deprecated: Do not use.
-func (x *Repo) GetUseChartsIndex() bool {
+func (x *RetryPolicy) GetMaxBackoff() string {
 	if x != nil {
-		return x.UseChartsIndex
+		return x.MaxBackoff
 	}
-	return false
+	return ""
 }
 
-func (x *Repo) GetDisableChartsIndex() bool {
+func (x *RetryPolicy) GetRetryOn() []uint32 {
 	if x != nil {
-		return x.DisableChartsIndex
+		return x.RetryOn
 	}
-	return false
+	return nil
 }
 
-// Auth contains credentials to login to a chart repository
-type Auth struct {
+// Telemetry configures the anonymous usage reports. They only contain the
+// kinds of the repositories, the number of charts, the classes of the errors
+// and the duration of the run: no URL, chart name or credential is sent.
+type Telemetry struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	// Send the reports. They are never sent unless explicitly enabled
+	Enabled bool `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// URL receiving the reports as POST requests
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// Time to wait for the endpoint to answer, e.g. 5s. Defaults to 10s
+	Timeout string `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
 }
 
-func (x *Auth) Reset() {
-	*x = Auth{}
+func (x *Telemetry) Reset() {
+	*x = Telemetry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[5]
+		mi := &file_config_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Auth) String() string {
+func (x *Telemetry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Auth) ProtoMessage() {}
+func (*Telemetry) ProtoMessage() {}
 
-func (x *Auth) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[5]
+func (x *Telemetry) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -550,54 +932,69 @@ func (x *Auth) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Auth.ProtoReflect.Descriptor instead.
-func (*Auth) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use Telemetry.ProtoReflect.Descriptor instead.
+func (*Telemetry) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *Auth) GetUsername() string {
+func (x *Telemetry) GetEnabled() bool {
 	if x != nil {
-		return x.Username
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Telemetry) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
 	}
 	return ""
 }
 
-func (x *Auth) GetPassword() string {
+func (x *Telemetry) GetTimeout() string {
 	if x != nil {
-		return x.Password
+		return x.Timeout
 	}
 	return ""
 }
 
-// ContainerAuth defines the authentication parameters required to access the source/target
-// OCI registries during container image relocation
-type Containers_ContainerAuth struct {
+// Notification configures an endpoint notified when a sync run completes,
+// with the number of synced and failed charts and the errors of the run.
+// Nothing is notified in dry-run mode.
+type Notification struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
-	Registry string `protobuf:"bytes,3,opt,name=registry,proto3" json:"registry,omitempty"`
+	// URL receiving the notifications as POST requests
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Payload of the notifications. Defaults to NOTIFICATION_WEBHOOK
+	Format NotificationFormat `protobuf:"varint,2,opt,name=format,proto3,enum=api.NotificationFormat" json:"format,omitempty"`
+	// Additional headers of the requests, e.g. a bearer token
+	Headers map[string]string `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Only notify the runs that failed or were interrupted
+	OnlyFailures bool `protobuf:"varint,4,opt,name=only_failures,json=onlyFailures,proto3" json:"only_failures,omitempty"`
+	// Time to wait for the endpoint to answer, e.g. 5s. Defaults to 10s
+	Timeout string `protobuf:"bytes,5,opt,name=timeout,proto3" json:"timeout,omitempty"`
 }
 
-func (x *Containers_ContainerAuth) Reset() {
-	*x = Containers_ContainerAuth{}
+func (x *Notification) Reset() {
+	*x = Notification{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_config_proto_msgTypes[6]
+		mi := &file_config_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Containers_ContainerAuth) String() string {
+func (x *Notification) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Containers_ContainerAuth) ProtoMessage() {}
+func (*Notification) ProtoMessage() {}
 
-func (x *Containers_ContainerAuth) ProtoReflect() protoreflect.Message {
-	mi := &file_config_proto_msgTypes[6]
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -608,70 +1005,3805 @@ func (x *Containers_ContainerAuth) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Containers_ContainerAuth.ProtoReflect.Descriptor instead.
-func (*Containers_ContainerAuth) Descriptor() ([]byte, []int) {
-	return file_config_proto_rawDescGZIP(), []int{2, 0}
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *Containers_ContainerAuth) GetUsername() string {
+func (x *Notification) GetUrl() string {
 	if x != nil {
-		return x.Username
+		return x.Url
 	}
 	return ""
 }
 
-func (x *Containers_ContainerAuth) GetPassword() string {
+func (x *Notification) GetFormat() NotificationFormat {
 	if x != nil {
-		return x.Password
+		return x.Format
 	}
-	return ""
+	return NotificationFormat_NOTIFICATION_WEBHOOK
 }
 
-func (x *Containers_ContainerAuth) GetRegistry() string {
+func (x *Notification) GetHeaders() map[string]string {
 	if x != nil {
-		return x.Registry
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *Notification) GetOnlyFailures() bool {
+	if x != nil {
+		return x.OnlyFailures
+	}
+	return false
+}
+
+func (x *Notification) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
 	}
 	return ""
 }
 
-var File_config_proto protoreflect.FileDescriptor
+// VerificationWebhook configures an HTTP endpoint that allows or denies the
+// push of every repackaged chart. It receives a POST request with the chart
+// metadata, digest and container images, and answers with a JSON object like
+// {"allowed": false, "reason": "CVE-2021-44228"}.
+type VerificationWebhook struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_config_proto_rawDesc = []byte{
-	0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03,
-	0x61, 0x70, 0x69, 0x22, 0xc7, 0x01, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x23,
-	0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b,
-	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6f, 0x75,
-	0x72, 0x63, 0x65, 0x12, 0x23, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
-	0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x68, 0x61, 0x72,
-	0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73,
-	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x18,
-	0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6b, 0x69, 0x70, 0x43, 0x68, 0x61, 0x72, 0x74,
-	0x73, 0x12, 0x3a, 0x0a, 0x19, 0x72, 0x65, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x6f,
-	0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x08, 0x52, 0x17, 0x72, 0x65, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x43, 0x6f,
-	0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x22, 0xa0, 0x01,
-	0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x04, 0x72, 0x65, 0x70, 0x6f,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x70,
-	0x6f, 0x48, 0x00, 0x52, 0x04, 0x72, 0x65, 0x70, 0x6f, 0x12, 0x3c, 0x0a, 0x19, 0x69, 0x6e, 0x74,
-	0x65, 0x72, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x5f, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
-	0x73, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x17,
-	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x42, 0x75, 0x6e, 0x64,
-	0x6c, 0x65, 0x73, 0x50, 0x61, 0x74, 0x68, 0x12, 0x2f, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61,
-	0x69, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70,
-	0x69, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x52, 0x0a, 0x63, 0x6f,
-	0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x42, 0x06, 0x0a, 0x04, 0x73, 0x70, 0x65, 0x63,
-	0x22, 0xa4, 0x01, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x12,
-	0x31, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
-	0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x2e, 0x43,
-	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75,
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// Basic authentication credentials of the webhook
+	Auth *Auth `protobuf:"bytes,2,opt,name=auth,proto3" json:"auth,omitempty"`
+	// Additional headers of the requests, e.g. a bearer token
+	Headers map[string]string `protobuf:"bytes,3,rep,name=headers,proto3" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Time to wait for the webhook to answer, e.g. 30s. Defaults to 1m
+	Timeout string `protobuf:"bytes,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Push the charts if the webhook cannot be reached or fails. By default
+	// the charts are not pushed
+	FailOpen bool `protobuf:"varint,5,opt,name=fail_open,json=failOpen,proto3" json:"fail_open,omitempty"`
+}
+
+func (x *VerificationWebhook) Reset() {
+	*x = VerificationWebhook{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerificationWebhook) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerificationWebhook) ProtoMessage() {}
+
+func (x *VerificationWebhook) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerificationWebhook.ProtoReflect.Descriptor instead.
+func (*VerificationWebhook) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VerificationWebhook) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *VerificationWebhook) GetAuth() *Auth {
+	if x != nil {
+		return x.Auth
+	}
+	return nil
+}
+
+func (x *VerificationWebhook) GetHeaders() map[string]string {
+	if x != nil {
+		return x.Headers
+	}
+	return nil
+}
+
+func (x *VerificationWebhook) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
+	}
+	return ""
+}
+
+func (x *VerificationWebhook) GetFailOpen() bool {
+	if x != nil {
+		return x.FailOpen
+	}
+	return false
+}
+
+// ArgoCD configures the Argo CD repository declaration written after a
+// successful sync, so the target repository can be consumed by Argo CD
+type ArgoCD struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// File the declaration is written to
+	Output string `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	// Name of the repository Secret, "charts-syncer" by default
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Namespace Argo CD runs in, "argocd" by default
+	Namespace string `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Declare the credentials of the target in a repo-creds Secret, shared by
+	// every repository under the target URL, rather than in the repository
+	// Secret
+	CredentialsTemplate bool `protobuf:"varint,4,opt,name=credentials_template,json=credentialsTemplate,proto3" json:"credentials_template,omitempty"`
+}
+
+func (x *ArgoCD) Reset() {
+	*x = ArgoCD{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArgoCD) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArgoCD) ProtoMessage() {}
+
+func (x *ArgoCD) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArgoCD.ProtoReflect.Descriptor instead.
+func (*ArgoCD) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ArgoCD) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *ArgoCD) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ArgoCD) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ArgoCD) GetCredentialsTemplate() bool {
+	if x != nil {
+		return x.CredentialsTemplate
+	}
+	return false
+}
+
+// Flux configures the Flux source manifest written after a successful sync,
+// so the target repository can be consumed by Flux
+type Flux struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// File the manifest is written to
+	Output string `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	// Name of the source, "charts-syncer" by default
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Namespace of the source, "flux-system" by default
+	Namespace string `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Interval at which Flux checks the repository, "10m" by default
+	Interval string `protobuf:"bytes,4,opt,name=interval,proto3" json:"interval,omitempty"`
+	// Name of the Secret with the credentials of the repository, referenced
+	// if the target requires authentication. "<name>-auth" by default.
+	SecretName string `protobuf:"bytes,5,opt,name=secret_name,json=secretName,proto3" json:"secret_name,omitempty"`
+}
+
+func (x *Flux) Reset() {
+	*x = Flux{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Flux) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Flux) ProtoMessage() {}
+
+func (x *Flux) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Flux.ProtoReflect.Descriptor instead.
+func (*Flux) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Flux) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *Flux) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Flux) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Flux) GetInterval() string {
+	if x != nil {
+		return x.Interval
+	}
+	return ""
+}
+
+func (x *Flux) GetSecretName() string {
+	if x != nil {
+		return x.SecretName
+	}
+	return ""
+}
+
+// State configures the store recording the synced charts
+type State struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Backend:
+	//	*State_File
+	//	*State_Kubernetes
+	//	*State_Object
+	Backend isState_Backend `protobuf_oneof:"backend"`
+}
+
+func (x *State) Reset() {
+	*x = State{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *State) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*State) ProtoMessage() {}
+
+func (x *State) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use State.ProtoReflect.Descriptor instead.
+func (*State) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{8}
+}
+
+func (m *State) GetBackend() isState_Backend {
+	if m != nil {
+		return m.Backend
+	}
+	return nil
+}
+
+func (x *State) GetFile() string {
+	if x, ok := x.GetBackend().(*State_File); ok {
+		return x.File
+	}
+	return ""
+}
+
+func (x *State) GetKubernetes() *KubernetesState {
+	if x, ok := x.GetBackend().(*State_Kubernetes); ok {
+		return x.Kubernetes
+	}
+	return nil
+}
+
+func (x *State) GetObject() *Repo {
+	if x, ok := x.GetBackend().(*State_Object); ok {
+		return x.Object
+	}
+	return nil
+}
+
+type isState_Backend interface {
+	isState_Backend()
+}
+
+type State_File struct {
+	// Path to the local state file, created if missing
+	File string `protobuf:"bytes,1,opt,name=file,proto3,oneof"`
+}
+
+type State_Kubernetes struct {
+	// ConfigMap storing the state in a Kubernetes cluster, shared by
+	// several syncers
+	Kubernetes *KubernetesState `protobuf:"bytes,2,opt,name=kubernetes,proto3,oneof"`
+}
+
+type State_Object struct {
+	// Object of an S3, GCS or AZBLOB bucket storing the state, with url
+	// like s3://bucket/path/state.json. The kind, auth and bucket options
+	// are the ones of a repository
+	Object *Repo `protobuf:"bytes,3,opt,name=object,proto3,oneof"`
+}
+
+func (*State_File) isState_Backend() {}
+
+func (*State_Kubernetes) isState_Backend() {}
+
+func (*State_Object) isState_Backend() {}
+
+// KubernetesState stores the state in a ConfigMap. A Lease with the same name
+// guards it, so only one syncer uses the state at a time
+type KubernetesState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of the ConfigMap and the Lease
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Namespace of the ConfigMap and the Lease. Defaults to the namespace of
+	// the kubeconfig context, or to the one of the pod in a cluster
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Path to the kubeconfig file. Defaults to $KUBECONFIG, ~/.kube/config or
+	// the in-cluster config, in that order
+	Kubeconfig string `protobuf:"bytes,3,opt,name=kubeconfig,proto3" json:"kubeconfig,omitempty"`
+	// How long to wait for the Lease held by another syncer, e.g. 10m.
+	// Defaults to 5m
+	LockTimeout string `protobuf:"bytes,4,opt,name=lock_timeout,json=lockTimeout,proto3" json:"lock_timeout,omitempty"`
+}
+
+func (x *KubernetesState) Reset() {
+	*x = KubernetesState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KubernetesState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KubernetesState) ProtoMessage() {}
+
+func (x *KubernetesState) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KubernetesState.ProtoReflect.Descriptor instead.
+func (*KubernetesState) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *KubernetesState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *KubernetesState) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *KubernetesState) GetKubeconfig() string {
+	if x != nil {
+		return x.Kubeconfig
+	}
+	return ""
+}
+
+func (x *KubernetesState) GetLockTimeout() string {
+	if x != nil {
+		return x.LockTimeout
+	}
+	return ""
+}
+
+// Attestation configures the SLSA provenance attestations of the repackaged
+// charts. They are in-toto statements describing the source chart, the
+// transformations applied and the sync run.
+type Attestation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Enabled bool `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// Identifier of the builder in the attestations. Defaults to the
+	// charts-syncer repository URL
+	BuilderId string `protobuf:"bytes,2,opt,name=builder_id,json=builderId,proto3" json:"builder_id,omitempty"`
+}
+
+func (x *Attestation) Reset() {
+	*x = Attestation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Attestation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attestation) ProtoMessage() {}
+
+func (x *Attestation) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attestation.ProtoReflect.Descriptor instead.
+func (*Attestation) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Attestation) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Attestation) GetBuilderId() string {
+	if x != nil {
+		return x.BuilderId
+	}
+	return ""
+}
+
+// Sbom configures the software bills of materials of the synced charts. They
+// describe the files and subcharts of every pushed chart and, optionally, the
+// container images it references, and are pushed along with it.
+type Sbom struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Enabled bool `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// List the container images referenced by the values of the charts, after
+	// they are relocated
+	IncludeImages bool `protobuf:"varint,2,opt,name=include_images,json=includeImages,proto3" json:"include_images,omitempty"`
+	// Submit the SBOMs to Dependency-Track before the charts are pushed
+	DependencyTrack *DependencyTrack `protobuf:"bytes,3,opt,name=dependency_track,json=dependencyTrack,proto3" json:"dependency_track,omitempty"`
+	Format          SbomFormat       `protobuf:"varint,4,opt,name=format,proto3,enum=api.SbomFormat" json:"format,omitempty"`
+}
+
+func (x *Sbom) Reset() {
+	*x = Sbom{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Sbom) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sbom) ProtoMessage() {}
+
+func (x *Sbom) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sbom.ProtoReflect.Descriptor instead.
+func (*Sbom) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Sbom) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *Sbom) GetIncludeImages() bool {
+	if x != nil {
+		return x.IncludeImages
+	}
+	return false
+}
+
+func (x *Sbom) GetDependencyTrack() *DependencyTrack {
+	if x != nil {
+		return x.DependencyTrack
+	}
+	return nil
+}
+
+func (x *Sbom) GetFormat() SbomFormat {
+	if x != nil {
+		return x.Format
+	}
+	return SbomFormat_SBOM_SPDX
+}
+
+// DependencyTrack configures the Dependency-Track server the SBOMs of the
+// charts are submitted to, and the vulnerabilities keeping them from being
+// pushed
+type DependencyTrack struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// URL of the Dependency-Track API server
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// API key of a team with the BOM_UPLOAD, PROJECT_CREATION_UPLOAD and
+	// VIEW_VULNERABILITY permissions
+	ApiKey string `protobuf:"bytes,2,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`
+	// Charts with a finding of this severity or higher are not pushed: LOW,
+	// MEDIUM, HIGH or CRITICAL. The findings are only logged if it is empty
+	SeverityThreshold string `protobuf:"bytes,3,opt,name=severity_threshold,json=severityThreshold,proto3" json:"severity_threshold,omitempty"`
+	// Time to wait for Dependency-Track to analyze an SBOM, e.g. 10m.
+	// Defaults to 5m
+	Timeout string `protobuf:"bytes,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Push the charts if Dependency-Track cannot be reached or fails. By
+	// default the charts are not pushed
+	FailOpen bool `protobuf:"varint,5,opt,name=fail_open,json=failOpen,proto3" json:"fail_open,omitempty"`
+}
+
+func (x *DependencyTrack) Reset() {
+	*x = DependencyTrack{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DependencyTrack) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DependencyTrack) ProtoMessage() {}
+
+func (x *DependencyTrack) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DependencyTrack.ProtoReflect.Descriptor instead.
+func (*DependencyTrack) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DependencyTrack) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *DependencyTrack) GetApiKey() string {
+	if x != nil {
+		return x.ApiKey
+	}
+	return ""
+}
+
+func (x *DependencyTrack) GetSeverityThreshold() string {
+	if x != nil {
+		return x.SeverityThreshold
+	}
+	return ""
+}
+
+func (x *DependencyTrack) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
+	}
+	return ""
+}
+
+func (x *DependencyTrack) GetFailOpen() bool {
+	if x != nil {
+		return x.FailOpen
+	}
+	return false
+}
+
+// Rename renames the charts pushed to the target. The dependencies, lock files
+// and package folders of the synced charts are updated accordingly.
+type Rename struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Prefix prepended to the names of the charts, e.g. internal-
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// New names of the charts, by upstream name. They take precedence over
+	// the prefix
+	Charts map[string]string `protobuf:"bytes,2,rep,name=charts,proto3" json:"charts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *Rename) Reset() {
+	*x = Rename{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Rename) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rename) ProtoMessage() {}
+
+func (x *Rename) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rename.ProtoReflect.Descriptor instead.
+func (*Rename) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Rename) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *Rename) GetCharts() map[string]string {
+	if x != nil {
+		return x.Charts
+	}
+	return nil
+}
+
+// SourceRepo contains the required information of the source chart repository
+type Source struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Spec:
+	//	*Source_Repo
+	//	*Source_IntermediateBundlesPath
+	Spec isSource_Spec `protobuf_oneof:"spec"`
+	// Ignored if the repo is an intermediate bundle since the images are inside the bundle
+	Containers *Containers `protobuf:"bytes,3,opt,name=containers,proto3" json:"containers,omitempty"`
+	// Other repositories providing charts along with repo, e.g. a vendor
+	// repository and an internal one. The chart versions provided by several
+	// of them with different contents are resolved with conflictStrategy
+	AdditionalRepos []*Repo `protobuf:"bytes,4,rep,name=additional_repos,json=additionalRepos,proto3" json:"additional_repos,omitempty"`
+	// Keys decrypting the intermediate bundles, if they are encrypted
+	BundleDecryption *BundleDecryption `protobuf:"bytes,5,opt,name=bundle_decryption,json=bundleDecryption,proto3" json:"bundle_decryption,omitempty"`
+	// Checks of the source charts before they are synced
+	Verify *SourceVerification `protobuf:"bytes,6,opt,name=verify,proto3" json:"verify,omitempty"`
+}
+
+func (x *Source) Reset() {
+	*x = Source{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Source) ProtoMessage() {}
+
+func (x *Source) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Source.ProtoReflect.Descriptor instead.
+func (*Source) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{14}
+}
+
+func (m *Source) GetSpec() isSource_Spec {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+func (x *Source) GetRepo() *Repo {
+	if x, ok := x.GetSpec().(*Source_Repo); ok {
+		return x.Repo
+	}
+	return nil
+}
+
+func (x *Source) GetIntermediateBundlesPath() string {
+	if x, ok := x.GetSpec().(*Source_IntermediateBundlesPath); ok {
+		return x.IntermediateBundlesPath
+	}
+	return ""
+}
+
+func (x *Source) GetContainers() *Containers {
+	if x != nil {
+		return x.Containers
+	}
+	return nil
+}
+
+func (x *Source) GetAdditionalRepos() []*Repo {
+	if x != nil {
+		return x.AdditionalRepos
+	}
+	return nil
+}
+
+func (x *Source) GetBundleDecryption() *BundleDecryption {
+	if x != nil {
+		return x.BundleDecryption
+	}
+	return nil
+}
+
+func (x *Source) GetVerify() *SourceVerification {
+	if x != nil {
+		return x.Verify
+	}
+	return nil
+}
+
+type isSource_Spec interface {
+	isSource_Spec()
+}
+
+type Source_Repo struct {
+	Repo *Repo `protobuf:"bytes,1,opt,name=repo,proto3,oneof"`
+}
+
+type Source_IntermediateBundlesPath struct {
+	IntermediateBundlesPath string `protobuf:"bytes,2,opt,name=intermediate_bundles_path,json=intermediateBundlesPath,proto3,oneof"`
+}
+
+func (*Source_Repo) isSource_Spec() {}
+
+func (*Source_IntermediateBundlesPath) isSource_Spec() {}
+
+// SourceVerification checks the integrity and authenticity of the charts
+// fetched from the source repository. Charts failing verification are not
+// pushed, nor the charts depending on them.
+type SourceVerification struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Require the digest of every fetched package to match the one of the
+	// source index or registry. Charts without digest fail verification
+	Digest bool `protobuf:"varint,1,opt,name=digest,proto3" json:"digest,omitempty"`
+	// Armored or binary GnuPG keyring verifying the provenance files of the
+	// source charts. Charts without provenance file fail verification
+	Keyring string `protobuf:"bytes,2,opt,name=keyring,proto3" json:"keyring,omitempty"`
+}
+
+func (x *SourceVerification) Reset() {
+	*x = SourceVerification{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SourceVerification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceVerification) ProtoMessage() {}
+
+func (x *SourceVerification) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceVerification.ProtoReflect.Descriptor instead.
+func (*SourceVerification) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SourceVerification) GetDigest() bool {
+	if x != nil {
+		return x.Digest
+	}
+	return false
+}
+
+func (x *SourceVerification) GetKeyring() string {
+	if x != nil {
+		return x.Keyring
+	}
+	return ""
+}
+
+type Containers struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Auth *Containers_ContainerAuth `protobuf:"bytes,1,opt,name=auth,proto3" json:"auth,omitempty"`
+}
+
+func (x *Containers) Reset() {
+	*x = Containers{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Containers) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Containers) ProtoMessage() {}
+
+func (x *Containers) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Containers.ProtoReflect.Descriptor instead.
+func (*Containers) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Containers) GetAuth() *Containers_ContainerAuth {
+	if x != nil {
+		return x.Auth
+	}
+	return nil
+}
+
+// TargetRepo contains the required information of the target chart repository
+type Target struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Spec:
+	//	*Target_Repo
+	//	*Target_IntermediateBundlesPath
+	Spec                isTarget_Spec `protobuf_oneof:"spec"`
+	ContainerRegistry   string        `protobuf:"bytes,2,opt,name=container_registry,json=containerRegistry,proto3" json:"container_registry,omitempty"`
+	ContainerRepository string        `protobuf:"bytes,3,opt,name=container_repository,json=containerRepository,proto3" json:"container_repository,omitempty"`
+	RepoName            string        `protobuf:"bytes,4,opt,name=repo_name,json=repoName,proto3" json:"repo_name,omitempty"`
+	Containers          *Containers   `protobuf:"bytes,6,opt,name=containers,proto3" json:"containers,omitempty"`
+	// Harbor project of a HARBOR or OCI target repository
+	Harbor *Harbor `protobuf:"bytes,7,opt,name=harbor,proto3" json:"harbor,omitempty"`
+	// Recipients the intermediate bundles are encrypted for
+	BundleEncryption *BundleEncryption `protobuf:"bytes,8,opt,name=bundle_encryption,json=bundleEncryption,proto3" json:"bundle_encryption,omitempty"`
+	// Size in bytes of the parts the intermediate bundles are split into, so
+	// they fit on removable media. Bundles are not split if unset
+	BundlePartSize int64 `protobuf:"varint,9,opt,name=bundle_part_size,json=bundlePartSize,proto3" json:"bundle_part_size,omitempty"`
+	// Key the charts pushed to the target are signed with. Repackaged charts
+	// get a new provenance file whatever the provenance policy
+	SigningKey *SigningKey `protobuf:"bytes,10,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`
+	// Signs the charts pushed to an OCI target with cosign
+	Cosign *Cosign `protobuf:"bytes,11,opt,name=cosign,proto3" json:"cosign,omitempty"`
+}
+
+func (x *Target) Reset() {
+	*x = Target{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Target) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Target) ProtoMessage() {}
+
+func (x *Target) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Target.ProtoReflect.Descriptor instead.
+func (*Target) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{17}
+}
+
+func (m *Target) GetSpec() isTarget_Spec {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+func (x *Target) GetRepo() *Repo {
+	if x, ok := x.GetSpec().(*Target_Repo); ok {
+		return x.Repo
+	}
+	return nil
+}
+
+func (x *Target) GetIntermediateBundlesPath() string {
+	if x, ok := x.GetSpec().(*Target_IntermediateBundlesPath); ok {
+		return x.IntermediateBundlesPath
+	}
+	return ""
+}
+
+func (x *Target) GetContainerRegistry() string {
+	if x != nil {
+		return x.ContainerRegistry
+	}
+	return ""
+}
+
+func (x *Target) GetContainerRepository() string {
+	if x != nil {
+		return x.ContainerRepository
+	}
+	return ""
+}
+
+func (x *Target) GetRepoName() string {
+	if x != nil {
+		return x.RepoName
+	}
+	return ""
+}
+
+func (x *Target) GetContainers() *Containers {
+	if x != nil {
+		return x.Containers
+	}
+	return nil
+}
+
+func (x *Target) GetHarbor() *Harbor {
+	if x != nil {
+		return x.Harbor
+	}
+	return nil
+}
+
+func (x *Target) GetBundleEncryption() *BundleEncryption {
+	if x != nil {
+		return x.BundleEncryption
+	}
+	return nil
+}
+
+func (x *Target) GetBundlePartSize() int64 {
+	if x != nil {
+		return x.BundlePartSize
+	}
+	return 0
+}
+
+func (x *Target) GetSigningKey() *SigningKey {
+	if x != nil {
+		return x.SigningKey
+	}
+	return nil
+}
+
+func (x *Target) GetCosign() *Cosign {
+	if x != nil {
+		return x.Cosign
+	}
+	return nil
+}
+
+type isTarget_Spec interface {
+	isTarget_Spec()
+}
+
+type Target_Repo struct {
+	Repo *Repo `protobuf:"bytes,1,opt,name=repo,proto3,oneof"`
+}
+
+type Target_IntermediateBundlesPath struct {
+	IntermediateBundlesPath string `protobuf:"bytes,5,opt,name=intermediate_bundles_path,json=intermediateBundlesPath,proto3,oneof"`
+}
+
+func (*Target_Repo) isTarget_Spec() {}
+
+func (*Target_IntermediateBundlesPath) isTarget_Spec() {}
+
+// Cosign signs the manifests of the charts pushed to an OCI registry like
+// cosign does, attaching the signatures with a sha256-<digest>.sig tag
+type Cosign struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path to a cosign private key, e.g. generated with cosign
+	// generate-key-pair, or to a PEM-encoded ECDSA private key. The charts are
+	// signed keyless with a Fulcio certificate if unset
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Path to a file with the password of the key. $COSIGN_PASSWORD is used
+	// if unset
+	PassphraseFile string `protobuf:"bytes,2,opt,name=passphrase_file,json=passphraseFile,proto3" json:"passphrase_file,omitempty"`
+	// Path to the OIDC identity token used to get the Fulcio certificate of a
+	// keyless signature, e.g. a projected service account token.
+	// $SIGSTORE_ID_TOKEN is used if unset
+	IdentityTokenFile string `protobuf:"bytes,3,opt,name=identity_token_file,json=identityTokenFile,proto3" json:"identity_token_file,omitempty"`
+	// URL of the Fulcio instance. Defaults to https://fulcio.sigstore.dev
+	FulcioUrl string `protobuf:"bytes,4,opt,name=fulcio_url,json=fulcioUrl,proto3" json:"fulcio_url,omitempty"`
+	// URL of the Rekor transparency log the signatures are uploaded to.
+	// Keyless signatures are uploaded to https://rekor.sigstore.dev by
+	// default, keyed ones are only uploaded if it is set
+	RekorUrl string `protobuf:"bytes,5,opt,name=rekor_url,json=rekorUrl,proto3" json:"rekor_url,omitempty"`
+}
+
+func (x *Cosign) Reset() {
+	*x = Cosign{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Cosign) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cosign) ProtoMessage() {}
+
+func (x *Cosign) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cosign.ProtoReflect.Descriptor instead.
+func (*Cosign) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Cosign) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Cosign) GetPassphraseFile() string {
+	if x != nil {
+		return x.PassphraseFile
+	}
+	return ""
+}
+
+func (x *Cosign) GetIdentityTokenFile() string {
+	if x != nil {
+		return x.IdentityTokenFile
+	}
+	return ""
+}
+
+func (x *Cosign) GetFulcioUrl() string {
+	if x != nil {
+		return x.FulcioUrl
+	}
+	return ""
+}
+
+func (x *Cosign) GetRekorUrl() string {
+	if x != nil {
+		return x.RekorUrl
+	}
+	return ""
+}
+
+// BundleEncryption encrypts the intermediate bundles with age or GnuPG. Only
+// one of them can be used.
+type BundleEncryption struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// age recipients, e.g. age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+	AgeRecipients []string `protobuf:"bytes,1,rep,name=age_recipients,json=ageRecipients,proto3" json:"age_recipients,omitempty"`
+	// Path to a GnuPG keyring with the public keys of the recipients. The
+	// bundles are encrypted for every key of the keyring
+	GpgKeyring string `protobuf:"bytes,2,opt,name=gpg_keyring,json=gpgKeyring,proto3" json:"gpg_keyring,omitempty"`
+}
+
+func (x *BundleEncryption) Reset() {
+	*x = BundleEncryption{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BundleEncryption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BundleEncryption) ProtoMessage() {}
+
+func (x *BundleEncryption) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BundleEncryption.ProtoReflect.Descriptor instead.
+func (*BundleEncryption) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BundleEncryption) GetAgeRecipients() []string {
+	if x != nil {
+		return x.AgeRecipients
+	}
+	return nil
+}
+
+func (x *BundleEncryption) GetGpgKeyring() string {
+	if x != nil {
+		return x.GpgKeyring
+	}
+	return ""
+}
+
+// BundleDecryption decrypts the intermediate bundles encrypted with age or
+// GnuPG
+type BundleDecryption struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path to a file with age identities, e.g. generated with age-keygen
+	AgeIdentities string `protobuf:"bytes,1,opt,name=age_identities,json=ageIdentities,proto3" json:"age_identities,omitempty"`
+	// Path to the GnuPG keyring with the secret keys
+	GpgKeyring string `protobuf:"bytes,2,opt,name=gpg_keyring,json=gpgKeyring,proto3" json:"gpg_keyring,omitempty"`
+	// Path to a file with the passphrase of the GnuPG keys, if they are
+	// encrypted
+	GpgPassphraseFile string `protobuf:"bytes,3,opt,name=gpg_passphrase_file,json=gpgPassphraseFile,proto3" json:"gpg_passphrase_file,omitempty"`
+}
+
+func (x *BundleDecryption) Reset() {
+	*x = BundleDecryption{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BundleDecryption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BundleDecryption) ProtoMessage() {}
+
+func (x *BundleDecryption) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BundleDecryption.ProtoReflect.Descriptor instead.
+func (*BundleDecryption) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *BundleDecryption) GetAgeIdentities() string {
+	if x != nil {
+		return x.AgeIdentities
+	}
+	return ""
+}
+
+func (x *BundleDecryption) GetGpgKeyring() string {
+	if x != nil {
+		return x.GpgKeyring
+	}
+	return ""
+}
+
+func (x *BundleDecryption) GetGpgPassphraseFile() string {
+	if x != nil {
+		return x.GpgPassphraseFile
+	}
+	return ""
+}
+
+// Harbor configures the Harbor project of the target repository through the
+// Harbor API. Robot accounts can be used as credentials, they need the
+// permissions to create and update projects and retention policies.
+type Harbor struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Create the project if it does not exist
+	CreateProject bool `protobuf:"varint,1,opt,name=create_project,json=createProject,proto3" json:"create_project,omitempty"`
+	// Project metadata, e.g. public: "true" or auto_scan: "true". It is set
+	// on every run.
+	Metadata map[string]string `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Retention rules of the project, an artifact is retained if it matches
+	// any of them. Only OCI artifacts are affected by retention policies.
+	Retention []*Harbor_RetentionRule `protobuf:"bytes,3,rep,name=retention,proto3" json:"retention,omitempty"`
+	// Cron schedule of the retention policy, e.g. "0 0 0 * * *". The policy
+	// has to be run manually if unset.
+	RetentionSchedule string `protobuf:"bytes,4,opt,name=retention_schedule,json=retentionSchedule,proto3" json:"retention_schedule,omitempty"`
+}
+
+func (x *Harbor) Reset() {
+	*x = Harbor{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Harbor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Harbor) ProtoMessage() {}
+
+func (x *Harbor) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Harbor.ProtoReflect.Descriptor instead.
+func (*Harbor) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Harbor) GetCreateProject() bool {
+	if x != nil {
+		return x.CreateProject
+	}
+	return false
+}
+
+func (x *Harbor) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Harbor) GetRetention() []*Harbor_RetentionRule {
+	if x != nil {
+		return x.Retention
+	}
+	return nil
+}
+
+func (x *Harbor) GetRetentionSchedule() string {
+	if x != nil {
+		return x.RetentionSchedule
+	}
+	return ""
+}
+
+// Generic repo representation
+type Repo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Url  string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Kind Kind   `protobuf:"varint,2,opt,name=kind,proto3,enum=api.Kind" json:"kind,omitempty"`
+	Auth *Auth  `protobuf:"bytes,3,opt,name=auth,proto3" json:"auth,omitempty"`
+	// The path where the repo stores charts. Useful for LOCAL kind only
+	Path string `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	// The OCI reference where the index of charts is located
+	// Example: my.oci.domain/index:latest
+	ChartsIndex string `protobuf:"bytes,5,opt,name=charts_index,json=chartsIndex,proto3" json:"charts_index,omitempty"`
+	// Whether to use a charts index to find charts
+	//
+	// Deprecated: Do not use.
+	UseChartsIndex     bool `protobuf:"varint,6,opt,name=use_charts_index,json=useChartsIndex,proto3" json:"use_charts_index,omitempty"`
+	DisableChartsIndex bool `protobuf:"varint,7,opt,name=disable_charts_index,json=disableChartsIndex,proto3" json:"disable_charts_index,omitempty"`
+	// Priority of the repository among the source repositories, used by the
+	// CONFLICT_PREFER_PRIORITY conflict strategy. Lower values are preferred
+	Priority int32 `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
+	// Size in bytes of the chunks used to upload chart packages. Useful for
+	// OCI kind only, for registries or proxies limiting the size of a request.
+	// Packages are uploaded in a single request if unset
+	UploadChunkSize int64 `protobuf:"varint,9,opt,name=upload_chunk_size,json=uploadChunkSize,proto3" json:"upload_chunk_size,omitempty"`
+	// Mirrors of the repository, tried in order when the index or a chart
+	// can not be fetched from url. Useful for HELM, CHARTMUSEUM and HARBOR
+	// source repositories only
+	Mirrors []string `protobuf:"bytes,10,rep,name=mirrors,proto3" json:"mirrors,omitempty"`
+	// Overwrite the chart versions that already exist in the repository.
+	// Useful for CHARTMUSEUM kind only, the server must allow overwrites
+	ForceUpload bool `protobuf:"varint,11,opt,name=force_upload,json=forceUpload,proto3" json:"force_upload,omitempty"`
+	// Maintain an index.yaml file with the charts of the repository, so it
+	// can be served by a static web server. Useful for LOCAL kind only. The
+	// chart URLs of the index are relative unless url is set
+	GenerateIndex bool `protobuf:"varint,12,opt,name=generate_index,json=generateIndex,proto3" json:"generate_index,omitempty"`
+	// Media types of the charts pushed to the repository, for registries or
+	// clients expecting legacy ones. Useful for OCI kind only
+	OciMediaTypes *OCIMediaTypes `protobuf:"bytes,13,opt,name=oci_media_types,json=ociMediaTypes,proto3" json:"oci_media_types,omitempty"`
+	// Options of the bucket. Useful for S3 kind only
+	S3 *S3Options `protobuf:"bytes,14,opt,name=s3,proto3" json:"s3,omitempty"`
+	// Options of the bucket. Useful for GCS kind only
+	Gcs *GCSOptions `protobuf:"bytes,15,opt,name=gcs,proto3" json:"gcs,omitempty"`
+	// Options of the container. Useful for AZBLOB kind only
+	Azblob *AZBlobOptions `protobuf:"bytes,16,opt,name=azblob,proto3" json:"azblob,omitempty"`
+	// Options of the branch. Useful for GIT kind only
+	Git *GitOptions `protobuf:"bytes,17,opt,name=git,proto3" json:"git,omitempty"`
+	// Limits the rate of the requests sent to the host of the repository.
+	// Useful for HELM, CHARTMUSEUM, HARBOR, ARTIFACTORY, NEXUS and OCI kinds
+	// only
+	RateLimit *RateLimit `protobuf:"bytes,18,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+}
+
+func (x *Repo) Reset() {
+	*x = Repo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Repo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Repo) ProtoMessage() {}
+
+func (x *Repo) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Repo.ProtoReflect.Descriptor instead.
+func (*Repo) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *Repo) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Repo) GetKind() Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Kind_UNKNOWN
+}
+
+func (x *Repo) GetAuth() *Auth {
+	if x != nil {
+		return x.Auth
+	}
+	return nil
+}
+
+func (x *Repo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Repo) GetChartsIndex() string {
+	if x != nil {
+		return x.ChartsIndex
+	}
+	return ""
+}
+
+// Deprecated: Do not use.
+func (x *Repo) GetUseChartsIndex() bool {
+	if x != nil {
+		return x.UseChartsIndex
+	}
+	return false
+}
+
+func (x *Repo) GetDisableChartsIndex() bool {
+	if x != nil {
+		return x.DisableChartsIndex
+	}
+	return false
+}
+
+func (x *Repo) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Repo) GetUploadChunkSize() int64 {
+	if x != nil {
+		return x.UploadChunkSize
+	}
+	return 0
+}
+
+func (x *Repo) GetMirrors() []string {
+	if x != nil {
+		return x.Mirrors
+	}
+	return nil
+}
+
+func (x *Repo) GetForceUpload() bool {
+	if x != nil {
+		return x.ForceUpload
+	}
+	return false
+}
+
+func (x *Repo) GetGenerateIndex() bool {
+	if x != nil {
+		return x.GenerateIndex
+	}
+	return false
+}
+
+func (x *Repo) GetOciMediaTypes() *OCIMediaTypes {
+	if x != nil {
+		return x.OciMediaTypes
+	}
+	return nil
+}
+
+func (x *Repo) GetS3() *S3Options {
+	if x != nil {
+		return x.S3
+	}
+	return nil
+}
+
+func (x *Repo) GetGcs() *GCSOptions {
+	if x != nil {
+		return x.Gcs
+	}
+	return nil
+}
+
+func (x *Repo) GetAzblob() *AZBlobOptions {
+	if x != nil {
+		return x.Azblob
+	}
+	return nil
+}
+
+func (x *Repo) GetGit() *GitOptions {
+	if x != nil {
+		return x.Git
+	}
+	return nil
+}
+
+func (x *Repo) GetRateLimit() *RateLimit {
+	if x != nil {
+		return x.RateLimit
+	}
+	return nil
+}
+
+// RateLimit limits the rate of the requests sent to a host, so registries
+// throttling aggressive clients do not fail big syncs
+type RateLimit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Average number of requests per second
+	RequestsPerSecond float64 `protobuf:"fixed64,1,opt,name=requests_per_second,json=requestsPerSecond,proto3" json:"requests_per_second,omitempty"`
+	// Maximum number of requests sent at once. Defaults to 1
+	Burst uint32 `protobuf:"varint,2,opt,name=burst,proto3" json:"burst,omitempty"`
+}
+
+func (x *RateLimit) Reset() {
+	*x = RateLimit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RateLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimit) ProtoMessage() {}
+
+func (x *RateLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimit.ProtoReflect.Descriptor instead.
+func (*RateLimit) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *RateLimit) GetRequestsPerSecond() float64 {
+	if x != nil {
+		return x.RequestsPerSecond
+	}
+	return 0
+}
+
+func (x *RateLimit) GetBurst() uint32 {
+	if x != nil {
+		return x.Burst
+	}
+	return 0
+}
+
+// S3Options configures the access to an S3 bucket. The URL of the repository is like
+// s3://bucket/prefix, and the username and password of its auth are the
+// access key ID and secret access key
+type S3Options struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Region of the bucket. Defaults to $AWS_REGION, $AWS_DEFAULT_REGION or
+	// us-east-1
+	Region string `protobuf:"bytes,1,opt,name=region,proto3" json:"region,omitempty"`
+	// Endpoint of S3-compatible services, like MinIO, reached with path-style
+	// requests, e.g. https://minio.example.com
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+}
+
+func (x *S3Options) Reset() {
+	*x = S3Options{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *S3Options) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*S3Options) ProtoMessage() {}
+
+func (x *S3Options) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use S3Options.ProtoReflect.Descriptor instead.
+func (*S3Options) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *S3Options) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *S3Options) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+// GCSOptions configures the access to a Google Cloud Storage bucket. The URL of
+// the repository is like gs://bucket/prefix, and the requests are authenticated
+// with the Application Default Credentials
+type GCSOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Endpoint of the storage service, e.g. an emulator like
+	// http://localhost:4443. Defaults to https://storage.googleapis.com
+	Endpoint string `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+}
+
+func (x *GCSOptions) Reset() {
+	*x = GCSOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GCSOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GCSOptions) ProtoMessage() {}
+
+func (x *GCSOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GCSOptions.ProtoReflect.Descriptor instead.
+func (*GCSOptions) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GCSOptions) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+// AZBlobOptions configures the access to an Azure Blob Storage container. The
+// URL of the repository is like azblob://container/prefix. Requests are
+// authenticated with the connection string if any, or the managed identity of
+// the workload otherwise
+type AZBlobOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Storage account of the container. Defaults to the one of the connection
+	// string or $AZURE_STORAGE_ACCOUNT
+	Account string `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	// Blob service endpoint, e.g. http://127.0.0.1:10000/devstoreaccount1 for
+	// Azurite. Defaults to the one of the connection string or
+	// https://<account>.blob.core.windows.net
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// Connection string with the account key or a SAS token. Defaults to
+	// $AZURE_STORAGE_CONNECTION_STRING
+	ConnectionString string `protobuf:"bytes,3,opt,name=connection_string,json=connectionString,proto3" json:"connection_string,omitempty"`
+	// Client ID of the user-assigned managed identity to use, if the workload
+	// has several of them
+	ClientId string `protobuf:"bytes,4,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+}
+
+func (x *AZBlobOptions) Reset() {
+	*x = AZBlobOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AZBlobOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AZBlobOptions) ProtoMessage() {}
+
+func (x *AZBlobOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AZBlobOptions.ProtoReflect.Descriptor instead.
+func (*AZBlobOptions) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AZBlobOptions) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *AZBlobOptions) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *AZBlobOptions) GetConnectionString() string {
+	if x != nil {
+		return x.ConnectionString
+	}
+	return ""
+}
+
+func (x *AZBlobOptions) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+type GitOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Branch holding the repository. Defaults to gh-pages. It is created if
+	// it does not exist
+	Branch string `protobuf:"bytes,1,opt,name=branch,proto3" json:"branch,omitempty"`
+	// Directory of the repository in the branch. Defaults to the root
+	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// URL the directory of the repository is served at, e.g.
+	// https://example.github.io/charts. The chart URLs of the index are
+	// relative unless it is set
+	PagesUrl string `protobuf:"bytes,3,opt,name=pages_url,json=pagesUrl,proto3" json:"pages_url,omitempty"`
+	// Author of the commits. Defaults to charts-syncer
+	AuthorName  string `protobuf:"bytes,4,opt,name=author_name,json=authorName,proto3" json:"author_name,omitempty"`
+	AuthorEmail string `protobuf:"bytes,5,opt,name=author_email,json=authorEmail,proto3" json:"author_email,omitempty"`
+}
+
+func (x *GitOptions) Reset() {
+	*x = GitOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GitOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GitOptions) ProtoMessage() {}
+
+func (x *GitOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[27]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GitOptions.ProtoReflect.Descriptor instead.
+func (*GitOptions) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GitOptions) GetBranch() string {
+	if x != nil {
+		return x.Branch
+	}
+	return ""
+}
+
+func (x *GitOptions) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *GitOptions) GetPagesUrl() string {
+	if x != nil {
+		return x.PagesUrl
+	}
+	return ""
+}
+
+func (x *GitOptions) GetAuthorName() string {
+	if x != nil {
+		return x.AuthorName
+	}
+	return ""
+}
+
+func (x *GitOptions) GetAuthorEmail() string {
+	if x != nil {
+		return x.AuthorEmail
+	}
+	return ""
+}
+
+// OCIMediaTypes configures the media types of the charts pushed to OCI
+// repositories
+type OCIMediaTypes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Media type of the manifest config. Defaults to
+	// application/vnd.cncf.helm.config.v1+json
+	Config string `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	// Media type of the chart package layer. Defaults to
+	// application/vnd.cncf.helm.chart.content.v1.tar+gzip. Helm versions
+	// prior to 3.7 also read application/tar+gzip
+	Content string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	// Push the chart package layer without the
+	// org.opencontainers.image.title annotation, like Helm does
+	OmitTitles bool `protobuf:"varint,3,opt,name=omit_titles,json=omitTitles,proto3" json:"omit_titles,omitempty"`
+}
+
+func (x *OCIMediaTypes) Reset() {
+	*x = OCIMediaTypes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[28]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OCIMediaTypes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OCIMediaTypes) ProtoMessage() {}
+
+func (x *OCIMediaTypes) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[28]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OCIMediaTypes.ProtoReflect.Descriptor instead.
+func (*OCIMediaTypes) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *OCIMediaTypes) GetConfig() string {
+	if x != nil {
+		return x.Config
+	}
+	return ""
+}
+
+func (x *OCIMediaTypes) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *OCIMediaTypes) GetOmitTitles() bool {
+	if x != nil {
+		return x.OmitTitles
+	}
+	return false
+}
+
+// SigningKey contains the information needed to sign a chart
+type SigningKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path to the GnuPG keyring with the secret key
+	Keyring string `protobuf:"bytes,1,opt,name=keyring,proto3" json:"keyring,omitempty"`
+	// Name of the key in the keyring, e.g. its email address
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Path to a file with the passphrase of the key, if it is encrypted
+	PassphraseFile string `protobuf:"bytes,3,opt,name=passphrase_file,json=passphraseFile,proto3" json:"passphrase_file,omitempty"`
+}
+
+func (x *SigningKey) Reset() {
+	*x = SigningKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[29]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SigningKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SigningKey) ProtoMessage() {}
+
+func (x *SigningKey) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[29]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SigningKey.ProtoReflect.Descriptor instead.
+func (*SigningKey) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SigningKey) GetKeyring() string {
+	if x != nil {
+		return x.Keyring
+	}
+	return ""
+}
+
+func (x *SigningKey) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SigningKey) GetPassphraseFile() string {
+	if x != nil {
+		return x.PassphraseFile
+	}
+	return ""
+}
+
+// Transformation contains the changes made to the selected charts while they
+// are repackaged
+type Transformation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Names of the charts to transform. Every chart is transformed if empty
+	Charts []string `protobuf:"bytes,1,rep,name=charts,proto3" json:"charts,omitempty"`
+	// Patches to the values.yaml file of the charts, applied in order
+	Values []*ValuesPatch `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	// Annotations added to the Chart.yaml file of the charts, overriding the
+	// existing ones
+	Annotations map[string]string `protobuf:"bytes,3,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// New appVersion of the charts
+	//
+	// Types that are assignable to AppVersionPolicy:
+	//	*Transformation_AppVersion
+	//	*Transformation_AppVersionFromValues
+	AppVersionPolicy isTransformation_AppVersionPolicy `protobuf_oneof:"app_version_policy"`
+	// Glob patterns of the files and directories removed from the charts,
+	// e.g. tests/** or *.md
+	Strip []string `protobuf:"bytes,6,rep,name=strip,proto3" json:"strip,omitempty"`
+	// Text appended to the templates/NOTES.txt and README.md files of the
+	// charts. It is a Go template, rendered while syncing, with the Name,
+	// Version and AppVersion of the chart
+	Notice string `protobuf:"bytes,7,opt,name=notice,proto3" json:"notice,omitempty"`
+	// Maintainers of the charts, replacing the existing ones unless
+	// append_maintainers is set
+	Maintainers       []*Maintainer `protobuf:"bytes,8,rep,name=maintainers,proto3" json:"maintainers,omitempty"`
+	AppendMaintainers bool          `protobuf:"varint,9,opt,name=append_maintainers,json=appendMaintainers,proto3" json:"append_maintainers,omitempty"`
+	// Files added to the charts, e.g. license or compliance notices
+	Files []*ChartFile `protobuf:"bytes,10,rep,name=files,proto3" json:"files,omitempty"`
+	// Rewrites the icon URL of the charts to an internal host
+	Icon *IconMirror `protobuf:"bytes,11,opt,name=icon,proto3" json:"icon,omitempty"`
+	// RFC6902 JSON patches applied to the Chart.yaml and values.yaml files of
+	// the charts, for changes not covered by the options above
+	ChartPatch  []*JSONPatchOperation `protobuf:"bytes,12,rep,name=chart_patch,json=chartPatch,proto3" json:"chart_patch,omitempty"`
+	ValuesPatch []*JSONPatchOperation `protobuf:"bytes,13,rep,name=values_patch,json=valuesPatch,proto3" json:"values_patch,omitempty"`
+	// Generates the README.md file of the charts from a template
+	Readme *Readme `protobuf:"bytes,14,opt,name=readme,proto3" json:"readme,omitempty"`
+	// Rewrites the home and sources URLs of the charts
+	Links *Links `protobuf:"bytes,15,opt,name=links,proto3" json:"links,omitempty"`
+	// External command editing the uncompressed charts, for changes which
+	// cannot be expressed with the options above
+	Exec *Exec `protobuf:"bytes,16,opt,name=exec,proto3" json:"exec,omitempty"`
+	// Well-known global values set in the values.yaml file of the charts,
+	// before the values patches
+	Globals *GlobalValues `protobuf:"bytes,17,opt,name=globals,proto3" json:"globals,omitempty"`
+	// Dependencies of the charts removed or substituted, e.g. forbidden
+	// metrics exporters
+	Dependencies []*DependencyRule `protobuf:"bytes,18,rep,name=dependencies,proto3" json:"dependencies,omitempty"`
+	// Convert apiVersion v1 charts to apiVersion v2, moving their
+	// requirements.yaml and requirements.lock files to Chart.yaml and
+	// Chart.lock. It is applied before any other change
+	ConvertToV2 bool `protobuf:"varint,19,opt,name=convert_to_v2,json=convertToV2,proto3" json:"convert_to_v2,omitempty"`
+}
+
+func (x *Transformation) Reset() {
+	*x = Transformation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[30]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Transformation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transformation) ProtoMessage() {}
+
+func (x *Transformation) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[30]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transformation.ProtoReflect.Descriptor instead.
+func (*Transformation) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *Transformation) GetCharts() []string {
+	if x != nil {
+		return x.Charts
+	}
+	return nil
+}
+
+func (x *Transformation) GetValues() []*ValuesPatch {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Transformation) GetAnnotations() map[string]string {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (m *Transformation) GetAppVersionPolicy() isTransformation_AppVersionPolicy {
+	if m != nil {
+		return m.AppVersionPolicy
+	}
+	return nil
+}
+
+func (x *Transformation) GetAppVersion() string {
+	if x, ok := x.GetAppVersionPolicy().(*Transformation_AppVersion); ok {
+		return x.AppVersion
+	}
+	return ""
+}
+
+func (x *Transformation) GetAppVersionFromValues() string {
+	if x, ok := x.GetAppVersionPolicy().(*Transformation_AppVersionFromValues); ok {
+		return x.AppVersionFromValues
+	}
+	return ""
+}
+
+func (x *Transformation) GetStrip() []string {
+	if x != nil {
+		return x.Strip
+	}
+	return nil
+}
+
+func (x *Transformation) GetNotice() string {
+	if x != nil {
+		return x.Notice
+	}
+	return ""
+}
+
+func (x *Transformation) GetMaintainers() []*Maintainer {
+	if x != nil {
+		return x.Maintainers
+	}
+	return nil
+}
+
+func (x *Transformation) GetAppendMaintainers() bool {
+	if x != nil {
+		return x.AppendMaintainers
+	}
+	return false
+}
+
+func (x *Transformation) GetFiles() []*ChartFile {
+	if x != nil {
+		return x.Files
+	}
+	return nil
+}
+
+func (x *Transformation) GetIcon() *IconMirror {
+	if x != nil {
+		return x.Icon
+	}
+	return nil
+}
+
+func (x *Transformation) GetChartPatch() []*JSONPatchOperation {
+	if x != nil {
+		return x.ChartPatch
+	}
+	return nil
+}
+
+func (x *Transformation) GetValuesPatch() []*JSONPatchOperation {
+	if x != nil {
+		return x.ValuesPatch
+	}
+	return nil
+}
+
+func (x *Transformation) GetReadme() *Readme {
+	if x != nil {
+		return x.Readme
+	}
+	return nil
+}
+
+func (x *Transformation) GetLinks() *Links {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
+func (x *Transformation) GetExec() *Exec {
+	if x != nil {
+		return x.Exec
+	}
+	return nil
+}
+
+func (x *Transformation) GetGlobals() *GlobalValues {
+	if x != nil {
+		return x.Globals
+	}
+	return nil
+}
+
+func (x *Transformation) GetDependencies() []*DependencyRule {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+func (x *Transformation) GetConvertToV2() bool {
+	if x != nil {
+		return x.ConvertToV2
+	}
+	return false
+}
+
+type isTransformation_AppVersionPolicy interface {
+	isTransformation_AppVersionPolicy()
+}
+
+type Transformation_AppVersion struct {
+	// Literal appVersion, e.g. the tag of the relocated image
+	AppVersion string `protobuf:"bytes,4,opt,name=app_version,json=appVersion,proto3,oneof"`
+}
+
+type Transformation_AppVersionFromValues struct {
+	// yq-style path of the values.yaml key holding the appVersion, once
+	// the values are patched, e.g. .image.tag
+	AppVersionFromValues string `protobuf:"bytes,5,opt,name=app_version_from_values,json=appVersionFromValues,proto3,oneof"`
+}
+
+func (*Transformation_AppVersion) isTransformation_AppVersionPolicy() {}
+
+func (*Transformation_AppVersionFromValues) isTransformation_AppVersionPolicy() {}
+
+// DependencyRule removes or substitutes the dependencies of a chart with a
+// given name. The lock file is updated accordingly.
+type DependencyRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Name of the dependency
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Types that are assignable to Action:
+	//	*DependencyRule_Remove
+	//	*DependencyRule_Substitute_
+	Action isDependencyRule_Action `protobuf_oneof:"action"`
+}
+
+func (x *DependencyRule) Reset() {
+	*x = DependencyRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DependencyRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DependencyRule) ProtoMessage() {}
+
+func (x *DependencyRule) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DependencyRule.ProtoReflect.Descriptor instead.
+func (*DependencyRule) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *DependencyRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (m *DependencyRule) GetAction() isDependencyRule_Action {
+	if m != nil {
+		return m.Action
+	}
+	return nil
+}
+
+func (x *DependencyRule) GetRemove() bool {
+	if x, ok := x.GetAction().(*DependencyRule_Remove); ok {
+		return x.Remove
+	}
+	return false
+}
+
+func (x *DependencyRule) GetSubstitute() *DependencyRule_Substitute {
+	if x, ok := x.GetAction().(*DependencyRule_Substitute_); ok {
+		return x.Substitute
+	}
+	return nil
+}
+
+type isDependencyRule_Action interface {
+	isDependencyRule_Action()
+}
+
+type DependencyRule_Remove struct {
+	Remove bool `protobuf:"varint,2,opt,name=remove,proto3,oneof"`
+}
+
+type DependencyRule_Substitute_ struct {
+	// Chart replacing the dependency. It must be available in the target
+	// repository
+	Substitute *DependencyRule_Substitute `protobuf:"bytes,3,opt,name=substitute,proto3,oneof"`
+}
+
+func (*DependencyRule_Remove) isDependencyRule_Action() {}
+
+func (*DependencyRule_Substitute_) isDependencyRule_Action() {}
+
+// GlobalValues are well-known global values of the charts
+type GlobalValues struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Value of global.imageRegistry
+	ImageRegistry string `protobuf:"bytes,1,opt,name=image_registry,json=imageRegistry,proto3" json:"image_registry,omitempty"`
+	// Value of global.imagePullSecrets, as a list of secret names
+	ImagePullSecrets []string `protobuf:"bytes,2,rep,name=image_pull_secrets,json=imagePullSecrets,proto3" json:"image_pull_secrets,omitempty"`
+}
+
+func (x *GlobalValues) Reset() {
+	*x = GlobalValues{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GlobalValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GlobalValues) ProtoMessage() {}
+
+func (x *GlobalValues) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GlobalValues.ProtoReflect.Descriptor instead.
+func (*GlobalValues) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GlobalValues) GetImageRegistry() string {
+	if x != nil {
+		return x.ImageRegistry
+	}
+	return ""
+}
+
+func (x *GlobalValues) GetImagePullSecrets() []string {
+	if x != nil {
+		return x.ImagePullSecrets
+	}
+	return nil
+}
+
+// Exec runs an external command editing an uncompressed chart. The command
+// runs in the chart directory, with an environment restricted to PATH, the
+// CHART_PATH, CHART_NAME and CHART_VERSION variables and the configured ones.
+type Exec struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Command and arguments, e.g. ["/usr/local/bin/fix-chart", "--strict"]
+	Command []string `protobuf:"bytes,1,rep,name=command,proto3" json:"command,omitempty"`
+	// Maximum duration of the command, e.g. 30s. Defaults to 1m
+	Timeout string `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	// Environment variables of the command, either NAME=value or the NAME of
+	// a variable of the charts-syncer environment
+	Env []string `protobuf:"bytes,3,rep,name=env,proto3" json:"env,omitempty"`
+}
+
+func (x *Exec) Reset() {
+	*x = Exec{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Exec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Exec) ProtoMessage() {}
+
+func (x *Exec) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Exec.ProtoReflect.Descriptor instead.
+func (*Exec) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *Exec) GetCommand() []string {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *Exec) GetTimeout() string {
+	if x != nil {
+		return x.Timeout
+	}
+	return ""
+}
+
+func (x *Exec) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+// Links rewrites the home and sources URLs of a chart, e.g. to internal
+// mirrors
+type Links struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// URL prefixes replaced in the home and sources URLs. The first matching
+	// rewrite applies
+	Rewrites []*Links_Rewrite `protobuf:"bytes,1,rep,name=rewrites,proto3" json:"rewrites,omitempty"`
+	// New home URL. It is a Go template rendered like the notice
+	Home string `protobuf:"bytes,2,opt,name=home,proto3" json:"home,omitempty"`
+	// URLs appended to the sources, e.g. the internal mirror of the chart. They
+	// are Go templates rendered like the notice
+	AppendSources []string `protobuf:"bytes,3,rep,name=append_sources,json=appendSources,proto3" json:"append_sources,omitempty"`
+}
+
+func (x *Links) Reset() {
+	*x = Links{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Links) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Links) ProtoMessage() {}
+
+func (x *Links) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Links.ProtoReflect.Descriptor instead.
+func (*Links) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *Links) GetRewrites() []*Links_Rewrite {
+	if x != nil {
+		return x.Rewrites
+	}
+	return nil
+}
+
+func (x *Links) GetHome() string {
+	if x != nil {
+		return x.Home
+	}
+	return ""
+}
+
+func (x *Links) GetAppendSources() []string {
+	if x != nil {
+		return x.AppendSources
+	}
+	return nil
+}
+
+// Readme generates the README.md file of a chart
+type Readme struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path to a Go template with the content of the README. It is rendered
+	// with the Name, Version, AppVersion and whole Chart metadata of the
+	// chart, the SourceRepo and TargetRepo URLs and the SyncTime
+	Template string `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	// Prepend the rendered template to the existing README instead of
+	// replacing it
+	Prepend bool `protobuf:"varint,2,opt,name=prepend,proto3" json:"prepend,omitempty"`
+}
+
+func (x *Readme) Reset() {
+	*x = Readme{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Readme) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Readme) ProtoMessage() {}
+
+func (x *Readme) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Readme.ProtoReflect.Descriptor instead.
+func (*Readme) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Readme) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *Readme) GetPrepend() bool {
+	if x != nil {
+		return x.Prepend
+	}
+	return false
+}
+
+// JSONPatchOperation is an RFC6902 JSON patch operation
+type JSONPatchOperation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// One of add, remove, replace, move, copy or test
+	Op string `protobuf:"bytes,1,opt,name=op,proto3" json:"op,omitempty"`
+	// JSON pointer of the target, e.g. /annotations/category
+	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// Value of add, replace and test operations, in YAML format
+	Value string `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	// JSON pointer of the source of move and copy operations
+	From string `protobuf:"bytes,4,opt,name=from,proto3" json:"from,omitempty"`
+}
+
+func (x *JSONPatchOperation) Reset() {
+	*x = JSONPatchOperation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[36]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JSONPatchOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JSONPatchOperation) ProtoMessage() {}
+
+func (x *JSONPatchOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[36]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JSONPatchOperation.ProtoReflect.Descriptor instead.
+func (*JSONPatchOperation) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *JSONPatchOperation) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *JSONPatchOperation) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *JSONPatchOperation) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *JSONPatchOperation) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+// IconMirror serves the chart icons from an internal host
+type IconMirror struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Base URL the icons are served from. Icons are rewritten to
+	// <base_url>/<chart name>/<icon file name>
+	BaseUrl string `protobuf:"bytes,1,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	// Directory the icons are downloaded to, so they can be published at the
+	// base URL. Icons are not downloaded if empty.
+	DownloadDir string `protobuf:"bytes,2,opt,name=download_dir,json=downloadDir,proto3" json:"download_dir,omitempty"`
+	// Publish the icons to the base URL with HTTP PUT requests, e.g. to a
+	// bucket or a static web server accepting uploads. Icons already
+	// published are not uploaded again
+	Upload bool `protobuf:"varint,3,opt,name=upload,proto3" json:"upload,omitempty"`
+	// Credentials for the uploads
+	Auth *Auth `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
+}
+
+func (x *IconMirror) Reset() {
+	*x = IconMirror{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[37]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *IconMirror) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IconMirror) ProtoMessage() {}
+
+func (x *IconMirror) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[37]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IconMirror.ProtoReflect.Descriptor instead.
+func (*IconMirror) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *IconMirror) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *IconMirror) GetDownloadDir() string {
+	if x != nil {
+		return x.DownloadDir
+	}
+	return ""
+}
+
+func (x *IconMirror) GetUpload() bool {
+	if x != nil {
+		return x.Upload
+	}
+	return false
+}
+
+func (x *IconMirror) GetAuth() *Auth {
+	if x != nil {
+		return x.Auth
+	}
+	return nil
+}
+
+// ChartFile is a file added to a chart
+type ChartFile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path of the file in the chart, relative to the chart root
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Path to a Go template with the content of the file. It is rendered with
+	// the Name, Version and AppVersion of the chart
+	Template string `protobuf:"bytes,2,opt,name=template,proto3" json:"template,omitempty"`
+}
+
+func (x *ChartFile) Reset() {
+	*x = ChartFile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[38]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChartFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChartFile) ProtoMessage() {}
+
+func (x *ChartFile) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[38]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChartFile.ProtoReflect.Descriptor instead.
+func (*ChartFile) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ChartFile) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ChartFile) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+// Maintainer describes a maintainer of a chart
+type Maintainer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Url   string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+}
+
+func (x *Maintainer) Reset() {
+	*x = Maintainer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[39]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Maintainer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Maintainer) ProtoMessage() {}
+
+func (x *Maintainer) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[39]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Maintainer.ProtoReflect.Descriptor instead.
+func (*Maintainer) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *Maintainer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Maintainer) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Maintainer) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// ValuesPatch is a change to a values.yaml file
+type ValuesPatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// yq-style path of the key to change, e.g. .image.registry,
+	// .imagePullSecrets[0] or .commonLabels["app.kubernetes.io/part-of"]
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Types that are assignable to Op:
+	//	*ValuesPatch_Set
+	//	*ValuesPatch_Delete
+	//	*ValuesPatch_Replace_
+	Op isValuesPatch_Op `protobuf_oneof:"op"`
+}
+
+func (x *ValuesPatch) Reset() {
+	*x = ValuesPatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[40]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValuesPatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValuesPatch) ProtoMessage() {}
+
+func (x *ValuesPatch) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[40]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValuesPatch.ProtoReflect.Descriptor instead.
+func (*ValuesPatch) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ValuesPatch) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (m *ValuesPatch) GetOp() isValuesPatch_Op {
+	if m != nil {
+		return m.Op
+	}
+	return nil
+}
+
+func (x *ValuesPatch) GetSet() string {
+	if x, ok := x.GetOp().(*ValuesPatch_Set); ok {
+		return x.Set
+	}
+	return ""
+}
+
+func (x *ValuesPatch) GetDelete() bool {
+	if x, ok := x.GetOp().(*ValuesPatch_Delete); ok {
+		return x.Delete
+	}
+	return false
+}
+
+func (x *ValuesPatch) GetReplace() *ValuesPatch_Replace {
+	if x, ok := x.GetOp().(*ValuesPatch_Replace_); ok {
+		return x.Replace
+	}
+	return nil
+}
+
+type isValuesPatch_Op interface {
+	isValuesPatch_Op()
+}
+
+type ValuesPatch_Set struct {
+	// New value of the key, in YAML format. Missing parent keys are
+	// created
+	Set string `protobuf:"bytes,2,opt,name=set,proto3,oneof"`
+}
+
+type ValuesPatch_Delete struct {
+	// Delete the key
+	Delete bool `protobuf:"varint,3,opt,name=delete,proto3,oneof"`
+}
+
+type ValuesPatch_Replace_ struct {
+	// Replace a substring in every string value under the key
+	Replace *ValuesPatch_Replace `protobuf:"bytes,4,opt,name=replace,proto3,oneof"`
+}
+
+func (*ValuesPatch_Set) isValuesPatch_Op() {}
+
+func (*ValuesPatch_Delete) isValuesPatch_Op() {}
+
+func (*ValuesPatch_Replace_) isValuesPatch_Op() {}
+
+// Auth contains credentials to login to a chart repository
+type Auth struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+}
+
+func (x *Auth) Reset() {
+	*x = Auth{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[41]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Auth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Auth) ProtoMessage() {}
+
+func (x *Auth) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[41]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Auth.ProtoReflect.Descriptor instead.
+func (*Auth) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *Auth) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Auth) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+// ContainerAuth defines the authentication parameters required to access the source/target
+// OCI registries during container image relocation
+type Containers_ContainerAuth struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Username string `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Registry string `protobuf:"bytes,3,opt,name=registry,proto3" json:"registry,omitempty"`
+}
+
+func (x *Containers_ContainerAuth) Reset() {
+	*x = Containers_ContainerAuth{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[46]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Containers_ContainerAuth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Containers_ContainerAuth) ProtoMessage() {}
+
+func (x *Containers_ContainerAuth) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[46]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Containers_ContainerAuth.ProtoReflect.Descriptor instead.
+func (*Containers_ContainerAuth) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{16, 0}
+}
+
+func (x *Containers_ContainerAuth) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Containers_ContainerAuth) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *Containers_ContainerAuth) GetRegistry() string {
+	if x != nil {
+		return x.Registry
+	}
+	return ""
+}
+
+type Harbor_RetentionRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Repositories the rule applies to, "**" by default
+	Repositories string `protobuf:"bytes,1,opt,name=repositories,proto3" json:"repositories,omitempty"`
+	// Tags the rule applies to, "**" by default
+	Tags string `protobuf:"bytes,2,opt,name=tags,proto3" json:"tags,omitempty"`
+	// Types that are assignable to Retain:
+	//	*Harbor_RetentionRule_LatestPushed
+	//	*Harbor_RetentionRule_PushedWithinDays
+	Retain isHarbor_RetentionRule_Retain `protobuf_oneof:"retain"`
+}
+
+func (x *Harbor_RetentionRule) Reset() {
+	*x = Harbor_RetentionRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[48]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Harbor_RetentionRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Harbor_RetentionRule) ProtoMessage() {}
+
+func (x *Harbor_RetentionRule) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[48]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Harbor_RetentionRule.ProtoReflect.Descriptor instead.
+func (*Harbor_RetentionRule) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{21, 1}
+}
+
+func (x *Harbor_RetentionRule) GetRepositories() string {
+	if x != nil {
+		return x.Repositories
+	}
+	return ""
+}
+
+func (x *Harbor_RetentionRule) GetTags() string {
+	if x != nil {
+		return x.Tags
+	}
+	return ""
+}
+
+func (m *Harbor_RetentionRule) GetRetain() isHarbor_RetentionRule_Retain {
+	if m != nil {
+		return m.Retain
+	}
+	return nil
+}
+
+func (x *Harbor_RetentionRule) GetLatestPushed() int32 {
+	if x, ok := x.GetRetain().(*Harbor_RetentionRule_LatestPushed); ok {
+		return x.LatestPushed
+	}
+	return 0
+}
+
+func (x *Harbor_RetentionRule) GetPushedWithinDays() int32 {
+	if x, ok := x.GetRetain().(*Harbor_RetentionRule_PushedWithinDays); ok {
+		return x.PushedWithinDays
+	}
+	return 0
+}
+
+type isHarbor_RetentionRule_Retain interface {
+	isHarbor_RetentionRule_Retain()
+}
+
+type Harbor_RetentionRule_LatestPushed struct {
+	// Retain the most recently pushed artifacts
+	LatestPushed int32 `protobuf:"varint,3,opt,name=latest_pushed,json=latestPushed,proto3,oneof"`
+}
+
+type Harbor_RetentionRule_PushedWithinDays struct {
+	// Retain the artifacts pushed in the last days
+	PushedWithinDays int32 `protobuf:"varint,4,opt,name=pushed_within_days,json=pushedWithinDays,proto3,oneof"`
+}
+
+func (*Harbor_RetentionRule_LatestPushed) isHarbor_RetentionRule_Retain() {}
+
+func (*Harbor_RetentionRule_PushedWithinDays) isHarbor_RetentionRule_Retain() {}
+
+type DependencyRule_Substitute struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// Repository URL of the chart, as written in the dependencies
+	Repository string `protobuf:"bytes,3,opt,name=repository,proto3" json:"repository,omitempty"`
+}
+
+func (x *DependencyRule_Substitute) Reset() {
+	*x = DependencyRule_Substitute{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[50]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DependencyRule_Substitute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DependencyRule_Substitute) ProtoMessage() {}
+
+func (x *DependencyRule_Substitute) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[50]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DependencyRule_Substitute.ProtoReflect.Descriptor instead.
+func (*DependencyRule_Substitute) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{31, 0}
+}
+
+func (x *DependencyRule_Substitute) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DependencyRule_Substitute) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *DependencyRule_Substitute) GetRepository() string {
+	if x != nil {
+		return x.Repository
+	}
+	return ""
+}
+
+type Links_Rewrite struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Old string `protobuf:"bytes,1,opt,name=old,proto3" json:"old,omitempty"`
+	New string `protobuf:"bytes,2,opt,name=new,proto3" json:"new,omitempty"`
+}
+
+func (x *Links_Rewrite) Reset() {
+	*x = Links_Rewrite{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[51]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Links_Rewrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Links_Rewrite) ProtoMessage() {}
+
+func (x *Links_Rewrite) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[51]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Links_Rewrite.ProtoReflect.Descriptor instead.
+func (*Links_Rewrite) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{34, 0}
+}
+
+func (x *Links_Rewrite) GetOld() string {
+	if x != nil {
+		return x.Old
+	}
+	return ""
+}
+
+func (x *Links_Rewrite) GetNew() string {
+	if x != nil {
+		return x.New
+	}
+	return ""
+}
+
+type ValuesPatch_Replace struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Old string `protobuf:"bytes,1,opt,name=old,proto3" json:"old,omitempty"`
+	New string `protobuf:"bytes,2,opt,name=new,proto3" json:"new,omitempty"`
+}
+
+func (x *ValuesPatch_Replace) Reset() {
+	*x = ValuesPatch_Replace{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_config_proto_msgTypes[52]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValuesPatch_Replace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValuesPatch_Replace) ProtoMessage() {}
+
+func (x *ValuesPatch_Replace) ProtoReflect() protoreflect.Message {
+	mi := &file_config_proto_msgTypes[52]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValuesPatch_Replace.ProtoReflect.Descriptor instead.
+func (*ValuesPatch_Replace) Descriptor() ([]byte, []int) {
+	return file_config_proto_rawDescGZIP(), []int{40, 0}
+}
+
+func (x *ValuesPatch_Replace) GetOld() string {
+	if x != nil {
+		return x.Old
+	}
+	return ""
+}
+
+func (x *ValuesPatch_Replace) GetNew() string {
+	if x != nil {
+		return x.New
+	}
+	return ""
+}
+
+var File_config_proto protoreflect.FileDescriptor
+
+var file_config_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x03,
+	0x61, 0x70, 0x69, 0x22, 0xc1, 0x0c, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x23,
+	0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x12, 0x23, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74,
+	0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x68, 0x61, 0x72,
+	0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73,
+	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x18,
+	0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x6b, 0x69, 0x70, 0x43, 0x68, 0x61, 0x72, 0x74,
+	0x73, 0x12, 0x3a, 0x0a, 0x19, 0x72, 0x65, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x63, 0x6f,
+	0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x17, 0x72, 0x65, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x43, 0x6f,
+	0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x12, 0x42, 0x0a,
+	0x11, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x5f, 0x73, 0x74, 0x72, 0x61, 0x74, 0x65,
+	0x67, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43,
+	0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x52,
+	0x10, 0x63, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67,
+	0x79, 0x12, 0x30, 0x0a, 0x0b, 0x6c, 0x69, 0x6e, 0x74, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x6e,
+	0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x0a, 0x6c, 0x69, 0x6e, 0x74, 0x50, 0x6f, 0x6c,
+	0x69, 0x63, 0x79, 0x12, 0x42, 0x0a, 0x11, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x63,
+	0x65, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x50, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x63, 0x65, 0x50,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x10, 0x70, 0x72, 0x6f, 0x76, 0x65, 0x6e, 0x61, 0x6e, 0x63,
+	0x65, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x30, 0x0a, 0x0b, 0x73, 0x69, 0x67, 0x6e, 0x69,
+	0x6e, 0x67, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x53, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x52, 0x0a, 0x73,
+	0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x12, 0x3d, 0x0a, 0x0f, 0x74, 0x72, 0x61,
+	0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x0a, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0f, 0x74, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x75, 0x66, 0x66, 0x69, 0x78, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0d, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x75, 0x66, 0x66, 0x69, 0x78, 0x12,
+	0x23, 0x0a, 0x06, 0x72, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x06, 0x72, 0x65,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x32, 0x0a, 0x0b, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x61, 0x74, 0x74,
+	0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x67, 0x6e, 0x6f,
+	0x72, 0x65, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65,
+	0x12, 0x20, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0a, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x1d, 0x0a, 0x04, 0x66, 0x6c, 0x75, 0x78, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x46, 0x6c, 0x75, 0x78, 0x52, 0x04, 0x66, 0x6c, 0x75,
+	0x78, 0x12, 0x23, 0x0a, 0x06, 0x61, 0x72, 0x67, 0x6f, 0x63, 0x64, 0x18, 0x11, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x72, 0x67, 0x6f, 0x43, 0x44, 0x52, 0x06,
+	0x61, 0x72, 0x67, 0x6f, 0x63, 0x64, 0x12, 0x4b, 0x0a, 0x14, 0x76, 0x65, 0x72, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x18, 0x12,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x52, 0x13,
+	0x76, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68,
+	0x6f, 0x6f, 0x6b, 0x12, 0x1d, 0x0a, 0x04, 0x73, 0x62, 0x6f, 0x6d, 0x18, 0x13, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x62, 0x6f, 0x6d, 0x52, 0x04, 0x73, 0x62,
+	0x6f, 0x6d, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x5f, 0x6f, 0x6e, 0x6c, 0x79,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x4f, 0x6e, 0x6c,
+	0x79, 0x12, 0x2c, 0x0a, 0x09, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x18, 0x15,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x65, 0x6c, 0x65, 0x6d,
+	0x65, 0x74, 0x72, 0x79, 0x52, 0x09, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12,
+	0x18, 0x0a, 0x07, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x07, 0x77, 0x6f, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x12, 0x26, 0x0a, 0x05, 0x72, 0x65, 0x74,
+	0x72, 0x79, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52,
+	0x65, 0x74, 0x72, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x05, 0x72, 0x65, 0x74, 0x72,
+	0x79, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x65, 0x5f, 0x64, 0x69, 0x67,
+	0x65, 0x73, 0x74, 0x18, 0x18, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x63, 0x6f, 0x6d, 0x70, 0x61,
+	0x72, 0x65, 0x44, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x66, 0x6f, 0x72, 0x63,
+	0x65, 0x5f, 0x6f, 0x76, 0x65, 0x72, 0x77, 0x72, 0x69, 0x74, 0x65, 0x18, 0x19, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0e, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x4f, 0x76, 0x65, 0x72, 0x77, 0x72, 0x69, 0x74,
+	0x65, 0x12, 0x42, 0x0a, 0x0d, 0x63, 0x68, 0x61, 0x72, 0x74, 0x5f, 0x66, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x73, 0x18, 0x1a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x68, 0x61, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65,
+	0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0c, 0x63, 0x68, 0x61, 0x72, 0x74, 0x46, 0x69,
+	0x6c, 0x74, 0x65, 0x72, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x5f,
+	0x72, 0x65, 0x67, 0x65, 0x78, 0x18, 0x1b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x68, 0x61,
+	0x72, 0x74, 0x73, 0x52, 0x65, 0x67, 0x65, 0x78, 0x12, 0x25, 0x0a, 0x0e, 0x65, 0x78, 0x63, 0x6c,
+	0x75, 0x64, 0x65, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x18, 0x1c, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0d, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x68, 0x61, 0x72, 0x74, 0x73, 0x12,
+	0x30, 0x0a, 0x14, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x74,
+	0x73, 0x5f, 0x72, 0x65, 0x67, 0x65, 0x78, 0x18, 0x1d, 0x20, 0x03, 0x28, 0x09, 0x52, 0x12, 0x65,
+	0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x43, 0x68, 0x61, 0x72, 0x74, 0x73, 0x52, 0x65, 0x67, 0x65,
+	0x78, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x70, 0x72, 0x65, 0x72, 0x65, 0x6c,
+	0x65, 0x61, 0x73, 0x65, 0x73, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x6b, 0x69,
+	0x70, 0x50, 0x72, 0x65, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x12, 0x27, 0x0a, 0x0f,
+	0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x1f, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x25, 0x0a, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73,
+	0x18, 0x20, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x52, 0x07, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x73, 0x12, 0x21, 0x0a, 0x05,
+	0x73, 0x79, 0x6e, 0x63, 0x73, 0x18, 0x21, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x05, 0x73, 0x79, 0x6e, 0x63, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x18, 0x22, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x12, 0x37, 0x0a, 0x0d, 0x6e,
+	0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x23, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0d, 0x6e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x1a, 0x51, 0x0a, 0x11, 0x43, 0x68, 0x61, 0x72, 0x74, 0x46, 0x69, 0x6c,
+	0x74, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x26, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x43, 0x68, 0x61, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x7d, 0x0a, 0x0b, 0x43, 0x68, 0x61, 0x72, 0x74,
+	0x46, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x70, 0x72, 0x65, 0x72, 0x65,
+	0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x6b,
+	0x69, 0x70, 0x50, 0x72, 0x65, 0x72, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x73, 0x12, 0x27, 0x0a,
+	0x0f, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x95, 0x01, 0x0a, 0x0b, 0x52, 0x65, 0x74, 0x72, 0x79,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x74,
+	0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x6d, 0x61,
+	0x78, 0x41, 0x74, 0x74, 0x65, 0x6d, 0x70, 0x74, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x69,
+	0x74, 0x69, 0x61, 0x6c, 0x5f, 0x62, 0x61, 0x63, 0x6b, 0x6f, 0x66, 0x66, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0e, 0x69, 0x6e, 0x69, 0x74, 0x69, 0x61, 0x6c, 0x42, 0x61, 0x63, 0x6b, 0x6f,
+	0x66, 0x66, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x61, 0x63, 0x6b, 0x6f, 0x66,
+	0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x42, 0x61, 0x63, 0x6b,
+	0x6f, 0x66, 0x66, 0x12, 0x19, 0x0a, 0x08, 0x72, 0x65, 0x74, 0x72, 0x79, 0x5f, 0x6f, 0x6e, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0d, 0x52, 0x07, 0x72, 0x65, 0x74, 0x72, 0x79, 0x4f, 0x6e, 0x22, 0x5b,
+	0x0a, 0x09, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x65,
+	0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x22, 0x86, 0x02, 0x0a, 0x0c,
+	0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03,
+	0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x2f,
+	0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x17,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12,
+	0x38, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x1e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x6e, 0x6c,
+	0x79, 0x5f, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0c, 0x6f, 0x6e, 0x6c, 0x79, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x1a, 0x3a, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0xfa, 0x01, 0x0a, 0x13, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x12, 0x10, 0x0a, 0x03,
+	0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x1d,
+	0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x12, 0x3f, 0x0a,
+	0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x25,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x61, 0x69, 0x6c,
+	0x5f, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x66, 0x61, 0x69,
+	0x6c, 0x4f, 0x70, 0x65, 0x6e, 0x1a, 0x3a, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x64, 0x65, 0x72, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x85, 0x01, 0x0a, 0x06, 0x41, 0x72, 0x67, 0x6f, 0x43, 0x44, 0x12, 0x16, 0x0a, 0x06,
+	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65,
+	0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d,
+	0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x31, 0x0a, 0x14, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x61, 0x6c, 0x73, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x13, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x73, 0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x22, 0x8d, 0x01, 0x0a, 0x04, 0x46, 0x6c,
+	0x75, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x63, 0x72,
+	0x65, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73,
+	0x65, 0x63, 0x72, 0x65, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x85, 0x01, 0x0a, 0x05, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x14, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x36, 0x0a, 0x0a, 0x6b, 0x75, 0x62,
+	0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x6b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65,
+	0x73, 0x12, 0x23, 0x0a, 0x06, 0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x48, 0x00, 0x52, 0x06,
+	0x6f, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x42, 0x09, 0x0a, 0x07, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e,
+	0x64, 0x22, 0x86, 0x01, 0x0a, 0x0f, 0x4b, 0x75, 0x62, 0x65, 0x72, 0x6e, 0x65, 0x74, 0x65, 0x73,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6e, 0x61, 0x6d,
+	0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6e, 0x61,
+	0x6d, 0x65, 0x73, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x6b, 0x75, 0x62, 0x65, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6b, 0x75, 0x62,
+	0x65, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x6f, 0x63, 0x6b, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6c,
+	0x6f, 0x63, 0x6b, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x22, 0x46, 0x0a, 0x0b, 0x41, 0x74,
+	0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x65, 0x6e, 0x61,
+	0x62, 0x6c, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62,
+	0x6c, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x65, 0x72,
+	0x49, 0x64, 0x22, 0xb1, 0x01, 0x0a, 0x04, 0x53, 0x62, 0x6f, 0x6d, 0x12, 0x18, 0x0a, 0x07, 0x65,
+	0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x65, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65,
+	0x5f, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x69,
+	0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x73, 0x12, 0x3f, 0x0a, 0x10,
+	0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x74, 0x72, 0x61, 0x63, 0x6b,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x65, 0x70,
+	0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x52, 0x0f, 0x64, 0x65,
+	0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x12, 0x27, 0x0a,
+	0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0f, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x53, 0x62, 0x6f, 0x6d, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x22, 0xa2, 0x01, 0x0a, 0x0f, 0x44, 0x65, 0x70, 0x65, 0x6e,
+	0x64, 0x65, 0x6e, 0x63, 0x79, 0x54, 0x72, 0x61, 0x63, 0x6b, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c, 0x12, 0x17, 0x0a, 0x07,
+	0x61, 0x70, 0x69, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x61,
+	0x70, 0x69, 0x4b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x12, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74,
+	0x79, 0x5f, 0x74, 0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x11, 0x73, 0x65, 0x76, 0x65, 0x72, 0x69, 0x74, 0x79, 0x54, 0x68, 0x72, 0x65, 0x73,
+	0x68, 0x6f, 0x6c, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x66, 0x61, 0x69, 0x6c, 0x5f, 0x6f, 0x70, 0x65, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x66, 0x61, 0x69, 0x6c, 0x4f, 0x70, 0x65, 0x6e, 0x22, 0x8c, 0x01, 0x0a, 0x06,
+	0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x2f,
+	0x0a, 0x06, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x2e, 0x43, 0x68, 0x61, 0x72,
+	0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x1a,
+	0x39, 0x0a, 0x0b, 0x43, 0x68, 0x61, 0x72, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0xcb, 0x02, 0x0a, 0x06, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1f, 0x0a, 0x04, 0x72, 0x65, 0x70, 0x6f, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x48, 0x00,
+	0x52, 0x04, 0x72, 0x65, 0x70, 0x6f, 0x12, 0x3c, 0x0a, 0x19, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d,
+	0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x5f, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x5f, 0x70,
+	0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x17, 0x69, 0x6e, 0x74,
+	0x65, 0x72, 0x6d, 0x65, 0x64, 0x69, 0x61, 0x74, 0x65, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73,
+	0x50, 0x61, 0x74, 0x68, 0x12, 0x2f, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65,
+	0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61,
+	0x69, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x34, 0x0a, 0x10, 0x61, 0x64, 0x64, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x61, 0x6c, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x52, 0x0f, 0x61, 0x64, 0x64, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x52, 0x65, 0x70, 0x6f, 0x73, 0x12, 0x42, 0x0a, 0x11, 0x62,
+	0x75, 0x6e, 0x64, 0x6c, 0x65, 0x5f, 0x64, 0x65, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x42, 0x75, 0x6e,
+	0x64, 0x6c, 0x65, 0x44, 0x65, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x10, 0x62,
+	0x75, 0x6e, 0x64, 0x6c, 0x65, 0x44, 0x65, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x2f, 0x0a, 0x06, 0x76, 0x65, 0x72, 0x69, 0x66, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x56, 0x65, 0x72, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x06, 0x76, 0x65, 0x72, 0x69, 0x66, 0x79,
+	0x42, 0x06, 0x0a, 0x04, 0x73, 0x70, 0x65, 0x63, 0x22, 0x46, 0x0a, 0x12, 0x53, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16,
+	0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
+	0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x6b, 0x65, 0x79, 0x72, 0x69, 0x6e,
+	0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6b, 0x65, 0x79, 0x72, 0x69, 0x6e, 0x67,
+	0x22, 0xa4, 0x01, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x12,
+	0x31, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x2e, 0x43,
+	0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75,
 	0x74, 0x68, 0x1a, 0x63, 0x0a, 0x0d, 0x43, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x41,
 	0x75, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12,
 	0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72,
 	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72,
-	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x22, 0x9f, 0x02, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x22, 0x89, 0x04, 0x0a, 0x06, 0x54, 0x61, 0x72, 0x67,
 	0x65, 0x74, 0x12, 0x1f, 0x0a, 0x04, 0x72, 0x65, 0x70, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
 	0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x48, 0x00, 0x52, 0x04, 0x72,
 	0x65, 0x70, 0x6f, 0x12, 0x3c, 0x0a, 0x19, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6d, 0x65, 0x64, 0x69,
@@ -689,35 +4821,331 @@ var file_config_proto_rawDesc = []byte{
 	0x12, 0x2f, 0x0a, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x06,
 	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x61,
 	0x69, 0x6e, 0x65, 0x72, 0x73, 0x52, 0x0a, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72,
-	0x73, 0x42, 0x06, 0x0a, 0x04, 0x73, 0x70, 0x65, 0x63, 0x22, 0xed, 0x01, 0x0a, 0x04, 0x52, 0x65,
-	0x70, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x75, 0x72, 0x6c, 0x12, 0x1d, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b,
-	0x69, 0x6e, 0x64, 0x12, 0x1d, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75,
-	0x74, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73,
-	0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x68,
-	0x61, 0x72, 0x74, 0x73, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x2c, 0x0a, 0x10, 0x75, 0x73, 0x65,
-	0x5f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x06, 0x20,
-	0x01, 0x28, 0x08, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0e, 0x75, 0x73, 0x65, 0x43, 0x68, 0x61, 0x72,
-	0x74, 0x73, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x30, 0x0a, 0x14, 0x64, 0x69, 0x73, 0x61, 0x62,
-	0x6c, 0x65, 0x5f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
-	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x12, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x43, 0x68,
-	0x61, 0x72, 0x74, 0x73, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x3e, 0x0a, 0x04, 0x41, 0x75, 0x74,
-	0x68, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a,
-	0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x2a, 0x4e, 0x0a, 0x04, 0x4b, 0x69, 0x6e,
-	0x64, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08,
-	0x0a, 0x04, 0x48, 0x45, 0x4c, 0x4d, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x43, 0x48, 0x41, 0x52,
-	0x54, 0x4d, 0x55, 0x53, 0x45, 0x55, 0x4d, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x41, 0x52,
-	0x42, 0x4f, 0x52, 0x10, 0x03, 0x12, 0x07, 0x0a, 0x03, 0x4f, 0x43, 0x49, 0x10, 0x04, 0x12, 0x09,
-	0x0a, 0x05, 0x4c, 0x4f, 0x43, 0x41, 0x4c, 0x10, 0x05, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x69, 0x74, 0x6e, 0x61, 0x6d, 0x69, 0x2d,
-	0x6c, 0x61, 0x62, 0x73, 0x2f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x2d, 0x73, 0x79, 0x6e, 0x63,
-	0x65, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x3b, 0x61, 0x70, 0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x33,
+	0x73, 0x12, 0x23, 0x0a, 0x06, 0x68, 0x61, 0x72, 0x62, 0x6f, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x48, 0x61, 0x72, 0x62, 0x6f, 0x72, 0x52, 0x06,
+	0x68, 0x61, 0x72, 0x62, 0x6f, 0x72, 0x12, 0x42, 0x0a, 0x11, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+	0x5f, 0x65, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x45, 0x6e,
+	0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x10, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+	0x45, 0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x28, 0x0a, 0x10, 0x62, 0x75,
+	0x6e, 0x64, 0x6c, 0x65, 0x5f, 0x70, 0x61, 0x72, 0x74, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x50, 0x61, 0x72, 0x74,
+	0x53, 0x69, 0x7a, 0x65, 0x12, 0x30, 0x0a, 0x0b, 0x73, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x5f,
+	0x6b, 0x65, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x53, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x52, 0x0a, 0x73, 0x69, 0x67, 0x6e,
+	0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x12, 0x23, 0x0a, 0x06, 0x63, 0x6f, 0x73, 0x69, 0x67, 0x6e,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x6f, 0x73,
+	0x69, 0x67, 0x6e, 0x52, 0x06, 0x63, 0x6f, 0x73, 0x69, 0x67, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x73,
+	0x70, 0x65, 0x63, 0x22, 0xaf, 0x01, 0x0a, 0x06, 0x43, 0x6f, 0x73, 0x69, 0x67, 0x6e, 0x12, 0x10,
+	0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79,
+	0x12, 0x27, 0x0a, 0x0f, 0x70, 0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65, 0x5f, 0x66,
+	0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x61, 0x73, 0x73, 0x70,
+	0x68, 0x72, 0x61, 0x73, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x2e, 0x0a, 0x13, 0x69, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x66, 0x69, 0x6c, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79,
+	0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x75, 0x6c,
+	0x63, 0x69, 0x6f, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66,
+	0x75, 0x6c, 0x63, 0x69, 0x6f, 0x55, 0x72, 0x6c, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x6b, 0x6f,
+	0x72, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x6b,
+	0x6f, 0x72, 0x55, 0x72, 0x6c, 0x22, 0x5a, 0x0a, 0x10, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x45,
+	0x6e, 0x63, 0x72, 0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x67, 0x65,
+	0x5f, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x1f, 0x0a, 0x0b, 0x67, 0x70, 0x67, 0x5f, 0x6b, 0x65, 0x79, 0x72, 0x69, 0x6e, 0x67, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x67, 0x70, 0x67, 0x4b, 0x65, 0x79, 0x72, 0x69, 0x6e,
+	0x67, 0x22, 0x8a, 0x01, 0x0a, 0x10, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x44, 0x65, 0x63, 0x72,
+	0x79, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64,
+	0x65, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x61, 0x67, 0x65, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x1f, 0x0a,
+	0x0b, 0x67, 0x70, 0x67, 0x5f, 0x6b, 0x65, 0x79, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x67, 0x70, 0x67, 0x4b, 0x65, 0x79, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x2e,
+	0x0a, 0x13, 0x67, 0x70, 0x67, 0x5f, 0x70, 0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65,
+	0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x67, 0x70, 0x67,
+	0x50, 0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x22, 0xb6,
+	0x03, 0x0a, 0x06, 0x48, 0x61, 0x72, 0x62, 0x6f, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x72, 0x65,
+	0x61, 0x74, 0x65, 0x5f, 0x70, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0d, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x6a, 0x65, 0x63, 0x74,
+	0x12, 0x35, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x48, 0x61, 0x72, 0x62, 0x6f, 0x72, 0x2e,
+	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x37, 0x0a, 0x09, 0x72, 0x65, 0x74, 0x65, 0x6e,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x48, 0x61, 0x72, 0x62, 0x6f, 0x72, 0x2e, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x09, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x2d, 0x0a, 0x12, 0x72, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x63,
+	0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x72, 0x65,
+	0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x1a,
+	0x3b, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0xa8, 0x01, 0x0a,
+	0x0d, 0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x22,
+	0x0a, 0x0c, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f, 0x72, 0x69,
+	0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x25, 0x0a, 0x0d, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74,
+	0x5f, 0x70, 0x75, 0x73, 0x68, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52,
+	0x0c, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x74, 0x50, 0x75, 0x73, 0x68, 0x65, 0x64, 0x12, 0x2e, 0x0a,
+	0x12, 0x70, 0x75, 0x73, 0x68, 0x65, 0x64, 0x5f, 0x77, 0x69, 0x74, 0x68, 0x69, 0x6e, 0x5f, 0x64,
+	0x61, 0x79, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x10, 0x70, 0x75, 0x73,
+	0x68, 0x65, 0x64, 0x57, 0x69, 0x74, 0x68, 0x69, 0x6e, 0x44, 0x61, 0x79, 0x73, 0x42, 0x08, 0x0a,
+	0x06, 0x72, 0x65, 0x74, 0x61, 0x69, 0x6e, 0x22, 0x96, 0x05, 0x0a, 0x04, 0x52, 0x65, 0x70, 0x6f,
+	0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75,
+	0x72, 0x6c, 0x12, 0x1d, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e,
+	0x64, 0x12, 0x1d, 0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68,
+	0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x5f, 0x69,
+	0x6e, 0x64, 0x65, 0x78, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x68, 0x61, 0x72,
+	0x74, 0x73, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x2c, 0x0a, 0x10, 0x75, 0x73, 0x65, 0x5f, 0x63,
+	0x68, 0x61, 0x72, 0x74, 0x73, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x08, 0x42, 0x02, 0x18, 0x01, 0x52, 0x0e, 0x75, 0x73, 0x65, 0x43, 0x68, 0x61, 0x72, 0x74, 0x73,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x30, 0x0a, 0x14, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65,
+	0x5f, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x12, 0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x43, 0x68, 0x61, 0x72,
+	0x74, 0x73, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72,
+	0x69, 0x74, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72,
+	0x69, 0x74, 0x79, 0x12, 0x2a, 0x0a, 0x11, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x63, 0x68,
+	0x75, 0x6e, 0x6b, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f,
+	0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x6d, 0x69, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x07, 0x6d, 0x69, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x66, 0x6f, 0x72,
+	0x63, 0x65, 0x5f, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x55, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x25, 0x0a, 0x0e,
+	0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x0c,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x12, 0x3a, 0x0a, 0x0f, 0x6f, 0x63, 0x69, 0x5f, 0x6d, 0x65, 0x64, 0x69, 0x61,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x4f, 0x43, 0x49, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x54, 0x79, 0x70, 0x65, 0x73,
+	0x52, 0x0d, 0x6f, 0x63, 0x69, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12,
+	0x1e, 0x0a, 0x02, 0x73, 0x33, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x53, 0x33, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x02, 0x73, 0x33, 0x12,
+	0x21, 0x0a, 0x03, 0x67, 0x63, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x47, 0x43, 0x53, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x03, 0x67,
+	0x63, 0x73, 0x12, 0x2a, 0x0a, 0x06, 0x61, 0x7a, 0x62, 0x6c, 0x6f, 0x62, 0x18, 0x10, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x5a, 0x42, 0x6c, 0x6f, 0x62, 0x4f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x06, 0x61, 0x7a, 0x62, 0x6c, 0x6f, 0x62, 0x12, 0x21,
+	0x0a, 0x03, 0x67, 0x69, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70,
+	0x69, 0x2e, 0x47, 0x69, 0x74, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x03, 0x67, 0x69,
+	0x74, 0x12, 0x2d, 0x0a, 0x0a, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18,
+	0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x61, 0x74, 0x65,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x52, 0x09, 0x72, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x22, 0x51, 0x0a, 0x09, 0x52, 0x61, 0x74, 0x65, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x2e, 0x0a,
+	0x13, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12, 0x14, 0x0a,
+	0x05, 0x62, 0x75, 0x72, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x62, 0x75,
+	0x72, 0x73, 0x74, 0x22, 0x3f, 0x0a, 0x09, 0x53, 0x33, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x64, 0x70,
+	0x6f, 0x69, 0x6e, 0x74, 0x22, 0x28, 0x0a, 0x0a, 0x47, 0x43, 0x53, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0x8f,
+	0x01, 0x0a, 0x0d, 0x41, 0x5a, 0x42, 0x6c, 0x6f, 0x62, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x18, 0x0a, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x6e,
+	0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e,
+	0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x10, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x72,
+	0x69, 0x6e, 0x67, 0x12, 0x1b, 0x0a, 0x09, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x22, 0x99, 0x01, 0x0a, 0x0a, 0x47, 0x69, 0x74, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x62, 0x72, 0x61, 0x6e, 0x63, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x70,
+	0x61, 0x67, 0x65, 0x73, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x61, 0x67, 0x65, 0x73, 0x55, 0x72, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x75, 0x74, 0x68,
+	0x6f, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x61,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x75, 0x74,
+	0x68, 0x6f, 0x72, 0x5f, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x45, 0x6d, 0x61, 0x69, 0x6c, 0x22, 0x62, 0x0a, 0x0d,
+	0x4f, 0x43, 0x49, 0x4d, 0x65, 0x64, 0x69, 0x61, 0x54, 0x79, 0x70, 0x65, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12,
+	0x1f, 0x0a, 0x0b, 0x6f, 0x6d, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6f, 0x6d, 0x69, 0x74, 0x54, 0x69, 0x74, 0x6c, 0x65, 0x73,
+	0x22, 0x63, 0x0a, 0x0a, 0x53, 0x69, 0x67, 0x6e, 0x69, 0x6e, 0x67, 0x4b, 0x65, 0x79, 0x12, 0x18,
+	0x0a, 0x07, 0x6b, 0x65, 0x79, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6b, 0x65, 0x79, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x0f,
+	0x70, 0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73, 0x65, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x61, 0x73, 0x73, 0x70, 0x68, 0x72, 0x61, 0x73,
+	0x65, 0x46, 0x69, 0x6c, 0x65, 0x22, 0x8d, 0x07, 0x0a, 0x0e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66,
+	0x6f, 0x72, 0x6d, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x68, 0x61, 0x72,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x63, 0x68, 0x61, 0x72, 0x74, 0x73,
+	0x12, 0x28, 0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x10, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x50, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x46, 0x0a, 0x0b, 0x61, 0x6e,
+	0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x24, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x66, 0x6f, 0x72, 0x6d, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x41, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0b, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0b, 0x61, 0x70, 0x70, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0a, 0x61, 0x70, 0x70, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x37, 0x0a, 0x17, 0x61, 0x70, 0x70, 0x5f, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x14, 0x61, 0x70, 0x70, 0x56, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x46, 0x72, 0x6f, 0x6d, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x74, 0x72, 0x69, 0x70, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x72, 0x69, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x12, 0x31, 0x0a, 0x0b,
+	0x6d, 0x61, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4d, 0x61, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e,
+	0x65, 0x72, 0x52, 0x0b, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x12,
+	0x2d, 0x0a, 0x12, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x61,
+	0x69, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x11, 0x61, 0x70, 0x70,
+	0x65, 0x6e, 0x64, 0x4d, 0x61, 0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x24,
+	0x0a, 0x05, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x43, 0x68, 0x61, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x05, 0x66,
+	0x69, 0x6c, 0x65, 0x73, 0x12, 0x23, 0x0a, 0x04, 0x69, 0x63, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x49, 0x63, 0x6f, 0x6e, 0x4d, 0x69, 0x72,
+	0x72, 0x6f, 0x72, 0x52, 0x04, 0x69, 0x63, 0x6f, 0x6e, 0x12, 0x38, 0x0a, 0x0b, 0x63, 0x68, 0x61,
+	0x72, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x63, 0x68, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4a, 0x53, 0x4f, 0x4e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x4f, 0x70,
+	0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0a, 0x63, 0x68, 0x61, 0x72, 0x74, 0x50, 0x61,
+	0x74, 0x63, 0x68, 0x12, 0x3a, 0x0a, 0x0c, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x5f, 0x70, 0x61,
+	0x74, 0x63, 0x68, 0x18, 0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x61, 0x70, 0x69, 0x2e,
+	0x4a, 0x53, 0x4f, 0x4e, 0x50, 0x61, 0x74, 0x63, 0x68, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x0b, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x50, 0x61, 0x74, 0x63, 0x68, 0x12,
+	0x23, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x64, 0x6d, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x6d, 0x65, 0x52, 0x06, 0x72, 0x65,
+	0x61, 0x64, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x0f, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x73, 0x52,
+	0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x1d, 0x0a, 0x04, 0x65, 0x78, 0x65, 0x63, 0x18, 0x10,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x45, 0x78, 0x65, 0x63, 0x52,
+	0x04, 0x65, 0x78, 0x65, 0x63, 0x12, 0x2b, 0x0a, 0x07, 0x67, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x73,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x47, 0x6c, 0x6f,
+	0x62, 0x61, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x52, 0x07, 0x67, 0x6c, 0x6f, 0x62, 0x61,
+	0x6c, 0x73, 0x12, 0x37, 0x0a, 0x0c, 0x64, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x69,
+	0x65, 0x73, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x44,
+	0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x0c, 0x64,
+	0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x69, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0d, 0x63,
+	0x6f, 0x6e, 0x76, 0x65, 0x72, 0x74, 0x5f, 0x74, 0x6f, 0x5f, 0x76, 0x32, 0x18, 0x13, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x76, 0x65, 0x72, 0x74, 0x54, 0x6f, 0x56, 0x32, 0x1a,
+	0x3e, 0x0a, 0x10, 0x41, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42,
+	0x14, 0x0a, 0x12, 0x61, 0x70, 0x70, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x70,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x22, 0xe6, 0x01, 0x0a, 0x0e, 0x44, 0x65, 0x70, 0x65, 0x6e, 0x64,
+	0x65, 0x6e, 0x63, 0x79, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x06,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x06,
+	0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x12, 0x40, 0x0a, 0x0a, 0x73, 0x75, 0x62, 0x73, 0x74, 0x69,
+	0x74, 0x75, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x44, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x75, 0x6c, 0x65, 0x2e,
+	0x53, 0x75, 0x62, 0x73, 0x74, 0x69, 0x74, 0x75, 0x74, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x73, 0x75,
+	0x62, 0x73, 0x74, 0x69, 0x74, 0x75, 0x74, 0x65, 0x1a, 0x5a, 0x0a, 0x0a, 0x53, 0x75, 0x62, 0x73,
+	0x74, 0x69, 0x74, 0x75, 0x74, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x6f,
+	0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x70, 0x6f, 0x73, 0x69,
+	0x74, 0x6f, 0x72, 0x79, 0x42, 0x08, 0x0a, 0x06, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x63,
+	0x0a, 0x0c, 0x47, 0x6c, 0x6f, 0x62, 0x61, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x12, 0x25,
+	0x0a, 0x0e, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x52, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x2c, 0x0a, 0x12, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x5f, 0x70,
+	0x75, 0x6c, 0x6c, 0x5f, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x10, 0x69, 0x6d, 0x61, 0x67, 0x65, 0x50, 0x75, 0x6c, 0x6c, 0x53, 0x65, 0x63, 0x72,
+	0x65, 0x74, 0x73, 0x22, 0x4c, 0x0a, 0x04, 0x45, 0x78, 0x65, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e,
+	0x76, 0x22, 0xa1, 0x01, 0x0a, 0x05, 0x4c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x2e, 0x0a, 0x08, 0x72,
+	0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e,
+	0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x73, 0x2e, 0x52, 0x65, 0x77, 0x72, 0x69, 0x74,
+	0x65, 0x52, 0x08, 0x72, 0x65, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x68,
+	0x6f, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x6f, 0x6d, 0x65, 0x12,
+	0x25, 0x0a, 0x0e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65,
+	0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0d, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x1a, 0x2d, 0x0a, 0x07, 0x52, 0x65, 0x77, 0x72, 0x69, 0x74,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x6f, 0x6c, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x6f, 0x6c, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x65, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6e, 0x65, 0x77, 0x22, 0x3e, 0x0a, 0x06, 0x52, 0x65, 0x61, 0x64, 0x6d, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70,
+	0x72, 0x65, 0x70, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x70, 0x72,
+	0x65, 0x70, 0x65, 0x6e, 0x64, 0x22, 0x62, 0x0a, 0x12, 0x4a, 0x53, 0x4f, 0x4e, 0x50, 0x61, 0x74,
+	0x63, 0x68, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x6f,
+	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6f, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x70,
+	0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x72, 0x6f, 0x6d, 0x22, 0x81, 0x01, 0x0a, 0x0a, 0x49, 0x63,
+	0x6f, 0x6e, 0x4d, 0x69, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x73, 0x65,
+	0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x62, 0x61, 0x73, 0x65,
+	0x55, 0x72, 0x6c, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x6f, 0x77, 0x6e, 0x6c, 0x6f, 0x61, 0x64, 0x5f,
+	0x64, 0x69, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x6f, 0x77, 0x6e, 0x6c,
+	0x6f, 0x61, 0x64, 0x44, 0x69, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x75, 0x70, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1d,
+	0x0a, 0x04, 0x61, 0x75, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x09, 0x2e, 0x61,
+	0x70, 0x69, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x52, 0x04, 0x61, 0x75, 0x74, 0x68, 0x22, 0x3b, 0x0a,
+	0x09, 0x43, 0x68, 0x61, 0x72, 0x74, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1a,
+	0x0a, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x22, 0x48, 0x0a, 0x0a, 0x4d, 0x61,
+	0x69, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61,
+	0x69, 0x6c, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x75, 0x72, 0x6c, 0x22, 0xba, 0x01, 0x0a, 0x0b, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x50,
+	0x61, 0x74, 0x63, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x12, 0x0a, 0x03, 0x73, 0x65, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x73, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x06,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x06,
+	0x64, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x34, 0x0a, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x73, 0x50, 0x61, 0x74, 0x63, 0x68, 0x2e, 0x52, 0x65, 0x70, 0x6c, 0x61, 0x63,
+	0x65, 0x48, 0x00, 0x52, 0x07, 0x72, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x1a, 0x2d, 0x0a, 0x07,
+	0x52, 0x65, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6f, 0x6c, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6f, 0x6c, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x65, 0x77,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6e, 0x65, 0x77, 0x42, 0x04, 0x0a, 0x02, 0x6f,
+	0x70, 0x22, 0x3e, 0x0a, 0x04, 0x41, 0x75, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65,
+	0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72,
+	0x64, 0x2a, 0x90, 0x01, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e,
+	0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x45, 0x4c, 0x4d, 0x10,
+	0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x43, 0x48, 0x41, 0x52, 0x54, 0x4d, 0x55, 0x53, 0x45, 0x55, 0x4d,
+	0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x41, 0x52, 0x42, 0x4f, 0x52, 0x10, 0x03, 0x12, 0x07,
+	0x0a, 0x03, 0x4f, 0x43, 0x49, 0x10, 0x04, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x4f, 0x43, 0x41, 0x4c,
+	0x10, 0x05, 0x12, 0x06, 0x0a, 0x02, 0x53, 0x33, 0x10, 0x06, 0x12, 0x07, 0x0a, 0x03, 0x47, 0x43,
+	0x53, 0x10, 0x07, 0x12, 0x0a, 0x0a, 0x06, 0x41, 0x5a, 0x42, 0x4c, 0x4f, 0x42, 0x10, 0x08, 0x12,
+	0x0f, 0x0a, 0x0b, 0x41, 0x52, 0x54, 0x49, 0x46, 0x41, 0x43, 0x54, 0x4f, 0x52, 0x59, 0x10, 0x09,
+	0x12, 0x09, 0x0a, 0x05, 0x4e, 0x45, 0x58, 0x55, 0x53, 0x10, 0x0a, 0x12, 0x07, 0x0a, 0x03, 0x47,
+	0x49, 0x54, 0x10, 0x0b, 0x2a, 0x5c, 0x0a, 0x10, 0x43, 0x6f, 0x6e, 0x66, 0x6c, 0x69, 0x63, 0x74,
+	0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12, 0x17, 0x0a, 0x13, 0x43, 0x4f, 0x4e, 0x46,
+	0x4c, 0x49, 0x43, 0x54, 0x5f, 0x46, 0x49, 0x52, 0x53, 0x54, 0x5f, 0x57, 0x49, 0x4e, 0x53, 0x10,
+	0x00, 0x12, 0x1c, 0x0a, 0x18, 0x43, 0x4f, 0x4e, 0x46, 0x4c, 0x49, 0x43, 0x54, 0x5f, 0x50, 0x52,
+	0x45, 0x46, 0x45, 0x52, 0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x10, 0x01, 0x12,
+	0x11, 0x0a, 0x0d, 0x43, 0x4f, 0x4e, 0x46, 0x4c, 0x49, 0x43, 0x54, 0x5f, 0x46, 0x41, 0x49, 0x4c,
+	0x10, 0x02, 0x2a, 0x39, 0x0a, 0x0a, 0x4c, 0x69, 0x6e, 0x74, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x12, 0x0d, 0x0a, 0x09, 0x4c, 0x49, 0x4e, 0x54, 0x5f, 0x57, 0x41, 0x52, 0x4e, 0x10, 0x00, 0x12,
+	0x0d, 0x0a, 0x09, 0x4c, 0x49, 0x4e, 0x54, 0x5f, 0x46, 0x41, 0x49, 0x4c, 0x10, 0x01, 0x12, 0x0d,
+	0x0a, 0x09, 0x4c, 0x49, 0x4e, 0x54, 0x5f, 0x53, 0x4b, 0x49, 0x50, 0x10, 0x02, 0x2a, 0x2f, 0x0a,
+	0x0a, 0x53, 0x62, 0x6f, 0x6d, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x0d, 0x0a, 0x09, 0x53,
+	0x42, 0x4f, 0x4d, 0x5f, 0x53, 0x50, 0x44, 0x58, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x53, 0x42,
+	0x4f, 0x4d, 0x5f, 0x43, 0x59, 0x43, 0x4c, 0x4f, 0x4e, 0x45, 0x44, 0x58, 0x10, 0x01, 0x2a, 0x46,
+	0x0a, 0x12, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x6f,
+	0x72, 0x6d, 0x61, 0x74, 0x12, 0x18, 0x0a, 0x14, 0x4e, 0x4f, 0x54, 0x49, 0x46, 0x49, 0x43, 0x41,
+	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x57, 0x45, 0x42, 0x48, 0x4f, 0x4f, 0x4b, 0x10, 0x00, 0x12, 0x16,
+	0x0a, 0x12, 0x4e, 0x4f, 0x54, 0x49, 0x46, 0x49, 0x43, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x53,
+	0x4c, 0x41, 0x43, 0x4b, 0x10, 0x01, 0x2a, 0x58, 0x0a, 0x10, 0x50, 0x72, 0x6f, 0x76, 0x65, 0x6e,
+	0x61, 0x6e, 0x63, 0x65, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x14, 0x0a, 0x10, 0x50, 0x52,
+	0x4f, 0x56, 0x45, 0x4e, 0x41, 0x4e, 0x43, 0x45, 0x5f, 0x53, 0x54, 0x52, 0x49, 0x50, 0x10, 0x00,
+	0x12, 0x13, 0x0a, 0x0f, 0x50, 0x52, 0x4f, 0x56, 0x45, 0x4e, 0x41, 0x4e, 0x43, 0x45, 0x5f, 0x4b,
+	0x45, 0x45, 0x50, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x50, 0x52, 0x4f, 0x56, 0x45, 0x4e, 0x41,
+	0x4e, 0x43, 0x45, 0x5f, 0x52, 0x45, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x41, 0x54, 0x45, 0x10, 0x02,
+	0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62,
+	0x69, 0x74, 0x6e, 0x61, 0x6d, 0x69, 0x2d, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x63, 0x68, 0x61, 0x72,
+	0x74, 0x73, 0x2d, 0x73, 0x79, 0x6e, 0x63, 0x65, 0x72, 0x2f, 0x61, 0x70, 0x69, 0x3b, 0x61, 0x70,
+	0x69, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -732,33 +5160,143 @@ func file_config_proto_rawDescGZIP() []byte {
 	return file_config_proto_rawDescData
 }
 
-var file_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_config_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 53)
 var file_config_proto_goTypes = []interface{}{
-	(Kind)(0),                        // 0: api.Kind
-	(*Config)(nil),                   // 1: api.Config
-	(*Source)(nil),                   // 2: api.Source
-	(*Containers)(nil),               // 3: api.Containers
-	(*Target)(nil),                   // 4: api.Target
-	(*Repo)(nil),                     // 5: api.Repo
-	(*Auth)(nil),                     // 6: api.Auth
-	(*Containers_ContainerAuth)(nil), // 7: api.Containers.ContainerAuth
+	(Kind)(0),                         // 0: api.Kind
+	(ConflictStrategy)(0),             // 1: api.ConflictStrategy
+	(LintPolicy)(0),                   // 2: api.LintPolicy
+	(SbomFormat)(0),                   // 3: api.SbomFormat
+	(NotificationFormat)(0),           // 4: api.NotificationFormat
+	(ProvenancePolicy)(0),             // 5: api.ProvenancePolicy
+	(*Config)(nil),                    // 6: api.Config
+	(*ChartFilter)(nil),               // 7: api.ChartFilter
+	(*RetryPolicy)(nil),               // 8: api.RetryPolicy
+	(*Telemetry)(nil),                 // 9: api.Telemetry
+	(*Notification)(nil),              // 10: api.Notification
+	(*VerificationWebhook)(nil),       // 11: api.VerificationWebhook
+	(*ArgoCD)(nil),                    // 12: api.ArgoCD
+	(*Flux)(nil),                      // 13: api.Flux
+	(*State)(nil),                     // 14: api.State
+	(*KubernetesState)(nil),           // 15: api.KubernetesState
+	(*Attestation)(nil),               // 16: api.Attestation
+	(*Sbom)(nil),                      // 17: api.Sbom
+	(*DependencyTrack)(nil),           // 18: api.DependencyTrack
+	(*Rename)(nil),                    // 19: api.Rename
+	(*Source)(nil),                    // 20: api.Source
+	(*SourceVerification)(nil),        // 21: api.SourceVerification
+	(*Containers)(nil),                // 22: api.Containers
+	(*Target)(nil),                    // 23: api.Target
+	(*Cosign)(nil),                    // 24: api.Cosign
+	(*BundleEncryption)(nil),          // 25: api.BundleEncryption
+	(*BundleDecryption)(nil),          // 26: api.BundleDecryption
+	(*Harbor)(nil),                    // 27: api.Harbor
+	(*Repo)(nil),                      // 28: api.Repo
+	(*RateLimit)(nil),                 // 29: api.RateLimit
+	(*S3Options)(nil),                 // 30: api.S3Options
+	(*GCSOptions)(nil),                // 31: api.GCSOptions
+	(*AZBlobOptions)(nil),             // 32: api.AZBlobOptions
+	(*GitOptions)(nil),                // 33: api.GitOptions
+	(*OCIMediaTypes)(nil),             // 34: api.OCIMediaTypes
+	(*SigningKey)(nil),                // 35: api.SigningKey
+	(*Transformation)(nil),            // 36: api.Transformation
+	(*DependencyRule)(nil),            // 37: api.DependencyRule
+	(*GlobalValues)(nil),              // 38: api.GlobalValues
+	(*Exec)(nil),                      // 39: api.Exec
+	(*Links)(nil),                     // 40: api.Links
+	(*Readme)(nil),                    // 41: api.Readme
+	(*JSONPatchOperation)(nil),        // 42: api.JSONPatchOperation
+	(*IconMirror)(nil),                // 43: api.IconMirror
+	(*ChartFile)(nil),                 // 44: api.ChartFile
+	(*Maintainer)(nil),                // 45: api.Maintainer
+	(*ValuesPatch)(nil),               // 46: api.ValuesPatch
+	(*Auth)(nil),                      // 47: api.Auth
+	nil,                               // 48: api.Config.ChartFiltersEntry
+	nil,                               // 49: api.Notification.HeadersEntry
+	nil,                               // 50: api.VerificationWebhook.HeadersEntry
+	nil,                               // 51: api.Rename.ChartsEntry
+	(*Containers_ContainerAuth)(nil),  // 52: api.Containers.ContainerAuth
+	nil,                               // 53: api.Harbor.MetadataEntry
+	(*Harbor_RetentionRule)(nil),      // 54: api.Harbor.RetentionRule
+	nil,                               // 55: api.Transformation.AnnotationsEntry
+	(*DependencyRule_Substitute)(nil), // 56: api.DependencyRule.Substitute
+	(*Links_Rewrite)(nil),             // 57: api.Links.Rewrite
+	(*ValuesPatch_Replace)(nil),       // 58: api.ValuesPatch.Replace
 }
 var file_config_proto_depIdxs = []int32{
-	2, // 0: api.Config.source:type_name -> api.Source
-	4, // 1: api.Config.target:type_name -> api.Target
-	5, // 2: api.Source.repo:type_name -> api.Repo
-	3, // 3: api.Source.containers:type_name -> api.Containers
-	7, // 4: api.Containers.auth:type_name -> api.Containers.ContainerAuth
-	5, // 5: api.Target.repo:type_name -> api.Repo
-	3, // 6: api.Target.containers:type_name -> api.Containers
-	0, // 7: api.Repo.kind:type_name -> api.Kind
-	6, // 8: api.Repo.auth:type_name -> api.Auth
-	9, // [9:9] is the sub-list for method output_type
-	9, // [9:9] is the sub-list for method input_type
-	9, // [9:9] is the sub-list for extension type_name
-	9, // [9:9] is the sub-list for extension extendee
-	0, // [0:9] is the sub-list for field type_name
+	20, // 0: api.Config.source:type_name -> api.Source
+	23, // 1: api.Config.target:type_name -> api.Target
+	1,  // 2: api.Config.conflict_strategy:type_name -> api.ConflictStrategy
+	2,  // 3: api.Config.lint_policy:type_name -> api.LintPolicy
+	5,  // 4: api.Config.provenance_policy:type_name -> api.ProvenancePolicy
+	35, // 5: api.Config.signing_key:type_name -> api.SigningKey
+	36, // 6: api.Config.transformations:type_name -> api.Transformation
+	19, // 7: api.Config.rename:type_name -> api.Rename
+	16, // 8: api.Config.attestation:type_name -> api.Attestation
+	14, // 9: api.Config.state:type_name -> api.State
+	13, // 10: api.Config.flux:type_name -> api.Flux
+	12, // 11: api.Config.argocd:type_name -> api.ArgoCD
+	11, // 12: api.Config.verification_webhook:type_name -> api.VerificationWebhook
+	17, // 13: api.Config.sbom:type_name -> api.Sbom
+	9,  // 14: api.Config.telemetry:type_name -> api.Telemetry
+	8,  // 15: api.Config.retry:type_name -> api.RetryPolicy
+	48, // 16: api.Config.chart_filters:type_name -> api.Config.ChartFiltersEntry
+	23, // 17: api.Config.targets:type_name -> api.Target
+	6,  // 18: api.Config.syncs:type_name -> api.Config
+	10, // 19: api.Config.notifications:type_name -> api.Notification
+	4,  // 20: api.Notification.format:type_name -> api.NotificationFormat
+	49, // 21: api.Notification.headers:type_name -> api.Notification.HeadersEntry
+	47, // 22: api.VerificationWebhook.auth:type_name -> api.Auth
+	50, // 23: api.VerificationWebhook.headers:type_name -> api.VerificationWebhook.HeadersEntry
+	15, // 24: api.State.kubernetes:type_name -> api.KubernetesState
+	28, // 25: api.State.object:type_name -> api.Repo
+	18, // 26: api.Sbom.dependency_track:type_name -> api.DependencyTrack
+	3,  // 27: api.Sbom.format:type_name -> api.SbomFormat
+	51, // 28: api.Rename.charts:type_name -> api.Rename.ChartsEntry
+	28, // 29: api.Source.repo:type_name -> api.Repo
+	22, // 30: api.Source.containers:type_name -> api.Containers
+	28, // 31: api.Source.additional_repos:type_name -> api.Repo
+	26, // 32: api.Source.bundle_decryption:type_name -> api.BundleDecryption
+	21, // 33: api.Source.verify:type_name -> api.SourceVerification
+	52, // 34: api.Containers.auth:type_name -> api.Containers.ContainerAuth
+	28, // 35: api.Target.repo:type_name -> api.Repo
+	22, // 36: api.Target.containers:type_name -> api.Containers
+	27, // 37: api.Target.harbor:type_name -> api.Harbor
+	25, // 38: api.Target.bundle_encryption:type_name -> api.BundleEncryption
+	35, // 39: api.Target.signing_key:type_name -> api.SigningKey
+	24, // 40: api.Target.cosign:type_name -> api.Cosign
+	53, // 41: api.Harbor.metadata:type_name -> api.Harbor.MetadataEntry
+	54, // 42: api.Harbor.retention:type_name -> api.Harbor.RetentionRule
+	0,  // 43: api.Repo.kind:type_name -> api.Kind
+	47, // 44: api.Repo.auth:type_name -> api.Auth
+	34, // 45: api.Repo.oci_media_types:type_name -> api.OCIMediaTypes
+	30, // 46: api.Repo.s3:type_name -> api.S3Options
+	31, // 47: api.Repo.gcs:type_name -> api.GCSOptions
+	32, // 48: api.Repo.azblob:type_name -> api.AZBlobOptions
+	33, // 49: api.Repo.git:type_name -> api.GitOptions
+	29, // 50: api.Repo.rate_limit:type_name -> api.RateLimit
+	46, // 51: api.Transformation.values:type_name -> api.ValuesPatch
+	55, // 52: api.Transformation.annotations:type_name -> api.Transformation.AnnotationsEntry
+	45, // 53: api.Transformation.maintainers:type_name -> api.Maintainer
+	44, // 54: api.Transformation.files:type_name -> api.ChartFile
+	43, // 55: api.Transformation.icon:type_name -> api.IconMirror
+	42, // 56: api.Transformation.chart_patch:type_name -> api.JSONPatchOperation
+	42, // 57: api.Transformation.values_patch:type_name -> api.JSONPatchOperation
+	41, // 58: api.Transformation.readme:type_name -> api.Readme
+	40, // 59: api.Transformation.links:type_name -> api.Links
+	39, // 60: api.Transformation.exec:type_name -> api.Exec
+	38, // 61: api.Transformation.globals:type_name -> api.GlobalValues
+	37, // 62: api.Transformation.dependencies:type_name -> api.DependencyRule
+	56, // 63: api.DependencyRule.substitute:type_name -> api.DependencyRule.Substitute
+	57, // 64: api.Links.rewrites:type_name -> api.Links.Rewrite
+	47, // 65: api.IconMirror.auth:type_name -> api.Auth
+	58, // 66: api.ValuesPatch.replace:type_name -> api.ValuesPatch.Replace
+	7,  // 67: api.Config.ChartFiltersEntry.value:type_name -> api.ChartFilter
+	68, // [68:68] is the sub-list for method output_type
+	68, // [68:68] is the sub-list for method input_type
+	68, // [68:68] is the sub-list for extension type_name
+	68, // [68:68] is the sub-list for extension extendee
+	0,  // [0:68] is the sub-list for field type_name
 }
 
 func init() { file_config_proto_init() }
@@ -779,8 +5317,200 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Source); i {
+		file_config_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChartFilter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetryPolicy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Telemetry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Notification); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerificationWebhook); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ArgoCD); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Flux); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*State); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KubernetesState); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Attestation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Sbom); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DependencyTrack); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Rename); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Source); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SourceVerification); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Containers); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Target); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -791,8 +5521,8 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Containers); i {
+		file_config_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Cosign); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -803,8 +5533,8 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Target); i {
+		file_config_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BundleEncryption); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -815,7 +5545,31 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BundleDecryption); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Harbor); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Repo); i {
 			case 0:
 				return &v.state
@@ -827,7 +5581,223 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RateLimit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*S3Options); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GCSOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AZBlobOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GitOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OCIMediaTypes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SigningKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Transformation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DependencyRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GlobalValues); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Exec); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Links); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Readme); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*JSONPatchOperation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*IconMirror); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChartFile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Maintainer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValuesPatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Auth); i {
 			case 0:
 				return &v.state
@@ -839,7 +5809,7 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
-		file_config_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Containers_ContainerAuth); i {
 			case 0:
 				return &v.state
@@ -851,22 +5821,92 @@ func file_config_proto_init() {
 				return nil
 			}
 		}
+		file_config_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Harbor_RetentionRule); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DependencyRule_Substitute); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Links_Rewrite); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_config_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValuesPatch_Replace); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_config_proto_msgTypes[8].OneofWrappers = []interface{}{
+		(*State_File)(nil),
+		(*State_Kubernetes)(nil),
+		(*State_Object)(nil),
 	}
-	file_config_proto_msgTypes[1].OneofWrappers = []interface{}{
+	file_config_proto_msgTypes[14].OneofWrappers = []interface{}{
 		(*Source_Repo)(nil),
 		(*Source_IntermediateBundlesPath)(nil),
 	}
-	file_config_proto_msgTypes[3].OneofWrappers = []interface{}{
+	file_config_proto_msgTypes[17].OneofWrappers = []interface{}{
 		(*Target_Repo)(nil),
 		(*Target_IntermediateBundlesPath)(nil),
 	}
+	file_config_proto_msgTypes[30].OneofWrappers = []interface{}{
+		(*Transformation_AppVersion)(nil),
+		(*Transformation_AppVersionFromValues)(nil),
+	}
+	file_config_proto_msgTypes[31].OneofWrappers = []interface{}{
+		(*DependencyRule_Remove)(nil),
+		(*DependencyRule_Substitute_)(nil),
+	}
+	file_config_proto_msgTypes[40].OneofWrappers = []interface{}{
+		(*ValuesPatch_Set)(nil),
+		(*ValuesPatch_Delete)(nil),
+		(*ValuesPatch_Replace_)(nil),
+	}
+	file_config_proto_msgTypes[48].OneofWrappers = []interface{}{
+		(*Harbor_RetentionRule_LatestPushed)(nil),
+		(*Harbor_RetentionRule_PushedWithinDays)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_config_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   7,
+			NumEnums:      6,
+			NumMessages:   53,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
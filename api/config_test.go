@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/bitnami-labs/charts-syncer/api"
@@ -45,3 +46,648 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateBucketRepo(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		repo   *api.Repo
+		errMsg string
+	}{
+		{
+			desc: "valid",
+			repo: &api.Repo{Kind: api.Kind_S3, Url: "s3://my-bucket/charts", S3: &api.S3Options{Endpoint: "https://minio.example.com"}},
+		},
+		{
+			desc:   "not an s3 URL",
+			repo:   &api.Repo{Kind: api.Kind_S3, Url: "https://my-bucket/charts"},
+			errMsg: `"target.repo.url" "https://my-bucket/charts" should be like s3://bucket/prefix`,
+		},
+		{
+			desc:   "invalid endpoint",
+			repo:   &api.Repo{Kind: api.Kind_S3, Url: "s3://my-bucket", S3: &api.S3Options{Endpoint: "minio:9000"}},
+			errMsg: `"target.repo.s3" "endpoint" "minio:9000" must be an http(s) URL`,
+		},
+		{
+			desc:   "s3 options of another kind",
+			repo:   &api.Repo{Kind: api.Kind_CHARTMUSEUM, Url: "https://charts.example.com", S3: &api.S3Options{Region: "eu-west-1"}},
+			errMsg: `"target.repo" "s3" requires the S3 kind`,
+		},
+		{
+			desc: "valid gcs",
+			repo: &api.Repo{Kind: api.Kind_GCS, Url: "gs://my-bucket/charts", Gcs: &api.GCSOptions{Endpoint: "http://localhost:4443"}},
+		},
+		{
+			desc:   "not a gs URL",
+			repo:   &api.Repo{Kind: api.Kind_GCS, Url: "s3://my-bucket/charts"},
+			errMsg: `"target.repo.url" "s3://my-bucket/charts" should be like gs://bucket/prefix`,
+		},
+		{
+			desc:   "invalid gcs endpoint",
+			repo:   &api.Repo{Kind: api.Kind_GCS, Url: "gs://my-bucket", Gcs: &api.GCSOptions{Endpoint: "localhost:4443"}},
+			errMsg: `"target.repo.gcs" "endpoint" "localhost:4443" must be an http(s) URL`,
+		},
+		{
+			desc:   "gcs options of another kind",
+			repo:   &api.Repo{Kind: api.Kind_S3, Url: "s3://my-bucket", Gcs: &api.GCSOptions{}},
+			errMsg: `"target.repo" "gcs" requires the GCS kind`,
+		},
+		{
+			desc: "valid azblob",
+			repo: &api.Repo{Kind: api.Kind_AZBLOB, Url: "azblob://charts/stable", Azblob: &api.AZBlobOptions{Account: "myaccount"}},
+		},
+		{
+			desc:   "not an azblob URL",
+			repo:   &api.Repo{Kind: api.Kind_AZBLOB, Url: "https://myaccount.blob.core.windows.net/charts"},
+			errMsg: `"target.repo.url" "https://myaccount.blob.core.windows.net/charts" should be like azblob://container/prefix`,
+		},
+		{
+			desc:   "azblob options of another kind",
+			repo:   &api.Repo{Kind: api.Kind_GCS, Url: "gs://my-bucket", Azblob: &api.AZBlobOptions{}},
+			errMsg: `"target.repo" "azblob" requires the AZBLOB kind`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: tc.repo}},
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateGitRepo(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		repo   *api.Repo
+		errMsg string
+	}{
+		{
+			desc: "scp-like remote",
+			repo: &api.Repo{Kind: api.Kind_GIT, Url: "git@github.com:example/charts.git", Git: &api.GitOptions{PagesUrl: "https://example.github.io/charts"}},
+		},
+		{
+			desc:   "no remote",
+			repo:   &api.Repo{Kind: api.Kind_GIT},
+			errMsg: `"target.repo.url" should be the git remote of the repository`,
+		},
+		{
+			desc:   "invalid pages URL",
+			repo:   &api.Repo{Kind: api.Kind_GIT, Url: "https://github.com/example/charts.git", Git: &api.GitOptions{PagesUrl: "example.github.io"}},
+			errMsg: `"target.repo.git.pagesUrl" should be a valid URL: parse "example.github.io": invalid URI for request`,
+		},
+		{
+			desc:   "git options of another kind",
+			repo:   &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com", Git: &api.GitOptions{}},
+			errMsg: `"target.repo.git" requires a GIT "target.repo"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: tc.repo}},
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateLocalTarget(t *testing.T) {
+	config := &api.Config{
+		Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com"}}},
+		Target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, GenerateIndex: true}}},
+	}
+	want := `"target.repo.path" is required for a LOCAL "target.repo"`
+	if err := config.Validate(); err == nil || err.Error() != want {
+		t.Errorf("got: %v, want: %q", err, want)
+	}
+}
+
+func TestValidateRetry(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		retry  *api.RetryPolicy
+		errMsg string
+	}{
+		{desc: "valid policy", retry: &api.RetryPolicy{MaxAttempts: 5, InitialBackoff: "500ms", MaxBackoff: "1m", RetryOn: []uint32{429, 502}}},
+		{desc: "invalid backoff", retry: &api.RetryPolicy{MaxBackoff: "soon"}, errMsg: `"retry" "maxBackoff" "soon" is not a valid duration`},
+		{desc: "invalid status code", retry: &api.RetryPolicy{RetryOn: []uint32{5000}}, errMsg: `"retry" "retryOn" 5000 is not an HTTP status code`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target"}}},
+				Retry:  tc.retry,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		repo   *api.Repo
+		errMsg string
+	}{
+		{desc: "valid limit", repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target", RateLimit: &api.RateLimit{RequestsPerSecond: 0.5, Burst: 10}}},
+		{desc: "non positive rate", repo: &api.Repo{Kind: api.Kind_OCI, Url: "https://registry.example.com/charts", RateLimit: &api.RateLimit{Burst: 10}}, errMsg: `"target.repo.rateLimit" "requestsPerSecond" must be positive`},
+		{desc: "unsupported kind", repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "/charts", RateLimit: &api.RateLimit{RequestsPerSecond: 5}}, errMsg: `"target.repo.rateLimit" is not supported by LOCAL repositories`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: tc.repo}},
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSigningKey(t *testing.T) {
+	key := &api.SigningKey{Keyring: "/keys/secring.gpg", Name: "john@example.com"}
+	testCases := []struct {
+		desc      string
+		policy    api.ProvenancePolicy
+		key       *api.SigningKey
+		targetKey *api.SigningKey
+		errMsg    string
+	}{
+		{desc: "regenerate with a signing key", policy: api.ProvenancePolicy_PROVENANCE_REGENERATE, key: key},
+		{desc: "regenerate without signing key", policy: api.ProvenancePolicy_PROVENANCE_REGENERATE, errMsg: `"signingKey" "keyring" and "name" are required by the PROVENANCE_REGENERATE provenance policy`},
+		{desc: "regenerate with a target signing key", policy: api.ProvenancePolicy_PROVENANCE_REGENERATE, targetKey: key},
+		{desc: "target signing key without name", targetKey: &api.SigningKey{Keyring: "/keys/secring.gpg"}, errMsg: `"target.signingKey" "keyring" and "name" are required`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:           &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target:           &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target"}}, SigningKey: tc.targetKey},
+				ProvenancePolicy: tc.policy,
+				SigningKey:       tc.key,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateCosign(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		repo   *api.Repo
+		cosign *api.Cosign
+		errMsg string
+	}{
+		{desc: "keyless", repo: &api.Repo{Kind: api.Kind_OCI, Url: "https://registry.example.com/charts"}, cosign: &api.Cosign{}},
+		{desc: "keyed with a transparency log", repo: &api.Repo{Kind: api.Kind_OCI, Url: "https://registry.example.com/charts"}, cosign: &api.Cosign{Key: "cosign.key", RekorUrl: "https://rekor.example.com"}},
+		{desc: "not an OCI target", repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target"}, cosign: &api.Cosign{Key: "cosign.key"}, errMsg: `"target.cosign" is only supported by OCI target repositories`},
+		{desc: "invalid Fulcio URL", repo: &api.Repo{Kind: api.Kind_OCI, Url: "https://registry.example.com/charts"}, cosign: &api.Cosign{FulcioUrl: "fulcio.example.com"}, errMsg: `"target.cosign.fulcioUrl" "fulcio.example.com" must be an HTTP(S) URL`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: tc.repo}, Cosign: tc.cosign},
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSourceVerify(t *testing.T) {
+	verify := &api.SourceVerification{Digest: true}
+	repo := &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}, Verify: verify}
+	testCases := []struct {
+		desc      string
+		source    *api.Source
+		indexOnly bool
+		errMsg    string
+	}{
+		{desc: "source repository", source: repo},
+		{desc: "intermediate bundles", source: &api.Source{Spec: &api.Source_IntermediateBundlesPath{IntermediateBundlesPath: "bundles"}, Verify: verify}, errMsg: `"source.verify" requires "source.repo"`},
+		{desc: "index-only sync", source: repo, indexOnly: true, errMsg: `"source.verify" is not supported by index-only syncs, the charts are not fetched`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:    tc.source,
+				Target:    &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}},
+				IndexOnly: tc.indexOnly,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateForceOverwrite(t *testing.T) {
+	config := &api.Config{
+		Source:         &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+		Target:         &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}},
+		ForceOverwrite: true,
+	}
+	want := `"forceOverwrite" requires "compareDigest"`
+	if err := config.Validate(); err == nil || err.Error() != want {
+		t.Errorf("got: %v, want: %q", err, want)
+	}
+	config.CompareDigest = true
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateChartFilters(t *testing.T) {
+	config := &api.Config{
+		Source:       &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+		Target:       &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}},
+		ChartFilters: map[string]*api.ChartFilter{"nginx": {Versions: ">=13.0.0 <15"}},
+	}
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+	config.ChartFilters["nginx"].Versions = "13.x.y"
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), `"charts" versions "13.x.y" of "nginx" chart is not a valid semver constraint`) {
+		t.Errorf("got: %v, want an invalid constraint error", err)
+	}
+}
+
+func TestValidateChartNameFilters(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		chartsRegex   []string
+		excludeCharts []string
+		excludeRegex  []string
+		errMsg        string
+	}{
+		{desc: "valid filters", chartsRegex: []string{"postgresql.*"}, excludeCharts: []string{"*-operator"}, excludeRegex: []string{".*-(alpha|beta)"}},
+		{desc: "invalid include regular expression", chartsRegex: []string{"postgresql("}, errMsg: "\"chartsRegex\" \"postgresql(\" is not a valid regular expression: error parsing regexp: missing closing ): `postgresql(`"},
+		{desc: "invalid exclude regular expression", excludeRegex: []string{"*-operator"}, errMsg: "\"excludeChartsRegex\" \"*-operator\" is not a valid regular expression: error parsing regexp: missing argument to repetition operator: `*`"},
+		{desc: "invalid glob pattern", excludeCharts: []string{"[-operator"}, errMsg: `"excludeCharts" "[-operator" is not a valid glob pattern: syntax error in pattern`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:             &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target:             &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}},
+				ChartsRegex:        tc.chartsRegex,
+				ExcludeCharts:      tc.excludeCharts,
+				ExcludeChartsRegex: tc.excludeRegex,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	local := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}}
+	harbor := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_HARBOR, Url: "https://harbor.eu.example.com/chartrepo/library"}}}
+	testCases := []struct {
+		desc    string
+		target  *api.Target
+		targets []*api.Target
+		state   *api.State
+		errMsg  string
+	}{
+		{desc: "several targets", targets: []*api.Target{local, harbor}},
+		{desc: "target and targets", target: local, targets: []*api.Target{harbor}, errMsg: `"target" and "targets" cannot be set at the same time`},
+		{desc: "state", targets: []*api.Target{local, harbor}, state: &api.State{Backend: &api.State_File{File: "state.db"}}, errMsg: `"state", "flux" and "argocd" are not supported with "targets"`},
+		{desc: "invalid target", targets: []*api.Target{local, {Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL}}}}, errMsg: `"targets[1]": "target.repo.path" is required for a LOCAL "target.repo"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:  &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target:  tc.target,
+				Targets: tc.targets,
+				State:   tc.state,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSyncs(t *testing.T) {
+	source := &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}}
+	local := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}}
+	testCases := []struct {
+		desc   string
+		config *api.Config
+		errMsg string
+	}{
+		{desc: "several syncs", config: &api.Config{Syncs: []*api.Config{{Source: source, Target: local}, {Source: source, Targets: []*api.Target{local}}}}},
+		{desc: "top-level source", config: &api.Config{Source: source, Syncs: []*api.Config{{Target: local}}}, errMsg: `"source", "target", "targets", "state", "flux" and "argocd" must be set in the "syncs" entries`},
+		{desc: "nested syncs", config: &api.Config{Syncs: []*api.Config{{Source: source, Target: local, Syncs: []*api.Config{{}}}}}, errMsg: `"syncs[0]" cannot have "syncs"`},
+		{desc: "schedule in an entry", config: &api.Config{Syncs: []*api.Config{{Source: source, Target: local, Schedule: "@daily"}}}, errMsg: `"syncs[0]" cannot have "schedule", it should be set for the whole file`},
+		{desc: "invalid entry", config: &api.Config{Syncs: []*api.Config{{Source: source, Target: local}, {Source: source, Target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL}}}}}}, errMsg: `"syncs[1]": "target.repo.path" is required for a LOCAL "target.repo"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			errMsg := ""
+			if err := tc.config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestSyncConfigs(t *testing.T) {
+	c := &api.Config{
+		RelocateContainerImages: true,
+		SkipCharts:              []string{"redis"},
+		Syncs: []*api.Config{
+			{Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/a"}}}},
+			{Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/b"}}}, SkipCharts: []string{"etcd"}},
+		},
+	}
+	configs := c.SyncConfigs()
+	if len(configs) != 2 {
+		t.Fatalf("got %d configs, want 2", len(configs))
+	}
+	for i, want := range [][]string{{"redis"}, {"redis", "etcd"}} {
+		sc := configs[i]
+		if !sc.GetRelocateContainerImages() || len(sc.GetSyncs()) != 0 {
+			t.Errorf("syncs[%d] did not inherit the configuration: %v", i, sc)
+		}
+		if got := strings.Join(sc.GetSkipCharts(), ","); got != strings.Join(want, ",") {
+			t.Errorf("syncs[%d] got skipCharts: %s, want: %v", i, got, want)
+		}
+	}
+	if got := configs[1].GetSource().GetRepo().GetUrl(); got != "https://charts.example.com/b" {
+		t.Errorf("got source: %q", got)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		schedule string
+		errMsg   string
+	}{
+		{desc: "cron expression", schedule: "*/15 * * * *"},
+		{desc: "shorthand", schedule: "@hourly"},
+		{desc: "invalid expression", schedule: "every 15m", errMsg: `"schedule" should be a valid cron expression: "every 15m" cron expression, it should have 5 fields not valid`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:   &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target:   &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target"}}},
+				Schedule: tc.schedule,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateNotifications(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		notification *api.Notification
+		errMsg       string
+	}{
+		{desc: "slack webhook", notification: &api.Notification{Url: "https://hooks.slack.com/services/T0/B0/X", Format: api.NotificationFormat_NOTIFICATION_SLACK, OnlyFailures: true}},
+		{desc: "invalid url", notification: &api.Notification{Url: "hooks.slack.com/services/T0/B0/X"}, errMsg: `"notifications[0]" "url" "hooks.slack.com/services/T0/B0/X" must be an http(s) URL`},
+		{desc: "invalid timeout", notification: &api.Notification{Url: "https://ci.example.com/hooks", Timeout: "often"}, errMsg: `"notifications[0]" "timeout" "often" is not a valid duration`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:        &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target:        &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target"}}},
+				Notifications: []*api.Notification{tc.notification},
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateStateObject(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		object *api.Repo
+		errMsg string
+	}{
+		{desc: "valid object", object: &api.Repo{Kind: api.Kind_GCS, Url: "gs://bucket/charts-syncer/state.json"}},
+		{desc: "unsupported kind", object: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/state.json"}, errMsg: `"state.object" "kind" must be S3, GCS or AZBLOB`},
+		{desc: "no key", object: &api.Repo{Kind: api.Kind_S3, Url: "s3://bucket/charts-syncer/"}, errMsg: `"state.object.url" "s3://bucket/charts-syncer/" should include the key of the state object`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/target"}}},
+				State:  &api.State{Backend: &api.State_Object{Object: tc.object}},
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateSameRepo(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		source    *api.Repo
+		target    *api.Repo
+		shouldErr bool
+	}{
+		{
+			desc:      "same URL",
+			source:    &api.Repo{Url: "https://charts.example.com/myrepo", Kind: api.Kind_CHARTMUSEUM},
+			target:    &api.Repo{Url: "https://charts.example.com/myrepo", Kind: api.Kind_CHARTMUSEUM},
+			shouldErr: true,
+		},
+		{
+			desc:      "same URL after normalization",
+			source:    &api.Repo{Url: "https://Charts.Example.com:443/myrepo/", Kind: api.Kind_HELM},
+			target:    &api.Repo{Url: "https://charts.example.com/myrepo", Kind: api.Kind_CHARTMUSEUM},
+			shouldErr: true,
+		},
+		{
+			desc:      "same OCI repository with a different scheme",
+			source:    &api.Repo{Url: "http://registry.example.com/charts", Kind: api.Kind_OCI},
+			target:    &api.Repo{Url: "https://registry.example.com/charts", Kind: api.Kind_OCI},
+			shouldErr: true,
+		},
+		{
+			desc:      "same local directory",
+			source:    &api.Repo{Kind: api.Kind_LOCAL, Path: "/tmp/charts"},
+			target:    &api.Repo{Kind: api.Kind_LOCAL, Path: "/tmp/charts/"},
+			shouldErr: true,
+		},
+		{
+			desc: "target is a mirror of the source",
+			source: &api.Repo{
+				Url:     "https://charts.example.com/myrepo",
+				Kind:    api.Kind_HELM,
+				Mirrors: []string{"https://mirror.example.com/myrepo"},
+			},
+			target:    &api.Repo{Url: "https://mirror.example.com/myrepo/", Kind: api.Kind_CHARTMUSEUM},
+			shouldErr: true,
+		},
+		{
+			desc:   "different path",
+			source: &api.Repo{Url: "https://charts.example.com/myrepo", Kind: api.Kind_CHARTMUSEUM},
+			target: &api.Repo{Url: "https://charts.example.com/other", Kind: api.Kind_CHARTMUSEUM},
+		},
+		{
+			desc:   "different port",
+			source: &api.Repo{Url: "http://charts.example.com/myrepo", Kind: api.Kind_CHARTMUSEUM},
+			target: &api.Repo{Url: "http://charts.example.com:8080/myrepo", Kind: api.Kind_CHARTMUSEUM},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: tc.source}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: tc.target}},
+			}
+			err := config.Validate()
+			if tc.shouldErr && err == nil {
+				t.Errorf("expected an error")
+			}
+			if !tc.shouldErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateDependencyTrack(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sbom   *api.Sbom
+		errMsg string
+	}{
+		{desc: "gated", sbom: &api.Sbom{Enabled: true, DependencyTrack: &api.DependencyTrack{Url: "https://dtrack.example.com", SeverityThreshold: "HIGH", Timeout: "10m"}}},
+		{desc: "sbom disabled", sbom: &api.Sbom{DependencyTrack: &api.DependencyTrack{Url: "https://dtrack.example.com"}}, errMsg: `"sbom" "dependencyTrack" requires "sbom" "enabled"`},
+		{desc: "invalid url", sbom: &api.Sbom{Enabled: true, DependencyTrack: &api.DependencyTrack{Url: "dtrack.example.com"}}, errMsg: `"sbom" "dependencyTrack" "url" "dtrack.example.com" must be an http(s) URL`},
+		{desc: "invalid severity", sbom: &api.Sbom{Enabled: true, DependencyTrack: &api.DependencyTrack{Url: "https://dtrack.example.com", SeverityThreshold: "high"}}, errMsg: `"sbom" "dependencyTrack" "severityThreshold" "high" must be LOW, MEDIUM, HIGH or CRITICAL`},
+		{desc: "invalid timeout", sbom: &api.Sbom{Enabled: true, DependencyTrack: &api.DependencyTrack{Url: "https://dtrack.example.com", Timeout: "soon"}}, errMsg: `"sbom" "dependencyTrack" "timeout" "soon" is not a valid duration`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source: &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}},
+				Target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_OCI, Url: "https://registry.example.com/charts"}}},
+				Sbom:   tc.sbom,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateAdditionalRepos(t *testing.T) {
+	repo := &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/source"}}
+	testCases := []struct {
+		desc      string
+		source    *api.Source
+		indexOnly bool
+		errMsg    string
+	}{
+		{desc: "additional repository", source: &api.Source{Spec: repo, AdditionalRepos: []*api.Repo{{Kind: api.Kind_OCI, Url: "https://registry.example.com/charts", Priority: 1}}}},
+		{desc: "intermediate bundles", source: &api.Source{Spec: &api.Source_IntermediateBundlesPath{IntermediateBundlesPath: "bundles"}, AdditionalRepos: []*api.Repo{{Kind: api.Kind_HELM, Url: "https://charts.example.com/other"}}}, errMsg: `"source.additionalRepos" requires "source.repo"`},
+		{desc: "invalid URL", source: &api.Source{Spec: repo, AdditionalRepos: []*api.Repo{{Kind: api.Kind_HELM, Url: "charts"}}}, errMsg: `"source.additionalRepos[0].url" should be a valid URL: parse "charts": invalid URI for request`},
+		{desc: "target", source: &api.Source{Spec: repo, AdditionalRepos: []*api.Repo{{Kind: api.Kind_LOCAL, Url: "file:///charts", Path: "charts"}}}, errMsg: `"source.additionalRepos[0]" and "target.repo" point at the same repository`},
+		{desc: "index-only sync", source: &api.Source{Spec: repo, AdditionalRepos: []*api.Repo{{Kind: api.Kind_HELM, Url: "https://charts.example.com/other"}}}, indexOnly: true, errMsg: `"indexOnly" cannot be used with "source.additionalRepos"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config := &api.Config{
+				Source:    tc.source,
+				Target:    &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}},
+				IndexOnly: tc.indexOnly,
+			}
+			errMsg := ""
+			if err := config.Validate(); err != nil {
+				errMsg = err.Error()
+			}
+			if errMsg != tc.errMsg {
+				t.Errorf("got: %q, want: %q", errMsg, tc.errMsg)
+			}
+		})
+	}
+}
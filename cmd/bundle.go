@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/intermediate"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+)
+
+var (
+	bundleOutput     string
+	bundleSkipImages bool
+	bundleInput      string
+	bundleMaxSize    int64
+)
+
+var (
+	exportBundleExample = `
+  # Exports the charts of the source repository defined in the configuration file, with their container images
+  charts-syncer export-bundle --output charts-bundle.tgz
+
+  # Exports the chart packages only
+  charts-syncer export-bundle --output charts-bundle.tgz --skip-images`
+
+	importBundleExample = `
+  # Imports the charts of a bundle to the target repository defined in the configuration file
+  charts-syncer import-bundle --input charts-bundle.tgz`
+)
+
+// bundleDir creates the directory the content of a bundle is staged in
+func bundleDir() (string, error) {
+	if err := os.MkdirAll(syncWorkdir, 0755); err != nil {
+		return "", errors.Trace(err)
+	}
+	dir, err := ioutil.TempDir(syncWorkdir, "bundle")
+	return dir, errors.Annotatef(err, "creating bundle dir")
+}
+
+// writeBundleManifest writes the manifest and the checksums of an exported
+// bundle, which import-bundle verifies
+func writeBundleManifest(dir string) error {
+	if bundleSkipImages {
+		return errors.Trace(intermediate.WritePackagesManifest(dir, version))
+	}
+	bd, err := intermediate.New(dir, intermediate.WithToolchainVersion(version))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(bd.WriteManifest())
+}
+
+func newExportBundleCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "export-bundle",
+		Short:   "Exports the charts of the source repository to a single bundle file, to be imported in a disconnected network",
+		Example: exportBundleExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return errors.Trace(loadSingleConfig(&c, "export-bundle"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if c.GetSource().GetRepo() == nil {
+				return errors.Errorf(`export-bundle requires a "source.repo"`)
+			}
+			dir, err := bundleDir()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer os.RemoveAll(dir)
+
+			// The charts are exported as intermediate bundles shipping their
+			// container images, or as plain packages indexed like a LOCAL
+			// repository. They are transformed when they are imported.
+			target := &api.Target{Spec: &api.Target_IntermediateBundlesPath{IntermediateBundlesPath: dir}}
+			if bundleSkipImages {
+				target = &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: dir, GenerateIndex: true}}}
+			}
+
+			ctx, stop := signalContext()
+			defer stop()
+			s, err := syncer.New(c.GetSource(), target,
+				syncer.WithContext(ctx),
+				syncer.WithAutoDiscovery(true),
+				syncer.WithDryRun(rootDryRun),
+				syncer.WithFromDate(syncFromDate),
+				syncer.WithWorkdir(syncWorkdir),
+				syncer.WithInsecure(rootInsecure),
+				syncer.WithSkipDependencies(syncSkipDependencies),
+				syncer.WithLatestVersionOnly(syncLatestVersionOnly),
+				syncer.WithSkipCharts(c.SkipCharts),
+				syncer.WithChartFilters(c.GetChartFilters()),
+				syncer.WithSkipPrereleases(c.GetSkipPrereleases()),
+				syncer.WithLatestVersions(c.GetLatestVersions()),
+				syncer.WithChartsRegex(c.GetChartsRegex()),
+				syncer.WithExcludeCharts(c.GetExcludeCharts()),
+				syncer.WithExcludeChartsRegex(c.GetExcludeChartsRegex()),
+				syncer.WithConflictStrategy(c.GetConflictStrategy()),
+				syncer.WithStrict(syncStrict),
+				syncer.WithProvenancePolicy(c.GetProvenancePolicy()),
+				syncer.WithWorkers(workers(&c)),
+				syncer.WithSyncerVersion(version),
+				syncer.WithRunID(runID()),
+			)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if err := s.SyncPendingCharts(c.GetCharts()...); err != nil {
+				return errors.Trace(err)
+			}
+
+			if rootDryRun {
+				klog.Infof("dry-run: Writing bundle to %q", bundleOutput)
+				return nil
+			}
+			if err := writeBundleManifest(dir); err != nil {
+				return errors.Trace(err)
+			}
+			if err := utils.Tar(dir, "", bundleOutput, nil); err != nil {
+				os.Remove(bundleOutput)
+				return errors.Annotatef(err, "writing bundle to %q", bundleOutput)
+			}
+			klog.Infof("Bundle written to %q", bundleOutput)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&bundleOutput, "output", "o", "", "Bundle file to write")
+	cmd.MarkFlagRequired("output")
+	cmd.Flags().BoolVar(&bundleSkipImages, "skip-images", false, "Export the chart packages without their container images")
+	cmd.Flags().StringVar(&syncFromDate, "from-date", "", "Date you want to export charts from. Format: YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS or RFC3339, optionally followed by a time zone (i.e \"2020-05-15 Europe/Madrid\")")
+	cmd.Flags().StringVar(&syncWorkdir, "workdir", syncer.DefaultWorkdir(), "Working directory")
+	cmd.Flags().BoolVar(&syncSkipDependencies, "skip-dependencies", false, "Skip exporting chart dependencies")
+	cmd.Flags().BoolVar(&syncLatestVersionOnly, "latest-version-only", false, "Export only latest version of each chart")
+	cmd.Flags().BoolVar(&syncStrict, "strict", false, "Fail on conditions that are otherwise only warned about, like charts that could not be indexed")
+	cmd.Flags().IntVar(&syncWorkers, "workers", 0, "Number of charts exported concurrently. Overrides the \"workers\" property of the config file")
+
+	return cmd
+}
+
+func newImportBundleCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "import-bundle",
+		Short:   "Imports the charts of a bundle file to the target repository",
+		Example: importBundleExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return errors.Trace(loadSingleConfig(&c, "import-bundle"))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := bundleDir()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer os.RemoveAll(dir)
+
+			if bundleMaxSize > 0 {
+				utils.UntarMaxSize = bundleMaxSize
+			}
+			klog.Infof("Extracting %q bundle...", bundleInput)
+			if err := utils.Untar(bundleInput, dir); err != nil {
+				return errors.Annotatef(err, "extracting %q bundle", bundleInput)
+			}
+			// The checksums of every file are verified before anything is
+			// pushed, so truncated or tampered bundles are rejected
+			m, err := intermediate.ReadManifest(dir)
+			if errors.IsNotFound(err) {
+				return errors.Errorf("%q bundle has no %s: it may be truncated, or exported by an older charts-syncer version", bundleInput, intermediate.ManifestFile)
+			} else if err != nil {
+				return errors.Annotatef(err, "verifying %q bundle", bundleInput)
+			}
+			source := &api.Source{Spec: &api.Source_IntermediateBundlesPath{IntermediateBundlesPath: dir}}
+			if m.Packages {
+				source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: dir}}}
+			}
+
+			ctx, stop := signalContext()
+			defer stop()
+			return errors.Trace(runSync(ctx, &c, source))
+		},
+	}
+
+	cmd.Flags().StringVarP(&bundleInput, "input", "i", "", "Bundle file to import")
+	cmd.MarkFlagRequired("input")
+	cmd.Flags().Int64Var(&bundleMaxSize, "max-size", utils.UntarMaxSize, "Maximum size in bytes of the extracted bundle")
+	cmd.Flags().StringVar(&syncWorkdir, "workdir", syncer.DefaultWorkdir(), "Working directory")
+	cmd.Flags().BoolVar(&syncStrict, "strict", false, "Fail on conditions that are otherwise only warned about, like charts that could not be indexed")
+	cmd.Flags().BoolVar(&syncRollback, "rollback", false, "Delete a pushed chart from the target if its provenance file or attestation cannot be pushed")
+	cmd.Flags().BoolVar(&syncVerifyUploads, "verify-uploads", false, "Read every pushed chart back from the target and fail it if its digest differs from the uploaded package")
+	cmd.Flags().StringVar(&syncRunID, "run-id", "", "Identifier of the sync run recorded in the chart attestations. Defaults to the start time of the run")
+	cmd.Flags().IntVar(&syncWorkers, "workers", 0, "Number of charts imported concurrently. Overrides the \"workers\" property of the config file")
+
+	return cmd
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/intermediate"
+)
+
+// bundleConfig writes a config file syncing a local repository with the
+// apache chart to an empty local repository
+func bundleConfig(t *testing.T) (string, string) {
+	t.Helper()
+	src, dst := t.TempDir(), t.TempDir()
+	data, err := ioutil.ReadFile("../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "apache-7.3.15.tgz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	content := fmt.Sprintf(`source:
+  repo:
+    kind: LOCAL
+    url: file://%s
+    path: %s
+target:
+  containerRegistry: test.registry.io
+  containerRepository: user/demo
+  repo:
+    kind: LOCAL
+    path: %s
+`, src, src, dst)
+	if err := ioutil.WriteFile(config, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return config, dst
+}
+
+// runCmd runs charts-syncer with the given arguments
+func runCmd(args ...string) error {
+	cmd := New()
+	cmd.SetArgs(args)
+	cmd.SetOut(ioutil.Discard)
+	cmd.SetErr(ioutil.Discard)
+	return cmd.Execute()
+}
+
+// retar extracts a bundle, applies a change to its files and packs it again
+func retar(t *testing.T, bundle string, change func(dir string)) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := utils.Untar(bundle, dir); err != nil {
+		t.Fatal(err)
+	}
+	change(dir)
+	out := filepath.Join(t.TempDir(), "bundle.tgz")
+	if err := utils.Tar(dir, "", out, nil); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestBundleSkipImages(t *testing.T) {
+	config, dst := bundleConfig(t)
+	workdir := t.TempDir()
+	bundle := filepath.Join(t.TempDir(), "bundle.tgz")
+	if err := runCmd("export-bundle", "--config", config, "--workdir", workdir, "--output", bundle, "--skip-images"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bundles of plain packages have a manifest and checksums too
+	dir := t.TempDir()
+	if err := utils.Untar(bundle, dir); err != nil {
+		t.Fatal(err)
+	}
+	m, err := intermediate.ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Packages || len(m.Charts) != 0 {
+		t.Errorf("got manifest: %+v, want a packages manifest", m)
+	}
+	sums, err := ioutil.ReadFile(filepath.Join(dir, intermediate.ChecksumsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{"apache-7.3.15.tgz", "index.yaml", intermediate.ManifestFile} {
+		if !strings.Contains(string(sums), "  "+f+"\n") {
+			t.Errorf("%q has no checksum in:\n%s", f, sums)
+		}
+	}
+
+	// Bundles without manifest, tampered or with unlisted files are refused
+	tests := []struct {
+		name   string
+		change func(dir string)
+		want   string
+	}{
+		{"no manifest", func(dir string) {
+			os.Remove(filepath.Join(dir, intermediate.ManifestFile))
+			os.Remove(filepath.Join(dir, intermediate.ChecksumsFile))
+		}, "has no manifest.json"},
+		{"no checksums", func(dir string) {
+			os.Remove(filepath.Join(dir, intermediate.ChecksumsFile))
+		}, `"SHA256SUMS" is missing`},
+		{"tampered", func(dir string) {
+			ioutil.WriteFile(filepath.Join(dir, "apache-7.3.15.tgz"), []byte("tampered"), 0644)
+		}, `"apache-7.3.15.tgz" checksum mismatch`},
+		{"unlisted", func(dir string) {
+			ioutil.WriteFile(filepath.Join(dir, "nginx-1.0.0.tgz"), []byte("unlisted"), 0644)
+		}, `"nginx-1.0.0.tgz" is not listed`},
+	}
+	for _, tc := range tests {
+		input := retar(t, bundle, tc.change)
+		err := runCmd("import-bundle", "--config", config, "--workdir", workdir, "--input", input)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got: %v, want an error containing %q", tc.name, err, tc.want)
+		}
+		if ok, _ := utils.FileExists(filepath.Join(dst, "apache-7.3.15.tgz")); ok {
+			t.Fatalf("%s: apache-7.3.15 was imported from a refused bundle", tc.name)
+		}
+	}
+
+	if err := runCmd("import-bundle", "--config", config, "--workdir", workdir, "--input", bundle); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := utils.FileExists(filepath.Join(dst, "apache-7.3.15.tgz")); err != nil || !ok {
+		t.Errorf("got: %v, %v, want apache-7.3.15 imported to the target", ok, err)
+	}
+}
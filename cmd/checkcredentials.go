@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/credentials"
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkCredentialsWrite bool
+)
+
+var (
+	checkCredentialsExample = `
+  # Checks that the repositories of the configuration file can be read
+  charts-syncer check-credentials
+
+  # Checks that the charts and images can also be pushed to the target
+  charts-syncer check-credentials --write`
+)
+
+func newCheckCredentialsCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "check-credentials",
+		Short:   "Checks the credentials of the configured repositories",
+		Example: checkCredentialsExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return errors.Trace(loadConfig(&c))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := checkCredentials(&c)
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tPROBE\tAUTH\tRESULT\tDETAIL")
+			var failed int
+			for _, r := range results {
+				result := "OK"
+				if !r.OK {
+					result = "FAILED"
+					failed++
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Name, r.Probe, r.Auth, result, r.Detail)
+			}
+			if err := w.Flush(); err != nil {
+				return errors.Trace(err)
+			}
+			if failed > 0 {
+				return errors.Errorf("%d of %d probes failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&checkCredentialsWrite, "write", false, "Also check that the target can be pushed to. The probes do not change the target")
+
+	return cmd
+}
+
+// checkCredentials probes the repositories, registries and directories of a
+// configuration, or of each of its syncs entries. Sources are only read.
+func checkCredentials(c *api.Config) []credentials.Result {
+	if len(c.GetSyncs()) == 0 {
+		return checkConfigCredentials(c, "", credentialsOrigin)
+	}
+	// The credentials of the syncs entries are only read from the config file
+	fromFile := func(string, ...string) string { return "config file" }
+	var results []credentials.Result
+	for i, sc := range c.SyncConfigs() {
+		results = append(results, checkConfigCredentials(sc, fmt.Sprintf("syncs[%d].", i), fromFile)...)
+	}
+	return results
+}
+
+// checkConfigCredentials probes the source and targets of a configuration,
+// whose result names are prefixed by prefix. origin describes where the
+// credentials of a configuration key are read from.
+func checkConfigCredentials(c *api.Config, prefix string, origin func(key string, envFallbacks ...string) string) []credentials.Result {
+	insecure := credentials.WithInsecure(rootInsecure)
+
+	var results []credentials.Result
+	source := c.GetSource()
+	if repo := source.GetRepo(); repo != nil {
+		repoOrigin := credentials.WithCredentialsOrigin(origin("source.repo.auth.username", "SOURCE_AUTH_USERNAME"))
+		results = append(results, credentials.CheckRepo(prefix+"source.repo", repo, insecure, repoOrigin)...)
+	}
+	// The credentials of the additional repositories are only read from the
+	// config file
+	for i, repo := range source.GetAdditionalRepos() {
+		repoOrigin := credentials.WithCredentialsOrigin("config file")
+		results = append(results, credentials.CheckRepo(fmt.Sprintf("%ssource.additionalRepos[%d]", prefix, i), repo, insecure, repoOrigin)...)
+	}
+	if dir := source.GetIntermediateBundlesPath(); dir != "" {
+		results = append(results, credentials.CheckDir(prefix+"source.intermediateBundlesPath", dir)...)
+	}
+	if auth := source.GetContainers().GetAuth(); auth.GetRegistry() != "" {
+		containersOrigin := credentials.WithCredentialsOrigin(origin("source.containers.auth.username"))
+		results = append(results, credentials.CheckRegistry(prefix+"source.containers", auth.GetRegistry(), "", auth.GetUsername(), auth.GetPassword(), insecure, containersOrigin)...)
+	}
+
+	if target := c.GetTarget(); target != nil {
+		results = append(results, checkTargetCredentials(c, prefix+"target", target, origin("target.repo.auth.username", "TARGET_AUTH_USERNAME"), origin("target.containers.auth.username"))...)
+	}
+	// The credentials of several targets are only read from the config file
+	for i, target := range c.GetTargets() {
+		results = append(results, checkTargetCredentials(c, fmt.Sprintf("%stargets[%d]", prefix, i), target, "config file", "config file")...)
+	}
+	return results
+}
+
+// checkTargetCredentials probes the repository, registry or directory of a
+// target
+func checkTargetCredentials(c *api.Config, name string, target *api.Target, repoOrigin, containersOrigin string) []credentials.Result {
+	insecure := credentials.WithInsecure(rootInsecure)
+	write := credentials.WithWrite(checkCredentialsWrite)
+
+	var results []credentials.Result
+	if repo := target.GetRepo(); repo != nil {
+		results = append(results, credentials.CheckRepo(name+".repo", repo, insecure, write, credentials.WithCredentialsOrigin(repoOrigin))...)
+	}
+	if dir := target.GetIntermediateBundlesPath(); dir != "" {
+		results = append(results, credentials.CheckDir(name+".intermediateBundlesPath", dir, write)...)
+	}
+	if registry := target.GetContainerRegistry(); registry != "" && (c.GetRelocateContainerImages() || c.GetSource().GetIntermediateBundlesPath() != "") {
+		auth := target.GetContainers().GetAuth()
+		results = append(results, credentials.CheckRegistry(name+".containers", registry, target.GetContainerRepository(), auth.GetUsername(), auth.GetPassword(), insecure, write, credentials.WithCredentialsOrigin(containersOrigin))...)
+	}
+	return results
+}
+
+// credentialsOrigin describes where the username of a configuration key is
+// read from
+func credentialsOrigin(key string, envFallbacks ...string) string {
+	for _, env := range append([]string{strings.ToUpper(strings.ReplaceAll(key, ".", "_"))}, envFallbacks...) {
+		if os.Getenv(env) != "" {
+			return env
+		}
+	}
+	return "config file"
+}
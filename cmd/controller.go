@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/controller"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var (
+	controllerNamespace  string
+	controllerKubeconfig string
+)
+
+var (
+	controllerExample = `
+  # Reconciles the ChartSync resources of all the namespaces
+  charts-syncer controller
+
+  # Reconciles the ChartSync resources of the "mirrors" namespace
+  charts-syncer controller --namespace mirrors`
+)
+
+func newControllerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "controller",
+		Short:   "Syncs the charts described by the ChartSync resources of a Kubernetes cluster",
+		Example: controllerExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules := clientcmd.NewDefaultClientConfigLoadingRules()
+			rules.ExplicitPath = controllerKubeconfig
+			restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+			if err != nil {
+				return errors.Annotatef(err, "loading kubeconfig")
+			}
+			client, err := dynamic.NewForConfig(restConfig)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			kube, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			ctx, stop := signalContext()
+			defer stop()
+			return errors.Trace(controller.New(client, kube, controllerNamespace, syncChartSync).Run(ctx))
+		},
+	}
+
+	cmd.Flags().StringVar(&controllerNamespace, "namespace", "", "Namespace of the ChartSync resources. Defaults to all the namespaces")
+	cmd.Flags().StringVar(&controllerKubeconfig, "kubeconfig", "", "Kubeconfig file. Defaults to $KUBECONFIG, ~/.kube/config or the in-cluster configuration")
+	cmd.Flags().StringVar(&syncWorkdir, "workdir", syncer.DefaultWorkdir(), "Working directory, shared by the ChartSync resources")
+	cmd.Flags().IntVar(&syncWorkers, "workers", 0, "Number of charts synced concurrently. Overrides the \"workers\" property of the ChartSync resources")
+	cmd.Flags().BoolVar(&syncStrict, "strict", false, "Fail on conditions that are otherwise only warned about, like charts that could not be indexed")
+
+	return cmd
+}
+
+// syncChartSync syncs the charts of the configuration of a ChartSync
+func syncChartSync(ctx context.Context, c *api.Config) (*state.Run, []syncer.ChartResult, error) {
+	utils.SetRetryPolicy(utils.NewRetryPolicy(c.GetRetry()))
+	s, err := syncCharts(ctx, c, c.GetSource())
+	if s == nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return s.LastRun(), s.LastResults(), errors.Trace(err)
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/klog"
+)
+
+var (
+	diffOutputFormat string
+)
+
+var (
+	diffExample = `
+  # Compares the source and the target repositories of the configuration file
+  charts-syncer diff
+
+  # Prints the differences as JSON, for instance to process them in a CI job
+  charts-syncer diff -c config.yaml --output json`
+)
+
+// The formats of the differences printed by --dry-run and diff
+const (
+	diffTable = "table"
+	diffJSON  = "json"
+)
+
+// shortDigestLength is the length of the digests printed in the tables
+const shortDigestLength = 12
+
+// diffOutput is the JSON document of the differences between a source and a
+// target
+type diffOutput struct {
+	Source  string             `json:"source"`
+	Target  string             `json:"target"`
+	Changes []syncer.DiffEntry `json:"changes"`
+}
+
+func newDiffCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Compares the source and the target repositories",
+		Long:    "Compares the chart names, versions and digests of the source and the target repositories without syncing anything. It exits with a non-zero code if they differ.",
+		Example: diffExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if diffOutputFormat != diffTable && diffOutputFormat != diffJSON {
+				return errors.NotValidf("%q output, it should be %q or %q", diffOutputFormat, diffTable, diffJSON)
+			}
+			return errors.Trace(loadConfig(&c))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext()
+			defer stop()
+			changes, err := diffSyncs(ctx, cmd.OutOrStdout(), &c)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if changes > 0 {
+				return errors.Errorf("the source and the target differ: %d changes", changes)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&diffOutputFormat, "output", "o", diffTable, fmt.Sprintf("Format of the differences, %q or %q", diffTable, diffJSON))
+	cmd.Flags().StringVar(&syncFromDate, "from-date", "", "Date you want to compare charts from. Format: YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS or RFC3339, optionally followed by a time zone (i.e \"2020-05-15 Europe/Madrid\")")
+	cmd.Flags().StringVar(&syncWorkdir, "workdir", syncer.DefaultWorkdir(), "Working directory")
+	cmd.Flags().BoolVar(&syncLatestVersionOnly, "latest-version-only", false, "Compare only latest version of each chart")
+	cmd.Flags().BoolVar(&syncStrict, "strict", false, "Fail on conditions that are otherwise only warned about, like charts that could not be indexed")
+
+	return cmd
+}
+
+// diffSyncs prints the differences between the source and every target of a
+// configuration, or of each of its syncs entries, returning the number of
+// changes found
+func diffSyncs(ctx context.Context, out io.Writer, c *api.Config) (int, error) {
+	changes := 0
+	err := forEachTarget(ctx, c, func(tc *api.Config) error {
+		diff, err := diffTarget(ctx, tc, tc.GetSource())
+		if diff != nil {
+			changes += len(diff)
+			if perr := printDiff(out, diffOutputFormat, tc.GetSource(), tc.GetTarget(), diff); perr != nil {
+				return errors.Trace(perr)
+			}
+		}
+		return errors.Trace(err)
+	})
+	return changes, errors.Trace(err)
+}
+
+// forEachTarget calls fn with the configuration of every target of a
+// configuration, or of each of its syncs entries, one after the other. A
+// failed target does not prevent the others from being processed.
+func forEachTarget(ctx context.Context, c *api.Config, fn func(tc *api.Config) error) error {
+	var errs error
+	for i, sc := range c.SyncConfigs() {
+		if len(c.GetSyncs()) > 0 {
+			utils.SetRetryPolicy(utils.NewRetryPolicy(sc.GetRetry()))
+		}
+		targets := sc.GetTargets()
+		if len(targets) == 0 {
+			targets = []*api.Target{sc.GetTarget()}
+		}
+		for j, target := range targets {
+			if ctx.Err() != nil {
+				return errors.Trace(ctx.Err())
+			}
+			tc := proto.Clone(sc).(*api.Config)
+			tc.Target, tc.Targets = target, nil
+			if err := fn(tc); err != nil {
+				name := fmt.Sprintf("targets[%d]", j)
+				if len(c.GetSyncs()) > 0 {
+					name = fmt.Sprintf("syncs[%d].%s", i, name)
+				}
+				klog.Errorf("unable to process %s: %v", name, err)
+				errs = multierror.Append(errs, errors.Annotate(err, name))
+			}
+		}
+	}
+	return errors.Trace(errs)
+}
+
+// diffTarget compares the charts of the given source with the target of a
+// configuration. The syncer runs in dry-run mode, so nothing is written to
+// the target or the state store.
+func diffTarget(ctx context.Context, c *api.Config, source *api.Source) ([]syncer.DiffEntry, error) {
+	syncerOptions := append(syncerOptions(ctx, c), syncer.WithDryRun(true), syncer.WithDigestComparison(true))
+	if c.GetState() != nil {
+		store, err := state.New(c.GetState(), rootInsecure)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer store.Close()
+		syncerOptions = append(syncerOptions, syncer.WithStateStore(store))
+	}
+	s, err := syncer.New(source, c.GetTarget(), syncerOptions...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	diff, err := s.Diff(c.GetCharts()...)
+	return diff, errors.Trace(err)
+}
+
+// printDiff writes the differences between a source and a target, as a table
+// or as a JSON document per line
+func printDiff(out io.Writer, format string, source *api.Source, target *api.Target, diff []syncer.DiffEntry) error {
+	if format == diffJSON {
+		data, err := json.Marshal(diffOutput{Source: describeSource(source), Target: describeTarget(target), Changes: diff})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return errors.Trace(err)
+	}
+
+	fmt.Fprintf(out, "Differences between %s and %s:\n", describeSource(source), describeTarget(target))
+	if len(diff) == 0 {
+		_, err := fmt.Fprintln(out, "No differences found")
+		return errors.Trace(err)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tCHANGE\tSOURCE DIGEST\tTARGET DIGEST")
+	counts := map[string]int{}
+	for _, e := range diff {
+		counts[e.Change]++
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Name, e.Version, e.Change, shortDigest(e.SourceDigest), shortDigest(e.TargetDigest))
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := fmt.Fprintf(out, "%d only in the source, %d only in the target, %d with a different digest\n", counts[syncer.DiffSourceOnly], counts[syncer.DiffTargetOnly], counts[syncer.DiffDigestMismatch])
+	return errors.Trace(err)
+}
+
+// shortDigest returns the beginning of a digest
+func shortDigest(digest string) string {
+	if len(digest) > shortDigestLength {
+		return digest[:shortDigestLength]
+	}
+	return digest
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneKeep []string
+)
+
+var (
+	pruneExample = `
+  # Lists the chart versions of the target repositories no longer in the source
+  charts-syncer prune --dry-run
+
+  # Deletes them, except any version of the apache chart and the 1.x versions of the mysql chart
+  charts-syncer prune --keep apache --keep 'mysql:1.*'`
+)
+
+func newPruneCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Deletes the chart versions of the target repository no longer in the source",
+		Long:    "Deletes the chart versions of the target repository that are no longer in the source, or that no longer match the chart filters of the config file. The versions matching a --keep pattern are never deleted.",
+		Example: pruneExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return errors.Trace(loadConfig(&c))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signalContext()
+			defer stop()
+			return errors.Trace(forEachTarget(ctx, &c, func(tc *api.Config) error {
+				entries, err := pruneTarget(ctx, tc)
+				if entries != nil {
+					if perr := printPruneEntries(cmd.OutOrStdout(), tc.GetTarget(), entries); perr != nil {
+						return errors.Trace(perr)
+					}
+				}
+				return errors.Trace(err)
+			}))
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&pruneKeep, "keep", nil, "Never delete the chart versions matching this pattern, a chart name or name:version glob like \"mysql:1.*\". Can be repeated")
+
+	return cmd
+}
+
+// pruneTarget deletes the chart versions of the target of a configuration no
+// longer in its source, and their records in the state store
+func pruneTarget(ctx context.Context, c *api.Config) ([]syncer.PruneEntry, error) {
+	syncerOptions := syncerOptions(ctx, c)
+	if c.GetState() != nil {
+		store, err := state.New(c.GetState(), rootInsecure)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer store.Close()
+		syncerOptions = append(syncerOptions, syncer.WithStateStore(store))
+	}
+	s, err := syncer.New(c.GetSource(), c.GetTarget(), syncerOptions...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	entries, err := s.Prune(pruneKeep, c.GetCharts()...)
+	return entries, errors.Trace(err)
+}
+
+// printPruneEntries writes the chart versions pruned from a target
+func printPruneEntries(out io.Writer, target *api.Target, entries []syncer.PruneEntry) error {
+	fmt.Fprintf(out, "Chart versions to prune from %s:\n", describeTarget(target))
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(out, "Nothing to prune")
+		return errors.Trace(err)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tREASON\tACTION")
+	for _, e := range entries {
+		action := "DELETED"
+		switch {
+		case e.Kept:
+			action = "KEPT"
+		case e.Error != "":
+			action = "FAILED: " + e.Error
+		case rootDryRun:
+			action = "WOULD DELETE"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Name, e.Version, e.Reason, action)
+	}
+	return errors.Trace(w.Flush())
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+)
+
+// syncReport is the report of --report-file, written after every run
+type syncReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	DryRun      bool      `json:"dryRun"`
+	// Totals counts the chart versions of all the syncs by action
+	Totals map[string]int `json:"totals"`
+	Syncs  []targetReport `json:"syncs"`
+}
+
+// targetReport is the outcome of the sync of a source to a target
+type targetReport struct {
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+	RunID     string    `json:"runId,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	// Duration is the time spent by the sync, in seconds
+	Duration float64 `json:"duration"`
+	// Throttled is the number of requests throttled by the remote servers,
+	// and ThrottleWait the time spent waiting for them, in seconds
+	Throttled    int                  `json:"throttled,omitempty"`
+	ThrottleWait float64              `json:"throttleWait,omitempty"`
+	Error        string               `json:"error,omitempty"`
+	Charts       []syncer.ChartReport `json:"charts"`
+}
+
+// reports collects the outcome of the syncs of a run, if --report-file is
+// set
+var reports struct {
+	mu    sync.Mutex
+	syncs []targetReport
+}
+
+// addReport records the outcome of the sync of a source to a target
+func addReport(source *api.Source, target *api.Target, s *syncer.Syncer, err error) {
+	if syncReportFile == "" {
+		return
+	}
+	r := targetReport{Source: describeSource(source), Target: describeTarget(target), Charts: []syncer.ChartReport{}}
+	if s != nil {
+		if run := s.LastRun(); run != nil {
+			r.RunID, r.StartedAt, r.Duration = run.ID, run.StartedAt, run.Duration.Seconds()
+			r.Throttled, r.ThrottleWait = run.Throttled, run.ThrottleWait.Seconds()
+		}
+		if charts := s.LastReport(); charts != nil {
+			r.Charts = charts
+		}
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	reports.mu.Lock()
+	defer reports.mu.Unlock()
+	reports.syncs = append(reports.syncs, r)
+}
+
+// reported runs the syncs of a run, then writes the report of their outcome
+// to --report-file, even if the run failed or was interrupted
+func reported(run func() error) error {
+	if syncReportFile == "" {
+		return run()
+	}
+	reports.mu.Lock()
+	reports.syncs = nil
+	reports.mu.Unlock()
+
+	err := run()
+	if werr := writeReport(syncReportFile); werr != nil {
+		klog.Errorf("unable to write the sync report: %v", werr)
+		if err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// writeReport writes the collected report as YAML if the file has a .yaml or
+// .yml extension, or as JSON otherwise
+func writeReport(file string) error {
+	reports.mu.Lock()
+	report := syncReport{
+		GeneratedAt: time.Now().UTC(),
+		DryRun:      rootDryRun,
+		Totals:      map[string]int{},
+		Syncs:       append([]targetReport{}, reports.syncs...),
+	}
+	reports.mu.Unlock()
+	for _, s := range report.Syncs {
+		for _, ch := range s.Charts {
+			report.Totals[ch.Action]++
+		}
+	}
+
+	var data []byte
+	var err error
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		data, err = yaml.Marshal(report)
+	default:
+		data, err = json.MarshalIndent(report, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if dir := filepath.Dir(file); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return errors.Annotatef(err, "writing %q report", file)
+	}
+	klog.Infof("Sync report written to %q", file)
+	return nil
+}
@@ -3,6 +3,8 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/bitnami-labs/charts-syncer/internal/logging"
+	"github.com/juju/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +17,11 @@ var (
 
 Find more information at: https://github.com/bitnami-labs/charts-syncer`
 
-	rootConfig   string
-	rootDryRun   bool
-	rootInsecure bool
+	rootConfig       string
+	rootDryRun       bool
+	rootDryRunOutput string
+	rootInsecure     bool
+	rootLogFormat    string
 )
 
 func newRootCmd() *cobra.Command {
@@ -27,15 +31,32 @@ func newRootCmd() *cobra.Command {
 		Long:  rootUsage,
 		// Do not show the Usage page on every raised error
 		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if rootDryRunOutput != diffTable && rootDryRunOutput != diffJSON {
+				return errors.NotValidf("%q dry-run output, it should be %q or %q", rootDryRunOutput, diffTable, diffJSON)
+			}
+			return errors.Trace(logging.SetFormat(rootLogFormat))
+		},
 	}
 
 	cmd.PersistentFlags().BoolVar(&rootDryRun, "dry-run", false, "Only shows the charts pending to be synced without syncing them")
+	cmd.PersistentFlags().StringVar(&rootDryRunOutput, "dry-run-output", diffTable, fmt.Sprintf("Format of the differences between the source and the target printed by --dry-run, %q or %q", diffTable, diffJSON))
 	cmd.PersistentFlags().StringVarP(&rootConfig, "config", "c", "", fmt.Sprintf("Config file. Defaults to ./%s or $HOME/%s)", defaultCfgFile, defaultCfgFile))
 	cmd.PersistentFlags().BoolVar(&rootInsecure, "insecure", false, "Allow insecure SSL connections")
+	cmd.PersistentFlags().StringVar(&rootLogFormat, "log-format", logging.FormatText, fmt.Sprintf("Format of the logs, %q or %q. JSON logs have one entry per line, with structured fields like chart, version, sourceRepo, targetRepo, duration and error", logging.FormatText, logging.FormatJSON))
 
 	// Add subcommands
 	cmd.AddCommand(
 		newSyncCmd(),
+		newStatusCmd(),
+		newStatsCmd(),
+		newDiffCmd(),
+		newVerifyCmd(),
+		newPruneCmd(),
+		newCheckCredentialsCmd(),
+		newExportBundleCmd(),
+		newImportBundleCmd(),
+		newControllerCmd(),
 		newVersionCmd(),
 	)
 
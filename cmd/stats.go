@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsLast int
+)
+
+var (
+	statsExample = `
+  # Summarizes the sync runs recorded in the state store of the configuration file
+  charts-syncer stats
+
+  # Summarizes the last 10 runs
+  charts-syncer stats --last 10`
+)
+
+func newStatsCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "stats",
+		Short:   "Summarizes the sync runs recorded in the state store",
+		Example: statsExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadSingleConfig(&c, "stats"); err != nil {
+				return errors.Trace(err)
+			}
+			if c.GetState() == nil {
+				return errors.New("no state store defined in config file")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := state.New(c.GetState(), rootInsecure)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer store.Close()
+			runs, err := store.ListRuns()
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if statsLast > 0 && len(runs) > statsLast {
+				runs = runs[len(runs)-statsLast:]
+			}
+			if len(runs) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No sync run recorded")
+				return nil
+			}
+			st := state.Summarize(runs)
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "Runs:\t%d (%s to %s)\n", st.Runs, st.First.StartedAt.Format(time.RFC3339), st.Last.StartedAt.Format(time.RFC3339))
+			fmt.Fprintf(w, "Runs with errors:\t%d\n", st.FailedRuns)
+			fmt.Fprintf(w, "Interrupted runs:\t%d\n", st.InterruptedRuns)
+			fmt.Fprintf(w, "Charts synced:\t%d\n", st.Synced)
+			fmt.Fprintf(w, "Charts failed:\t%d\n", st.Failed)
+			fmt.Fprintf(w, "Average duration:\t%s\n", st.AverageDuration.Round(time.Second))
+			fmt.Fprintf(w, "Longest duration:\t%s\n", st.MaxDuration.Round(time.Second))
+			fmt.Fprintf(w, "Last run:\t%s, %d synced, %d failed\n", st.Last.Duration.Round(time.Second), st.Last.Synced, st.Last.Failed)
+			for _, k := range state.SortedKeys(st.Kinds) {
+				fmt.Fprintf(w, "Runs %s:\t%d\n", k, st.Kinds[k])
+			}
+			for _, class := range state.SortedKeys(st.ErrorClasses) {
+				fmt.Fprintf(w, "Errors %s:\t%d\n", class, st.ErrorClasses[class])
+			}
+			return errors.Trace(w.Flush())
+		},
+	}
+
+	cmd.Flags().IntVar(&statsLast, "last", 0, "Only summarize the given number of most recent runs")
+
+	return cmd
+}
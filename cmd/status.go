@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusExample = `
+  # Lists the charts recorded in the state store of the configuration file
+  charts-syncer status`
+)
+
+func newStatusCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "status",
+		Short:   "Lists the charts recorded in the state store",
+		Example: statusExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadSingleConfig(&c, "status"); err != nil {
+				return errors.Trace(err)
+			}
+			if c.GetState() == nil {
+				return errors.New("no state store defined in config file")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := state.New(c.GetState(), rootInsecure)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			defer store.Close()
+			records, err := store.List()
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVERSION\tSOURCE\tTRANSFORMATIONS\tSYNCED AT")
+			for _, r := range records {
+				fmt.Fprintf(w, "%s\t%s\t%s-%s\t%d\t%s\n", r.Name, r.Version, r.SourceName, r.SourceVersion, len(r.Transformations), r.SyncedAt.Format(time.RFC3339))
+			}
+			return errors.Trace(w.Flush())
+		},
+	}
+
+	return cmd
+}
@@ -1,13 +1,31 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
 	"github.com/bitnami-labs/charts-syncer/api"
 	"github.com/bitnami-labs/charts-syncer/internal/config"
+	"github.com/bitnami-labs/charts-syncer/internal/gitops"
+	"github.com/bitnami-labs/charts-syncer/internal/schedule"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
 	"github.com/juju/errors"
 	"github.com/mitchellh/go-homedir"
+	"github.com/mkmik/multierror"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/protobuf/proto"
 	"k8s.io/klog"
 )
 
@@ -16,15 +34,36 @@ var (
 	syncWorkdir           string
 	syncSkipDependencies  bool
 	syncLatestVersionOnly bool
+	syncStrict            bool
+	syncRollback          bool
+	syncVerifyUploads     bool
+	syncRunID             string
+	syncWorkers           int
+	syncWatch             bool
+	syncInterval          time.Duration
+	syncListen            string
+	syncListenTokenFile   string
+	syncListenInsecure    bool
+	syncReportFile        string
 )
 
+// syncRuns serializes the runs of --watch and --listen, which share the
+// workdir and the retry policy
+var syncRuns sync.Mutex
+
 var (
 	syncExample = `
   # Synchronizes all charts defined in the configuration file
   charts-syncer sync
 
   # Synchronizes all charts defined in the configuration file from May 1st, 2020
-  charts-syncer sync --from-date 2020-05-01`
+  charts-syncer sync --from-date 2020-05-01
+
+  # Keeps running and synchronizes the charts every 15 minutes
+  charts-syncer sync --watch --interval 15m
+
+  # Keeps running and synchronizes the charts requested to POST http://localhost:8080/sync
+  charts-syncer sync --listen :8080 --listen-token-file /etc/charts-syncer/token`
 )
 
 func initConfigFile() error {
@@ -50,6 +89,41 @@ func initConfigFile() error {
 	return errors.Trace(viper.ReadInConfig())
 }
 
+// loadConfig finds, loads and validates the config file
+func loadConfig(c *api.Config) error {
+	if err := initConfigFile(); err != nil {
+		return errors.Trace(err)
+	}
+
+	// Env variables bindings for viper
+	if err := config.InitEnvBindings(); err != nil {
+		return errors.Trace(err)
+	}
+
+	// Load config file relying on viper to find it
+	if err := config.Load(c); err != nil {
+		return errors.Trace(err)
+	}
+	if err := c.Validate(); err != nil {
+		return errors.Trace(err)
+	}
+
+	utils.SetRetryPolicy(utils.NewRetryPolicy(c.GetRetry()))
+	return nil
+}
+
+// loadSingleConfig loads the config file of the commands that do not run the
+// syncs entries
+func loadSingleConfig(c *api.Config, command string) error {
+	if err := loadConfig(c); err != nil {
+		return errors.Trace(err)
+	}
+	if len(c.GetSyncs()) > 0 {
+		return errors.NotSupportedf("%q with %s command", "syncs", command)
+	}
+	return nil
+}
+
 func newSyncCmd() *cobra.Command {
 	var c api.Config
 
@@ -58,52 +132,366 @@ func newSyncCmd() *cobra.Command {
 		Short:   "Synchronizes two chart repositories",
 		Example: syncExample,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if err := initConfigFile(); err != nil {
-				return errors.Trace(err)
+			return errors.Trace(loadConfig(&c))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !syncWatch && syncInterval != 0 {
+				return errors.New("--interval requires --watch")
 			}
-
-			// Env variables bindings for viper
-			if err := config.InitEnvBindings(); err != nil {
-				return errors.Trace(err)
+			if syncListen == "" && (syncListenTokenFile != "" || syncListenInsecure) {
+				return errors.New("--listen-token-file and --listen-insecure require --listen")
 			}
-
-			// Load config file relying on viper to find it
-			if err := config.Load(&c); err != nil {
-				return errors.Trace(err)
+			if syncListen != "" && syncListenTokenFile == "" && !syncListenInsecure {
+				return errors.New("--listen requires --listen-token-file, or --listen-insecure to accept the sync requests without a token")
 			}
-
-			if err := c.Validate(); err != nil {
-				return errors.Trace(err)
+			if !syncWatch && syncListen == "" {
+				ctx, stop := signalContext()
+				defer stop()
+				return errors.Trace(reported(func() error { return runSyncs(ctx, &c) }))
 			}
 
-			return nil
-		},
-		RunE: func(cmd *cobra.Command, args []string) error {
-			syncerOptions := []syncer.Option{
-				// TODO(jdrios): Some backends may not support discovery
-				syncer.WithAutoDiscovery(true),
-				syncer.WithDryRun(rootDryRun),
-				syncer.WithFromDate(syncFromDate),
-				syncer.WithWorkdir(syncWorkdir),
-				syncer.WithInsecure(rootInsecure),
-				syncer.WithContainerImageRelocation(c.RelocateContainerImages),
-				syncer.WithSkipDependencies(syncSkipDependencies),
-				syncer.WithLatestVersionOnly(syncLatestVersionOnly),
-				syncer.WithSkipCharts(c.SkipCharts),
+			var sched schedule.Schedule
+			if syncWatch {
+				var err error
+				if sched, err = watchSchedule(&c); err != nil {
+					return errors.Trace(err)
+				}
 			}
-			s, err := syncer.New(c.GetSource(), c.GetTarget(), syncerOptions...)
-			if err != nil {
-				return errors.Trace(err)
+			ctx, stop := signalContext()
+			defer stop()
+			// The process stops if any of them fails
+			g, gctx := errgroup.WithContext(ctx)
+			if syncListen != "" {
+				g.Go(func() error { return serveWebhook(gctx, &c) })
 			}
-
-			return errors.Trace(s.SyncPendingCharts(c.GetCharts()...))
+			if syncWatch {
+				g.Go(func() error { return watch(gctx, &c, sched) })
+			}
+			return errors.Trace(g.Wait())
 		},
 	}
 
-	cmd.Flags().StringVar(&syncFromDate, "from-date", "", "Date you want to synchronize charts from. Format: YYYY-MM-DD")
+	cmd.Flags().StringVar(&syncFromDate, "from-date", "", "Date you want to synchronize charts from. Format: YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS or RFC3339, optionally followed by a time zone (i.e \"2020-05-15 Europe/Madrid\")")
 	cmd.Flags().StringVar(&syncWorkdir, "workdir", syncer.DefaultWorkdir(), "Working directory")
 	cmd.Flags().BoolVar(&syncSkipDependencies, "skip-dependencies", false, "Skip syncing chart dependencies")
 	cmd.Flags().BoolVar(&syncLatestVersionOnly, "latest-version-only", false, "Sync only latest version of each chart")
+	cmd.Flags().BoolVar(&syncStrict, "strict", false, "Fail on conditions that are otherwise only warned about, like charts that could not be indexed")
+	cmd.Flags().BoolVar(&syncRollback, "rollback", false, "Delete a pushed chart from the target if its provenance file or attestation cannot be pushed")
+	cmd.Flags().BoolVar(&syncVerifyUploads, "verify-uploads", false, "Read every pushed chart back from the target and fail it if its digest differs from the uploaded package")
+	cmd.Flags().StringVar(&syncRunID, "run-id", "", "Identifier of the sync run recorded in the chart attestations. Defaults to the start time of the run")
+	cmd.Flags().IntVar(&syncWorkers, "workers", 0, "Number of charts synced concurrently. Overrides the \"workers\" property of the config file")
+	cmd.Flags().BoolVar(&syncWatch, "watch", false, "Keep running and sync the charts periodically, every --interval or on the \"schedule\" of the config file")
+	cmd.Flags().DurationVar(&syncInterval, "interval", 0, "Time between the starts of the sync runs of --watch, e.g. 15m. Overrides the \"schedule\" property of the config file")
+	cmd.Flags().StringVar(&syncListen, "listen", "", "Keep running and sync the charts requested to the POST /sync endpoint served on the given address, e.g. :8080")
+	cmd.Flags().StringVar(&syncListenTokenFile, "listen-token-file", "", "File with the token the requests to --listen should have in their Authorization header")
+	cmd.Flags().BoolVar(&syncListenInsecure, "listen-insecure", false, "Accept the requests to --listen without a token, if --listen-token-file is not set")
+	cmd.Flags().StringVar(&syncReportFile, "report-file", "", "Write a report of the chart versions considered by every run and the action taken on them to this file, as YAML if it has a .yaml or .yml extension or as JSON otherwise")
 
 	return cmd
 }
+
+// signalContext returns a context cancelled on SIGINT/SIGTERM, so no new
+// charts are synced. A second signal terminates the process immediately.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			klog.Warning("Received termination signal. Waiting for in-flight charts to finish...")
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, stop
+}
+
+// watchSchedule returns the schedule of the sync runs of --watch
+func watchSchedule(c *api.Config) (schedule.Schedule, error) {
+	if syncRunID != "" {
+		return nil, errors.New("--run-id cannot be used with --watch, every run has its own identifier")
+	}
+	if syncInterval < 0 {
+		return nil, errors.NotValidf("%s interval", syncInterval)
+	}
+	if syncInterval > 0 {
+		return schedule.Every(syncInterval), nil
+	}
+	if c.GetSchedule() != "" {
+		return schedule.Parse(c.GetSchedule())
+	}
+	return nil, errors.New(`--watch requires --interval or a "schedule" in the config file`)
+}
+
+// watch runs the syncs of a configuration on a schedule until the context is
+// cancelled. A failed run does not stop the next ones, which retry its
+// charts. The runs missed while a run is in progress are skipped.
+func watch(ctx context.Context, c *api.Config, sched schedule.Schedule) error {
+	for {
+		start := time.Now()
+		syncRuns.Lock()
+		err := reported(func() error { return runSyncs(ctx, c) })
+		syncRuns.Unlock()
+		if err != nil {
+			// Like a single run, an interrupted one exits with its checkpoint
+			if errors.Cause(err) == syncer.ErrInterrupted {
+				return errors.Trace(err)
+			}
+			klog.Errorf("sync run failed: %v", err)
+		}
+
+		next := sched.Next(start)
+		for !next.IsZero() && !next.After(time.Now()) {
+			next = sched.Next(next)
+		}
+		if next.IsZero() {
+			return errors.New("the schedule has no next run")
+		}
+		klog.Infof("Next sync run at %s", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			klog.Info("Stopped watching the source repository")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// runSyncs syncs the charts of a configuration, or of each of its syncs
+// entries, one after the other
+func runSyncs(ctx context.Context, c *api.Config) error {
+	if len(c.GetSyncs()) == 0 {
+		return errors.Trace(runSync(ctx, c, c.GetSource()))
+	}
+
+	var errs error
+	for i, sc := range c.SyncConfigs() {
+		if ctx.Err() != nil {
+			break
+		}
+		klog.Infof("Running syncs[%d] from %s...", i, describeSource(sc.GetSource()))
+		utils.SetRetryPolicy(utils.NewRetryPolicy(sc.GetRetry()))
+		if err := runSync(ctx, sc, sc.GetSource()); err != nil {
+			klog.Errorf("unable to run syncs[%d]: %v", i, err)
+			errs = multierror.Append(errs, errors.Annotatef(err, "syncs[%d]", i))
+		}
+	}
+	if errs != nil {
+		return errors.Trace(errs)
+	}
+	return errors.Trace(ctx.Err())
+}
+
+// runSync syncs the charts of a configuration from the given source to its
+// target, or to each of its targets, then writes the GitOps manifests of the
+// target
+func runSync(ctx context.Context, c *api.Config, source *api.Source) error {
+	if len(c.GetTargets()) == 0 {
+		_, err := syncTarget(ctx, c, source)
+		return errors.Trace(err)
+	}
+
+	// The targets are synced one after the other with the same workdir, so
+	// the source charts are only downloaded by the first one
+	var results []targetResult
+	failed := 0
+	for i, target := range c.GetTargets() {
+		name := fmt.Sprintf("targets[%d]", i)
+		if ctx.Err() != nil {
+			results = append(results, targetResult{name: name, target: target, status: "INTERRUPTED"})
+			failed++
+			continue
+		}
+		klog.Infof("Syncing charts to %s (%s)...", name, describeTarget(target))
+		tc := proto.Clone(c).(*api.Config)
+		tc.Target, tc.Targets = target, nil
+		run, err := syncTarget(ctx, tc, source)
+		r := targetResult{name: name, target: target, run: run, status: "OK"}
+		if err != nil {
+			klog.Errorf("unable to sync charts to %s: %v", name, err)
+			r.status, r.err = "FAILED", err
+			failed++
+		}
+		results = append(results, r)
+	}
+	if err := printTargetResults(os.Stdout, results); err != nil {
+		return errors.Trace(err)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d targets failed", failed, len(results))
+	}
+	return nil
+}
+
+// targetResult is the outcome of the sync of one of the targets
+type targetResult struct {
+	name   string
+	target *api.Target
+	run    *state.Run
+	status string
+	err    error
+}
+
+// printTargetResults writes the outcome of the sync of every target
+func printTargetResults(out io.Writer, results []targetResult) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTARGET\tCHARTS\tSYNCED\tFAILED\tRESULT\tDETAIL")
+	for _, r := range results {
+		var charts, synced, failed int
+		if r.run != nil {
+			charts, synced, failed = r.run.Charts, r.run.Synced, r.run.Failed
+		}
+		detail := ""
+		if r.err != nil {
+			detail = strings.ReplaceAll(r.err.Error(), "\n", " ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\n", r.name, describeTarget(r.target), charts, synced, failed, r.status, detail)
+	}
+	return errors.Trace(w.Flush())
+}
+
+// describeSource returns the location of a source
+func describeSource(s *api.Source) string {
+	if dir := s.GetIntermediateBundlesPath(); dir != "" {
+		return dir
+	}
+	if repo := s.GetRepo(); repo.GetUrl() != "" {
+		return repo.GetUrl()
+	}
+	return s.GetRepo().GetPath()
+}
+
+// describeTarget returns the location of a target
+func describeTarget(t *api.Target) string {
+	if dir := t.GetIntermediateBundlesPath(); dir != "" {
+		return dir
+	}
+	if repo := t.GetRepo(); repo.GetUrl() != "" {
+		return repo.GetUrl()
+	}
+	return t.GetRepo().GetPath()
+}
+
+// syncTarget syncs the charts of a configuration from the given source to its
+// target, then writes the GitOps manifests of the target. It returns the
+// summary of the sync run, if it started.
+func syncTarget(ctx context.Context, c *api.Config, source *api.Source) (*state.Run, error) {
+	s, err := syncCharts(ctx, c, source)
+	if s == nil {
+		return nil, errors.Trace(err)
+	}
+	if err != nil {
+		return s.LastRun(), errors.Trace(err)
+	}
+
+	if c.GetFlux() != nil {
+		if rootDryRun {
+			klog.Infof("dry-run: Writing Flux source manifest to %q", c.GetFlux().GetOutput())
+		} else if err := gitops.WriteFlux(c.GetTarget(), c.GetFlux()); err != nil {
+			return s.LastRun(), errors.Trace(err)
+		}
+	}
+	if c.GetArgocd() != nil {
+		if rootDryRun {
+			klog.Infof("dry-run: Writing Argo CD repository declaration to %q", c.GetArgocd().GetOutput())
+		} else if err := gitops.WriteArgoCD(c.GetTarget(), c.GetArgocd()); err != nil {
+			return s.LastRun(), errors.Trace(err)
+		}
+	}
+	return s.LastRun(), nil
+}
+
+// syncCharts syncs the charts of a configuration from the given source to its
+// target. It returns the syncer, to read the outcome of the sync run, unless
+// it could not be created.
+func syncCharts(ctx context.Context, c *api.Config, source *api.Source) (*syncer.Syncer, error) {
+	syncerOptions := syncerOptions(ctx, c)
+	if c.GetState() != nil {
+		store, err := state.New(c.GetState(), rootInsecure)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer store.Close()
+		syncerOptions = append(syncerOptions, syncer.WithStateStore(store))
+	}
+	s, err := syncer.New(source, c.GetTarget(), syncerOptions...)
+	if err != nil {
+		addReport(source, c.GetTarget(), nil, err)
+		return nil, errors.Trace(err)
+	}
+
+	err = s.SyncPendingCharts(c.GetCharts()...)
+	addReport(source, c.GetTarget(), s, err)
+	if diff := s.LastDiff(); diff != nil {
+		if perr := printDiff(os.Stdout, rootDryRunOutput, source, c.GetTarget(), diff); perr != nil {
+			klog.Errorf("unable to print the dry-run differences: %v", perr)
+		}
+	}
+	return s, errors.Trace(err)
+}
+
+// syncerOptions returns the options of the syncer of a configuration
+func syncerOptions(ctx context.Context, c *api.Config) []syncer.Option {
+	return []syncer.Option{
+		syncer.WithContext(ctx),
+		// TODO(jdrios): Some backends may not support discovery
+		syncer.WithAutoDiscovery(true),
+		syncer.WithDryRun(rootDryRun),
+		syncer.WithFromDate(syncFromDate),
+		syncer.WithWorkdir(syncWorkdir),
+		syncer.WithInsecure(rootInsecure),
+		syncer.WithContainerImageRelocation(c.RelocateContainerImages),
+		syncer.WithSkipDependencies(syncSkipDependencies),
+		syncer.WithLatestVersionOnly(syncLatestVersionOnly),
+		syncer.WithSkipCharts(c.SkipCharts),
+		syncer.WithChartFilters(c.GetChartFilters()),
+		syncer.WithSkipPrereleases(c.GetSkipPrereleases()),
+		syncer.WithLatestVersions(c.GetLatestVersions()),
+		syncer.WithChartsRegex(c.GetChartsRegex()),
+		syncer.WithExcludeCharts(c.GetExcludeCharts()),
+		syncer.WithExcludeChartsRegex(c.GetExcludeChartsRegex()),
+		syncer.WithConflictStrategy(c.GetConflictStrategy()),
+		syncer.WithStrict(syncStrict),
+		syncer.WithRollback(syncRollback),
+		syncer.WithUploadVerification(syncVerifyUploads),
+		syncer.WithDigestComparison(c.GetCompareDigest()),
+		syncer.WithForceOverwrite(c.GetForceOverwrite()),
+		syncer.WithLintPolicy(c.GetLintPolicy()),
+		syncer.WithProvenancePolicy(c.GetProvenancePolicy()),
+		syncer.WithSigningKey(c.GetSigningKey()),
+		syncer.WithTransformations(c.GetTransformations()),
+		syncer.WithVersionSuffix(c.GetVersionSuffix()),
+		syncer.WithRename(c.GetRename()),
+		syncer.WithIgnore(c.GetIgnore()),
+		syncer.WithAttestation(c.GetAttestation()),
+		syncer.WithSBOM(c.GetSbom()),
+		syncer.WithVerificationWebhook(c.GetVerificationWebhook()),
+		syncer.WithIndexOnly(c.GetIndexOnly()),
+		syncer.WithTelemetry(c.GetTelemetry()),
+		syncer.WithNotifications(c.GetNotifications()),
+		syncer.WithWorkers(workers(c)),
+		syncer.WithSyncerVersion(version),
+		syncer.WithRunID(runID()),
+	}
+}
+
+// workers returns the number of charts synced concurrently
+func workers(c *api.Config) int {
+	if syncWorkers > 0 {
+		return syncWorkers
+	}
+	return int(c.GetWorkers())
+}
+
+// runID returns the identifier of the sync run
+func runID() string {
+	if syncRunID != "" {
+		return syncRunID
+	}
+	return time.Now().UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyDependencies bool
+	verifyWorkdir      string
+	verifyOutputFormat string
+)
+
+var (
+	verifyExample = `
+  # Verifies the charts of the target repositories of the configuration file
+  charts-syncer verify
+
+  # Also checks that the dependencies of the charts are in the target repositories
+  charts-syncer verify --dependencies`
+)
+
+// verifyOutput is the JSON document of the verification of a target
+type verifyOutput struct {
+	Target  string                `json:"target"`
+	Results []syncer.VerifyResult `json:"results"`
+}
+
+func newVerifyCmd() *cobra.Command {
+	var c api.Config
+
+	cmd := &cobra.Command{
+		Use:     "verify",
+		Short:   "Verifies the charts of the target repository",
+		Long:    "Verifies that every chart of the target repository, or of the charts of the config file, can be fetched and has the expected digest: the one recorded in the state store, if any, or the one reported by the repository. It exits with a non-zero code if any chart fails the verification.",
+		Example: verifyExample,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if verifyOutputFormat != diffTable && verifyOutputFormat != diffJSON {
+				return errors.NotValidf("%q output, it should be %q or %q", verifyOutputFormat, diffTable, diffJSON)
+			}
+			return errors.Trace(loadConfig(&c))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The charts are fetched again, not read from the cache of a sync
+			workdir := verifyWorkdir
+			if workdir == "" {
+				dir, err := ioutil.TempDir("", "charts-syncer-verify")
+				if err != nil {
+					return errors.Trace(err)
+				}
+				defer os.RemoveAll(dir)
+				workdir = dir
+			}
+
+			ctx, stop := signalContext()
+			defer stop()
+			failed := 0
+			err := forEachTarget(ctx, &c, func(tc *api.Config) error {
+				results, err := verifyTarget(ctx, tc, workdir)
+				if results != nil {
+					for _, r := range results {
+						if !r.OK() {
+							failed++
+						}
+					}
+					if perr := printVerifyResults(cmd.OutOrStdout(), verifyOutputFormat, tc.GetTarget(), results); perr != nil {
+						return errors.Trace(perr)
+					}
+				}
+				return errors.Trace(err)
+			})
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if failed > 0 {
+				return errors.Errorf("%d charts failed the verification", failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&verifyDependencies, "dependencies", false, "Check that the locked dependencies of the charts are in the target repository")
+	cmd.Flags().StringVar(&verifyWorkdir, "workdir", "", "Working directory. Defaults to a temporary directory, so the charts are always fetched")
+	cmd.Flags().StringVarP(&verifyOutputFormat, "output", "o", diffTable, fmt.Sprintf("Format of the results, %q or %q", diffTable, diffJSON))
+
+	return cmd
+}
+
+// verifyTarget verifies the charts of the target of a configuration. The
+// syncer runs in dry-run mode, so nothing is written to the target or the
+// state store.
+func verifyTarget(ctx context.Context, c *api.Config, workdir string) ([]syncer.VerifyResult, error) {
+	syncerOptions := append(syncerOptions(ctx, c), syncer.WithDryRun(true), syncer.WithWorkdir(workdir))
+	if c.GetState() != nil {
+		store, err := state.New(c.GetState(), rootInsecure)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		defer store.Close()
+		syncerOptions = append(syncerOptions, syncer.WithStateStore(store))
+	}
+	s, err := syncer.New(c.GetSource(), c.GetTarget(), syncerOptions...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	results, err := s.Verify(verifyDependencies, c.GetCharts()...)
+	return results, errors.Trace(err)
+}
+
+// printVerifyResults writes the verification of the charts of a target, as a
+// table or as a JSON document per line
+func printVerifyResults(out io.Writer, format string, target *api.Target, results []syncer.VerifyResult) error {
+	if format == diffJSON {
+		data, err := json.Marshal(verifyOutput{Target: describeTarget(target), Results: results})
+		if err != nil {
+			return errors.Trace(err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return errors.Trace(err)
+	}
+
+	fmt.Fprintf(out, "Verification of %s:\n", describeTarget(target))
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tDIGEST\tSTATUS")
+	failed := 0
+	for _, r := range results {
+		status := "OK"
+		if !r.OK() {
+			status = "FAILED: " + strings.Join(r.Problems, "; ")
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, r.Version, shortDigest(r.Digest), status)
+	}
+	if err := w.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	_, err := fmt.Fprintf(out, "%d charts verified, %d failed\n", len(results), failed)
+	return errors.Trace(err)
+}
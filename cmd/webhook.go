@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/internal/webhook"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/klog"
+)
+
+// triggerQueueSize is the number of triggered syncs waiting for the running
+// one
+const triggerQueueSize = 64
+
+// shutdownTimeout is the time given to the webhook requests being answered
+// once the process is stopped
+const shutdownTimeout = 10 * time.Second
+
+// triggerQueue holds the triggered syncs waiting for the running one. A
+// request already waiting is not queued again.
+type triggerQueue struct {
+	mu      sync.Mutex
+	pending map[webhook.Request]bool
+	ch      chan webhook.Request
+}
+
+func newTriggerQueue() *triggerQueue {
+	return &triggerQueue{pending: map[webhook.Request]bool{}, ch: make(chan webhook.Request, triggerQueueSize)}
+}
+
+// push queues a request
+func (q *triggerQueue) push(req webhook.Request) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending[req] {
+		return nil
+	}
+	select {
+	case q.ch <- req:
+		q.pending[req] = true
+		return nil
+	default:
+		return errors.Errorf("too many pending syncs, %d are waiting", triggerQueueSize)
+	}
+}
+
+// start removes a request from the waiting ones when its sync starts, so it
+// can be queued again meanwhile
+func (q *triggerQueue) start(req webhook.Request) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, req)
+}
+
+// serveWebhook serves the endpoint triggering the syncs of a configuration,
+// one after the other, until the context is cancelled
+func serveWebhook(ctx context.Context, c *api.Config) error {
+	token := ""
+	if syncListenTokenFile != "" {
+		data, err := ioutil.ReadFile(syncListenTokenFile)
+		if err != nil {
+			return errors.Annotatef(err, "reading %q token file", syncListenTokenFile)
+		}
+		token = strings.TrimSpace(string(data))
+	} else {
+		klog.Warning("The sync requests do not require a token with --listen-insecure, set --listen-token-file to require one")
+	}
+
+	queue := newTriggerQueue()
+	trigger := func(req webhook.Request) error {
+		if _, err := triggeredConfigs(c, req); err != nil {
+			return errors.Trace(err)
+		}
+		return queue.push(req)
+	}
+	srv := &http.Server{Addr: syncListen, Handler: webhook.NewHandler(token, trigger), ReadHeaderTimeout: shutdownTimeout}
+
+	// The triggered syncs run in the background, the requests are answered
+	// once they are queued
+	var runErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case req := <-queue.ch:
+				queue.start(req)
+				if err := runTriggered(ctx, c, req); err != nil {
+					klog.Errorf("unable to sync %s: %v", req, err)
+					if errors.Cause(err) == syncer.ErrInterrupted {
+						runErr = err
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+	klog.Infof("Listening for sync requests on %s", syncListen)
+
+	select {
+	case err := <-serveErr:
+		return errors.Annotatef(err, "listening on %q", syncListen)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		klog.Warningf("unable to stop the webhook listener: %v", err)
+	}
+	<-done
+	return errors.Trace(runErr)
+}
+
+// runTriggered runs the syncs of a request
+func runTriggered(ctx context.Context, c *api.Config, req webhook.Request) error {
+	configs, err := triggeredConfigs(c, req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	syncRuns.Lock()
+	defer syncRuns.Unlock()
+	klog.Infof("Syncing %s...", req)
+	return reported(func() error {
+		var errs error
+		for _, tc := range configs {
+			utils.SetRetryPolicy(utils.NewRetryPolicy(tc.GetRetry()))
+			if err := runSync(ctx, tc, tc.GetSource()); err != nil {
+				if errors.Cause(err) == syncer.ErrInterrupted {
+					return errors.Trace(err)
+				}
+				errs = multierror.Append(errs, err)
+			}
+		}
+		return errors.Trace(errs)
+	})
+}
+
+// triggeredConfigs returns the configurations syncing the chart of a request,
+// restricted to the chart and its version. All the configurations are
+// returned for the requests of all the charts.
+func triggeredConfigs(c *api.Config, req webhook.Request) ([]*api.Config, error) {
+	if req.Chart == "" {
+		return c.SyncConfigs(), nil
+	}
+
+	var configs []*api.Config
+	for _, sc := range c.SyncConfigs() {
+		selected, err := syncer.SelectsChart(sc, req.Chart)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !selected {
+			continue
+		}
+		filter := &api.ChartFilter{}
+		if f := sc.GetChartFilters()[req.Chart]; f != nil {
+			filter = proto.Clone(f).(*api.ChartFilter)
+		}
+		if req.Version != "" {
+			// The version should be synced by the configuration too
+			if !matchesConstraint(filter.GetVersions(), req.Version) {
+				continue
+			}
+			filter.Versions = "=" + req.Version
+		}
+
+		tc := proto.Clone(sc).(*api.Config)
+		tc.Charts, tc.ChartsRegex = []string{req.Chart}, nil
+		tc.ChartFilters = map[string]*api.ChartFilter{req.Chart: filter}
+		configs = append(configs, tc)
+	}
+	if len(configs) == 0 {
+		return nil, errors.NotFoundf("%s in the configuration", req)
+	}
+	return configs, nil
+}
+
+// matchesConstraint returns whether a version matches a semver constraint, if
+// any
+func matchesConstraint(constraint, version string) bool {
+	if constraint == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	v, err := utils.ParseSemver(version)
+	return err == nil && c.Check(v)
+}
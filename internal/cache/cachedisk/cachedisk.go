@@ -2,7 +2,6 @@ package cachedisk
 
 import (
 	"io"
-	"io/ioutil"
 	"os"
 	"path"
 
@@ -65,11 +64,12 @@ func (c *Cache) Read(w io.Writer, filename string) error {
 	if !c.Has(filename) {
 		return errors.NotFoundf("cache { id:%s, filename:%s }", c.id, filename)
 	}
-	data, err := ioutil.ReadFile(c.Path(filename))
+	f, err := os.Open(c.Path(filename))
 	if err != nil {
 		return errors.Annotatef(err, "reading %q from the cache", filename)
 	}
-	if _, err := w.Write(data); err != nil {
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
 		return errors.Annotatef(err, "reading %q from the cache", filename)
 	}
 	klog.V(4).Infof("cache hit { op:read, id:%s, filename:%s }", c.id, filename)
@@ -3,12 +3,15 @@ package chart
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path"
 	"regexp"
 	"strings"
 
 	"github.com/juju/errors"
 
 	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
 )
 
 var (
@@ -86,3 +89,47 @@ func updateReadmeFile(readmeFile, sourceURL, targetURL, chartName, repoName stri
 	}
 	return errors.Trace(ioutil.WriteFile(readmeFile, []byte(newContent), 0))
 }
+
+// FindChartPath returns the root folder of an uncompressed chart in dir.
+//
+// Charts are expected to be packaged under a folder named after the chart,
+// but some vendors use a different folder name, no folder at all or leading
+// "./" entries. The root is the shallowest folder containing a Chart.yaml
+// file. If several folders qualify, the one named after the chart is
+// preferred.
+func FindChartPath(dir, name string) (string, error) {
+	level := []string{dir}
+	for len(level) > 0 {
+		var found, next []string
+		for _, d := range level {
+			if ok, err := utils.FileExists(path.Join(d, ChartFilename)); err != nil {
+				return "", errors.Trace(err)
+			} else if ok {
+				found = append(found, d)
+				continue
+			}
+			entries, err := os.ReadDir(d)
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			for _, e := range entries {
+				if e.IsDir() {
+					next = append(next, path.Join(d, e.Name()))
+				}
+			}
+		}
+		switch {
+		case len(found) == 1:
+			return found[0], nil
+		case len(found) > 1:
+			for _, f := range found {
+				if path.Base(f) == name {
+					return f, nil
+				}
+			}
+			return "", errors.Errorf("found several charts in %q: %v", dir, found)
+		}
+		level = next
+	}
+	return "", errors.NotFoundf("%s file in %q", ChartFilename, dir)
+}
@@ -135,3 +135,74 @@ The above parameters map to the env variables defined in [bitnami/ghost](http://
 		t.Errorf("incorrect modification, got: \n %s \n, want: \n %s \n", got, want)
 	}
 }
+
+func TestFindChartPath(t *testing.T) {
+	tests := []struct {
+		desc    string
+		files   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:  "folder named after the chart",
+			files: []string{"apache/Chart.yaml", "apache/charts/common/Chart.yaml"},
+			want:  "apache",
+		},
+		{
+			desc:  "folder with a different name",
+			files: []string{"apache-chart-v1/Chart.yaml", "apache-chart-v1/values.yaml"},
+			want:  "apache-chart-v1",
+		},
+		{
+			desc:  "no top-level folder",
+			files: []string{"Chart.yaml", "charts/common/Chart.yaml"},
+			want:  "",
+		},
+		{
+			desc:  "several candidates",
+			files: []string{"common/Chart.yaml", "apache/Chart.yaml"},
+			want:  "apache",
+		},
+		{
+			desc:    "several candidates without the chart name",
+			files:   []string{"foo/Chart.yaml", "bar/Chart.yaml"},
+			wantErr: true,
+		},
+		{
+			desc:    "no chart",
+			files:   []string{"apache/values.yaml"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "charts-syncer-tests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for _, f := range tc.files {
+				if err := os.MkdirAll(path.Join(dir, path.Dir(f)), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path.Join(dir, f), []byte{}, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := FindChartPath(dir, "apache")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got chart path %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := path.Join(dir, tc.want); got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
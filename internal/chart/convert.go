@@ -0,0 +1,79 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// convertToV2 converts an uncompressed apiVersion v1 chart to apiVersion v2.
+//
+// The dependencies in requirements.yaml are moved to Chart.yaml and
+// requirements.lock to Chart.lock. The lock digest is computed again, as Helm 2
+// and Helm 3 compute it differently.
+func convertToV2(chartPath string) error {
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if metadata.APIVersion != APIV1 {
+		return nil
+	}
+	klog.V(4).Infof("Converting %q chart to apiVersion %s", metadata.Name, APIV2)
+
+	// The requirements are kept as generic maps, so fields like tags or
+	// import-values are preserved
+	var requirements struct {
+		Dependencies []interface{} `json:"dependencies"`
+	}
+	requirementsFile := path.Join(chartPath, RequirementsFilename)
+	if ok, err := utils.FileExists(requirementsFile); err != nil {
+		return errors.Trace(err)
+	} else if ok {
+		data, err := ioutil.ReadFile(requirementsFile)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := yaml.Unmarshal(data, &requirements); err != nil {
+			return errors.Annotatef(err, "unmarshaling %q file", requirementsFile)
+		}
+	}
+	if err := editChartFile(chartPath, func(metadata map[string]interface{}) {
+		metadata["apiVersion"] = APIV2
+		if len(requirements.Dependencies) > 0 {
+			metadata["dependencies"] = requirements.Dependencies
+		}
+	}); err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.RemoveAll(requirementsFile); err != nil {
+		return errors.Trace(err)
+	}
+
+	lockFile := path.Join(chartPath, RequirementsLockFilename)
+	if ok, err := utils.FileExists(lockFile); err != nil {
+		return errors.Trace(err)
+	} else if !ok {
+		return nil
+	}
+	lock, err := GetChartLock(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if metadata, err = readChartMetadata(chartPath); err != nil {
+		return errors.Trace(err)
+	}
+	if lock.Digest, err = hashDeps(metadata.Dependencies, lock.Dependencies); err != nil {
+		return errors.Trace(err)
+	}
+	if err := writeChartFile(path.Join(chartPath, ChartLockFilename), lock); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Remove(lockFile))
+}
@@ -0,0 +1,54 @@
+package chart
+
+import (
+	"path"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+func TestConvertToV2(t *testing.T) {
+	chartPath := newChartPath(t, "../../testdata/kafka-10.3.3.tgz", "kafka")
+	// Converting twice leaves the converted chart as it is
+	for i := 0; i < 2; i++ {
+		if err := convertToV2(chartPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, f := range []string{RequirementsFilename, RequirementsLockFilename} {
+		if ok, err := utils.FileExists(path.Join(chartPath, f)); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			t.Errorf("got: %s file, want it removed", f)
+		}
+	}
+	c, err := loader.Load(chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Metadata.APIVersion, APIV2; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	wantDeps := []*chart.Dependency{
+		{Name: "zookeeper", Version: "5.x.x", Repository: "https://charts.bitnami.com/bitnami", Condition: "zookeeper.enabled"},
+	}
+	if !reflect.DeepEqual(c.Metadata.Dependencies, wantDeps) {
+		t.Errorf("got: %+v, want: %+v", c.Metadata.Dependencies, wantDeps)
+	}
+	if c.Lock == nil {
+		t.Fatalf("got no %s file", ChartLockFilename)
+	}
+	// The lock digest still matches the dependencies
+	digest, err := hashDeps(c.Metadata.Dependencies, c.Lock.Dependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Lock.Digest != digest {
+		t.Errorf("got: %q, want: %q", c.Lock.Digest, digest)
+	}
+}
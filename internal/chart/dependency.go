@@ -8,6 +8,8 @@ import (
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"sync"
 
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
@@ -21,6 +23,10 @@ import (
 	"github.com/bitnami-labs/charts-syncer/pkg/client"
 )
 
+// maxDependencyFetches is the number of dependencies of a chart fetched
+// concurrently
+const maxDependencyFetches = 4
+
 // dependencies is the list of dependencies of a chart
 type dependencies struct {
 	Dependencies []*chart.Dependency `json:"dependencies"`
@@ -78,8 +84,11 @@ func GetChartDependencies(filepath string, name string) ([]*chart.Dependency, er
 	if err := utils.Untar(filepath, chartPath); err != nil {
 		return nil, errors.Annotatef(err, "uncompressing %q", filepath)
 	}
-	// Untar uncompress the chart in a subfolder
-	chartPath = path.Join(chartPath, name)
+	// Untar uncompress the chart in a subfolder, usually named after the chart
+	chartPath, err = FindChartPath(chartPath, name)
+	if err != nil {
+		return nil, errors.Annotatef(err, "looking for chart in %q", filepath)
+	}
 
 	lock, err := GetChartLock(chartPath)
 	if err != nil {
@@ -114,7 +123,13 @@ func GetLockAPIVersion(chartPath string) (string, error) {
 //
 // It reads the lock file to download the versions from the target
 // chart repository (it assumes all charts are stored in a single repo).
-func BuildDependencies(chartPath string, r client.ChartsReader, sourceRepo, targetRepo *api.Repo) error {
+//
+// The dependencies from the source repository are renamed and get
+// versionSuffix appended, as they were pushed to the target that way.
+//
+// Up to maxDependencyFetches dependencies are fetched at once, so r must allow
+// concurrent Fetch calls.
+func BuildDependencies(chartPath string, r client.ChartsReader, sourceRepo, targetRepo *api.Repo, versionSuffix string, rename *api.Rename) error {
 	// Build deps manually for OCI as helm does not support it yet
 	if err := os.RemoveAll(path.Join(chartPath, "charts")); err != nil {
 		return errors.Trace(err)
@@ -140,11 +155,11 @@ func BuildDependencies(chartPath string, r client.ChartsReader, sourceRepo, targ
 	}
 	switch apiVersion {
 	case APIV1:
-		if err := updateRequirementsFile(chartPath, lock, sourceRepo, targetRepo); err != nil {
+		if err := updateRequirementsFile(chartPath, lock, sourceRepo, targetRepo, versionSuffix, rename); err != nil {
 			return errors.Trace(err)
 		}
 	case APIV2:
-		if err := updateChartMetadataFile(chartPath, lock, sourceRepo, targetRepo); err != nil {
+		if err := updateChartMetadataFile(chartPath, lock, sourceRepo, targetRepo, versionSuffix, rename); err != nil {
 			return errors.Trace(err)
 		}
 	default:
@@ -152,34 +167,59 @@ func BuildDependencies(chartPath string, r client.ChartsReader, sourceRepo, targ
 	}
 
 	// Step 2. Build charts/ folder
+	if lock == nil {
+		return nil
+	}
+	// The dependencies are fetched concurrently, the errors are aggregated in
+	// the order of the lock file
+	depErrs := make([]error, len(lock.Dependencies))
+	sem := make(chan struct{}, maxDependencyFetches)
+	var wg sync.WaitGroup
+	for i, dep := range lock.Dependencies {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, dep *chart.Dependency) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			depErrs[i] = fetchDependency(chartPath, r, dep)
+		}(i, dep)
+	}
+	wg.Wait()
+
 	var errs error
-	if lock != nil {
-		for _, dep := range lock.Dependencies {
-			id := fmt.Sprintf("%s-%s", dep.Name, dep.Version)
-			klog.V(4).Infof("Building %q chart dependency", id)
+	for _, err := range depErrs {
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
 
-			depTgz, err := r.Fetch(dep.Name, dep.Version)
-			if err != nil {
-				klog.Warningf("Failed fetching %q chart. The dependencies processing will remain incomplete.", id)
-				errs = multierror.Append(errs, errors.Annotatef(err, "fetching %q chart", id))
-				continue
-			}
+// fetchDependency copies a dependency from the chart repository to the charts
+// folder of a chart
+func fetchDependency(chartPath string, r client.ChartsReader, dep *chart.Dependency) error {
+	id := fmt.Sprintf("%s-%s", dep.Name, dep.Version)
+	klog.V(4).Infof("Building %q chart dependency", id)
 
-			depFile := path.Join(chartPath, "charts", fmt.Sprintf("%s.tgz", id))
-			if err := utils.CopyFile(depFile, depTgz); err != nil {
-				klog.Warningf("Failed copying %q chart. The dependencies processing will remain incomplete.", id)
-				errs = multierror.Append(errs, errors.Annotatef(err, "copying %q chart to %q", id, depFile))
-				continue
-			}
-		}
+	depTgz, err := r.Fetch(dep.Name, dep.Version)
+	if err != nil {
+		klog.Warningf("Failed fetching %q chart. The dependencies processing will remain incomplete.", id)
+		return errors.Annotatef(err, "fetching %q chart", id)
 	}
 
-	return errs
+	depFile := path.Join(chartPath, "charts", fmt.Sprintf("%s.tgz", id))
+	if err := utils.CopyFile(depFile, depTgz); err != nil {
+		klog.Warningf("Failed copying %q chart. The dependencies processing will remain incomplete.", id)
+		return errors.Annotatef(err, "copying %q chart to %q", id, depFile)
+	}
+	return nil
 }
 
 // updateChartMetadataFile updates the dependencies in Chart.yaml
 // For helm v3 dependency management
-func updateChartMetadataFile(chartPath string, lock *chart.Lock, sourceRepo, targetRepo *api.Repo) error {
+func updateChartMetadataFile(chartPath string, lock *chart.Lock, sourceRepo, targetRepo *api.Repo, versionSuffix string, rename *api.Rename) error {
 	chartFile := path.Join(chartPath, ChartFilename)
 	chartYamlContent, err := ioutil.ReadFile(chartFile)
 	if err != nil {
@@ -190,6 +230,7 @@ func updateChartMetadataFile(chartPath string, lock *chart.Lock, sourceRepo, tar
 	if err != nil {
 		return errors.Annotatef(err, "error unmarshaling %s file", chartFile)
 	}
+	retargetDependencies(chartMetadata.Dependencies, lock, sourceRepo, versionSuffix, rename)
 	for _, dep := range chartMetadata.Dependencies {
 		// Maybe there are dependencies from other chart repos. In this case we don't want to replace
 		// the repository.
@@ -201,9 +242,9 @@ func updateChartMetadataFile(chartPath string, lock *chart.Lock, sourceRepo, tar
 			dep.Repository = repoUrl
 		}
 	}
-	// Write updated chart yaml file
-	dest := path.Join(chartPath, ChartFilename)
-	if err := writeChartFile(dest, chartMetadata); err != nil {
+	// Only the dependencies are written, so the fields unknown to the Helm
+	// library are preserved
+	if err := setDependencies(chartPath, chartMetadata.Dependencies); err != nil {
 		return errors.Trace(err)
 	}
 	if err := updateLockFile(chartPath, lock, chartMetadata.Dependencies, sourceRepo, targetRepo, false); err != nil {
@@ -214,7 +255,7 @@ func updateChartMetadataFile(chartPath string, lock *chart.Lock, sourceRepo, tar
 
 // updateRequirementsFile returns the full list of dependencies and the list of missing dependencies.
 // For helm v2 dependency management
-func updateRequirementsFile(chartPath string, lock *chart.Lock, sourceRepo, targetRepo *api.Repo) error {
+func updateRequirementsFile(chartPath string, lock *chart.Lock, sourceRepo, targetRepo *api.Repo, versionSuffix string, rename *api.Rename) error {
 	requirementsFile := path.Join(chartPath, RequirementsFilename)
 	requirements, err := ioutil.ReadFile(requirementsFile)
 	if err != nil {
@@ -226,6 +267,7 @@ func updateRequirementsFile(chartPath string, lock *chart.Lock, sourceRepo, targ
 	if err != nil {
 		return errors.Annotatef(err, "error unmarshaling %s file", requirementsFile)
 	}
+	retargetDependencies(deps.Dependencies, lock, sourceRepo, versionSuffix, rename)
 	for _, dep := range deps.Dependencies {
 		// Maybe there are dependencies from other chart repos. In this case we don't want to replace
 		// the repository.
@@ -250,6 +292,41 @@ func updateRequirementsFile(chartPath string, lock *chart.Lock, sourceRepo, targ
 	return nil
 }
 
+// retargetDependencies renames the dependencies from the source repository
+// and appends versionSuffix to their versions, as they were pushed to the
+// target. Dependencies pinned to the locked version are updated too, so they
+// still match it.
+//
+// Renamed dependencies without an alias get their upstream name as alias, so
+// the values and templates of the parent chart keep working.
+func retargetDependencies(deps []*chart.Dependency, lock *chart.Lock, sourceRepo *api.Repo, versionSuffix string, rename *api.Rename) {
+	if (versionSuffix == "" && rename == nil) || lock == nil {
+		return
+	}
+	for _, l := range lock.Dependencies {
+		if l.Repository != sourceRepo.GetUrl() {
+			continue
+		}
+		name := utils.TargetChartName(l.Name, rename)
+		version := utils.AppendVersionSuffix(l.Version, versionSuffix)
+		for _, dep := range deps {
+			if dep.Name != l.Name || dep.Repository != l.Repository {
+				continue
+			}
+			if dep.Version == l.Version {
+				dep.Version = version
+			}
+			if name != dep.Name {
+				if dep.Alias == "" {
+					dep.Alias = dep.Name
+				}
+				dep.Name = name
+			}
+		}
+		l.Name, l.Version = name, version
+	}
+}
+
 // updateLockFile updates the lock file with the new registry
 func updateLockFile(chartPath string, lock *chart.Lock, deps []*chart.Dependency, sourceRepo *api.Repo, targetRepo *api.Repo, legacyLockfile bool) error {
 	for _, dep := range lock.Dependencies {
@@ -279,13 +356,16 @@ func updateLockFile(chartPath string, lock *chart.Lock, deps []*chart.Dependency
 	return nil
 }
 
-// writeChartFile writes a chart file to disk
-func writeChartFile(dest string, v interface{}) error {
-	data, err := yaml.Marshal(v)
-	if err != nil {
-		return errors.Trace(err)
-	}
-	return ioutil.WriteFile(dest, data, 0644)
+// setDependencies sets the dependencies in the Chart.yaml file of an
+// uncompressed chart
+func setDependencies(chartPath string, deps []*chart.Dependency) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		if len(deps) == 0 {
+			delete(metadata, "dependencies")
+			return
+		}
+		metadata["dependencies"] = deps
+	})
 }
 
 // hashDeps generates a hash of the dependencies.
@@ -314,3 +394,114 @@ func getDependencyRepoURL(targetRepo *api.Repo) (string, error) {
 	}
 	return repoUrl, nil
 }
+
+// rewriteDependencies removes or substitutes the dependencies of an
+// uncompressed chart matching the rules, in its dependencies and lock files.
+// The vendored copies of the changed dependencies are removed, they are
+// fetched again when the dependencies are built.
+func rewriteDependencies(chartPath string, rules []*api.DependencyRule) error {
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Helm v2 charts list their dependencies in requirements.yaml
+	depsFile := path.Join(chartPath, ChartFilename)
+	var deps []*chart.Dependency
+	if metadata.APIVersion == APIV1 {
+		depsFile = path.Join(chartPath, RequirementsFilename)
+		if ok, err := utils.FileExists(depsFile); err != nil {
+			return errors.Trace(err)
+		} else if !ok {
+			return nil
+		}
+		data, err := ioutil.ReadFile(depsFile)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		requirements := &dependencies{}
+		if err := yaml.Unmarshal(data, requirements); err != nil {
+			return errors.Annotatef(err, "unmarshaling %q file", depsFile)
+		}
+		deps = requirements.Dependencies
+	} else {
+		deps = metadata.Dependencies
+	}
+	lock, err := GetChartLock(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	for _, rule := range rules {
+		deps = applyDependencyRule(deps, rule)
+		if lock != nil {
+			lock.Dependencies = applyDependencyRule(lock.Dependencies, rule)
+		}
+		if err := removeVendoredDependency(chartPath, rule.GetName()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	if metadata.APIVersion == APIV1 {
+		err = writeChartFile(depsFile, &dependencies{Dependencies: deps})
+	} else {
+		err = setDependencies(chartPath, deps)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if lock == nil {
+		return nil
+	}
+	if lock.Digest, err = hashDeps(deps, lock.Dependencies); err != nil {
+		return errors.Trace(err)
+	}
+	apiVersion, err := GetLockAPIVersion(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	lockFile, err := lockFilePath(chartPath, apiVersion)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(writeChartFile(lockFile, lock))
+}
+
+// applyDependencyRule removes or substitutes the dependencies matching a rule
+func applyDependencyRule(deps []*chart.Dependency, rule *api.DependencyRule) []*chart.Dependency {
+	var result []*chart.Dependency
+	for _, dep := range deps {
+		if dep.Name != rule.GetName() {
+			result = append(result, dep)
+			continue
+		}
+		sub := rule.GetSubstitute()
+		if sub == nil {
+			klog.V(4).Infof("Removing %q dependency", dep.Name)
+			continue
+		}
+		klog.V(4).Infof("Substituting %q dependency with %s-%s", dep.Name, sub.GetName(), sub.GetVersion())
+		// The alias keeps the values and templates of the parent chart
+		// working
+		if dep.Alias == "" && sub.GetName() != dep.Name {
+			dep.Alias = dep.Name
+		}
+		dep.Name, dep.Version, dep.Repository = sub.GetName(), sub.GetVersion(), sub.GetRepository()
+		result = append(result, dep)
+	}
+	return result
+}
+
+// removeVendoredDependency removes the copies of a dependency in the charts
+// folder of an uncompressed chart, either packaged or uncompressed
+func removeVendoredDependency(chartPath, name string) error {
+	files, err := filepath.Glob(path.Join(chartPath, "charts", name+"-[0-9]*.tgz"))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, f := range append(files, path.Join(chartPath, "charts", name)) {
+		if err := os.RemoveAll(f); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
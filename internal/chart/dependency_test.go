@@ -5,11 +5,13 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/bitnami-labs/charts-syncer/api"
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
 	"helm.sh/helm/v3/pkg/chart"
 	"sigs.k8s.io/yaml"
 )
@@ -89,7 +91,7 @@ func TestUpdateRequirementsFile(t *testing.T) {
 
 	chartPath := newChartPath(t, "../../testdata/kafka-10.3.3.tgz", "kafka")
 	requirementsFile := path.Join(chartPath, RequirementsFilename)
-	if err := updateRequirementsFile(chartPath, lock, source.GetRepo(), target.GetRepo()); err != nil {
+	if err := updateRequirementsFile(chartPath, lock, source.GetRepo(), target.GetRepo(), "", nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -163,7 +165,7 @@ func TestUpdateChartMetadataFile(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := updateChartMetadataFile(chartPath, lock, source.GetRepo(), target.GetRepo()); err != nil {
+	if err := updateChartMetadataFile(chartPath, lock, source.GetRepo(), target.GetRepo(), "", nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -240,3 +242,110 @@ func TestGetDependencyRepoURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRewriteDependencies(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		rule     *api.DependencyRule
+		wantDeps []*chart.Dependency
+		wantLock []*chart.Dependency
+	}{
+		{
+			desc: "remove dependency",
+			rule: &api.DependencyRule{Name: "zookeeper", Action: &api.DependencyRule_Remove{Remove: true}},
+		},
+		{
+			desc: "substitute dependency",
+			rule: &api.DependencyRule{Name: "zookeeper", Action: &api.DependencyRule_Substitute_{Substitute: &api.DependencyRule_Substitute{
+				Name: "internal-zookeeper", Version: "1.0.0", Repository: "https://charts.example.com",
+			}}},
+			wantDeps: []*chart.Dependency{
+				{Name: "internal-zookeeper", Alias: "zookeeper", Version: "1.0.0", Repository: "https://charts.example.com", Condition: "zookeeper.enabled"},
+			},
+			wantLock: []*chart.Dependency{
+				{Name: "internal-zookeeper", Alias: "zookeeper", Version: "1.0.0", Repository: "https://charts.example.com"},
+			},
+		},
+		{
+			desc: "unknown dependency",
+			rule: &api.DependencyRule{Name: "redis", Action: &api.DependencyRule_Remove{Remove: true}},
+			wantDeps: []*chart.Dependency{
+				{Name: "zookeeper", Version: "5.x.x", Repository: "https://charts.bitnami.com/bitnami", Condition: "zookeeper.enabled"},
+			},
+			wantLock: []*chart.Dependency{
+				{Name: "zookeeper", Version: "5.14.3", Repository: "https://charts.bitnami.com/bitnami"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/kafka-10.3.3.tgz", "kafka")
+			if err := rewriteDependencies(chartPath, []*api.DependencyRule{tc.rule}); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := ioutil.ReadFile(path.Join(chartPath, RequirementsFilename))
+			if err != nil {
+				t.Fatal(err)
+			}
+			deps := &dependencies{}
+			if err := yaml.Unmarshal(data, deps); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(deps.Dependencies, tc.wantDeps) {
+				t.Errorf("got: %+v, want: %+v", deps.Dependencies, tc.wantDeps)
+			}
+			lock, err := GetChartLock(chartPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(lock.Dependencies, tc.wantLock) {
+				t.Errorf("got: %+v, want: %+v", lock.Dependencies, tc.wantLock)
+			}
+			// The lock digest matches the new dependencies
+			digest, err := hashDeps(deps.Dependencies, lock.Dependencies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lock.Digest != digest {
+				t.Errorf("got: %q, want: %q", lock.Digest, digest)
+			}
+
+			_, err = os.Stat(path.Join(chartPath, "charts", "zookeeper"))
+			if vendored := err == nil; vendored != (tc.rule.GetName() != "zookeeper") {
+				t.Errorf("got vendored zookeeper: %v", vendored)
+			}
+		})
+	}
+}
+
+// fakeReader serves the charts of a map from their id to their package
+type fakeReader struct {
+	client.ChartsReader
+	charts map[string]string
+}
+
+func (r *fakeReader) Fetch(name string, version string) (string, error) {
+	tgz, ok := r.charts[name+"-"+version]
+	if !ok {
+		return "", errors.New("chart not found")
+	}
+	return tgz, nil
+}
+
+func TestBuildDependencies(t *testing.T) {
+	chartPath := newChartPath(t, "../../testdata/kafka-10.3.3.tgz", "kafka")
+	r := &fakeReader{charts: map[string]string{"zookeeper-5.14.3": "../../testdata/zookeeper-5.14.3.tgz"}}
+	if err := BuildDependencies(chartPath, r, source.GetRepo(), target.GetRepo(), "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(chartPath, "charts", "zookeeper-5.14.3.tgz")); err != nil {
+		t.Errorf("got: %v, want zookeeper dependency in the charts folder", err)
+	}
+
+	// Missing dependencies are reported
+	chartPath = newChartPath(t, "../../testdata/kafka-10.3.3.tgz", "kafka")
+	if err := BuildDependencies(chartPath, &fakeReader{}, source.GetRepo(), target.GetRepo(), "", nil); err == nil {
+		t.Error("got no error, want an error for the missing zookeeper dependency")
+	}
+}
@@ -0,0 +1,92 @@
+package chart
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// defaultExecTimeout is the maximum duration of external transformers without
+// a configured timeout
+const defaultExecTimeout = time.Minute
+
+// execTransformer runs an external command editing an uncompressed chart.
+//
+// The command runs in the chart directory with a minimal environment, so it
+// does not get the credentials charts-syncer was configured with.
+func execTransformer(chartPath string, e *api.Exec) error {
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	timeout := defaultExecTimeout
+	if e.GetTimeout() != "" {
+		if timeout, err = time.ParseDuration(e.GetTimeout()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	command := e.GetCommand()
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = chartPath
+	setProcessGroup(cmd)
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"CHART_PATH=" + chartPath,
+		"CHART_NAME=" + metadata.Name,
+		"CHART_VERSION=" + metadata.Version,
+	}
+	for _, env := range e.GetEnv() {
+		if !strings.Contains(env, "=") {
+			env += "=" + os.Getenv(env)
+		}
+		cmd.Env = append(cmd.Env, env)
+	}
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	klog.V(4).Infof("Running %q transformer on %q chart", command[0], chartPath)
+	err = runUntil(ctx, cmd)
+	if out.Len() > 0 {
+		klog.V(4).Infof("%q transformer output:\n%s", command[0], out.String())
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Errorf("%q transformer timed out after %s", command[0], timeout)
+	}
+	if err != nil {
+		return errors.Annotatef(err, "running %q transformer: %s", command[0], strings.TrimSpace(out.String()))
+	}
+	return nil
+}
+
+// runUntil runs a command, killing it and the processes it started when the
+// context is done. Otherwise, the commands they run in the background would
+// keep running, and keep the output pipes open, after the timeout.
+func runUntil(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return errors.Trace(err)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := killProcessGroup(cmd); err != nil {
+				klog.Warningf("unable to kill %q transformer: %v", cmd.Path, err)
+			}
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
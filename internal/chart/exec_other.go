@@ -0,0 +1,16 @@
+//go:build !unix
+
+package chart
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op where process groups are not supported
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills a started command. The processes it started are not
+// killed.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
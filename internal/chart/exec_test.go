@@ -0,0 +1,81 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestExecTransformer(t *testing.T) {
+	os.Setenv("CHARTS_SYNCER_TEST_SECRET", "secret")
+	defer os.Unsetenv("CHARTS_SYNCER_TEST_SECRET")
+	os.Setenv("CHARTS_SYNCER_TEST_TEAM", "platform")
+	defer os.Unsetenv("CHARTS_SYNCER_TEST_TEAM")
+
+	testCases := []struct {
+		desc      string
+		exec      *api.Exec
+		want      string
+		shouldErr bool
+	}{
+		{
+			desc: "restricted environment",
+			exec: &api.Exec{
+				Command: []string{"sh", "-c", `echo "$CHART_NAME $CHART_VERSION $CHARTS_SYNCER_TEST_TEAM $OWNER [$CHARTS_SYNCER_TEST_SECRET]" > owner.txt`},
+				Env:     []string{"CHARTS_SYNCER_TEST_TEAM", "OWNER=team@example.com"},
+			},
+			want: "apache 7.3.15 platform team@example.com []\n",
+		},
+		{
+			desc:      "failed command",
+			exec:      &api.Exec{Command: []string{"sh", "-c", "echo broken chart >&2; exit 1"}},
+			shouldErr: true,
+		},
+		{
+			desc:      "timeout",
+			exec:      &api.Exec{Command: []string{"sleep", "10"}, Timeout: "100ms"},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+			err := execTransformer(chartPath, tc.exec)
+			if tc.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadFile(path.Join(chartPath, "owner.txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecTransformerKillsBackgroundProcesses(t *testing.T) {
+	chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+	// The background sleep keeps the output pipe open unless it is killed too
+	e := &api.Exec{Command: []string{"sh", "-c", "sleep 10 & sleep 10"}, Timeout: "100ms"}
+	start := time.Now()
+	err := execTransformer(chartPath, e)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("got %v error, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("the transformer returned after %s, the background process was not killed", elapsed)
+	}
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package chart
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs a command in a new process group, so the processes it
+// starts can be killed with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the process group of a started command
+func killProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
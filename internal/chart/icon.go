@@ -0,0 +1,173 @@
+package chart
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// mirrorIcon rewrites the icon URL in the Chart.yaml file of an uncompressed
+// chart to the internal host, downloading the icon first if needed.
+//
+// Charts without icons, or whose icon is not an http(s) URL, are left
+// untouched. In dry-run mode, the icon URL is rewritten without downloading
+// nor uploading the icon.
+func mirrorIcon(chartPath, name string, icon *api.IconMirror, opts transformOptions) error {
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	baseURL := strings.TrimSuffix(icon.GetBaseUrl(), "/")
+	if metadata.Icon == "" || strings.HasPrefix(metadata.Icon, baseURL+"/") {
+		return nil
+	}
+	u, err := url.Parse(metadata.Icon)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		klog.V(4).Infof("Skipping %q icon of %q chart: not an http(s) URL", metadata.Icon, name)
+		return nil
+	}
+
+	iconFile := path.Base(u.Path)
+	if iconFile == "." || iconFile == "/" {
+		iconFile = "icon"
+	}
+	rel := path.Join(name, iconFile)
+	mirrored := baseURL + "/" + rel
+	dir := icon.GetDownloadDir()
+	// Nothing is downloaded nor published in dry-run mode
+	if opts.dryRun {
+		if icon.GetUpload() {
+			klog.Infof("dry-run: Uploading %q icon to %q", metadata.Icon, mirrored)
+		}
+		dir = ""
+	} else if dir == "" && icon.GetUpload() {
+		// The icon is only kept until it is uploaded
+		if dir, err = ioutil.TempDir("", "charts-syncer-icon"); err != nil {
+			return errors.Trace(err)
+		}
+		defer os.RemoveAll(dir)
+	}
+	if dir != "" {
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := downloadIcon(metadata.Icon, file); err != nil {
+			return errors.Trace(err)
+		}
+		if icon.GetUpload() {
+			if err := uploadIcon(file, mirrored, icon.GetAuth()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+
+	klog.V(4).Infof("Rewriting %q icon of %q chart to %q", metadata.Icon, name, mirrored)
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		metadata["icon"] = mirrored
+	})
+}
+
+// downloadIcon downloads an icon unless it was already downloaded, e.g. for
+// another version of the chart
+func downloadIcon(u, dest string) error {
+	if ok, err := utils.FileExists(dest); err != nil {
+		return errors.Trace(err)
+	} else if ok {
+		return nil
+	}
+
+	klog.V(4).Infof("Downloading %q icon to %q", u, dest)
+	res, err := utils.DefaultClient.Get(u)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("error downloading %q icon: %s", u, res.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	// Write to a temporary file so an interrupted download is not kept
+	tmp := dest + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := io.Copy(f, res.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return errors.Trace(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, dest))
+}
+
+// uploadIcon publishes an icon with an HTTP PUT request unless it is already
+// served at the given URL
+func uploadIcon(file, u string, auth *api.Auth) error {
+	req, err := http.NewRequest("HEAD", u, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if auth.GetUsername() != "" {
+		req.SetBasicAuth(auth.GetUsername(), auth.GetPassword())
+	}
+	res, err := utils.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		klog.V(4).Infof("Skipping upload of %q icon: already published", u)
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req, err = http.NewRequest("PUT", u, f)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.ContentLength = fi.Size()
+	if contentType := mime.TypeByExtension(filepath.Ext(file)); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if auth.GetUsername() != "" {
+		req.SetBasicAuth(auth.GetUsername(), auth.GetPassword())
+	}
+
+	klog.V(4).Infof("Uploading %q icon to %q", file, u)
+	res, err = utils.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	default:
+		return errors.Errorf("error uploading %q icon: %s, Resp: %v", u, res.Status, utils.HTTPResponseBody(res))
+	}
+}
@@ -0,0 +1,133 @@
+package chart
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestMirrorIcon(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/assets/stacks/apache/img/apache-stack-220x234.png" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("icon"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+	if err := setIcon(chartPath, server.URL+"/assets/stacks/apache/img/apache-stack-220x234.png"); err != nil {
+		t.Fatal(err)
+	}
+	icon := &api.IconMirror{BaseUrl: "https://static.example.com/icons/", DownloadDir: dir}
+	// Mirroring twice neither rewrites the internal URL nor downloads the icon again
+	for i := 0; i < 2; i++ {
+		if err := mirrorIcon(chartPath, "apache", icon, transformOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := metadata.Icon, "https://static.example.com/icons/apache/apache-stack-220x234.png"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "apache", "apache-stack-220x234.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "icon"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if requests != 1 {
+		t.Errorf("got: %d requests, want: 1", requests)
+	}
+
+	// Missing icons fail the transformation instead of pointing to nothing
+	chartPath = newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+	if err := setIcon(chartPath, server.URL+"/missing.png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirrorIcon(chartPath, "apache", icon, transformOptions{}); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func setIcon(chartPath, icon string) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		metadata["icon"] = icon
+	})
+}
+
+func TestMirrorIconUpload(t *testing.T) {
+	published := map[string][]byte{}
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/apache-stack-220x234.png":
+			w.Write([]byte("icon"))
+		case r.Method == "PUT":
+			if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if got, want := r.Header.Get("Content-Type"), "image/png"; got != want {
+				t.Errorf("got content type: %q, want: %q", got, want)
+			}
+			uploads++
+			data, _ := ioutil.ReadAll(r.Body)
+			published[r.URL.Path] = data
+			w.WriteHeader(http.StatusCreated)
+		case published[r.URL.Path] != nil:
+			w.Write(published[r.URL.Path])
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	icon := &api.IconMirror{
+		BaseUrl: server.URL + "/icons",
+		Upload:  true,
+		Auth:    &api.Auth{Username: "user", Password: "pass"},
+	}
+	// Icons shared by several chart versions are only uploaded once
+	for i := 0; i < 2; i++ {
+		chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+		if err := setIcon(chartPath, server.URL+"/apache-stack-220x234.png"); err != nil {
+			t.Fatal(err)
+		}
+		if err := mirrorIcon(chartPath, "apache", icon, transformOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		metadata, err := readChartMetadata(chartPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := metadata.Icon, server.URL+"/icons/apache/apache-stack-220x234.png"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	}
+	if got, want := string(published["/icons/apache/apache-stack-220x234.png"]), "icon"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if uploads != 1 {
+		t.Errorf("got: %d uploads, want: 1", uploads)
+	}
+}
@@ -0,0 +1,138 @@
+package chart
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+// ignoreRules is a set of .helmignore rules.
+//
+// Helm does not expose its implementation, so this one mimics its behaviour:
+// rules are evaluated in order, a leading "!" negates a rule, a trailing "/"
+// only matches directories, a leading "/" anchors the rule to the chart root
+// and rules without slashes are only evaluated against the base name.
+type ignoreRules struct {
+	patterns []*ignorePattern
+}
+
+type ignorePattern struct {
+	rule    string
+	negate  bool
+	mustDir bool
+	// anchored indicates that the rule must match the full relative path
+	anchored bool
+}
+
+// newIgnoreRules returns the ignore rules for a chart directory, including the
+// Helm defaults, the chart .helmignore file, if any, and the extra rules.
+func newIgnoreRules(chartPath string, extra []string) (*ignoreRules, error) {
+	r := &ignoreRules{}
+	ignoreFile := filepath.Join(chartPath, HelmIgnoreFilename)
+	f, err := os.Open(ignoreFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Trace(err)
+	}
+	if err == nil {
+		defer f.Close()
+		if err := r.parse(f); err != nil {
+			return nil, errors.Annotatef(err, "parsing %q", ignoreFile)
+		}
+	}
+	for _, rule := range extra {
+		if err := r.add(rule); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	// Ignore all dotfiles in "templates/", as Helm does
+	if err := r.add(`templates/.?*`); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return r, nil
+}
+
+// parse adds the rules read from a .helmignore file
+func (r *ignoreRules) parse(f io.Reader) error {
+	s := bufio.NewScanner(f)
+	first := true
+	for s.Scan() {
+		line := s.Bytes()
+		if first {
+			line = bytes.TrimPrefix(line, []byte{0xEF, 0xBB, 0xBF})
+			first = false
+		}
+		if err := r.add(string(line)); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(s.Err())
+}
+
+// add adds a single rule
+func (r *ignoreRules) add(rule string) error {
+	rule = strings.TrimSpace(rule)
+	if rule == "" || strings.HasPrefix(rule, "#") {
+		return nil
+	}
+	if strings.Contains(rule, "**") {
+		return errors.Errorf("double-star (**) syntax is not supported in %q", rule)
+	}
+	if _, err := filepath.Match(rule, "abc"); err != nil {
+		return errors.Annotatef(err, "invalid rule %q", rule)
+	}
+
+	p := &ignorePattern{}
+	if strings.HasPrefix(rule, "!") {
+		p.negate = true
+		rule = rule[1:]
+	}
+	if strings.HasSuffix(rule, "/") {
+		p.mustDir = true
+		rule = strings.TrimSuffix(rule, "/")
+	}
+	if strings.HasPrefix(rule, "/") {
+		rule = strings.TrimPrefix(rule, "/")
+		p.anchored = true
+	} else if strings.Contains(rule, "/") {
+		p.anchored = true
+	}
+	p.rule = rule
+	r.patterns = append(r.patterns, p)
+	return nil
+}
+
+// Ignore returns whether the file at the relative path rel should be ignored.
+func (r *ignoreRules) Ignore(rel string, fi os.FileInfo) bool {
+	if rel == "" || rel == "." || rel == "./" {
+		return false
+	}
+	for _, p := range r.patterns {
+		name := rel
+		if !p.anchored {
+			name = filepath.Base(rel)
+		}
+		match, _ := filepath.Match(p.rule, name)
+		// A negated rule stops the evaluation unless it matches
+		if p.negate {
+			if p.mustDir && !fi.IsDir() {
+				return true
+			}
+			if !match {
+				return true
+			}
+			continue
+		}
+		if p.mustDir && !fi.IsDir() {
+			continue
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
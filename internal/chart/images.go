@@ -0,0 +1,236 @@
+package chart
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/yaml"
+)
+
+// Images returns the container images referenced by the values of a chart and
+// its subcharts, sorted and without duplicates.
+//
+// Images are read from the maps with a repository and a tag or digest, the
+// layout used by Bitnami charts:
+//
+//	image:
+//	  registry: docker.io
+//	  repository: bitnami/kafka
+//	  tag: 2.5.0-debian-10-r29
+func Images(ch *chart.Chart) []string {
+	refs := map[string]bool{}
+	collectImages(ch, refs)
+	images := make([]string, 0, len(refs))
+	for ref := range refs {
+		images = append(images, ref)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// collectImages adds the images of a chart and its subcharts to refs
+func collectImages(ch *chart.Chart, refs map[string]bool) {
+	var globalRegistry string
+	if global, ok := ch.Values["global"].(map[string]interface{}); ok {
+		globalRegistry, _ = global["imageRegistry"].(string)
+	}
+	walkImages(ch.Values, globalRegistry, refs)
+	for _, dep := range ch.Dependencies() {
+		collectImages(dep, refs)
+	}
+}
+
+// walkImages adds the image references found in a values tree to refs
+func walkImages(v interface{}, globalRegistry string, refs map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref := imageRef(v, globalRegistry); ref != "" {
+			refs[ref] = true
+			return
+		}
+		for _, child := range v {
+			walkImages(child, globalRegistry, refs)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkImages(child, globalRegistry, refs)
+		}
+	}
+}
+
+// imageRef returns the reference of an image map, or an empty string if the
+// map does not describe an image
+func imageRef(m map[string]interface{}, globalRegistry string) string {
+	repository, _ := m["repository"].(string)
+	if repository == "" {
+		return ""
+	}
+	// Unquoted tags like 1.0 are parsed as numbers
+	var tag string
+	if t := m["tag"]; t != nil {
+		tag = fmt.Sprint(t)
+	}
+	digest, _ := m["digest"].(string)
+	if tag == "" && digest == "" {
+		return ""
+	}
+
+	ref := repository
+	registry, _ := m["registry"].(string)
+	if globalRegistry != "" {
+		registry = globalRegistry
+	}
+	if registry != "" {
+		ref = registry + "/" + ref
+	}
+	if tag != "" {
+		ref += ":" + tag
+	}
+	if digest != "" {
+		ref += "@" + digest
+	}
+	return ref
+}
+
+// imagesAnnotations are the annotations listing the images of a chart, used
+// by Bitnami charts and Artifact Hub
+//
+//	images: |
+//	  - name: kafka
+//	    image: docker.io/bitnami/kafka:2.5.0-debian-10-r29
+var imagesAnnotations = []string{"images", "artifacthub.io/images"}
+
+// AnnotatedImages returns the container images listed in the annotations of a
+// chart, without the ones of its subcharts.
+func AnnotatedImages(metadata *chart.Metadata) ([]string, error) {
+	var images []string
+	for _, key := range imagesAnnotations {
+		annotation, ok := metadata.Annotations[key]
+		if !ok {
+			continue
+		}
+		var entries []struct {
+			Image string `json:"image"`
+		}
+		if err := yaml.Unmarshal([]byte(annotation), &entries); err != nil {
+			return nil, errors.Annotatef(err, "parsing %q annotation of %q chart", key, metadata.Name)
+		}
+		for _, e := range entries {
+			if e.Image != "" {
+				images = append(images, e.Image)
+			}
+		}
+	}
+	return images, nil
+}
+
+// normalizeImage returns the fully qualified name of an image, so references
+// like bitnami/kafka and docker.io/bitnami/kafka are equal
+func normalizeImage(image string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return image
+	}
+	return ref.Name()
+}
+
+// ImageHints returns the templates of a relok8s image hints file for the
+// images referenced by the values of a chart and its subcharts, found like in
+// Images. The values of the subcharts are nested under their name, like relok8s
+// renders them.
+//
+//	- "{{ .image.registry }}/{{ .image.repository }}:{{ .image.tag }}"
+//
+// The images of the charts listing them in their annotations are restricted to
+// these. The annotated images that are not referenced by the values of their
+// chart are returned as unmatched.
+func ImageHints(ch *chart.Chart) (hints []string, unmatched []string, err error) {
+	found := map[string]bool{}
+	if err := collectImageHints(ch, "", found, &unmatched); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	hints = make([]string, 0, len(found))
+	for hint := range found {
+		hints = append(hints, hint)
+	}
+	sort.Strings(hints)
+	return hints, unmatched, nil
+}
+
+// collectImageHints adds the image hints of a chart and its subcharts to
+// hints, and their annotated images without values to unmatched
+func collectImageHints(ch *chart.Chart, prefix string, hints map[string]bool, unmatched *[]string) error {
+	// Templates of the values of the chart, with the images they render
+	images := map[string]string{}
+	walkImageHints(ch.Values, prefix, images)
+	annotated, err := AnnotatedImages(ch.Metadata)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(annotated) == 0 {
+		for hint := range images {
+			hints[hint] = true
+		}
+	} else {
+		want := map[string]bool{}
+		for _, image := range annotated {
+			want[normalizeImage(image)] = true
+		}
+		matched := map[string]bool{}
+		for hint, image := range images {
+			if n := normalizeImage(image); want[n] {
+				hints[hint] = true
+				matched[n] = true
+			}
+		}
+		for _, image := range annotated {
+			if !matched[normalizeImage(image)] {
+				*unmatched = append(*unmatched, image)
+			}
+		}
+	}
+	for _, dep := range ch.Dependencies() {
+		if err := collectImageHints(dep, prefix+"."+dep.Name(), hints, unmatched); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// walkImageHints adds the image hints found in a values tree to images, with
+// the images they render. Lists and keys with dots cannot be addressed by the
+// templates, so they are not walked.
+func walkImageHints(m map[string]interface{}, path string, images map[string]string) {
+	if path != "" {
+		if hint := imageHint(m, path); hint != "" {
+			images[hint] = imageRef(m, "")
+			return
+		}
+	}
+	for k, v := range m {
+		child, ok := v.(map[string]interface{})
+		if !ok || k == "" || strings.ContainsAny(k, ". {}") {
+			continue
+		}
+		walkImageHints(child, path+"."+k, images)
+	}
+}
+// imageHint returns the template of an image map, or an empty string if the
+// map does not describe an image. Digests are preferred over tags.
+func imageHint(m map[string]interface{}, path string) string {
+	if imageRef(m, "") == "" {
+		return ""
+	}
+	hint := "{{ " + path + ".repository }}"
+	if registry, _ := m["registry"].(string); registry != "" {
+		hint = "{{ " + path + ".registry }}/" + hint
+	}
+	if digest, _ := m["digest"].(string); digest != "" {
+		return hint + "@{{ " + path + ".digest }}"
+	}
+	return hint + ":{{ " + path + ".tag }}"
+}
@@ -0,0 +1,86 @@
+package chart
+
+import (
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+)
+
+func TestImages(t *testing.T) {
+	ch, err := loader.LoadFile("../../testdata/kafka-10.3.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := Images(ch)
+	for _, want := range []string{
+		"docker.io/bitnami/kafka:2.5.0-debian-10-r29",
+		"docker.io/bitnami/minideb:buster",
+	} {
+		found := false
+		for _, got := range images {
+			found = found || got == want
+		}
+		if !found {
+			t.Errorf("%q image not found in %v", want, images)
+		}
+	}
+	for i := 1; i < len(images); i++ {
+		if images[i-1] >= images[i] {
+			t.Errorf("images are not sorted and unique: %v", images)
+		}
+	}
+}
+
+func TestImageHints(t *testing.T) {
+	ch, err := loader.LoadFile("../../testdata/kafka-10.3.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hints, unmatched, err := ImageHints(ch)
+	if err != nil || len(unmatched) > 0 {
+		t.Fatalf("got: %v, %v, want no error nor unmatched images", unmatched, err)
+	}
+	for _, want := range []string{
+		"{{ .image.registry }}/{{ .image.repository }}:{{ .image.tag }}",
+		"{{ .zookeeper.image.registry }}/{{ .zookeeper.image.repository }}:{{ .zookeeper.image.tag }}",
+	} {
+		found := false
+		for _, got := range hints {
+			found = found || got == want
+		}
+		if !found {
+			t.Errorf("%q hint not found in %v", want, hints)
+		}
+	}
+	// Images referenced by several values have one hint for each of them
+	if len(hints) < len(Images(ch)) {
+		t.Errorf("got: %d hints, want at least one for each of the %d images", len(hints), len(Images(ch)))
+	}
+}
+
+func TestImageHintsAnnotations(t *testing.T) {
+	ch, err := loader.LoadFile("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the annotated images are relocated
+	ch.Metadata.Annotations = map[string]string{
+		"images": "- name: apache\n  image: bitnami/apache:2.4.43-debian-10-r25\n- name: os-shell\n  image: docker.io/bitnami/os-shell:11\n",
+	}
+	hints, unmatched, err := ImageHints(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"{{ .image.registry }}/{{ .image.repository }}:{{ .image.tag }}"}; !reflect.DeepEqual(hints, want) {
+		t.Errorf("got: %q hints, want: %q", hints, want)
+	}
+	if want := []string{"docker.io/bitnami/os-shell:11"}; !reflect.DeepEqual(unmatched, want) {
+		t.Errorf("got: %q unmatched images, want: %q", unmatched, want)
+	}
+
+	ch.Metadata.Annotations["images"] = "image: {"
+	if _, _, err := ImageHints(ch); err == nil {
+		t.Error("got no error, want an error for an invalid images annotation")
+	}
+}
@@ -0,0 +1,71 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/juju/errors"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// jsonPatchOperation is the JSON representation of an RFC6902 operation
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+	From  string          `json:"from,omitempty"`
+}
+
+// applyJSONPatch applies an RFC6902 JSON patch to a YAML file.
+//
+// The comments and formatting of the unchanged fields are preserved.
+func applyJSONPatch(file string, ops []*api.JSONPatchOperation) error {
+	patch, err := decodeJSONPatch(ops)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	doc, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return errors.Annotatef(err, "parsing %q file", file)
+	}
+	patched, err := patch.Apply(doc)
+	if err != nil {
+		return errors.Annotatef(err, "patching %q file", file)
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(writeChartFile(file, v))
+}
+
+// decodeJSONPatch builds a JSON patch from the configured operations
+func decodeJSONPatch(ops []*api.JSONPatchOperation) (jsonpatch.Patch, error) {
+	var patch []jsonPatchOperation
+	for _, op := range ops {
+		p := jsonPatchOperation{Op: op.GetOp(), Path: op.GetPath(), From: op.GetFrom()}
+		if op.GetValue() != "" {
+			value, err := yaml.YAMLToJSON([]byte(op.GetValue()))
+			if err != nil {
+				return nil, errors.Annotatef(err, "parsing %q value of %q operation", op.GetPath(), op.GetOp())
+			}
+			p.Value = value
+		}
+		patch = append(patch, p)
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return jsonpatch.DecodePatch(data)
+}
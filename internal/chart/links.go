@@ -0,0 +1,68 @@
+package chart
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// rewriteLinks rewrites the home and sources URLs in the Chart.yaml file of an
+// uncompressed chart
+func rewriteLinks(chartPath string, links *api.Links, opts transformOptions) error {
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	home := rewriteURL(metadata.Home, links.GetRewrites())
+	if links.GetHome() != "" {
+		if home, err = renderChartTemplate(chartPath, "home", links.GetHome(), opts); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	var sources []interface{}
+	for _, s := range metadata.Sources {
+		sources = appendURL(sources, rewriteURL(s, links.GetRewrites()))
+	}
+	for _, s := range links.GetAppendSources() {
+		u, err := renderChartTemplate(chartPath, "source", s, opts)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		sources = appendURL(sources, u)
+	}
+
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		if home != "" {
+			metadata["home"] = home
+		}
+		if len(sources) > 0 {
+			metadata["sources"] = sources
+		}
+	})
+}
+
+// rewriteURL replaces the prefix of a URL with the first matching rewrite
+func rewriteURL(u string, rewrites []*api.Links_Rewrite) string {
+	for _, r := range rewrites {
+		if strings.HasPrefix(u, r.GetOld()) {
+			return r.GetNew() + strings.TrimPrefix(u, r.GetOld())
+		}
+	}
+	return u
+}
+
+// appendURL appends a URL to a list unless it is empty or already listed
+func appendURL(urls []interface{}, u string) []interface{} {
+	if u == "" {
+		return urls
+	}
+	for _, c := range urls {
+		if c == u {
+			return urls
+		}
+	}
+	return append(urls, u)
+}
@@ -0,0 +1,32 @@
+package chart
+
+import (
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/lint/support"
+	"k8s.io/klog"
+)
+
+// Lint validates a packaged chart using the Helm loader and linter.
+//
+// It returns an error aggregating the linter messages with error severity.
+// Messages with lower severity are only logged.
+func Lint(tgz string) error {
+	if _, err := loader.LoadFile(tgz); err != nil {
+		return errors.Annotatef(err, "loading %q", tgz)
+	}
+
+	result := action.NewLint().Run([]string{tgz}, nil)
+	var errs error
+	for _, msg := range result.Messages {
+		if msg.Severity > support.InfoSev && msg.Severity < support.ErrorSev {
+			klog.V(3).Infof("Lint %s: %s", tgz, msg)
+		}
+	}
+	for _, err := range result.Errors {
+		errs = multierror.Append(errs, err)
+	}
+	return errors.Trace(errs)
+}
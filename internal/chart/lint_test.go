@@ -0,0 +1,66 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		template string
+		wantErr  bool
+	}{
+		{
+			desc:     "valid chart",
+			template: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n",
+		},
+		{
+			desc:     "broken template",
+			template: "{{ .Values.foo | nonexistent }}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			workdir, err := ioutil.TempDir("", "charts-syncer-tests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(workdir)
+
+			chartPath := path.Join(workdir, "mychart")
+			if err := os.MkdirAll(path.Join(chartPath, "templates"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			chartYaml := "apiVersion: v2\nname: mychart\nversion: 1.0.0\nicon: https://example.com/icon.png\n"
+			if err := ioutil.WriteFile(path.Join(chartPath, ChartFilename), []byte(chartYaml), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path.Join(chartPath, ValuesFilename), []byte("foo: bar\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(path.Join(chartPath, "templates", "cm.yaml"), []byte(tc.template), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			tgz, err := Package(chartPath, workdir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Lint(tgz)
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Errorf("got error: %v, want error: %t", err, want)
+			}
+		})
+	}
+}
+
+func TestLintTestdataChart(t *testing.T) {
+	if err := Lint("../../testdata/apache-7.3.15.tgz"); err != nil {
+		t.Errorf("unexpected lint error: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package chart
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// templateData is the data available to the templates of notices, files and
+// READMEs
+type templateData struct {
+	Name       string
+	Version    string
+	AppVersion string
+	// Chart is the whole metadata of the chart
+	Chart *chart.Metadata
+	// SourceRepo and TargetRepo are the URLs of the repositories the chart is
+	// synced from and to
+	SourceRepo string
+	TargetRepo string
+	SyncTime   time.Time
+}
+
+// appendNotice renders a notice and appends it to the notes and README files
+// of an uncompressed chart, creating them if needed.
+//
+// The notice is rendered before the chart is packaged, so it must not
+// contain Helm template actions once rendered.
+func appendNotice(chartPath, notice string, opts transformOptions) error {
+	text, err := renderChartTemplate(chartPath, "notice", notice, opts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	text = strings.TrimRight(text, "\n") + "\n"
+
+	for _, f := range []string{NotesFilename, ReadmeFilename} {
+		if err := appendToFile(path.Join(chartPath, f), text); err != nil {
+			return errors.Annotatef(err, "appending notice to %q", f)
+		}
+	}
+	return nil
+}
+
+// addFiles renders the templates of the files and writes them into an
+// uncompressed chart, replacing the existing ones
+func addFiles(chartPath string, files []*api.ChartFile, opts transformOptions) error {
+	for _, f := range files {
+		tmpl, err := ioutil.ReadFile(f.GetTemplate())
+		if err != nil {
+			return errors.Annotatef(err, "reading %q template", f.GetTemplate())
+		}
+		text, err := renderChartTemplate(chartPath, f.GetTemplate(), string(tmpl), opts)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		dest := path.Join(chartPath, path.Clean("/"+f.GetPath()))
+		if err := os.MkdirAll(path.Dir(dest), 0755); err != nil {
+			return errors.Trace(err)
+		}
+		if err := ioutil.WriteFile(dest, []byte(text), 0644); err != nil {
+			return errors.Annotatef(err, "adding %q file", f.GetPath())
+		}
+	}
+	return nil
+}
+
+// writeReadme renders the README template and replaces or prepends the README
+// file of an uncompressed chart with it
+func writeReadme(chartPath string, readme *api.Readme, opts transformOptions) error {
+	tmpl, err := ioutil.ReadFile(readme.GetTemplate())
+	if err != nil {
+		return errors.Annotatef(err, "reading %q template", readme.GetTemplate())
+	}
+	text, err := renderChartTemplate(chartPath, readme.GetTemplate(), string(tmpl), opts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	text = strings.TrimRight(text, "\n") + "\n"
+
+	readmeFile := path.Join(chartPath, ReadmeFilename)
+	if readme.GetPrepend() {
+		current, err := ioutil.ReadFile(readmeFile)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+		if len(current) > 0 {
+			text += "\n" + string(current)
+		}
+	}
+	return ioutil.WriteFile(readmeFile, []byte(text), 0644)
+}
+
+// renderChartTemplate renders a template with the metadata of an
+// uncompressed chart
+func renderChartTemplate(chartPath, name, text string, opts transformOptions) (string, error) {
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Annotatef(err, "parsing %q template", name)
+	}
+	var buf bytes.Buffer
+	data := templateData{
+		Name:       metadata.Name,
+		Version:    metadata.Version,
+		AppVersion: metadata.AppVersion,
+		Chart:      metadata,
+		SourceRepo: opts.sourceRepo,
+		TargetRepo: opts.targetRepo,
+		SyncTime:   opts.syncTime,
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.Annotatef(err, "rendering %q template", name)
+	}
+	return buf.String(), nil
+}
+
+// appendToFile appends text to a file as a new paragraph
+func appendToFile(file, text string) error {
+	current, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	if len(current) > 0 {
+		text = strings.TrimRight(string(current), "\n") + "\n\n" + text
+	}
+	if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	return ioutil.WriteFile(file, []byte(text), 0644)
+}
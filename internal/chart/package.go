@@ -0,0 +1,96 @@
+package chart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// Package packages the uncompressed chart in chartPath into outdir and
+// returns the path to the packaged chart.
+//
+// Unlike `helm package`, the files are archived as they are on disk: file
+// modes, symlinks and empty directories are preserved. The .helmignore rules
+// of the chart are honored, followed by the ignore ones.
+func Package(chartPath, outdir string, ignore ...string) (string, error) {
+	// Make sure the chart is still loadable after our modifications
+	if _, err := loader.LoadDir(chartPath); err != nil {
+		return "", errors.Annotatef(err, "loading chart from %q", chartPath)
+	}
+
+	metadata, err := readChartMetadata(chartPath)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	rules, err := newIgnoreRules(chartPath, ignore)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+
+	tarball := path.Join(outdir, fmt.Sprintf("%s-%s.tgz", metadata.Name, metadata.Version))
+	if err := utils.Tar(chartPath, metadata.Name, tarball, rules.Ignore); err != nil {
+		os.Remove(tarball)
+		return "", errors.Annotatef(err, "packaging %q", chartPath)
+	}
+	return tarball, nil
+}
+
+// Rewrite uncompresses a packaged chart, lets fn modify it and packages it
+// again in place, honoring the ignore rules like Package does
+func Rewrite(tgz string, fn func(chartPath string) error, ignore ...string) error {
+	c, err := loader.LoadFile(tgz)
+	if err != nil {
+		return errors.Annotatef(err, "loading %q", tgz)
+	}
+
+	workdir, err := ioutil.TempDir("", "charts-syncer")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.RemoveAll(workdir)
+	if err := utils.Untar(tgz, workdir); err != nil {
+		return errors.Trace(err)
+	}
+	chartPath, err := FindChartPath(workdir, c.Name())
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if err := fn(chartPath); err != nil {
+		return errors.Trace(err)
+	}
+
+	outdir, err := ioutil.TempDir(path.Dir(tgz), "charts-syncer")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.RemoveAll(outdir)
+	packaged, err := Package(chartPath, outdir, ignore...)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(packaged, tgz))
+}
+
+// readChartMetadata reads the Chart.yaml file of an uncompressed chart
+func readChartMetadata(chartPath string) (*chart.Metadata, error) {
+	chartFile := path.Join(chartPath, ChartFilename)
+	data, err := ioutil.ReadFile(chartFile)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	metadata := &chart.Metadata{}
+	if err := yaml.Unmarshal(data, metadata); err != nil {
+		return nil, errors.Annotatef(err, "unmarshaling %q file", chartFile)
+	}
+	return metadata, nil
+}
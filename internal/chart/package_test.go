@@ -0,0 +1,112 @@
+package chart
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/provenance"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+func TestPackage(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	if err := utils.Untar("../../testdata/apache-7.3.15.tgz", workdir); err != nil {
+		t.Fatal(err)
+	}
+	chartPath := path.Join(workdir, "apache")
+	script := path.Join(chartPath, "files", "run.sh")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// .helmignore rules are honored
+	if err := ioutil.WriteFile(path.Join(chartPath, HelmIgnoreFilename), []byte("*.bak\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(chartPath, "values.yaml.bak"), []byte("backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// And so are the additional rules
+	if err := os.Mkdir(path.Join(chartPath, "ci"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(chartPath, "ci", "values.yaml"), []byte("ci: true"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outdir := path.Join(workdir, "out")
+	if err := os.Mkdir(outdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tgz, err := Package(chartPath, outdir, "ci/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tgz, path.Join(outdir, "apache-7.3.15.tgz"); got != want {
+		t.Errorf("wrong packaged chart path, got: %q, want: %q", got, want)
+	}
+
+	resdir := path.Join(workdir, "result")
+	if err := utils.Untar(tgz, resdir); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path.Join(resdir, "apache", "files", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0755); got != want {
+		t.Errorf("wrong file mode, got: %v, want: %v", got, want)
+	}
+	if ok, _ := utils.FileExists(path.Join(resdir, "apache", "values.yaml.bak")); ok {
+		t.Errorf("ignored file should not be packaged")
+	}
+	if ok, _ := utils.FileExists(path.Join(resdir, "apache", "ci")); ok {
+		t.Errorf("additionally ignored directory should not be packaged")
+	}
+}
+
+func TestPackageIsReproducible(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	var digests []string
+	for i, mtime := range []time.Time{time.Now(), time.Now().Add(-24 * time.Hour)} {
+		dir := path.Join(workdir, fmt.Sprintf("run-%d", i))
+		if err := utils.Untar("../../testdata/kafka-10.3.3.tgz", dir); err != nil {
+			t.Fatal(err)
+		}
+		chartPath := path.Join(dir, "kafka")
+		if err := os.Chtimes(path.Join(chartPath, ChartFilename), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		tgz, err := Package(chartPath, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.Open(tgz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest, err := provenance.Digest(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		digests = append(digests, digest)
+	}
+	if digests[0] != digests[1] {
+		t.Errorf("packaging the same chart twice produced different digests: %v", digests)
+	}
+}
@@ -0,0 +1,87 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/provenance"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const (
+	// ProvenanceAnnotation is the Chart.yaml annotation added to the charts
+	// that keep their upstream provenance file after being repackaged
+	ProvenanceAnnotation = "charts-syncer/provenance"
+	// ProvenanceInvalidated is the value of ProvenanceAnnotation
+	ProvenanceInvalidated = "upstream signature does not match the repackaged chart"
+)
+
+// Annotate adds annotations to the Chart.yaml file of a packaged chart and
+// repackages it in place.
+func Annotate(tgz string, annotations map[string]string) error {
+	return Rewrite(tgz, func(chartPath string) error {
+		return annotateChart(chartPath, annotations)
+	})
+}
+
+// Sign signs a packaged chart and returns the content of its provenance file
+func Sign(tgz string, key *api.SigningKey) ([]byte, error) {
+	signer, err := provenance.NewFromKeyring(key.GetKeyring(), key.GetName())
+	if err != nil {
+		return nil, errors.Annotatef(err, "loading %q key from %q", key.GetName(), key.GetKeyring())
+	}
+	// This also fails if the key is not found
+	if err := signer.DecryptKey(passphraseFetcher(key.GetPassphraseFile())); err != nil {
+		return nil, errors.Annotatef(err, "decrypting %q key", key.GetName())
+	}
+
+	sig, err := signer.ClearSign(tgz)
+	if err != nil {
+		return nil, errors.Annotatef(err, "signing %q", tgz)
+	}
+	return []byte(sig), nil
+}
+
+// Verify checks the provenance file of a packaged chart against a keyring.
+// The package must keep the file name it was signed with.
+func Verify(tgz string, prov []byte, keyring string) error {
+	verifier, err := provenance.NewFromKeyring(keyring, "")
+	if err != nil {
+		return errors.Annotatef(err, "loading %q keyring", keyring)
+	}
+	f, err := ioutil.TempFile("", "charts-syncer-*.prov")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(prov)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := verifier.Verify(tgz, f.Name()); err != nil {
+		return errors.Annotatef(err, "verifying %q", filepath.Base(tgz))
+	}
+	return nil
+}
+
+// passphraseFetcher returns a provenance.PassphraseFetcher reading the
+// passphrase from a file
+func passphraseFetcher(file string) provenance.PassphraseFetcher {
+	return func(_ string) ([]byte, error) {
+		if file == "" {
+			return nil, errors.New("the signing key is encrypted but no passphrase file was provided")
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Annotatef(err, "reading passphrase file")
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+}
@@ -0,0 +1,106 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+func TestAnnotate(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	tgz := path.Join(workdir, "apache-7.3.15.tgz")
+	if err := utils.CopyFile(tgz, "../../testdata/apache-7.3.15.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Annotate(tgz, map[string]string{ProvenanceAnnotation: ProvenanceInvalidated}); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loader.LoadFile(tgz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Metadata.Annotations[ProvenanceAnnotation], ProvenanceInvalidated; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := c.Metadata.Version, "7.3.15"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestSign(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	passphraseFile := path.Join(workdir, "passphrase")
+	if err := ioutil.WriteFile(passphraseFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		desc      string
+		key       *api.SigningKey
+		shouldErr bool
+	}{
+		{
+			desc: "sign with a key without passphrase",
+			key:  &api.SigningKey{Keyring: "../../testdata/signing/helm-test-key.secret", Name: "helm-testing@helm.sh"},
+		},
+		{
+			desc: "sign with an encrypted key",
+			key:  &api.SigningKey{Keyring: "../../testdata/signing/helm-password-key.secret", Name: "fake@helm.sh", PassphraseFile: passphraseFile},
+		},
+		{
+			desc:      "encrypted key without passphrase",
+			key:       &api.SigningKey{Keyring: "../../testdata/signing/helm-password-key.secret", Name: "fake@helm.sh"},
+			shouldErr: true,
+		},
+		{
+			desc:      "unknown key",
+			key:       &api.SigningKey{Keyring: "../../testdata/signing/helm-test-key.secret", Name: "john@example.com"},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tgz := "../../testdata/apache-7.3.15.tgz"
+			prov, err := Sign(tgz, tc.key)
+			if tc.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			provFile := path.Join(workdir, "apache-7.3.15.tgz.prov")
+			if err := ioutil.WriteFile(provFile, prov, 0644); err != nil {
+				t.Fatal(err)
+			}
+			verifier, err := provenance.NewFromKeyring(tc.key.GetKeyring(), tc.key.GetName())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := verifier.Verify(tgz, provFile); err != nil {
+				t.Errorf("provenance file does not verify the chart: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package chart
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+)
+
+// Strip removes the files and directories of an uncompressed chart matching
+// any of the patterns.
+//
+// Patterns are globs relative to the chart root where "**" matches any number
+// of directories, e.g. "tests/**". Patterns without slashes, e.g. "*.md", are
+// matched against the base name of the files at any depth, unless they have a
+// leading slash.
+func Strip(chartPath string, patterns []string) error {
+	return filepath.Walk(chartPath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel, err := filepath.Rel(chartPath, p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." || !matchesAny(patterns, rel) {
+			return nil
+		}
+		// The chart would not be loadable anymore
+		if rel == ChartFilename {
+			klog.Warningf("Refusing to strip %q file from %q chart", rel, chartPath)
+			return nil
+		}
+
+		klog.V(4).Infof("Stripping %q from %q chart", rel, chartPath)
+		if err := os.RemoveAll(p); err != nil {
+			return errors.Trace(err)
+		}
+		if fi.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+}
+
+// matchesAny returns whether a relative path matches any of the patterns
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+				return true
+			}
+			continue
+		}
+		// A leading slash anchors base name patterns to the chart root
+		if matchSegments(strings.Split(strings.TrimPrefix(pattern, "/"), "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against glob segments, where "**"
+// matches zero or more segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,90 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestStrip(t *testing.T) {
+	files := []string{
+		"Chart.yaml",
+		"README.md",
+		"values.yaml",
+		"ci/values-ci.yaml",
+		"templates/NOTES.txt",
+		"templates/deployment.yaml",
+		"templates/tests/test-connection.yaml",
+		"tests/unit/deployment_test.yaml",
+		"docs/usage.md",
+		"docs/README.md",
+	}
+	testCases := []struct {
+		desc     string
+		patterns []string
+		want     []string
+	}{
+		{
+			desc:     "directories and base names",
+			patterns: []string{"tests/**", "ci/**", "*.md"},
+			want:     []string{"Chart.yaml", "templates/NOTES.txt", "templates/deployment.yaml", "templates/tests/test-connection.yaml", "values.yaml"},
+		},
+		{
+			desc:     "double star at any depth",
+			patterns: []string{"**/tests/**"},
+			want:     []string{"Chart.yaml", "README.md", "ci/values-ci.yaml", "docs/README.md", "docs/usage.md", "templates/NOTES.txt", "templates/deployment.yaml", "values.yaml"},
+		},
+		{
+			desc:     "anchored file patterns",
+			patterns: []string{"templates/*.txt", "/README.md"},
+			want:     []string{"Chart.yaml", "ci/values-ci.yaml", "docs/README.md", "docs/usage.md", "templates/deployment.yaml", "templates/tests/test-connection.yaml", "tests/unit/deployment_test.yaml", "values.yaml"},
+		},
+		{
+			desc:     "Chart.yaml is never stripped",
+			patterns: []string{"*.yaml"},
+			want:     []string{"Chart.yaml", "README.md", "docs/README.md", "docs/usage.md", "templates/NOTES.txt"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "charts-syncer-tests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			for _, f := range files {
+				p := filepath.Join(dir, f)
+				if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(p, []byte("test"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := Strip(dir, tc.patterns); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			err = filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+				if err != nil || fi.IsDir() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, p)
+				got = append(got, filepath.ToSlash(rel))
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
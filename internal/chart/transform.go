@@ -0,0 +1,283 @@
+package chart
+
+import (
+	"io/ioutil"
+	"path"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// transformOptions is the context of a transformation, available to its
+// templates
+type transformOptions struct {
+	sourceRepo string
+	targetRepo string
+	syncTime   time.Time
+	dryRun     bool
+}
+
+// TransformOption configures the context of a transformation
+type TransformOption func(opts *transformOptions)
+
+// WithTransformSourceRepo configures the URL of the repository the chart is
+// synced from
+func WithTransformSourceRepo(url string) TransformOption {
+	return func(opts *transformOptions) {
+		opts.sourceRepo = url
+	}
+}
+
+// WithTransformTargetRepo configures the URL of the repository the chart is
+// synced to
+func WithTransformTargetRepo(url string) TransformOption {
+	return func(opts *transformOptions) {
+		opts.targetRepo = url
+	}
+}
+
+// WithTransformSyncTime configures the time the chart is synced at. It
+// defaults to the current time.
+func WithTransformSyncTime(t time.Time) TransformOption {
+	return func(opts *transformOptions) {
+		opts.syncTime = t
+	}
+}
+
+// WithTransformDryRun configures whether the chart is only transformed to
+// show what would be pushed. Icons are then rewritten without being downloaded
+// nor uploaded.
+func WithTransformDryRun(enable bool) TransformOption {
+	return func(opts *transformOptions) {
+		opts.dryRun = enable
+	}
+}
+
+// Transform applies the transformations selecting a chart to its uncompressed
+// directory, in order
+func Transform(chartPath, name string, transformations []*api.Transformation, topts ...TransformOption) error {
+	opts := transformOptions{syncTime: time.Now().UTC()}
+	for _, o := range topts {
+		o(&opts)
+	}
+	for _, t := range SelectTransformations(transformations, name) {
+		if t.GetConvertToV2() {
+			if err := convertToV2(chartPath); err != nil {
+				return errors.Annotatef(err, "converting to apiVersion %s", APIV2)
+			}
+		}
+		patches, err := globalValuesPatches(t.GetGlobals())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if patches = append(patches, t.GetValues()...); len(patches) > 0 {
+			valuesFile := path.Join(chartPath, ValuesFilename)
+			if ok, err := utils.FileExists(valuesFile); err != nil {
+				return errors.Trace(err)
+			} else if !ok {
+				klog.V(4).Infof("%q chart has no %s file, skipping values patches", name, ValuesFilename)
+			} else if err := PatchValues(valuesFile, patches); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(t.GetValuesPatch()) > 0 {
+			valuesFile := path.Join(chartPath, ValuesFilename)
+			if ok, err := utils.FileExists(valuesFile); err != nil {
+				return errors.Trace(err)
+			} else if !ok {
+				klog.V(4).Infof("%q chart has no %s file, skipping values JSON patch", name, ValuesFilename)
+			} else if err := applyJSONPatch(valuesFile, t.GetValuesPatch()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(t.GetDependencies()) > 0 {
+			if err := rewriteDependencies(chartPath, t.GetDependencies()); err != nil {
+				return errors.Annotatef(err, "rewriting dependencies")
+			}
+		}
+		if len(t.GetAnnotations()) > 0 {
+			if err := annotateChart(chartPath, t.GetAnnotations()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		switch p := t.GetAppVersionPolicy().(type) {
+		case *api.Transformation_AppVersion:
+			if err := setAppVersion(chartPath, p.AppVersion); err != nil {
+				return errors.Trace(err)
+			}
+		case *api.Transformation_AppVersionFromValues:
+			appVersion, err := lookupValuesScalar(path.Join(chartPath, ValuesFilename), p.AppVersionFromValues)
+			if err != nil {
+				return errors.Annotatef(err, "reading appVersion from values")
+			}
+			if err := setAppVersion(chartPath, appVersion); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(t.GetMaintainers()) > 0 {
+			if err := setMaintainers(chartPath, t.GetMaintainers(), t.GetAppendMaintainers()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if t.GetIcon() != nil {
+			if err := mirrorIcon(chartPath, name, t.GetIcon(), opts); err != nil {
+				return errors.Annotatef(err, "mirroring icon")
+			}
+		}
+		if t.GetLinks() != nil {
+			if err := rewriteLinks(chartPath, t.GetLinks(), opts); err != nil {
+				return errors.Annotatef(err, "rewriting links")
+			}
+		}
+		if len(t.GetChartPatch()) > 0 {
+			if err := applyJSONPatch(path.Join(chartPath, ChartFilename), t.GetChartPatch()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if t.GetReadme() != nil {
+			if err := writeReadme(chartPath, t.GetReadme(), opts); err != nil {
+				return errors.Annotatef(err, "writing README")
+			}
+		}
+		if t.GetNotice() != "" {
+			if err := appendNotice(chartPath, t.GetNotice(), opts); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(t.GetFiles()) > 0 {
+			if err := addFiles(chartPath, t.GetFiles(), opts); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if t.GetExec() != nil {
+			if err := execTransformer(chartPath, t.GetExec()); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if len(t.GetStrip()) > 0 {
+			if err := Strip(chartPath, t.GetStrip()); err != nil {
+				return errors.Annotatef(err, "stripping files")
+			}
+		}
+	}
+	return nil
+}
+
+// annotateChart adds annotations to the Chart.yaml file of an uncompressed
+// chart
+func annotateChart(chartPath string, annotations map[string]string) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		current, _ := metadata["annotations"].(map[string]interface{})
+		if current == nil {
+			current = map[string]interface{}{}
+		}
+		for k, v := range annotations {
+			current[k] = v
+		}
+		metadata["annotations"] = current
+	})
+}
+
+// SetName changes the name in the Chart.yaml file of an uncompressed chart.
+// The chart is packaged in a folder with the new name.
+func SetName(chartPath, name string) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		metadata["name"] = name
+	})
+}
+
+// SetVersion changes the version in the Chart.yaml file of an uncompressed
+// chart
+func SetVersion(chartPath, version string) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		metadata["version"] = version
+	})
+}
+
+// setAppVersion changes the appVersion in the Chart.yaml file of an
+// uncompressed chart
+func setAppVersion(chartPath, appVersion string) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		metadata["appVersion"] = appVersion
+	})
+}
+
+// setMaintainers replaces or extends the maintainers in the Chart.yaml file
+// of an uncompressed chart. Maintainers already listed are not duplicated.
+func setMaintainers(chartPath string, maintainers []*api.Maintainer, appendMaintainers bool) error {
+	return editChartFile(chartPath, func(metadata map[string]interface{}) {
+		var current []interface{}
+		if appendMaintainers {
+			current, _ = metadata["maintainers"].([]interface{})
+		}
+		for _, m := range maintainers {
+			entry := map[string]interface{}{"name": m.GetName()}
+			if m.GetEmail() != "" {
+				entry["email"] = m.GetEmail()
+			}
+			if m.GetUrl() != "" {
+				entry["url"] = m.GetUrl()
+			}
+			if !containsMaintainer(current, entry) {
+				current = append(current, entry)
+			}
+		}
+		metadata["maintainers"] = current
+	})
+}
+
+// containsMaintainer returns whether a maintainer with the same name and email
+// is in the list
+func containsMaintainer(maintainers []interface{}, m map[string]interface{}) bool {
+	for _, c := range maintainers {
+		if c, ok := c.(map[string]interface{}); ok && c["name"] == m["name"] && c["email"] == m["email"] {
+			return true
+		}
+	}
+	return false
+}
+
+// editChartFile lets fn modify the Chart.yaml file of an uncompressed chart
+func editChartFile(chartPath string, fn func(metadata map[string]interface{})) error {
+	// Use a generic map so fields unknown to the Helm library are preserved
+	chartFile := path.Join(chartPath, ChartFilename)
+	data, err := ioutil.ReadFile(chartFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	metadata := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return errors.Annotatef(err, "unmarshaling %q file", chartFile)
+	}
+	fn(metadata)
+	return errors.Trace(writeChartFile(chartFile, metadata))
+}
+
+// SelectTransformations returns the transformations applying to a chart
+func SelectTransformations(transformations []*api.Transformation, name string) []*api.Transformation {
+	var selected []*api.Transformation
+	for _, t := range transformations {
+		if selectsChart(t, name) {
+			selected = append(selected, t)
+		}
+	}
+	return selected
+}
+
+// selectsChart returns whether a transformation applies to a chart
+func selectsChart(t *api.Transformation, name string) bool {
+	if len(t.GetCharts()) == 0 {
+		return true
+	}
+	for _, c := range t.GetCharts() {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
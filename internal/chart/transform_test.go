@@ -0,0 +1,389 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestTransformAppVersion(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		transformations []*api.Transformation
+		want            string
+		shouldErr       bool
+	}{
+		{
+			desc: "literal appVersion",
+			transformations: []*api.Transformation{
+				{AppVersionPolicy: &api.Transformation_AppVersion{AppVersion: "2.4.43-internal"}},
+			},
+			want: "2.4.43-internal",
+		},
+		{
+			desc: "appVersion from patched values",
+			transformations: []*api.Transformation{
+				{
+					Values: []*api.ValuesPatch{
+						{Path: ".image.tag", Op: &api.ValuesPatch_Set{Set: "2.4.43-debian-10-r25@sha256:0123"}},
+					},
+					AppVersionPolicy: &api.Transformation_AppVersionFromValues{AppVersionFromValues: ".image.tag"},
+				},
+			},
+			want: "2.4.43-debian-10-r25@sha256:0123",
+		},
+		{
+			desc: "not selected chart",
+			transformations: []*api.Transformation{
+				{Charts: []string{"zookeeper"}, AppVersionPolicy: &api.Transformation_AppVersion{AppVersion: "3.6.1"}},
+			},
+			want: "2.4.43",
+		},
+		{
+			desc: "missing values key",
+			transformations: []*api.Transformation{
+				{AppVersionPolicy: &api.Transformation_AppVersionFromValues{AppVersionFromValues: ".image.digest"}},
+			},
+			shouldErr: true,
+		},
+		{
+			desc: "non-scalar values key",
+			transformations: []*api.Transformation{
+				{AppVersionPolicy: &api.Transformation_AppVersionFromValues{AppVersionFromValues: ".image"}},
+			},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+			err := Transform(chartPath, "apache", tc.transformations)
+			if tc.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			metadata, err := readChartMetadata(chartPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := metadata.AppVersion; got != tc.want {
+				t.Errorf("got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTransformNotice(t *testing.T) {
+	chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+	transformations := []*api.Transformation{
+		{Notice: "Mirrored {{ .Name }} {{ .Version }} ({{ .AppVersion }}). Support: support@example.com\n"},
+	}
+	if err := Transform(chartPath, "apache", transformations); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "\n\nMirrored apache 7.3.15 (2.4.43). Support: support@example.com\n"
+	for _, f := range []string{NotesFilename, ReadmeFilename} {
+		data, err := ioutil.ReadFile(path.Join(chartPath, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(data), want) {
+			t.Errorf("%s does not end with the notice: %q", f, data)
+		}
+	}
+}
+
+func TestTransformMaintainers(t *testing.T) {
+	platform := &api.Maintainer{Name: "Platform Team", Email: "platform@example.com", Url: "https://example.com/platform"}
+	testCases := []struct {
+		desc   string
+		append bool
+		want   []*chart.Maintainer
+	}{
+		{
+			desc: "replace maintainers",
+			want: []*chart.Maintainer{{Name: "Platform Team", Email: "platform@example.com", URL: "https://example.com/platform"}},
+		},
+		{
+			desc:   "append maintainers",
+			append: true,
+			want: []*chart.Maintainer{
+				{Name: "Bitnami", Email: "containers@bitnami.com"},
+				{Name: "Platform Team", Email: "platform@example.com", URL: "https://example.com/platform"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+			// Applying the transformation twice does not duplicate maintainers
+			transformation := &api.Transformation{Maintainers: []*api.Maintainer{platform}, AppendMaintainers: tc.append}
+			if err := Transform(chartPath, "apache", []*api.Transformation{transformation, transformation}); err != nil {
+				t.Fatal(err)
+			}
+			metadata, err := readChartMetadata(chartPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(metadata.Maintainers, tc.want) {
+				t.Errorf("got: %+v, want: %+v", metadata.Maintainers, tc.want)
+			}
+		})
+	}
+}
+
+func TestTransformFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmpl := path.Join(dir, "LICENSE-THIRD-PARTY.tmpl")
+	if err := ioutil.WriteFile(tmpl, []byte("{{ .Name }} {{ .Version }} redistributed by Example Corp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+	transformations := []*api.Transformation{
+		{Files: []*api.ChartFile{
+			{Path: "LICENSE-THIRD-PARTY", Template: tmpl},
+			{Path: "compliance/NOTICE", Template: tmpl},
+		}},
+	}
+	if err := Transform(chartPath, "apache", transformations); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "apache 7.3.15 redistributed by Example Corp\n"
+	for _, f := range []string{"LICENSE-THIRD-PARTY", "compliance/NOTICE"} {
+		got, err := ioutil.ReadFile(path.Join(chartPath, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestTransformJSONPatch(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		transformation *api.Transformation
+		shouldErr      bool
+	}{
+		{
+			desc: "patch Chart.yaml and values.yaml",
+			transformation: &api.Transformation{
+				ChartPatch: []*api.JSONPatchOperation{
+					{Op: "add", Path: "/annotations", Value: "{category: WebServer}"},
+					{Op: "add", Path: "/keywords/-", Value: "internal"},
+					{Op: "remove", Path: "/home"},
+				},
+				ValuesPatch: []*api.JSONPatchOperation{
+					{Op: "replace", Path: "/image/registry", Value: "registry.example.com"},
+					{Op: "copy", From: "/image/tag", Path: "/metrics/image/tag"},
+				},
+			},
+		},
+		{
+			desc: "failed test operation",
+			transformation: &api.Transformation{
+				ChartPatch: []*api.JSONPatchOperation{{Op: "test", Path: "/name", Value: "nginx"}},
+			},
+			shouldErr: true,
+		},
+		{
+			desc: "missing path",
+			transformation: &api.Transformation{
+				ValuesPatch: []*api.JSONPatchOperation{{Op: "remove", Path: "/missing"}},
+			},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+			err := Transform(chartPath, "apache", []*api.Transformation{tc.transformation})
+			if tc.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			metadata, err := readChartMetadata(chartPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := metadata.Annotations["category"], "WebServer"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if got, want := metadata.Keywords[len(metadata.Keywords)-1], "internal"; got != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+			if metadata.Home != "" {
+				t.Errorf("got: %q, want no home", metadata.Home)
+			}
+			for p, want := range map[string]string{".image.registry": "registry.example.com", ".metrics.image.tag": "2.4.43-debian-10-r25"} {
+				got, err := lookupValuesScalar(path.Join(chartPath, ValuesFilename), p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%s got: %q, want: %q", p, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTransformReadme(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tmpl := path.Join(dir, "README.md.tmpl")
+	text := "# {{ .Name }}\n\n{{ .Chart.Description }} mirrored from {{ .SourceRepo }} on {{ .SyncTime.Format \"2006-01-02\" }}.\n\n" +
+		"    helm install my-{{ .Name }} {{ .TargetRepo }}/{{ .Name }} --version {{ .Version }}\n"
+	if err := ioutil.WriteFile(tmpl, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := []TransformOption{
+		WithTransformSourceRepo("https://charts.bitnami.com/bitnami"),
+		WithTransformTargetRepo("https://charts.example.com"),
+		WithTransformSyncTime(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	generated := "# apache\n\nChart for Apache HTTP Server mirrored from https://charts.bitnami.com/bitnami on 2020-06-01.\n\n" +
+		"    helm install my-apache https://charts.example.com/apache --version 7.3.15\n"
+
+	testCases := []struct {
+		desc    string
+		prepend bool
+	}{
+		{desc: "replace README"},
+		{desc: "prepend README", prepend: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+			readmeFile := path.Join(chartPath, ReadmeFilename)
+			original, err := ioutil.ReadFile(readmeFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			transformations := []*api.Transformation{{Readme: &api.Readme{Template: tmpl, Prepend: tc.prepend}}}
+			if err := Transform(chartPath, "apache", transformations, opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ioutil.ReadFile(readmeFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := generated
+			if tc.prepend {
+				want += "\n" + string(original)
+			}
+			if string(got) != want {
+				t.Errorf("got: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTransformLinks(t *testing.T) {
+	rewrites := []*api.Links_Rewrite{
+		{Old: "https://github.com/bitnami/", New: "https://git.example.com/mirrors/bitnami/"},
+		{Old: "https://github.com/", New: "https://git.example.com/mirrors/"},
+	}
+	testCases := []struct {
+		desc        string
+		links       *api.Links
+		wantHome    string
+		wantSources []string
+	}{
+		{
+			desc:        "rewrite prefixes",
+			links:       &api.Links{Rewrites: rewrites},
+			wantHome:    "https://httpd.apache.org",
+			wantSources: []string{"https://git.example.com/mirrors/bitnami/bitnami-docker-apache"},
+		},
+		{
+			desc: "replace home and append sources",
+			links: &api.Links{
+				Home:          "https://catalog.example.com/charts/{{ .Name }}",
+				AppendSources: []string{"https://git.example.com/charts/{{ .Name }}", "https://github.com/bitnami/bitnami-docker-apache"},
+			},
+			wantHome:    "https://catalog.example.com/charts/apache",
+			wantSources: []string{"https://github.com/bitnami/bitnami-docker-apache", "https://git.example.com/charts/apache"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+			if err := Transform(chartPath, "apache", []*api.Transformation{{Links: tc.links}}); err != nil {
+				t.Fatal(err)
+			}
+			metadata, err := readChartMetadata(chartPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metadata.Home != tc.wantHome {
+				t.Errorf("got: %q, want: %q", metadata.Home, tc.wantHome)
+			}
+			if !reflect.DeepEqual(metadata.Sources, tc.wantSources) {
+				t.Errorf("got: %v, want: %v", metadata.Sources, tc.wantSources)
+			}
+		})
+	}
+}
+
+func TestTransformGlobals(t *testing.T) {
+	chartPath := newChartPath(t, "../../testdata/apache-7.3.15.tgz", "apache")
+	transformations := []*api.Transformation{
+		{
+			Globals: &api.GlobalValues{ImageRegistry: "registry.example.com:5000", ImagePullSecrets: []string{"internal-pull-secret", "mirror-pull-secret"}},
+			// Values patches apply after the global values
+			Values: []*api.ValuesPatch{{Path: ".global.imagePullSecrets[1]", Op: &api.ValuesPatch_Delete{Delete: true}}},
+		},
+	}
+	if err := Transform(chartPath, "apache", transformations); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path.Join(chartPath, ValuesFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"imageRegistry":    "registry.example.com:5000",
+		"imagePullSecrets": []interface{}{"internal-pull-secret"},
+	}
+	if got := values["global"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
@@ -11,4 +11,6 @@ const (
 	RequirementsFilename     string = "requirements.yaml"
 	RequirementsLockFilename string = "requirements.lock"
 	ReadmeFilename           string = "README.md"
+	HelmIgnoreFilename       string = ".helmignore"
+	NotesFilename            string = "templates/NOTES.txt"
 )
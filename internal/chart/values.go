@@ -0,0 +1,334 @@
+package chart
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// valuesPathElem is an element of a values path: either a map key or a list
+// index
+type valuesPathElem struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// parseValuesPath parses a yq-style path like .image.registry,
+// .imagePullSecrets[0] or .commonLabels["app.kubernetes.io/part-of"]
+func parseValuesPath(p string) ([]valuesPathElem, error) {
+	var elems []valuesPathElem
+	s := strings.TrimPrefix(p, ".")
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, `["`):
+			end := strings.Index(s, `"]`)
+			if end < 0 {
+				return nil, errors.Errorf("invalid path %q: unterminated quoted key", p)
+			}
+			elems = append(elems, valuesPathElem{key: s[2:end], isKey: true})
+			s = s[end+2:]
+		case strings.HasPrefix(s, "["):
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, errors.Errorf("invalid path %q: unterminated index", p)
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil || index < 0 {
+				return nil, errors.Errorf("invalid path %q: %q is not a valid index", p, s[1:end])
+			}
+			elems = append(elems, valuesPathElem{index: index})
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, errors.Errorf("invalid path %q: empty key", p)
+			}
+			elems = append(elems, valuesPathElem{key: s[:end], isKey: true})
+			s = s[end:]
+		}
+		if strings.HasPrefix(s, ".") {
+			s = s[1:]
+			if s == "" {
+				return nil, errors.Errorf("invalid path %q: empty key", p)
+			}
+		} else if s != "" && !strings.HasPrefix(s, "[") {
+			return nil, errors.Errorf("invalid path %q: unexpected %q", p, s)
+		}
+	}
+	return elems, nil
+}
+
+// PatchValues applies patches to a values.yaml file.
+//
+// The file is edited as a YAML tree so comments, which are the documentation
+// of most charts, are preserved.
+func PatchValues(valuesFile string, patches []*api.ValuesPatch) error {
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return errors.Annotatef(err, "unmarshaling %q file", valuesFile)
+	}
+	if len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	for _, p := range patches {
+		if err := patchValues(doc, p); err != nil {
+			return errors.Annotatef(err, "patching %q file", valuesFile)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return errors.Trace(err)
+	}
+	if err := enc.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return ioutil.WriteFile(valuesFile, buf.Bytes(), 0644)
+}
+
+// lookupValuesScalar returns the scalar value of a key in a values.yaml file
+func lookupValuesScalar(valuesFile, p string) (string, error) {
+	elems, err := parseValuesPath(p)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	data, err := ioutil.ReadFile(valuesFile)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return "", errors.Annotatef(err, "unmarshaling %q file", valuesFile)
+	}
+	var n *yaml.Node
+	if len(doc.Content) > 0 {
+		n = lookupValue(doc.Content[0], elems)
+	}
+	if n == nil {
+		return "", errors.NotFoundf("%q key", p)
+	}
+	if n.Kind != yaml.ScalarNode || n.ShortTag() == "!!null" {
+		return "", errors.Errorf("%q key is not a scalar value", p)
+	}
+	return n.Value, nil
+}
+
+// patchValues applies a patch to a values document
+func patchValues(doc *yaml.Node, p *api.ValuesPatch) error {
+	elems, err := parseValuesPath(p.GetPath())
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	switch op := p.GetOp().(type) {
+	case *api.ValuesPatch_Set:
+		value := &yaml.Node{}
+		if err := yaml.Unmarshal([]byte(op.Set), value); err != nil {
+			return errors.Annotatef(err, "parsing value of %q", p.GetPath())
+		}
+		if len(value.Content) == 0 {
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str"}
+		} else {
+			value = value.Content[0]
+		}
+		return errors.Trace(setValue(doc, 0, elems, value))
+	case *api.ValuesPatch_Delete:
+		if !op.Delete {
+			return nil
+		}
+		if len(elems) == 0 {
+			return errors.Errorf("the root of the values can not be deleted")
+		}
+		parent := lookupValue(doc.Content[0], elems[:len(elems)-1])
+		if parent == nil || !deleteValue(parent, elems[len(elems)-1]) {
+			klog.V(4).Infof("%q key not found, nothing to delete", p.GetPath())
+		}
+		return nil
+	case *api.ValuesPatch_Replace_:
+		if op.Replace.GetOld() == "" {
+			return errors.Errorf("the substring to replace in %q can not be empty", p.GetPath())
+		}
+		n := lookupValue(doc.Content[0], elems)
+		if n == nil {
+			klog.V(4).Infof("%q key not found, nothing to replace", p.GetPath())
+			return nil
+		}
+		replaceStrings(n, op.Replace.GetOld(), op.Replace.GetNew())
+		return nil
+	default:
+		return errors.Errorf("no operation defined for %q", p.GetPath())
+	}
+}
+
+// setValue sets the value of the i-th child of parent following elems,
+// creating the missing keys
+func setValue(parent *yaml.Node, i int, elems []valuesPathElem, value *yaml.Node) error {
+	if len(elems) == 0 {
+		// Keep the comments of the replaced value
+		old := parent.Content[i]
+		if value.HeadComment == "" && value.LineComment == "" && value.FootComment == "" {
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		}
+		parent.Content[i] = value
+		return nil
+	}
+	n := parent.Content[i]
+	elem := elems[0]
+	if n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null" {
+		// Replace empty values by the expected collection
+		n = newCollection(elem)
+		parent.Content[i] = n
+	}
+
+	if elem.isKey {
+		if n.Kind != yaml.MappingNode {
+			return errors.Errorf("%q key can not be set in a non-map value", elem.key)
+		}
+		for j := 0; j+1 < len(n.Content); j += 2 {
+			if n.Content[j].Value == elem.key {
+				return setValue(n, j+1, elems[1:], value)
+			}
+		}
+		// Missing keys are appended to the map
+		blockStyle(n)
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: elem.key}, placeholder(elems[1:]))
+		return setValue(n, len(n.Content)-1, elems[1:], value)
+	}
+
+	if n.Kind != yaml.SequenceNode {
+		return errors.Errorf("index %d can not be set in a non-list value", elem.index)
+	}
+	switch {
+	case elem.index < len(n.Content):
+	case elem.index == len(n.Content):
+		// Setting the next index appends an element to the list
+		blockStyle(n)
+		n.Content = append(n.Content, placeholder(elems[1:]))
+	default:
+		return errors.Errorf("index %d is out of range", elem.index)
+	}
+	return setValue(n, elem.index, elems[1:], value)
+}
+
+// blockStyle makes empty collections written as {} or [] render in block
+// style once they are filled
+func blockStyle(n *yaml.Node) {
+	if len(n.Content) == 0 {
+		n.Style &^= yaml.FlowStyle
+	}
+}
+
+// placeholder returns the node holding a key to be created
+func placeholder(elems []valuesPathElem) *yaml.Node {
+	if len(elems) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+	}
+	return newCollection(elems[0])
+}
+
+// newCollection returns an empty collection able to hold elem
+func newCollection(elem valuesPathElem) *yaml.Node {
+	if elem.isKey {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+}
+
+// lookupValue returns the node found following elems, or nil if not found
+func lookupValue(n *yaml.Node, elems []valuesPathElem) *yaml.Node {
+	for _, elem := range elems {
+		var next *yaml.Node
+		switch {
+		case elem.isKey && n.Kind == yaml.MappingNode:
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				if n.Content[j].Value == elem.key {
+					next = n.Content[j+1]
+					break
+				}
+			}
+		case !elem.isKey && n.Kind == yaml.SequenceNode && elem.index < len(n.Content):
+			next = n.Content[elem.index]
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
+
+// deleteValue deletes elem from a map or a list and returns whether it was
+// found
+func deleteValue(n *yaml.Node, elem valuesPathElem) bool {
+	switch {
+	case elem.isKey && n.Kind == yaml.MappingNode:
+		for j := 0; j+1 < len(n.Content); j += 2 {
+			if n.Content[j].Value == elem.key {
+				n.Content = append(n.Content[:j], n.Content[j+2:]...)
+				return true
+			}
+		}
+	case !elem.isKey && n.Kind == yaml.SequenceNode && elem.index < len(n.Content):
+		n.Content = append(n.Content[:elem.index], n.Content[elem.index+1:]...)
+		return true
+	}
+	return false
+}
+
+// replaceStrings replaces old with new in every string value under n. Map
+// keys are left untouched.
+func replaceStrings(n *yaml.Node, old, new string) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		if n.ShortTag() == "!!str" {
+			n.Value = strings.ReplaceAll(n.Value, old, new)
+		}
+	case yaml.MappingNode:
+		for j := 1; j < len(n.Content); j += 2 {
+			replaceStrings(n.Content[j], old, new)
+		}
+	default:
+		for _, c := range n.Content {
+			replaceStrings(c, old, new)
+		}
+	}
+}
+
+// globalValuesPatches returns the values patches setting the well-known global
+// values
+func globalValuesPatches(g *api.GlobalValues) ([]*api.ValuesPatch, error) {
+	var patches []*api.ValuesPatch
+	if g.GetImageRegistry() != "" {
+		value, err := yaml.Marshal(g.GetImageRegistry())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		patches = append(patches, &api.ValuesPatch{Path: ".global.imageRegistry", Op: &api.ValuesPatch_Set{Set: string(value)}})
+	}
+	if len(g.GetImagePullSecrets()) > 0 {
+		value, err := yaml.Marshal(g.GetImagePullSecrets())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		patches = append(patches, &api.ValuesPatch{Path: ".global.imagePullSecrets", Op: &api.ValuesPatch_Set{Set: string(value)}})
+	}
+	return patches, nil
+}
@@ -0,0 +1,217 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const testValues = `## Global parameters
+global:
+  imageRegistry: ""
+  ## Pull secrets
+  imagePullSecrets: []
+image:
+  registry: docker.io # upstream registry
+  repository: bitnami/apache
+tests:
+  enabled: true
+extraEnv:
+  - name: URL
+    value: https://docker.io/v2
+`
+
+func TestParseValuesPath(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		path      string
+		want      []valuesPathElem
+		shouldErr bool
+	}{
+		{desc: "root", path: "."},
+		{
+			desc: "nested keys",
+			path: ".image.registry",
+			want: []valuesPathElem{{key: "image", isKey: true}, {key: "registry", isKey: true}},
+		},
+		{
+			desc: "without leading dot",
+			path: "image",
+			want: []valuesPathElem{{key: "image", isKey: true}},
+		},
+		{
+			desc: "list index",
+			path: ".extraEnv[0].name",
+			want: []valuesPathElem{{key: "extraEnv", isKey: true}, {index: 0}, {key: "name", isKey: true}},
+		},
+		{
+			desc: "quoted key",
+			path: `.commonLabels["app.kubernetes.io/part-of"]`,
+			want: []valuesPathElem{{key: "commonLabels", isKey: true}, {key: "app.kubernetes.io/part-of", isKey: true}},
+		},
+		{desc: "empty key", path: ".image..registry", shouldErr: true},
+		{desc: "trailing dot", path: ".image.", shouldErr: true},
+		{desc: "invalid index", path: ".extraEnv[a]", shouldErr: true},
+		{desc: "unterminated index", path: ".extraEnv[0", shouldErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := parseValuesPath(tc.path)
+			if tc.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(valuesPathElem{})); diff != "" {
+				t.Errorf("unexpected path elements (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPatchValues(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		patches   []*api.ValuesPatch
+		want      string
+		shouldErr bool
+	}{
+		{
+			desc: "set keys",
+			patches: []*api.ValuesPatch{
+				{Path: ".global.imageRegistry", Op: &api.ValuesPatch_Set{Set: "registry.internal"}},
+				{Path: ".global.imagePullSecrets[0]", Op: &api.ValuesPatch_Set{Set: "internal-pull-secret"}},
+				{Path: ".image.registry", Op: &api.ValuesPatch_Set{Set: "registry.internal"}},
+				{Path: `.commonLabels["example.com/team"]`, Op: &api.ValuesPatch_Set{Set: "platform"}},
+			},
+			want: `## Global parameters
+global:
+  imageRegistry: registry.internal
+  ## Pull secrets
+  imagePullSecrets:
+  - internal-pull-secret
+image:
+  registry: registry.internal # upstream registry
+  repository: bitnami/apache
+tests:
+  enabled: true
+extraEnv:
+- name: URL
+  value: https://docker.io/v2
+commonLabels:
+  example.com/team: platform
+`,
+		},
+		{
+			desc: "set a structured value",
+			patches: []*api.ValuesPatch{
+				{Path: ".tests", Op: &api.ValuesPatch_Set{Set: "{enabled: false, timeout: 30}"}},
+			},
+			want: `## Global parameters
+global:
+  imageRegistry: ""
+  ## Pull secrets
+  imagePullSecrets: []
+image:
+  registry: docker.io # upstream registry
+  repository: bitnami/apache
+tests: {enabled: false, timeout: 30}
+extraEnv:
+- name: URL
+  value: https://docker.io/v2
+`,
+		},
+		{
+			desc: "delete keys",
+			patches: []*api.ValuesPatch{
+				{Path: ".tests", Op: &api.ValuesPatch_Delete{Delete: true}},
+				{Path: ".extraEnv[0]", Op: &api.ValuesPatch_Delete{Delete: true}},
+				{Path: ".missing.key", Op: &api.ValuesPatch_Delete{Delete: true}},
+			},
+			want: `## Global parameters
+global:
+  imageRegistry: ""
+  ## Pull secrets
+  imagePullSecrets: []
+image:
+  registry: docker.io # upstream registry
+  repository: bitnami/apache
+extraEnv: []
+`,
+		},
+		{
+			desc: "replace substrings",
+			patches: []*api.ValuesPatch{
+				{Path: ".", Op: &api.ValuesPatch_Replace_{Replace: &api.ValuesPatch_Replace{Old: "docker.io", New: "registry.internal"}}},
+			},
+			want: `## Global parameters
+global:
+  imageRegistry: ""
+  ## Pull secrets
+  imagePullSecrets: []
+image:
+  registry: registry.internal # upstream registry
+  repository: bitnami/apache
+tests:
+  enabled: true
+extraEnv:
+- name: URL
+  value: https://registry.internal/v2
+`,
+		},
+		{
+			desc: "set a key in a non-map value",
+			patches: []*api.ValuesPatch{
+				{Path: ".image.registry.host", Op: &api.ValuesPatch_Set{Set: "registry.internal"}},
+			},
+			shouldErr: true,
+		},
+		{
+			desc: "set an out of range index",
+			patches: []*api.ValuesPatch{
+				{Path: ".extraEnv[3]", Op: &api.ValuesPatch_Set{Set: "{name: FOO}"}},
+			},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "charts-syncer-tests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			valuesFile := path.Join(dir, ValuesFilename)
+			if err := ioutil.WriteFile(valuesFile, []byte(testValues), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err = PatchValues(valuesFile, tc.patches)
+			if tc.shouldErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadFile(valuesFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("unexpected values.yaml (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
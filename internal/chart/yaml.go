@@ -0,0 +1,106 @@
+package chart
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+	"gopkg.in/yaml.v3"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// writeChartFile writes a chart file to disk.
+//
+// Existing files are edited as a YAML tree, so the comments, key order and
+// quoting of the fields that did not change are preserved and the upstream
+// file only differs in the intended fields.
+func writeChartFile(dest string, v interface{}) error {
+	data, err := sigsyaml.Marshal(v)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if ok, err := utils.FileExists(dest); err != nil {
+		return errors.Trace(err)
+	} else if !ok {
+		return ioutil.WriteFile(dest, data, 0644)
+	}
+
+	current, err := ioutil.ReadFile(dest)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	doc := &yaml.Node{}
+	updated := &yaml.Node{}
+	if err := yaml.Unmarshal(data, updated); err != nil {
+		return errors.Trace(err)
+	}
+	// Files that cannot be edited as a tree are replaced
+	if err := yaml.Unmarshal(current, doc); err != nil || len(doc.Content) == 0 || len(updated.Content) == 0 {
+		return ioutil.WriteFile(dest, data, 0644)
+	}
+	mergeYAMLNode(doc.Content[0], updated.Content[0])
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return errors.Trace(err)
+	}
+	if err := enc.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return ioutil.WriteFile(dest, buf.Bytes(), 0644)
+}
+
+// mergeYAMLNode updates a YAML node in place to the content of another one.
+//
+// Map keys missing in the new node are removed and new keys are appended. The
+// nodes keep their comments, and unchanged scalars keep their style.
+func mergeYAMLNode(dst, src *yaml.Node) {
+	if dst.Kind != src.Kind || (dst.Kind == yaml.ScalarNode && dst.ShortTag() != src.ShortTag()) {
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+		return
+	}
+
+	switch dst.Kind {
+	case yaml.MappingNode:
+		var content, added []*yaml.Node
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			if value := mappingValue(src, dst.Content[i].Value); value != nil {
+				mergeYAMLNode(dst.Content[i+1], value)
+				content = append(content, dst.Content[i], dst.Content[i+1])
+			}
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if mappingValue(dst, src.Content[i].Value) == nil {
+				added = append(added, src.Content[i], src.Content[i+1])
+			}
+		}
+		dst.Content = append(content, added...)
+	case yaml.SequenceNode:
+		for i, n := range src.Content {
+			if i < len(dst.Content) {
+				mergeYAMLNode(dst.Content[i], n)
+			} else {
+				dst.Content = append(dst.Content, n)
+			}
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+	case yaml.ScalarNode:
+		dst.Value = src.Value
+	}
+}
+
+// mappingValue returns the value of a key in a YAML mapping node, if any
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
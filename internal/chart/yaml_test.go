@@ -0,0 +1,71 @@
+package chart
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+)
+
+func TestWriteChartFilePreservesFormatting(t *testing.T) {
+	chartPath, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(chartPath)
+
+	upstream := `# Upstream header comment
+apiVersion: v2
+name: wordpress
+# The chart version
+version: 15.1.2 # bumped by the release pipeline
+appVersion: "6.0.1"
+description: 'Web publishing platform'
+x-internal-field: kept
+dependencies:
+- name: mariadb
+  # Database
+  version: 11.x.x
+  repository: https://charts.bitnami.com/bitnami
+  condition: mariadb.enabled
+`
+	chartFile := path.Join(chartPath, ChartFilename)
+	if err := ioutil.WriteFile(chartFile, []byte(upstream), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetVersion(chartPath, "15.1.2+mirror.1"); err != nil {
+		t.Fatal(err)
+	}
+	deps := []*chart.Dependency{
+		{Name: "mariadb", Version: "11.x.x", Repository: "https://charts.example.com", Condition: "mariadb.enabled"},
+	}
+	if err := setDependencies(chartPath, deps); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(chartFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Upstream header comment
+apiVersion: v2
+name: wordpress
+# The chart version
+version: 15.1.2+mirror.1 # bumped by the release pipeline
+appVersion: "6.0.1"
+description: 'Web publishing platform'
+x-internal-field: kept
+dependencies:
+- name: mariadb
+  # Database
+  version: 11.x.x
+  repository: https://charts.example.com
+  condition: mariadb.enabled
+`
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -51,14 +52,64 @@ func Load(config *api.Config) error {
 		return errors.Trace(err)
 	}
 
-	if len(config.GetCharts()) > 0 && len(config.GetSkipCharts()) > 0 {
-		return errors.New("\"charts\" and \"skipCharts\" properties can not be set at the same time")
+	return errors.Trace(checkCharts(config))
+}
+
+// LoadJSON unmarshalls the JSON of a configuration, like the spec of a
+// ChartSync resource, into Config struct. Unlike Load, the credentials are
+// not read from the environment.
+func LoadJSON(jsonBytes []byte, config *api.Config) error {
+	if err := jsonToProto(jsonBytes, config); err != nil {
+		return errors.Trace(fmt.Errorf("error unmarshalling config: %w", err))
 	}
+	if err := setAllRepoDefaults(config); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(checkCharts(config))
+}
 
+// checkCharts checks the charts properties of a configuration
+func checkCharts(config *api.Config) error {
+	for _, c := range config.SyncConfigs() {
+		if len(c.GetCharts()) > 0 && len(c.GetSkipCharts()) > 0 {
+			return errors.New("\"charts\" and \"skipCharts\" properties can not be set at the same time")
+		}
+	}
 	return nil
 }
 
 func setDefaultOverrides(config *api.Config) error {
+	if err := setAllRepoDefaults(config); err != nil {
+		return err
+	}
+
+	// Container registry authentication override. The environment only
+	// provides the credentials of a single source and target
+	if err := setAuthentication(config.GetSource(), config.GetTarget(), viper.GetString); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setAllRepoDefaults sets the default values of the sources and targets of a
+// configuration and of its syncs entries, which are configured like the whole
+// file
+func setAllRepoDefaults(config *api.Config) error {
+	if err := setRepoDefaults(config); err != nil {
+		return err
+	}
+	for _, entry := range config.GetSyncs() {
+		if err := setRepoDefaults(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRepoDefaults sets the default values of the source and targets of a
+// configuration
+func setRepoDefaults(config *api.Config) error {
 	if repo := config.GetSource().GetRepo(); repo != nil {
 		if !repo.GetDisableChartsIndex() && repo.GetChartsIndex() == "" {
 			if err := setDefaultChartsIndex(config); err != nil {
@@ -67,40 +118,42 @@ func setDefaultOverrides(config *api.Config) error {
 		}
 	}
 
-	// Target OCI Chart repositories do not use the custom index
-	if repo := config.GetTarget().GetRepo(); repo != nil {
-		if repo.Kind == api.Kind_OCI {
-			repo.DisableChartsIndex = true
-		}
-	}
-
-	if config.GetTarget() != nil && config.GetTarget().GetRepoName() == "" {
-		klog.V(4).Infof("'target.repoName' property is empty. Using %q default value", defaultRepoName)
-		config.GetTarget().RepoName = defaultRepoName
+	targets := config.GetTargets()
+	if config.GetTarget() != nil {
+		targets = append([]*api.Target{config.GetTarget()}, targets...)
 	}
+	for _, target := range targets {
+		// Target OCI Chart repositories do not use the custom index
+		if repo := target.GetRepo(); repo != nil {
+			if repo.Kind == api.Kind_OCI {
+				repo.DisableChartsIndex = true
+			}
+		}
 
-	// Container registry authentication override
-	if err := setAuthentication(config.GetSource(), config.GetTarget()); err != nil {
-		return err
+		if target.GetRepoName() == "" {
+			klog.V(4).Infof("'target.repoName' property is empty. Using %q default value", defaultRepoName)
+			target.RepoName = defaultRepoName
+		}
 	}
-
 	return nil
 }
 
 // Sets the authentication configuration for container images and Helm Chart repositories
 // It reads the configuration from the viper config repository which values might come from the config file, env vars or flags
-func setAuthentication(source *api.Source, target *api.Target) error {
+// setAuthentication sets the credentials of the source and target read by get
+// from their viper keys
+func setAuthentication(source *api.Source, target *api.Target, get func(key string) string) error {
 	// Source Chart and container images authentication
 	if source != nil {
 		// Helm Chart authentication
 		// NOTE: Getting entries one by one is required since they match the env variables defined and being overridden i.e SOURCE_containers.auth_REGISTRY
-		username, password := viper.GetString("source.repo.auth.username"), viper.GetString("source.repo.auth.password")
+		username, password := get("source.repo.auth.username"), get("source.repo.auth.password")
 		if username != "" && password != "" && source.GetRepo() != nil {
 			source.GetRepo().Auth = &api.Auth{Username: username, Password: password}
 		}
 
 		// Container images OCI repository authentication
-		username, password, registry := viper.GetString("source.containers.auth.username"), viper.GetString("source.containers.auth.password"), viper.GetString("source.containers.auth.registry")
+		username, password, registry := get("source.containers.auth.username"), get("source.containers.auth.password"), get("source.containers.auth.registry")
 		// Validation will happen in a later stage config.Validate()
 		// For now we set the struct value if any of the properties is available
 		if username != "" || password != "" || registry != "" {
@@ -110,7 +163,7 @@ func setAuthentication(source *api.Source, target *api.Target) error {
 
 	// Target Chart and container images authentication
 	if target != nil {
-		username, password := viper.GetString("target.repo.auth.username"), viper.GetString("target.repo.auth.password")
+		username, password := get("target.repo.auth.username"), get("target.repo.auth.password")
 		if username != "" && password != "" && target.GetRepo() != nil {
 			target.GetRepo().Auth = &api.Auth{Username: username, Password: password}
 		}
@@ -119,7 +172,7 @@ func setAuthentication(source *api.Source, target *api.Target) error {
 		// NOTE: the registry value is retrieved from target.Containerregistry instead of target.containers.auth.registry
 		// This is because as part of the target definition the registry is set to indicate where the images
 		// should be pushed to, so the authentication must match this registry
-		username, password, registry := viper.GetString("target.containers.auth.username"), viper.GetString("target.containers.auth.password"), viper.GetString("target.containerregistry")
+		username, password, registry := get("target.containers.auth.username"), get("target.containers.auth.password"), get("target.containerregistry")
 		if username != "" || password != "" {
 			target.Containers = &api.Containers{Auth: &api.Containers_ContainerAuth{Username: username, Password: password, Registry: registry}}
 		}
@@ -138,34 +191,125 @@ func yamlToProto(path string, v proto.Message) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	return errors.Trace(jsonToProto(jsonBytes, v))
+}
+
+// jsonToProto unmarshals the JSON of a configuration into the provided proto
+// message
+func jsonToProto(jsonBytes []byte, v proto.Message) error {
+	jsonBytes, err := expandChartFilters(jsonBytes)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	r := bytes.NewReader(jsonBytes)
 	err = pbjson.NewDecoder(r).Decode(v)
 	return errors.Trace(err)
 }
 
+// SetSecretAuthentication sets the credentials of the source and target of a
+// configuration from the data of a Secret. Its keys are the names of the
+// environment variables of the credentials, e.g. SOURCE_REPO_AUTH_USERNAME.
+func SetSecretAuthentication(config *api.Config, data map[string][]byte) error {
+	get := func(key string) string {
+		// The registry of the target is part of its definition
+		if key == "target.containerregistry" {
+			return config.GetTarget().GetContainerRegistry()
+		}
+		for _, k := range envBindings {
+			if k.key != key {
+				continue
+			}
+			for _, env := range []string{strings.ToUpper(strings.ReplaceAll(key, ".", "_")), k.envNameFallback} {
+				if v := data[env]; env != "" && len(v) > 0 {
+					return strings.TrimSpace(string(v))
+				}
+			}
+		}
+		return ""
+	}
+	return errors.Trace(setAuthentication(config.GetSource(), config.GetTarget(), get))
+}
+
+// expandChartFilters moves the filters of the `name: filter` entries of the
+// charts property to the chartFilters one, keeping only their names in charts,
+// in the whole file and in its syncs entries. A filter is either a semver
+// constraint or a ChartFilter object.
+func expandChartFilters(jsonBytes []byte) ([]byte, error) {
+	var config map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &config); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := expandConfigChartFilters(config, ""); err != nil {
+		return nil, errors.Trace(err)
+	}
+	syncs, _ := config["syncs"].([]interface{})
+	for i, entry := range syncs {
+		if m, ok := entry.(map[string]interface{}); ok {
+			if err := expandConfigChartFilters(m, fmt.Sprintf("syncs[%d].", i)); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	return json.Marshal(config)
+}
+
+// expandConfigChartFilters expands the charts entries of a configuration,
+// whose properties are prefixed by prefix in the errors
+func expandConfigChartFilters(config map[string]interface{}, prefix string) error {
+	entries, ok := config["charts"].([]interface{})
+	if !ok {
+		return nil
+	}
+	filters, _ := config["chartFilters"].(map[string]interface{})
+	if filters == nil {
+		filters = map[string]interface{}{}
+	}
+	for i, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if len(m) != 1 {
+			return errors.Errorf("\"%scharts[%d]\" should be a chart name or a single \"name: filter\" entry", prefix, i)
+		}
+		for name, filter := range m {
+			if constraint, ok := filter.(string); ok {
+				filter = map[string]interface{}{"versions": constraint}
+			}
+			filters[name] = filter
+			entries[i] = name
+		}
+	}
+	if len(filters) > 0 {
+		config["chartFilters"] = filters
+	}
+	return nil
+}
+
+// envBindings are the viper keys of the credentials read from the environment
+var envBindings = []struct {
+	// viper key associated with the env variable
+	key string
+	// name for the env variable in addition to the default one
+	// i.e source.containers.auth.registry => SOURCE_CONTAINERS_AUTH_REGISTRY
+	envNameFallback string
+}{
+	// Container Authentication
+	{key: "source.containers.auth.registry"}, {key: "source.containers.auth.username"}, {key: "source.containers.auth.password"},
+	// NOTE: target registry will be retrieved from target.containerregistry instead since it indicates
+	// where the images are going to be pushed to so duplication is not needed
+	{key: "target.containers.auth.username"}, {key: "target.containers.auth.password"},
+
+	// Helm Chart repository authentication. Maintaining previous name for compatibility reasons
+	{key: "source.repo.auth.username", envNameFallback: "SOURCE_AUTH_USERNAME"}, {key: "source.repo.auth.password", envNameFallback: "SOURCE_AUTH_PASSWORD"},
+	{key: "target.repo.auth.username", envNameFallback: "TARGET_AUTH_USERNAME"}, {key: "target.repo.auth.password", envNameFallback: "TARGET_AUTH_PASSWORD"},
+}
+
 // InitEnvBindings defines the env variables bindings associated with local viper keys
 func InitEnvBindings() error {
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	boundKeys := []struct {
-		// viper key associated with the env variable
-		key string
-		// name for the env variable in addition to the default one
-		// i.e source.containers.auth.registry => SOURCE_CONTAINERS_AUTH_REGISTRY
-		envNameFallback string
-	}{
-		// Container Authentication
-		{key: "source.containers.auth.registry"}, {key: "source.containers.auth.username"}, {key: "source.containers.auth.password"},
-		// NOTE: target registry will be retrieved from target.containerregistry instead since it indicates
-		// where the images are going to be pushed to so duplication is not needed
-		{key: "target.containers.auth.username"}, {key: "target.containers.auth.password"},
-
-		// Helm Chart repository authentication. Maintaining previous name for compatibility reasons
-		{key: "source.repo.auth.username", envNameFallback: "SOURCE_AUTH_USERNAME"}, {key: "source.repo.auth.password", envNameFallback: "SOURCE_AUTH_PASSWORD"},
-		{key: "target.repo.auth.username", envNameFallback: "TARGET_AUTH_USERNAME"}, {key: "target.repo.auth.password", envNameFallback: "TARGET_AUTH_PASSWORD"},
-	}
-
-	for _, k := range boundKeys {
+	for _, k := range envBindings {
 		if err := viper.BindEnv(k.key); err != nil {
 			return errors.Trace(err)
 		}
@@ -179,3 +179,84 @@ func TestGetAuthFromEnvVar(t *testing.T) {
 		})
 	}
 }
+
+func TestExpandChartFilters(t *testing.T) {
+	tests := map[string]struct {
+		input  string
+		want   string
+		errMsg string
+	}{
+		"chart names": {
+			input: `{"charts":["redis","etcd"]}`,
+			want:  `{"charts":["redis","etcd"]}`,
+		},
+		"semver constraint": {
+			input: `{"charts":["redis",{"nginx":"13.x"}]}`,
+			want:  `{"chartFilters":{"nginx":{"versions":"13.x"}},"charts":["redis","nginx"]}`,
+		},
+		"filter object": {
+			input: `{"charts":[{"nginx":{"versions":"~13"}}],"chartFilters":{"redis":{"versions":"^17"}}}`,
+			want:  `{"chartFilters":{"nginx":{"versions":"~13"},"redis":{"versions":"^17"}},"charts":["nginx"]}`,
+		},
+		"several charts in an entry": {
+			input:  `{"charts":[{"nginx":"~13","redis":"^17"}]}`,
+			errMsg: `"charts[0]" should be a chart name or a single "name: filter" entry`,
+		},
+		"syncs entries": {
+			input: `{"syncs":[{"charts":[{"nginx":"13.x"}]},{"charts":["redis"]}]}`,
+			want:  `{"syncs":[{"chartFilters":{"nginx":{"versions":"13.x"}},"charts":["nginx"]},{"charts":["redis"]}]}`,
+		},
+		"several charts in a syncs entry": {
+			input:  `{"syncs":[{"charts":["redis"]},{"charts":[{"nginx":"~13","redis":"^17"}]}]}`,
+			errMsg: `"syncs[1].charts[0]" should be a chart name or a single "name: filter" entry`,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := expandChartFilters([]byte(tc.input))
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("got: %v, want: %q", err, tc.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got: %s, want: %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetSecretAuthentication(t *testing.T) {
+	c := &api.Config{}
+	jsonBytes := []byte(`{"source":{"repo":{"kind":"HELM","url":"https://charts.example.com"}},"target":{"containerRegistry":"registry.example.com","repo":{"kind":"HARBOR","url":"https://harbor.example.com/chartrepo/library"}}}`)
+	if err := LoadJSON(jsonBytes, c); err != nil {
+		t.Fatal(err)
+	}
+	data := map[string][]byte{
+		"SOURCE_AUTH_USERNAME":            []byte("sUsername"),
+		"SOURCE_AUTH_PASSWORD":            []byte("sPassword"),
+		"TARGET_REPO_AUTH_USERNAME":       []byte("tUsername"),
+		"TARGET_REPO_AUTH_PASSWORD":       []byte("tPassword\n"),
+		"TARGET_CONTAINERS_AUTH_USERNAME": []byte("tcUsername"),
+		"TARGET_CONTAINERS_AUTH_PASSWORD": []byte("tcPassword"),
+	}
+	if err := SetSecretAuthentication(c, data); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.GetSource().GetRepo().GetAuth(), (&api.Auth{Username: "sUsername", Password: "sPassword"}); !proto.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := c.GetTarget().GetRepo().GetAuth(), (&api.Auth{Username: "tUsername", Password: "tPassword"}); !proto.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := c.GetTarget().GetContainers().GetAuth(), (&api.Containers_ContainerAuth{Username: "tcUsername", Password: "tcPassword", Registry: "registry.example.com"}); !proto.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if c.GetSource().GetContainers() != nil {
+		t.Errorf("got: %v source containers, want none", c.GetSource().GetContainers())
+	}
+}
@@ -0,0 +1,416 @@
+// Package controller reconciles the ChartSync custom resources: it syncs the
+// charts they describe periodically and records the outcome in their status.
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/config"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+)
+
+const (
+	// defaultInterval is the time between the syncs of a ChartSync without
+	// interval
+	defaultInterval = time.Hour
+	// maxChartResults is the number of chart versions listed in the status
+	maxChartResults = 100
+	// resyncPeriod is how often the ChartSync resources are listed again, in
+	// case a watch event was missed
+	resyncPeriod = 10 * time.Minute
+)
+
+// The phases of a ChartSync
+const (
+	PhaseSynced    = "Synced"
+	PhaseFailed    = "Failed"
+	PhaseInvalid   = "Invalid"
+	PhaseSuspended = "Suspended"
+)
+
+// GroupVersionResource is the resource of the ChartSync objects
+var GroupVersionResource = schema.GroupVersionResource{Group: "charts-syncer.bitnami.com", Version: "v1alpha1", Resource: "chartsyncs"}
+
+// SyncFunc syncs the charts of a configuration. It returns the summary of the
+// run, if it started, and the outcome of the charts it processed.
+type SyncFunc func(ctx context.Context, c *api.Config) (*state.Run, []syncer.ChartResult, error)
+
+// Status is the status of a ChartSync
+type Status struct {
+	// ObservedGeneration is the generation of the spec last synced
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	Phase              string `json:"phase,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastSyncTime       string `json:"lastSyncTime,omitempty"`
+	NextSyncTime       string `json:"nextSyncTime,omitempty"`
+	LastRun            *Run   `json:"lastRun,omitempty"`
+	// Charts are the outcome of the chart versions last synced, most recent
+	// first
+	Charts []ChartStatus `json:"charts,omitempty"`
+}
+
+// Run is the summary of a sync run in the status of a ChartSync
+type Run struct {
+	ID       string `json:"id"`
+	Charts   int64  `json:"charts"`
+	Synced   int64  `json:"synced"`
+	Failed   int64  `json:"failed"`
+	Duration string `json:"duration"`
+}
+
+// ChartStatus is the outcome of the last sync of a chart version
+type ChartStatus struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Phase    string `json:"phase"`
+	Error    string `json:"error,omitempty"`
+	SyncTime string `json:"syncTime"`
+}
+
+// spec is the parsed spec of a ChartSync: the properties of a configuration
+// file, besides the ones of the controller
+type spec struct {
+	config   *api.Config
+	interval time.Duration
+	suspend  bool
+	// secret is the name of the Secret with the credentials of the source
+	// and target
+	secret string
+}
+
+// Controller reconciles the ChartSync resources of a namespace, or of all the
+// namespaces. They are synced one after the other, as they share the workdir.
+type Controller struct {
+	client    dynamic.Interface
+	kube      kubernetes.Interface
+	namespace string
+	sync      SyncFunc
+	queue     workqueue.RateLimitingInterface
+	now       func() time.Time
+}
+
+// New returns a controller of the ChartSync resources of a namespace, or of
+// all the namespaces if it is empty
+func New(client dynamic.Interface, kube kubernetes.Interface, namespace string, sync SyncFunc) *Controller {
+	return &Controller{
+		client:    client,
+		kube:      kube,
+		namespace: namespace,
+		sync:      sync,
+		queue:     workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		now:       time.Now,
+	}
+}
+
+// Run watches the ChartSync resources and reconciles them until the context
+// is cancelled
+func (c *Controller) Run(ctx context.Context) error {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.client, resyncPeriod, c.namespace, nil)
+	informer := factory.ForResource(GroupVersionResource).Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueue,
+		// The updates of the status do not change the generation
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			o, okOld := oldObj.(*unstructured.Unstructured)
+			n, okNew := newObj.(*unstructured.Unstructured)
+			if okOld && okNew && o.GetGeneration() != n.GetGeneration() {
+				c.enqueue(newObj)
+			}
+		},
+	})
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return errors.Trace(ctx.Err())
+	}
+	klog.Infof("Watching the ChartSync resources")
+
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	for c.processNext(ctx) {
+	}
+	return nil
+}
+
+// enqueue queues the reconciliation of a ChartSync
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Warningf("unable to queue ChartSync: %v", err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// processNext reconciles the next queued ChartSync. It returns false once the
+// queue is shut down.
+func (c *Controller) processNext(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	requeue, err := c.reconcile(ctx, key.(string))
+	if err != nil {
+		klog.Errorf("unable to reconcile %q ChartSync: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if requeue > 0 {
+		c.queue.AddAfter(key, requeue)
+	}
+	return true
+}
+
+// reconcile syncs the charts of a ChartSync if its interval elapsed or its
+// spec changed since the last sync, and updates its status. It returns when
+// the ChartSync should be reconciled again, or 0 if it should not.
+func (c *Controller) reconcile(ctx context.Context, key string) (time.Duration, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	obj, err := c.client.Resource(GroupVersionResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.Trace(err)
+	}
+	status, err := readStatus(obj)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+
+	// The invalid specs are reconciled again once they are updated
+	s, err := parseSpec(obj)
+	if err != nil {
+		status.ObservedGeneration, status.Phase, status.Message, status.NextSyncTime = obj.GetGeneration(), PhaseInvalid, err.Error(), ""
+		return 0, errors.Trace(c.updateStatus(ctx, obj, status))
+	}
+	if s.suspend {
+		status.ObservedGeneration, status.Phase, status.Message, status.NextSyncTime = obj.GetGeneration(), PhaseSuspended, "", ""
+		return 0, errors.Trace(c.updateStatus(ctx, obj, status))
+	}
+
+	now := c.now()
+	if last, err := time.Parse(time.RFC3339, status.LastSyncTime); err == nil && status.ObservedGeneration == obj.GetGeneration() {
+		if next := last.Add(s.interval); next.After(now) {
+			return next.Sub(now), nil
+		}
+	}
+
+	klog.Infof("Syncing %s ChartSync...", key)
+	run, results, err := c.syncSpec(ctx, namespace, s)
+	if ctx.Err() != nil {
+		// The sync is resumed when the controller is started again
+		return 0, nil
+	}
+	status.ObservedGeneration = obj.GetGeneration()
+	status.LastSyncTime = now.UTC().Format(time.RFC3339)
+	status.NextSyncTime = now.Add(s.interval).UTC().Format(time.RFC3339)
+	status.Phase, status.Message = PhaseSynced, ""
+	if err != nil {
+		klog.Errorf("unable to sync %s ChartSync: %v", key, err)
+		status.Phase, status.Message = PhaseFailed, err.Error()
+	}
+	if run != nil {
+		status.LastRun = &Run{ID: run.ID, Charts: int64(run.Charts), Synced: int64(run.Synced), Failed: int64(run.Failed), Duration: run.Duration.Round(time.Second).String()}
+	}
+	status.Charts = mergeChartResults(status.Charts, results, now)
+	return s.interval, errors.Trace(c.updateStatus(ctx, obj, status))
+}
+
+// syncSpec syncs the charts of a ChartSync spec, with the credentials of its
+// Secret
+func (c *Controller) syncSpec(ctx context.Context, namespace string, s *spec) (*state.Run, []syncer.ChartResult, error) {
+	if s.secret != "" {
+		secret, err := c.kube.CoreV1().Secrets(namespace).Get(ctx, s.secret, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, errors.Annotatef(err, "reading %q credentials secret", s.secret)
+		}
+		if err := config.SetSecretAuthentication(s.config, secret.Data); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		if err := s.config.Validate(); err != nil {
+			return nil, nil, errors.Annotatef(err, "validating the credentials of %q secret", s.secret)
+		}
+	}
+	return c.sync(ctx, s.config)
+}
+
+// parseSpec parses and validates the spec of a ChartSync
+func parseSpec(obj *unstructured.Unstructured) (*spec, error) {
+	raw, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s := &spec{interval: defaultInterval}
+	if v, ok := raw["interval"]; ok {
+		str, _ := v.(string)
+		if s.interval, err = time.ParseDuration(str); err != nil || s.interval <= 0 {
+			return nil, errors.Errorf(`"interval" should be a positive duration, e.g. 30m: %v`, v)
+		}
+	}
+	if v, ok := raw["suspend"]; ok {
+		if s.suspend, ok = v.(bool); !ok {
+			return nil, errors.Errorf(`"suspend" should be a boolean: %v`, v)
+		}
+	}
+	if v, ok := raw["credentialsSecretRef"]; ok {
+		ref, _ := v.(map[string]interface{})
+		if s.secret, _ = ref["name"].(string); s.secret == "" {
+			return nil, errors.Errorf(`"credentialsSecretRef" should have a "name"`)
+		}
+	}
+	for _, key := range []string{"interval", "suspend", "credentialsSecretRef"} {
+		delete(raw, key)
+	}
+
+	// The rest of the spec is a configuration file
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.config = &api.Config{}
+	if err := config.LoadJSON(data, s.config); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// A ChartSync describes a single source and target, and there are no
+	// files to write the GitOps manifests to
+	for _, p := range []struct {
+		name string
+		set  bool
+	}{
+		{"targets", len(s.config.GetTargets()) > 0},
+		{"syncs", len(s.config.GetSyncs()) > 0},
+		{"schedule", s.config.GetSchedule() != ""},
+		{"flux", s.config.GetFlux() != nil},
+		{"argocd", s.config.GetArgocd() != nil},
+	} {
+		if p.set {
+			return nil, errors.NotSupportedf("%q in a ChartSync", p.name)
+		}
+	}
+	// The ChartSync resources can be created by the users of any namespace,
+	// and the controller pod can read the Secrets of all of them
+	if names := hostSettings(s.config); len(names) > 0 {
+		return nil, errors.NotSupportedf("%q in a ChartSync", names[0])
+	}
+	if s.config.GetSource() == nil || s.config.GetTarget() == nil {
+		return nil, errors.New(`"source" and "target" are required`)
+	}
+	if err := s.config.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return s, nil
+}
+
+// hostSettings returns the properties of a configuration running commands in
+// the controller pod, or reading and writing its files
+func hostSettings(c *api.Config) []string {
+	var names []string
+	add := func(name string, set bool) {
+		if set {
+			names = append(names, name)
+		}
+	}
+	for i, t := range c.GetTransformations() {
+		add(fmt.Sprintf("transformations[%d].exec", i), t.GetExec() != nil)
+		for j, f := range t.GetFiles() {
+			add(fmt.Sprintf("transformations[%d].files[%d].template", i, j), f.GetTemplate() != "")
+		}
+		add(fmt.Sprintf("transformations[%d].icon.downloadDir", i), t.GetIcon().GetDownloadDir() != "")
+		add(fmt.Sprintf("transformations[%d].readme.template", i), t.GetReadme().GetTemplate() != "")
+	}
+
+	src, dst := c.GetSource(), c.GetTarget()
+	add("source.repo.path", src.GetRepo().GetKind() == api.Kind_LOCAL || src.GetRepo().GetPath() != "")
+	for i, r := range src.GetAdditionalRepos() {
+		add(fmt.Sprintf("source.additionalRepos[%d].path", i), r.GetKind() == api.Kind_LOCAL || r.GetPath() != "")
+	}
+	add("source.intermediateBundlesPath", src.GetIntermediateBundlesPath() != "")
+	add("source.bundleDecryption", src.GetBundleDecryption() != nil)
+	add("source.verify.keyring", src.GetVerify().GetKeyring() != "")
+	add("target.repo.path", dst.GetRepo().GetKind() == api.Kind_LOCAL || dst.GetRepo().GetPath() != "")
+	add("target.intermediateBundlesPath", dst.GetIntermediateBundlesPath() != "")
+	add("target.bundleEncryption.gpgKeyring", dst.GetBundleEncryption().GetGpgKeyring() != "")
+	add("target.signingKey", dst.GetSigningKey() != nil)
+	add("target.cosign.key", dst.GetCosign().GetKey() != "")
+	add("target.cosign.passphraseFile", dst.GetCosign().GetPassphraseFile() != "")
+	add("target.cosign.identityTokenFile", dst.GetCosign().GetIdentityTokenFile() != "")
+	add("signingKey", c.GetSigningKey() != nil)
+	add("state.file", c.GetState().GetFile() != "")
+	add("state.kubernetes.kubeconfig", c.GetState().GetKubernetes().GetKubeconfig() != "")
+	return names
+}
+
+// readStatus returns the status of a ChartSync
+func readStatus(obj *unstructured.Unstructured) (*Status, error) {
+	status := &Status{}
+	raw, ok, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil || !ok {
+		return status, errors.Trace(err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, status); err != nil {
+		return nil, errors.Annotatef(err, "reading status")
+	}
+	return status, nil
+}
+
+// updateStatus writes the status of a ChartSync
+func (c *Controller) updateStatus(ctx context.Context, obj *unstructured.Unstructured, status *Status) error {
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	obj = obj.DeepCopy()
+	obj.Object["status"] = raw
+	_, err = c.client.Resource(GroupVersionResource).Namespace(obj.GetNamespace()).UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	return errors.Annotatef(err, "updating status of %s/%s ChartSync", obj.GetNamespace(), obj.GetName())
+}
+
+// mergeChartResults returns the chart versions of the status updated with the
+// results of a run, most recent first
+func mergeChartResults(charts []ChartStatus, results []syncer.ChartResult, now time.Time) []ChartStatus {
+	merged := make([]ChartStatus, 0, len(charts)+len(results))
+	seen := map[string]bool{}
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		cs := ChartStatus{Name: r.Name, Version: r.Version, Phase: PhaseSynced, SyncTime: now.UTC().Format(time.RFC3339)}
+		if r.Err != nil {
+			cs.Phase, cs.Error = PhaseFailed, r.Err.Error()
+		}
+		merged = append(merged, cs)
+		seen[fmt.Sprintf("%s-%s", r.Name, r.Version)] = true
+	}
+	for _, cs := range charts {
+		if !seen[fmt.Sprintf("%s-%s", cs.Name, cs.Version)] {
+			merged = append(merged, cs)
+		}
+	}
+	if len(merged) > maxChartResults {
+		merged = merged[:maxChartResults]
+	}
+	return merged
+}
@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+)
+
+// chartSync returns a ChartSync with the given spec
+func chartSync(generation int64, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "charts-syncer.bitnami.com/v1alpha1",
+		"kind":       "ChartSync",
+		"metadata":   map[string]interface{}{"name": "bitnami", "namespace": "mirrors"},
+		"spec":       spec,
+	}}
+	obj.SetGeneration(generation)
+	return obj
+}
+
+func baseSpec() map[string]interface{} {
+	return map[string]interface{}{
+		"interval":             "30m",
+		"credentialsSecretRef": map[string]interface{}{"name": "credentials"},
+		"source":               map[string]interface{}{"repo": map[string]interface{}{"kind": "HELM", "url": "https://charts.example.com/bitnami"}},
+		"target":               map[string]interface{}{"repo": map[string]interface{}{"kind": "HARBOR", "url": "https://harbor.example.com/chartrepo/library"}},
+		"charts":               []interface{}{"redis", map[string]interface{}{"nginx": "13.x"}},
+	}
+}
+
+// fakeSync records the synced configurations
+type fakeSync struct {
+	configs []*api.Config
+	results []syncer.ChartResult
+	err     error
+}
+
+func (f *fakeSync) sync(_ context.Context, c *api.Config) (*state.Run, []syncer.ChartResult, error) {
+	f.configs = append(f.configs, c)
+	return &state.Run{ID: "run", Charts: 2, Synced: 1, Failed: 1, Duration: 90 * time.Second}, f.results, f.err
+}
+
+func newFakeController(t *testing.T, obj *unstructured.Unstructured, f *fakeSync) *Controller {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{GroupVersionResource: "ChartSyncList"}, obj)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "mirrors"},
+		Data:       map[string][]byte{"TARGET_REPO_AUTH_USERNAME": []byte("robot"), "TARGET_AUTH_PASSWORD": []byte("secret\n")},
+	}
+	c := New(client, kubefake.NewSimpleClientset(secret), "", f.sync)
+	c.now = func() time.Time { return time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC) }
+	return c
+}
+
+func getStatus(t *testing.T, c *Controller) *Status {
+	t.Helper()
+	obj, err := c.client.Resource(GroupVersionResource).Namespace("mirrors").Get(context.Background(), "bitnami", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := readStatus(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+func TestReconcile(t *testing.T) {
+	f := &fakeSync{
+		results: []syncer.ChartResult{{Name: "redis", Version: "17.3.7"}, {Name: "nginx", Version: "13.2.0", Err: errors.New("unable to upload")}},
+		err:     errors.New("1 chart failed"),
+	}
+	c := newFakeController(t, chartSync(1, baseSpec()), f)
+	ctx := context.Background()
+
+	requeue, err := c.reconcile(ctx, "mirrors/bitnami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requeue != 30*time.Minute {
+		t.Errorf("got: %s requeue, want: 30m", requeue)
+	}
+	if len(f.configs) != 1 {
+		t.Fatalf("got: %d syncs, want: 1", len(f.configs))
+	}
+	cfg := f.configs[0]
+	if got := cfg.GetTarget().GetRepo().GetAuth(); got.GetUsername() != "robot" || got.GetPassword() != "secret" {
+		t.Errorf("got: %v target credentials, want the ones of the secret", got)
+	}
+	if got := cfg.GetChartFilters()["nginx"].GetVersions(); got != "13.x" {
+		t.Errorf("got: %q nginx versions, want: 13.x", got)
+	}
+
+	status := getStatus(t, c)
+	if status.Phase != PhaseFailed || status.Message != "1 chart failed" || status.ObservedGeneration != 1 {
+		t.Errorf("got: %+v status, want a failed sync of the first generation", status)
+	}
+	if status.LastSyncTime != "2023-03-15T10:00:00Z" || status.NextSyncTime != "2023-03-15T10:30:00Z" {
+		t.Errorf("got: %s last and %s next sync times", status.LastSyncTime, status.NextSyncTime)
+	}
+	if status.LastRun == nil || status.LastRun.Synced != 1 || status.LastRun.Failed != 1 || status.LastRun.Duration != "1m30s" {
+		t.Errorf("got: %+v last run", status.LastRun)
+	}
+	if len(status.Charts) != 2 || status.Charts[0].Name != "nginx" || status.Charts[0].Phase != PhaseFailed || status.Charts[1].Phase != PhaseSynced {
+		t.Errorf("got: %+v charts, want nginx failed then redis synced", status.Charts)
+	}
+
+	// The interval has not elapsed yet
+	c.now = func() time.Time { return time.Date(2023, time.March, 15, 10, 20, 0, 0, time.UTC) }
+	if requeue, err = c.reconcile(ctx, "mirrors/bitnami"); err != nil {
+		t.Fatal(err)
+	}
+	if requeue != 10*time.Minute || len(f.configs) != 1 {
+		t.Errorf("got: %s requeue and %d syncs, want: 10m and 1", requeue, len(f.configs))
+	}
+
+	// Deleted resources are not reconciled again
+	if err := c.client.Resource(GroupVersionResource).Namespace("mirrors").Delete(ctx, "bitnami", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if requeue, err = c.reconcile(ctx, "mirrors/bitnami"); err != nil || requeue != 0 {
+		t.Errorf("got: %s requeue and %v, want none", requeue, err)
+	}
+}
+
+func TestReconcileSpecChange(t *testing.T) {
+	f := &fakeSync{}
+	obj := chartSync(2, baseSpec())
+	obj.Object["status"] = map[string]interface{}{"observedGeneration": int64(1), "lastSyncTime": "2023-03-15T09:55:00Z", "phase": PhaseSynced}
+	c := newFakeController(t, obj, f)
+
+	if _, err := c.reconcile(context.Background(), "mirrors/bitnami"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.configs) != 1 {
+		t.Errorf("got: %d syncs, want the new generation synced before the interval elapsed", len(f.configs))
+	}
+	if status := getStatus(t, c); status.Phase != PhaseSynced || status.ObservedGeneration != 2 {
+		t.Errorf("got: %+v status", status)
+	}
+}
+
+func TestReconcileInvalid(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		update func(spec map[string]interface{})
+		phase  string
+		errMsg string
+	}{
+		{desc: "suspended", update: func(spec map[string]interface{}) { spec["suspend"] = true }, phase: PhaseSuspended},
+		{desc: "invalid interval", update: func(spec map[string]interface{}) { spec["interval"] = "often" }, phase: PhaseInvalid, errMsg: `"interval" should be a positive duration, e.g. 30m: often`},
+		{desc: "several targets", update: func(spec map[string]interface{}) {
+			spec["targets"] = []interface{}{spec["target"]}
+			delete(spec, "target")
+		}, phase: PhaseInvalid, errMsg: `"targets" in a ChartSync not supported`},
+		{desc: "unknown property", update: func(spec map[string]interface{}) { spec["mirror"] = true }, phase: PhaseInvalid, errMsg: `error unmarshalling config: unknown field "mirror" in api.Config`},
+		{desc: "exec transformation", update: func(spec map[string]interface{}) {
+			spec["transformations"] = []interface{}{map[string]interface{}{"exec": map[string]interface{}{"command": []interface{}{"sh", "-c", "cat /var/run/secrets/kubernetes.io/serviceaccount/token"}}}}
+		}, phase: PhaseInvalid, errMsg: `"transformations[0].exec" in a ChartSync not supported`},
+		{desc: "local repository", update: func(spec map[string]interface{}) {
+			spec["target"] = map[string]interface{}{"repo": map[string]interface{}{"kind": "LOCAL", "path": "/etc"}}
+		}, phase: PhaseInvalid, errMsg: `"target.repo.path" in a ChartSync not supported`},
+		{desc: "state file", update: func(spec map[string]interface{}) {
+			spec["state"] = map[string]interface{}{"file": "/tmp/state.json"}
+		}, phase: PhaseInvalid, errMsg: `"state.file" in a ChartSync not supported`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			spec := baseSpec()
+			tc.update(spec)
+			f := &fakeSync{}
+			c := newFakeController(t, chartSync(1, spec), f)
+			requeue, err := c.reconcile(context.Background(), "mirrors/bitnami")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requeue != 0 || len(f.configs) != 0 {
+				t.Errorf("got: %s requeue and %d syncs, want none", requeue, len(f.configs))
+			}
+			if status := getStatus(t, c); status.Phase != tc.phase || status.Message != tc.errMsg {
+				t.Errorf("got: %s %q, want: %s %q", status.Phase, status.Message, tc.phase, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestMergeChartResults(t *testing.T) {
+	now := time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+	previous := []ChartStatus{
+		{Name: "nginx", Version: "13.2.0", Phase: PhaseFailed, Error: "unable to upload"},
+		{Name: "redis", Version: "17.3.7", Phase: PhaseSynced},
+	}
+	got := mergeChartResults(previous, []syncer.ChartResult{{Name: "nginx", Version: "13.2.0"}, {Name: "etcd", Version: "8.5.0"}}, now)
+	want := []string{"etcd-8.5.0 Synced", "nginx-13.2.0 Synced", "redis-17.3.7 Synced"}
+	if len(got) != len(want) {
+		t.Fatalf("got: %+v, want: %v", got, want)
+	}
+	for i, cs := range got {
+		if s := cs.Name + "-" + cs.Version + " " + cs.Phase; s != want[i] {
+			t.Errorf("got: %s, want: %s", s, want[i])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cosign
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+// inTotoPayloadType is the DSSE payload type of in-toto statements
+const inTotoPayloadType = "application/vnd.in-toto+json"
+
+// envelope is a DSSE envelope, in which cosign attest signs the attestations
+type envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     string              `json:"payload"`
+	Signatures  []envelopeSignature `json:"signatures"`
+}
+
+type envelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// pae returns the pre-authentication encoding of a DSSE payload, which is what
+// is signed
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// SignStatement signs an in-toto statement in a DSSE envelope, like cosign
+// attest does, and uploads it to the transparency log, if any. The payload of
+// the signature is the JSON-encoded envelope, which holds the signature.
+func (s *Signer) SignStatement(statement []byte) (*types.Signature, error) {
+	rawSig, err := s.sign(pae(inTotoPayloadType, statement))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	env, err := json.Marshal(&envelope{
+		PayloadType: inTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(statement),
+		Signatures:  []envelopeSignature{{Sig: base64.StdEncoding.EncodeToString(rawSig)}},
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sig := &types.Signature{Payload: env}
+	verifier, err := s.verifier(sig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if s.rekor != nil {
+		klog.V(4).Infof("Uploading attestation to %q transparency log...", s.rekor)
+		if sig.Bundle, err = s.uploadEntry(inTotoEntry(env, verifier)); err != nil {
+			return nil, errors.Annotatef(err, "uploading attestation to %q", s.rekor)
+		}
+	}
+	return sig, nil
+}
+
+// inTotoEntry returns the intoto entry of a DSSE envelope
+func inTotoEntry(env, verifier []byte) map[string]interface{} {
+	h := sha256.Sum256(env)
+	return map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "intoto",
+		"spec": map[string]interface{}{
+			"content": map[string]interface{}{
+				"envelope": string(env),
+				"hash":     map[string]string{"algorithm": "sha256", "value": fmt.Sprintf("%x", h)},
+			},
+			"publicKey": base64.StdEncoding.EncodeToString(verifier),
+		},
+	}
+}
@@ -0,0 +1,429 @@
+// Package cosign signs the manifests and attestations of the charts pushed to
+// OCI registries with the signature formats of cosign, so they can be verified
+// with `cosign verify` and `cosign verify-attestation` or by the admission
+// controllers enforcing sigstore policies.
+//
+// Charts are signed with a cosign or PEM-encoded ECDSA private key, or keyless
+// with a short-lived Fulcio certificate issued for an OIDC identity token.
+package cosign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+const (
+	// DefaultFulcioURL is the Fulcio instance issuing the certificates of the
+	// keyless signatures
+	DefaultFulcioURL = "https://fulcio.sigstore.dev"
+	// DefaultRekorURL is the transparency log the keyless signatures are
+	// uploaded to
+	DefaultRekorURL = "https://rekor.sigstore.dev"
+
+	// passwordEnv and tokenEnv are the environment variables cosign reads the
+	// password of the key and the identity token from
+	passwordEnv = "COSIGN_PASSWORD"
+	tokenEnv    = "SIGSTORE_ID_TOKEN"
+
+	// signatureType is the type of the simple signing payloads of cosign
+	signatureType = "cosign container image signature"
+	// certificateRenewal is how long before its expiration a Fulcio
+	// certificate is renewed
+	certificateRenewal = time.Minute
+)
+
+// Signer signs manifests and in-toto statements like cosign does. It
+// implements types.ManifestSigner and types.StatementSigner.
+type Signer struct {
+	key *ecdsa.PrivateKey
+	// keyless signatures get a Fulcio certificate for the identity token
+	keyless   bool
+	tokenFile string
+	fulcio    *url.URL
+	// rekor is the transparency log the signatures are uploaded to, if any
+	rekor  *url.URL
+	client *http.Client
+
+	mu          sync.Mutex
+	certificate string
+	chain       string
+	expiration  time.Time
+}
+
+// NewSigner creates a Signer from an api.Cosign object. Keyless signers
+// generate an ephemeral key, whose certificate is requested on the first
+// signature.
+func NewSigner(c *api.Cosign, insecure bool) (*Signer, error) {
+	s := &Signer{keyless: c.GetKey() == "", tokenFile: c.GetIdentityTokenFile(), client: utils.DefaultClient}
+	if insecure {
+		s.client = utils.InsecureClient
+	}
+
+	rekorURL := c.GetRekorUrl()
+	if s.keyless {
+		fulcioURL := c.GetFulcioUrl()
+		if fulcioURL == "" {
+			fulcioURL = DefaultFulcioURL
+		}
+		u, err := url.Parse(fulcioURL)
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing %q Fulcio URL", fulcioURL)
+		}
+		s.fulcio = u
+		// Short-lived certificates can only be verified with the time of the
+		// transparency log entry
+		if rekorURL == "" {
+			rekorURL = DefaultRekorURL
+		}
+		if s.key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return nil, errors.Trace(err)
+		}
+	} else {
+		password, err := readPassword(c.GetPassphraseFile())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if s.key, err = LoadKey(c.GetKey(), password); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if rekorURL != "" {
+		u, err := url.Parse(rekorURL)
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing %q Rekor URL", rekorURL)
+		}
+		s.rekor = u
+	}
+	return s, nil
+}
+
+// readPassword returns the password of a cosign key, read from a file or from
+// $COSIGN_PASSWORD
+func readPassword(file string) ([]byte, error) {
+	if file == "" {
+		return []byte(os.Getenv(passwordEnv)), nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading passphrase file")
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// simpleSigning is the payload signed by cosign
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+// Payload returns the simple signing payload of a manifest
+func Payload(reference, manifestDigest string) ([]byte, error) {
+	p := &simpleSigning{}
+	p.Critical.Identity.DockerReference = reference
+	p.Critical.Image.DockerManifestDigest = manifestDigest
+	p.Critical.Type = signatureType
+	data, err := json.Marshal(p)
+	return data, errors.Trace(err)
+}
+
+// SignManifest signs the simple signing payload of a manifest and uploads the
+// signature to the transparency log, if any
+func (s *Signer) SignManifest(reference, manifestDigest string) (*types.Signature, error) {
+	payload, err := Payload(reference, manifestDigest)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rawSig, err := s.sign(payload)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sig := &types.Signature{Payload: payload, Signature: base64.StdEncoding.EncodeToString(rawSig)}
+	verifier, err := s.verifier(sig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if s.rekor != nil {
+		klog.V(4).Infof("Uploading %s@%s signature to %q transparency log...", reference, manifestDigest, s.rekor)
+		if sig.Bundle, err = s.uploadEntry(hashedRekord(payload, rawSig, verifier)); err != nil {
+			return nil, errors.Annotatef(err, "uploading %s@%s signature to %q", reference, manifestDigest, s.rekor)
+		}
+	}
+	return sig, nil
+}
+
+// verifier returns the PEM-encoded certificate of keyless signatures, which
+// is set in the signature, or the public key otherwise. Rekor entries are
+// verified with it.
+func (s *Signer) verifier(sig *types.Signature) ([]byte, error) {
+	if !s.keyless {
+		return publicKeyPEM(&s.key.PublicKey)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.ensureCertificate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	sig.Certificate, sig.Chain = s.certificate, s.chain
+	return []byte(sig.Certificate), nil
+}
+
+// sign signs the SHA256 digest of some data
+func (s *Signer) sign(data []byte) ([]byte, error) {
+	h := sha256.Sum256(data)
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, h[:])
+	return sig, errors.Trace(err)
+}
+
+// publicKeyPEM returns the PEM encoding of a public key
+func publicKeyPEM(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// ensureCertificate requests a Fulcio certificate for the ephemeral key if
+// there is none or it is about to expire. s.mu must be held.
+func (s *Signer) ensureCertificate() error {
+	if s.certificate != "" && time.Now().Add(certificateRenewal).Before(s.expiration) {
+		return nil
+	}
+	token, err := s.identityToken()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	subject, err := tokenSubject(token)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Fulcio checks the key is owned by signing the subject of the token
+	proof, err := s.sign([]byte(subject))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	pub, err := publicKeyPEM(&s.key.PublicKey)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	req := &fulcioRequest{}
+	req.Credentials.OIDCIdentityToken = token
+	req.PublicKeyRequest.PublicKey.Algorithm = "ECDSA"
+	req.PublicKeyRequest.PublicKey.Content = string(pub)
+	req.PublicKeyRequest.ProofOfPossession = base64.StdEncoding.EncodeToString(proof)
+	res := &fulcioResponse{}
+	u := *s.fulcio
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/signingCert"
+	klog.V(3).Infof("Requesting signing certificate to %q for %q...", s.fulcio, subject)
+	if err := s.post(u.String(), req, res); err != nil {
+		return errors.Annotatef(err, "requesting signing certificate")
+	}
+
+	chain := res.SignedCertificateEmbeddedSct.Chain.Certificates
+	if len(chain) == 0 {
+		chain = res.SignedCertificateDetachedSct.Chain.Certificates
+	}
+	if len(chain) == 0 {
+		return errors.Errorf("%q returned no signing certificate", s.fulcio)
+	}
+	block, _ := pem.Decode([]byte(chain[0]))
+	if block == nil {
+		return errors.Errorf("%q returned a signing certificate that is not PEM-encoded", s.fulcio)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return errors.Annotatef(err, "parsing signing certificate")
+	}
+	s.certificate, s.chain, s.expiration = chain[0], strings.Join(chain[1:], ""), cert.NotAfter
+	return nil
+}
+
+// identityToken returns the OIDC identity token of keyless signatures
+func (s *Signer) identityToken() (string, error) {
+	if s.tokenFile == "" {
+		if token := os.Getenv(tokenEnv); token != "" {
+			return token, nil
+		}
+		return "", errors.Errorf("keyless signatures require an identity token, set %s or the identity token file", tokenEnv)
+	}
+	// Projected tokens are rotated, so they are read again for every
+	// certificate
+	data, err := ioutil.ReadFile(s.tokenFile)
+	if err != nil {
+		return "", errors.Annotatef(err, "reading identity token")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// tokenSubject returns the subject of an OIDC identity token, which is its
+// email claim if set
+func tokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.NotValidf("identity token, it is not a JWT")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", errors.Annotatef(err, "decoding identity token")
+	}
+	claims := struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+	}{}
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return "", errors.Annotatef(err, "decoding identity token")
+	}
+	if claims.Email != "" {
+		return claims.Email, nil
+	}
+	if claims.Subject == "" {
+		return "", errors.NotValidf("identity token, it has no subject")
+	}
+	return claims.Subject, nil
+}
+
+// fulcioRequest is a signing certificate request of the Fulcio v2 API
+type fulcioRequest struct {
+	Credentials struct {
+		OIDCIdentityToken string `json:"oidcIdentityToken"`
+	} `json:"credentials"`
+	PublicKeyRequest struct {
+		PublicKey struct {
+			Algorithm string `json:"algorithm"`
+			Content   string `json:"content"`
+		} `json:"publicKey"`
+		ProofOfPossession string `json:"proofOfPossession"`
+	} `json:"publicKeyRequest"`
+}
+
+// fulcioChain is a certificate chain returned by Fulcio, leaf first
+type fulcioChain struct {
+	Chain struct {
+		Certificates []string `json:"certificates"`
+	} `json:"chain"`
+}
+
+// fulcioResponse is the response to a signing certificate request
+type fulcioResponse struct {
+	SignedCertificateEmbeddedSct fulcioChain `json:"signedCertificateEmbeddedSct"`
+	SignedCertificateDetachedSct fulcioChain `json:"signedCertificateDetachedSct"`
+}
+
+// rekorEntry is a transparency log entry returned by Rekor
+type rekorEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+	Verification   struct {
+		SignedEntryTimestamp string `json:"signedEntryTimestamp"`
+	} `json:"verification"`
+}
+
+// bundle is the Rekor bundle cosign attaches to the signatures, so they can
+// be verified offline
+type bundle struct {
+	SignedEntryTimestamp string        `json:"SignedEntryTimestamp"`
+	Payload              bundlePayload `json:"Payload"`
+}
+
+type bundlePayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogIndex       int64  `json:"logIndex"`
+	LogID          string `json:"logID"`
+}
+
+// hashedRekord returns the hashedrekord entry of the signature of a payload
+func hashedRekord(payload, sig, verifier []byte) map[string]interface{} {
+	h := sha256.Sum256(payload)
+	return map[string]interface{}{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]interface{}{
+			"data": map[string]interface{}{
+				"hash": map[string]string{"algorithm": "sha256", "value": fmt.Sprintf("%x", h)},
+			},
+			"signature": map[string]interface{}{
+				"content":   base64.StdEncoding.EncodeToString(sig),
+				"publicKey": map[string]string{"content": base64.StdEncoding.EncodeToString(verifier)},
+			},
+		},
+	}
+}
+
+// uploadEntry uploads an entry to the transparency log and returns the
+// JSON-encoded bundle of the entry
+func (s *Signer) uploadEntry(entry map[string]interface{}) (string, error) {
+	u := *s.rekor
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1/log/entries"
+	res := map[string]*rekorEntry{}
+	if err := s.post(u.String(), entry, &res); err != nil {
+		return "", errors.Trace(err)
+	}
+	for _, e := range res {
+		data, err := json.Marshal(&bundle{
+			SignedEntryTimestamp: e.Verification.SignedEntryTimestamp,
+			Payload:              bundlePayload{Body: e.Body, IntegratedTime: e.IntegratedTime, LogIndex: e.LogIndex, LogID: e.LogID},
+		})
+		return string(data), errors.Trace(err)
+	}
+	return "", errors.Errorf("%q returned no log entry", s.rekor)
+}
+
+// post sends a JSON request and decodes the JSON response
+func (s *Signer) post(u string, in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("got HTTP Status: %s, Resp: %v", res.Status, bodyStr)
+	}
+	return errors.Trace(json.NewDecoder(res.Body).Decode(out))
+}
@@ -0,0 +1,328 @@
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// writeKey writes a PEM-encoded private key, encrypted like cosign does if a
+// password is provided
+func writeKey(t *testing.T, key *ecdsa.PrivateKey, blockType string, password []byte) string {
+	t.Helper()
+	var der []byte
+	var err error
+	if blockType == "EC PRIVATE KEY" {
+		der, err = x509.MarshalECPrivateKey(key)
+	} else {
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != nil {
+		k := &encryptedKey{}
+		k.KDF.Name, k.Cipher.Name = "scrypt", "nacl/secretbox"
+		k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P = 1024, 8, 1
+		k.KDF.Salt, k.Cipher.Nonce = make([]byte, 32), make([]byte, 24)
+		rand.Read(k.KDF.Salt)
+		rand.Read(k.Cipher.Nonce)
+		secret, err := scrypt.Key(password, k.KDF.Salt, 1024, 8, 1, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var nonce [24]byte
+		var secretKey [32]byte
+		copy(nonce[:], k.Cipher.Nonce)
+		copy(secretKey[:], secret)
+		k.Ciphertext = secretbox.Seal(nil, der, &nonce, &secretKey)
+		if der, err = json.Marshal(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(t.TempDir(), "cosign.key")
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		desc      string
+		blockType string
+		password  string
+		encrypted bool
+		shouldErr bool
+	}{
+		{desc: "cosign key", blockType: sigstorePrivateKeyType, password: "secret", encrypted: true},
+		{desc: "legacy cosign key", blockType: cosignPrivateKeyType, password: "secret", encrypted: true},
+		{desc: "cosign key with a wrong password", blockType: sigstorePrivateKeyType, password: "wrong", encrypted: true, shouldErr: true},
+		{desc: "PKCS #8 key", blockType: "PRIVATE KEY"},
+		{desc: "SEC 1 key", blockType: "EC PRIVATE KEY"},
+		{desc: "unsupported key", blockType: "RSA PRIVATE KEY", shouldErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var password []byte
+			if tc.encrypted {
+				password = []byte("secret")
+			}
+			file := writeKey(t, key, tc.blockType, password)
+			got, err := LoadKey(file, []byte(tc.password))
+			if tc.shouldErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(key) {
+				t.Errorf("got a different key")
+			}
+		})
+	}
+}
+
+func TestSignManifest(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passFile := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(passFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSigner(&api.Cosign{Key: writeKey(t, key, sigstorePrivateKeyType, []byte("secret")), PassphraseFile: passFile}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := s.SignManifest("registry.example.com/charts/apache", "sha256:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"critical":{"identity":{"docker-reference":"registry.example.com/charts/apache"},"image":{"docker-manifest-digest":"sha256:abc"},"type":"cosign container image signature"},"optional":null}`
+	if got := string(sig.Payload); got != want {
+		t.Errorf("got payload: %s, want: %s", got, want)
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.Sum256(sig.Payload)
+	if !ecdsa.VerifyASN1(&key.PublicKey, h[:], raw) {
+		t.Errorf("the signature does not match the payload")
+	}
+	if sig.Certificate != "" || sig.Bundle != "" {
+		t.Errorf("got certificate %q and bundle %q, want none for a keyed signature without transparency log", sig.Certificate, sig.Bundle)
+	}
+}
+
+func TestSignStatement(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSigner(&api.Cosign{Key: writeKey(t, key, "EC PRIVATE KEY", nil)}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`)
+	sig, err := s.SignStatement(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &envelope{}
+	if err := json.Unmarshal(sig.Payload, env); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := env.PayloadType, "application/vnd.in-toto+json"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(payload) != string(statement) {
+		t.Errorf("got payload: %s, want: %s", payload, statement)
+	}
+	if got, want := len(env.Signatures), 1; got != want {
+		t.Fatalf("got: %d signatures, want: %d", got, want)
+	}
+	raw, err := base64.StdEncoding.DecodeString(env.Signatures[0].Sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DSSE signs the pre-authentication encoding of the payload
+	h := sha256.Sum256([]byte("DSSEv1 28 application/vnd.in-toto+json 45 " + string(statement)))
+	if !ecdsa.VerifyASN1(&key.PublicKey, h[:], raw) {
+		t.Errorf("the signature does not match the envelope payload")
+	}
+	if sig.Signature != "" || sig.Bundle != "" {
+		t.Errorf("got signature %q and bundle %q, want none outside of the envelope", sig.Signature, sig.Bundle)
+	}
+}
+
+// fakeSigstore serves the Fulcio and Rekor APIs
+type fakeSigstore struct {
+	t      *testing.T
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  string
+	hashes []string
+}
+
+func (f *fakeSigstore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/v2/signingCert":
+		req := &fulcioRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			f.t.Fatal(err)
+		}
+		block, _ := pem.Decode([]byte(req.PublicKeyRequest.PublicKey.Content))
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		proof, _ := base64.StdEncoding.DecodeString(req.PublicKeyRequest.ProofOfPossession)
+		h := sha256.Sum256([]byte("ci@example.com"))
+		if !ecdsa.VerifyASN1(pub.(*ecdsa.PublicKey), h[:], proof) {
+			http.Error(w, "invalid proof of possession", http.StatusBadRequest)
+			return
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber:   big.NewInt(2),
+			NotBefore:      time.Now(),
+			NotAfter:       time.Now().Add(10 * time.Minute),
+			EmailAddresses: []string{"ci@example.com"},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, f.ca, pub, f.caKey)
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		leaf := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"signedCertificateEmbeddedSct": map[string]interface{}{"chain": map[string]interface{}{"certificates": []string{leaf, f.caPEM}}},
+		})
+	case "/api/v1/log/entries":
+		type hash struct {
+			Hash struct {
+				Value string `json:"value"`
+			} `json:"hash"`
+		}
+		entry := struct {
+			Kind string `json:"kind"`
+			Spec struct {
+				// Data is set in hashedrekord entries and Content in intoto
+				// ones
+				Data    hash `json:"data"`
+				Content hash `json:"content"`
+			} `json:"spec"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			f.t.Fatal(err)
+		}
+		if entry.Kind == "intoto" {
+			f.hashes = append(f.hashes, entry.Spec.Content.Hash.Value)
+		} else {
+			f.hashes = append(f.hashes, entry.Spec.Data.Hash.Value)
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"24296fb24b8ad77a": {"body": "Ym9keQ==", "integratedTime": 1589541600, "logID": "c0d23d6a", "logIndex": 7, "verification": {"signedEntryTimestamp": "c2V0"}}}`)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSignManifestKeyless(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sigstore"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeSigstore{t: t, ca: ca, caKey: caKey, caPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1234","email":"ci@example.com"}`))
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("eyJhbGciOiJSUzI1NiJ9."+claims+".c2ln\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSigner(&api.Cosign{IdentityTokenFile: tokenFile, FulcioUrl: srv.URL, RekorUrl: srv.URL}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := s.SignManifest("registry.example.com/charts/apache", "sha256:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sig.Certificate, "-----BEGIN CERTIFICATE-----") {
+		t.Errorf("got: %q, want a PEM certificate", sig.Certificate)
+	}
+	if sig.Chain != f.caPEM {
+		t.Errorf("got chain: %q, want: %q", sig.Chain, f.caPEM)
+	}
+	want := `{"SignedEntryTimestamp":"c2V0","Payload":{"body":"Ym9keQ==","integratedTime":1589541600,"logIndex":7,"logID":"c0d23d6a"}}`
+	if sig.Bundle != want {
+		t.Errorf("got bundle: %s, want: %s", sig.Bundle, want)
+	}
+	h := sha256.Sum256(sig.Payload)
+	if len(f.hashes) != 1 || f.hashes[0] != fmt.Sprintf("%x", h) {
+		t.Errorf("got: %v uploaded hashes, want the payload one", f.hashes)
+	}
+
+	// Attestations are signed with the same certificate
+	att, err := s.SignStatement([]byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Certificate != sig.Certificate || att.Bundle != want {
+		t.Errorf("got certificate %q and bundle %s, want the ones of the manifest signature", att.Certificate, att.Bundle)
+	}
+	h = sha256.Sum256(att.Payload)
+	if len(f.hashes) != 2 || f.hashes[1] != fmt.Sprintf("%x", h) {
+		t.Errorf("got: %v uploaded hashes, want the envelope one", f.hashes)
+	}
+}
@@ -0,0 +1,107 @@
+package cosign
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+
+	"github.com/juju/errors"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PEM block types of the private keys of cosign
+const (
+	sigstorePrivateKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	cosignPrivateKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+)
+
+// encryptedKey is the content of an encrypted cosign private key: a PKCS #8
+// key sealed with a NaCl secretbox, whose key is derived from the password
+// with scrypt
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadKey loads an ECDSA private key from a cosign key file or a PEM-encoded
+// PKCS #8 or SEC 1 file. The password is only used by cosign keys.
+func LoadKey(file string, password []byte) (*ecdsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading %q key", file)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.NotValidf("%q key, it is not PEM-encoded", file)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case sigstorePrivateKeyType, cosignPrivateKeyType:
+		der, err := decryptKey(block.Bytes, password)
+		if err != nil {
+			return nil, errors.Annotatef(err, "decrypting %q key", file)
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing %q key", file)
+		}
+	case "PRIVATE KEY":
+		if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			return nil, errors.Annotatef(err, "parsing %q key", file)
+		}
+	case "EC PRIVATE KEY":
+		if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+			return nil, errors.Annotatef(err, "parsing %q key", file)
+		}
+	default:
+		return nil, errors.NotSupportedf("%q key of type %q", file, block.Type)
+	}
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.NotSupportedf("%q key of type %T, only ECDSA keys are", file, key)
+	}
+	return ecKey, nil
+}
+
+// decryptKey decrypts the DER-encoded private key of an encrypted cosign key
+func decryptKey(data, password []byte) ([]byte, error) {
+	k := &encryptedKey{}
+	if err := json.Unmarshal(data, k); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if k.KDF.Name != "scrypt" || k.Cipher.Name != "nacl/secretbox" {
+		return nil, errors.NotSupportedf("%s key derivation with %s cipher", k.KDF.Name, k.Cipher.Name)
+	}
+	if len(k.Cipher.Nonce) != 24 {
+		return nil, errors.NotValidf("nonce of %d bytes", len(k.Cipher.Nonce))
+	}
+	secret, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var nonce [24]byte
+	var secretKey [32]byte
+	copy(nonce[:], k.Cipher.Nonce)
+	copy(secretKey[:], secret)
+	der, ok := secretbox.Open(nil, k.Ciphertext, &nonce, &secretKey)
+	if !ok {
+		return nil, errors.New("wrong password")
+	}
+	return der, nil
+}
@@ -0,0 +1,507 @@
+// Package credentials probes the repositories and registries of a
+// configuration with their credentials, reporting the authentication method
+// used and the permissions missing.
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/azblob"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/gcs"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/git"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/jfrog"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/nexus"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/s3"
+)
+
+const (
+	// ReadProbe checks that the charts or images can be listed and fetched
+	ReadProbe = "read"
+	// WriteProbe checks that the charts or images can be pushed, without
+	// changing the repository
+	WriteProbe = "write"
+
+	// probeName is the chart or image repository used by the probes, which
+	// is not expected to exist
+	probeName    = "charts-syncer-credentials-probe"
+	probeVersion = "0.0.0-probe"
+)
+
+// Result is the outcome of a probe
+type Result struct {
+	// Name of the probed repository, e.g. source.repo
+	Name  string
+	Probe string
+	// Auth describes the authentication method used
+	Auth string
+	OK   bool
+	// Detail explains the outcome, e.g. the permission that is missing
+	Detail string
+}
+
+// Option configures the probes
+type Option func(*prober)
+
+// WithInsecure allows insecure SSL connections
+func WithInsecure(enable bool) Option {
+	return func(p *prober) {
+		p.insecure = enable
+	}
+}
+
+// WithWrite enables the write probes
+func WithWrite(enable bool) Option {
+	return func(p *prober) {
+		p.write = enable
+	}
+}
+
+// WithCredentialsOrigin describes where the credentials come from, e.g. an
+// environment variable
+func WithCredentialsOrigin(origin string) Option {
+	return func(p *prober) {
+		p.origin = origin
+	}
+}
+
+// prober runs the probes of a repository
+type prober struct {
+	name     string
+	username string
+	password string
+	insecure bool
+	write    bool
+	origin   string
+}
+
+func newProber(name, username, password string, opts ...Option) *prober {
+	p := &prober{name: name, username: username, password: password}
+	for _, o := range opts {
+		o(p)
+	}
+	return p
+}
+
+// CheckRepo probes a chart repository
+func CheckRepo(name string, repo *api.Repo, opts ...Option) []Result {
+	if repo.GetKind() == api.Kind_LOCAL {
+		return CheckDir(name, repo.GetPath(), opts...)
+	}
+	p := newProber(name, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), opts...)
+	if repo.GetKind() == api.Kind_S3 {
+		store, err := s3.NewStore(repo, p.insecure)
+		if err != nil {
+			return []Result{p.fail(ReadProbe, "", err.Error())}
+		}
+		auth := store.Credentials()
+		if p.username != "" && p.origin != "" {
+			auth += " from " + p.origin
+		}
+		return p.bucket(store, auth)
+	}
+	if repo.GetKind() == api.Kind_GCS {
+		store, err := gcs.NewStore(repo, p.insecure)
+		if err != nil {
+			return []Result{p.fail(ReadProbe, "", err.Error())}
+		}
+		return p.bucket(store, store.Credentials())
+	}
+	if repo.GetKind() == api.Kind_AZBLOB {
+		store, err := azblob.NewStore(repo, p.insecure)
+		if err != nil {
+			return []Result{p.fail(ReadProbe, "", err.Error())}
+		}
+		return p.bucket(store, store.Credentials())
+	}
+	if repo.GetKind() == api.Kind_GIT {
+		return p.git(repo)
+	}
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return []Result{p.fail(ReadProbe, "", fmt.Sprintf("invalid URL: %v", err))}
+	}
+
+	switch repo.GetKind() {
+	case api.Kind_HELM, api.Kind_CHARTMUSEUM, api.Kind_HARBOR:
+		index := *u
+		index.Path += "/index.yaml"
+		results := []Result{p.probe(ReadProbe, http.MethodGet, index.String(), http.StatusOK)}
+		if !p.write {
+			return results
+		}
+		if repo.GetKind() == api.Kind_HELM {
+			return append(results, p.fail(WriteProbe, p.auth(), "HELM repositories do not support pushing charts"))
+		}
+		// Deleting a chart that does not exist is authorized like an upload,
+		// without changing the repository
+		del := *u
+		if repo.GetKind() == api.Kind_HARBOR {
+			del.Path = strings.Replace(del.Path, "/chartrepo/", "/api/chartrepo/", 1) + fmt.Sprintf("/charts/%s/%s", probeName, probeVersion)
+		} else {
+			del.Path += fmt.Sprintf("/api/charts/%s/%s", probeName, probeVersion)
+		}
+		return append(results, p.probe(WriteProbe, http.MethodDelete, del.String(), http.StatusNotFound, http.StatusOK))
+	case api.Kind_ARTIFACTORY:
+		base, key, err := jfrog.ParseURL(repo.GetUrl())
+		if err != nil {
+			return []Result{p.fail(ReadProbe, "", err.Error())}
+		}
+		index := jfrog.HelmURL(base, key)
+		index.Path += "/index.yaml"
+		results := []Result{p.probe(ReadProbe, http.MethodGet, index.String(), http.StatusOK)}
+		if !p.write {
+			return results
+		}
+		deploy := *base
+		deploy.Path += fmt.Sprintf("/%s/%s-%s.tgz", key, probeName, probeVersion)
+		return append(results, p.checksumDeploy(deploy.String()))
+	case api.Kind_NEXUS:
+		base, name, err := nexus.ParseURL(repo.GetUrl())
+		if err != nil {
+			return []Result{p.fail(ReadProbe, "", err.Error())}
+		}
+		index := nexus.RepositoryURL(base, name)
+		index.Path += "/index.yaml"
+		// Nexus has no request authorized like an upload that does not change
+		// the repository, so push permissions are not probed
+		return []Result{p.probe(ReadProbe, http.MethodGet, index.String(), http.StatusOK)}
+	case api.Kind_OCI:
+		return p.registry(u, strings.Trim(u.Path, "/"))
+	default:
+		return []Result{p.fail(ReadProbe, "", fmt.Sprintf("unsupported repo kind %q", repo.GetKind()))}
+	}
+}
+
+// CheckRegistry probes a container registry. Images are pushed under the
+// repository prefix, if any.
+func CheckRegistry(name, registry, repository, username, password string, opts ...Option) []Result {
+	p := newProber(name, username, password, opts...)
+	if !strings.Contains(registry, "://") {
+		registry = "https://" + registry
+	}
+	u, err := url.Parse(registry)
+	if err != nil {
+		return []Result{p.fail(ReadProbe, "", fmt.Sprintf("invalid registry: %v", err))}
+	}
+	return p.registry(u, strings.Trim(repository, "/"))
+}
+
+// CheckDir probes a local directory, like a LOCAL repository or an
+// intermediate bundles directory
+func CheckDir(name, dir string, opts ...Option) []Result {
+	p := newProber(name, "", "", opts...)
+	const auth = "local filesystem"
+	var results []Result
+	if _, err := ioutil.ReadDir(dir); err != nil {
+		results = append(results, p.fail(ReadProbe, auth, err.Error()))
+	} else {
+		results = append(results, p.ok(ReadProbe, auth, "directory is readable"))
+	}
+	if !p.write {
+		return results
+	}
+	f, err := ioutil.TempFile(dir, "."+probeName+"-")
+	if err != nil {
+		return append(results, p.fail(WriteProbe, auth, err.Error()))
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return append(results, p.ok(WriteProbe, auth, "directory is writable"))
+}
+
+// registry runs the probes of an OCI registry, under a repository prefix
+func (p *prober) registry(u *url.URL, prefix string) []Result {
+	repository := path.Join(prefix, probeName)
+	tags := *u
+	tags.Path = "/" + path.Join("v2", repository, "tags", "list")
+	// The probe repository does not exist, so a NAME_UNKNOWN error means
+	// the credentials were accepted
+	results := []Result{p.probe(ReadProbe, http.MethodGet, tags.String(), http.StatusOK, http.StatusNotFound)}
+	if !p.write {
+		return results
+	}
+
+	// Starting a blob upload needs the push permission. The upload is
+	// cancelled right away, so the registry does not change
+	uploads := *u
+	uploads.Path = "/" + path.Join("v2", repository, "blobs", "uploads") + "/"
+	res, auth, scope, err := p.do(http.MethodPost, uploads.String())
+	if err != nil {
+		return append(results, p.fail(WriteProbe, auth, err.Error()))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		return append(results, p.fail(WriteProbe, auth, p.denied(res, scope)))
+	}
+	if loc, err := uploads.Parse(res.Header.Get("Location")); err == nil && res.Header.Get("Location") != "" {
+		if cancel, _, _, err := p.do(http.MethodDelete, loc.String()); err == nil {
+			cancel.Body.Close()
+		}
+	}
+	return append(results, p.ok(WriteProbe, auth, "blob uploads are allowed"))
+}
+
+// probe sends a request and checks that it is answered with one of the
+// given status codes
+func (p *prober) probe(probe, method, u string, want ...int) Result {
+	res, auth, scope, err := p.do(method, u)
+	if err != nil {
+		return p.fail(probe, auth, err.Error())
+	}
+	defer res.Body.Close()
+	for _, code := range want {
+		if res.StatusCode == code {
+			return p.ok(probe, auth, fmt.Sprintf("%s %s: HTTP %s", method, u, res.Status))
+		}
+	}
+	return p.fail(probe, auth, p.denied(res, scope))
+}
+
+// denied explains why a request was not answered as expected
+func (p *prober) denied(res *http.Response, scope string) string {
+	body := strings.TrimSpace(utils.HTTPResponseBody(res))
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	var reason string
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		reason = "the credentials were rejected"
+		if p.username == "" {
+			reason = "the repository requires credentials"
+		}
+	case http.StatusForbidden:
+		reason = "the credentials lack the permission"
+	default:
+		reason = "unexpected answer"
+	}
+	if challenge := parseChallenge(res.Header.Get("WWW-Authenticate")); challenge["scope"] != "" {
+		scope = challenge["scope"]
+	}
+	if scope != "" && (res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden) {
+		reason += fmt.Sprintf(", missing %q scope", scope)
+	}
+	if body != "" {
+		return fmt.Sprintf("%s (HTTP %s): %s", reason, res.Status, body)
+	}
+	return fmt.Sprintf("%s (HTTP %s)", reason, res.Status)
+}
+
+// do sends a request with the basic credentials, exchanging them for a
+// bearer token if the server asks for one. It returns the response, the
+// authentication method used and the scope of the token, if any.
+func (p *prober) do(method, u string) (*http.Response, string, string, error) {
+	auth := p.auth()
+	req, err := p.request(method, u)
+	if err != nil {
+		return nil, auth, "", errors.Trace(err)
+	}
+	res, err := p.client().Do(req)
+	if err != nil {
+		return nil, auth, "", errors.Trace(err)
+	}
+	challenge := res.Header.Get("WWW-Authenticate")
+	if res.StatusCode != http.StatusUnauthorized || !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return res, auth, "", nil
+	}
+	res.Body.Close()
+
+	params := parseChallenge(challenge)
+	auth = fmt.Sprintf("bearer token from %s, requested with %s", params["realm"], auth)
+	token, err := p.token(params)
+	if err != nil {
+		return nil, auth, params["scope"], errors.Annotatef(err, "requesting a token with %q scope", params["scope"])
+	}
+	if req, err = p.request(method, u); err != nil {
+		return nil, auth, params["scope"], errors.Trace(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err = p.client().Do(req)
+	return res, auth, params["scope"], errors.Trace(err)
+}
+
+// token exchanges the credentials for a bearer token, as requested by a
+// challenge
+func (p *prober) token(params map[string]string) (string, error) {
+	u, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", errors.Errorf("invalid token realm %q", params["realm"])
+	}
+	q := u.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	u.RawQuery = q.Encode()
+	req, err := p.request(http.MethodGet, u.String())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	res, err := p.client().Do(req)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("token request failed (HTTP %s)", res.Status)
+	}
+	var t struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&t); err != nil {
+		return "", errors.Annotatef(err, "parsing token")
+	}
+	if t.Token == "" {
+		t.Token = t.AccessToken
+	}
+	if t.Token == "" {
+		return "", errors.New("the token server returned no token")
+	}
+	return t.Token, nil
+}
+
+func (p *prober) request(method, u string) (*http.Request, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if p.username != "" || p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+	return req, nil
+}
+
+func (p *prober) client() *http.Client {
+	if p.insecure {
+		return utils.InsecureClient
+	}
+	return utils.DefaultClient
+}
+
+// auth describes the basic credentials of the prober
+func (p *prober) auth() string {
+	if p.username == "" && p.password == "" {
+		return "anonymous"
+	}
+	auth := fmt.Sprintf("basic auth as %q", p.username)
+	if p.origin != "" {
+		auth += " from " + p.origin
+	}
+	return auth
+}
+
+// checksumDeploy probes the deploy permission of an Artifactory repository.
+// Deploying a file from the checksum of a content Artifactory does not store
+// is authorized like an upload, and fails without changing the repository.
+func (p *prober) checksumDeploy(u string) Result {
+	auth := p.auth()
+	req, err := p.request(http.MethodPut, u)
+	if err != nil {
+		return p.fail(WriteProbe, auth, err.Error())
+	}
+	req.Header.Set("X-Checksum-Deploy", "true")
+	req.Header.Set("X-Checksum-Sha1", strings.Repeat("0", 40))
+	res, err := p.client().Do(req)
+	if err != nil {
+		return p.fail(WriteProbe, auth, err.Error())
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return p.ok(WriteProbe, auth, fmt.Sprintf("checksum deploy to %s: HTTP %s", u, res.Status))
+	}
+	return p.fail(WriteProbe, auth, p.denied(res, ""))
+}
+
+// bucket probes a repository stored in a bucket. Write access cannot be
+// checked without changing the bucket, so it is not probed.
+func (p *prober) bucket(store bucket.Store, auth string) []Result {
+	rc, err := store.Get(bucket.IndexFile)
+	switch {
+	case err == nil:
+		rc.Close()
+		return []Result{p.ok(ReadProbe, auth, "")}
+	case errors.IsNotFound(err):
+		return []Result{p.ok(ReadProbe, auth, fmt.Sprintf("no %s, the repository is empty", bucket.IndexFile))}
+	case errors.IsForbidden(err):
+		return []Result{p.fail(ReadProbe, auth, "access denied, the credentials need permission to get the objects of the bucket")}
+	default:
+		return []Result{p.fail(ReadProbe, auth, err.Error())}
+	}
+}
+
+// git probes a repository stored in a git branch by listing the branch.
+// Pushing cannot be checked without changing the branch, so it is not probed.
+func (p *prober) git(repo *api.Repo) []Result {
+	auth := "git credentials of the environment"
+	if p.username != "" || p.password != "" {
+		auth = p.auth()
+	}
+	ok, err := git.HasBranch(repo, p.insecure)
+	switch {
+	case err != nil:
+		return []Result{p.fail(ReadProbe, auth, err.Error())}
+	case !ok:
+		return []Result{p.ok(ReadProbe, auth, "the branch does not exist, it is created with the first chart")}
+	default:
+		return []Result{p.ok(ReadProbe, auth, "")}
+	}
+}
+
+func (p *prober) ok(probe, auth, detail string) Result {
+	return Result{Name: p.name, Probe: probe, Auth: auth, OK: true, Detail: detail}
+}
+
+func (p *prober) fail(probe, auth, detail string) Result {
+	return Result{Name: p.name, Probe: probe, Auth: auth, Detail: detail}
+}
+
+// parseChallenge returns the parameters of a WWW-Authenticate challenge like
+// Bearer realm="https://auth.example.com/token",scope="repository:foo:pull"
+func parseChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	i := strings.IndexByte(challenge, ' ')
+	if i < 0 {
+		return params
+	}
+	s := challenge[i+1:]
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			// Quoted values may contain commas, like the actions of a scope
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if comma := strings.IndexByte(s, ','); comma >= 0 {
+			value, s = s[:comma], s[comma+1:]
+		} else {
+			value, s = s, ""
+		}
+		params[key] = value
+	}
+	return params
+}
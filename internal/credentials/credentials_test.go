@@ -0,0 +1,226 @@
+package credentials
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestCheckRegistry(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, _ := r.BasicAuth(); user != "user" || pass != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			// The user can only pull
+			fmt.Fprintf(w, `{"token": "pull"}`)
+			return
+		}
+		repository := "charts/" + probeName
+		if r.Header.Get("Authorization") != "Bearer pull" || r.Method == http.MethodPost {
+			action := "pull"
+			if r.Method == http.MethodPost {
+				action = "pull,push"
+			}
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:%s:%s"`, srv.URL, repository, action))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	results := CheckRegistry("target.containers", srv.URL, "charts", "user", "password", WithWrite(true), WithCredentialsOrigin("config file"))
+	if got, want := len(results), 2; got != want {
+		t.Fatalf("got: %d results, want: %d", got, want)
+	}
+	if read := results[0]; !read.OK || read.Probe != ReadProbe {
+		t.Errorf("unexpected read result: %+v", read)
+	}
+	if got, want := results[0].Auth, fmt.Sprintf(`bearer token from %s/token, requested with basic auth as "user" from config file`, srv.URL); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	write := results[1]
+	if write.OK || !strings.Contains(write.Detail, fmt.Sprintf(`missing "repository:charts/%s:pull,push" scope`, probeName)) {
+		t.Errorf("unexpected write result: %+v", write)
+	}
+}
+
+func TestCheckRepo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/index.yaml":
+			fmt.Fprint(w, "apiVersion: v1\nentries: {}\n")
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		desc string
+		auth *api.Auth
+		want []bool
+	}{
+		{
+			desc: "read-only credentials",
+			auth: &api.Auth{Username: "user", Password: "password"},
+			want: []bool{true, false},
+		},
+		{
+			desc: "wrong credentials",
+			auth: &api.Auth{Username: "user", Password: "wrong"},
+			want: []bool{false, false},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			repo := &api.Repo{Kind: api.Kind_CHARTMUSEUM, Url: srv.URL, Auth: tc.auth}
+			var got []bool
+			for _, r := range CheckRepo("target.repo", repo, WithWrite(true)) {
+				got = append(got, r.OK)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckRepoS3(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// The bucket is empty
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	repo := &api.Repo{Kind: api.Kind_S3, Url: "s3://charts", S3: &api.S3Options{Endpoint: srv.URL}, Auth: &api.Auth{Username: "AKIDEXAMPLE", Password: "secret"}}
+	results := CheckRepo("target.repo", repo, WithWrite(true))
+	if len(results) != 1 || !results[0].OK || results[0].Auth != `AWS signature as "AKIDEXAMPLE"` {
+		t.Errorf("got: %+v, want a successful read probe", results)
+	}
+
+	repo.Auth.Username = "other"
+	results = CheckRepo("target.repo", repo)
+	if len(results) != 1 || results[0].OK || !strings.Contains(results[0].Detail, "access denied") {
+		t.Errorf("got: %+v, want a failed read probe", results)
+	}
+}
+
+func TestCheckRepoGCS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/computeMetadata/v1/instance/service-accounts/default/token" {
+			fmt.Fprint(w, `{"access_token": "ya29.test", "token_type": "Bearer", "expires_in": 3600}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer ya29.test" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", u.Host)
+
+	repo := &api.Repo{Kind: api.Kind_GCS, Url: "gs://charts", Gcs: &api.GCSOptions{Endpoint: srv.URL}}
+	results := CheckRepo("target.repo", repo)
+	if len(results) != 1 || !results[0].OK || results[0].Auth != "service account of the metadata server" {
+		t.Errorf("got: %+v, want a successful read probe", results)
+	}
+}
+
+func TestCheckRepoAZBlob(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey devstoreaccount1:") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		// The container is empty
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	repo := &api.Repo{Kind: api.Kind_AZBLOB, Url: "azblob://charts", Azblob: &api.AZBlobOptions{ConnectionString: "UseDevelopmentStorage=true", Endpoint: srv.URL}}
+	results := CheckRepo("target.repo", repo)
+	if len(results) != 1 || !results[0].OK || results[0].Auth != `shared key of account "devstoreaccount1" from azblob.connectionString` {
+		t.Errorf("got: %+v, want a successful read probe", results)
+	}
+}
+
+func TestCheckRepoArtifactory(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/artifactory/api/helm/charts/index.yaml":
+			w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+		case r.Method == http.MethodPut && r.Header.Get("X-Checksum-Deploy") == "true":
+			if user, _, _ := r.BasicAuth(); user != "deployer" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	repo := &api.Repo{Kind: api.Kind_ARTIFACTORY, Url: srv.URL + "/artifactory/api/helm/charts", Auth: &api.Auth{Username: "deployer", Password: "password"}}
+	results := CheckRepo("target.repo", repo, WithWrite(true))
+	if len(results) != 2 || !results[0].OK || !results[1].OK {
+		t.Errorf("got: %+v, want successful read and write probes", results)
+	}
+
+	repo.Auth.Username = "reader"
+	results = CheckRepo("target.repo", repo, WithWrite(true))
+	if len(results) != 2 || !results[0].OK || results[1].OK || !strings.Contains(results[1].Detail, "lack the permission") {
+		t.Errorf("got: %+v, want a failed write probe", results)
+	}
+}
+
+func TestCheckDir(t *testing.T) {
+	results := CheckDir("target.intermediateBundlesPath", t.TempDir(), WithWrite(true))
+	for _, r := range results {
+		if !r.OK {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	}
+	if results := CheckDir("source.intermediateBundlesPath", "/nonexistent"); results[0].OK {
+		t.Errorf("unexpected result: %+v", results[0])
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	got := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:foo:pull,push"`)
+	want := map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:foo:pull,push",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
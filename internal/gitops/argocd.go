@@ -0,0 +1,115 @@
+package gitops
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const (
+	argoCDSecretTypeLabel  = "argocd.argoproj.io/secret-type"
+	defaultArgoCDName      = "charts-syncer"
+	defaultArgoCDNamespace = "argocd"
+)
+
+// secret is a Kubernetes Secret declaring an Argo CD repository or
+// credentials template
+type secret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   objectMeta        `json:"metadata"`
+	StringData map[string]string `json:"stringData"`
+}
+
+// ArgoCD returns the Argo CD declaration of the target repository: a
+// repository Secret and, if configured, a repo-creds Secret with its
+// credentials.
+//
+// Credentials are never written, the Secrets contain placeholders instead.
+func ArgoCD(target *api.Target, config *api.ArgoCD) ([]byte, error) {
+	repo := target.GetRepo()
+	u, err := repoURL(repo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	name := config.GetName()
+	if name == "" {
+		name = defaultArgoCDName
+	}
+	namespace := config.GetNamespace()
+	if namespace == "" {
+		namespace = defaultArgoCDNamespace
+	}
+	data := map[string]string{"type": "helm", "url": u}
+	// Argo CD references OCI registries without scheme
+	if repo.GetKind() == api.Kind_OCI {
+		data["url"] = strings.TrimPrefix(u, "oci://")
+		data["enableOCI"] = "true"
+	}
+	credentials := map[string]string{}
+	if repo.GetAuth().GetUsername() != "" {
+		credentials["username"] = "USERNAME"
+		credentials["password"] = "PASSWORD"
+	}
+
+	repository := newArgoCDSecret(name, namespace, "repository", data, map[string]string{"name": name})
+	secrets := []secret{repository}
+	if len(credentials) > 0 && config.GetCredentialsTemplate() {
+		secrets = append(secrets, newArgoCDSecret(name+"-creds", namespace, "repo-creds", data, credentials))
+	} else if len(credentials) > 0 {
+		secrets[0] = newArgoCDSecret(name, namespace, "repository", repository.StringData, credentials)
+	}
+
+	out := []byte("# Argo CD declaration of the repository synced by charts-syncer\n")
+	if len(credentials) > 0 {
+		out = append(out, "# Replace the USERNAME and PASSWORD placeholders with the credentials of the repository\n"...)
+	}
+	for i, s := range secrets {
+		b, err := yaml.Marshal(s)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if i > 0 {
+			out = append(out, "---\n"...)
+		}
+		out = append(out, b...)
+	}
+	return out, nil
+}
+
+// newArgoCDSecret returns an Argo CD Secret of the given type, merging the
+// provided data
+func newArgoCDSecret(name, namespace, secretType string, data ...map[string]string) secret {
+	s := secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: objectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{argoCDSecretTypeLabel: secretType},
+		},
+		StringData: map[string]string{},
+	}
+	for _, d := range data {
+		for k, v := range d {
+			s.StringData[k] = v
+		}
+	}
+	return s
+}
+
+// WriteArgoCD writes the Argo CD declaration of the target repository to the
+// configured file
+func WriteArgoCD(target *api.Target, config *api.ArgoCD) error {
+	data, err := ArgoCD(target, config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	klog.Infof("Writing Argo CD repository declaration to %q", config.GetOutput())
+	return errors.Annotatef(writeManifest(config.GetOutput(), data), "writing Argo CD repository declaration")
+}
@@ -0,0 +1,112 @@
+package gitops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestArgoCD(t *testing.T) {
+	oci := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{
+		Kind: api.Kind_OCI,
+		Url:  "https://harbor.example.com/mirror/charts",
+		Auth: &api.Auth{Username: "user", Password: "pass"},
+	}}}
+	tests := []struct {
+		desc   string
+		target *api.Target
+		config *api.ArgoCD
+		want   string
+	}{
+		{
+			desc: "helm repository",
+			target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{
+				Kind: api.Kind_HELM,
+				Url:  "https://charts.example.com",
+			}}},
+			config: &api.ArgoCD{Output: "argocd.yaml"},
+			want: `# Argo CD declaration of the repository synced by charts-syncer
+apiVersion: v1
+kind: Secret
+metadata:
+  labels:
+    argocd.argoproj.io/secret-type: repository
+  name: charts-syncer
+  namespace: argocd
+stringData:
+  name: charts-syncer
+  type: helm
+  url: https://charts.example.com
+`,
+		},
+		{
+			desc:   "oci registry with credentials",
+			target: oci,
+			config: &api.ArgoCD{Output: "argocd.yaml", Name: "mirror"},
+			want: `# Argo CD declaration of the repository synced by charts-syncer
+# Replace the USERNAME and PASSWORD placeholders with the credentials of the repository
+apiVersion: v1
+kind: Secret
+metadata:
+  labels:
+    argocd.argoproj.io/secret-type: repository
+  name: mirror
+  namespace: argocd
+stringData:
+  enableOCI: "true"
+  name: mirror
+  password: PASSWORD
+  type: helm
+  url: harbor.example.com/mirror/charts
+  username: USERNAME
+`,
+		},
+		{
+			desc:   "credentials template",
+			target: oci,
+			config: &api.ArgoCD{Output: "argocd.yaml", Namespace: "gitops", CredentialsTemplate: true},
+			want: `# Argo CD declaration of the repository synced by charts-syncer
+# Replace the USERNAME and PASSWORD placeholders with the credentials of the repository
+apiVersion: v1
+kind: Secret
+metadata:
+  labels:
+    argocd.argoproj.io/secret-type: repository
+  name: charts-syncer
+  namespace: gitops
+stringData:
+  enableOCI: "true"
+  name: charts-syncer
+  type: helm
+  url: harbor.example.com/mirror/charts
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  labels:
+    argocd.argoproj.io/secret-type: repo-creds
+  name: charts-syncer-creds
+  namespace: gitops
+stringData:
+  enableOCI: "true"
+  password: PASSWORD
+  type: helm
+  url: harbor.example.com/mirror/charts
+  username: USERNAME
+`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ArgoCD(tc.target, tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("unexpected declaration (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package gitops
+
+import (
+	"fmt"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const (
+	fluxSourceAPIVersion = "source.toolkit.fluxcd.io/v1beta2"
+	defaultFluxName      = "charts-syncer"
+	defaultFluxNamespace = "flux-system"
+	defaultFluxInterval  = "10m"
+)
+
+// helmRepository is a Flux HelmRepository source
+type helmRepository struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   objectMeta         `json:"metadata"`
+	Spec       helmRepositorySpec `json:"spec"`
+}
+
+type helmRepositorySpec struct {
+	// Type is "oci" for OCI registries
+	Type      string     `json:"type,omitempty"`
+	URL       string     `json:"url"`
+	Interval  string     `json:"interval"`
+	SecretRef *secretRef `json:"secretRef,omitempty"`
+}
+
+type secretRef struct {
+	Name string `json:"name"`
+}
+
+// Flux returns the Flux source manifest describing the target repository.
+//
+// OCI registries are described with a HelmRepository of type oci, which is
+// what HelmReleases consume charts from, rather than with an OCIRepository.
+func Flux(target *api.Target, config *api.Flux) ([]byte, error) {
+	repo := target.GetRepo()
+	u, err := repoURL(repo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	name := config.GetName()
+	if name == "" {
+		name = defaultFluxName
+	}
+	namespace := config.GetNamespace()
+	if namespace == "" {
+		namespace = defaultFluxNamespace
+	}
+	interval := config.GetInterval()
+	if interval == "" {
+		interval = defaultFluxInterval
+	}
+	source := helmRepository{
+		APIVersion: fluxSourceAPIVersion,
+		Kind:       "HelmRepository",
+		Metadata:   objectMeta{Name: name, Namespace: namespace},
+		Spec:       helmRepositorySpec{URL: u, Interval: interval},
+	}
+	if repo.GetKind() == api.Kind_OCI {
+		source.Spec.Type = "oci"
+	}
+
+	header := "# Flux source of the charts synced by charts-syncer\n"
+	if repo.GetAuth().GetUsername() != "" {
+		secret := config.GetSecretName()
+		if secret == "" {
+			secret = fmt.Sprintf("%s-auth", name)
+		}
+		source.Spec.SecretRef = &secretRef{Name: secret}
+		header += fmt.Sprintf("# Create the %q Secret with the username and password of the repository:\n", secret)
+		header += fmt.Sprintf("#   kubectl -n %s create secret generic %s --from-literal=username=USERNAME --from-literal=password=PASSWORD\n", namespace, secret)
+	}
+
+	data, err := yaml.Marshal(source)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return append([]byte(header), data...), nil
+}
+
+// WriteFlux writes the Flux source manifest describing the target repository
+// to the configured file
+func WriteFlux(target *api.Target, config *api.Flux) error {
+	data, err := Flux(target, config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	klog.Infof("Writing Flux source manifest to %q", config.GetOutput())
+	return errors.Annotatef(writeManifest(config.GetOutput(), data), "writing Flux source manifest")
+}
@@ -0,0 +1,77 @@
+package gitops
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestFlux(t *testing.T) {
+	tests := []struct {
+		desc   string
+		target *api.Target
+		config *api.Flux
+		want   string
+	}{
+		{
+			desc: "helm repository",
+			target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{
+				Kind: api.Kind_CHARTMUSEUM,
+				Url:  "https://charts.example.com",
+			}}},
+			config: &api.Flux{Output: "flux.yaml"},
+			want: `# Flux source of the charts synced by charts-syncer
+apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: HelmRepository
+metadata:
+  name: charts-syncer
+  namespace: flux-system
+spec:
+  interval: 10m
+  url: https://charts.example.com
+`,
+		},
+		{
+			desc: "oci registry with credentials",
+			target: &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{
+				Kind: api.Kind_OCI,
+				Url:  "https://harbor.example.com/mirror/charts",
+				Auth: &api.Auth{Username: "user", Password: "pass"},
+			}}},
+			config: &api.Flux{Output: "flux.yaml", Name: "mirror", Namespace: "apps", Interval: "1h"},
+			want: `# Flux source of the charts synced by charts-syncer
+# Create the "mirror-auth" Secret with the username and password of the repository:
+#   kubectl -n apps create secret generic mirror-auth --from-literal=username=USERNAME --from-literal=password=PASSWORD
+apiVersion: source.toolkit.fluxcd.io/v1beta2
+kind: HelmRepository
+metadata:
+  name: mirror
+  namespace: apps
+spec:
+  interval: 1h
+  secretRef:
+    name: mirror-auth
+  type: oci
+  url: oci://harbor.example.com/mirror/charts
+`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := Flux(tc.target, tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("unexpected manifest (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	local := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: "charts"}}}
+	if _, err := Flux(local, &api.Flux{Output: "flux.yaml"}); err == nil {
+		t.Error("got no error for a local target")
+	}
+}
@@ -0,0 +1,65 @@
+// Package gitops generates the manifests GitOps tools need to consume the
+// target repository, so onboarding the mirror is a matter of applying them.
+package gitops
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/jfrog"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/nexus"
+)
+
+type objectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// repoURL returns the URL GitOps tools use to reach a repository. OCI
+// registries are referenced with the oci scheme.
+func repoURL(repo *api.Repo) (string, error) {
+	switch repo.GetKind() {
+	case api.Kind_HELM, api.Kind_CHARTMUSEUM, api.Kind_HARBOR:
+		return repo.GetUrl(), nil
+	case api.Kind_ARTIFACTORY:
+		base, key, err := jfrog.ParseURL(repo.GetUrl())
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return jfrog.HelmURL(base, key).String(), nil
+	case api.Kind_NEXUS:
+		base, name, err := nexus.ParseURL(repo.GetUrl())
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		return nexus.RepositoryURL(base, name).String(), nil
+	case api.Kind_GIT:
+		if u := repo.GetGit().GetPagesUrl(); u != "" {
+			return u, nil
+		}
+		return "", errors.Errorf(`GIT repositories need "git.pagesUrl" to be consumed by GitOps tools`)
+	case api.Kind_OCI:
+		u, err := url.Parse(repo.GetUrl())
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		u.Scheme = "oci"
+		return u.String(), nil
+	default:
+		return "", errors.Errorf("%q repositories cannot be consumed by GitOps tools", repo.GetKind())
+	}
+}
+
+// writeManifest writes a manifest to a file, creating its directory if needed
+func writeManifest(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(ioutil.WriteFile(file, data, 0644))
+}
@@ -0,0 +1,201 @@
+// Package logging switches the klog output between its text format and
+// structured JSON entries, and logs the structured events of the syncs.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+)
+
+// The supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Fields are the structured fields of a log entry. time.Duration values are
+// logged in seconds, and errors as their message.
+type Fields map[string]interface{}
+
+var (
+	mu     sync.Mutex
+	output *jsonWriter
+)
+
+// SetFormat sets the format of the logs written to the standard error
+func SetFormat(format string) error {
+	switch format {
+	case FormatText:
+		return nil
+	case FormatJSON:
+	default:
+		return errors.NotValidf("%q log format, it should be %q or %q", format, FormatText, FormatJSON)
+	}
+
+	// The entries are only written to the JSON writer, all of them go
+	// through the INFO one
+	fs := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	for name, value := range map[string]string{"logtostderr": "false", "alsologtostderr": "false", "stderrthreshold": "FATAL", "skip_headers": "false"} {
+		if err := fs.Set(name, value); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	w := newJSONWriter(os.Stderr)
+	klog.SetOutputBySeverity("INFO", w)
+	for _, s := range []string{"WARNING", "ERROR", "FATAL"} {
+		klog.SetOutputBySeverity(s, ioutil.Discard)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+	return nil
+}
+
+// Info logs a message with structured fields
+func Info(msg string, fields Fields) {
+	logDepth(1, "info", msg, fields)
+}
+
+// Error logs a failure with structured fields
+func Error(msg string, fields Fields) {
+	logDepth(1, "error", msg, fields)
+}
+
+func logDepth(depth int, level, msg string, fields Fields) {
+	mu.Lock()
+	w := output
+	mu.Unlock()
+	if w != nil {
+		_, file, line, _ := runtime.Caller(depth + 1)
+		w.write(entry(time.Now(), level, fmt.Sprintf("%s:%d", filepath.Base(file), line), msg, fields))
+		return
+	}
+
+	text := msg + formatText(fields)
+	if level == "error" {
+		klog.ErrorDepth(depth+1, text)
+	} else {
+		klog.InfoDepth(depth+1, text)
+	}
+}
+
+// formatText returns the fields as the key="value" pairs appended to the text
+// entries, sorted by key
+func formatText(fields Fields) string {
+	var b strings.Builder
+	for _, k := range sortedKeys(fields) {
+		fmt.Fprintf(&b, " %s=%q", k, fmt.Sprint(fieldValue(fields[k], false)))
+	}
+	return b.String()
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldValue returns the value of a field as logged
+func fieldValue(v interface{}, structured bool) interface{} {
+	switch v := v.(type) {
+	case time.Duration:
+		if structured {
+			return v.Seconds()
+		}
+		return v.Round(time.Millisecond).String()
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return v
+}
+
+// entry returns a JSON entry
+func entry(t time.Time, level, caller, msg string, fields Fields) map[string]interface{} {
+	e := map[string]interface{}{}
+	for k, v := range fields {
+		e[k] = fieldValue(v, true)
+	}
+	// The fields never override the ones of every entry
+	e["time"] = t.UTC().Format(time.RFC3339Nano)
+	e["level"] = level
+	e["caller"] = caller
+	e["msg"] = msg
+	return e
+}
+
+// jsonWriter turns the klog entries into JSON lines
+type jsonWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	now func() time.Time
+}
+
+func newJSONWriter(out io.Writer) *jsonWriter {
+	return &jsonWriter{out: out, now: time.Now}
+}
+
+// klogHeader matches the header of the klog entries:
+// Lmmdd hh:mm:ss.uuuuuu threadid file:line]
+var klogHeader = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2}) (\d{2}):(\d{2}):(\d{2})\.(\d{6}) +\d+ ([^\]]+)\] `)
+
+var levels = map[string]string{"I": "info", "W": "warning", "E": "error", "F": "fatal"}
+
+// Write writes a klog entry, klog writes each of them at once
+func (w *jsonWriter) Write(p []byte) (int, error) {
+	w.write(w.parse(p))
+	return len(p), nil
+}
+
+// parse returns the JSON entry of a klog one. Entries without header are
+// logged as info messages.
+func (w *jsonWriter) parse(p []byte) map[string]interface{} {
+	now := w.now()
+	m := klogHeader.FindSubmatch(p)
+	if m == nil {
+		return entry(now, "info", "", string(bytes.TrimRight(p, "\n")), nil)
+	}
+	// klog does not log the year
+	var n [6]int
+	for i := range n {
+		n[i], _ = strconv.Atoi(string(m[i+2]))
+	}
+	t := time.Date(now.Year(), time.Month(n[0]), n[1], n[2], n[3], n[4], n[5]*1000, time.Local)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	msg := string(bytes.TrimRight(p[len(m[0]):], "\n"))
+	return entry(t, levels[string(m[1])], string(m[8]), msg, nil)
+}
+
+func (w *jsonWriter) write(e map[string]interface{}) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		line, _ = json.Marshal(entry(w.now(), "error", "", fmt.Sprintf("unable to encode log entry %q: %v", e["msg"], err), nil))
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.out.Write(append(line, '\n'))
+}
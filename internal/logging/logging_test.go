@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+func TestJSONWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := newJSONWriter(&out)
+	w.now = func() time.Time { return time.Date(2023, time.January, 2, 10, 0, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		desc string
+		line string
+		want map[string]interface{}
+	}{
+		{
+			desc: "info entry",
+			line: "I0102 09:59:58.123456   12345 sync.go:179] Syncing \"redis-17.3.7\" chart...\n",
+			want: map[string]interface{}{"level": "info", "caller": "sync.go:179", "msg": `Syncing "redis-17.3.7" chart...`, "time": time.Date(2023, time.January, 2, 9, 59, 58, 123456000, time.Local).UTC().Format(time.RFC3339Nano)},
+		},
+		{
+			desc: "error entry of the previous year",
+			line: "E1231 23:59:59.000000       7 sync.go:291] unable to upload \"redis-17.3.7\" chart: 500\nand its trace\n",
+			want: map[string]interface{}{"level": "error", "caller": "sync.go:291", "msg": "unable to upload \"redis-17.3.7\" chart: 500\nand its trace", "time": time.Date(2022, time.December, 31, 23, 59, 59, 0, time.Local).UTC().Format(time.RFC3339Nano)},
+		},
+		{
+			desc: "entry without header",
+			line: "Syncing\n",
+			want: map[string]interface{}{"level": "info", "caller": "", "msg": "Syncing", "time": "2023-01-02T10:00:00Z"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			out.Reset()
+			if _, err := w.Write([]byte(tc.line)); err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+				t.Fatalf("unable to decode %q: %v", out.String(), err)
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+			for k, v := range tc.want {
+				if got[k] != v {
+					t.Errorf("got: %q %s, want: %q", got[k], k, v)
+				}
+			}
+		})
+	}
+}
+
+func TestEntryFields(t *testing.T) {
+	e := entry(time.Date(2023, time.January, 2, 10, 0, 0, 0, time.UTC), "error", "run.go:60", "Chart sync failed", Fields{
+		"chart":    "redis",
+		"duration": 1500 * time.Millisecond,
+		"error":    errors.New("unable to upload"),
+		"msg":      "overridden",
+	})
+	line, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"caller":"run.go:60","chart":"redis","duration":1.5,"error":"unable to upload","level":"error","msg":"Chart sync failed","time":"2023-01-02T10:00:00Z"}`
+	if string(line) != want {
+		t.Errorf("got: %s, want: %s", line, want)
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	got := formatText(Fields{"version": "17.3.7", "chart": "redis", "duration": 1500 * time.Millisecond, "error": errors.New(`"x" failed`)})
+	want := ` chart="redis" duration="1.5s" error="\"x\" failed" version="17.3.7"`
+	if got != want {
+		t.Errorf("got: %s, want: %s", got, want)
+	}
+}
+
+func TestSetFormat(t *testing.T) {
+	if err := SetFormat("yaml"); !errors.IsNotValid(err) {
+		t.Errorf("got: %v, want a NotValid error", err)
+	}
+	if err := SetFormat(FormatText); err != nil {
+		t.Errorf("got: %v, want none", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package notify posts the outcome of the sync runs to the configured
+// endpoints, as a generic webhook payload or a Slack-compatible message.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// defaultTimeout is the time to wait for an endpoint to answer if no timeout
+// is configured
+const defaultTimeout = 10 * time.Second
+
+// maxSlackErrors is the number of errors listed in the Slack messages, the
+// remaining ones are only counted
+const maxSlackErrors = 10
+
+// The statuses of the sync runs
+const (
+	StatusSucceeded   = "succeeded"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+)
+
+// Event is the notification of a completed sync run
+type Event struct {
+	RunID  string `json:"runId,omitempty"`
+	Status string `json:"status"`
+	// Source and Target are the locations of the synced repositories
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+	StartedAt time.Time `json:"startedAt"`
+	// Duration is the time spent by the run, in seconds
+	Duration float64 `json:"duration"`
+	Charts   int     `json:"charts"`
+	Synced   int     `json:"synced"`
+	Failed   int     `json:"failed"`
+	// Errors are the errors of the run, one per failed chart or problem
+	Errors []string `json:"errors,omitempty"`
+}
+
+// slackMessage is the payload of the Slack incoming webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Send posts an event to the configured endpoints, skipping the ones only
+// notified about failures if the run succeeded. An endpoint failing does not
+// prevent the others from being notified.
+func Send(notifications []*api.Notification, event *Event, insecure bool) error {
+	var errs error
+	for i, n := range notifications {
+		if n.GetOnlyFailures() && event.Status == StatusSucceeded {
+			continue
+		}
+		if err := send(n, event, insecure); err != nil {
+			errs = multierror.Append(errs, errors.Annotatef(err, "notifications[%d]", i))
+		}
+	}
+	return errors.Trace(errs)
+}
+
+func send(n *api.Notification, event *Event, insecure bool) error {
+	var payload interface{} = event
+	if n.GetFormat() == api.NotificationFormat_NOTIFICATION_SLACK {
+		payload = slackMessage{Text: slackText(event)}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	timeout := defaultTimeout
+	if t := n.GetTimeout(); t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return errors.Annotatef(err, "parsing notification timeout")
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.GetUrl(), bytes.NewReader(body))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.GetHeaders() {
+		req.Header.Set(k, v)
+	}
+
+	client := utils.DefaultClient
+	if insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("notification endpoint answered with HTTP status %s: %s", resp.Status, utils.HTTPResponseBody(resp))
+	}
+	return nil
+}
+
+// slackText returns the summary of an event in Slack markup
+func slackText(event *Event) string {
+	var b strings.Builder
+	icon := ":white_check_mark:"
+	switch event.Status {
+	case StatusFailed:
+		icon = ":x:"
+	case StatusInterrupted:
+		icon = ":warning:"
+	}
+	fmt.Fprintf(&b, "%s charts-syncer run %s %s: %s -> %s\n", icon, event.RunID, event.Status, event.Source, event.Target)
+	fmt.Fprintf(&b, "%d charts out of sync, %d synced, %d failed in %s", event.Charts, event.Synced, event.Failed, (time.Duration(event.Duration * float64(time.Second))).Round(time.Second))
+	if len(event.Errors) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n```\n")
+	for i, e := range event.Errors {
+		if i == maxSlackErrors {
+			fmt.Fprintf(&b, "... and %d more errors\n", len(event.Errors)-maxSlackErrors)
+			break
+		}
+		b.WriteString(strings.ReplaceAll(e, "```", "'''") + "\n")
+	}
+	b.WriteString("```")
+	return b.String()
+}
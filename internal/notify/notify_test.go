@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestSend(t *testing.T) {
+	var got []map[string]interface{}
+	var headers []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got: %s request, want: POST", r.Method)
+		}
+		body := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		got = append(got, body)
+		headers = append(headers, r.Header)
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	event := &Event{
+		RunID:     "run-1",
+		Status:    StatusFailed,
+		Source:    "https://charts.example.com/bitnami",
+		Target:    "https://harbor.example.com/chartrepo/library",
+		StartedAt: time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC),
+		Duration:  90,
+		Charts:    3,
+		Synced:    2,
+		Failed:    1,
+		Errors:    []string{"nginx-13.2.0: unable to upload"},
+	}
+	notifications := []*api.Notification{
+		{Url: srv.URL + "/hooks", Headers: map[string]string{"Authorization": "Bearer token"}},
+		{Url: srv.URL + "/slack", Format: api.NotificationFormat_NOTIFICATION_SLACK, OnlyFailures: true},
+	}
+	if err := Send(notifications, event, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got: %d notifications, want: 2", len(got))
+	}
+	want := map[string]interface{}{
+		"runId":     "run-1",
+		"status":    "failed",
+		"source":    "https://charts.example.com/bitnami",
+		"target":    "https://harbor.example.com/chartrepo/library",
+		"startedAt": "2023-03-15T10:00:00Z",
+		"duration":  float64(90),
+		"charts":    float64(3),
+		"synced":    float64(2),
+		"failed":    float64(1),
+		"errors":    []interface{}{"nginx-13.2.0: unable to upload"},
+	}
+	if !reflect.DeepEqual(got[0], want) {
+		t.Errorf("got: %v, want: %v", got[0], want)
+	}
+	if h := headers[0].Get("Authorization"); h != "Bearer token" {
+		t.Errorf("got: %q authorization header, want the configured one", h)
+	}
+	if text, _ := got[1]["text"].(string); !strings.Contains(text, "charts-syncer run run-1 failed") || !strings.Contains(text, "nginx-13.2.0: unable to upload") {
+		t.Errorf("got: %q slack message", text)
+	}
+
+	// Endpoints only notified about failures are skipped for successful runs
+	got = nil
+	event.Status, event.Failed, event.Errors = StatusSucceeded, 0, nil
+	if err := Send(notifications, event, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got: %d notifications, want: 1", len(got))
+	}
+
+	// The failing endpoints do not prevent the others from being notified
+	got = nil
+	err := Send([]*api.Notification{{Url: srv.URL + "/broken"}, {Url: srv.URL + "/hooks"}}, event, false)
+	if err == nil || !strings.Contains(err.Error(), "notifications[0]") {
+		t.Errorf("got: %v, want an error of the first endpoint", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("got: %d notifications, want: 2", len(got))
+	}
+}
+
+func TestSlackText(t *testing.T) {
+	event := &Event{RunID: "run-1", Status: StatusFailed, Source: "src", Target: "dst", Duration: 61.4, Charts: 12, Synced: 0, Failed: 12}
+	for i := 0; i < 12; i++ {
+		event.Errors = append(event.Errors, fmt.Sprintf("chart-%d: unable to upload", i))
+	}
+	got := slackText(event)
+	if !strings.HasPrefix(got, ":x: charts-syncer run run-1 failed: src -> dst\n12 charts out of sync, 0 synced, 12 failed in 1m1s\n```\nchart-0: unable to upload\n") {
+		t.Errorf("got: %q", got)
+	}
+	if !strings.HasSuffix(got, "chart-9: unable to upload\n... and 2 more errors\n```") {
+		t.Errorf("got: %q, want the last errors counted", got)
+	}
+}
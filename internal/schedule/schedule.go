@@ -0,0 +1,202 @@
+// Package schedule computes the times of the periodic sync runs, from a fixed
+// interval or a cron expression.
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+// searchYears is how far the next time of a cron expression is looked for,
+// so expressions like "0 0 30 2 *" do not loop forever
+const searchYears = 5
+
+// Schedule returns the times of the periodic runs
+type Schedule interface {
+	// Next returns the first time of a run after t, or the zero time if
+	// there is none
+	Next(t time.Time) time.Time
+}
+
+// every runs at a fixed interval
+type every time.Duration
+
+// Every returns a schedule running every d
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// macros are the shorthands of the usual cron expressions
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of values of a field of a cron expression
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// 7 is also Sunday
+	dowField = field{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// cron runs at the times matching a cron expression, in the time zone of the
+// times it is given. Every field is a bit set of the values it matches.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// Like cron, a day matches any of the day of month and day of week
+	// fields if both are restricted
+	domAny, dowAny bool
+}
+
+// Parse parses a standard cron expression with five fields: minute, hour, day
+// of month, month and day of week, e.g. "*/15 * * * *". The fields accept
+// lists, ranges, steps and the English names of the months and days. The
+// @hourly, @daily, @weekly, @monthly and @yearly shorthands are supported too.
+func Parse(expr string) (Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.NotValidf("%q cron expression, it should have 5 fields", expr)
+	}
+
+	c := &cron{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	for i, f := range []struct {
+		bits  *uint64
+		field field
+	}{
+		{&c.minute, minuteField},
+		{&c.hour, hourField},
+		{&c.dom, domField},
+		{&c.month, monthField},
+		{&c.dow, dowField},
+	} {
+		bits, err := f.field.parse(fields[i])
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing %q cron expression", expr)
+		}
+		*f.bits = bits
+	}
+	// Sunday is 0 for time.Weekday
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	if c.Next(time.Now()).IsZero() {
+		return nil, errors.NotValidf("%q cron expression, it never runs", expr)
+	}
+	return c, nil
+}
+
+// parse returns the bit set of the values of a field
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			rng = item[:i]
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return 0, errors.NotValidf("%q step of %s field", item[i+1:], f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		switch i := strings.Index(rng, "-"); {
+		case rng == "*":
+		case i >= 0:
+			var err error
+			if low, err = f.value(rng[:i]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(rng[i+1:]); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, errors.NotValidf("%q range of %s field", rng, f.name)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			low = v
+			// Like cron, "5/10" is "5-max/10"
+			if step == 1 {
+				high = v
+			}
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or a name of a field
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return i + f.min, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, errors.NotValidf("%q %s, it should be between %d and %d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (c *cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(searchYears, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay returns whether the day of t matches the day of month and day of
+// week fields
+func (c *cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
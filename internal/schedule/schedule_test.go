@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	from := time.Date(2023, time.March, 15, 10, 7, 30, 0, time.UTC) // Wednesday
+	testCases := []struct {
+		expr   string
+		want   []string
+		errMsg string
+	}{
+		{expr: "*/15 * * * *", want: []string{"2023-03-15T10:15:00Z", "2023-03-15T10:30:00Z", "2023-03-15T10:45:00Z"}},
+		{expr: "0 2 * * *", want: []string{"2023-03-16T02:00:00Z", "2023-03-17T02:00:00Z"}},
+		{expr: "30 9-10 * * mon-fri", want: []string{"2023-03-15T10:30:00Z", "2023-03-16T09:30:00Z"}},
+		{expr: "0 0 * * 7", want: []string{"2023-03-19T00:00:00Z", "2023-03-26T00:00:00Z"}},
+		{expr: "0 0 1,15 * sun", want: []string{"2023-03-19T00:00:00Z", "2023-03-26T00:00:00Z", "2023-04-01T00:00:00Z"}},
+		{expr: "0 0 29 feb *", want: []string{"2024-02-29T00:00:00Z", "2028-02-29T00:00:00Z"}},
+		{expr: "5/20 12 * * *", want: []string{"2023-03-15T12:05:00Z", "2023-03-15T12:25:00Z", "2023-03-15T12:45:00Z"}},
+		{expr: "@weekly", want: []string{"2023-03-19T00:00:00Z"}},
+		{expr: "0 0 * *", errMsg: `"0 0 * *" cron expression, it should have 5 fields not valid`},
+		{expr: "60 * * * *", errMsg: `parsing "60 * * * *" cron expression: "60" minute, it should be between 0 and 59 not valid`},
+		{expr: "*/0 * * * *", errMsg: `parsing "*/0 * * * *" cron expression: "0" step of minute field not valid`},
+		{expr: "0 5-2 * * *", errMsg: `parsing "0 5-2 * * *" cron expression: "5-2" range of hour field not valid`},
+		{expr: "0 0 30 2 *", errMsg: `"0 0 30 2 *" cron expression, it never runs not valid`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("got: %v, want: %q", err, tc.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := from
+			for _, want := range tc.want {
+				next = s.Next(next)
+				if got := next.Format(time.RFC3339); got != want {
+					t.Errorf("got: %s, want: %s", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvery(t *testing.T) {
+	from := time.Date(2023, time.March, 15, 10, 7, 30, 0, time.UTC)
+	if got, want := Every(15*time.Minute).Next(from), from.Add(15*time.Minute); !got.Equal(want) {
+		t.Errorf("got: %s, want: %s", got, want)
+	}
+}
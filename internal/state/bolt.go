@@ -0,0 +1,169 @@
+package state
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// openTimeout is how long to wait for the file lock held by a
+	// concurrent run
+	openTimeout  = 5 * time.Second
+	chartsBucket = []byte("charts")
+	runsBucket   = []byte("runs")
+)
+
+// boltStore stores the records in a local bbolt file
+type boltStore struct {
+	db *bolt.DB
+}
+
+// newBoltStore opens the state file, creating it if missing
+func newBoltStore(file string) (*boltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, errors.Trace(err)
+	}
+	db, err := bolt.Open(file, 0644, &bolt.Options{Timeout: openTimeout})
+	if err == bolt.ErrTimeout {
+		return nil, errors.Errorf("state file %q is locked by another run", file)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "opening state file %q", file)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(chartsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(runsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, errors.Trace(err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+// recordKey returns the key of a chart version. Chart names cannot contain
+// slashes, so keys are unique.
+func recordKey(name, version string) []byte {
+	return []byte(name + "/" + version)
+}
+
+// Get returns the record of a chart version
+func (s *boltStore) Get(name, version string) (*Record, error) {
+	r := &Record{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(chartsBucket).Get(recordKey(name, version))
+		if data == nil {
+			return errors.NotFoundf("%s:%s chart record", name, version)
+		}
+		return errors.Trace(json.Unmarshal(data, r))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Put records a chart version
+func (s *boltStore) Put(r *Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(chartsBucket).Put(recordKey(r.Name, r.Version), data)
+	}))
+}
+
+// List returns the records sorted by name and version
+func (s *boltStore) List() ([]*Record, error) {
+	var records []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(chartsBucket).ForEach(func(_, data []byte) error {
+			r := &Record{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return errors.Trace(err)
+			}
+			records = append(records, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Delete removes the record of a chart version
+func (s *boltStore) Delete(name, version string) error {
+	return errors.Trace(s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(chartsBucket).Delete(recordKey(name, version))
+	}))
+}
+
+// PutRun records a sync run. Runs are keyed by a sequence number, so they
+// are iterated in the order they were recorded.
+func (s *boltStore) PutRun(r *Run) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(runsBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := b.Put(key, data); err != nil {
+			return errors.Trace(err)
+		}
+		var keys [][]byte
+		if err := b.ForEach(func(k, _ []byte) error {
+			keys = append(keys, k)
+			return nil
+		}); err != nil {
+			return errors.Trace(err)
+		}
+		for len(keys) > maxRuns {
+			if err := b.Delete(keys[0]); err != nil {
+				return errors.Trace(err)
+			}
+			keys = keys[1:]
+		}
+		return nil
+	}))
+}
+
+// ListRuns returns the recorded runs, oldest first
+func (s *boltStore) ListRuns() ([]*Run, error) {
+	var runs []*Run
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(runsBucket).ForEach(func(_, data []byte) error {
+			r := &Run{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return errors.Trace(err)
+			}
+			runs = append(runs, r)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return runs, nil
+}
+
+// Close releases the state file
+func (s *boltStore) Close() error {
+	return errors.Trace(s.db.Close())
+}
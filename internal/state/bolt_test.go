@@ -0,0 +1,131 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+func TestBoltStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state", "state.db")
+
+	s, err := newBoltStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("apache", "7.3.15"); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a not found error", err)
+	}
+
+	syncedAt := time.Date(2020, 5, 15, 10, 0, 0, 0, time.UTC)
+	records := []*Record{
+		{Name: "redis", Version: "10.0.0", SourceName: "redis", SourceVersion: "10.0.0", SourceDigest: "abc", SyncedAt: syncedAt},
+		{Name: "apache", Version: "7.10.0", SourceName: "apache", SourceVersion: "7.10.0", SourceDigest: "def", SyncedAt: syncedAt},
+		{Name: "apache", Version: "7.3.15", SourceName: "apache", SourceVersion: "7.3.15", SourceDigest: "ghi", SyncedAt: syncedAt},
+	}
+	for _, r := range records {
+		if err := s.Put(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Records persist once the store is reopened
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = newBoltStore(file); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got, err := s.Get("apache", "7.3.15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, records[2]) {
+		t.Errorf("got: %+v, want: %+v", got, records[2])
+	}
+	if err := s.Delete("redis", "10.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	list, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Records are sorted by version, not lexically
+	if want := []*Record{records[2], records[1]}; !reflect.DeepEqual(list, want) {
+		t.Errorf("got: %+v, want: %+v", list, want)
+	}
+}
+
+func TestBoltStoreRuns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.db")
+	defer func(n int) { maxRuns = n }(maxRuns)
+	maxRuns = 2
+
+	s, err := newBoltStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	startedAt := time.Date(2020, 5, 15, 10, 0, 0, 0, time.UTC)
+	runs := []*Run{
+		{ID: "1", StartedAt: startedAt, Duration: time.Minute, SourceKind: "HELM", TargetKind: "OCI", Charts: 2, Synced: 2},
+		{ID: "2", StartedAt: startedAt.Add(time.Hour), Duration: time.Second, SourceKind: "HELM", TargetKind: "OCI"},
+		{ID: "3", StartedAt: startedAt.Add(2 * time.Hour), Duration: time.Minute, SourceKind: "HELM", TargetKind: "OCI", Charts: 1, Failed: 1, ErrorClasses: map[string]int{"not_found": 1}},
+	}
+	for _, r := range runs {
+		if err := s.PutRun(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = newBoltStore(file); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got, err := s.ListRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The oldest run is discarded
+	if want := runs[1:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
+func TestBoltStoreIsLocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "state.db")
+	defer func(timeout time.Duration) { openTimeout = timeout }(openTimeout)
+	openTimeout = 100 * time.Millisecond
+
+	s, err := newBoltStore(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := newBoltStore(file); err == nil {
+		t.Errorf("expected an error opening a state file used by another run")
+	}
+}
@@ -0,0 +1,364 @@
+package state
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const (
+	// recordsKey is the ConfigMap key storing the records
+	recordsKey = "records.json"
+	// runsKey is the ConfigMap key storing the runs
+	runsKey = "runs.json"
+	// leaseDuration is how long a Lease is valid if the syncer holding it
+	// stops renewing it, e.g. because it was killed
+	leaseDuration = time.Minute
+	// defaultLockTimeout is how long to wait for the Lease held by another
+	// syncer if no timeout is configured
+	defaultLockTimeout = 5 * time.Minute
+)
+
+var (
+	// leaseRenewPeriod is how often the Lease is renewed
+	leaseRenewPeriod = leaseDuration / 3
+	// lockRetryPeriod is how often acquiring the Lease is retried
+	lockRetryPeriod = 5 * time.Second
+)
+
+// kubernetesStore stores the records in a ConfigMap, guarded by a Lease.
+//
+// The records are loaded once the Lease is acquired. As no other syncer can
+// write them while the Lease is held, they are only read from memory then.
+type kubernetesStore struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	identity  string
+
+	mu        sync.Mutex
+	records   map[string]*Record
+	runs      []*Run
+	configMap *corev1.ConfigMap
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newKubernetesStore connects to the configured cluster and acquires the
+// state Lease
+func newKubernetesStore(config *api.KubernetesState) (*kubernetesStore, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = config.GetKubeconfig()
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, errors.Annotatef(err, "loading kubeconfig")
+	}
+	namespace := config.GetNamespace()
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	lockTimeout := defaultLockTimeout
+	if config.GetLockTimeout() != "" {
+		if lockTimeout, err = time.ParseDuration(config.GetLockTimeout()); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return openKubernetesStore(client, namespace, config.GetName(), lockTimeout)
+}
+
+// openKubernetesStore acquires the state Lease and loads the records
+func openKubernetesStore(client kubernetes.Interface, namespace, name string, lockTimeout time.Duration) (*kubernetesStore, error) {
+	identity, err := lockIdentity()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s := &kubernetesStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		identity:  identity,
+		records:   map[string]*Record{},
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := s.acquireLease()
+		if err != nil {
+			return nil, errors.Annotatef(err, "acquiring %s/%s lease", namespace, name)
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("state %s/%s is locked by another syncer", namespace, name)
+		}
+		klog.V(3).Infof("State %s/%s is locked by another syncer, waiting...", namespace, name)
+		time.Sleep(lockRetryPeriod)
+	}
+	go s.renewLease()
+
+	if err := s.load(); err != nil {
+		s.Close()
+		return nil, errors.Trace(err)
+	}
+	return s, nil
+}
+
+// lockIdentity returns a unique identity for the Lease holder
+func lockIdentity() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Trace(err)
+	}
+	return fmt.Sprintf("%s_%s", hostname, hex.EncodeToString(b)), nil
+}
+
+// acquireLease returns whether the Lease could be acquired. It fails if it
+// is held by another syncer and has not expired.
+func (s *kubernetesStore) acquireLease() (bool, error) {
+	ctx := context.Background()
+	leases := s.client.CoordinationV1().Leases(s.namespace)
+	now := metav1.NewMicroTime(time.Now())
+	seconds := int32(leaseDuration.Seconds())
+
+	lease, err := leases.Get(ctx, s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &s.identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		_, err := leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, errors.Trace(err)
+	}
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	spec := lease.Spec
+	if spec.HolderIdentity != nil && *spec.HolderIdentity != "" && *spec.HolderIdentity != s.identity &&
+		spec.RenewTime != nil && spec.LeaseDurationSeconds != nil &&
+		spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds)*time.Second).After(now.Time) {
+		return false, nil
+	}
+	lease.Spec.HolderIdentity = &s.identity
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.AcquireTime = &now
+	lease.Spec.RenewTime = &now
+	// A conflict means another syncer acquired the Lease meanwhile
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, errors.Trace(err)
+}
+
+// renewLease renews the Lease until the store is closed
+func (s *kubernetesStore) renewLease() {
+	defer close(s.done)
+	ticker := time.NewTicker(leaseRenewPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.updateLease(func(spec *coordinationv1.LeaseSpec) {
+				now := metav1.NewMicroTime(time.Now())
+				spec.RenewTime = &now
+			}); err != nil {
+				klog.Errorf("unable to renew state %s/%s lease: %v", s.namespace, s.name, err)
+			}
+		}
+	}
+}
+
+// updateLease modifies the Lease if it is still held by this store
+func (s *kubernetesStore) updateLease(fn func(spec *coordinationv1.LeaseSpec)) error {
+	ctx := context.Background()
+	leases := s.client.CoordinationV1().Leases(s.namespace)
+	lease, err := leases.Get(ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != s.identity {
+		return errors.Errorf("lease is held by another syncer")
+	}
+	fn(&lease.Spec)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	return errors.Trace(err)
+}
+
+// load reads the records from the ConfigMap, if it exists
+func (s *kubernetesStore) load() error {
+	cm, err := s.client.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), s.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Annotatef(err, "reading %s/%s config map", s.namespace, s.name)
+	}
+	s.configMap = cm
+	if data, ok := cm.Data[recordsKey]; ok {
+		var records []*Record
+		if err := json.Unmarshal([]byte(data), &records); err != nil {
+			return errors.Annotatef(err, "parsing %s/%s config map", s.namespace, s.name)
+		}
+		for _, r := range records {
+			s.records[string(recordKey(r.Name, r.Version))] = r
+		}
+	}
+	if data, ok := cm.Data[runsKey]; ok {
+		if err := json.Unmarshal([]byte(data), &s.runs); err != nil {
+			return errors.Annotatef(err, "parsing %s/%s config map", s.namespace, s.name)
+		}
+	}
+	return nil
+}
+
+// save writes the records to the ConfigMap, creating it if needed
+func (s *kubernetesStore) save() error {
+	data, err := json.Marshal(s.sortedRecords())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	runs, err := json.Marshal(s.runs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ctx := context.Background()
+	configMaps := s.client.CoreV1().ConfigMaps(s.namespace)
+	if s.configMap == nil {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+			Data:       map[string]string{recordsKey: string(data), runsKey: string(runs)},
+		}
+		if s.configMap, err = configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return errors.Annotatef(err, "creating %s/%s config map", s.namespace, s.name)
+		}
+		return nil
+	}
+	cm := s.configMap.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[recordsKey] = string(data)
+	cm.Data[runsKey] = string(runs)
+	if s.configMap, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return errors.Annotatef(err, "updating %s/%s config map", s.namespace, s.name)
+	}
+	return nil
+}
+
+// sortedRecords returns the records sorted by name and version
+func (s *kubernetesStore) sortedRecords() []*Record {
+	records := make([]*Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	sortRecords(records)
+	return records
+}
+
+// Get returns the record of a chart version
+func (s *kubernetesStore) Get(name, version string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[string(recordKey(name, version))]
+	if !ok {
+		return nil, errors.NotFoundf("%s:%s chart record", name, version)
+	}
+	return r, nil
+}
+
+// Put records a chart version
+func (s *kubernetesStore) Put(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[string(recordKey(r.Name, r.Version))] = r
+	return errors.Trace(s.save())
+}
+
+// List returns the records sorted by name and version
+func (s *kubernetesStore) List() ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedRecords(), nil
+}
+
+// Delete removes the record of a chart version
+func (s *kubernetesStore) Delete(name, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := string(recordKey(name, version))
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+	delete(s.records, key)
+	return errors.Trace(s.save())
+}
+
+// PutRun records a sync run
+func (s *kubernetesStore) PutRun(r *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, r)
+	if len(s.runs) > maxRuns {
+		s.runs = s.runs[len(s.runs)-maxRuns:]
+	}
+	return errors.Trace(s.save())
+}
+
+// ListRuns returns the recorded runs, oldest first
+func (s *kubernetesStore) ListRuns() ([]*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Run(nil), s.runs...), nil
+}
+
+// Close releases the Lease, so other syncers do not wait for it to expire
+func (s *kubernetesStore) Close() error {
+	close(s.stop)
+	<-s.done
+	return errors.Trace(s.updateLease(func(spec *coordinationv1.LeaseSpec) {
+		spec.HolderIdentity = nil
+		spec.RenewTime = nil
+	}))
+}
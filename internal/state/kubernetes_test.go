@@ -0,0 +1,99 @@
+package state
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubernetesStore(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	s, err := openKubernetesStore(client, "default", "charts-syncer", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("apache", "7.3.15"); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a not found error", err)
+	}
+
+	syncedAt := time.Date(2020, 5, 15, 10, 0, 0, 0, time.UTC)
+	records := []*Record{
+		{Name: "redis", Version: "10.0.0", SourceName: "redis", SourceVersion: "10.0.0", SourceDigest: "abc", SyncedAt: syncedAt},
+		{Name: "apache", Version: "7.10.0", SourceName: "apache", SourceVersion: "7.10.0", SourceDigest: "def", SyncedAt: syncedAt},
+		{Name: "apache", Version: "7.3.15", SourceName: "apache", SourceVersion: "7.3.15", SourceDigest: "ghi", SyncedAt: syncedAt},
+	}
+	for _, r := range records {
+		if err := s.Put(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Delete("redis", "10.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	run := &Run{ID: "1", StartedAt: syncedAt, Duration: time.Minute, SourceKind: "HELM", TargetKind: "OCI", Charts: 3, Synced: 3}
+	if err := s.PutRun(run); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records are persisted in the ConfigMap
+	s, err = openKubernetesStore(client, "default", "charts-syncer", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	got, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Record{records[2], records[1]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+	r, err := s.Get("apache", "7.10.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, records[1]) {
+		t.Errorf("got: %+v, want: %+v", r, records[1])
+	}
+	runs, err := s.ListRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*Run{run}; !reflect.DeepEqual(runs, want) {
+		t.Errorf("got: %+v, want: %+v", runs, want)
+	}
+}
+
+func TestKubernetesStoreIsLocked(t *testing.T) {
+	defer func(d time.Duration) { lockRetryPeriod = d }(lockRetryPeriod)
+	lockRetryPeriod = 10 * time.Millisecond
+	client := fake.NewSimpleClientset()
+
+	s, err := openKubernetesStore(client, "default", "charts-syncer", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = openKubernetesStore(client, "default", "charts-syncer", 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "locked by another syncer") {
+		t.Errorf("got: %v, want a locked error", err)
+	}
+
+	// The Lease is released when the store is closed
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = openKubernetesStore(client, "default", "charts-syncer", 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+}
@@ -0,0 +1,186 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"sync"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/azblob"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/gcs"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/s3"
+)
+
+// objectDocument is the content of a state object
+type objectDocument struct {
+	Records []*Record `json:"records"`
+	Runs    []*Run    `json:"runs,omitempty"`
+}
+
+// objectStore stores the records in a JSON object of a bucket.
+//
+// The records are loaded once, then read from memory. The object is written
+// again on every change, so an interrupted run does not lose the charts it
+// synced. It is not locked, so it must not be used by several runs at a time.
+type objectStore struct {
+	store bucket.Store
+	key   string
+
+	mu      sync.Mutex
+	records map[string]*Record
+	runs    []*Run
+}
+
+// newObjectStore connects to the bucket of the state object and loads the
+// records
+func newObjectStore(repo *api.Repo, insecure bool) (*objectStore, error) {
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The store of the bucket is rooted at the folder of the object
+	dir, key := path.Split(u.Path)
+	if key == "" {
+		return nil, errors.NotValidf("state object URL %q, it has no key", repo.GetUrl())
+	}
+	u.Path = dir
+	root := &api.Repo{
+		Kind:   repo.GetKind(),
+		Url:    u.String(),
+		Auth:   repo.GetAuth(),
+		S3:     repo.GetS3(),
+		Gcs:    repo.GetGcs(),
+		Azblob: repo.GetAzblob(),
+	}
+
+	var store bucket.Store
+	switch repo.GetKind() {
+	case api.Kind_S3:
+		store, err = s3.NewStore(root, insecure)
+	case api.Kind_GCS:
+		store, err = gcs.NewStore(root, insecure)
+	case api.Kind_AZBLOB:
+		store, err = azblob.NewStore(root, insecure)
+	default:
+		return nil, errors.NotSupportedf("state objects in %s repositories", repo.GetKind())
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return openObjectStore(store, key)
+}
+
+// openObjectStore loads the records of the state object, if it exists
+func openObjectStore(store bucket.Store, key string) (*objectStore, error) {
+	s := &objectStore{store: store, key: key, records: map[string]*Record{}}
+	rc, err := store.Get(key)
+	if errors.IsNotFound(err) {
+		klog.V(3).Infof("%s/%s state object does not exist, it is created with the first record", store, key)
+		return s, nil
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading %s/%s state object", store, key)
+	}
+	defer rc.Close()
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading %s/%s state object", store, key)
+	}
+	doc := &objectDocument{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return nil, errors.Annotatef(err, "parsing %s/%s state object", store, key)
+	}
+	for _, r := range doc.Records {
+		s.records[string(recordKey(r.Name, r.Version))] = r
+	}
+	s.runs = doc.Runs
+	return s, nil
+}
+
+// save writes the records to the state object
+func (s *objectStore) save() error {
+	records := make([]*Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	sortRecords(records)
+	data, err := json.Marshal(&objectDocument{Records: records, Runs: s.runs})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = s.store.Put(s.key, bytes.NewReader(data), int64(len(data)), "application/json")
+	return errors.Annotatef(err, "writing %s/%s state object", s.store, s.key)
+}
+
+// Get returns the record of a chart version
+func (s *objectStore) Get(name, version string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.records[string(recordKey(name, version))]
+	if !ok {
+		return nil, errors.NotFoundf("%s:%s chart record", name, version)
+	}
+	return r, nil
+}
+
+// Put records a chart version
+func (s *objectStore) Put(r *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[string(recordKey(r.Name, r.Version))] = r
+	return errors.Trace(s.save())
+}
+
+// List returns the records sorted by name and version
+func (s *objectStore) List() ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]*Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+// Delete removes the record of a chart version
+func (s *objectStore) Delete(name, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := string(recordKey(name, version))
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+	delete(s.records, key)
+	return errors.Trace(s.save())
+}
+
+// PutRun records a sync run
+func (s *objectStore) PutRun(r *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, r)
+	if len(s.runs) > maxRuns {
+		s.runs = s.runs[len(s.runs)-maxRuns:]
+	}
+	return errors.Trace(s.save())
+}
+
+// ListRuns returns the recorded runs, oldest first
+func (s *objectStore) ListRuns() ([]*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Run(nil), s.runs...), nil
+}
+
+// Close does nothing, the object is written on every change
+func (s *objectStore) Close() error {
+	return nil
+}
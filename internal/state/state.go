@@ -0,0 +1,98 @@
+// Package state records the charts synced to a target repository, so later
+// runs can skip them without exploring the target and notice the charts that
+// changed upstream since they were synced.
+package state
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// Record is the state of a chart version synced to the target repository
+type Record struct {
+	// Name and version of the chart in the target repository
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Upstream name, version and digest of the chart
+	SourceName    string `json:"sourceName"`
+	SourceVersion string `json:"sourceVersion"`
+	SourceDigest  string `json:"sourceDigest"`
+	// Digest of the pushed package
+	TargetDigest string `json:"targetDigest,omitempty"`
+	// Transformations applied to the chart
+	Transformations []json.RawMessage `json:"transformations,omitempty"`
+	SyncedAt        time.Time         `json:"syncedAt"`
+}
+
+// maxRuns is the number of runs kept in the store. Older runs are discarded.
+var maxRuns = 500
+
+// Run is the summary of a sync run. It does not identify the synced charts.
+type Run struct {
+	ID        string        `json:"id"`
+	StartedAt time.Time     `json:"startedAt"`
+	Duration  time.Duration `json:"duration"`
+	// Kinds of the source and target, like HELM or INTERMEDIATE_BUNDLES
+	SourceKind string `json:"sourceKind"`
+	TargetKind string `json:"targetKind"`
+	// Charts is the number of charts out of sync when the run started
+	Charts int `json:"charts"`
+	Synced int `json:"synced"`
+	Failed int `json:"failed"`
+	// ErrorClasses counts the errors of the run by class, like not_found
+	ErrorClasses map[string]int `json:"errorClasses,omitempty"`
+	Interrupted  bool           `json:"interrupted,omitempty"`
+	// Throttled is the number of requests throttled by the remote servers
+	// during the run, and ThrottleWait the time spent waiting for them
+	Throttled    int           `json:"throttled,omitempty"`
+	ThrottleWait time.Duration `json:"throttleWait,omitempty"`
+}
+
+// Store is implemented by the backends storing the records
+type Store interface {
+	// Get returns the record of a chart version in the target repository. It
+	// returns a NotFound error if the chart version was not recorded.
+	Get(name, version string) (*Record, error)
+	// Put records a chart version, replacing its previous record
+	Put(r *Record) error
+	// List returns the records sorted by name and version
+	List() ([]*Record, error)
+	// Delete removes the record of a chart version, if any
+	Delete(name, version string) error
+	// PutRun records a sync run, discarding the oldest runs beyond maxRuns
+	PutRun(r *Run) error
+	// ListRuns returns the recorded runs, oldest first
+	ListRuns() ([]*Run, error)
+	Close() error
+}
+
+// New returns a store for the provided config. Insecure SSL connections are
+// allowed to the buckets of the state objects if insecure is set.
+func New(config *api.State, insecure bool) (Store, error) {
+	switch backend := config.GetBackend().(type) {
+	case *api.State_File:
+		return newBoltStore(backend.File)
+	case *api.State_Kubernetes:
+		return newKubernetesStore(backend.Kubernetes)
+	case *api.State_Object:
+		return newObjectStore(backend.Object, insecure)
+	default:
+		return nil, errors.New("no state backend defined")
+	}
+}
+
+// sortRecords sorts records by name and version
+func sortRecords(records []*Record) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return utils.CompareVersions(records[i].Version, records[j].Version) < 0
+	})
+}
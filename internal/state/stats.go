@@ -0,0 +1,72 @@
+package state
+
+import (
+	"sort"
+	"time"
+)
+
+// Stats summarizes the recorded sync runs
+type Stats struct {
+	Runs int
+	// Runs that failed to sync at least one chart, or failed altogether
+	FailedRuns      int
+	InterruptedRuns int
+	Synced          int
+	Failed          int
+	// ErrorClasses counts the errors of all the runs by class
+	ErrorClasses map[string]int
+	// Kinds counts the runs by source and target kinds, like "HELM -> OCI"
+	Kinds           map[string]int
+	AverageDuration time.Duration
+	MaxDuration     time.Duration
+	First, Last     *Run
+}
+
+// Summarize returns the stats of the provided runs, oldest first
+func Summarize(runs []*Run) *Stats {
+	st := &Stats{
+		Runs:         len(runs),
+		ErrorClasses: map[string]int{},
+		Kinds:        map[string]int{},
+	}
+	if len(runs) == 0 {
+		return st
+	}
+	st.First, st.Last = runs[0], runs[len(runs)-1]
+	var total time.Duration
+	for _, r := range runs {
+		st.Synced += r.Synced
+		st.Failed += r.Failed
+		if r.Interrupted {
+			st.InterruptedRuns++
+		} else if len(r.ErrorClasses) > 0 {
+			st.FailedRuns++
+		}
+		for class, n := range r.ErrorClasses {
+			st.ErrorClasses[class] += n
+		}
+		st.Kinds[r.SourceKind+" -> "+r.TargetKind]++
+		total += r.Duration
+		if r.Duration > st.MaxDuration {
+			st.MaxDuration = r.Duration
+		}
+	}
+	st.AverageDuration = total / time.Duration(len(runs))
+	return st
+}
+
+// SortedKeys returns the keys of a counter sorted by decreasing count and
+// name
+func SortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
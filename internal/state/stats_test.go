@@ -0,0 +1,37 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	startedAt := time.Date(2020, 5, 15, 10, 0, 0, 0, time.UTC)
+	runs := []*Run{
+		{StartedAt: startedAt, Duration: time.Minute, SourceKind: "HELM", TargetKind: "OCI", Charts: 3, Synced: 3},
+		{StartedAt: startedAt.Add(time.Hour), Duration: 3 * time.Minute, SourceKind: "HELM", TargetKind: "OCI", Charts: 2, Synced: 1, Failed: 1, ErrorClasses: map[string]int{"not_found": 1}},
+		{StartedAt: startedAt.Add(2 * time.Hour), Duration: 2 * time.Minute, SourceKind: "HELM", TargetKind: "LOCAL", Interrupted: true, ErrorClasses: map[string]int{"timeout": 2}},
+	}
+
+	st := Summarize(runs)
+	if st.Runs != 3 || st.FailedRuns != 1 || st.InterruptedRuns != 1 || st.Synced != 4 || st.Failed != 1 {
+		t.Errorf("got: %+v", st)
+	}
+	if st.AverageDuration != 2*time.Minute || st.MaxDuration != 3*time.Minute {
+		t.Errorf("got: %s average and %s longest durations", st.AverageDuration, st.MaxDuration)
+	}
+	if st.First != runs[0] || st.Last != runs[2] {
+		t.Errorf("got: %+v first and %+v last runs", st.First, st.Last)
+	}
+	if got, want := SortedKeys(st.ErrorClasses), []string{"timeout", "not_found"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := SortedKeys(st.Kinds), []string{"HELM -> OCI", "HELM -> LOCAL"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if st := Summarize(nil); st.Runs != 0 || st.Last != nil {
+		t.Errorf("got: %+v, want empty stats", st)
+	}
+}
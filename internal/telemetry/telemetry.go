@@ -0,0 +1,96 @@
+// Package telemetry sends the anonymous usage reports of the sync runs, if
+// explicitly enabled.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// defaultTimeout is the time to wait for the endpoint to answer if no timeout
+// is configured
+const defaultTimeout = 10 * time.Second
+
+// Report is the anonymous usage report of a sync run. It does not include
+// any URL, chart name or identifier of the run.
+type Report struct {
+	Version    string `json:"version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	SourceKind string `json:"sourceKind"`
+	TargetKind string `json:"targetKind"`
+	Charts     int    `json:"charts"`
+	Synced     int    `json:"synced"`
+	Failed     int    `json:"failed"`
+	// ErrorClasses counts the errors of the run by class, like not_found
+	ErrorClasses    map[string]int `json:"errorClasses,omitempty"`
+	DurationSeconds float64        `json:"durationSeconds"`
+	Interrupted     bool           `json:"interrupted,omitempty"`
+}
+
+// NewReport returns the report of a sync run made by the provided version of
+// charts-syncer
+func NewReport(run *state.Run, version string) *Report {
+	return &Report{
+		Version:         version,
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		SourceKind:      run.SourceKind,
+		TargetKind:      run.TargetKind,
+		Charts:          run.Charts,
+		Synced:          run.Synced,
+		Failed:          run.Failed,
+		ErrorClasses:    run.ErrorClasses,
+		DurationSeconds: run.Duration.Seconds(),
+		Interrupted:     run.Interrupted,
+	}
+}
+
+// Send posts a report to the configured endpoint. Reports are only sent if
+// telemetry is enabled.
+func Send(config *api.Telemetry, report *Report, insecure bool) error {
+	if !config.GetEnabled() {
+		return nil
+	}
+	body, err := json.Marshal(report)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	timeout := defaultTimeout
+	if t := config.GetTimeout(); t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return errors.Annotatef(err, "parsing telemetry timeout")
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.GetEndpoint(), bytes.NewReader(body))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := utils.DefaultClient
+	if insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("telemetry endpoint answered with HTTP status %s: %s", resp.Status, utils.HTTPResponseBody(resp))
+	}
+	return nil
+}
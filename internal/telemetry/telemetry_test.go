@@ -0,0 +1,69 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+)
+
+func TestSend(t *testing.T) {
+	var got *Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got: %s request, want: POST", r.Method)
+		}
+		got = &Report{}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	run := &state.Run{
+		ID:           "run-1",
+		StartedAt:    time.Now(),
+		Duration:     90 * time.Second,
+		SourceKind:   "HELM",
+		TargetKind:   "OCI",
+		Charts:       3,
+		Synced:       2,
+		Failed:       1,
+		ErrorClasses: map[string]int{"unauthorized": 1},
+	}
+	report := NewReport(run, "v1.0.0")
+
+	// Nothing is sent unless enabled
+	if err := Send(&api.Telemetry{Endpoint: srv.URL}, report, false); err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("got: %+v, want no report", got)
+	}
+
+	if err := Send(&api.Telemetry{Enabled: true, Endpoint: srv.URL}, report, false); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, report) {
+		t.Errorf("got: %+v, want: %+v", got, report)
+	}
+	if got, want := report.DurationSeconds, 90.0; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestSendFails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	if err := Send(&api.Telemetry{Enabled: true, Endpoint: srv.URL}, &Report{}, false); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+)
+
+var (
+	digestCacheMu sync.RWMutex
+	// digestCacheDir is the directory of the chart packages keyed by their
+	// digest. The packages are not shared if it is empty.
+	digestCacheDir string
+)
+
+// SetDigestCache configures the directory storing the fetched chart packages
+// keyed by their SHA256 digest. Packages fetched once from any repository are
+// then reused for the charts with the same digest, e.g. common dependencies,
+// instead of being downloaded again. An empty dir disables it.
+func SetDigestCache(dir string) {
+	digestCacheMu.Lock()
+	defer digestCacheMu.Unlock()
+	digestCacheDir = dir
+}
+
+// digestCachePath returns the path of a package in the digest cache, or an
+// empty string if the cache is disabled or the digest is not a SHA256 one
+func digestCachePath(digest string) string {
+	digestCacheMu.RLock()
+	defer digestCacheMu.RUnlock()
+	digest = strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+	if digestCacheDir == "" || len(digest) != 64 || strings.Trim(digest, "0123456789abcdef") != "" {
+		return ""
+	}
+	return filepath.Join(digestCacheDir, "sha256", digest+".tgz")
+}
+
+// ReadDigestCache copies the package with the provided digest from the digest
+// cache to the id file of a repository cache. It returns false if the package
+// is not in the digest cache.
+func ReadDigestCache(digest, id string, c cache.Cacher) (bool, error) {
+	file := digestCachePath(digest)
+	if file == "" {
+		return false, nil
+	}
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer f.Close()
+
+	if err := c.Invalidate(id); err != nil {
+		return false, errors.Trace(err)
+	}
+	w, err := c.Writer(id)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	_, err = io.Copy(w, f)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = validateFetchedChart(c.Path(id), digest)
+	}
+	if err != nil {
+		// A package that cannot be reused is fetched again
+		klog.Warningf("Package of %q chart in the digest cache is not valid, fetching it again: %v", id, err)
+		os.Remove(file)
+		return false, errors.Trace(c.Invalidate(id))
+	}
+	klog.V(4).Infof("Reused %q chart from the digest cache", id)
+	return true, nil
+}
+
+// WriteDigestCache stores a fetched package in the digest cache, keyed by its
+// SHA256 digest. Packages already in the cache are not written again.
+func WriteDigestCache(file string) error {
+	digest, err := FileSha256(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	dst := digestCachePath(digest)
+	if dst == "" {
+		return nil
+	}
+	if ok, err := FileExists(dst); err != nil || ok {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return errors.Trace(err)
+	}
+
+	src, err := os.Open(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer src.Close()
+	// Packages are written to a temporary file first, so concurrent runs never
+	// read a partial one
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), digest+".*.tmp")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp.Name(), dst))
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+)
+
+// MultipartFile is a file field of a multipart/form-data body. Its content is
+// read from Path, or taken from Data if Path is empty.
+type MultipartFile struct {
+	Field string
+	Name  string
+	Path  string
+	Data  []byte
+}
+
+// multipartBody streams a multipart body and closes the underlying files
+type multipartBody struct {
+	io.Reader
+	files []*os.File
+}
+
+func (b *multipartBody) Close() error {
+	var errs error
+	for _, f := range b.files {
+		if err := f.Close(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// NewMultipartFileBody prepares a multipart/form-data request body with a
+// single file field.
+//
+// The file is streamed from disk instead of being copied into memory. It
+// returns a function that opens a new body each time it is called, so it can
+// be used as http.Request.GetBody to retry requests, the content type and the
+// size of the body.
+func NewMultipartFileBody(field, file string) (func() (io.ReadCloser, error), string, int64, error) {
+	return NewMultipartBody(MultipartFile{Field: field, Name: file, Path: file})
+}
+
+// NewMultipartBody prepares a multipart/form-data request body with several
+// file fields, in order. It behaves like NewMultipartFileBody.
+func NewMultipartBody(files ...MultipartFile) (func() (io.ReadCloser, error), string, int64, error) {
+	buf := &bytes.Buffer{}
+	mpw := multipart.NewWriter(buf)
+	// Each file is preceded by the part header in buf, which ends at the
+	// recorded offset
+	offsets := make([]int, len(files))
+	var size int64
+	for i, f := range files {
+		name := f.Name
+		if name == "" {
+			name = filepath.Base(f.Path)
+		}
+		if _, err := mpw.CreateFormFile(f.Field, name); err != nil {
+			return nil, "", 0, errors.Trace(err)
+		}
+		offsets[i] = buf.Len()
+		if f.Path == "" {
+			size += int64(len(f.Data))
+			continue
+		}
+		fi, err := os.Stat(f.Path)
+		if err != nil {
+			return nil, "", 0, errors.Trace(err)
+		}
+		size += fi.Size()
+	}
+	// Closing the writer appends the closing boundary
+	if err := mpw.Close(); err != nil {
+		return nil, "", 0, errors.Trace(err)
+	}
+	data := buf.Bytes()
+
+	body := func() (io.ReadCloser, error) {
+		b := &multipartBody{}
+		var readers []io.Reader
+		start := 0
+		for i, f := range files {
+			readers = append(readers, bytes.NewReader(data[start:offsets[i]]))
+			start = offsets[i]
+			if f.Path == "" {
+				readers = append(readers, bytes.NewReader(f.Data))
+				continue
+			}
+			fd, err := os.Open(f.Path)
+			if err != nil {
+				b.Close()
+				return nil, errors.Trace(err)
+			}
+			b.files = append(b.files, fd)
+			readers = append(readers, fd)
+		}
+		readers = append(readers, bytes.NewReader(data[start:]))
+		b.Reader = io.MultiReader(readers...)
+		return b, nil
+	}
+	size += int64(len(data))
+	return body, mpw.FormDataContentType(), size, nil
+}
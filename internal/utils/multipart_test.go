@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"testing"
+)
+
+func TestNewMultipartFileBody(t *testing.T) {
+	file := "../../testdata/apache-7.3.15.tgz"
+	want, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, contentType, size, err := NewMultipartFileBody("chart", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The body can be read several times, i.e. to retry a request
+	for i := 0; i < 2; i++ {
+		rc, err := body()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := int64(len(data)), size; got != want {
+			t.Errorf("got: %d bytes, want: %d", got, want)
+		}
+
+		r := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+		part, err := r.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := part.FormName(), "chart"; got != want {
+			t.Errorf("got: %q, want: %q", got, want)
+		}
+		got, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("the file content does not match")
+		}
+		if _, err := r.NextPart(); err == nil {
+			t.Errorf("expected a single part")
+		}
+	}
+}
+
+func TestNewMultipartBody(t *testing.T) {
+	file := "../../testdata/apache-7.3.15.tgz"
+	chart, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prov := []byte("provenance")
+
+	body, contentType, size, err := NewMultipartBody(
+		MultipartFile{Field: "chart", Path: file},
+		MultipartFile{Field: "prov", Name: "apache-7.3.15.tgz.prov", Data: prov},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := body()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := int64(len(data)), size; got != want {
+		t.Errorf("got: %d bytes, want: %d", got, want)
+	}
+
+	r := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+	for _, want := range []struct {
+		field, name string
+		data        []byte
+	}{
+		{"chart", "apache-7.3.15.tgz", chart},
+		{"prov", "apache-7.3.15.tgz.prov", prov},
+	} {
+		part, err := r.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := part.FormName(); got != want.field {
+			t.Errorf("got: %q, want: %q", got, want.field)
+		}
+		if got := part.FileName(); got != want.name {
+			t.Errorf("got: %q, want: %q", got, want.name)
+		}
+		got, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want.data) {
+			t.Errorf("the %q part content does not match", want.field)
+		}
+	}
+	if _, err := r.NextPart(); err == nil {
+		t.Errorf("expected two parts")
+	}
+}
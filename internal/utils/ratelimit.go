@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/juju/errors"
+	"golang.org/x/time/rate"
+	"k8s.io/klog"
+)
+
+var (
+	rateLimitMu sync.RWMutex
+	// rateLimiters holds the limiters of the hosts with a rate limit
+	rateLimiters = map[string]*rate.Limiter{}
+)
+
+// LimitRate limits the rate of the requests sent by the default HTTP clients
+// to the host of a URL. If the host is limited several times, for repositories
+// sharing it, the lowest rate is kept.
+func LimitRate(rawURL string, requestsPerSecond float64, burst int) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return errors.Errorf("unable to limit the rate of the requests to %q, it is not a valid URL", rawURL)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	if l, ok := rateLimiters[u.Host]; ok && float64(l.Limit()) <= requestsPerSecond {
+		return nil
+	}
+	klog.V(3).Infof("Limiting the requests to %q to %v per second", u.Host, requestsPerSecond)
+	rateLimiters[u.Host] = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	return nil
+}
+
+// getRateLimiter returns the limiter of a host, if it has a rate limit
+func getRateLimiter(host string) *rate.Limiter {
+	rateLimitMu.RLock()
+	defer rateLimitMu.RUnlock()
+	return rateLimiters[host]
+}
+
+// rateLimitingTransport is an http.RoundTripper that holds back the requests
+// to the hosts with a rate limit. Every attempt of a retried request counts.
+type rateLimitingTransport struct {
+	base http.RoundTripper
+	// limiter returns the limiter of a host, or nil if it has no rate limit
+	limiter func(host string) *rate.Limiter
+}
+
+// newRateLimitingTransport returns a transport wrapping base that applies the
+// rate limits of the default HTTP clients
+func newRateLimitingTransport(base http.RoundTripper) *rateLimitingTransport {
+	return &rateLimitingTransport{base: base, limiter: getRateLimiter}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *rateLimitingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if l := t.limiter(req.URL.Host); l != nil {
+		if err := l.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestLimitRate(t *testing.T) {
+	defer func() { rateLimiters = map[string]*rate.Limiter{} }()
+
+	if err := LimitRate("https://charts.example.com/source", 10, 5); err != nil {
+		t.Fatal(err)
+	}
+	// Repositories of the same host share the lowest rate
+	if err := LimitRate("https://charts.example.com/target", 20, 0); err != nil {
+		t.Fatal(err)
+	}
+	l := getRateLimiter("charts.example.com")
+	if l == nil || l.Limit() != 10 || l.Burst() != 5 {
+		t.Fatalf("got: %+v limiter, want 10 requests per second with a burst of 5", l)
+	}
+	if err := LimitRate("https://charts.example.com", 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if l := getRateLimiter("charts.example.com"); l.Limit() != 2 || l.Burst() != 1 {
+		t.Errorf("got: %v, %v, want 2 requests per second with a burst of 1", l.Limit(), l.Burst())
+	}
+	if l := getRateLimiter("other.example.com"); l != nil {
+		t.Errorf("got: %+v, want no limiter", l)
+	}
+
+	if err := LimitRate("charts", 1, 1); err == nil {
+		t.Error("got no error, want an invalid URL error")
+	}
+}
+
+func TestRateLimitingTransport(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+
+	limiter := rate.NewLimiter(rate.Limit(20), 1)
+	c := &http.Client{Transport: &rateLimitingTransport{
+		base:    http.DefaultTransport,
+		limiter: func(string) *rate.Limiter { return limiter },
+	}}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		res, err := c.Get(s.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	// The first request is sent right away, the others every 50ms
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("got: 4 requests in %s, want at least 150ms", elapsed)
+	}
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const (
+	// retryDefaultInitialBackoff is the time waited before the first retry of
+	// a request. It is doubled on every retry.
+	retryDefaultInitialBackoff = 1 * time.Second
+	// retryDefaultMaxBackoff caps the time waited before retrying a request
+	retryDefaultMaxBackoff = 30 * time.Second
+)
+
+// retryDefaultStatusCodes are the HTTP status codes retried by default
+var retryDefaultStatusCodes = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy configures the retries of the requests failing with transient
+// errors
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, including
+	// the first one
+	MaxAttempts int
+	// InitialBackoff is the time waited before the first retry. It is doubled
+	// on every retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// RetryOn are the HTTP status codes retried
+	RetryOn []int
+}
+
+var (
+	retryMu sync.RWMutex
+	// retryPolicy is the policy of the default HTTP clients. Requests are not
+	// retried unless configured.
+	retryPolicy = RetryPolicy{MaxAttempts: 1}
+)
+
+// NewRetryPolicy returns the retry policy described by an api.RetryPolicy
+// object, with the defaults of the fields that are not set. Requests are not
+// retried if it is nil.
+func NewRetryPolicy(p *api.RetryPolicy) RetryPolicy {
+	if p == nil {
+		return RetryPolicy{MaxAttempts: 1}
+	}
+	policy := RetryPolicy{
+		MaxAttempts:    int(p.GetMaxAttempts()),
+		InitialBackoff: retryDefaultInitialBackoff,
+		MaxBackoff:     retryDefaultMaxBackoff,
+		RetryOn:        retryDefaultStatusCodes,
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if d, err := time.ParseDuration(p.GetInitialBackoff()); err == nil && d > 0 {
+		policy.InitialBackoff = d
+	}
+	if d, err := time.ParseDuration(p.GetMaxBackoff()); err == nil && d > 0 {
+		policy.MaxBackoff = d
+	}
+	if len(p.GetRetryOn()) > 0 {
+		policy.RetryOn = nil
+		for _, code := range p.GetRetryOn() {
+			policy.RetryOn = append(policy.RetryOn, int(code))
+		}
+	}
+	return policy
+}
+
+// SetRetryPolicy configures the retries of the requests sent by the default
+// HTTP clients
+func SetRetryPolicy(p RetryPolicy) {
+	retryMu.Lock()
+	defer retryMu.Unlock()
+	retryPolicy = p
+}
+
+// getRetryPolicy returns the retry policy of the default HTTP clients
+func getRetryPolicy() RetryPolicy {
+	retryMu.RLock()
+	defer retryMu.RUnlock()
+	return retryPolicy
+}
+
+// retryingTransport is an http.RoundTripper that retries the requests failing
+// with transient errors, waiting exponentially longer between attempts.
+//
+// Requests answered with one of the retried status codes are retried if their
+// body can be rewound. Requests that could not reach the server are only
+// retried if they read data, as the server may have processed the others.
+type retryingTransport struct {
+	base http.RoundTripper
+	// policy returns the retry policy applied to the requests
+	policy func() RetryPolicy
+}
+
+// newRetryingTransport returns a transport wrapping base that applies the
+// retry policy of the default HTTP clients
+func newRetryingTransport(base http.RoundTripper) *retryingTransport {
+	return &retryingTransport{base: base, policy: getRetryPolicy}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	p := t.policy()
+	backoff := p.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if attempt >= p.MaxAttempts || req.Context().Err() != nil || (req.Body != nil && req.GetBody == nil) {
+			return res, err
+		}
+		var reason string
+		switch {
+		case err != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+			reason = err.Error()
+		case err == nil && retriedStatus(res.StatusCode, p.RetryOn):
+			reason = fmt.Sprintf("%q", res.Status)
+			res.Body.Close()
+		default:
+			return res, err
+		}
+
+		if backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+		klog.Warningf("%s %q failed with %s. Retrying in %s (attempt %d of %d)...", req.Method, req.URL.Redacted(), reason, backoff, attempt+1, p.MaxAttempts)
+		if err := sleepContext(req.Context(), backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retriedStatus returns whether a status code is one of the retried ones
+func retriedStatus(code int, retryOn []int) bool {
+	for _, c := range retryOn {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext waits for the specified period, or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
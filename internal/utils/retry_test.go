@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	if got, want := NewRetryPolicy(nil), (RetryPolicy{MaxAttempts: 1}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+	got := NewRetryPolicy(&api.RetryPolicy{MaxAttempts: 4, MaxBackoff: "1m", RetryOn: []uint32{429}})
+	want := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Second, MaxBackoff: time.Minute, RetryOn: []int{429}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
+func TestRetryingTransport(t *testing.T) {
+	tests := []struct {
+		desc         string
+		statuses     []int
+		wantStatus   int
+		wantRequests int
+	}{
+		{
+			desc:         "5xx is retried",
+			statuses:     []int{http.StatusBadGateway, http.StatusInternalServerError},
+			wantStatus:   http.StatusOK,
+			wantRequests: 3,
+		},
+		{
+			desc:         "other errors are not retried",
+			statuses:     []int{http.StatusNotFound},
+			wantStatus:   http.StatusNotFound,
+			wantRequests: 1,
+		},
+		{
+			desc:         "the last response is returned",
+			statuses:     []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			wantStatus:   http.StatusBadGateway,
+			wantRequests: 3,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			requests := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				body, _ := ioutil.ReadAll(r.Body)
+				if got, want := string(body), "payload"; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+				if requests <= len(tc.statuses) {
+					w.WriteHeader(tc.statuses[requests-1])
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer s.Close()
+
+			policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, RetryOn: retryDefaultStatusCodes}
+			tr := &retryingTransport{base: http.DefaultTransport, policy: func() RetryPolicy { return policy }}
+			client := &http.Client{Transport: tr}
+			res, err := client.Post(s.URL, "text/plain", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got, want := res.StatusCode, tc.wantStatus; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			if got, want := requests, tc.wantRequests; got != want {
+				t.Errorf("got: %d requests, want: %d", got, want)
+			}
+		})
+	}
+}
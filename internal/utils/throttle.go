@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	// throttleMaxRetries is the maximum number of times a throttled request
+	// is retried
+	throttleMaxRetries = 5
+	// throttleDefaultWait is used when the server does not send a Retry-After
+	// header. It is doubled on every retry.
+	throttleDefaultWait = 1 * time.Second
+	// throttleMaxWait caps the time waited before retrying a request
+	throttleMaxWait = 2 * time.Minute
+)
+
+// ThrottleStats contains information about the requests throttled by the
+// remote servers
+type ThrottleStats struct {
+	// Throttled is the number of throttled responses received
+	Throttled int
+	// Waited is the total time spent waiting because of throttling
+	Waited time.Duration
+}
+
+// throttlingTransport is an http.RoundTripper that retries the requests
+// rejected with "429 Too Many Requests" or "503 Service Unavailable", waiting
+// for the period indicated in the Retry-After header.
+//
+// While a server is throttling, new requests to the same host are held back
+// until the indicated period is over, so concurrent callers slow down too.
+type throttlingTransport struct {
+	base http.RoundTripper
+
+	mu sync.Mutex
+	// until stores the time until which requests to a host must wait
+	until map[string]time.Time
+	stats ThrottleStats
+}
+
+var throttleTransports []*throttlingTransport
+
+// newThrottlingTransport returns a throttling transport wrapping base
+func newThrottlingTransport(base http.RoundTripper) *throttlingTransport {
+	t := &throttlingTransport{base: base, until: make(map[string]time.Time)}
+	throttleTransports = append(throttleTransports, t)
+	return t
+}
+
+// GetThrottleStats returns the aggregated throttling statistics of the
+// default HTTP clients
+func GetThrottleStats() ThrottleStats {
+	var stats ThrottleStats
+	for _, t := range throttleTransports {
+		t.mu.Lock()
+		stats.Throttled += t.stats.Throttled
+		stats.Waited += t.stats.Waited
+		t.mu.Unlock()
+	}
+	return stats
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (t *throttlingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	wait := throttleDefaultWait
+	for retry := 0; ; retry++ {
+		if err := t.waitForHost(req, host); err != nil {
+			return nil, err
+		}
+
+		res, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable {
+			return res, nil
+		}
+		// The request can only be retried if its body can be rewound
+		if retry == throttleMaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return res, nil
+		}
+		// A 503 without Retry-After is not necessarily throttling
+		after, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+		if !ok && res.StatusCode == http.StatusServiceUnavailable {
+			return res, nil
+		}
+		if !ok {
+			after = wait
+			wait *= 2
+		}
+		if after > throttleMaxWait {
+			after = throttleMaxWait
+		}
+		res.Body.Close()
+
+		klog.Warningf("%s %q throttled with %q. Retrying in %s...", req.Method, req.URL.Redacted(), res.Status, after)
+		t.throttle(host, after)
+
+		if req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// throttle records that a host requested to wait for the specified period
+func (t *throttlingTransport) throttle(host string, after time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stats.Throttled++
+	if until := time.Now().Add(after); until.After(t.until[host]) {
+		t.until[host] = until
+	}
+}
+
+// waitForHost waits until the host stops throttling requests
+func (t *throttlingTransport) waitForHost(req *http.Request, host string) error {
+	t.mu.Lock()
+	d := time.Until(t.until[host])
+	if d > 0 {
+		t.stats.Waited += d
+	}
+	t.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which can be
+// either a number of seconds or an HTTP date
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if date, err := http.ParseTime(v); err == nil {
+		d := date.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
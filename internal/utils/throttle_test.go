@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		desc   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{desc: "empty header", value: ""},
+		{desc: "seconds", value: "30", want: 30 * time.Second, wantOk: true},
+		{desc: "negative seconds", value: "-1"},
+		{desc: "http date", value: "Sun, 01 May 2022 10:01:00 GMT", want: time.Minute, wantOk: true},
+		{desc: "http date in the past", value: "Sun, 01 May 2022 09:00:00 GMT", want: 0, wantOk: true},
+		{desc: "invalid value", value: "soon"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.value, now)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("got: %v, %t, want: %v, %t", got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
+
+func TestThrottlingTransport(t *testing.T) {
+	tests := []struct {
+		desc          string
+		status        int
+		retryAfter    string
+		wantStatus    int
+		wantRequests  int
+		wantThrottled int
+	}{
+		{
+			desc:          "429 is retried",
+			status:        http.StatusTooManyRequests,
+			retryAfter:    "0",
+			wantStatus:    http.StatusOK,
+			wantRequests:  2,
+			wantThrottled: 1,
+		},
+		{
+			desc:          "503 with Retry-After is retried",
+			status:        http.StatusServiceUnavailable,
+			retryAfter:    "0",
+			wantStatus:    http.StatusOK,
+			wantRequests:  2,
+			wantThrottled: 1,
+		},
+		{
+			desc:         "503 without Retry-After is not retried",
+			status:       http.StatusServiceUnavailable,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantRequests: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			requests := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				body, _ := ioutil.ReadAll(r.Body)
+				if got, want := string(body), "payload"; got != want {
+					t.Errorf("got: %q, want: %q", got, want)
+				}
+				if requests == 1 {
+					if tc.retryAfter != "" {
+						w.Header().Set("Retry-After", tc.retryAfter)
+					}
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer s.Close()
+
+			tr := &throttlingTransport{base: http.DefaultTransport, until: make(map[string]time.Time)}
+			client := &http.Client{Transport: tr}
+			res, err := client.Post(s.URL, "text/plain", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if got, want := res.StatusCode, tc.wantStatus; got != want {
+				t.Errorf("got: %d, want: %d", got, want)
+			}
+			if got, want := requests, tc.wantRequests; got != want {
+				t.Errorf("got: %d requests, want: %d", got, want)
+			}
+			if got, want := tr.stats.Throttled, tc.wantThrottled; got != want {
+				t.Errorf("got: %d throttled requests, want: %d", got, want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"compress/gzip"
 	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/tls"
 	"fmt"
 	"io"
@@ -12,12 +13,14 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
+	"golang.org/x/sync/singleflight"
 	helmRepo "helm.sh/helm/v3/pkg/repo"
 	"k8s.io/klog"
 
@@ -26,16 +29,17 @@ import (
 )
 
 const (
-	timeLayoutISO = "2006-01-02"
+	timeLayoutISO         = "2006-01-02"
+	timeLayoutISODateTime = "2006-01-02T15:04:05"
 )
 
 var (
 	// UnixEpoch is the number of seconds that have elapsed since January 1, 1970
 	UnixEpoch      = time.Unix(0, 0)
-	DefaultClient  = &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}}
-	InsecureClient = &http.Client{Transport: &http.Transport{
+	DefaultClient  = &http.Client{Transport: newRetryingTransport(newThrottlingTransport(newRateLimitingTransport(&http.Transport{Proxy: http.ProxyFromEnvironment})))}
+	InsecureClient = &http.Client{Transport: newRetryingTransport(newThrottlingTransport(newRateLimitingTransport(&http.Transport{
 		Proxy:           http.ProxyFromEnvironment,
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}))),
 	}
 )
 
@@ -111,7 +115,18 @@ func downloadIndex(repo *api.Repo) (string, error) {
 	return out.Name(), errors.Trace(err)
 }
 
+// UntarMaxSize is the maximum number of bytes Untar extracts from an archive.
+// It protects against decompression bombs.
+var UntarMaxSize int64 = 10 << 30
+
 // Untar extracts compressed archives
+//
+// File modes, modification times, symlinks and (empty) directories are
+// restored as described by the tar headers.
+//
+// Archives are not trusted: entries with absolute paths or paths escaping
+// targetDir, symlinks pointing outside of targetDir and archives extracting
+// more than UntarMaxSize bytes are rejected.
 func Untar(tarball, targetDir string) error {
 	if err := os.MkdirAll(targetDir, 0755); err != nil {
 		return errors.Trace(err)
@@ -128,6 +143,11 @@ func Untar(tarball, targetDir string) error {
 	}
 	tarReader := tar.NewReader(gzipReader)
 
+	// Directory modification times are restored once all the entries have
+	// been extracted, as writing into a directory updates its mtime.
+	dirTimes := map[string]time.Time{}
+	remaining := UntarMaxSize
+	var symlinks []string
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
@@ -136,33 +156,79 @@ func Untar(tarball, targetDir string) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if !isLocalPath(header.Name) {
+			return errors.Errorf("refusing to extract %q: path is outside of the target directory", header.Name)
+		}
+		// Entries are never extracted through symlinks, which could have
+		// been crafted to point outside of the target directory
+		if err := checkNoSymlinks(targetDir, filepath.Dir(header.Name)); err != nil {
+			return errors.Annotatef(err, "refusing to extract %q", header.Name)
+		}
 		path := filepath.Join(targetDir, header.Name)
+		// Entries replace previous symlinks instead of being written through them
+		if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(path); err != nil {
+				return errors.Trace(err)
+			}
+		}
 		targetFolder := filepath.Join(targetDir, filepath.Dir(header.Name))
-		// For some reason the for loop only iterates over files and not folders, so the switch below for folders is
-		// never executed and so we are creating the target folder at this point.
+		// Most chart packages do not contain entries for folders, so the
+		// parent folder of each entry is created as needed.
 		if _, err := os.Stat(targetFolder); err != nil {
 			if err := os.MkdirAll(targetFolder, 0755); err != nil {
 				return err
 			}
 		}
+		mode := header.FileInfo().Mode().Perm()
 		switch header.Typeflag {
-		// Related to previous comment. It seems this block of code is never executed.
 		case tar.TypeDir:
 			if _, err := os.Stat(path); err != nil {
 				if err := os.Mkdir(path, 0755); err != nil {
 					return errors.Trace(err)
 				}
 			}
+			if err := os.Chmod(path, mode); err != nil {
+				return errors.Trace(err)
+			}
+			dirTimes[path] = header.ModTime
 		case tar.TypeReg:
-			outFile, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, os.FileMode(header.Mode))
+			outFile, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, mode)
 			if err != nil {
 				return errors.Trace(err)
 			}
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				outFile.Close()
+			// Read one byte more than allowed to detect oversized archives
+			n, err := io.Copy(outFile, io.LimitReader(tarReader, remaining+1))
+			outFile.Close()
+			if err != nil {
 				return errors.Trace(err)
 			}
-			outFile.Close()
+			if remaining -= n; remaining < 0 {
+				return errors.Errorf("refusing to extract %q: archive exceeds the maximum extracted size of %d bytes", tarball, UntarMaxSize)
+			}
+			// The file mode requested at creation time is subject to umask
+			if err := os.Chmod(path, mode); err != nil {
+				return errors.Trace(err)
+			}
+			if err := os.Chtimes(path, header.ModTime, header.ModTime); err != nil {
+				return errors.Trace(err)
+			}
+		case tar.TypeSymlink:
+			// Symlinks are resolved relative to their folder
+			if filepath.IsAbs(header.Linkname) || !isLocalPath(filepath.Join(filepath.Dir(header.Name), header.Linkname)) {
+				return errors.Errorf("refusing to extract %q: symlink to %q points outside of the target directory", header.Name, header.Linkname)
+			}
+			// The target is not cleaned, "a/.." goes through a if it is a
+			// symlink
+			if err := resolveSymlinks(targetDir, filepath.Dir(header.Name)+"/"+header.Linkname); err != nil {
+				return errors.Annotatef(err, "refusing to extract %q: symlink to %q", header.Name, header.Linkname)
+			}
+			if err := os.RemoveAll(path); err != nil {
+				return errors.Trace(err)
+			}
+			if err := os.Symlink(header.Linkname, path); err != nil {
+				return errors.Trace(err)
+			}
+			symlinks = append(symlinks, header.Name)
 		// We don't want to process these extension header files.
 		case tar.TypeXGlobalHeader, tar.TypeXHeader:
 			continue
@@ -170,9 +236,216 @@ func Untar(tarball, targetDir string) error {
 			return errors.Errorf("unknown type: %b in %s", header.Typeflag, header.Name)
 		}
 	}
+	// A symlink may also escape through the symlinks extracted after it
+	for _, name := range symlinks {
+		if fi, err := os.Lstat(filepath.Join(targetDir, name)); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		if err := resolveSymlinks(targetDir, name); err != nil {
+			return errors.Annotatef(err, "refusing to extract %q", name)
+		}
+	}
+	for dir, mtime := range dirTimes {
+		// The directory might have been replaced by a later entry
+		if fi, err := os.Lstat(dir); err != nil || !fi.IsDir() {
+			continue
+		}
+		if err := os.Chtimes(dir, mtime, mtime); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	return nil
 }
 
+// isLocalPath returns whether a relative path stays within its base
+// directory, i.e. it is not absolute and does not escape it through ".."
+// components
+func isLocalPath(p string) bool {
+	p = filepath.FromSlash(p)
+	if filepath.IsAbs(p) || strings.HasPrefix(p, string(filepath.Separator)) {
+		return false
+	}
+	clean := filepath.Clean(p)
+	return clean != ".." && !strings.HasPrefix(clean, ".."+string(filepath.Separator))
+}
+
+// maxSymlinkHops is the number of symlinks followed by resolveSymlinks before
+// giving up, like the ELOOP limit of the kernel
+const maxSymlinkHops = 40
+
+// resolveSymlinks follows the symlinks of the relative path rel under baseDir,
+// component by component as the filesystem does, and returns an error if it
+// leaves baseDir. The components that do not exist are resolved lexically.
+func resolveSymlinks(baseDir, rel string) error {
+	var resolved []string
+	pending := strings.Split(filepath.ToSlash(rel), "/")
+	for hops := 0; len(pending) > 0; {
+		c := pending[0]
+		pending = pending[1:]
+		switch c {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return errors.New("path points outside of the target directory")
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		cur := filepath.Join(append([]string{baseDir}, append(resolved, c)...)...)
+		fi, err := os.Lstat(cur)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Trace(err)
+		}
+		if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+			resolved = append(resolved, c)
+			continue
+		}
+		if hops++; hops > maxSymlinkHops {
+			return errors.Errorf("too many levels of symlinks in %q", rel)
+		}
+		link, err := os.Readlink(cur)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if filepath.IsAbs(link) {
+			return errors.Errorf("%q is a symlink to an absolute path", cur)
+		}
+		// The symlink is resolved relative to its folder, which is the one
+		// resolved so far
+		pending = append(strings.Split(filepath.ToSlash(link), "/"), pending...)
+	}
+	return nil
+}
+
+// checkNoSymlinks returns an error if any existing component of the relative
+// path rel under baseDir is a symlink
+func checkNoSymlinks(baseDir, rel string) error {
+	cur := baseDir
+	for _, c := range strings.Split(filepath.Clean(filepath.FromSlash(rel)), string(filepath.Separator)) {
+		if c == "." || c == "" {
+			continue
+		}
+		cur = filepath.Join(cur, c)
+		fi, err := os.Lstat(cur)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return errors.Errorf("%q is a symlink", cur)
+		}
+	}
+	return nil
+}
+
+// tarModTime is the modification time set to every archived file so archives
+// are reproducible
+var tarModTime = UnixEpoch
+
+// Tar creates a compressed archive with the contents of srcDir, placing them
+// under the baseDir folder of the archive.
+//
+// File modes, symlinks and (empty) directories are kept in the tar headers.
+// Entries for which skip returns true are not archived; if the entry is a
+// directory, its whole content is skipped.
+//
+// The output is reproducible: entries are written in lexical order, with a
+// fixed modification time and no owner information, and the gzip stream
+// does not carry a name or timestamp. Archiving the same content twice
+// produces the same digest.
+func Tar(srcDir, baseDir, tarball string, skip func(rel string, fi os.FileInfo) bool) (e error) {
+	out, err := os.Create(tarball)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer func() {
+		if err := out.Close(); e == nil {
+			e = errors.Trace(err)
+		}
+	}()
+
+	gzipWriter, err := gzip.NewWriterLevel(out, gzip.DefaultCompression)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	walk := func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Trace(err)
+		}
+		rel, err := filepath.Rel(srcDir, p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if skip != nil && skip(rel, fi) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return errors.Trace(err)
+			}
+		} else if !fi.Mode().IsRegular() && !fi.IsDir() {
+			return errors.Errorf("unsupported file type %q in %s", fi.Mode().Type(), rel)
+		}
+
+		// The header is built from scratch instead of using tar.FileInfoHeader
+		// so that no owner, access or change time information leaks into it.
+		header := &tar.Header{
+			Name:    path.Join(baseDir, rel),
+			Mode:    int64(fi.Mode().Perm()),
+			ModTime: tarModTime,
+		}
+		switch {
+		case fi.IsDir():
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+		case link != "":
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = link
+		default:
+			header.Typeflag = tar.TypeReg
+			header.Size = fi.Size()
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return errors.Trace(err)
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer f.Close()
+		if _, err := io.Copy(tarWriter, f); err != nil {
+			return errors.Annotatef(err, "archiving %q", rel)
+		}
+		return nil
+	}
+	if err := filepath.Walk(srcDir, walk); err != nil {
+		return errors.Trace(err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(gzipWriter.Close())
+}
+
 // GetFileContentType returns the content type of a file.
 func GetFileContentType(filepath string) (string, error) {
 	// Only the first 512 bytes are used to sniff the content type.
@@ -192,16 +465,43 @@ func GetFileContentType(filepath string) (string, error) {
 	return contentType, err
 }
 
-// GetDateThreshold will parse a string date agains a fixed layout and return a time.Date value
+// GetDateThreshold will parse a string date and return a time.Date value
+//
+// The date can be provided in the following formats:
+//   - YYYY-MM-DD, e.g. 2020-05-15
+//   - RFC3339, e.g. 2020-05-15T10:00:00Z or 2020-05-15T10:00:00+02:00
+//   - YYYY-MM-DDTHH:MM:SS, e.g. 2020-05-15T10:00:00
+//
+// Dates without an explicit offset are considered UTC unless they are followed
+// by an IANA time zone name, e.g. "2020-05-15 Europe/Madrid".
 func GetDateThreshold(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
 	if date == "" {
 		return UnixEpoch, nil
 	}
-	dateThreshold, err := time.Parse(timeLayoutISO, date)
-	if err != nil {
-		return dateThreshold, errors.Trace(err)
+
+	loc := time.UTC
+	if i := strings.LastIndex(date, " "); i != -1 {
+		l, err := time.LoadLocation(date[i+1:])
+		if err != nil {
+			return time.Time{}, errors.Annotatef(err, "invalid time zone in %q", date)
+		}
+		loc = l
+		date = strings.TrimSpace(date[:i])
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, date); err == nil {
+		if loc != time.UTC {
+			return time.Time{}, errors.Errorf("%q already includes an offset, a time zone is not allowed", date)
+		}
+		return t, nil
 	}
-	return dateThreshold, nil
+	for _, layout := range []string{timeLayoutISO, timeLayoutISODateTime} {
+		if t, err := time.ParseInLocation(layout, date, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("unable to parse %q date. Supported formats are YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS and RFC3339", date)
 }
 
 // FindChartURL will return the chart url
@@ -284,6 +584,56 @@ func EncodeSha1(s string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// FileSha256 returns the hex encoded SHA256 digest of a file
+func FileSha256(f string) (string, error) {
+	file, err := os.Open(f)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", errors.Trace(err)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// RemoteSha256 downloads a file with the do function, which authenticates the
+// request, and returns its hex encoded SHA256 digest. It returns a NotFound
+// error if the file does not exist.
+func RemoteSha256(u string, do func(*http.Request) (*http.Response, error)) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	klog.V(4).Infof("GET %q", u)
+	res, err := do(req)
+	if err != nil {
+		return "", errors.Annotatef(err, "fetching %q", u)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return "", errors.NotFoundf("%q", u)
+	}
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		return "", errors.Errorf("unable to fetch %q, got HTTP Status: %s, Resp: %v", u, res.Status, HTTPResponseBody(res))
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, res.Body); err != nil {
+		return "", errors.Annotatef(err, "fetching %q", u)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// TargetChartName returns the name of a chart once renamed
+func TargetChartName(name string, rename *api.Rename) string {
+	if newName, ok := rename.GetCharts()[name]; ok {
+		return newName
+	}
+	return rename.GetPrefix() + name
+}
+
 // NormalizeChartURL forms the full download URL in case we pass a relative URL
 func NormalizeChartURL(repoURL, chartURL string) (string, error) {
 	if chartURL == "" {
@@ -328,6 +678,7 @@ type fetchOptions struct {
 	insecure        bool
 	statusHandlerFn statusHandler
 	urlBuilderFn    urlBuilder
+	digest          string
 }
 
 type FetchOption func(opts *fetchOptions)
@@ -367,6 +718,17 @@ func WithFetchURLBuilder(h urlBuilder) FetchOption {
 	}
 }
 
+// WithFetchDigest configures the expected SHA256 digest of the fetched file
+func WithFetchDigest(digest string) FetchOption {
+	return func(opts *fetchOptions) {
+		opts.digest = digest
+	}
+}
+
+// fetchMaxAttempts is the maximum number of times a corrupted download is
+// attempted
+const fetchMaxAttempts = 3
+
 var defaultStatusHandler = func(res *http.Response) error {
 	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
 		bodyStr := HTTPResponseBody(res)
@@ -375,18 +737,57 @@ var defaultStatusHandler = func(res *http.Response) error {
 	return nil
 }
 
+// fetchGroup deduplicates concurrent fetches of the same chart
+var fetchGroup singleflight.Group
+
 // FetchAndCache fetches a chart and stores it in provided cache
+//
+// The downloaded package is validated before being cached, and the download
+// is retried if it is corrupted. Packages with a known digest are reused from
+// the digest cache, if configured.
+//
+// Concurrent calls fetching the same chart into the same cache share a single
+// download.
 func FetchAndCache(name, version string, cache cache.Cacher, fopts ...FetchOption) (string, error) {
 	id := fmt.Sprintf("%s-%s.tgz", name, version)
-	if cache.Has(id) {
-		return cache.Path(id), nil
+	// Each repository has its own cache, so the cache path identifies the
+	// repository, name and version of the chart
+	chartPath, err, shared := fetchGroup.Do(cache.Path(id), func() (interface{}, error) {
+		return fetchAndCache(id, name, version, cache, fopts...)
+	})
+	if shared {
+		klog.V(4).Infof("Shared %q chart download with a concurrent fetch", id)
+	}
+	if err != nil {
+		return "", err
 	}
+	return chartPath.(string), nil
+}
 
+func fetchAndCache(id, name, version string, cache cache.Cacher, fopts ...FetchOption) (string, error) {
 	opts := fetchOptions{statusHandlerFn: defaultStatusHandler}
 	for _, opt := range fopts {
 		opt(&opts)
 	}
 
+	if cache.Has(id) {
+		err := validateFetchedChart(cache.Path(id), opts.digest)
+		if err == nil {
+			return cache.Path(id), nil
+		}
+		klog.Warningf("Cached %q chart is not valid, fetching it again: %v", id, err)
+		if err := cache.Invalidate(id); err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+
+	// The package may have been fetched from another repository already
+	if ok, err := ReadDigestCache(opts.digest, id, cache); err != nil {
+		return "", errors.Trace(err)
+	} else if ok {
+		return cache.Path(id), nil
+	}
+
 	if opts.urlBuilderFn == nil {
 		return "", fmt.Errorf("requires a download URL builder")
 	}
@@ -396,9 +797,30 @@ func FetchAndCache(name, version string, cache cache.Cacher, fopts ...FetchOptio
 		return "", errors.Trace(err)
 	}
 
+	var errs error
+	for attempt := 1; attempt <= fetchMaxAttempts; attempt++ {
+		err := fetchToCache(u, id, cache, opts)
+		if err == nil {
+			if err := WriteDigestCache(cache.Path(id)); err != nil {
+				klog.Warningf("unable to store %q chart in the digest cache: %v", id, err)
+			}
+			return cache.Path(id), nil
+		}
+		if !IsCorruptedTarball(err) {
+			return "", errors.Trace(err)
+		}
+		klog.Warningf("Fetched %q chart is not valid (attempt %d/%d): %v", id, attempt, fetchMaxAttempts, err)
+		errs = multierror.Append(errs, err)
+	}
+	return "", errors.Annotatef(errs, "fetching %q", u)
+}
+
+// fetchToCache downloads a chart into the cache, making sure the cache never
+// keeps a corrupted copy
+func fetchToCache(u, id string, cache cache.Cacher, opts fetchOptions) error {
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		return "", errors.Trace(err)
+		return errors.Trace(err)
 	}
 
 	if opts.user != "" && opts.pass != "" {
@@ -415,33 +837,115 @@ func FetchAndCache(name, version string, cache cache.Cacher, fopts ...FetchOptio
 
 	res, err := client.Do(req)
 	if err != nil {
-		return "", errors.Trace(err)
+		return errors.Trace(err)
 	}
+	defer res.Body.Close()
 
 	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
 	if opts.statusHandlerFn != nil {
 		if err := opts.statusHandlerFn(res); err != nil {
-			return "", errors.Trace(err)
+			return errors.Trace(err)
 		}
 	}
 
 	w, err := cache.Writer(id)
 	if err != nil {
-		return "", errors.Trace(err)
+		return errors.Trace(err)
 	}
 
 	if _, err := io.Copy(w, res.Body); err != nil {
+		w.Close()
 		// Invalidate the cache
-		return "", errors.Trace(multierror.Append(err, cache.Invalidate(id)))
+		return errors.Trace(multierror.Append(err, cache.Invalidate(id)))
 	}
 
 	if err := w.Close(); err != nil {
 		// Invalidate the cache
-		return "", errors.Trace(multierror.Append(err, cache.Invalidate(id)))
+		return errors.Trace(multierror.Append(err, cache.Invalidate(id)))
 	}
-	if err := res.Body.Close(); err != nil {
-		return "", errors.Trace(err)
+
+	if err := validateFetchedChart(cache.Path(id), opts.digest); err != nil {
+		// Invalidate the cache
+		if ierr := cache.Invalidate(id); ierr != nil {
+			return errors.Trace(multierror.Append(err, ierr))
+		}
+		return err
+	}
+	return nil
+}
+
+// validateFetchedChart checks the integrity of a fetched chart package and,
+// if provided, its digest
+func validateFetchedChart(file, digest string) error {
+	if err := ValidateTarball(file); err != nil {
+		return err
+	}
+	if digest == "" {
+		return nil
+	}
+	got, err := FileSha256(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if want := strings.ToLower(strings.TrimPrefix(digest, "sha256:")); got != want {
+		return &corruptedTarballError{file: file, err: errors.Errorf("digest mismatch (got: %s, want: %s)", got, want)}
 	}
+	return nil
+}
+
+// corruptedTarballError is returned when a tarball cannot be fully read
+type corruptedTarballError struct {
+	file string
+	err  error
+}
+
+func (e *corruptedTarballError) Error() string {
+	return fmt.Sprintf("corrupted tarball %q: %v", filepath.Base(e.file), e.err)
+}
+
+// IsCorruptedTarball returns whether the error was caused by a corrupted or
+// truncated tarball
+func IsCorruptedTarball(err error) bool {
+	_, ok := errors.Cause(err).(*corruptedTarballError)
+	return ok
+}
 
-	return cache.Path(id), nil
+// ValidateTarball checks that a file is a complete, readable gzipped tarball
+// containing at least one file
+func ValidateTarball(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+
+	corrupted := func(err error) error {
+		return &corruptedTarballError{file: file, err: err}
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return corrupted(err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	entries := 0
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return corrupted(err)
+		}
+		// Read the content so truncated files and checksum errors are detected
+		if _, err := io.Copy(ioutil.Discard, tr); err != nil {
+			return corrupted(err)
+		}
+		entries++
+	}
+	if entries == 0 {
+		return corrupted(errors.New("empty tarball"))
+	}
+	return nil
 }
@@ -1,10 +1,17 @@
 package utils
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -96,6 +103,205 @@ func TestUntar(t *testing.T) {
 	}
 }
 
+func TestTarUntar(t *testing.T) {
+	srcDir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(srcDir)
+
+	if err := os.MkdirAll(path.Join(srcDir, "scripts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path.Join(srcDir, "empty"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(srcDir, "scripts", "run.sh"), []byte("#!/bin/sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path.Join(srcDir, "scripts", "run.sh"), 0775); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(srcDir, "skipped.txt"), []byte("skipped"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("scripts/run.sh", path.Join(srcDir, "run.sh")); err != nil {
+		t.Fatal(err)
+	}
+
+	tarball := path.Join(srcDir, "..", fmt.Sprintf("%s.tgz", path.Base(srcDir)))
+	defer os.Remove(tarball)
+	skip := func(rel string, _ os.FileInfo) bool { return rel == "skipped.txt" }
+	if err := Tar(srcDir, "mychart", tarball, skip); err != nil {
+		t.Fatal(err)
+	}
+
+	dstDir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstDir)
+	if err := Untar(tarball, dstDir); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path.Join(dstDir, "mychart", "scripts", "run.sh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0775); got != want {
+		t.Errorf("wrong file mode, got: %v, want: %v", got, want)
+	}
+	fi, err = os.Stat(path.Join(dstDir, "mychart", "empty"))
+	if err != nil {
+		t.Fatalf("empty directory not restored: %v", err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0700); got != want {
+		t.Errorf("wrong directory mode, got: %v, want: %v", got, want)
+	}
+	link, err := os.Readlink(path.Join(dstDir, "mychart", "run.sh"))
+	if err != nil {
+		t.Fatalf("symlink not restored: %v", err)
+	}
+	if got, want := link, "scripts/run.sh"; got != want {
+		t.Errorf("wrong symlink target, got: %q, want: %q", got, want)
+	}
+	if ok, _ := FileExists(path.Join(dstDir, "mychart", "skipped.txt")); ok {
+		t.Errorf("skipped file should not be archived")
+	}
+}
+
+// writeTestTarball writes a compressed archive with the provided entries
+func writeTestTarball(t *testing.T, tarball string, headers []*tar.Header) {
+	t.Helper()
+	f, err := os.Create(tarball)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	for _, h := range headers {
+		if h.Typeflag == tar.TypeReg {
+			h.Mode = 0644
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if h.Size > 0 {
+			if _, err := tw.Write(make([]byte, h.Size)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUntarRejectsUnsafeArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	defer func(size int64) { UntarMaxSize = size }(UntarMaxSize)
+	UntarMaxSize = 1024
+
+	tests := []struct {
+		desc    string
+		headers []*tar.Header
+		wantErr bool
+	}{
+		{
+			desc: "regular chart",
+			headers: []*tar.Header{
+				{Name: "mychart/Chart.yaml", Typeflag: tar.TypeReg, Size: 10},
+				{Name: "./mychart/values.yaml", Typeflag: tar.TypeReg, Size: 10},
+				{Name: "mychart/link", Typeflag: tar.TypeSymlink, Linkname: "values.yaml"},
+				{Name: "mychart/templates/link", Typeflag: tar.TypeSymlink, Linkname: "../values.yaml"},
+			},
+		},
+		{
+			desc:    "absolute path",
+			headers: []*tar.Header{{Name: "/etc/passwd", Typeflag: tar.TypeReg, Size: 10}},
+			wantErr: true,
+		},
+		{
+			desc:    "parent directory",
+			headers: []*tar.Header{{Name: "mychart/../../evil", Typeflag: tar.TypeReg, Size: 10}},
+			wantErr: true,
+		},
+		{
+			desc:    "absolute symlink",
+			headers: []*tar.Header{{Name: "mychart/link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}},
+			wantErr: true,
+		},
+		{
+			desc:    "symlink escaping the target directory",
+			headers: []*tar.Header{{Name: "mychart/link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}},
+			wantErr: true,
+		},
+		{
+			desc: "chained symlinks escaping the target directory",
+			headers: []*tar.Header{
+				{Name: "mychart/a", Typeflag: tar.TypeSymlink, Linkname: ".."},
+				{Name: "mychart/b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "symlink escaping through a later symlink",
+			headers: []*tar.Header{
+				{Name: "mychart/b", Typeflag: tar.TypeSymlink, Linkname: "a/.."},
+				{Name: "mychart/a", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "extracting through a symlink",
+			headers: []*tar.Header{
+				{Name: "mychart/link", Typeflag: tar.TypeSymlink, Linkname: "."},
+				{Name: "mychart/link/evil", Typeflag: tar.TypeReg, Size: 10},
+			},
+			wantErr: true,
+		},
+		{
+			desc: "overwriting a symlink",
+			headers: []*tar.Header{
+				{Name: "mychart/sub/link", Typeflag: tar.TypeSymlink, Linkname: ".."},
+				{Name: "mychart/link", Typeflag: tar.TypeSymlink, Linkname: "sub/link/../evil"},
+				{Name: "mychart/link", Typeflag: tar.TypeReg, Size: 10},
+			},
+		},
+		{
+			desc: "archive exceeding the maximum size",
+			headers: []*tar.Header{
+				{Name: "mychart/Chart.yaml", Typeflag: tar.TypeReg, Size: 1000},
+				{Name: "mychart/values.yaml", Typeflag: tar.TypeReg, Size: 1000},
+			},
+			wantErr: true,
+		},
+	}
+	for i, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			tarball := path.Join(dir, fmt.Sprintf("chart-%d.tgz", i))
+			writeTestTarball(t, tarball, tc.headers)
+			err := Untar(tarball, path.Join(dir, fmt.Sprintf("out-%d", i)))
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Errorf("got error: %v, want error: %t", err, want)
+			}
+		})
+	}
+	if ok, _ := FileExists(path.Join(dir, "evil")); ok {
+		t.Errorf("a file was extracted outside of the target directory")
+	}
+}
+
 func TestGetFileContentType(t *testing.T) {
 	filepath := "../../testdata/apache-7.3.15.tgz"
 	contentType, err := GetFileContentType(filepath)
@@ -108,14 +314,43 @@ func TestGetFileContentType(t *testing.T) {
 }
 
 func TestGetDateThreshold(t *testing.T) {
-	date := time.Date(2020, 05, 15, 0, 0, 0, 0, time.UTC)
-	fromDate := "2020-05-15"
-	dateThreshold, err := GetDateThreshold(fromDate)
+	madrid, err := time.LoadLocation("Europe/Madrid")
 	if err != nil {
 		t.Fatal(err)
 	}
-	if dateThreshold != date {
-		t.Errorf("incorrect dateThreshold, expected: %v, got %v", date, dateThreshold)
+	tests := []struct {
+		desc    string
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{desc: "empty date", date: "", want: UnixEpoch},
+		{desc: "date", date: "2020-05-15", want: time.Date(2020, 05, 15, 0, 0, 0, 0, time.UTC)},
+		{desc: "date and time", date: "2020-05-15T23:30:00", want: time.Date(2020, 05, 15, 23, 30, 0, 0, time.UTC)},
+		{desc: "rfc3339 utc", date: "2020-05-15T23:30:00Z", want: time.Date(2020, 05, 15, 23, 30, 0, 0, time.UTC)},
+		{desc: "rfc3339 offset", date: "2020-05-16T01:30:00+02:00", want: time.Date(2020, 05, 15, 23, 30, 0, 0, time.UTC)},
+		{desc: "date with time zone", date: "2020-05-15 Europe/Madrid", want: time.Date(2020, 05, 15, 0, 0, 0, 0, madrid)},
+		{desc: "date and time with time zone", date: "2020-05-15T10:00:00 Europe/Madrid", want: time.Date(2020, 05, 15, 10, 0, 0, 0, madrid)},
+		{desc: "offset and time zone", date: "2020-05-15T10:00:00Z Europe/Madrid", wantErr: true},
+		{desc: "invalid time zone", date: "2020-05-15 Mars/Olympus", wantErr: true},
+		{desc: "invalid date", date: "15/05/2020", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := GetDateThreshold(tc.date)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got: %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("incorrect dateThreshold, expected: %v, got %v", tc.want, got)
+			}
+		})
 	}
 }
 
@@ -230,3 +465,244 @@ func TestNormalizeChartURL(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTarball(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := ioutil.ReadFile("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		desc    string
+		data    []byte
+		wantErr bool
+	}{
+		{desc: "valid tarball", data: data},
+		{desc: "truncated tarball", data: data[:len(data)/2], wantErr: true},
+		{desc: "not a tarball", data: []byte("<html>Not found</html>"), wantErr: true},
+	}
+	for i, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			f := path.Join(dir, fmt.Sprintf("chart-%d.tgz", i))
+			if err := ioutil.WriteFile(f, tc.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateTarball(f)
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Errorf("got error: %v, want error: %t", err, want)
+			}
+			if err != nil && !IsCorruptedTarball(err) {
+				t.Errorf("got: %v, want a corrupted tarball error", err)
+			}
+		})
+	}
+}
+
+// dirCache is a minimal cache.Cacher implementation
+type dirCache struct {
+	dir string
+}
+
+func (c *dirCache) Store(r io.Reader, filename string) error {
+	w, err := c.Writer(filename)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+func (c *dirCache) Writer(filename string) (*os.File, error) { return os.Create(c.Path(filename)) }
+func (c *dirCache) Invalidate(filename string) error {
+	if err := os.Remove(c.Path(filename)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+func (c *dirCache) Read(w io.Writer, filename string) error {
+	f, err := os.Open(c.Path(filename))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+func (c *dirCache) Has(filename string) bool {
+	ok, _ := FileExists(c.Path(filename))
+	return ok
+}
+func (c *dirCache) Path(filename string) string { return path.Join(c.dir, filename) }
+
+func TestFetchAndCacheRetriesCorruptedDownloads(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := FileSha256("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc         string
+		corrupted    int
+		digest       string
+		wantErr      bool
+		wantRequests int
+	}{
+		{desc: "valid download", digest: digest, wantRequests: 1},
+		{desc: "truncated download is retried", corrupted: 1, digest: digest, wantRequests: 2},
+		{desc: "persistently truncated download", corrupted: fetchMaxAttempts, wantErr: true, wantRequests: fetchMaxAttempts},
+		{desc: "digest mismatch", digest: "sha256:0000", wantErr: true, wantRequests: fetchMaxAttempts},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			requests := 0
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests <= tc.corrupted {
+					w.Write(data[:len(data)/2])
+					return
+				}
+				w.Write(data)
+			}))
+			defer s.Close()
+
+			dir, err := ioutil.TempDir("", "charts-syncer-tests")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			c := &dirCache{dir: dir}
+
+			urlBuilder := func(name, version string) (string, error) {
+				return fmt.Sprintf("%s/%s-%s.tgz", s.URL, name, version), nil
+			}
+			_, err = FetchAndCache("apache", "7.3.15", c, WithFetchURLBuilder(urlBuilder), WithFetchDigest(tc.digest))
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Fatalf("got error: %v, want error: %t", err, want)
+			}
+			if got, want := requests, tc.wantRequests; got != want {
+				t.Errorf("got: %d requests, want: %d", got, want)
+			}
+			// Corrupted downloads must never be cached
+			if got, want := c.Has("apache-7.3.15.tgz"), !tc.wantErr; got != want {
+				t.Errorf("got cached: %t, want: %t", got, want)
+			}
+		})
+	}
+}
+
+func TestFetchAndCacheDeduplicatesConcurrentFetches(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		// Keep the download in flight so the other fetches overlap with it
+		time.Sleep(200 * time.Millisecond)
+		w.Write(data)
+	}))
+	defer s.Close()
+
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := &dirCache{dir: dir}
+
+	urlBuilder := func(name, version string) (string, error) {
+		return fmt.Sprintf("%s/%s-%s.tgz", s.URL, name, version), nil
+	}
+	const workers = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chartPath, err := FetchAndCache("apache", "7.3.15", c, WithFetchURLBuilder(urlBuilder))
+			if err == nil && chartPath != c.Path("apache-7.3.15.tgz") {
+				err = fmt.Errorf("got: %q, want: %q", chartPath, c.Path("apache-7.3.15.tgz"))
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if got, want := atomic.LoadInt32(&requests), int32(1); got != want {
+		t.Errorf("got: %d requests, want: %d", got, want)
+	}
+}
+
+func TestFetchAndCacheReusesDigestCache(t *testing.T) {
+	data, err := ioutil.ReadFile("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := FileSha256("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(data)
+	}))
+	defer s.Close()
+	urlBuilder := func(name, version string) (string, error) {
+		return fmt.Sprintf("%s/%s-%s.tgz", s.URL, name, version), nil
+	}
+
+	dir := t.TempDir()
+	SetDigestCache(path.Join(dir, "digests"))
+	defer SetDigestCache("")
+
+	// Every repository has its own cache, the package is fetched once
+	for _, repo := range []string{"source", "target", "other"} {
+		c := &dirCache{dir: path.Join(dir, repo)}
+		if err := os.MkdirAll(c.dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		chartPath, err := FetchAndCache("apache", "7.3.15", c, WithFetchURLBuilder(urlBuilder), WithFetchDigest(digest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ValidateTarball(chartPath); err != nil {
+			t.Errorf("got: %v, want a valid %q package", err, repo)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got: %d requests, want: 1", requests)
+	}
+
+	// A corrupted package in the digest cache is fetched again
+	if err := ioutil.WriteFile(path.Join(dir, "digests", "sha256", digest+".tgz"), data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := &dirCache{dir: path.Join(dir, "new")}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FetchAndCache("apache", "7.3.15", c, WithFetchURLBuilder(urlBuilder), WithFetchDigest(digest)); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("got: %d requests, want: 2", requests)
+	}
+}
@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// CompareVersions compares two chart versions. It returns a negative number
+// if a is older than b, a positive number if it is newer and zero if both
+// are the same.
+//
+// Ordering rules:
+//   - Semantic versions, optionally prefixed with "v", follow the semver
+//     precedence rules. Versions with the same precedence (i.e. only differing
+//     in build metadata) are ordered by their build metadata, then by the
+//     original string, so the ordering is total.
+//   - Versions that are not semantic versions (i.e. dates) are older than any
+//     semantic version and are ordered naturally among them: digit sequences
+//     are compared numerically and the rest lexically.
+func CompareVersions(a, b string) int {
+	va, erra := ParseSemver(a)
+	vb, errb := ParseSemver(b)
+	switch {
+	case erra == nil && errb == nil:
+		if c := va.Compare(vb); c != 0 {
+			return c
+		}
+		if c := naturalCompare(va.Metadata(), vb.Metadata()); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case erra == nil:
+		return 1
+	case errb == nil:
+		return -1
+	default:
+		return naturalCompare(a, b)
+	}
+}
+
+// SortVersions sorts chart versions from oldest to newest, as defined by
+// CompareVersions
+func SortVersions(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return CompareVersions(versions[i], versions[j]) < 0
+	})
+}
+
+// LatestVersion returns the newest of a list of chart versions, as defined
+// by CompareVersions. It returns false if the list is empty.
+func LatestVersion(versions []string) (string, bool) {
+	if len(versions) == 0 {
+		return "", false
+	}
+	latest := versions[0]
+	for _, v := range versions[1:] {
+		if CompareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest, true
+}
+
+// ParseSemver parses a full semantic version, optionally prefixed with "v".
+//
+// semver.NewVersion is not used since it coerces partial versions, which
+// would turn dates like "2021-03-10" into pre-releases of "2021.0.0".
+func ParseSemver(v string) (*semver.Version, error) {
+	return semver.StrictNewVersion(strings.TrimPrefix(v, "v"))
+}
+
+// naturalCompare compares two strings, comparing digit sequences numerically
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		ca, ra := nextChunk(a)
+		cb, rb := nextChunk(b)
+		if c := compareChunks(ca, cb); c != 0 {
+			return c
+		}
+		a, b = ra, rb
+	}
+	return strings.Compare(a, b)
+}
+
+// nextChunk splits s after its leading run of digits or non-digits
+func nextChunk(s string) (string, string) {
+	digit := unicode.IsDigit(rune(s[0]))
+	i := 1
+	for i < len(s) && unicode.IsDigit(rune(s[i])) == digit {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareChunks compares two chunks numerically if both are numbers
+func compareChunks(a, b string) int {
+	na, erra := strconv.ParseUint(a, 10, 64)
+	nb, errb := strconv.ParseUint(b, 10, 64)
+	if erra == nil && errb == nil && na != nb {
+		if na < nb {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// AppendVersionSuffix appends a pre-release ("-mirror") or build metadata
+// ("+mirror.1") suffix to a chart version. The suffix is merged with the
+// build metadata the version may already have, so the result is still a
+// semantic version.
+func AppendVersionSuffix(version, suffix string) string {
+	if suffix == "" {
+		return version
+	}
+	base, metadata := version, ""
+	if i := strings.Index(version, "+"); i >= 0 {
+		base, metadata = version[:i], version[i+1:]
+	}
+	if strings.HasPrefix(suffix, "+") {
+		if metadata != "" {
+			return base + "+" + metadata + "." + suffix[1:]
+		}
+		return base + suffix
+	}
+	// Pre-release suffixes go before the build metadata
+	if strings.Contains(base, "-") {
+		// The version is a pre-release already
+		base += "." + strings.TrimPrefix(suffix, "-")
+	} else {
+		base += suffix
+	}
+	if metadata != "" {
+		return base + "+" + metadata
+	}
+	return base
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortVersions(t *testing.T) {
+	tests := []struct {
+		desc     string
+		versions []string
+		want     []string
+	}{
+		{
+			desc:     "semantic versions",
+			versions: []string{"1.10.0", "1.2.0", "1.2.0-rc.1", "0.9.12"},
+			want:     []string{"0.9.12", "1.2.0-rc.1", "1.2.0", "1.10.0"},
+		},
+		{
+			desc:     "build metadata",
+			versions: []string{"1.0.0+build.10", "1.0.0+build.9", "1.0.0", "0.1.0+zzz"},
+			want:     []string{"0.1.0+zzz", "1.0.0", "1.0.0+build.9", "1.0.0+build.10"},
+		},
+		{
+			desc:     "v prefix and numeric pre-releases",
+			versions: []string{"v1.2.3-10", "v1.2.3-1", "1.2.3-2", "v1.2.3"},
+			want:     []string{"v1.2.3-1", "1.2.3-2", "v1.2.3-10", "v1.2.3"},
+		},
+		{
+			desc:     "non-semver versions are older than semver ones",
+			versions: []string{"1.0.0", "latest", "2021-03-10", "2021-12-01"},
+			want:     []string{"2021-03-10", "2021-12-01", "latest", "1.0.0"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got := append([]string{}, tc.versions...)
+			SortVersions(got)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	tests := []struct {
+		desc     string
+		versions []string
+		want     string
+		wantOk   bool
+	}{
+		{desc: "no versions"},
+		{desc: "semantic versions", versions: []string{"1.9.0", "1.10.0", "1.10.0-rc.1"}, want: "1.10.0", wantOk: true},
+		{desc: "non-semver versions", versions: []string{"2021-03-10", "1.0.0", "nightly"}, want: "1.0.0", wantOk: true},
+		{desc: "only non-semver versions", versions: []string{"build-9", "build-10"}, want: "build-10", wantOk: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, ok := LatestVersion(tc.versions)
+			if got != tc.want || ok != tc.wantOk {
+				t.Errorf("got: %q, %t, want: %q, %t", got, ok, tc.want, tc.wantOk)
+			}
+		})
+	}
+}
+
+func TestAppendVersionSuffix(t *testing.T) {
+	tests := []struct {
+		desc    string
+		version string
+		suffix  string
+		want    string
+	}{
+		{desc: "no suffix", version: "1.2.3", want: "1.2.3"},
+		{desc: "build metadata", version: "15.1.2", suffix: "+mirror.1", want: "15.1.2+mirror.1"},
+		{desc: "build metadata is merged", version: "1.2.3+abc", suffix: "+mirror.1", want: "1.2.3+abc.mirror.1"},
+		{desc: "pre-release", version: "1.2.3", suffix: "-mirror", want: "1.2.3-mirror"},
+		{desc: "pre-release is merged", version: "1.2.3-rc.1", suffix: "-mirror", want: "1.2.3-rc.1.mirror"},
+		{desc: "pre-release goes before build metadata", version: "1.2.3+abc", suffix: "-mirror", want: "1.2.3-mirror+abc"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := AppendVersionSuffix(tc.version, tc.suffix); got != tc.want {
+				t.Errorf("got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
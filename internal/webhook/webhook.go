@@ -0,0 +1,202 @@
+// Package webhook serves the HTTP endpoint triggering syncs, e.g. from a
+// Harbor webhook or a CI pipeline.
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+)
+
+// maxBodySize is the size limit of the request bodies
+const maxBodySize = 1 << 20
+
+// The Harbor events of the pushed charts
+const (
+	harborUploadChart  = "UPLOAD_CHART"
+	harborPushArtifact = "PUSH_ARTIFACT"
+)
+
+// Request is a sync requested to the endpoint
+type Request struct {
+	// Chart is the name of the chart to sync. All the charts are synced if
+	// it is empty
+	Chart string `json:"chart,omitempty"`
+	// Version is the version of the chart to sync. All the versions are
+	// synced if it is empty
+	Version string `json:"version,omitempty"`
+}
+
+// String returns the description of a request in the logs
+func (r Request) String() string {
+	switch {
+	case r.Chart == "":
+		return "all charts"
+	case r.Version == "":
+		return fmt.Sprintf("%q chart", r.Chart)
+	default:
+		return fmt.Sprintf("%q chart", r.Chart+"-"+r.Version)
+	}
+}
+
+// TriggerFunc queues the sync of a request. It returns a NotFound error if the
+// requested chart is not synced by the configuration.
+type TriggerFunc func(Request) error
+
+// handler serves the sync requests
+type handler struct {
+	token   string
+	trigger TriggerFunc
+}
+
+// NewHandler returns the handler of the endpoint, serving the sync requests in
+// POST /sync and the health checks in GET /healthz. The sync requests must
+// have the token in their Authorization header, as is or as a bearer token,
+// if it is not empty.
+func NewHandler(token string, trigger TriggerFunc) http.Handler {
+	h := &handler{token: token, trigger: trigger}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sync", h.sync)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// response is the body of the answers to the sync requests
+type response struct {
+	Status   string    `json:"status"`
+	Requests []Request `json:"requests,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+func (h *handler) sync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		reply(w, http.StatusMethodNotAllowed, response{Status: "failed", Error: "only POST requests are supported"})
+		return
+	}
+	if !h.authorized(r) {
+		reply(w, http.StatusUnauthorized, response{Status: "failed", Error: "invalid or missing token"})
+		return
+	}
+
+	reqs, err := ParseRequests(r)
+	if err != nil {
+		reply(w, http.StatusBadRequest, response{Status: "failed", Error: err.Error()})
+		return
+	}
+	if len(reqs) == 0 {
+		reply(w, http.StatusOK, response{Status: "ignored"})
+		return
+	}
+	for _, req := range reqs {
+		if err := h.trigger(req); err != nil {
+			status := http.StatusServiceUnavailable
+			if errors.IsNotFound(err) {
+				status = http.StatusNotFound
+			}
+			klog.Warningf("unable to trigger the sync of %s: %v", req, err)
+			reply(w, status, response{Status: "failed", Requests: []Request{req}, Error: err.Error()})
+			return
+		}
+		klog.Infof("Triggered the sync of %s", req)
+	}
+	reply(w, http.StatusAccepted, response{Status: "queued", Requests: reqs})
+}
+
+// authorized returns whether a request has the token of the endpoint
+func (h *handler) authorized(r *http.Request) bool {
+	if h.token == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(h.token)) == 1
+}
+
+// reply writes the JSON answer to a request
+func reply(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		klog.Warningf("unable to write the webhook response: %v", err)
+	}
+}
+
+// harborEvent is the payload of the Harbor webhooks
+type harborEvent struct {
+	Type      string `json:"type"`
+	EventData struct {
+		Repository struct {
+			Name string `json:"name"`
+		} `json:"repository"`
+		Resources []struct {
+			Tag string `json:"tag"`
+		} `json:"resources"`
+	} `json:"event_data"`
+}
+
+// ParseRequests returns the syncs requested by a POST request: the chart and
+// version of the query parameters, of a {"chart": ..., "version": ...} body or
+// of the charts of a Harbor event. An empty request syncs all the charts. The
+// Harbor events other than the pushed charts request nothing.
+func ParseRequests(r *http.Request) ([]Request, error) {
+	q := r.URL.Query()
+	query := Request{Chart: q.Get("chart"), Version: q.Get("version")}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading request body")
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return validate([]Request{query})
+	}
+
+	var payload struct {
+		Request
+		harborEvent
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.NotValidf("request body, it should be a JSON object: %v", err)
+	}
+	if payload.Type == "" {
+		if payload.Request == (Request{}) {
+			payload.Request = query
+		}
+		return validate([]Request{payload.Request})
+	}
+
+	if payload.Type != harborUploadChart && payload.Type != harborPushArtifact {
+		klog.V(3).Infof("Ignoring %s Harbor event", payload.Type)
+		return nil, nil
+	}
+	name := payload.EventData.Repository.Name
+	if name == "" {
+		return nil, errors.NotValidf("%s Harbor event without repository name", payload.Type)
+	}
+	var reqs []Request
+	for _, res := range payload.EventData.Resources {
+		reqs = append(reqs, Request{Chart: name, Version: res.Tag})
+	}
+	if len(reqs) == 0 {
+		reqs = append(reqs, Request{Chart: name})
+	}
+	return validate(reqs)
+}
+
+// validate checks that the requests of a version have a chart too
+func validate(reqs []Request) ([]Request, error) {
+	for _, req := range reqs {
+		if req.Chart == "" && req.Version != "" {
+			return nil, errors.NotValidf("request of %q version without chart", req.Version)
+		}
+	}
+	return reqs, nil
+}
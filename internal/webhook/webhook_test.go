@@ -0,0 +1,124 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/juju/errors"
+)
+
+func TestParseRequests(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		query  string
+		body   string
+		want   []Request
+		errMsg string
+	}{
+		{desc: "empty request", want: []Request{{}}},
+		{desc: "query parameters", query: "?chart=nginx&version=13.2.0", want: []Request{{Chart: "nginx", Version: "13.2.0"}}},
+		{desc: "JSON body", body: `{"chart":"nginx"}`, want: []Request{{Chart: "nginx"}}},
+		{desc: "empty JSON body", query: "?chart=nginx", body: `{}`, want: []Request{{Chart: "nginx"}}},
+		{
+			desc: "Harbor chart upload",
+			body: `{"type":"UPLOAD_CHART","occur_at":1680000000,"operator":"admin","event_data":{"resources":[{"tag":"13.2.0","resource_url":"harbor.example.com/chartrepo/library/charts/nginx-13.2.0.tgz"}],"repository":{"name":"nginx","namespace":"library","repo_full_name":"library/nginx"}}}`,
+			want: []Request{{Chart: "nginx", Version: "13.2.0"}},
+		},
+		{
+			desc: "Harbor artifact push",
+			body: `{"type":"PUSH_ARTIFACT","event_data":{"resources":[{"tag":"13.2.0"},{"tag":"13.2.1"}],"repository":{"name":"nginx"}}}`,
+			want: []Request{{Chart: "nginx", Version: "13.2.0"}, {Chart: "nginx", Version: "13.2.1"}},
+		},
+		{desc: "other Harbor event", body: `{"type":"DELETE_CHART","event_data":{"repository":{"name":"nginx"}}}`},
+		{desc: "version without chart", query: "?version=13.2.0", errMsg: `request of "13.2.0" version without chart not valid`},
+		{desc: "invalid body", body: `nginx`, errMsg: `request body, it should be a JSON object: invalid character 'g' in literal null (expecting 'u') not valid`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/sync"+tc.query, strings.NewReader(tc.body))
+			got, err := ParseRequests(r)
+			if tc.errMsg != "" {
+				if err == nil || err.Error() != tc.errMsg {
+					t.Errorf("got: %v, want: %q", err, tc.errMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %+v, want: %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var triggered []Request
+	trigger := func(req Request) error {
+		if req.Chart == "redis" {
+			return errors.NotFoundf("%q chart in the configuration", req.Chart)
+		}
+		triggered = append(triggered, req)
+		return nil
+	}
+	srv := httptest.NewServer(NewHandler("secret", trigger))
+	defer srv.Close()
+
+	testCases := []struct {
+		desc   string
+		method string
+		auth   string
+		body   string
+		status int
+		want   []Request
+	}{
+		{desc: "sync", method: http.MethodPost, auth: "Bearer secret", body: `{"chart":"nginx","version":"13.2.0"}`, status: http.StatusAccepted, want: []Request{{Chart: "nginx", Version: "13.2.0"}}},
+		{desc: "raw token", method: http.MethodPost, auth: "secret", status: http.StatusAccepted, want: []Request{{}}},
+		{desc: "wrong token", method: http.MethodPost, auth: "Bearer wrong", status: http.StatusUnauthorized},
+		{desc: "missing token", method: http.MethodPost, status: http.StatusUnauthorized},
+		{desc: "GET request", method: http.MethodGet, auth: "Bearer secret", status: http.StatusMethodNotAllowed},
+		{desc: "unknown chart", method: http.MethodPost, auth: "Bearer secret", body: `{"chart":"redis"}`, status: http.StatusNotFound},
+		{desc: "bad request", method: http.MethodPost, auth: "Bearer secret", body: `{"version":"1.0.0"}`, status: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			triggered = nil
+			req, err := http.NewRequest(tc.method, srv.URL+"/sync", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.auth != "" {
+				req.Header.Set("Authorization", tc.auth)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tc.status {
+				t.Errorf("got: %d status, want: %d", res.StatusCode, tc.status)
+			}
+			resp := &response{}
+			if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(triggered, tc.want) {
+				t.Errorf("got: %+v triggered, want: %+v", triggered, tc.want)
+			}
+		})
+	}
+
+	res, err := http.Get(srv.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("got: %d health check status", res.StatusCode)
+	}
+}
@@ -3,12 +3,21 @@ package main
 import (
 	"flag"
 	"os"
+	// The container image is built from scratch, so time zones used in date
+	// filters must be embedded in the binary
+	_ "time/tzdata"
 
+	"github.com/juju/errors"
 	"k8s.io/klog"
 
 	"github.com/bitnami-labs/charts-syncer/cmd"
+	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
 )
 
+// exitInterrupted is the exit code used when the sync was interrupted by a
+// signal, following the shell convention for SIGINT
+const exitInterrupted = 130
+
 func main() {
 	defer klog.Flush()
 
@@ -26,6 +35,10 @@ func main() {
 	command.PersistentFlags().AddGoFlagSet(klogFlags)
 
 	if err := command.Execute(); err != nil {
+		if errors.Cause(err) == syncer.ErrInterrupted {
+			klog.Flush()
+			os.Exit(exitInterrupted)
+		}
 		// No need to print the errors, Cobra does it for us already since SilenceErrors = false
 		os.Exit(1)
 	}
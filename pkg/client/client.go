@@ -2,11 +2,14 @@ package client
 
 import (
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+	"github.com/containerd/containerd/remotes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
 )
 
 // This package defines the interfaces that clients needs to satisfy in order to work with chart repositories or
-// intermediate bundles directories.
+// intermediate bundles directories. Clients must be safe for concurrent use, as charts are synced concurrently.
 
 // ChartsReader defines the methods that a ReadOnly chart or bundle client should implement.
 type ChartsReader interface {
@@ -30,3 +33,103 @@ type ChartsReaderWriter interface {
 	ChartsReader
 	ChartsWriter
 }
+
+// IndexReporter is implemented by clients based on an index that can report
+// the index entries they were unable to load.
+type IndexReporter interface {
+	InvalidIndexEntries() []string
+}
+
+// IndexEntryReader is implemented by clients based on an index that can
+// return the index entry of a chart, with absolute URLs.
+type IndexEntryReader interface {
+	GetIndexEntry(name string, version string) (*repo.ChartVersion, error)
+}
+
+// ProvenanceReader is implemented by clients that can fetch the provenance
+// file of a chart. It returns a NotFound error if the chart has none.
+type ProvenanceReader interface {
+	FetchProvenance(name string, version string) ([]byte, error)
+}
+
+// ProvenanceWriter is implemented by clients that can store the provenance
+// file of a chart along with the chart.
+type ProvenanceWriter interface {
+	UploadProvenance(filepath string, prov []byte, metadata *chart.Metadata) error
+}
+
+// SignedChartsWriter is implemented by clients that can store a chart and its
+// provenance file at once, so the chart is never published without it.
+type SignedChartsWriter interface {
+	UploadWithProvenance(filepath string, prov []byte, metadata *chart.Metadata) error
+}
+
+// AttestationWriter is implemented by clients that can store an attestation,
+// like an in-toto statement, along with a chart.
+type AttestationWriter interface {
+	UploadAttestation(filepath string, attestation []byte, metadata *chart.Metadata) error
+}
+
+// SignedAttestationWriter is implemented by clients that can attach an
+// in-toto statement signed in a DSSE envelope to the manifest of a chart.
+type SignedAttestationWriter interface {
+	UploadSignedAttestation(attestation []byte, metadata *chart.Metadata, signer types.StatementSigner) error
+}
+
+// SBOMWriter is implemented by clients that can store the software bill of
+// materials of a chart, of the SPDX or CycloneDX media type, along with it.
+type SBOMWriter interface {
+	UploadSBOM(filepath string, sbom []byte, mediaType string, metadata *chart.Metadata) error
+}
+
+// SignatureWriter is implemented by clients that can attach a cosign
+// signature to the manifest of a chart.
+type SignatureWriter interface {
+	UploadSignature(metadata *chart.Metadata, signer types.ManifestSigner) error
+}
+
+// ChartDigestReader is implemented by clients that can read back the SHA256
+// digest of a stored chart package from the repository, not from a cached
+// index, so uploads can be verified.
+type ChartDigestReader interface {
+	ChartDigest(name string, version string) (string, error)
+}
+
+// ManifestDigestReader is implemented by clients of OCI registries that can
+// read the manifest digest of a stored chart, and compute the one uploading or
+// copying a chart would push, so charts drifted from their expected manifest
+// are detected.
+type ManifestDigestReader interface {
+	ManifestDigest(name string, version string) (string, error)
+	UploadManifestDigest(filepath string, prov []byte, metadata *chart.Metadata) (string, error)
+	CopyManifestDigest(src ChartsReader, name string, version string, keepProvenance bool) (string, error)
+}
+
+// ChartsDeleter is implemented by clients that can remove a chart from the
+// repository.
+type ChartsDeleter interface {
+	Delete(name string, version string) error
+}
+
+// OCIReferenceReader is implemented by clients of OCI registries. It returns
+// the reference of a chart and a resolver that can only fetch it, so the chart
+// can be copied to another registry.
+type OCIReferenceReader interface {
+	OCIReference(name string, version string) (string, remotes.Resolver, error)
+}
+
+// OCIReferrersReader is implemented by clients of OCI registries that can
+// list the artifacts referring to a manifest, like signatures or SBOMs. It
+// returns a NotSupported error if the registry does not support the referrers
+// API.
+type OCIReferrersReader interface {
+	Referrers(name string, manifestDigest string) ([]ocispec.Descriptor, error)
+}
+
+// ChartsCopier is implemented by clients that can copy a chart verbatim from
+// another repository, keeping its digest. The provenance file of the chart is
+// only copied if keepProvenance is set. It returns a NotSupported error if
+// the chart cannot be copied from src as is.
+type ChartsCopier interface {
+	Copy(src ChartsReader, name string, version string, keepProvenance bool) error
+}
@@ -0,0 +1,174 @@
+package intermediate
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/juju/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+const (
+	// ageEncryption is the suffix and manifest name of age encrypted bundles
+	ageEncryption = "age"
+	// gpgEncryption is the suffix and manifest name of GnuPG encrypted bundles
+	gpgEncryption = "gpg"
+)
+
+// encryptor encrypts a bundle for the configured recipients
+type encryptor struct {
+	name string
+	age  []age.Recipient
+	gpg  openpgp.EntityList
+}
+
+// newEncryptor parses the recipients of the bundles. It returns nil if the
+// bundles are not encrypted.
+func newEncryptor(config *api.BundleEncryption) (*encryptor, error) {
+	if len(config.GetAgeRecipients()) > 0 {
+		e := &encryptor{name: ageEncryption}
+		for _, r := range config.GetAgeRecipients() {
+			recipient, err := age.ParseX25519Recipient(r)
+			if err != nil {
+				return nil, errors.Annotatef(err, "parsing %q age recipient", r)
+			}
+			e.age = append(e.age, recipient)
+		}
+		return e, nil
+	}
+	if config.GetGpgKeyring() != "" {
+		keyring, err := readKeyring(config.GetGpgKeyring())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return &encryptor{name: gpgEncryption, gpg: keyring}, nil
+	}
+	return nil, nil
+}
+
+// encrypt writes the encrypted content of src to dst
+func (e *encryptor) encrypt(dst io.Writer, src io.Reader) error {
+	var w io.WriteCloser
+	var err error
+	if e.name == ageEncryption {
+		w, err = age.Encrypt(dst, e.age...)
+	} else {
+		w, err = openpgp.Encrypt(dst, e.gpg, nil, &openpgp.FileHints{IsBinary: true}, nil)
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		w.Close()
+		return errors.Trace(err)
+	}
+	// Closing the writer flushes the last encrypted chunk
+	return errors.Trace(w.Close())
+}
+
+// encryptionOf returns the encryption name of a bundle file, or an empty
+// string if it is not encrypted
+func encryptionOf(file string) string {
+	for _, e := range []string{ageEncryption, gpgEncryption} {
+		if strings.HasSuffix(file, "."+e) {
+			return e
+		}
+	}
+	return ""
+}
+
+// decrypt writes the decrypted content of src to dst, with the tool given by
+// the encryption name of the bundle
+func decrypt(dst io.Writer, src io.Reader, encryption string, config *api.BundleDecryption) error {
+	var r io.Reader
+	switch encryption {
+	case ageEncryption:
+		if config.GetAgeIdentities() == "" {
+			return errors.New(`the bundle is encrypted with age but "source.bundleDecryption" "ageIdentities" is not set`)
+		}
+		f, err := os.Open(config.GetAgeIdentities())
+		if err != nil {
+			return errors.Annotatef(err, "reading age identities")
+		}
+		identities, err := age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return errors.Annotatef(err, "parsing age identities")
+		}
+		if r, err = age.Decrypt(src, identities...); err != nil {
+			return errors.Trace(err)
+		}
+	case gpgEncryption:
+		if config.GetGpgKeyring() == "" {
+			return errors.New(`the bundle is encrypted with GnuPG but "source.bundleDecryption" "gpgKeyring" is not set`)
+		}
+		keyring, err := readKeyring(config.GetGpgKeyring())
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err := decryptKeys(keyring, config.GetGpgPassphraseFile()); err != nil {
+			return errors.Trace(err)
+		}
+		md, err := openpgp.ReadMessage(src, keyring, nil, nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		r = md.UnverifiedBody
+	default:
+		return errors.Errorf("unsupported %q bundle encryption", encryption)
+	}
+	// Both formats authenticate the content, so a tampered bundle fails here
+	_, err := io.Copy(dst, r)
+	return errors.Trace(err)
+}
+
+// readKeyring reads an armored or binary GnuPG keyring
+func readKeyring(file string) (openpgp.EntityList, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading %q keyring", file)
+	}
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		keyring, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	return keyring, errors.Annotatef(err, "parsing %q keyring", file)
+}
+
+// decryptKeys decrypts the secret keys of a keyring protected with a
+// passphrase
+func decryptKeys(keyring openpgp.EntityList, passphraseFile string) error {
+	var passphrase []byte
+	for _, e := range keyring {
+		keys := []*packet.PrivateKey{e.PrivateKey}
+		for _, sk := range e.Subkeys {
+			keys = append(keys, sk.PrivateKey)
+		}
+		for _, k := range keys {
+			if k == nil || !k.Encrypted {
+				continue
+			}
+			if passphrase == nil {
+				if passphraseFile == "" {
+					return errors.New(`the GnuPG keys are encrypted but "source.bundleDecryption" "gpgPassphraseFile" is not set`)
+				}
+				data, err := ioutil.ReadFile(passphraseFile)
+				if err != nil {
+					return errors.Annotatef(err, "reading passphrase file")
+				}
+				passphrase = []byte(strings.TrimRight(string(data), "\r\n"))
+			}
+			if err := k.Decrypt(passphrase); err != nil {
+				return errors.Annotatef(err, "decrypting GnuPG key")
+			}
+		}
+	}
+	return nil
+}
@@ -1,41 +1,97 @@
 package intermediate
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
-	"sort"
+	"sync"
 
+	"github.com/bitnami-labs/charts-syncer/api"
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/client"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"github.com/juju/errors"
 	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/klog"
 )
 
 var (
-	versionRe = regexp.MustCompile("(.*)-(\\d+\\.\\d+\\.\\d+)\\.bundle.tar")
+	versionRe = regexp.MustCompile("^(.+?)-(v?\\d+\\.\\d+\\.\\d+.*)\\.bundle.tar(\\.age|\\.gpg)?$")
 )
 
 type chartVersions []string
 
 // BundlesDir allows to operate a chart bundles directory
-// It should implement pkg/client ChartsReaderWriter interface
+// It should implement pkg/client ChartsReaderWriter interface, and is safe for
+// concurrent use
 type BundlesDir struct {
-	dir     string
+	dir string
+	// mu guards the entries, the manifest and the bundles
+	mu      sync.RWMutex
 	entries map[string]chartVersions
+	// manifest is nil for directories written by older versions, which
+	// have no manifest
+	manifest  *Manifest
+	toolchain string
+	// bundles are the bundle files by chart name and version
+	bundles    map[string]Bundle
+	encryption *api.BundleEncryption
+	encryptor  *encryptor
+	decryption *api.BundleDecryption
+	workdir    string
+	partSize   int64
+}
+
+// Option is an option value used to create a new bundles directory client
+type Option func(*BundlesDir)
+
+// WithToolchainVersion configures the charts-syncer version recorded in the
+// manifest of the directory
+func WithToolchainVersion(version string) Option {
+	return func(bd *BundlesDir) {
+		bd.toolchain = version
+	}
+}
+
+// WithEncryption encrypts the uploaded bundles
+func WithEncryption(config *api.BundleEncryption) Option {
+	return func(bd *BundlesDir) {
+		bd.encryption = config
+	}
+}
+
+// WithDecryption decrypts the fetched bundles to the given work directory
+func WithDecryption(config *api.BundleDecryption, workdir string) Option {
+	return func(bd *BundlesDir) {
+		bd.decryption = config
+		bd.workdir = workdir
+	}
+}
+
+// WithPartSize splits the uploaded bundles larger than the given size in
+// bytes into parts of that size
+func WithPartSize(size int64) Option {
+	return func(bd *BundlesDir) {
+		bd.partSize = size
+	}
 }
 
 // NewIntermediateClient returns a ChartsReaderWriter object
-func NewIntermediateClient(intermediateBundlesPath string) (client.ChartsReaderWriter, error) {
-	return New(intermediateBundlesPath)
+func NewIntermediateClient(intermediateBundlesPath string, opts ...Option) (client.ChartsReaderWriter, error) {
+	return New(intermediateBundlesPath, opts...)
 }
 
 // New creates a Repo object from an api.Repo object.
-func New(dir string) (*BundlesDir, error) {
+//
+// The checksums of the directory files are verified against its manifest, so
+// partially transferred or modified directories are rejected.
+func New(dir string, opts ...Option) (*BundlesDir, error) {
 	d, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -43,25 +99,69 @@ func New(dir string) (*BundlesDir, error) {
 	if err := os.MkdirAll(d, 0755); err != nil {
 		return nil, errors.Trace(err)
 	}
+	bd := &BundlesDir{dir: d, entries: make(map[string]chartVersions), bundles: make(map[string]Bundle)}
+	for _, o := range opts {
+		o(bd)
+	}
+	if bd.encryptor, err = newEncryptor(bd.encryption); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	manifest, err := ReadManifest(d)
+	if errors.IsNotFound(err) {
+		manifest = nil
+	} else if err != nil {
+		return nil, errors.Annotatef(err, "verifying %q bundles directory", dir)
+	}
+	bd.manifest = manifest
+	listed := map[string]bool{}
+	for _, b := range manifest.GetCharts() {
+		listed[b.File] = true
+		bd.add(b)
+	}
 
 	// Populate entries from directory
-	entries := make(map[string]chartVersions)
-	matches, err := filepath.Glob(filepath.Join(d, "*.tar"))
-	if err != nil {
-		return nil, errors.Trace(err)
+	var matches []string
+	for _, pattern := range []string{"*.tar", "*.tar." + ageEncryption, "*.tar." + gpgEncryption} {
+		m, err := filepath.Glob(filepath.Join(d, pattern))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		matches = append(matches, m...)
 	}
 	for _, m := range matches {
 		filename := filepath.Base(m)
+		if manifest != nil {
+			if !listed[filename] {
+				klog.Warningf("Ignoring %q: not listed in %q", m, ManifestFile)
+			}
+			continue
+		}
 		s := versionRe.FindStringSubmatch(filename)
-		entries[s[1]] = append(entries[s[1]], s[2])
-		sort.Strings(entries[s[0]])
+		if s == nil {
+			klog.Warningf("Ignoring %q: unable to parse the chart name and version", m)
+			continue
+		}
+		bd.add(Bundle{Name: s[1], Version: s[2], File: filename})
+	}
+	if manifest == nil && len(matches) > 0 {
+		klog.Warningf("%q has no %s, its bundles are not verified", dir, ManifestFile)
 	}
 
-	return &BundlesDir{dir: d, entries: entries}, nil
+	return bd, nil
+}
+
+// add adds a bundle to the entries
+func (bd *BundlesDir) add(b Bundle) {
+	bd.entries[b.Name] = append(bd.entries[b.Name], b.Version)
+	utils.SortVersions(bd.entries[b.Name])
+	bd.bundles[fmt.Sprintf("%s-%s", b.Name, b.Version)] = b
 }
 
 // List lists all chart names in a repo
 func (bd *BundlesDir) List() ([]string, error) {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
 	var names []string
 	for name := range bd.entries {
 		names = append(names, name)
@@ -71,16 +171,101 @@ func (bd *BundlesDir) List() ([]string, error) {
 
 // ListChartVersions lists all versions of a chart
 func (bd *BundlesDir) ListChartVersions(name string) ([]string, error) {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
 	versions, ok := bd.entries[name]
 	if !ok {
 		return []string{}, nil
 	}
-	return versions, nil
+	return append([]string{}, versions...), nil
 }
 
 // Fetch fetches a chart
+//
+// Split bundles are reassembled and encrypted bundles are decrypted to the
+// work directory, which keeps them until it is removed.
 func (bd *BundlesDir) Fetch(name string, version string) (string, error) {
-	return path.Join(bd.dir, fmt.Sprintf("%s-%s.bundle.tar", name, version)), nil
+	plain := fmt.Sprintf("%s-%s.bundle.tar", name, version)
+	bd.mu.RLock()
+	b, ok := bd.bundles[fmt.Sprintf("%s-%s", name, version)]
+	bd.mu.RUnlock()
+	if !ok {
+		b = Bundle{File: plain}
+	}
+	encryption := encryptionOf(b.File)
+	if encryption == "" && len(b.Parts) == 0 {
+		return path.Join(bd.dir, b.File), nil
+	}
+
+	workdir := bd.workdir
+	if workdir == "" {
+		workdir = os.TempDir()
+	}
+	out := filepath.Join(workdir, "bundles", plain)
+	if ok, err := utils.FileExists(out); err != nil {
+		return "", errors.Trace(err)
+	} else if ok {
+		return out, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0700); err != nil {
+		return "", errors.Trace(err)
+	}
+	klog.V(4).Infof("Restoring %q bundle", b.File)
+	if err := bd.restore(b, out, encryption); err != nil {
+		return "", errors.Annotatef(err, "restoring %q", b.File)
+	}
+	return out, nil
+}
+
+// restore reassembles the parts of a bundle and decrypts it, removing the
+// output if it fails so a partially restored bundle is never used
+func (bd *BundlesDir) restore(b Bundle, dst, encryption string) error {
+	files := []string{b.File}
+	if len(b.Parts) > 0 {
+		files = nil
+		for _, p := range b.Parts {
+			files = append(files, p.File)
+		}
+	}
+	var readers []io.Reader
+	for _, f := range files {
+		in, err := os.Open(path.Join(bd.dir, f))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer in.Close()
+		readers = append(readers, in)
+	}
+	h := sha256.New()
+	src := io.TeeReader(io.MultiReader(readers...), h)
+
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if encryption != "" {
+		err = decrypt(out, src, encryption, bd.decryption)
+	} else {
+		_, err = io.Copy(out, src)
+	}
+	if err == nil {
+		// Decryption may not read the trailing bytes
+		_, err = io.Copy(ioutil.Discard, src)
+	}
+	if err == nil && len(b.Parts) > 0 && "sha256:"+hex.EncodeToString(h.Sum(nil)) != b.Digest {
+		err = errors.Errorf("the reassembled parts do not match the %q digest", b.Digest)
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return errors.Trace(err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, dst))
 }
 
 // Has checks if a repo has a specific chart
@@ -110,32 +295,154 @@ func (bd *BundlesDir) Upload(filepath string, metadata *chart.Metadata) error {
 		return errors.AlreadyExistsf("%s-%s", name, version)
 	}
 
-	src, err := os.Open(filepath)
+	file := fmt.Sprintf("%s-%s.bundle.tar", name, version)
+	if bd.encryptor != nil {
+		file += "." + bd.encryptor.name
+	}
+	out := path.Join(bd.dir, file)
+	digest, parts, err := bd.copyFile(filepath, out)
 	if err != nil {
-		return errors.Annotatef(err, "reading %q", filepath)
+		return errors.Trace(err)
 	}
 
-	out := path.Join(bd.dir, fmt.Sprintf("%s-%s.bundle.tar", name, version))
-	dst, err := os.Create(out)
-	if err != nil {
-		return errors.Annotatef(err, "creating %q", out)
+	b := newBundle(name, version, file, digest, filepath)
+	b.Parts = parts
+
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	// Older directories get a manifest listing their bundles too
+	if bd.manifest == nil {
+		if err := bd.initManifest(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	bd.manifest.Charts = append(bd.manifest.Charts, b)
+	bd.manifest.Toolchain = Toolchain{ChartsSyncer: bd.toolchain, Relok8s: relok8sVersion()}
+	if err := writeManifest(bd.dir, bd.manifest); err != nil {
+		return errors.Annotatef(err, "writing %q", ManifestFile)
 	}
-	_, err = io.Copy(dst, src)
+
+	bd.add(b)
+
+	return nil
+}
+
+// WriteManifest writes the manifest of the directory, so directories without
+// bundles get one too
+func (bd *BundlesDir) WriteManifest() error {
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	if bd.manifest == nil {
+		if err := bd.initManifest(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	bd.manifest.Toolchain = Toolchain{ChartsSyncer: bd.toolchain, Relok8s: relok8sVersion()}
+	return errors.Annotatef(writeManifest(bd.dir, bd.manifest), "writing %q", ManifestFile)
+}
+
+// copyFile copies a file, encrypting and splitting it if configured, and
+// returns the hex-encoded sha256 digest of the copy and its parts
+func (bd *BundlesDir) copyFile(src, dst string) (string, []Part, error) {
+	in, err := os.Open(src)
 	if err != nil {
-		return errors.Annotatef(err, "copying %q to %q", filepath, out)
+		return "", nil, errors.Annotatef(err, "reading %q", src)
+	}
+	defer in.Close()
+	var out io.WriteCloser
+	var pw *partWriter
+	if bd.partSize > 0 {
+		pw = &partWriter{file: dst, size: bd.partSize}
+		out = pw
+	} else if out, err = os.Create(dst); err != nil {
+		return "", nil, errors.Annotatef(err, "creating %q", dst)
+	}
+	remove := func() {
+		if pw != nil {
+			pw.remove()
+		} else {
+			os.Remove(dst)
+		}
 	}
 
-	bd.entries[name] = append(bd.entries[name], version)
-	sort.Strings(bd.entries[name])
+	h := sha256.New()
+	w := io.MultiWriter(out, h)
+	if bd.encryptor != nil {
+		err = bd.encryptor.encrypt(w, in)
+	} else {
+		_, err = io.Copy(w, in)
+	}
+	if err != nil {
+		out.Close()
+		remove()
+		return "", nil, errors.Annotatef(err, "copying %q to %q", src, dst)
+	}
+	if err := out.Close(); err != nil {
+		remove()
+		return "", nil, errors.Annotatef(err, "copying %q to %q", src, dst)
+	}
+	var parts []Part
+	if pw != nil {
+		parts = pw.parts
+	}
+	return hex.EncodeToString(h.Sum(nil)), parts, nil
+}
 
+// initManifest creates the manifest of a directory written by an older
+// version, listing its existing bundles
+func (bd *BundlesDir) initManifest() error {
+	bd.manifest = &Manifest{FormatVersion: FormatVersion}
+	for name, versions := range bd.entries {
+		for _, version := range versions {
+			file := bd.bundles[fmt.Sprintf("%s-%s", name, version)].File
+			digest, err := fileDigest(path.Join(bd.dir, file))
+			if err != nil {
+				return errors.Trace(err)
+			}
+			// The images of encrypted bundles cannot be listed
+			plain := ""
+			if encryptionOf(file) == "" {
+				plain = path.Join(bd.dir, file)
+			}
+			bd.manifest.Charts = append(bd.manifest.Charts, newBundle(name, version, file, digest, plain))
+		}
+	}
 	return nil
 }
 
+// newBundle describes a bundle file of the directory. The images are listed
+// from the unencrypted bundle, if given.
+func newBundle(name, version, file, digest, plain string) Bundle {
+	var images []string
+	if plain != "" {
+		var err error
+		if images, err = bundleImages(plain); err != nil {
+			klog.Warningf("Unable to list the container images of %q: %v", file, err)
+		}
+	}
+	return Bundle{
+		Name:       name,
+		Version:    version,
+		File:       file,
+		Digest:     "sha256:" + digest,
+		Encryption: encryptionOf(file),
+		Images:     images,
+	}
+}
+
 // GetChartDetails returns the details of a chart
 func (bd *BundlesDir) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	bd.mu.RLock()
+	defer bd.mu.RUnlock()
+	digest := "deadbeef"
+	for _, b := range bd.manifest.GetCharts() {
+		if b.Name == name && b.Version == version {
+			digest = b.Digest
+		}
+	}
 	return &types.ChartDetails{
 		PublishedAt: utils.UnixEpoch,
-		Digest:      "deadbeef",
+		Digest:      digest,
 	}, nil
 }
 
@@ -1,11 +1,23 @@
 package intermediate_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
+	"filippo.io/age"
+	"github.com/juju/errors"
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/bitnami-labs/charts-syncer/api"
 	intermediate "github.com/bitnami-labs/charts-syncer/pkg/client/intermediate"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"helm.sh/helm/v3/pkg/chart"
@@ -93,7 +105,8 @@ func TestGetChartDetails(t *testing.T) {
 }
 
 func TestUpload(t *testing.T) {
-	c, err := intermediate.NewIntermediateClient("../../../testdata/intermediate_bundles")
+	dir := newBundlesDir(t, "../../../testdata/intermediate_bundles")
+	c, err := intermediate.NewIntermediateClient(dir)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -105,11 +118,352 @@ func TestUpload(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	expectedChartPath := "../../../testdata/intermediate_bundles/apache-7.3.15.bundle.tar"
+	expectedChartPath := filepath.Join(dir, "apache-7.3.15.bundle.tar")
 	if _, err := os.Stat(expectedChartPath); err != nil {
 		t.Errorf("chart bundle does not exist after upload method")
 	}
-	if err := os.Remove(expectedChartPath); err != nil {
-		t.Errorf("error cleaning chart bundle path from %q after successful upload", expectedChartPath)
+}
+
+func TestManifest(t *testing.T) {
+	// Legacy directories get a manifest listing their bundles on upload
+	dir := newBundlesDir(t, "../../../testdata/intermediate_bundles")
+	c, err := intermediate.New(dir, intermediate.WithToolchainVersion("v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(t.TempDir(), "apache-7.3.15.bundle.tar")
+	writeBundle(t, bundle, "docker.io/bitnami/apache:2.4.41")
+	if err := c.Upload(bundle, &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, intermediate.ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &intermediate.Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		t.Fatal(err)
+	}
+	if m.FormatVersion != intermediate.FormatVersion || m.Toolchain.ChartsSyncer != "v1.0.0" {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+	var files []string
+	for _, b := range m.Charts {
+		files = append(files, b.File)
+		if b.Name == "apache" && !reflect.DeepEqual(b.Images, []string{"docker.io/bitnami/apache:2.4.41"}) {
+			t.Errorf("got images: %v, want the bundled image", b.Images)
+		}
+	}
+	if want := []string{"apache-7.3.15.bundle.tar", "common-1.10.1.bundle.tar", "etcd-4.8.0.bundle.tar"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("got bundles: %v, want: %v", files, want)
+	}
+
+	c, err = intermediate.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	details, err := c.GetChartDetails("apache", "7.3.15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range m.Charts {
+		if b.Name == "apache" && details.Digest != b.Digest {
+			t.Errorf("got digest: %q, want: %q", details.Digest, b.Digest)
+		}
+	}
+
+	// Bundles added behind the manifest are ignored
+	if err := ioutil.WriteFile(filepath.Join(dir, "nginx-1.0.0.bundle.tar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.ListChartVersions("nginx"); len(got) != 0 {
+		t.Errorf("got: %v, want unlisted bundles to be ignored", got)
+	}
+
+	// Modified and missing bundles are rejected
+	if err := ioutil.WriteFile(filepath.Join(dir, "etcd-4.8.0.bundle.tar"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intermediate.New(dir); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("got: %v, want a checksum mismatch error", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "etcd-4.8.0.bundle.tar")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := intermediate.New(dir); err == nil || !strings.Contains(err.Error(), "partially transferred") {
+		t.Errorf("got: %v, want a missing file error", err)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	// Directories without bundles get a manifest too
+	dir := t.TempDir()
+	c, err := intermediate.New(dir, intermediate.WithToolchainVersion("v1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteManifest(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := intermediate.ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Charts) != 0 || m.Packages || m.Toolchain.ChartsSyncer != "v1.0.0" {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+	if _, err := intermediate.ReadManifest(t.TempDir()); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a NotFound error", err)
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity, err := openpgp.NewEntity("charts-syncer", "", "charts-syncer@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GnuPG keys declare the hash functions they prefer, unlike the generated
+	// ones
+	for _, ident := range entity.Identities {
+		ident.SelfSignature.PreferredHash = []uint8{8} // SHA256
+		if err := ident.SelfSignature.SignUserId(ident.UserId.Id, entity.PrimaryKey, entity.PrivateKey, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys := t.TempDir()
+	writeKeys := func(file, content string) string {
+		p := filepath.Join(keys, file)
+		if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	public, secret := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := entity.Serialize(public); err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.SerializePrivate(secret, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc       string
+		encryption *api.BundleEncryption
+		decryption *api.BundleDecryption
+		file       string
+		wantErr    bool
+	}{
+		{
+			desc:       "age",
+			encryption: &api.BundleEncryption{AgeRecipients: []string{identity.Recipient().String()}},
+			decryption: &api.BundleDecryption{AgeIdentities: writeKeys("age.txt", identity.String())},
+			file:       "apache-7.3.15.bundle.tar.age",
+		},
+		{
+			desc:       "gpg",
+			encryption: &api.BundleEncryption{GpgKeyring: writeKeys("pub.gpg", public.String())},
+			decryption: &api.BundleDecryption{GpgKeyring: writeKeys("secring.gpg", secret.String())},
+			file:       "apache-7.3.15.bundle.tar.gpg",
+		},
+		{
+			desc:       "wrong age identity",
+			encryption: &api.BundleEncryption{AgeRecipients: []string{identity.Recipient().String()}},
+			decryption: &api.BundleDecryption{AgeIdentities: writeKeys("other.txt", other.String())},
+			file:       "apache-7.3.15.bundle.tar.age",
+			wantErr:    true,
+		},
+		{
+			desc:       "missing keys",
+			encryption: &api.BundleEncryption{AgeRecipients: []string{identity.Recipient().String()}},
+			file:       "apache-7.3.15.bundle.tar.age",
+			wantErr:    true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			bundle := filepath.Join(t.TempDir(), "apache-7.3.15.bundle.tar")
+			writeBundle(t, bundle, "docker.io/bitnami/apache:2.4.41")
+			c, err := intermediate.New(dir, intermediate.WithEncryption(tc.encryption))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Upload(bundle, &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, tc.file)); err != nil {
+				t.Fatalf("encrypted bundle does not exist: %v", err)
+			}
+
+			c, err = intermediate.New(dir, intermediate.WithDecryption(tc.decryption, t.TempDir()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if versions, _ := c.ListChartVersions("apache"); !reflect.DeepEqual(versions, []string{"7.3.15"}) {
+				t.Errorf("got versions: %v, want: [7.3.15]", versions)
+			}
+			got, err := c.Fetch("apache", "7.3.15")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("decrypted bundle does not match the uploaded one")
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	identities := filepath.Join(t.TempDir(), "age.txt")
+	if err := ioutil.WriteFile(identities, []byte(identity.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc       string
+		partSize   int64
+		encryption *api.BundleEncryption
+		file       string
+		wantParts  bool
+	}{
+		{desc: "split", partSize: 1000, file: "apache-7.3.15.bundle.tar", wantParts: true},
+		{desc: "fits in a part", partSize: 1 << 20, file: "apache-7.3.15.bundle.tar"},
+		{
+			desc:       "encrypted",
+			partSize:   1000,
+			encryption: &api.BundleEncryption{AgeRecipients: []string{identity.Recipient().String()}},
+			file:       "apache-7.3.15.bundle.tar.age",
+			wantParts:  true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			bundle := filepath.Join(t.TempDir(), "apache-7.3.15.bundle.tar")
+			writeBundle(t, bundle, "docker.io/bitnami/apache:2.4.41")
+			c, err := intermediate.New(dir, intermediate.WithPartSize(tc.partSize), intermediate.WithEncryption(tc.encryption))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Upload(bundle, &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+				t.Fatal(err)
+			}
+			parts, err := filepath.Glob(filepath.Join(dir, tc.file+".part*"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = os.Stat(filepath.Join(dir, tc.file))
+			if tc.wantParts && (len(parts) < 2 || !os.IsNotExist(err)) {
+				t.Errorf("got %d parts (bundle file error: %v), want the bundle to be split", len(parts), err)
+			} else if !tc.wantParts && (len(parts) > 0 || err != nil) {
+				t.Errorf("got %d parts (bundle file error: %v), want a single bundle file", len(parts), err)
+			}
+
+			c, err = intermediate.New(dir, intermediate.WithDecryption(&api.BundleDecryption{AgeIdentities: identities}, t.TempDir()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.Fetch("apache", "7.3.15")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ioutil.ReadFile(bundle)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, want) {
+				t.Errorf("restored bundle does not match the uploaded one")
+			}
+
+			// A missing part is detected before importing the bundle
+			if tc.wantParts {
+				if err := os.Remove(parts[len(parts)-1]); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := intermediate.New(dir); err == nil || !strings.Contains(err.Error(), "partially transferred") {
+					t.Errorf("got: %v, want a missing file error", err)
+				}
+			}
+		})
+	}
+}
+
+// newBundlesDir copies a bundles directory to a temporary directory
+func newBundlesDir(t *testing.T, src string) string {
+	dir := t.TempDir()
+	matches, err := filepath.Glob(filepath.Join(src, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range matches {
+		data, err := ioutil.ReadFile(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(m)), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// writeBundle writes a relok8s bundle whose images tarball only holds its
+// manifest
+func writeBundle(t *testing.T, file string, images ...string) {
+	manifest, err := json.Marshal([]map[string][]string{{"RepoTags": images}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	imagesTar := &bytes.Buffer{}
+	writeTar(t, imagesTar, "manifest.json", manifest)
+	f, err := os.Create(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	writeTar(t, f, "images.tar", imagesTar.Bytes())
+}
+
+func writeTar(t *testing.T, w io.Writer, name string, data []byte) {
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
 	}
 }
@@ -0,0 +1,314 @@
+package intermediate
+
+import (
+	"archive/tar"
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+const (
+	// ManifestFile describes the charts of a bundles directory
+	ManifestFile = "manifest.json"
+	// ChecksumsFile holds the sha256 sums of the files of a bundles directory,
+	// in the format of the sha256sum tool
+	ChecksumsFile = "SHA256SUMS"
+	// FormatVersion is the version of the bundles directory format
+	FormatVersion = 2
+
+	relok8sModule = "github.com/vmware-tanzu/asset-relocation-tool-for-kubernetes"
+	// imagesTar holds the container images of a relok8s bundle
+	imagesTar = "images.tar"
+)
+
+// Manifest describes the charts of a bundles directory
+type Manifest struct {
+	FormatVersion int       `json:"formatVersion"`
+	Toolchain     Toolchain `json:"toolchain"`
+	Charts        []Bundle  `json:"charts"`
+	// Packages is set for bundles of plain chart packages, indexed like a
+	// local repository, which do not ship the container images
+	Packages bool `json:"packages,omitempty"`
+	// Files are the files of the directory other than the chart bundles, like
+	// the packages, index and provenance files of a packages bundle
+	Files []string `json:"files,omitempty"`
+}
+
+// Toolchain contains the versions of the tools that wrote a bundles directory
+type Toolchain struct {
+	ChartsSyncer string `json:"chartsSyncer,omitempty"`
+	Relok8s      string `json:"relok8s,omitempty"`
+}
+
+// Bundle describes a chart bundle
+type Bundle struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	File    string `json:"file"`
+	// Digest is the sha256 digest of the bundle file
+	Digest string `json:"digest"`
+	// Encryption is the tool the bundle is encrypted with, age or gpg
+	Encryption string `json:"encryption,omitempty"`
+	// Images are the container images shipped with the chart
+	Images []string `json:"images,omitempty"`
+	// Parts are the files the bundle is split into, in order. The bundle
+	// file itself does not exist if it is split
+	Parts []Part `json:"parts,omitempty"`
+}
+
+// GetCharts returns the charts of a manifest, which may be nil
+func (m *Manifest) GetCharts() []Bundle {
+	if m == nil {
+		return nil
+	}
+	return m.Charts
+}
+
+// ReadManifest reads the manifest of a bundles directory and verifies the
+// checksums of its files. It returns a NotFound error for directories without
+// manifest.
+func ReadManifest(dir string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, errors.NotFoundf("%q in %q", ManifestFile, dir)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, errors.Annotatef(err, "parsing %q", ManifestFile)
+	}
+	if m.FormatVersion != FormatVersion {
+		return nil, errors.Errorf("unsupported bundles format version %d, want %d", m.FormatVersion, FormatVersion)
+	}
+
+	sums, err := readChecksums(dir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if _, ok := sums[ManifestFile]; !ok {
+		return nil, errors.Errorf("%q has no checksum", ManifestFile)
+	}
+	for _, b := range m.Charts {
+		files := []Part{{File: b.File, Digest: b.Digest}}
+		if len(b.Parts) > 0 {
+			files = b.Parts
+		}
+		for _, f := range files {
+			if sum, ok := sums[f.File]; !ok {
+				return nil, errors.Errorf("%q has no checksum", f.File)
+			} else if "sha256:"+sum != f.Digest {
+				return nil, errors.Errorf("%q digest does not match its checksum", f.File)
+			}
+		}
+	}
+	for _, f := range m.Files {
+		if _, ok := sums[f]; !ok {
+			return nil, errors.Errorf("%q has no checksum", f)
+		}
+	}
+	// Every file of a packages bundle is read, so none may be unlisted
+	if m.Packages {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		for _, f := range files {
+			if _, ok := sums[f.Name()]; !ok && f.Name() != ChecksumsFile {
+				return nil, errors.Errorf("%q is not listed in %q", f.Name(), ChecksumsFile)
+			}
+		}
+	}
+	for file, sum := range sums {
+		got, err := fileDigest(filepath.Join(dir, file))
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, errors.Errorf("%q is missing, the bundles directory may be partially transferred", file)
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if got != sum {
+			return nil, errors.Errorf("%q checksum mismatch (got: %s, want: %s), the file was modified or partially transferred", file, got, sum)
+		}
+	}
+	return m, nil
+}
+
+// readChecksums reads the checksums file of a bundles directory
+func readChecksums(dir string) (map[string]string, error) {
+	f, err := os.Open(filepath.Join(dir, ChecksumsFile))
+	if os.IsNotExist(err) {
+		return nil, errors.Errorf("%q is missing, the bundles directory may be partially transferred", ChecksumsFile)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// sha256sum prefixes the files read in binary mode with '*'
+		if len(fields) != 2 {
+			return nil, errors.Errorf("invalid %q line: %q", ChecksumsFile, scanner.Text())
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		// Bundles directories are flat
+		if file != filepath.Base(file) || file == ".." {
+			return nil, errors.Errorf("invalid %q file path: %q", ChecksumsFile, file)
+		}
+		sums[file] = fields[0]
+	}
+	return sums, errors.Trace(scanner.Err())
+}
+
+// writeManifest writes the manifest of a bundles directory and the checksums
+// of its files
+func writeManifest(dir string, m *Manifest) error {
+	sort.Slice(m.Charts, func(i, j int) bool { return m.Charts[i].File < m.Charts[j].File })
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := writeFile(filepath.Join(dir, ManifestFile), data); err != nil {
+		return errors.Trace(err)
+	}
+
+	sum := sha256.Sum256(data)
+	var sums strings.Builder
+	for _, b := range m.Charts {
+		if len(b.Parts) == 0 {
+			fmt.Fprintf(&sums, "%s  %s\n", strings.TrimPrefix(b.Digest, "sha256:"), b.File)
+		}
+		for _, p := range b.Parts {
+			fmt.Fprintf(&sums, "%s  %s\n", strings.TrimPrefix(p.Digest, "sha256:"), p.File)
+		}
+	}
+	for _, f := range m.Files {
+		digest, err := fileDigest(filepath.Join(dir, f))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", digest, f)
+	}
+	fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(sum[:]), ManifestFile)
+	return errors.Trace(writeFile(filepath.Join(dir, ChecksumsFile), []byte(sums.String())))
+}
+
+// WritePackagesManifest writes the manifest of a packages bundle, listing every
+// file of the directory, and their checksums
+func WritePackagesManifest(dir, toolchainVersion string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	m := &Manifest{FormatVersion: FormatVersion, Toolchain: Toolchain{ChartsSyncer: toolchainVersion}, Packages: true}
+	for _, f := range files {
+		if f.Name() == ManifestFile || f.Name() == ChecksumsFile {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			return errors.NotSupportedf("%q in a packages bundle: it is not a regular file", f.Name())
+		}
+		m.Files = append(m.Files, f.Name())
+	}
+	return errors.Annotatef(writeManifest(dir, m), "writing %q", ManifestFile)
+}
+
+// writeFile replaces a file so readers never see it partially written
+func writeFile(file string, data []byte) error {
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, file))
+}
+
+// fileDigest returns the hex-encoded sha256 digest of a file
+func fileDigest(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Trace(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// bundleImages returns the container images of a relok8s bundle, read from
+// the manifest of its images tarball
+func bundleImages(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, errors.NotFoundf("%q in %q", imagesTar, file)
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if hdr.Name == imagesTar {
+			break
+		}
+	}
+
+	images := tar.NewReader(tr)
+	for {
+		hdr, err := images.Next()
+		if err == io.EOF {
+			return nil, errors.NotFoundf("images manifest in %q", file)
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if hdr.Name != "manifest.json" {
+			continue
+		}
+		var manifest []struct {
+			RepoTags []string
+		}
+		if err := json.NewDecoder(images).Decode(&manifest); err != nil {
+			return nil, errors.Annotatef(err, "parsing images manifest of %q", file)
+		}
+		var refs []string
+		for _, m := range manifest {
+			refs = append(refs, m.RepoTags...)
+		}
+		sort.Strings(refs)
+		return refs, nil
+	}
+}
+
+// relok8sVersion returns the version of relok8s charts-syncer is built with
+func relok8sVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == relok8sModule {
+			return dep.Version
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package intermediate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/juju/errors"
+)
+
+// Part is a file of a split bundle
+type Part struct {
+	File string `json:"file"`
+	// Digest is the sha256 digest of the part
+	Digest string `json:"digest"`
+	Size   int64  `json:"size"`
+}
+
+// partWriter splits the content written to it into files of a fixed size,
+// named after the bundle file with a .partNNN suffix. Content fitting in a
+// single part is written to the bundle file instead.
+type partWriter struct {
+	file  string
+	size  int64
+	parts []Part
+	cur   *os.File
+	h     hash.Hash
+	n     int64
+}
+
+func (w *partWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if w.cur == nil || w.n == w.size {
+			if err := w.next(); err != nil {
+				return written, errors.Trace(err)
+			}
+		}
+		chunk := p
+		if rem := w.size - w.n; int64(len(chunk)) > rem {
+			chunk = chunk[:rem]
+		}
+		n, err := w.cur.Write(chunk)
+		w.h.Write(chunk[:n])
+		w.n += int64(n)
+		written += n
+		if err != nil {
+			return written, errors.Trace(err)
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// next closes the current part and opens the next one
+func (w *partWriter) next() error {
+	if err := w.closePart(); err != nil {
+		return errors.Trace(err)
+	}
+	name := fmt.Sprintf("%s.part%03d", w.file, len(w.parts)+1)
+	f, err := os.Create(name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	w.cur, w.h, w.n = f, sha256.New(), 0
+	w.parts = append(w.parts, Part{File: filepath.Base(name)})
+	return nil
+}
+
+func (w *partWriter) closePart() error {
+	if w.cur == nil {
+		return nil
+	}
+	last := &w.parts[len(w.parts)-1]
+	last.Digest = "sha256:" + hex.EncodeToString(w.h.Sum(nil))
+	last.Size = w.n
+	err := w.cur.Close()
+	w.cur = nil
+	return errors.Trace(err)
+}
+
+// Close closes the last part
+func (w *partWriter) Close() error {
+	if err := w.closePart(); err != nil {
+		return errors.Trace(err)
+	}
+	switch len(w.parts) {
+	case 0:
+		return errors.Trace(ioutil.WriteFile(w.file, nil, 0644))
+	case 1:
+		part := filepath.Join(filepath.Dir(w.file), w.parts[0].File)
+		w.parts = nil
+		return errors.Trace(os.Rename(part, w.file))
+	}
+	return nil
+}
+
+// remove removes the written files
+func (w *partWriter) remove() {
+	for _, p := range w.parts {
+		os.Remove(filepath.Join(filepath.Dir(w.file), p.File))
+	}
+	os.Remove(w.file)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+	"github.com/containerd/containerd/remotes"
+	"github.com/juju/errors"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// readOnly wraps a chart or bundle client so that only its read methods can
+// be reached, even through type assertions.
+type readOnly struct {
+	c ChartsReader
+}
+
+// ReadOnly returns a client exposing only the read methods of c.
+//
+// The optional interfaces implemented by c to read data, like
+// ProvenanceReader or IndexEntryReader, are still available. The ones writing
+// data, like ChartsWriter or ChartsDeleter, are not, so the returned client can
+// never modify the repository even if its credentials allow it.
+func ReadOnly(c ChartsReader) ChartsReader {
+	if r, ok := c.(*readOnly); ok {
+		return r
+	}
+	return &readOnly{c: c}
+}
+
+// Fetch fetches a chart
+func (r *readOnly) Fetch(name string, version string) (string, error) {
+	return r.c.Fetch(name, version)
+}
+
+// List lists all chart names in a repo
+func (r *readOnly) List() ([]string, error) {
+	return r.c.List()
+}
+
+// ListChartVersions lists all versions of a chart
+func (r *readOnly) ListChartVersions(name string) ([]string, error) {
+	return r.c.ListChartVersions(name)
+}
+
+// Has checks if a repo has a specific chart
+func (r *readOnly) Has(name string, version string) (bool, error) {
+	return r.c.Has(name, version)
+}
+
+// GetChartDetails returns the details of a chart
+func (r *readOnly) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	return r.c.GetChartDetails(name, version)
+}
+
+// Reload reloads the index
+func (r *readOnly) Reload() error {
+	return r.c.Reload()
+}
+
+// InvalidIndexEntries returns the index entries the wrapped client was unable
+// to load, if it is based on an index
+func (r *readOnly) InvalidIndexEntries() []string {
+	if ir, ok := r.c.(IndexReporter); ok {
+		return ir.InvalidIndexEntries()
+	}
+	return nil
+}
+
+// GetIndexEntry returns the index entry of a chart, if the wrapped client is
+// based on an index
+func (r *readOnly) GetIndexEntry(name string, version string) (*repo.ChartVersion, error) {
+	if ir, ok := r.c.(IndexEntryReader); ok {
+		return ir.GetIndexEntry(name, version)
+	}
+	return nil, errors.NotSupportedf("reading index entries from %T clients", r.c)
+}
+
+// FetchProvenance fetches the provenance file of a chart. It returns a
+// NotFound error if the wrapped client does not support provenance files.
+func (r *readOnly) FetchProvenance(name string, version string) ([]byte, error) {
+	if pr, ok := r.c.(ProvenanceReader); ok {
+		return pr.FetchProvenance(name, version)
+	}
+	return nil, errors.NotFoundf("provenance file of %s-%s", name, version)
+}
+
+// OCIReference returns the reference of a chart and a resolver that can only
+// fetch it, if the wrapped client is based on an OCI registry
+func (r *readOnly) OCIReference(name string, version string) (string, remotes.Resolver, error) {
+	if rr, ok := r.c.(OCIReferenceReader); ok {
+		return rr.OCIReference(name, version)
+	}
+	return "", nil, errors.NotSupportedf("reading OCI references from %T clients", r.c)
+}
+
+// Referrers lists the artifacts referring to a manifest, if the wrapped client
+// is based on an OCI registry
+func (r *readOnly) Referrers(name string, manifestDigest string) ([]ocispec.Descriptor, error) {
+	if rr, ok := r.c.(OCIReferrersReader); ok {
+		return rr.Referrers(name, manifestDigest)
+	}
+	return nil, errors.NotSupportedf("listing referrers from %T clients", r.c)
+}
@@ -0,0 +1,322 @@
+// Package azblob implements static Helm repositories stored in Azure Blob
+// Storage containers, with the chart packages and their index.yaml file at the
+// root of the repository.
+package azblob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"golang.org/x/oauth2"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+)
+
+// apiVersion is the version of the Blob service REST API
+const apiVersion = "2021-08-06"
+
+// Repo allows to operate a chart repository stored in an Azure Blob Storage
+// container.
+type Repo struct {
+	*bucket.Repo
+}
+
+// New creates a Repo object from an api.Repo object. The URL of the
+// repository is like azblob://container/prefix.
+func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
+	store, err := NewStore(repo, insecure)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r, err := bucket.New(store, c)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Repo{Repo: r}, nil
+}
+
+// Store reads and writes the blobs of a repository in a container. Requests
+// are authorized with the account key (Shared Key), a SAS token or the access
+// tokens of a managed identity.
+type Store struct {
+	account   string
+	container string
+	prefix    string
+	endpoint  *url.URL
+
+	accountKey []byte
+	sas        url.Values
+	tokens     oauth2.TokenSource
+	// credentials describes how the requests are authorized
+	credentials string
+
+	client *http.Client
+	// now returns the time the requests are signed at
+	now func() time.Time
+}
+
+// NewStore returns the store of an Azure Blob Storage repository.
+//
+// The connection string of the repository, or the
+// AZURE_STORAGE_CONNECTION_STRING env var, provides the account key or a SAS
+// token. Without connection string, the managed identity of the workload is
+// used.
+func NewStore(repo *api.Repo, insecure bool) (*Store, error) {
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if u.Scheme != "azblob" || u.Host == "" {
+		return nil, errors.NotValidf("Azure Blob Storage repository URL %q, it should be like azblob://container/prefix", repo.GetUrl())
+	}
+	opts := repo.GetAzblob()
+	s := &Store{
+		account:   opts.GetAccount(),
+		container: u.Host,
+		prefix:    strings.Trim(u.Path, "/"),
+		client:    utils.DefaultClient,
+		now:       time.Now,
+	}
+	if insecure {
+		s.client = utils.InsecureClient
+	}
+
+	var cs connectionString
+	origin := "azblob.connectionString"
+	raw := opts.GetConnectionString()
+	if raw == "" {
+		raw, origin = os.Getenv("AZURE_STORAGE_CONNECTION_STRING"), "AZURE_STORAGE_CONNECTION_STRING"
+	}
+	if raw != "" {
+		if cs, err = parseConnectionString(raw); err != nil {
+			return nil, errors.Annotatef(err, "parsing the connection string of %s", origin)
+		}
+	}
+	if s.account == "" {
+		s.account = cs.accountName
+	}
+	if s.account == "" {
+		s.account = os.Getenv("AZURE_STORAGE_ACCOUNT")
+	}
+	endpoint := opts.GetEndpoint()
+	if endpoint == "" {
+		endpoint = cs.endpoint(s.account)
+	}
+	// The account is part of the default endpoint and of the Shared Key
+	// signatures
+	if s.account == "" && (cs.blobEndpoint == "" && opts.GetEndpoint() == "" || cs.accountKey != "") {
+		return nil, errors.NotValidf("Azure Blob Storage repository %q without storage account, set it in the connection string, azblob.account or AZURE_STORAGE_ACCOUNT", repo.GetUrl())
+	}
+	if s.endpoint, err = url.Parse(endpoint); err != nil {
+		return nil, errors.Annotatef(err, "parsing Azure Blob Storage endpoint %q", endpoint)
+	}
+
+	switch {
+	case cs.accountKey != "":
+		if s.accountKey, err = base64.StdEncoding.DecodeString(cs.accountKey); err != nil {
+			return nil, errors.Annotatef(err, "decoding the account key of %s", origin)
+		}
+		s.credentials = fmt.Sprintf("shared key of account %q from %s", s.account, origin)
+	case cs.sas != "":
+		if s.sas, err = url.ParseQuery(strings.TrimPrefix(cs.sas, "?")); err != nil {
+			return nil, errors.Annotatef(err, "parsing the SAS token of %s", origin)
+		}
+		s.credentials = "SAS token from " + origin
+	default:
+		s.tokens, s.credentials = managedIdentity(opts.GetClientId())
+	}
+	return s, nil
+}
+
+// Credentials describes how the requests are authorized
+func (s *Store) Credentials() string {
+	return s.credentials
+}
+
+// String returns the URL of the repository
+func (s *Store) String() string {
+	return strings.TrimSuffix(fmt.Sprintf("azblob://%s/%s", s.container, s.prefix), "/")
+}
+
+// blobURL returns the URL of a blob
+func (s *Store) blobURL(key string) *url.URL {
+	p := key
+	if s.prefix != "" {
+		p = s.prefix + "/" + key
+	}
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.container + "/" + p
+	if s.sas != nil {
+		u.RawQuery = s.sas.Encode()
+	}
+	return &u
+}
+
+// do sends an authorized request for a blob
+func (s *Store) do(method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.blobURL(key).String(), body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	req.Header.Set("x-ms-version", apiVersion)
+	req.Header.Set("x-ms-date", s.now().UTC().Format(http.TimeFormat))
+	switch {
+	case s.accountKey != nil:
+		s.sign(req)
+	case s.tokens != nil:
+		token, err := s.tokens.Token()
+		if err != nil {
+			return nil, errors.Annotatef(err, "getting an access token for the %s", s.credentials)
+		}
+		token.SetAuthHeader(req)
+	}
+
+	reqID := utils.EncodeSha1(req.URL.Path)
+	klog.V(4).Infof("[%s] %s %q", reqID, method, req.URL.Path)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+	return res, nil
+}
+
+// sign adds the Shared Key authorization of a request to its headers
+func (s *Store) sign(req *http.Request) {
+	mac := hmac.New(sha256.New, s.accountKey)
+	mac.Write([]byte(s.stringToSign(req)))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", s.account, signature))
+}
+
+// stringToSign returns the string signed with the account key
+func (s *Store) stringToSign(req *http.Request) string {
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var names []string
+	for k := range req.Header {
+		if k := strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	var headers strings.Builder
+	for _, k := range names {
+		headers.WriteString(k + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n")
+	}
+
+	resource := "/" + s.account + req.URL.EscapedPath()
+	q := req.URL.Query()
+	params := make([]string, 0, len(q))
+	for k := range q {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	for _, k := range params {
+		values := q[k]
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(k) + ":" + strings.Join(values, ",")
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		length,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		// The x-ms-date header is set
+		"",
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		headers.String() + resource,
+	}, "\n")
+}
+
+// statusError returns the error of a failed request for a blob
+func statusError(res *http.Response, method, key string) error {
+	body := utils.HTTPResponseBody(res)
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return errors.NotFoundf("%q blob", key)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.Forbiddenf("%s %q: got HTTP Status: %s, Resp: %v", method, key, res.Status, body)
+	default:
+		return errors.Errorf("unable to %s %q, got HTTP Status: %s, Resp: %v", method, key, res.Status, body)
+	}
+}
+
+// Get returns the content of a blob
+func (s *Store) Get(key string) (io.ReadCloser, error) {
+	res, err := s.do(http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, statusError(res, http.MethodGet, key)
+	}
+	return res.Body, nil
+}
+
+// Put creates or replaces a block blob. The index is served with caching
+// disabled, so clients see the new chart versions right away.
+func (s *Store) Put(key string, r io.Reader, size int64, contentType string) error {
+	header := http.Header{
+		"Content-Type":   {contentType},
+		"X-Ms-Blob-Type": {"BlockBlob"},
+	}
+	if key == bucket.IndexFile {
+		header.Set("x-ms-blob-cache-control", "no-cache, max-age=0")
+	}
+	res, err := s.do(http.MethodPut, key, r, size, header)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return statusError(res, http.MethodPut, key)
+	}
+	return nil
+}
+
+// Delete removes a blob
+func (s *Store) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNotFound:
+		return nil
+	default:
+		return statusError(res, http.MethodDelete, key)
+	}
+}
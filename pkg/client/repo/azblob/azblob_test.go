@@ -0,0 +1,225 @@
+package azblob
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+)
+
+const testToken = "eyJ0eXAi.test"
+
+// fakeBlob is an in-memory Blob service answering path-style requests, with
+// the identity endpoint of App Service
+type fakeBlob struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+	t     *testing.T
+}
+
+// blob returns the blobs under a prefix
+func (f *fakeBlob) blob(prefix string) func(string) ([]byte, bool) {
+	return func(key string) ([]byte, bool) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		data, ok := f.blobs[prefix+key]
+		return data, ok
+	}
+}
+
+func (f *fakeBlob) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/identity" {
+		if r.Header.Get("X-Identity-Header") != "secret" || r.URL.Query().Get("resource") != storageResource {
+			http.Error(w, "invalid identity request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer", "expires_on": "%d"}`, testToken, time.Now().Add(time.Hour).Unix())
+		return
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "SharedKey devstoreaccount1:") && auth != "Bearer "+testToken && r.URL.Query().Get("sig") == "" {
+		http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.blobs[r.URL.Path]
+		if !ok {
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodPut:
+		if got := r.Header.Get("X-Ms-Blob-Type"); got != "BlockBlob" {
+			f.t.Errorf("got: %q blob type, want: BlockBlob", got)
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			f.t.Error(err)
+		}
+		f.blobs[r.URL.Path] = data
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if _, ok := f.blobs[r.URL.Path]; !ok {
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+			return
+		}
+		delete(f.blobs, r.URL.Path)
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func TestParseConnectionString(t *testing.T) {
+	cs, err := parseConnectionString("DefaultEndpointsProtocol=https;AccountName=myaccount;AccountKey=a2V5==;EndpointSuffix=core.chinacloudapi.cn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.accountName != "myaccount" || cs.accountKey != "a2V5==" {
+		t.Errorf("got: %+v, want the account name and key", cs)
+	}
+	if got, want := cs.endpoint("myaccount"), "https://myaccount.blob.core.chinacloudapi.cn"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	cs, err = parseConnectionString("UseDevelopmentStorage=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cs.endpoint(cs.accountName), "http://127.0.0.1:10000/devstoreaccount1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if _, err := parseConnectionString("AccountName"); !errors.IsNotValid(err) {
+		t.Errorf("got: %v, want a not valid error", err)
+	}
+}
+
+func TestStringToSign(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+	s, err := NewStore(&api.Repo{
+		Kind:   api.Kind_AZBLOB,
+		Url:    "azblob://charts/stable",
+		Azblob: &api.AZBlobOptions{ConnectionString: "AccountName=myaccount;AccountKey=a2V5"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPut, s.blobURL("index.yaml").String(), strings.NewReader("entries: {}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-yaml")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+	req.Header.Set("x-ms-date", "Fri, 26 Jun 2015 23:39:12 GMT")
+	req.Header.Set("x-ms-version", apiVersion)
+
+	want := "PUT\n\n\n11\n\napplication/x-yaml\n\n\n\n\n\n\n" +
+		"x-ms-blob-type:BlockBlob\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-version:2021-08-06\n" +
+		"/myaccount/charts/stable/index.yaml"
+	if got := s.stringToSign(req); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := req.URL.String(), "https://myaccount.blob.core.windows.net/charts/stable/index.yaml"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	t.Setenv("AZURE_STORAGE_ACCOUNT", "")
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+	testCases := []struct {
+		desc    string
+		env     map[string]string
+		options *api.AZBlobOptions
+		want    string
+	}{
+		{
+			desc:    "account key",
+			options: &api.AZBlobOptions{ConnectionString: "AccountName=myaccount;AccountKey=a2V5"},
+			want:    `shared key of account "myaccount" from azblob.connectionString`,
+		},
+		{
+			desc: "SAS token from the environment",
+			env:  map[string]string{"AZURE_STORAGE_CONNECTION_STRING": "BlobEndpoint=https://myaccount.blob.core.windows.net;SharedAccessSignature=sv=2021-08-06&sig=abc"},
+			want: "SAS token from AZURE_STORAGE_CONNECTION_STRING",
+		},
+		{
+			desc: "managed identity",
+			env:  map[string]string{"AZURE_STORAGE_ACCOUNT": "myaccount"},
+			want: "managed identity",
+		},
+		{
+			desc:    "user-assigned managed identity",
+			options: &api.AZBlobOptions{Account: "myaccount", ClientId: "00000000-0000-0000-0000-000000000000"},
+			want:    `managed identity "00000000-0000-0000-0000-000000000000"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			s, err := NewStore(&api.Repo{Kind: api.Kind_AZBLOB, Url: "azblob://charts", Azblob: tc.options}, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Credentials(); got != tc.want {
+				t.Errorf("got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := NewStore(&api.Repo{Kind: api.Kind_AZBLOB, Url: "azblob://charts"}, false); !errors.IsNotValid(err) {
+		t.Errorf("got: %v, want a not valid error without storage account", err)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	f := &fakeBlob{blobs: map[string][]byte{}, t: t}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	t.Setenv("AZURE_STORAGE_CONNECTION_STRING", "")
+
+	repo := &api.Repo{
+		Kind: api.Kind_AZBLOB,
+		Url:  "azblob://charts/stable",
+		Azblob: &api.AZBlobOptions{
+			ConnectionString: "UseDevelopmentStorage=true",
+			Endpoint:         srv.URL + "/devstoreaccount1",
+		},
+	}
+	newRepo := func(c cache.Cacher) (*bucket.Repo, error) {
+		r, err := New(repo, c, false)
+		if err != nil {
+			return nil, err
+		}
+		return r.Repo, nil
+	}
+	c := bucket.CheckConformance(t, repo.GetUrl(), newRepo, f.blob("/devstoreaccount1/charts/stable/"))
+
+	// A client authenticated with a managed identity reads the index written
+	// with the shared key
+	t.Setenv("IDENTITY_ENDPOINT", srv.URL+"/identity")
+	t.Setenv("IDENTITY_HEADER", "secret")
+	repo.Azblob = &api.AZBlobOptions{Account: "devstoreaccount1", Endpoint: srv.URL + "/devstoreaccount1"}
+	r, err := New(repo, c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names, err := r.List(); err != nil || len(names) != 0 {
+		t.Errorf("got: %v, %v, want the empty index read with a managed identity", names, err)
+	}
+}
@@ -0,0 +1,159 @@
+package azblob
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"golang.org/x/oauth2"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+const (
+	// storageResource is the resource the access tokens are requested for
+	storageResource = "https://storage.azure.com/"
+	// devStorageAccount and devStorageKey are the well-known credentials of
+	// the Azurite emulator
+	devStorageAccount = "devstoreaccount1"
+	devStorageKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+var (
+	// imdsEndpoint is the token endpoint of the Azure Instance Metadata
+	// Service, reached from virtual machines and AKS pods
+	imdsEndpoint = "http://169.254.169.254/metadata/identity/oauth2/token"
+	// identityClient reaches the identity endpoints directly, failing fast
+	// outside of Azure
+	identityClient = &http.Client{Transport: &http.Transport{}, Timeout: 5 * time.Second}
+)
+
+// connectionString holds the settings of an Azure Storage connection string
+type connectionString struct {
+	protocol       string
+	accountName    string
+	accountKey     string
+	blobEndpoint   string
+	endpointSuffix string
+	sas            string
+}
+
+// parseConnectionString parses a connection string like
+// DefaultEndpointsProtocol=https;AccountName=myaccount;AccountKey=mykey
+func parseConnectionString(s string) (connectionString, error) {
+	cs := connectionString{protocol: "https", endpointSuffix: "core.windows.net"}
+	for _, part := range strings.Split(s, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		// Values like account keys may contain '='
+		i := strings.IndexByte(part, '=')
+		if i < 0 {
+			return cs, errors.NotValidf("connection string setting %q", part)
+		}
+		k, v := strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+		switch strings.ToLower(k) {
+		case "defaultendpointsprotocol":
+			cs.protocol = v
+		case "accountname":
+			cs.accountName = v
+		case "accountkey":
+			cs.accountKey = v
+		case "blobendpoint":
+			cs.blobEndpoint = v
+		case "endpointsuffix":
+			cs.endpointSuffix = v
+		case "sharedaccesssignature":
+			cs.sas = v
+		case "usedevelopmentstorage":
+			if strings.EqualFold(v, "true") {
+				cs.accountName, cs.accountKey = devStorageAccount, devStorageKey
+				cs.blobEndpoint = "http://127.0.0.1:10000/" + devStorageAccount
+			}
+		}
+	}
+	return cs, nil
+}
+
+// endpoint returns the Blob service endpoint of an account
+func (cs connectionString) endpoint(account string) string {
+	if cs.blobEndpoint != "" {
+		return cs.blobEndpoint
+	}
+	protocol, suffix := cs.protocol, cs.endpointSuffix
+	if protocol == "" {
+		protocol = "https"
+	}
+	if suffix == "" {
+		suffix = "core.windows.net"
+	}
+	return fmt.Sprintf("%s://%s.blob.%s", protocol, account, suffix)
+}
+
+// managedIdentity returns the token source of the managed identity of the
+// workload and describes it. The identity endpoint of App Service, Functions
+// and Container Apps is used if set, the Instance Metadata Service otherwise.
+func managedIdentity(clientID string) (oauth2.TokenSource, string) {
+	desc := "managed identity"
+	if clientID != "" {
+		desc = fmt.Sprintf("managed identity %q", clientID)
+	}
+	return oauth2.ReuseTokenSource(nil, &identityTokens{clientID: clientID}), desc
+}
+
+// identityTokens fetches the access tokens of a managed identity
+type identityTokens struct {
+	clientID string
+}
+
+// Token implements oauth2.TokenSource
+func (m *identityTokens) Token() (*oauth2.Token, error) {
+	q := url.Values{"resource": {storageResource}}
+	if m.clientID != "" {
+		q.Set("client_id", m.clientID)
+	}
+	endpoint, header := imdsEndpoint, http.Header{"Metadata": {"true"}}
+	if e := os.Getenv("IDENTITY_ENDPOINT"); e != "" {
+		endpoint, header = e, http.Header{"X-Identity-Header": {os.Getenv("IDENTITY_HEADER")}}
+		q.Set("api-version", "2019-08-01")
+	} else {
+		q.Set("api-version", "2018-02-01")
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header = header
+	res, err := identityClient.Do(req)
+	if err != nil {
+		return nil, errors.Annotate(err, "no managed identity available outside of Azure")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to fetch an access token of the managed identity, got HTTP Status: %s, Resp: %v", res.Status, utils.HTTPResponseBody(res))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		// ExpiresOn is a number of seconds since the epoch, sent as a string
+		// by some endpoints
+		ExpiresOn json.Number `json:"expires_on"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return nil, errors.Annotate(err, "parsing the access token of the managed identity")
+	}
+	expiresOn, err := token.ExpiresOn.Int64()
+	if err != nil {
+		return nil, errors.Annotate(err, "parsing the expiration of the access token of the managed identity")
+	}
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Unix(expiresOn, 0),
+	}, nil
+}
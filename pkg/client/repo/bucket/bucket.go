@@ -0,0 +1,385 @@
+// Package bucket implements static Helm repositories stored in object storage
+// buckets: the chart packages and their index.yaml file are objects at the
+// root of the repository, like the ones served by a static web server.
+package bucket
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
+
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+// IndexFile is the key of the index of the repository
+const IndexFile = "index.yaml"
+
+// Store is implemented by the object storages holding a repository. Keys are
+// relative to the root of the repository.
+type Store interface {
+	// Get returns the content of an object. It returns a NotFound error if
+	// the object does not exist.
+	Get(key string) (io.ReadCloser, error)
+	// Put creates or replaces an object
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Delete removes an object. It does not fail if the object does not
+	// exist.
+	Delete(key string) error
+	// String returns the location of the repository, used in messages
+	String() string
+}
+
+// Repo allows to operate a chart repository stored in a bucket. It is safe
+// for concurrent use.
+type Repo struct {
+	store Store
+	// mu guards the index, which is replaced but never modified
+	mu    sync.RWMutex
+	index *repo.IndexFile
+	// updateMu serializes the updates of the index.yaml object
+	updateMu sync.Mutex
+	cache    cache.Cacher
+}
+
+// New creates a Repo object, loading the index of the repository. A
+// repository without index is empty.
+func New(store Store, c cache.Cacher) (*Repo, error) {
+	r := &Repo{store: store, cache: c}
+	if err := r.Reload(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return r, nil
+}
+
+// loadIndex reads the index of the repository, or returns an empty index if
+// it does not exist
+func (r *Repo) loadIndex() (*repo.IndexFile, error) {
+	rc, err := r.store.Get(IndexFile)
+	if errors.IsNotFound(err) {
+		klog.V(3).Infof("%q has no %s, it is considered empty", r.store, IndexFile)
+		return repo.NewIndexFile(), nil
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "fetching %s", IndexFile)
+	}
+	defer rc.Close()
+
+	// The helm Go library does not expose a loader from bytes
+	f, err := ioutil.TempFile("", "index.*.yaml")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, rc)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "fetching %s", IndexFile)
+	}
+	index, err := repo.LoadIndexFile(f.Name())
+	return index, errors.Annotatef(err, "loading %s", IndexFile)
+}
+
+// writeIndex stores the index of the repository
+func (r *Repo) writeIndex(index *repo.IndexFile) error {
+	index.SortEntries()
+	index.Generated = time.Now()
+	data, err := yaml.Marshal(index)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Annotatef(r.store.Put(IndexFile, bytes.NewReader(data), int64(len(data)), "application/x-yaml"), "storing %s", IndexFile)
+}
+
+// updateIndex replaces a chart version in the index, or removes it if no
+// package is given. The index is read again first, so the entries added by
+// other tools are kept.
+func (r *Repo) updateIndex(name, version, tgz string) error {
+	r.updateMu.Lock()
+	defer r.updateMu.Unlock()
+	index, err := r.loadIndex()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var versions repo.ChartVersions
+	for _, cv := range index.Entries[name] {
+		if cv.Version != version {
+			versions = append(versions, cv)
+		}
+	}
+	index.Entries[name] = versions
+	if len(versions) == 0 {
+		delete(index.Entries, name)
+	}
+	if tgz != "" {
+		ch, err := loader.Load(tgz)
+		if err != nil {
+			return errors.Annotatef(err, "loading %q", tgz)
+		}
+		digest, err := provenance.DigestFile(tgz)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		// Chart URLs are relative to the index, so the repository can be
+		// served from any URL
+		if err := index.MustAdd(ch.Metadata, chartKey(name, version), "", digest); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if err := r.writeIndex(index); err != nil {
+		return errors.Trace(err)
+	}
+	r.setIndex(index)
+	return nil
+}
+
+// getIndex returns the loaded index
+func (r *Repo) getIndex() *repo.IndexFile {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.index
+}
+
+// setIndex replaces the loaded index
+func (r *Repo) setIndex(index *repo.IndexFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index = index
+}
+
+// chartKey returns the key of a chart package
+func chartKey(name, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+// List lists all chart names in a repo
+func (r *Repo) List() ([]string, error) {
+	var names []string
+	for name := range r.getIndex().Entries {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ListChartVersions lists all versions of a chart
+func (r *Repo) ListChartVersions(name string) ([]string, error) {
+	var versions []string
+	for _, cv := range r.getIndex().Entries[name] {
+		versions = append(versions, cv.Version)
+	}
+	if versions == nil {
+		return []string{}, nil
+	}
+	return versions, nil
+}
+
+// Has checks if a repo has a specific chart
+func (r *Repo) Has(name string, version string) (bool, error) {
+	return r.getIndex().Has(name, version), nil
+}
+
+// GetChartDetails returns the details of a chart
+func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	cv, err := r.getIndex().Get(name, version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &types.ChartDetails{
+		PublishedAt: cv.Created,
+		Digest:      cv.Digest,
+	}, nil
+}
+
+// objectKey returns the key of the first URL of a chart in the index. Only
+// URLs relative to the repository are supported.
+func (r *Repo) objectKey(name, version string) (string, error) {
+	cv, err := r.getIndex().Get(name, version)
+	if err != nil {
+		return "", errors.Annotatef(err, "getting %s-%s from index file", name, version)
+	}
+	if len(cv.URLs) == 0 {
+		return "", errors.NotFoundf("%s-%s chart URL", name, version)
+	}
+	key := cv.URLs[0]
+	if strings.Contains(key, "://") {
+		return "", errors.NotSupportedf("absolute %q chart URL in %s", key, r.store)
+	}
+	return strings.TrimPrefix(key, "./"), nil
+}
+
+// ChartDigest reads a stored chart from the bucket and returns its SHA256
+// digest
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	key := chartKey(name, version)
+	rc, err := r.store.Get(key)
+	if err != nil {
+		return "", errors.Annotatef(err, "reading %q", key)
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return "", errors.Annotatef(err, "reading %q", key)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// Fetch fetches a chart
+func (r *Repo) Fetch(name string, version string) (string, error) {
+	id := chartKey(name, version)
+	var digest string
+	if cv, err := r.getIndex().Get(name, version); err == nil {
+		digest = cv.Digest
+	}
+	if r.cache.Has(id) {
+		if err := validateChart(r.cache.Path(id), digest); err == nil {
+			return r.cache.Path(id), nil
+		}
+		if err := r.cache.Invalidate(id); err != nil {
+			return "", errors.Trace(err)
+		}
+	}
+
+	if ok, err := utils.ReadDigestCache(digest, id, r.cache); err != nil {
+		return "", errors.Trace(err)
+	} else if ok {
+		return r.cache.Path(id), nil
+	}
+
+	key, err := r.objectKey(name, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	klog.V(4).Infof("Fetching %q from %q", key, r.store)
+	rc, err := r.store.Get(key)
+	if err != nil {
+		return "", errors.Annotatef(err, "fetching %s:%s chart", name, version)
+	}
+	defer rc.Close()
+	w, err := r.cache.Writer(id)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	_, err = io.Copy(w, rc)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = validateChart(r.cache.Path(id), digest)
+	}
+	if err != nil {
+		r.cache.Invalidate(id)
+		return "", errors.Annotatef(err, "fetching %s:%s chart", name, version)
+	}
+	if err := utils.WriteDigestCache(r.cache.Path(id)); err != nil {
+		klog.Warningf("unable to store %q chart in the digest cache: %v", id, err)
+	}
+	return r.cache.Path(id), nil
+}
+
+// validateChart checks the integrity of a fetched chart package and, if
+// known, its digest
+func validateChart(file, digest string) error {
+	if err := utils.ValidateTarball(file); err != nil {
+		return errors.Trace(err)
+	}
+	if digest == "" {
+		return nil
+	}
+	got, err := utils.FileSha256(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if want := strings.ToLower(strings.TrimPrefix(digest, "sha256:")); got != want {
+		return errors.Errorf("digest mismatch (got: %s, want: %s)", got, want)
+	}
+	return nil
+}
+
+// Upload uploads a chart to the repo and adds it to its index
+func (r *Repo) Upload(file string, metadata *chart.Metadata) error {
+	if err := r.putFile(chartKey(metadata.Name, metadata.Version), file, "application/gzip"); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(r.updateIndex(metadata.Name, metadata.Version, file))
+}
+
+// putFile stores a local file
+func (r *Repo) putFile(key, file, contentType string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	klog.V(4).Infof("Storing %q in %q", key, r.store)
+	return errors.Annotatef(r.store.Put(key, f, st.Size(), contentType), "storing %q", key)
+}
+
+// FetchProvenance returns the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	key, err := r.objectKey(name, version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	rc, err := r.store.Get(key + ".prov")
+	if errors.IsNotFound(err) {
+		return nil, errors.NotFoundf("provenance file for %s-%s", name, version)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "fetching %s-%s provenance file", name, version)
+	}
+	defer rc.Close()
+	prov, err := ioutil.ReadAll(rc)
+	return prov, errors.Trace(err)
+}
+
+// UploadProvenance stores the provenance file of a chart next to it
+func (r *Repo) UploadProvenance(_ string, prov []byte, metadata *chart.Metadata) error {
+	key := chartKey(metadata.Name, metadata.Version) + ".prov"
+	return errors.Annotatef(r.store.Put(key, bytes.NewReader(prov), int64(len(prov)), "text/plain"), "storing %q", key)
+}
+
+// Delete removes a chart and its provenance file from the repo and its index.
+// The index is updated first, so it never points at a missing package.
+func (r *Repo) Delete(name string, version string) error {
+	if err := r.updateIndex(name, version, ""); err != nil {
+		return errors.Trace(err)
+	}
+	key := chartKey(name, version)
+	for _, k := range []string{key, key + ".prov"} {
+		if err := r.store.Delete(k); err != nil {
+			return errors.Annotatef(err, "removing %q", k)
+		}
+	}
+	return nil
+}
+
+// Reload reloads the index
+func (r *Repo) Reload() error {
+	index, err := r.loadIndex()
+	if err != nil {
+		return errors.Annotatef(err, "reloading %q chart repo", r.store)
+	}
+	r.setIndex(index)
+	return nil
+}
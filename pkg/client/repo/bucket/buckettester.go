@@ -0,0 +1,92 @@
+package bucket
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/cache/cachedisk"
+)
+
+// testChart is the chart package pushed by the conformance tests, relative to
+// the packages of the clients in pkg/client/repo
+const testChart = "../../../../testdata/apache-7.3.15.tgz"
+
+// CheckConformance checks a client of a bucket repository against a fake
+// service of its store, starting with an empty bucket. newRepo creates a
+// client of the repository with the given cache, and stored returns the
+// objects of the fake service, given their key relative to the root of the
+// repository. It returns the cache of the clients.
+func CheckConformance(t *testing.T, url string, newRepo func(c cache.Cacher) (*Repo, error), stored func(key string) ([]byte, bool)) cache.Cacher {
+	t.Helper()
+	c, err := cachedisk.New(filepath.Join(t.TempDir(), "cache"), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A bucket without index is an empty repository
+	r, err := newRepo(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := r.List(); len(names) != 0 {
+		t.Errorf("got: %v, want an empty repository", names)
+	}
+
+	metadata := &chart.Metadata{Name: "apache", Version: "7.3.15"}
+	if err := r.Upload(testChart, metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UploadProvenance(testChart, []byte("prov"), metadata); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"apache-7.3.15.tgz", "apache-7.3.15.tgz.prov", IndexFile} {
+		if _, ok := stored(key); !ok {
+			t.Errorf("%q was not stored", key)
+		}
+	}
+	if index, _ := stored(IndexFile); !strings.Contains(string(index), "- apache-7.3.15.tgz") {
+		t.Errorf("got index:\n%s\nwant a relative apache-7.3.15.tgz URL", index)
+	}
+
+	// A new client reads the index written by the first one
+	if r, err = newRepo(c); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.Has("apache", "7.3.15"); err != nil || !ok {
+		t.Errorf("got: %v, %v, want apache-7.3.15 in the repository", ok, err)
+	}
+	if err := c.Invalidate("apache-7.3.15.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	fetched, err := r.Fetch("apache", "7.3.15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile(testChart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ioutil.ReadFile(fetched); err != nil || string(got) != string(want) {
+		t.Errorf("fetched chart differs from the uploaded one: %v", err)
+	}
+	if prov, err := r.FetchProvenance("apache", "7.3.15"); err != nil || string(prov) != "prov" {
+		t.Errorf("got: %q, %v, want the uploaded provenance file", prov, err)
+	}
+
+	if err := r.Delete("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r.Has("apache", "7.3.15"); ok {
+		t.Errorf("apache-7.3.15 was not deleted")
+	}
+	for _, key := range []string{"apache-7.3.15.tgz", "apache-7.3.15.tgz.prov"} {
+		if _, ok := stored(key); ok {
+			t.Errorf("%q was not deleted", key)
+		}
+	}
+	return c
+}
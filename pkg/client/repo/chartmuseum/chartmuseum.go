@@ -2,8 +2,8 @@ package chartmuseum
 
 import (
 	"bytes"
+	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
@@ -19,6 +19,7 @@ import (
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
 )
 
 // Repo allows to operate a chart repository.
@@ -27,6 +28,8 @@ type Repo struct {
 	username string
 	password string
 	insecure bool
+	// force overwrites the chart versions that already exist
+	force bool
 
 	helm *helmclassic.Repo
 
@@ -40,12 +43,22 @@ func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
 		return nil, errors.Trace(err)
 	}
 
-	return NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure)
+	mirrors, err := helmclassic.ParseMirrors(repo.GetMirrors())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	r, err := NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, mirrors...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r.force = repo.GetForceUpload()
+	return r, nil
 }
 
-// NewRaw creates a Repo object.
-func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool) (*Repo, error) {
-	helm, err := helmclassic.NewRaw(u, user, pass, c, insecure)
+// NewRaw creates a Repo object. Mirrors are only used to read from the repo.
+func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool, mirrors ...*url.URL) (*Repo, error) {
+	helm, err := helmclassic.NewRaw(u, user, pass, c, insecure, mirrors...)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -57,79 +70,185 @@ func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool)
 func (r *Repo) GetUploadURL() string {
 	u := *r.url
 	u.Path += "/api/charts"
+	if r.force {
+		u.RawQuery = "force"
+	}
+	return u.String()
+}
+
+// GetStaticURL returns the URL a chart package is served from
+func (r *Repo) GetStaticURL(file string) string {
+	u := *r.url
+	u.Path += "/charts/" + filepath.Base(file)
 	return u.String()
 }
 
 // Upload uploads a chart to the repo.
 func (r *Repo) Upload(file string, _ *chart.Metadata) error {
+	return r.upload(file, nil)
+}
+
+// UploadWithProvenance uploads a chart and its provenance file to the repo in
+// a single request
+func (r *Repo) UploadWithProvenance(file string, prov []byte, _ *chart.Metadata) error {
+	return r.upload(file, prov)
+}
+
+// upload uploads a chart, and its provenance file if any, through the
+// ChartMuseum API.
+//
+// Charts that already exist with the same content are not uploaded again, so
+// interrupted syncs can be resumed. The charts are uploaded to the static path
+// if the API is disabled.
+func (r *Repo) upload(file string, prov []byte) error {
 	f, err := os.Open(file)
 	if err != nil {
 		return errors.Trace(err)
 	}
 	defer f.Close()
 
-	body := &bytes.Buffer{}
-	mpw := multipart.NewWriter(body)
-	cw, err := mpw.CreateFormFile("chart", file)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
 	// Invalidate cache to avoid inconsistency between an old cache result and
 	// the chart repo
 	if err := r.cache.Invalidate(filepath.Base(file)); err != nil {
 		return errors.Trace(err)
 	}
+	if err := r.cache.Store(f, filepath.Base(file)); err != nil {
+		return errors.Trace(err)
+	}
 
-	// Write file to the multipart and cache writers at the same time.
-	cachew, err := r.cache.Writer(filepath.Base(file))
+	// The chart is streamed from disk so big charts are not kept in memory
+	parts := []utils.MultipartFile{{Field: "chart", Name: file, Path: file}}
+	if prov != nil {
+		parts = append(parts, utils.MultipartFile{Field: "prov", Name: file + ".prov", Data: prov})
+	}
+	body, contentType, size, err := utils.NewMultipartBody(parts...)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	defer cachew.Close()
 
-	w := io.MultiWriter(cw, cachew)
-	_, err = io.Copy(w, f)
+	u := r.GetUploadURL()
+	req, err := http.NewRequest("POST", u, nil)
 	if err != nil {
 		return errors.Trace(err)
 	}
-
-	contentType := mpw.FormDataContentType()
-	if err := mpw.Close(); err != nil {
+	if req.Body, err = body(); err != nil {
 		return errors.Trace(err)
 	}
+	req.GetBody = body
+	req.ContentLength = size
+	req.Header.Add("content-type", contentType)
 
-	u := r.GetUploadURL()
-	req, err := http.NewRequest("POST", u, body)
+	reqID := utils.EncodeSha1(u + file)
+	klog.V(4).Infof("[%s] POST %q", reqID, u)
+	res, err := r.do(req)
+	if err != nil {
+		return errors.Annotatef(err, "uploading %q chart", file)
+	}
+	defer res.Body.Close()
+
+	bodyStr := utils.HTTPResponseBody(res)
+	klog.V(4).Infof("[%s] HTTP Status: %s, Resp: %v", reqID, res.Status, bodyStr)
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode <= 299:
+		return nil
+	case res.StatusCode == http.StatusConflict:
+		return r.checkExisting(file, prov)
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusMethodNotAllowed:
+		klog.Warningf("ChartMuseum API of %q is not available, uploading %q chart to the static path", r.url, file)
+		return r.uploadStatic(file, prov)
+	default:
+		return errors.Errorf("unable to upload %q chart, got HTTP Status: %s, Resp: %v", file, res.Status, bodyStr)
+	}
+}
+
+// checkExisting checks that a chart the repo refused because it already
+// exists has the same content as the local one
+func (r *Repo) checkExisting(file string, prov []byte) error {
+	want, err := utils.FileSha256(file)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	req.Header.Add("content-type", contentType)
-	if r.username != "" && r.password != "" {
-		req.SetBasicAuth(r.username, r.password)
+	got, err := utils.RemoteSha256(r.GetStaticURL(file), r.do)
+	if err != nil {
+		return errors.Annotatef(err, "fetching existing %q chart", file)
+	}
+	if got != want {
+		return errors.Errorf("%q chart already exists with a different content, enable forceUpload to overwrite it", filepath.Base(file))
 	}
 
-	reqID := utils.EncodeSha1(u + file)
-	klog.V(4).Infof("[%s] POST %q", reqID, u)
-	client := utils.DefaultClient
-	if r.insecure {
-		client = utils.InsecureClient
+	klog.V(3).Infof("%q chart already exists with the same content", filepath.Base(file))
+	if prov != nil {
+		return errors.Trace(r.UploadProvenance(file, prov, nil))
 	}
-	res, err := client.Do(req)
+	return nil
+}
+
+// uploadStatic uploads a chart, and its provenance file if any, to the path
+// they are served from
+func (r *Repo) uploadStatic(file string, prov []byte) error {
+	f, err := os.Open(file)
 	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := r.put(r.GetStaticURL(file), f, fi.Size()); err != nil {
 		return errors.Annotatef(err, "uploading %q chart", file)
 	}
+	if prov != nil {
+		if err := r.put(r.GetStaticURL(file)+".prov", bytes.NewReader(prov), int64(len(prov))); err != nil {
+			return errors.Annotatef(err, "uploading %q provenance file", file)
+		}
+	}
+	return nil
+}
+
+// put uploads a file with a PUT request
+func (r *Repo) put(u string, body io.Reader, size int64) error {
+	req, err := http.NewRequest("PUT", u, body)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.ContentLength = size
+	req.Header.Add("content-type", "application/octet-stream")
+
+	reqID := utils.EncodeSha1(u)
+	klog.V(4).Infof("[%s] PUT %q", reqID, u)
+	res, err := r.do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	defer res.Body.Close()
 
 	bodyStr := utils.HTTPResponseBody(res)
 	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
-		return errors.Errorf("unable to upload %q chart, got HTTP Status: %s, Resp: %v", file, res.Status, bodyStr)
+		return errors.Errorf("got HTTP Status: %s, Resp: %v", res.Status, bodyStr)
 	}
 	klog.V(4).Infof("[%s] HTTP Status: %s, Resp: %v", reqID, res.Status, bodyStr)
-
 	return nil
 }
 
+// do sends an authenticated request to the repo
+func (r *Repo) do(req *http.Request) (*http.Response, error) {
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	return client.Do(req)
+}
+
+// ChartDigest downloads a stored chart from its static path and returns its
+// SHA256 digest
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	return utils.RemoteSha256(r.GetStaticURL(fmt.Sprintf("%s-%s.tgz", name, version)), r.do)
+}
+
 // Fetch downloads a chart from the repo
 func (r *Repo) Fetch(name string, version string) (string, error) {
 	return r.helm.Fetch(name, version)
@@ -155,6 +274,108 @@ func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails
 	return r.helm.GetChartDetails(name, version)
 }
 
+// GetIndexEntry returns the index entry of a chart, with absolute URLs
+func (r *Repo) GetIndexEntry(name string, version string) (*repo.ChartVersion, error) {
+	return r.helm.GetIndexEntry(name, version)
+}
+
+// GetProvenanceUploadURL returns the URL to upload a provenance file
+func (r *Repo) GetProvenanceUploadURL() string {
+	u := *r.url
+	u.Path += "/api/prov"
+	if r.force {
+		u.RawQuery = "force"
+	}
+	return u.String()
+}
+
+// UploadProvenance uploads the provenance file of a chart to the repo
+func (r *Repo) UploadProvenance(file string, prov []byte, _ *chart.Metadata) error {
+	u := r.GetProvenanceUploadURL()
+	req, err := http.NewRequest("POST", u, bytes.NewReader(prov))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Add("content-type", "application/octet-stream")
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	reqID := utils.EncodeSha1(u + file + ".prov")
+	klog.V(4).Infof("[%s] POST %q", reqID, u)
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Annotatef(err, "uploading %q provenance file", file)
+	}
+	defer res.Body.Close()
+
+	bodyStr := utils.HTTPResponseBody(res)
+	if res.StatusCode == http.StatusConflict {
+		klog.Warningf("%q chart already has a provenance file, keeping it", filepath.Base(file))
+		return nil
+	}
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		return errors.Errorf("unable to upload %q provenance file, got HTTP Status: %s, Resp: %v", file, res.Status, bodyStr)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s, Resp: %v", reqID, res.Status, bodyStr)
+
+	return nil
+}
+
+// GetDeleteURL returns the URL to delete a chart
+func (r *Repo) GetDeleteURL(name string, version string) string {
+	u := *r.url
+	u.Path += fmt.Sprintf("/api/charts/%s/%s", name, version)
+	return u.String()
+}
+
+// Delete removes a chart from the repo
+func (r *Repo) Delete(name string, version string) error {
+	u := r.GetDeleteURL(name, version)
+	req, err := http.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	reqID := utils.EncodeSha1(u)
+	klog.V(4).Infof("[%s] DELETE %q", reqID, u)
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Annotatef(err, "deleting %s-%s chart", name, version)
+	}
+	defer res.Body.Close()
+
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok && res.StatusCode != http.StatusNotFound {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to delete %s-%s chart, got HTTP Status: %s, Resp: %v", name, version, res.Status, bodyStr)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+
+	// Invalidate cache to avoid inconsistency with the chart repo
+	return errors.Trace(r.cache.Invalidate(fmt.Sprintf("%s-%s.tgz", name, version)))
+}
+
+// FetchProvenance fetches the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	return r.helm.FetchProvenance(name, version)
+}
+
+// InvalidIndexEntries returns the index entries that could not be loaded
+func (r *Repo) InvalidIndexEntries() []string {
+	return r.helm.InvalidIndexEntries()
+}
+
 // Reload reloads the index
 func (r *Repo) Reload() error {
 	return r.helm.Reload()
@@ -1,10 +1,12 @@
 package chartmuseum_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -148,7 +150,7 @@ func TestGetChartDetails(t *testing.T) {
 	}
 	want := types.ChartDetails{
 		PublishedAt: time.Now().Time,
-		Digest:      "d47d94c52aff1fbb92235f0753c691072db1d19ec43fa9a438ab6736dfa7f867",
+		Digest:      "ea04778b1a4e266763d330e227a560e5c5eed8193bf9cd4c56ae2c425e05e354",
 	}
 	got, err := c.GetChartDetails("etcd", "4.8.0")
 	if err != nil {
@@ -208,3 +210,135 @@ func TestUpload(t *testing.T) {
 		t.Errorf("got: %q, want: %q", got, want)
 	}
 }
+
+// fakeUploadServer records the uploads to a ChartMuseum server
+type fakeUploadServer struct {
+	t *testing.T
+	// uploadStatus is the status returned by the upload API
+	uploadStatus int
+	// existing is the content of the served chart packages
+	existing []byte
+
+	parts map[string][]byte
+	query string
+	puts  []string
+}
+
+func (f *fakeUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/index.yaml":
+		w.Write([]byte("apiVersion: v1\nentries: {}\n"))
+	case r.Method == "POST" && r.URL.Path == "/api/charts":
+		f.query = r.URL.RawQuery
+		if f.uploadStatus != 0 {
+			w.WriteHeader(f.uploadStatus)
+			return
+		}
+		mr, err := r.MultipartReader()
+		if err != nil {
+			f.t.Fatal(err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := ioutil.ReadAll(part)
+			f.parts[part.FormName()] = data
+		}
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/charts/"):
+		w.Write(f.existing)
+	case r.Method == "POST" && r.URL.Path == "/api/prov":
+		data, _ := ioutil.ReadAll(r.Body)
+		f.parts["prov"] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/charts/"):
+		f.puts = append(f.puts, r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func newUploadTest(t *testing.T, f *fakeUploadServer, force bool) *chartmuseum.Repo {
+	t.Helper()
+	f.t = t
+	f.parts = map[string][]byte{}
+	s := httptest.NewServer(f)
+	t.Cleanup(s.Close)
+
+	cacheDir, err := ioutil.TempDir("", "client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(cacheDir) })
+	cache, err := cachedisk.New(cacheDir, s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := chartmuseum.New(&api.Repo{Kind: api.Kind_CHARTMUSEUM, Url: s.URL, ForceUpload: force}, cache, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestUploadWithProvenance(t *testing.T) {
+	file := "../../../../testdata/apache-7.3.15.tgz"
+	chart, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeUploadServer{}
+	c := newUploadTest(t, f, true)
+
+	if err := c.UploadWithProvenance(file, []byte("signature"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.query, "force"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if !bytes.Equal(f.parts["chart"], chart) {
+		t.Errorf("the chart part does not match the chart")
+	}
+	if got, want := string(f.parts["prov"]), "signature"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestUploadExisting(t *testing.T) {
+	file := "../../../../testdata/apache-7.3.15.tgz"
+	chart, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeUploadServer{uploadStatus: http.StatusConflict, existing: chart}
+	c := newUploadTest(t, f, false)
+	if err := c.UploadWithProvenance(file, []byte("signature"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(f.parts["prov"]), "signature"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	f = &fakeUploadServer{uploadStatus: http.StatusConflict, existing: []byte("other")}
+	c = newUploadTest(t, f, false)
+	if err := c.Upload(file, nil); err == nil || !strings.Contains(err.Error(), "different content") {
+		t.Errorf("got: %v, want a different content error", err)
+	}
+}
+
+func TestUploadStatic(t *testing.T) {
+	f := &fakeUploadServer{uploadStatus: http.StatusNotFound}
+	c := newUploadTest(t, f, false)
+	if err := c.UploadWithProvenance("../../../../testdata/apache-7.3.15.tgz", []byte("signature"), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/charts/apache-7.3.15.tgz", "/charts/apache-7.3.15.tgz.prov"}
+	if !reflect.DeepEqual(f.puts, want) {
+		t.Errorf("got: %v, want: %v", f.puts, want)
+	}
+}
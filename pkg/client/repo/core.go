@@ -7,12 +7,19 @@ import (
 
 	"github.com/bitnami-labs/charts-syncer/api"
 	"github.com/bitnami-labs/charts-syncer/internal/cache/cachedisk"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/client"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/azblob"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/chartmuseum"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/gcs"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/git"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/harbor"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/helmclassic"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/jfrog"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/local"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/nexus"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/oci"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/s3"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 )
 
@@ -37,6 +44,11 @@ func NewClient(repo *api.Repo, opts ...types.Option) (client.ChartsReaderWriter,
 	if err != nil {
 		return nil, errors.Annotatef(err, "allocating cache")
 	}
+	if l := repo.GetRateLimit(); l != nil {
+		if err := utils.LimitRate(repo.GetUrl(), l.GetRequestsPerSecond(), int(l.GetBurst())); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 
 	switch repo.Kind {
 	case api.Kind_HELM:
@@ -45,11 +57,37 @@ func NewClient(repo *api.Repo, opts ...types.Option) (client.ChartsReaderWriter,
 		return chartmuseum.New(repo, c, insecure)
 	case api.Kind_HARBOR:
 		return harbor.New(repo, c, insecure)
+	case api.Kind_ARTIFACTORY:
+		return jfrog.New(repo, c, insecure)
+	case api.Kind_NEXUS:
+		return nexus.New(repo, c, insecure)
 	case api.Kind_OCI:
 		return oci.New(repo, c, insecure)
+	case api.Kind_S3:
+		return s3.New(repo, c, insecure)
+	case api.Kind_GCS:
+		return gcs.New(repo, c, insecure)
+	case api.Kind_AZBLOB:
+		return azblob.New(repo, c, insecure)
+	case api.Kind_GIT:
+		return git.New(repo, insecure)
 	case api.Kind_LOCAL:
-		return local.New(repo.Path)
+		var opts []local.Option
+		if repo.GetGenerateIndex() {
+			opts = append(opts, local.WithIndex(repo.GetUrl()))
+		}
+		return local.New(repo.Path, opts...)
 	default:
 		return nil, errors.Errorf("unsupported repo kind %q", repo.Kind)
 	}
 }
+
+// NewReader returns a client that can only read from the repository, to be
+// used for source repositories.
+func NewReader(repo *api.Repo, opts ...types.Option) (client.ChartsReader, error) {
+	c, err := NewClient(repo, opts...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return client.ReadOnly(c), nil
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/oci"
 )
 
@@ -102,3 +103,22 @@ func TestNewClient(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReader(t *testing.T) {
+	c, err := NewReader(&api.Repo{Kind: api.Kind_LOCAL, Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.(client.ChartsWriter); ok {
+		t.Errorf("reader client can upload charts")
+	}
+	if _, ok := c.(client.ChartsDeleter); ok {
+		t.Errorf("reader client can delete charts")
+	}
+	if _, ok := c.(client.ProvenanceWriter); ok {
+		t.Errorf("reader client can upload provenance files")
+	}
+	if _, err := c.List(); err != nil {
+		t.Errorf("unexpected error listing charts: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/mitchellh/go-homedir"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+const (
+	// storageScope is the OAuth2 scope of the access tokens
+	storageScope = "https://www.googleapis.com/auth/devstorage.read_write"
+	// defaultTokenURL is the token endpoint of the Google OAuth2 server
+	defaultTokenURL = "https://oauth2.googleapis.com/token"
+	// defaultMetadataHost is the address of the metadata server of Compute
+	// Engine, GKE and Cloud Run
+	defaultMetadataHost = "169.254.169.254"
+)
+
+// metadataClient reaches the metadata server directly, failing fast outside
+// of Google Cloud
+var metadataClient = &http.Client{Transport: &http.Transport{}, Timeout: 5 * time.Second}
+
+// credentialsFile is a service account key or the user credentials written by
+// `gcloud auth application-default login`
+type credentialsFile struct {
+	Type string `json:"type"`
+
+	// Service account keys
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+
+	// User credentials
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// defaultCredentials returns the token source of the Application Default
+// Credentials and describes them. They are looked up, in order:
+//
+//   - in the file pointed by the GOOGLE_APPLICATION_CREDENTIALS env var
+//   - in the well-known file of the gcloud CLI
+//   - from the metadata server, when running in Google Cloud
+func defaultCredentials(client *http.Client) (oauth2.TokenSource, string, error) {
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	if file := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); file != "" {
+		tokens, desc, err := fileCredentials(ctx, file)
+		if err != nil {
+			return nil, "", errors.Annotate(err, "loading the credentials pointed by GOOGLE_APPLICATION_CREDENTIALS")
+		}
+		return tokens, desc + " from GOOGLE_APPLICATION_CREDENTIALS", nil
+	}
+	if file := wellKnownFile(); file != "" {
+		if _, err := os.Stat(file); err == nil {
+			tokens, desc, err := fileCredentials(ctx, file)
+			if err != nil {
+				return nil, "", errors.Trace(err)
+			}
+			return tokens, fmt.Sprintf("%s from %q", desc, file), nil
+		}
+	}
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = defaultMetadataHost
+	}
+	return oauth2.ReuseTokenSource(nil, &metadataTokens{host: host}), "service account of the metadata server", nil
+}
+
+// wellKnownFile returns the path of the Application Default Credentials
+// written by the gcloud CLI
+func wellKnownFile() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, "application_default_credentials.json")
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", "application_default_credentials.json")
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+// fileCredentials returns the token source of a credentials file
+func fileCredentials(ctx context.Context, file string) (oauth2.TokenSource, string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	var f credentialsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, "", errors.Annotatef(err, "parsing %q", file)
+	}
+	tokenURL := f.TokenURI
+	if tokenURL == "" {
+		tokenURL = defaultTokenURL
+	}
+	switch f.Type {
+	case "service_account":
+		c := &jwt.Config{
+			Email:        f.ClientEmail,
+			PrivateKey:   []byte(f.PrivateKey),
+			PrivateKeyID: f.PrivateKeyID,
+			TokenURL:     tokenURL,
+			Scopes:       []string{storageScope},
+		}
+		return c.TokenSource(ctx), fmt.Sprintf("service account %q", f.ClientEmail), nil
+	case "authorized_user":
+		c := &oauth2.Config{
+			ClientID:     f.ClientID,
+			ClientSecret: f.ClientSecret,
+			Endpoint:     oauth2.Endpoint{TokenURL: tokenURL, AuthStyle: oauth2.AuthStyleInParams},
+			Scopes:       []string{storageScope},
+		}
+		return c.TokenSource(ctx, &oauth2.Token{RefreshToken: f.RefreshToken}), "user credentials", nil
+	default:
+		return nil, "", errors.NotSupportedf("%q credentials in %q", f.Type, file)
+	}
+}
+
+// metadataTokens fetches the access tokens of the service account attached to
+// the workload from the metadata server
+type metadataTokens struct {
+	host string
+}
+
+// Token implements oauth2.TokenSource
+func (m *metadataTokens) Token() (*oauth2.Token, error) {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     m.host,
+		Path:     "/computeMetadata/v1/instance/service-accounts/default/token",
+		RawQuery: url.Values{"scopes": {storageScope}}.Encode(),
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	res, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, errors.Annotate(err, "no Application Default Credentials found outside of Google Cloud")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to fetch an access token from the metadata server, got HTTP Status: %s, Resp: %v", res.Status, utils.HTTPResponseBody(res))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return nil, errors.Annotate(err, "parsing the access token of the metadata server")
+	}
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}, nil
+}
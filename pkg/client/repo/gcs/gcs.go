@@ -0,0 +1,197 @@
+// Package gcs implements static Helm repositories stored in Google Cloud
+// Storage buckets, with the chart packages and their index.yaml file at the
+// root of the repository.
+package gcs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/juju/errors"
+	"golang.org/x/oauth2"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+)
+
+// defaultEndpoint is the endpoint of the XML API of Cloud Storage
+const defaultEndpoint = "https://storage.googleapis.com"
+
+// Repo allows to operate a chart repository stored in a GCS bucket.
+type Repo struct {
+	*bucket.Repo
+}
+
+// New creates a Repo object from an api.Repo object. The URL of the
+// repository is like gs://bucket/prefix.
+func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
+	store, err := NewStore(repo, insecure)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r, err := bucket.New(store, c)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Repo{Repo: r}, nil
+}
+
+// Store reads and writes the objects of a repository in a GCS bucket through
+// the XML API of Cloud Storage, authenticating the requests with OAuth2 access
+// tokens.
+type Store struct {
+	bucket   string
+	prefix   string
+	endpoint *url.URL
+
+	tokens oauth2.TokenSource
+	// credentials describes where the tokens come from
+	credentials string
+
+	client *http.Client
+}
+
+// NewStore returns the store of a GCS repository. The requests are
+// authenticated with the Application Default Credentials.
+func NewStore(repo *api.Repo, insecure bool) (*Store, error) {
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if u.Scheme != "gs" || u.Host == "" {
+		return nil, errors.NotValidf("GCS repository URL %q, it should be like gs://bucket/prefix", repo.GetUrl())
+	}
+	s := &Store{
+		bucket: u.Host,
+		prefix: strings.Trim(u.Path, "/"),
+		client: utils.DefaultClient,
+	}
+	if insecure {
+		s.client = utils.InsecureClient
+	}
+	endpoint := defaultEndpoint
+	if e := repo.GetGcs().GetEndpoint(); e != "" {
+		endpoint = e
+	}
+	if s.endpoint, err = url.Parse(endpoint); err != nil {
+		return nil, errors.Annotatef(err, "parsing GCS endpoint %q", endpoint)
+	}
+	if s.tokens, s.credentials, err = defaultCredentials(s.client); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return s, nil
+}
+
+// Credentials describes the credentials the requests are authenticated with
+func (s *Store) Credentials() string {
+	return s.credentials
+}
+
+// String returns the URL of the repository
+func (s *Store) String() string {
+	return strings.TrimSuffix(fmt.Sprintf("gs://%s/%s", s.bucket, s.prefix), "/")
+}
+
+// objectURL returns the URL of an object
+func (s *Store) objectURL(key string) *url.URL {
+	p := key
+	if s.prefix != "" {
+		p = s.prefix + "/" + key
+	}
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + p
+	return &u
+}
+
+// do sends an authenticated request for an object
+func (s *Store) do(method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	token, err := s.tokens.Token()
+	if err != nil {
+		return nil, errors.Annotatef(err, "getting an access token for the %s", s.credentials)
+	}
+	token.SetAuthHeader(req)
+
+	reqID := utils.EncodeSha1(req.URL.String())
+	klog.V(4).Infof("[%s] %s %q", reqID, method, req.URL)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+	return res, nil
+}
+
+// statusError returns the error of a failed request for an object
+func statusError(res *http.Response, method, key string) error {
+	body := utils.HTTPResponseBody(res)
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return errors.NotFoundf("%q object", key)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errors.Forbiddenf("%s %q: got HTTP Status: %s, Resp: %v", method, key, res.Status, body)
+	default:
+		return errors.Errorf("unable to %s %q, got HTTP Status: %s, Resp: %v", method, key, res.Status, body)
+	}
+}
+
+// Get returns the content of an object
+func (s *Store) Get(key string) (io.ReadCloser, error) {
+	res, err := s.do(http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, statusError(res, http.MethodGet, key)
+	}
+	return res.Body, nil
+}
+
+// Put creates or replaces an object. The index is served with caching
+// disabled, so clients see the new chart versions right away.
+func (s *Store) Put(key string, r io.Reader, size int64, contentType string) error {
+	header := http.Header{"Content-Type": {contentType}}
+	if key == bucket.IndexFile {
+		header.Set("Cache-Control", "no-cache, max-age=0")
+	}
+	res, err := s.do(http.MethodPut, key, r, size, header)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return statusError(res, http.MethodPut, key)
+	}
+	return nil
+}
+
+// Delete removes an object
+func (s *Store) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return statusError(res, http.MethodDelete, key)
+	}
+}
@@ -0,0 +1,228 @@
+package gcs
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+)
+
+const testToken = "ya29.test"
+
+// fakeGCS is an in-memory Cloud Storage service, with the token endpoints of
+// the OAuth2 and metadata servers
+type fakeGCS struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	t       *testing.T
+}
+
+// object returns the objects under a prefix
+func (f *fakeGCS) object(prefix string) func(string) ([]byte, bool) {
+	return func(key string) ([]byte, bool) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		data, ok := f.objects[prefix+key]
+		return data, ok
+	}
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/token":
+		if err := r.ParseForm(); err != nil {
+			f.t.Error(err)
+		}
+		if gt := r.PostForm.Get("grant_type"); gt != "refresh_token" && gt != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			http.Error(w, "unsupported_grant_type", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer", "expires_in": 3600}`, testToken)
+		return
+	case "/computeMetadata/v1/instance/service-accounts/default/token":
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor header", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": %q, "token_type": "Bearer", "expires_in": 3600}`, testToken)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+testToken {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodPut:
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			f.t.Error(err)
+		}
+		f.objects[r.URL.Path] = data
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeCredentials writes a credentials file and returns its path
+func writeCredentials(t *testing.T, creds map[string]string) string {
+	data, err := json.Marshal(creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestDefaultCredentials(t *testing.T) {
+	srv := httptest.NewServer(&fakeGCS{t: t})
+	defer srv.Close()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The well-known file of the gcloud CLI does not exist
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", u.Host)
+
+	testCases := []struct {
+		desc  string
+		creds map[string]string
+		want  string
+	}{
+		{
+			desc: "service account",
+			creds: map[string]string{
+				"type":         "service_account",
+				"client_email": "syncer@my-project.iam.gserviceaccount.com",
+				"private_key":  string(keyPEM),
+				"token_uri":    srv.URL + "/token",
+			},
+			want: `service account "syncer@my-project.iam.gserviceaccount.com" from GOOGLE_APPLICATION_CREDENTIALS`,
+		},
+		{
+			desc: "user credentials",
+			creds: map[string]string{
+				"type":          "authorized_user",
+				"client_id":     "id",
+				"client_secret": "secret",
+				"refresh_token": "refresh",
+				"token_uri":     srv.URL + "/token",
+			},
+			want: "user credentials from GOOGLE_APPLICATION_CREDENTIALS",
+		},
+		{
+			desc: "metadata server",
+			want: "service account of the metadata server",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			file := ""
+			if tc.creds != nil {
+				file = writeCredentials(t, tc.creds)
+			}
+			t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", file)
+			tokens, desc, err := defaultCredentials(http.DefaultClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if desc != tc.want {
+				t.Errorf("got: %q, want: %q", desc, tc.want)
+			}
+			token, err := tokens.Token()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.AccessToken != testToken {
+				t.Errorf("got: %q access token, want: %q", token.AccessToken, testToken)
+			}
+		})
+	}
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", writeCredentials(t, map[string]string{"type": "external_account"}))
+	if _, _, err := defaultCredentials(http.DefaultClient); !errors.IsNotSupported(errors.Cause(err)) {
+		t.Errorf("got: %v, want a not supported error", err)
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	s, err := NewStore(&api.Repo{Kind: api.Kind_GCS, Url: "gs://my-bucket/charts/"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.objectURL("apache-7.3.15+mirror.1.tgz").String(), "https://storage.googleapis.com/my-bucket/charts/apache-7.3.15+mirror.1.tgz"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := s.String(), "gs://my-bucket/charts"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if _, err := NewStore(&api.Repo{Kind: api.Kind_GCS, Url: "https://my-bucket"}, false); !errors.IsNotValid(err) {
+		t.Errorf("got: %v, want a not valid error", err)
+	}
+}
+
+func TestRepo(t *testing.T) {
+	f := &fakeGCS{objects: map[string][]byte{}, t: t}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Requests are authenticated with the tokens of the metadata server
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", u.Host)
+
+	repo := &api.Repo{
+		Kind: api.Kind_GCS,
+		Url:  "gs://charts/stable",
+		Gcs:  &api.GCSOptions{Endpoint: srv.URL},
+	}
+	newRepo := func(c cache.Cacher) (*bucket.Repo, error) {
+		r, err := New(repo, c, false)
+		if err != nil {
+			return nil, err
+		}
+		return r.Repo, nil
+	}
+	bucket.CheckConformance(t, repo.GetUrl(), newRepo, f.object("/charts/stable/"))
+}
@@ -0,0 +1,355 @@
+// Package git implements static Helm repositories stored in a branch of a git
+// repository, like the ones served by GitHub Pages. The branch is cloned, and
+// every change of the charts and their index.yaml file is committed and pushed.
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/local"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+const (
+	defaultBranch      = "gh-pages"
+	defaultAuthorName  = "charts-syncer"
+	defaultAuthorEmail = "charts-syncer@localhost"
+
+	// gitTimeout is the maximum duration of a git command
+	gitTimeout = 5 * time.Minute
+	// maxPushAttempts is the number of times a change is pushed when the
+	// branch is updated concurrently
+	maxPushAttempts = 3
+)
+
+// Repo allows to operate a chart repository stored in a git branch. It is
+// safe for concurrent use, the changes are committed one at a time.
+type Repo struct {
+	remote string
+	branch string
+	// dir is the working tree of the clone, the repository is in its path
+	// subdirectory
+	dir      string
+	path     string
+	pagesURL string
+
+	authorName  string
+	authorEmail string
+	username    string
+	password    string
+	insecure    bool
+
+	// mu serializes the changes of the working tree and guards local
+	mu    sync.RWMutex
+	local *local.Repo
+}
+
+// New creates a Repo object from an api.Repo object, cloning its branch in a
+// temporary directory. The URL of the repository is the git remote.
+//
+// Basic auth credentials are sent to HTTP(S) remotes. Otherwise, git uses the
+// credentials of the environment, e.g. the SSH keys of the user.
+func New(repo *api.Repo, insecure bool) (*Repo, error) {
+	r := newRepo(repo, insecure)
+	dir, err := ioutil.TempDir("", "git")
+	if err != nil {
+		return nil, errors.Annotatef(err, "creating temporary dir")
+	}
+	r.dir = dir
+	if _, err := r.git("init", "-q"); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := r.sync(); err != nil {
+		return nil, errors.Annotatef(err, "cloning %q branch of %q", r.branch, r.remote)
+	}
+	return r, nil
+}
+
+// newRepo returns a Repo object without clone
+func newRepo(repo *api.Repo, insecure bool) *Repo {
+	opts := repo.GetGit()
+	r := &Repo{
+		remote:      repo.GetUrl(),
+		branch:      opts.GetBranch(),
+		path:        strings.Trim(opts.GetPath(), "/"),
+		pagesURL:    opts.GetPagesUrl(),
+		authorName:  opts.GetAuthorName(),
+		authorEmail: opts.GetAuthorEmail(),
+		username:    repo.GetAuth().GetUsername(),
+		password:    repo.GetAuth().GetPassword(),
+		insecure:    insecure,
+	}
+	if r.branch == "" {
+		r.branch = defaultBranch
+	}
+	if r.authorName == "" {
+		r.authorName = defaultAuthorName
+	}
+	if r.authorEmail == "" {
+		r.authorEmail = defaultAuthorEmail
+	}
+	return r
+}
+
+// HasBranch returns whether the branch of a repository exists in its remote,
+// without cloning it
+func HasBranch(repo *api.Repo, insecure bool) (bool, error) {
+	return newRepo(repo, insecure).hasBranch()
+}
+
+// hasBranch returns whether the branch exists in the remote
+func (r *Repo) hasBranch() (bool, error) {
+	out, err := r.git("ls-remote", "--heads", r.remote, "refs/heads/"+r.branch)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// sync resets the working tree to the remote branch, or to an empty branch if
+// it does not exist yet
+func (r *Repo) sync() error {
+	ok, err := r.hasBranch()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if ok {
+		if _, err := r.git("fetch", "-q", "--depth", "1", r.remote, "refs/heads/"+r.branch); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := r.git("checkout", "-q", "-f", "-B", r.branch, "FETCH_HEAD"); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := r.git("clean", "-q", "-f", "-d", "-x"); err != nil {
+			return errors.Trace(err)
+		}
+	} else {
+		klog.V(3).Infof("%q has no %q branch, it is created with the first chart", r.remote, r.branch)
+		for _, args := range [][]string{
+			{"symbolic-ref", "HEAD", "refs/heads/" + r.branch},
+			{"read-tree", "--empty"},
+			{"clean", "-q", "-f", "-d", "-x"},
+		} {
+			if _, err := r.git(args...); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	// The index is regenerated with the chart URLs of the repository
+	r.local, err = local.New(filepath.Join(r.dir, r.path), local.WithIndex(r.pagesURL))
+	return errors.Trace(err)
+}
+
+// publish applies a change to the working tree, then commits and pushes it.
+// If the branch was updated since it was fetched, the change is applied again
+// on top of it.
+func (r *Repo) publish(message string, change func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		out, err := r.commit(message, change)
+		if err == nil {
+			return nil
+		}
+		// The working tree must not keep a change that was not pushed
+		if serr := r.sync(); serr != nil {
+			klog.Warningf("unable to reset the clone of %q branch: %v", r.branch, serr)
+			return errors.Trace(err)
+		}
+		if attempt == maxPushAttempts || !strings.Contains(out, "[rejected]") {
+			return errors.Trace(err)
+		}
+		klog.V(3).Infof("%q branch was updated concurrently, applying %q again...", r.branch, message)
+	}
+}
+
+// commit applies a change to the working tree, then commits and pushes it. It
+// returns the output of the push.
+func (r *Repo) commit(message string, change func() error) (string, error) {
+	if err := change(); err != nil {
+		return "", errors.Trace(err)
+	}
+	pathspec := r.path
+	if pathspec == "" {
+		pathspec = "."
+	}
+	if _, err := r.git("add", "-A", "--", pathspec); err != nil {
+		return "", errors.Trace(err)
+	}
+	status, err := r.git("status", "--porcelain")
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if strings.TrimSpace(status) == "" {
+		klog.V(3).Infof("Nothing to commit in %q branch for %q", r.branch, message)
+		return "", nil
+	}
+	if _, err := r.git("commit", "-q", "-m", message); err != nil {
+		return "", errors.Trace(err)
+	}
+	out, err := r.git("push", "-q", r.remote, "HEAD:refs/heads/"+r.branch)
+	return out, errors.Annotatef(err, "pushing to %q branch", r.branch)
+}
+
+// git runs a git command in the working tree and returns its output
+func (r *Repo) git(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = r.env()
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	klog.V(4).Infof("Running git %s in %q", args[0], r.dir)
+	err := cmd.Run()
+	if out.Len() > 0 {
+		klog.V(4).Infof("git %s output:\n%s", args[0], out.String())
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return out.String(), errors.Errorf("git %s timed out after %s", args[0], gitTimeout)
+	}
+	if err != nil {
+		return out.String(), errors.Annotatef(err, "running git %s: %s", args[0], strings.TrimSpace(out.String()))
+	}
+	return out.String(), nil
+}
+
+// env returns the environment of the git commands. The credentials are passed
+// as configuration through the environment, so they are neither stored in
+// the clone nor visible in the command line.
+func (r *Repo) env() []string {
+	env := append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_AUTHOR_NAME="+r.authorName,
+		"GIT_AUTHOR_EMAIL="+r.authorEmail,
+		"GIT_COMMITTER_NAME="+r.authorName,
+		"GIT_COMMITTER_EMAIL="+r.authorEmail,
+	)
+	var config [][2]string
+	if r.username != "" || r.password != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(r.username + ":" + r.password))
+		config = append(config, [2]string{"http.extraHeader", "Authorization: Basic " + auth})
+	}
+	if r.insecure {
+		config = append(config, [2]string{"http.sslVerify", "false"})
+	}
+	if len(config) > 0 {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)))
+	}
+	for i, c := range config {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, c[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, c[1]))
+	}
+	return env
+}
+
+// List lists all chart names in the repo
+func (r *Repo) List() ([]string, error) {
+	return r.clone().List()
+}
+
+// ListChartVersions lists all versions of a chart
+func (r *Repo) ListChartVersions(name string) ([]string, error) {
+	return r.clone().ListChartVersions(name)
+}
+
+// ChartDigest returns the SHA256 digest of a chart committed to the branch
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	return r.clone().ChartDigest(name, version)
+}
+
+// Fetch returns the path of a chart in the clone
+func (r *Repo) Fetch(name string, version string) (string, error) {
+	return r.clone().Fetch(name, version)
+}
+
+// Has checks if a repo has a specific chart
+func (r *Repo) Has(name string, version string) (bool, error) {
+	return r.clone().Has(name, version)
+}
+
+// GetChartDetails returns the details of a chart
+func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	return r.clone().GetChartDetails(name, version)
+}
+
+// Upload commits a chart and the updated index to the branch
+func (r *Repo) Upload(file string, metadata *chart.Metadata) error {
+	return r.publish(fmt.Sprintf("Add %s-%s chart", metadata.Name, metadata.Version), func() error {
+		return r.local.Upload(file, metadata)
+	})
+}
+
+// UploadWithProvenance commits a chart, its provenance file and the updated
+// index to the branch at once
+func (r *Repo) UploadWithProvenance(file string, prov []byte, metadata *chart.Metadata) error {
+	return r.publish(fmt.Sprintf("Add %s-%s chart", metadata.Name, metadata.Version), func() error {
+		if err := r.local.Upload(file, metadata); err != nil {
+			return errors.Trace(err)
+		}
+		return r.local.UploadProvenance(file, prov, metadata)
+	})
+}
+
+// FetchProvenance returns the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	return r.clone().FetchProvenance(name, version)
+}
+
+// UploadProvenance commits the provenance file of a chart to the branch
+func (r *Repo) UploadProvenance(file string, prov []byte, metadata *chart.Metadata) error {
+	return r.publish(fmt.Sprintf("Add %s-%s provenance file", metadata.Name, metadata.Version), func() error {
+		return r.local.UploadProvenance(file, prov, metadata)
+	})
+}
+
+// UploadAttestation commits the attestation of a chart to the branch
+func (r *Repo) UploadAttestation(file string, attestation []byte, metadata *chart.Metadata) error {
+	return r.publish(fmt.Sprintf("Add %s-%s attestation", metadata.Name, metadata.Version), func() error {
+		return r.local.UploadAttestation(file, attestation, metadata)
+	})
+}
+
+// UploadSBOM commits the software bill of materials of a chart to the branch
+func (r *Repo) UploadSBOM(file string, sbom []byte, mediaType string, metadata *chart.Metadata) error {
+	return r.publish(fmt.Sprintf("Add %s-%s SBOM", metadata.Name, metadata.Version), func() error {
+		return r.local.UploadSBOM(file, sbom, mediaType, metadata)
+	})
+}
+
+// Delete removes a chart from the branch and its index
+func (r *Repo) Delete(name string, version string) error {
+	return r.publish(fmt.Sprintf("Remove %s-%s chart", name, version), func() error {
+		return r.local.Delete(name, version)
+	})
+}
+
+// clone returns the local repository of the working tree
+func (r *Repo) clone() *local.Repo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.local
+}
+
+// Reload fetches the branch again
+func (r *Repo) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Annotatef(r.sync(), "reloading %q branch of %q", r.branch, r.remote)
+}
@@ -0,0 +1,94 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	helmrepo "helm.sh/helm/v3/pkg/repo"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+)
+
+// run runs a git command in a directory
+func run(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Env, "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func TestRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	remote := filepath.Join(t.TempDir(), "charts.git")
+	run(t, "", "init", "-q", "--bare", remote)
+
+	repo := &api.Repo{
+		Kind: api.Kind_GIT,
+		Url:  "file://" + remote,
+		Git:  &api.GitOptions{Path: "charts", PagesUrl: "https://example.github.io/charts"},
+	}
+	// The branch is created with the first chart
+	r, err := New(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := HasBranch(repo, false); err != nil || ok {
+		t.Fatalf("got: %v, %v, want no gh-pages branch yet", ok, err)
+	}
+	tgz := "../../../../testdata/apache-7.3.15.tgz"
+	if err := r.UploadWithProvenance(tgz, []byte("signature"), &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another clone pushes a chart concurrently
+	other, err := New(repo, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Upload("../../../../testdata/kafka-10.3.3.tgz", &chart.Metadata{Name: "kafka", Version: "10.3.3"}); err != nil {
+		t.Fatal(err)
+	}
+	// The first clone applies its change on top of it
+	if err := r.Delete("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	}
+
+	log := run(t, remote, "log", "--format=%an %s", "gh-pages")
+	want := "charts-syncer Remove apache-7.3.15 chart\ncharts-syncer Add kafka-10.3.3 chart\ncharts-syncer Add apache-7.3.15 chart\n"
+	if log != want {
+		t.Errorf("got: %q commits, want: %q", log, want)
+	}
+	files := run(t, remote, "ls-tree", "-r", "--name-only", "gh-pages")
+	if want := "charts/index.yaml\ncharts/kafka-10.3.3.tgz\n"; files != want {
+		t.Errorf("got: %q files, want: %q", files, want)
+	}
+
+	// The index has absolute chart URLs
+	if err := r.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.Has("kafka", "10.3.3"); err != nil || !ok {
+		t.Errorf("got: %v, %v, want kafka-10.3.3 in the repository", ok, err)
+	}
+	index, err := helmrepo.LoadIndexFile(filepath.Join(r.dir, "charts", "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv, err := index.Get("kafka", "10.3.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.github.io/charts/kafka-10.3.3.tgz"; cv.URLs[0] != want {
+		t.Errorf("got: %q chart URL, want: %q", cv.URLs[0], want)
+	}
+}
@@ -1,9 +1,7 @@
 package harbor
 
 import (
-	"bytes"
-	"io"
-	"mime/multipart"
+	"fmt"
 	"net/http"
 	"net/url"
 	"os"
@@ -17,6 +15,7 @@ import (
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"github.com/juju/errors"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/klog"
 )
 
@@ -39,12 +38,17 @@ func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
 		return nil, errors.Trace(err)
 	}
 
-	return NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure)
+	mirrors, err := helmclassic.ParseMirrors(repo.GetMirrors())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, mirrors...)
 }
 
-// NewRaw creates a Repo object.
-func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool) (*Repo, error) {
-	helm, err := helmclassic.NewRaw(u, user, pass, c, insecure)
+// NewRaw creates a Repo object. Mirrors are only used to read from the repo.
+func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool, mirrors ...*url.URL) (*Repo, error) {
+	helm, err := helmclassic.NewRaw(u, user, pass, c, insecure, mirrors...)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -61,48 +65,53 @@ func (r *Repo) GetUploadURL() string {
 
 // Upload uploads a chart to the repo
 func (r *Repo) Upload(file string, _ *chart.Metadata) error {
+	return r.upload(file, nil)
+}
+
+// UploadWithProvenance uploads a chart and its provenance file to the repo in
+// a single request
+func (r *Repo) UploadWithProvenance(file string, prov []byte, _ *chart.Metadata) error {
+	return r.upload(file, prov)
+}
+
+// upload uploads a chart, and its provenance file if any, through the Harbor
+// chart API
+func (r *Repo) upload(file string, prov []byte) error {
 	f, err := os.Open(file)
 	if err != nil {
 		return errors.Trace(err)
 	}
 	defer f.Close()
 
-	body := &bytes.Buffer{}
-	mpw := multipart.NewWriter(body)
-	cw, err := mpw.CreateFormFile("chart", file)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
 	// Invalidate cache to avoid inconsistency between an old cache result and
 	// the chart repo
 	if err := r.cache.Invalidate(filepath.Base(file)); err != nil {
 		return errors.Trace(err)
 	}
-
-	// Write file to the multipart and cache writers at the same time.
-	cachew, err := r.cache.Writer(filepath.Base(file))
-	if err != nil {
+	if err := r.cache.Store(f, filepath.Base(file)); err != nil {
 		return errors.Trace(err)
 	}
-	defer cachew.Close()
 
-	w := io.MultiWriter(cw, cachew)
-	_, err = io.Copy(w, f)
-	if err != nil {
-		return errors.Trace(err)
+	// The chart is streamed from disk so big charts are not kept in memory
+	parts := []utils.MultipartFile{{Field: "chart", Name: file, Path: file}}
+	if prov != nil {
+		parts = append(parts, utils.MultipartFile{Field: "prov", Name: file + ".prov", Data: prov})
 	}
-
-	contentType := mpw.FormDataContentType()
-	if err := mpw.Close(); err != nil {
+	body, contentType, size, err := utils.NewMultipartBody(parts...)
+	if err != nil {
 		return errors.Trace(err)
 	}
 
 	u := r.GetUploadURL()
-	req, err := http.NewRequest("POST", u, body)
+	req, err := http.NewRequest("POST", u, nil)
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if req.Body, err = body(); err != nil {
+		return errors.Trace(err)
+	}
+	req.GetBody = body
+	req.ContentLength = size
 	req.Header.Add("content-type", contentType)
 	if r.username != "" && r.password != "" {
 		req.SetBasicAuth(r.username, r.password)
@@ -128,6 +137,25 @@ func (r *Repo) Upload(file string, _ *chart.Metadata) error {
 	return nil
 }
 
+// ChartDigest downloads a stored chart and returns its SHA256 digest
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	u := *r.url
+	u.Path += fmt.Sprintf("/charts/%s-%s.tgz", name, version)
+	return utils.RemoteSha256(u.String(), r.do)
+}
+
+// do sends an authenticated request
+func (r *Repo) do(req *http.Request) (*http.Response, error) {
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	return client.Do(req)
+}
+
 // Fetch downloads a chart from the repo
 func (r *Repo) Fetch(name string, version string) (string, error) {
 	return r.helm.Fetch(name, version)
@@ -153,6 +181,61 @@ func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails
 	return r.helm.GetChartDetails(name, version)
 }
 
+// GetIndexEntry returns the index entry of a chart, with absolute URLs
+func (r *Repo) GetIndexEntry(name string, version string) (*repo.ChartVersion, error) {
+	return r.helm.GetIndexEntry(name, version)
+}
+
+// GetDeleteURL returns the URL to delete a chart
+func (r *Repo) GetDeleteURL(name string, version string) string {
+	u := *r.url
+	u.Path = strings.Replace(u.Path, "/chartrepo/", "/api/chartrepo/", 1) + fmt.Sprintf("/charts/%s/%s", name, version)
+	return u.String()
+}
+
+// Delete removes a chart from the repo
+func (r *Repo) Delete(name string, version string) error {
+	u := r.GetDeleteURL(name, version)
+	req, err := http.NewRequest("DELETE", u, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	reqID := utils.EncodeSha1(u)
+	klog.V(4).Infof("[%s] DELETE %q", reqID, u)
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Annotatef(err, "deleting %s-%s chart", name, version)
+	}
+	defer res.Body.Close()
+
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok && res.StatusCode != http.StatusNotFound {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to delete %s-%s chart, got HTTP Status: %s, Resp: %v", name, version, res.Status, bodyStr)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+
+	// Invalidate cache to avoid inconsistency with the chart repo
+	return errors.Trace(r.cache.Invalidate(fmt.Sprintf("%s-%s.tgz", name, version)))
+}
+
+// FetchProvenance fetches the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	return r.helm.FetchProvenance(name, version)
+}
+
+// InvalidIndexEntries returns the index entries that could not be loaded
+func (r *Repo) InvalidIndexEntries() []string {
+	return r.helm.InvalidIndexEntries()
+}
+
 // Reload reloads the index
 func (r *Repo) Reload() error {
 	return r.helm.Reload()
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -149,7 +150,7 @@ func TestGetChartDetails(t *testing.T) {
 	}
 	want := types.ChartDetails{
 		PublishedAt: time.Now().Time,
-		Digest:      "d47d94c52aff1fbb92235f0753c691072db1d19ec43fa9a438ab6736dfa7f867",
+		Digest:      "ea04778b1a4e266763d330e227a560e5c5eed8193bf9cd4c56ae2c425e05e354",
 	}
 	got, err := c.GetChartDetails("etcd", "4.8.0")
 	if err != nil {
@@ -216,3 +217,53 @@ func TestUpload(t *testing.T) {
 		t.Errorf("got: %q, want: %q", got, want)
 	}
 }
+
+func TestUploadWithProvenance(t *testing.T) {
+	var parts map[string][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/chartrepo/library/index.yaml":
+			fmt.Fprint(w, "apiVersion: v1\nentries: {}\n")
+		case r.URL.Path == "/api/chartrepo/library/charts" && r.Method == "POST":
+			parts = map[string][]byte{}
+			for _, field := range []string{"chart", "prov"} {
+				f, _, err := r.FormFile(field)
+				if err != nil {
+					t.Errorf("reading %q part: %v", field, err)
+					continue
+				}
+				parts[field], _ = ioutil.ReadAll(f)
+				f.Close()
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	repo := &api.Repo{Kind: api.Kind_HARBOR, Url: srv.URL + "/chartrepo/library", Auth: harborRepo.GetAuth()}
+	cache, err := cachedisk.New(t.TempDir(), repo.GetUrl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := harbor.New(repo, cache, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := "../../../../testdata/apache-7.3.15.tgz"
+	if err := c.UploadWithProvenance(file, []byte("signature"), nil); err != nil {
+		t.Fatal(err)
+	}
+	chart, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(parts["chart"]) != string(chart) {
+		t.Errorf("the chart part does not match the chart")
+	}
+	if got, want := string(parts["prov"]), "signature"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
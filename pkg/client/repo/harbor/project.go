@@ -0,0 +1,279 @@
+package harbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+const (
+	// apiPath is the base path of the Harbor v2 API
+	apiPath = "/api/v2.0"
+	// retentionIDKey is the project metadata key referencing its retention
+	// policy
+	retentionIDKey = "retention_id"
+)
+
+// projectInfo is the subset of a Harbor project used by charts-syncer
+type projectInfo struct {
+	ID       int64             `json:"project_id"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// retentionPolicy is a Harbor tag retention policy
+type retentionPolicy struct {
+	ID        int64            `json:"id,omitempty"`
+	Algorithm string           `json:"algorithm"`
+	Rules     []retentionRule  `json:"rules"`
+	Trigger   retentionTrigger `json:"trigger"`
+	Scope     retentionScope   `json:"scope"`
+}
+
+type retentionRule struct {
+	Action         string                         `json:"action"`
+	Template       string                         `json:"template"`
+	Params         map[string]int32               `json:"params"`
+	TagSelectors   []retentionSelector            `json:"tag_selectors"`
+	ScopeSelectors map[string][]retentionSelector `json:"scope_selectors"`
+}
+
+type retentionSelector struct {
+	Kind       string `json:"kind"`
+	Decoration string `json:"decoration"`
+	Pattern    string `json:"pattern"`
+}
+
+type retentionTrigger struct {
+	Kind     string            `json:"kind"`
+	Settings map[string]string `json:"settings"`
+}
+
+type retentionScope struct {
+	Level string `json:"level"`
+	Ref   int64  `json:"ref"`
+}
+
+// projectClient operates a Harbor project through the Harbor API
+type projectClient struct {
+	base     *url.URL
+	name     string
+	username string
+	password string
+	insecure bool
+}
+
+// SetupProject prepares the Harbor project of a HARBOR or OCI repository
+// before charts are pushed to it. It creates the project if missing and
+// configured to, and sets its metadata and retention policy.
+func SetupProject(repo *api.Repo, config *api.Harbor, insecure bool) error {
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	name, err := ProjectName(repo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	p := &projectClient{
+		base:     &url.URL{Scheme: u.Scheme, Host: u.Host},
+		name:     name,
+		username: repo.GetAuth().GetUsername(),
+		password: repo.GetAuth().GetPassword(),
+		insecure: insecure,
+	}
+
+	info, err := p.get()
+	if errors.IsNotFound(err) {
+		if !config.GetCreateProject() {
+			return errors.Errorf("%q Harbor project does not exist", name)
+		}
+		klog.Infof("Creating %q Harbor project", name)
+		if err := p.create(config.GetMetadata()); err != nil {
+			return errors.Trace(err)
+		}
+		info, err = p.get()
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if len(config.GetMetadata()) > 0 {
+		klog.V(3).Infof("Setting %q Harbor project metadata", name)
+		if err := p.setMetadata(config.GetMetadata()); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(config.GetRetention()) > 0 {
+		klog.V(3).Infof("Setting %q Harbor project retention policy", name)
+		if err := p.setRetention(info, config); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// ProjectName returns the Harbor project of a HARBOR or OCI repository
+func ProjectName(repo *api.Repo) (string, error) {
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	path := strings.Trim(u.Path, "/")
+	switch repo.GetKind() {
+	case api.Kind_HARBOR:
+		// Harbor chart repositories are served at /chartrepo/<project>
+		if parts := strings.Split(path, "/"); len(parts) == 2 && parts[0] == "chartrepo" && parts[1] != "" {
+			return parts[1], nil
+		}
+	case api.Kind_OCI:
+		if project := strings.Split(path, "/")[0]; project != "" {
+			return project, nil
+		}
+	default:
+		return "", errors.Errorf("%q repositories are not served by Harbor", repo.GetKind())
+	}
+	return "", errors.Errorf("unable to find the Harbor project of %q", repo.GetUrl())
+}
+
+// do sends a request to the Harbor API. It returns a NotFound error if the
+// resource does not exist.
+func (p *projectClient) do(method, path string, in, out interface{}) error {
+	u := *p.base
+	u.Path = apiPath + path
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	// Projects are referenced by name rather than by ID
+	req.Header.Set("X-Is-Resource-Name", "true")
+	if p.username != "" && p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	reqID := utils.EncodeSha1(method + u.String())
+	klog.V(4).Infof("[%s] %s %q", reqID, method, u.String())
+	client := utils.DefaultClient
+	if p.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return errors.Annotatef(err, "requesting %q", u.String())
+	}
+	defer res.Body.Close()
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+
+	if res.StatusCode == http.StatusNotFound {
+		return errors.NotFoundf("%q", u.String())
+	}
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to %s %q, got HTTP Status: %s, Resp: %v", method, u.String(), res.Status, bodyStr)
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return errors.Annotatef(err, "decoding %q response", u.String())
+		}
+	}
+	return nil
+}
+
+// get returns the project
+func (p *projectClient) get() (*projectInfo, error) {
+	info := &projectInfo{}
+	if err := p.do("GET", "/projects/"+url.PathEscape(p.name), nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// create creates the project
+func (p *projectClient) create(metadata map[string]string) error {
+	in := map[string]interface{}{"project_name": p.name, "metadata": metadata}
+	err := p.do("POST", "/projects", in, nil)
+	return errors.Annotatef(err, "creating %q Harbor project", p.name)
+}
+
+// setMetadata updates the project metadata
+func (p *projectClient) setMetadata(metadata map[string]string) error {
+	in := map[string]interface{}{"metadata": metadata}
+	err := p.do("PUT", "/projects/"+url.PathEscape(p.name), in, nil)
+	return errors.Annotatef(err, "updating %q Harbor project metadata", p.name)
+}
+
+// setRetention creates or replaces the project retention policy
+func (p *projectClient) setRetention(info *projectInfo, config *api.Harbor) error {
+	policy := retentionPolicy{
+		Algorithm: "or",
+		Trigger: retentionTrigger{
+			Kind:     "Schedule",
+			Settings: map[string]string{"cron": config.GetRetentionSchedule()},
+		},
+		Scope: retentionScope{Level: "project", Ref: info.ID},
+	}
+	for _, r := range config.GetRetention() {
+		policy.Rules = append(policy.Rules, newRetentionRule(r))
+	}
+
+	id := info.Metadata[retentionIDKey]
+	if id == "" {
+		err := p.do("POST", "/retentions", policy, nil)
+		return errors.Annotatef(err, "creating %q Harbor project retention policy", p.name)
+	}
+	if policy.ID, _ = strconv.ParseInt(id, 10, 64); policy.ID == 0 {
+		return errors.Errorf("%q Harbor project retention policy ID %q is not valid", p.name, id)
+	}
+	err := p.do("PUT", fmt.Sprintf("/retentions/%d", policy.ID), policy, nil)
+	return errors.Annotatef(err, "updating %q Harbor project retention policy", p.name)
+}
+
+// newRetentionRule returns the Harbor representation of a retention rule
+func newRetentionRule(r *api.Harbor_RetentionRule) retentionRule {
+	repositories, tags := r.GetRepositories(), r.GetTags()
+	if repositories == "" {
+		repositories = "**"
+	}
+	if tags == "" {
+		tags = "**"
+	}
+	rule := retentionRule{
+		Action:       "retain",
+		TagSelectors: []retentionSelector{{Kind: "doublestar", Decoration: "matches", Pattern: tags}},
+		ScopeSelectors: map[string][]retentionSelector{
+			"repository": {{Kind: "doublestar", Decoration: "repoMatches", Pattern: repositories}},
+		},
+	}
+	switch r.GetRetain().(type) {
+	case *api.Harbor_RetentionRule_LatestPushed:
+		rule.Template = "latestPushedK"
+		rule.Params = map[string]int32{rule.Template: r.GetLatestPushed()}
+	case *api.Harbor_RetentionRule_PushedWithinDays:
+		rule.Template = "nDaysSinceLastPush"
+		rule.Params = map[string]int32{rule.Template: r.GetPushedWithinDays()}
+	}
+	return rule
+}
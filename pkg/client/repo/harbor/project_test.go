@@ -0,0 +1,146 @@
+package harbor_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/harbor"
+)
+
+// fakeHarborAPI implements the subset of the Harbor API used to set up
+// projects
+type fakeHarborAPI struct {
+	t        *testing.T
+	projects map[string]map[string]string
+	policies map[string]map[string]interface{}
+}
+
+func (f *fakeHarborAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "robot$library+syncer" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var body map[string]interface{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	switch {
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/api/v2.0/projects/"):
+		name := strings.TrimPrefix(r.URL.Path, "/api/v2.0/projects/")
+		metadata, ok := f.projects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"project_id": 7, "name": name, "metadata": metadata})
+	case r.Method == "POST" && r.URL.Path == "/api/v2.0/projects":
+		metadata := map[string]string{}
+		for k, v := range body["metadata"].(map[string]interface{}) {
+			metadata[k] = v.(string)
+		}
+		f.projects[body["project_name"].(string)] = metadata
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/v2.0/projects/"):
+		name := strings.TrimPrefix(r.URL.Path, "/api/v2.0/projects/")
+		for k, v := range body["metadata"].(map[string]interface{}) {
+			f.projects[name][k] = v.(string)
+		}
+	case r.Method == "POST" && r.URL.Path == "/api/v2.0/retentions":
+		f.policies["1"] = body
+		f.projects["library"]["retention_id"] = "1"
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == "PUT" && strings.HasPrefix(r.URL.Path, "/api/v2.0/retentions/"):
+		f.policies[strings.TrimPrefix(r.URL.Path, "/api/v2.0/retentions/")] = body
+	default:
+		f.t.Errorf("unexpected %s %q request", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestSetupProject(t *testing.T) {
+	fake := &fakeHarborAPI{
+		t:        t,
+		projects: map[string]map[string]string{},
+		policies: map[string]map[string]interface{}{},
+	}
+	s := httptest.NewServer(fake)
+	defer s.Close()
+
+	repo := &api.Repo{
+		Kind: api.Kind_HARBOR,
+		Url:  fmt.Sprintf("%s/chartrepo/library", s.URL),
+		Auth: &api.Auth{Username: "robot$library+syncer", Password: "secret"},
+	}
+	config := &api.Harbor{
+		Metadata: map[string]string{"public": "true"},
+		Retention: []*api.Harbor_RetentionRule{
+			{Retain: &api.Harbor_RetentionRule_LatestPushed{LatestPushed: 10}},
+		},
+		RetentionSchedule: "0 0 0 * * *",
+	}
+
+	if err := harbor.SetupProject(repo, config, false); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("got: %v, want a missing project error", err)
+	}
+
+	config.CreateProject = true
+	for i := 0; i < 2; i++ {
+		if err := harbor.SetupProject(repo, config, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := fake.projects["library"], map[string]string{"public": "true", "retention_id": "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	// The policy was created on the first run and updated on the second one
+	policy := fake.policies["1"]
+	if got, want := policy["id"], float64(1); got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	rule := policy["rules"].([]interface{})[0].(map[string]interface{})
+	if got, want := rule["template"], "latestPushedK"; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := rule["params"], map[string]interface{}{"latestPushedK": float64(10)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := policy["scope"], map[string]interface{}{"level": "project", "ref": float64(7)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		desc string
+		repo *api.Repo
+		want string
+	}{
+		{"harbor", &api.Repo{Kind: api.Kind_HARBOR, Url: "https://harbor.example.com/chartrepo/mirror"}, "mirror"},
+		{"oci", &api.Repo{Kind: api.Kind_OCI, Url: "https://harbor.example.com/mirror/charts"}, "mirror"},
+		{"invalid harbor", &api.Repo{Kind: api.Kind_HARBOR, Url: "https://harbor.example.com/mirror"}, ""},
+		{"helm", &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com"}, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := harbor.ProjectName(tc.repo)
+			if tc.want == "" {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got: %q, want: %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,21 @@
 package helmclassic
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
 	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
 
 	"github.com/bitnami-labs/charts-syncer/api"
 	"github.com/bitnami-labs/charts-syncer/internal/cache"
@@ -24,16 +29,37 @@ type Repo struct {
 	username string
 	password string
 	insecure bool
+	// mirrors are tried in order when url fails
+	mirrors []*url.URL
 
 	// NOTE: We need a lock for index to allow concurrency
 	Index *repo.IndexFile
+	// invalidEntries contains the index entries Helm skipped while loading the
+	// index
+	invalidEntries []string
 
 	cache cache.Cacher
 }
 
 // This allows test to replace the client index for testing.
 var reloadIndex = func(r *Repo) error {
-	u := r.GetIndexURL()
+	var errs error
+	for i, base := range r.baseURLs() {
+		if i > 0 {
+			klog.Warningf("Unable to load the index of %q. Retrying with mirror %q...", r.url, base)
+		}
+		err := loadIndex(r, base)
+		if err == nil {
+			return nil
+		}
+		errs = multierror.Append(errs, err)
+	}
+	return errors.Trace(errs)
+}
+
+// loadIndex loads the index of the repository located at base
+func loadIndex(r *Repo, base *url.URL) error {
+	u := indexURL(base)
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
 		return errors.Trace(err)
@@ -80,11 +106,55 @@ var reloadIndex = func(r *Repo) error {
 	if err != nil {
 		return errors.Annotate(err, "loading index.yaml file")
 	}
+	invalid, err := invalidIndexEntries(f.Name())
+	if err != nil {
+		return errors.Annotate(err, "validating index.yaml file")
+	}
+	for _, e := range invalid {
+		klog.Warningf("Skipping invalid entry in %q: %s", u, e)
+	}
 
 	r.Index = index
+	r.invalidEntries = invalid
 	return nil
 }
 
+// invalidIndexEntries returns the entries of an index file that Helm skips
+// when loading it
+func invalidIndexEntries(file string) ([]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	i := &repo.IndexFile{}
+	if err := yaml.Unmarshal(data, i); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var invalid []string
+	for name, cvs := range i.Entries {
+		for _, cv := range cvs {
+			if cv == nil {
+				invalid = append(invalid, fmt.Sprintf("%s: empty entry", name))
+				continue
+			}
+			if cv.APIVersion == "" {
+				cv.APIVersion = chart.APIVersionV1
+			}
+			if err := cv.Validate(); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s-%s: %v", name, cv.Version, err))
+			}
+		}
+	}
+	sort.Strings(invalid)
+	return invalid, nil
+}
+
+// InvalidIndexEntries returns the index entries that could not be loaded
+func (r *Repo) InvalidIndexEntries() []string {
+	return r.invalidEntries
+}
+
 // New creates a Repo object from an api.Repo object.
 func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
 	u, err := url.Parse(repo.GetUrl())
@@ -92,12 +162,31 @@ func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
 		return nil, errors.Trace(err)
 	}
 
-	return NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure)
+	mirrors, err := ParseMirrors(repo.GetMirrors())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, mirrors...)
+}
+
+// ParseMirrors parses the mirror URLs of a repository
+func ParseMirrors(mirrors []string) ([]*url.URL, error) {
+	var urls []*url.URL
+	for _, m := range mirrors {
+		u, err := url.Parse(m)
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing %q mirror", m)
+		}
+		urls = append(urls, u)
+	}
+	return urls, nil
 }
 
-// NewRaw creates a Repo object.
-func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool) (*Repo, error) {
-	r := &Repo{url: u, username: user, password: pass, cache: c, insecure: insecure}
+// NewRaw creates a Repo object. Mirrors are tried in order when the index or
+// a chart can not be fetched from u.
+func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool, mirrors ...*url.URL) (*Repo, error) {
+	r := &Repo{url: u, username: user, password: pass, cache: c, insecure: insecure, mirrors: mirrors}
 
 	if err := r.Reload(); err != nil {
 		return nil, errors.Trace(err)
@@ -121,11 +210,31 @@ func (r *Repo) GetDownloadURL(n string, v string) (string, error) {
 
 // GetIndexURL returns the URL to download the index.yaml
 func (r *Repo) GetIndexURL() string {
-	u := *r.url
+	return indexURL(r.url)
+}
+
+// indexURL returns the URL of the index.yaml of the repository at base
+func indexURL(base *url.URL) string {
+	u := *base
 	u.Path = u.Path + "/index.yaml"
 	return u.String()
 }
 
+// baseURLs returns the URL of the repository followed by its mirrors
+func (r *Repo) baseURLs() []*url.URL {
+	return append([]*url.URL{r.url}, r.mirrors...)
+}
+
+// mirrorURL rewrites a chart URL to download it from a mirror. It returns
+// false if the chart is not served by the repository itself.
+func (r *Repo) mirrorURL(chartURL string, mirror *url.URL) (string, bool) {
+	repoURL := strings.TrimSuffix(r.url.String(), "/")
+	if !strings.HasPrefix(chartURL, repoURL+"/") {
+		return "", false
+	}
+	return strings.TrimSuffix(mirror.String(), "/") + strings.TrimPrefix(chartURL, repoURL), true
+}
+
 // List lists all chart names in a repo
 func (r *Repo) List() ([]string, error) {
 	var names []string
@@ -159,7 +268,25 @@ func (r *Repo) Fetch(name string, version string) (string, error) {
 		utils.WithFetchInsecure(r.insecure),
 		utils.WithFetchURLBuilder(r.GetDownloadURL),
 	}
+	// Verify the downloaded package against the index, if possible
+	if cv, err := r.Index.Get(name, version); err == nil && cv.Digest != "" {
+		fetchOpts = append(fetchOpts, utils.WithFetchDigest(cv.Digest))
+	}
 	chartPath, err := utils.FetchAndCache(name, version, r.cache, fetchOpts...)
+	for i := 0; err != nil && i < len(r.mirrors); i++ {
+		mirror := r.mirrors[i]
+		u, uerr := r.GetDownloadURL(name, version)
+		if uerr != nil {
+			break
+		}
+		mu, ok := r.mirrorURL(u, mirror)
+		if !ok {
+			break
+		}
+		klog.Warningf("Unable to fetch %s:%s chart: %v. Retrying with mirror %q...", name, version, err, mirror)
+		mirrorOpts := append(fetchOpts[:len(fetchOpts):len(fetchOpts)], utils.WithFetchURLBuilder(func(string, string) (string, error) { return mu, nil }))
+		chartPath, err = utils.FetchAndCache(name, version, r.cache, mirrorOpts...)
+	}
 	if err != nil {
 		return "", errors.Annotatef(err, "fetching %s:%s chart", name, version)
 	}
@@ -167,6 +294,48 @@ func (r *Repo) Fetch(name string, version string) (string, error) {
 	return chartPath, nil
 }
 
+// FetchProvenance fetches the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	u, err := r.GetDownloadURL(name, version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	u += ".prov"
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	reqID := utils.EncodeSha1(u)
+	klog.V(4).Infof("[%s] GET %q", reqID, u)
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Annotatef(err, "fetching %s-%s provenance file", name, version)
+	}
+	defer res.Body.Close()
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, errors.NotFoundf("provenance file for %s-%s", name, version)
+	}
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		bodyStr := utils.HTTPResponseBody(res)
+		return nil, errors.Errorf("unable to fetch %s-%s provenance file, got HTTP Status: %s, Resp: %v", name, version, res.Status, bodyStr)
+	}
+	prov, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return prov, nil
+}
+
 // Has checks if a repo has a specific chart
 func (r *Repo) Has(name string, version string) (bool, error) {
 	versions, err := r.ListChartVersions(name)
@@ -200,6 +369,24 @@ func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails
 	}, nil
 }
 
+// GetIndexEntry returns the index entry of a chart, with absolute URLs
+func (r *Repo) GetIndexEntry(name string, version string) (*repo.ChartVersion, error) {
+	cv, err := r.Index.Get(name, version)
+	if err != nil {
+		return nil, errors.Annotatef(err, "getting %s-%s from index file", name, version)
+	}
+	entry := *cv
+	entry.URLs = make([]string, 0, len(cv.URLs))
+	for _, u := range cv.URLs {
+		abs, err := utils.NormalizeChartURL(r.url.String(), u)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		entry.URLs = append(entry.URLs, abs)
+	}
+	return &entry, nil
+}
+
 // Reload reloads the index
 func (r *Repo) Reload() error {
 	return errors.Annotatef(reloadIndex(r), "reloading %q chart repo", r.url)
@@ -3,6 +3,8 @@ package helmclassic_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -132,7 +134,7 @@ func TestGetChartDetails(t *testing.T) {
 	c := prepareTest(t, "index.yaml")
 	want := types.ChartDetails{
 		PublishedAt: time.Now().Time,
-		Digest:      "d47d94c52aff1fbb92235f0753c691072db1d19ec43fa9a438ab6736dfa7f867",
+		Digest:      "ea04778b1a4e266763d330e227a560e5c5eed8193bf9cd4c56ae2c425e05e354",
 	}
 	got, err := c.GetChartDetails("etcd", "4.8.0")
 	if err != nil {
@@ -207,3 +209,68 @@ func TestUpload(t *testing.T) {
 		t.Errorf("unexpected error message. got: %q, want: %q", err.Error(), expectedError)
 	}
 }
+
+func TestInvalidIndexEntries(t *testing.T) {
+	c := prepareTest(t, "invalid-entries-index.yaml")
+	want := []string{`zookeeper-latest: validation: chart.metadata.version "latest" is invalid`}
+	if got := c.InvalidIndexEntries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected invalid entries. got: %v, want: %v", got, want)
+	}
+
+	c = prepareTest(t, "index.yaml")
+	if got := c.InvalidIndexEntries(); len(got) != 0 {
+		t.Errorf("unexpected invalid entries. got: %v, want none", got)
+	}
+}
+
+func TestMirrors(t *testing.T) {
+	index := "../../../../testdata/index-relative.yaml"
+	mirror := helmclassic.NewTester(t, cmRepo, false, index, true)
+
+	// The primary repository serves the index but fails to serve charts
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.yaml" {
+			http.ServeFile(w, r, index)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer flaky.Close()
+	// The primary repository is down
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	testCases := []struct {
+		desc string
+		url  string
+	}{
+		{desc: "fetch charts from a mirror", url: flaky.URL},
+		{desc: "load the index from a mirror", url: down.URL},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			cacheDir, err := ioutil.TempDir("", "client")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(cacheDir)
+			cache, err := cachedisk.New(cacheDir, tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repo := &api.Repo{Kind: api.Kind_HELM, Url: tc.url, Auth: cmRepo.GetAuth(), Mirrors: []string{mirror.GetURL()}}
+			c, err := helmclassic.New(repo, cache, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chartPath, err := c.Fetch("etcd", "4.8.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(chartPath); err != nil {
+				t.Errorf("chart package does not exist")
+			}
+		})
+	}
+}
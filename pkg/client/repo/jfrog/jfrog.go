@@ -0,0 +1,414 @@
+// Package jfrog implements JFrog Artifactory Helm repositories. Charts are read
+// through the Helm index served by Artifactory, and deployed, checked and
+// removed through the Artifactory REST API.
+package jfrog
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/helmclassic"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+// Repo allows to operate an Artifactory Helm repository.
+type Repo struct {
+	// base is the URL of Artifactory, like https://example.jfrog.io/artifactory
+	base *url.URL
+	// key is the key of the repository
+	key      string
+	username string
+	password string
+	insecure bool
+
+	helm *helmclassic.Repo
+
+	cache cache.Cacher
+
+	detectOnce sync.Once
+	// deployKey is the key of the local repository the charts are deployed
+	// to, which differs from key for virtual repositories
+	deployKey string
+	detectErr error
+}
+
+// Repository is the configuration of an Artifactory repository
+type Repository struct {
+	Key string `json:"key"`
+	// RClass is local, remote, virtual or federated
+	RClass                string `json:"rclass"`
+	PackageType           string `json:"packageType"`
+	DefaultDeploymentRepo string `json:"defaultDeploymentRepo"`
+	ProjectKey            string `json:"projectKey"`
+}
+
+// New creates a Repo object from an api.Repo object.
+func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
+	base, key, err := ParseURL(repo.GetUrl())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	mirrors, err := helmclassic.ParseMirrors(repo.GetMirrors())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return NewRaw(base, key, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, mirrors...)
+}
+
+// NewRaw creates a Repo object. Mirrors are only used to read from the repo.
+func NewRaw(base *url.URL, key string, user string, pass string, c cache.Cacher, insecure bool, mirrors ...*url.URL) (*Repo, error) {
+	helm, err := helmclassic.NewRaw(HelmURL(base, key), user, pass, c, insecure, mirrors...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Repo{base: base, key: key, username: user, password: pass, helm: helm, cache: c, insecure: insecure}, nil
+}
+
+// ParseURL returns the URL of Artifactory and the key of the repository of a
+// repository URL. Both the Helm API URL, like
+// https://example.jfrog.io/artifactory/api/helm/charts, and the repository
+// URL, like https://example.jfrog.io/artifactory/charts, are supported.
+func ParseURL(raw string) (*url.URL, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	base, key := segs[:len(segs)-1], segs[len(segs)-1]
+	if n := len(segs); n >= 3 && segs[n-3] == "api" && segs[n-2] == "helm" {
+		base = segs[:n-3]
+	}
+	if key == "" || key == "helm" && len(base) > 0 && base[len(base)-1] == "api" {
+		return nil, "", errors.NotValidf("Artifactory repository URL %q, it should be like https://example.jfrog.io/artifactory/api/helm/<repository>", raw)
+	}
+	b := *u
+	b.Path = ""
+	if len(base) > 0 {
+		b.Path = "/" + strings.Join(base, "/")
+	}
+	return &b, key, nil
+}
+
+// HelmURL returns the URL of the Helm API of a repository, serving its
+// index.yaml
+func HelmURL(base *url.URL, key string) *url.URL {
+	u := *base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/helm/" + key
+	return &u
+}
+
+// artifactURL returns the URL of a file in a repository
+func (r *Repo) artifactURL(key, file string) string {
+	u := *r.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key + "/" + file
+	return u.String()
+}
+
+// do sends an authenticated request to Artifactory
+func (r *Repo) do(req *http.Request) (*http.Response, error) {
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	reqID := utils.EncodeSha1(req.Method + req.URL.String())
+	klog.V(4).Infof("[%s] %s %q", reqID, req.Method, req.URL)
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+	return res, nil
+}
+
+// GetRepository returns the configuration of the repository. Users without
+// admin permissions may get a partial configuration.
+func (r *Repo) GetRepository() (*Repository, error) {
+	u := *r.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/repositories/" + r.key
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	res, err := r.do(req)
+	if err != nil {
+		return nil, errors.Annotatef(err, "getting %q repository", r.key)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unable to get %q repository, got HTTP Status: %s, Resp: %v", r.key, res.Status, utils.HTTPResponseBody(res))
+	}
+	var repository Repository
+	if err := json.NewDecoder(res.Body).Decode(&repository); err != nil {
+		return nil, errors.Annotatef(err, "parsing %q repository", r.key)
+	}
+	return &repository, nil
+}
+
+// detect returns the key of the repository the charts are deployed to. For
+// virtual repositories, it is their default deployment repository. If the
+// configuration of the repository cannot be read, the charts are deployed to
+// the repository itself.
+func (r *Repo) detect() (string, error) {
+	r.detectOnce.Do(func() {
+		r.deployKey = r.key
+		repository, err := r.GetRepository()
+		if err != nil {
+			klog.V(3).Infof("Unable to detect the class of %q, deploying to it: %v", r.key, err)
+			return
+		}
+		if repository.ProjectKey != "" {
+			klog.V(3).Infof("%q belongs to the %q project", r.key, repository.ProjectKey)
+		}
+		if t := repository.PackageType; t != "" && !strings.EqualFold(t, "helm") {
+			r.detectErr = errors.NotValidf("%q repository of %q packages, it should be a Helm repository", r.key, t)
+			return
+		}
+		switch repository.RClass {
+		case "remote":
+			r.detectErr = errors.NotSupportedf("deploying charts to %q remote repository", r.key)
+		case "virtual":
+			if repository.DefaultDeploymentRepo == "" {
+				r.detectErr = errors.NotSupportedf("deploying charts to %q virtual repository without default deployment repository", r.key)
+				return
+			}
+			klog.V(3).Infof("Deploying charts of %q virtual repository to %q", r.key, repository.DefaultDeploymentRepo)
+			r.deployKey = repository.DefaultDeploymentRepo
+		}
+	})
+	return r.deployKey, r.detectErr
+}
+
+// chartFile returns the name of a chart package
+func chartFile(name, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+// fileChecksums returns the SHA-1 and SHA-256 checksums of a file
+func fileChecksums(file string) (string, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	defer f.Close()
+	h1, h256 := sha1.New(), sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h1, h256), f); err != nil {
+		return "", "", errors.Trace(err)
+	}
+	return hex.EncodeToString(h1.Sum(nil)), hex.EncodeToString(h256.Sum(nil)), nil
+}
+
+// Upload deploys a chart to the repo. Artifactory is first asked to deploy
+// the chart from its checksums, so the content is only sent if it does not
+// store it yet. The checksums are verified by Artifactory otherwise.
+func (r *Repo) Upload(file string, metadata *chart.Metadata) error {
+	key, err := r.detect()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sha1sum, sha256sum, err := fileChecksums(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// Invalidate cache to avoid inconsistency between an old cache result and
+	// the chart repo
+	id := chartFile(metadata.Name, metadata.Version)
+	if err := r.cache.Invalidate(id); err != nil {
+		return errors.Trace(err)
+	}
+
+	u := r.artifactURL(key, id)
+	header := http.Header{
+		"X-Checksum-Sha1":   {sha1sum},
+		"X-Checksum-Sha256": {sha256sum},
+	}
+	req, err := http.NewRequest(http.MethodPut, u, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header = header.Clone()
+	req.Header.Set("X-Checksum-Deploy", "true")
+	res, err := r.do(req)
+	if err != nil {
+		return errors.Annotatef(err, "deploying %q chart", file)
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		// Artifactory does not store the content yet
+		f, err := os.Open(file)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer f.Close()
+		st, err := f.Stat()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		req, err := http.NewRequest(http.MethodPut, u, f)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		req.ContentLength = st.Size()
+		req.Header = header.Clone()
+		req.Header.Set("Content-Type", "application/gzip")
+		if res, err = r.do(req); err != nil {
+			return errors.Annotatef(err, "deploying %q chart", file)
+		}
+		defer res.Body.Close()
+	}
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to deploy %q chart, got HTTP Status: %s, Resp: %v", file, res.Status, bodyStr)
+	}
+	return nil
+}
+
+// ChartDigest downloads a deployed chart and returns its SHA256 digest
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	key, err := r.detect()
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return utils.RemoteSha256(r.artifactURL(key, chartFile(name, version)), r.do)
+}
+
+// Fetch downloads a chart from the repo
+func (r *Repo) Fetch(name string, version string) (string, error) {
+	return r.helm.Fetch(name, version)
+}
+
+// List lists all chart names in the repo
+func (r *Repo) List() ([]string, error) {
+	return r.helm.List()
+}
+
+// ListChartVersions lists all versions of a chart
+func (r *Repo) ListChartVersions(name string) ([]string, error) {
+	return r.helm.ListChartVersions(name)
+}
+
+// Has checks if a repo has a specific chart. Artifactory indexes the charts
+// asynchronously, so the charts missing from the index are looked up in the
+// repository as well.
+func (r *Repo) Has(name string, version string) (bool, error) {
+	if ok, err := r.helm.Has(name, version); err != nil || ok {
+		return ok, errors.Trace(err)
+	}
+	req, err := http.NewRequest(http.MethodHead, r.artifactURL(r.key, chartFile(name, version)), nil)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	res, err := r.do(req)
+	if err != nil {
+		return false, errors.Annotatef(err, "checking %s-%s chart", name, version)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, errors.Errorf("unable to check %s-%s chart, got HTTP Status: %s", name, version, res.Status)
+	}
+}
+
+// GetChartDetails returns the details of a chart
+func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	return r.helm.GetChartDetails(name, version)
+}
+
+// GetIndexEntry returns the index entry of a chart, with absolute URLs
+func (r *Repo) GetIndexEntry(name string, version string) (*repo.ChartVersion, error) {
+	return r.helm.GetIndexEntry(name, version)
+}
+
+// Delete removes a chart from the repo
+func (r *Repo) Delete(name string, version string) error {
+	key, err := r.detect()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req, err := http.NewRequest(http.MethodDelete, r.artifactURL(key, chartFile(name, version)), nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	res, err := r.do(req)
+	if err != nil {
+		return errors.Annotatef(err, "deleting %s-%s chart", name, version)
+	}
+	defer res.Body.Close()
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok && res.StatusCode != http.StatusNotFound {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to delete %s-%s chart, got HTTP Status: %s, Resp: %v", name, version, res.Status, bodyStr)
+	}
+
+	// Invalidate cache to avoid inconsistency with the chart repo
+	return errors.Trace(r.cache.Invalidate(chartFile(name, version)))
+}
+
+// FetchProvenance fetches the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	return r.helm.FetchProvenance(name, version)
+}
+
+// UploadProvenance deploys the provenance file of a chart next to its package,
+// where Helm looks it up
+func (r *Repo) UploadProvenance(file string, prov []byte, metadata *chart.Metadata) error {
+	key, err := r.detect()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	u := r.artifactURL(key, chartFile(metadata.Name, metadata.Version)+".prov")
+	req, err := http.NewRequest(http.MethodPut, u, bytes.NewReader(prov))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// The checksums are verified by Artifactory
+	h1, h256 := sha1.Sum(prov), sha256.Sum256(prov)
+	req.ContentLength = int64(len(prov))
+	req.Header.Set("X-Checksum-Sha1", hex.EncodeToString(h1[:]))
+	req.Header.Set("X-Checksum-Sha256", hex.EncodeToString(h256[:]))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	res, err := r.do(req)
+	if err != nil {
+		return errors.Annotatef(err, "deploying %q provenance file", file)
+	}
+	defer res.Body.Close()
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to deploy %q provenance file, got HTTP Status: %s, Resp: %v", file, res.Status, bodyStr)
+	}
+	return nil
+}
+
+// InvalidIndexEntries returns the index entries that could not be loaded
+func (r *Repo) InvalidIndexEntries() []string {
+	return r.helm.InvalidIndexEntries()
+}
+
+// Reload reloads the index
+func (r *Repo) Reload() error {
+	return r.helm.Reload()
+}
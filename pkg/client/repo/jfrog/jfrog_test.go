@@ -0,0 +1,173 @@
+package jfrog
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache/cachedisk"
+)
+
+// fakeArtifactory serves a "charts" virtual repository deploying to the
+// "charts-local" local repository
+type fakeArtifactory struct {
+	mu       sync.Mutex
+	files    map[string][]byte
+	checksum map[string][]byte
+	uploads  int
+	t        *testing.T
+}
+
+func (f *fakeArtifactory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "password" {
+		http.Error(w, `{"errors": [{"status": 401, "message": "Bad credentials"}]}`, http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.URL.Path == "/artifactory/api/helm/charts/index.yaml":
+		fmt.Fprint(w, "apiVersion: v1\nentries: {}\n")
+	case r.URL.Path == "/artifactory/api/repositories/charts":
+		fmt.Fprint(w, `{"key": "charts", "rclass": "virtual", "packageType": "helm", "defaultDeploymentRepo": "charts-local", "projectKey": "prj"}`)
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/artifactory/charts-local/"):
+		sum := r.Header.Get("X-Checksum-Sha1")
+		if r.Header.Get("X-Checksum-Deploy") == "true" {
+			data, ok := f.checksum[sum]
+			if !ok {
+				http.Error(w, "Checksum deploy failed", http.StatusNotFound)
+				return
+			}
+			f.files[path.Base(r.URL.Path)] = data
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			f.t.Error(err)
+		}
+		h := sha1.Sum(data)
+		if got := hex.EncodeToString(h[:]); got != sum {
+			http.Error(w, "Checksum mismatch", http.StatusConflict)
+			return
+		}
+		f.uploads++
+		f.files[path.Base(r.URL.Path)] = data
+		f.checksum[sum] = data
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/artifactory/charts/"):
+		if _, ok := f.files[path.Base(r.URL.Path)]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/artifactory/charts-local/"):
+		if _, ok := f.files[path.Base(r.URL.Path)]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.files, path.Base(r.URL.Path))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	testCases := []struct {
+		url  string
+		base string
+		key  string
+	}{
+		{url: "https://example.jfrog.io/artifactory/api/helm/charts", base: "https://example.jfrog.io/artifactory", key: "charts"},
+		{url: "https://example.jfrog.io/artifactory/charts/", base: "https://example.jfrog.io/artifactory", key: "charts"},
+		{url: "https://artifactory.example.com/api/helm/prj-charts", base: "https://artifactory.example.com", key: "prj-charts"},
+	}
+	for _, tc := range testCases {
+		base, key, err := ParseURL(tc.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if base.String() != tc.base || key != tc.key {
+			t.Errorf("got: %q, %q, want: %q, %q", base, key, tc.base, tc.key)
+		}
+	}
+	for _, u := range []string{"https://example.jfrog.io", "https://example.jfrog.io/artifactory/api/helm"} {
+		if _, _, err := ParseURL(u); !errors.IsNotValid(err) {
+			t.Errorf("got: %v, want a not valid error for %q", err, u)
+		}
+	}
+}
+
+func TestRepo(t *testing.T) {
+	f := &fakeArtifactory{files: map[string][]byte{}, checksum: map[string][]byte{}, t: t}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	repo := &api.Repo{
+		Kind: api.Kind_ARTIFACTORY,
+		Url:  srv.URL + "/artifactory/api/helm/charts",
+		Auth: &api.Auth{Username: "user", Password: "password"},
+	}
+	c, err := cachedisk.New(filepath.Join(t.TempDir(), "cache"), repo.GetUrl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(repo, c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Charts are deployed to the default deployment repository, with their
+	// content sent only once
+	tgz := "../../../../testdata/apache-7.3.15.tgz"
+	for _, version := range []string{"7.3.15", "7.3.15"} {
+		if err := r.Upload(tgz, &chart.Metadata{Name: "apache", Version: version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if f.uploads != 1 {
+		t.Errorf("got: %d uploads, want: 1", f.uploads)
+	}
+	if _, ok := f.files["apache-7.3.15.tgz"]; !ok {
+		t.Errorf("apache-7.3.15 was not deployed")
+	}
+
+	// Provenance files are deployed next to the chart
+	prov := []byte("-----BEGIN PGP SIGNED MESSAGE-----\n")
+	if err := r.UploadProvenance(tgz, prov, &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.files["apache-7.3.15.tgz.prov"]; string(got) != string(prov) {
+		t.Errorf("got provenance file: %q, want: %q", got, prov)
+	}
+
+	// The chart is not indexed yet, but exists in the repository
+	if ok, err := r.Has("apache", "7.3.15"); err != nil || !ok {
+		t.Errorf("got: %v, %v, want apache-7.3.15 in the repository", ok, err)
+	}
+	if ok, err := r.Has("apache", "7.3.16"); err != nil || ok {
+		t.Errorf("got: %v, %v, want no apache-7.3.16 in the repository", ok, err)
+	}
+
+	if err := r.Delete("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f.files["apache-7.3.15.tgz"]; ok {
+		t.Errorf("apache-7.3.15 was not deleted")
+	}
+	if err := r.Delete("apache", "7.3.15"); err != nil {
+		t.Errorf("deleting a missing chart: %v", err)
+	}
+}
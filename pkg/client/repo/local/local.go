@@ -7,27 +7,52 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
-	"sort"
+	"sync"
+	"time"
 
 	"github.com/juju/errors"
+	"k8s.io/klog"
 
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
 )
 
 var (
-	versionRe = regexp.MustCompile("(.*)-(\\d+\\.\\d+\\.\\d+)\\.tgz")
+	versionRe = regexp.MustCompile("^(.+?)-(v?\\d+\\.\\d+\\.\\d+.*)\\.tgz$")
 )
 
-// Repo allows to operate a chart repository.
+// indexFile is the index of the charts of the repository
+const indexFile = "index.yaml"
+
+// Repo allows to operate a chart repository. It is safe for concurrent use.
 type Repo struct {
-	dir     string
+	dir string
+	// mu guards the entries and the index.yaml file
+	mu      sync.RWMutex
 	entries map[string][]string
+	// index is set if the repository maintains an index.yaml file
+	index   bool
+	baseURL string
+}
+
+// Option is an option value used to create a new local repository client
+type Option func(*Repo)
+
+// WithIndex maintains an index.yaml file with the charts of the repository.
+// Chart URLs are relative to the index unless a base URL is given.
+func WithIndex(baseURL string) Option {
+	return func(r *Repo) {
+		r.index = true
+		r.baseURL = baseURL
+	}
 }
 
 // New creates a Repo object from an api.Repo object.
-func New(dir string) (*Repo, error) {
+func New(dir string, opts ...Option) (*Repo, error) {
 	d, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -45,15 +70,30 @@ func New(dir string) (*Repo, error) {
 	for _, m := range matches {
 		filename := filepath.Base(m)
 		s := versionRe.FindStringSubmatch(filename)
+		if s == nil {
+			klog.Warningf("Ignoring %q: unable to parse the chart name and version", m)
+			continue
+		}
 		entries[s[1]] = append(entries[s[1]], s[2])
-		sort.Strings(entries[s[0]])
+		utils.SortVersions(entries[s[1]])
 	}
 
-	return &Repo{dir: d, entries: entries}, nil
+	r := &Repo{dir: d, entries: entries}
+	for _, o := range opts {
+		o(r)
+	}
+	if r.index {
+		if err := r.initIndex(); err != nil {
+			return nil, errors.Annotatef(err, "generating %q", indexFile)
+		}
+	}
+	return r, nil
 }
 
 // List lists all chart names in a repo
 func (r *Repo) List() ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	var names []string
 	for name := range r.entries {
 		names = append(names, name)
@@ -63,11 +103,22 @@ func (r *Repo) List() ([]string, error) {
 
 // ListChartVersions lists all versions of a chart
 func (r *Repo) ListChartVersions(name string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	versions, ok := r.entries[name]
 	if !ok {
 		return []string{}, nil
 	}
-	return versions, nil
+	return append([]string{}, versions...), nil
+}
+
+// ChartDigest returns the SHA256 digest of a stored chart
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	file := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz", name, version))
+	if ok, err := utils.FileExists(file); err != nil || !ok {
+		return "", errors.NewNotFound(err, fmt.Sprintf("%s-%s chart", name, version))
+	}
+	return utils.FileSha256(file)
 }
 
 // Fetch fetches a chart
@@ -94,30 +145,84 @@ func (r *Repo) Has(name string, version string) (bool, error) {
 func (r *Repo) Upload(filepath string, metadata *chart.Metadata) error {
 	name := metadata.Name
 	version := metadata.Version
-	if _, ok := r.entries[name]; ok {
-		for _, v := range r.entries[name] {
-			if v == version {
-				return errors.AlreadyExistsf("%s-%s", name, version)
-			}
+	if ok, err := r.Has(name, version); err != nil || ok {
+		if ok {
+			err = errors.AlreadyExistsf("%s-%s", name, version)
 		}
+		return errors.Trace(err)
 	}
 
-	input, err := ioutil.ReadFile(filepath)
-	if err != nil {
-		return errors.Annotatef(err, "reading %q", filepath)
-	}
-
+	// The package is copied without holding the lock, so the charts are
+	// uploaded concurrently
 	out := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz", name, version))
-	if err := ioutil.WriteFile(out, input, 0644); err != nil {
+	if err := utils.CopyFile(out, filepath); err != nil {
+		os.Remove(out)
 		return errors.Annotatef(err, "creating %q", out)
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.entries[name] = append(r.entries[name], version)
-	sort.Strings(r.entries[name])
+	utils.SortVersions(r.entries[name])
+
+	return r.updateIndex(name, version, out)
+}
+
+// UploadAttestation stores the attestation of a chart next to it
+func (r *Repo) UploadAttestation(_ string, attestation []byte, metadata *chart.Metadata) error {
+	out := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz.intoto.json", metadata.Name, metadata.Version))
+	if err := ioutil.WriteFile(out, attestation, 0644); err != nil {
+		return errors.Annotatef(err, "creating %q", out)
+	}
+	return nil
+}
+
+// sbomExtensions are the extensions of the software bills of materials
+// stored next to the charts, by media type
+var sbomExtensions = map[string]string{
+	types.SPDXMediaType:      ".spdx.json",
+	types.CycloneDXMediaType: ".cdx.json",
+}
 
+// UploadSBOM stores the software bill of materials of a chart next to it
+func (r *Repo) UploadSBOM(_ string, sbom []byte, mediaType string, metadata *chart.Metadata) error {
+	ext, ok := sbomExtensions[mediaType]
+	if !ok {
+		return errors.NotSupportedf("SBOM media type %q", mediaType)
+	}
+	out := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz%s", metadata.Name, metadata.Version, ext))
+	if err := ioutil.WriteFile(out, sbom, 0644); err != nil {
+		return errors.Annotatef(err, "creating %q", out)
+	}
 	return nil
 }
 
+// Delete removes a chart, its provenance file, attestation and software bills
+// of materials from the repo
+func (r *Repo) Delete(name string, version string) error {
+	tgz := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz", name, version))
+	for _, f := range []string{tgz, tgz + ".prov", tgz + ".intoto.json", tgz + ".spdx.json", tgz + ".cdx.json"} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return errors.Annotatef(err, "removing %q", f)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var versions []string
+	for _, v := range r.entries[name] {
+		if v != version {
+			versions = append(versions, v)
+		}
+	}
+	r.entries[name] = versions
+	if len(versions) == 0 {
+		delete(r.entries, name)
+	}
+
+	return r.updateIndex(name, version, "")
+}
+
 // GetChartDetails returns the details of a chart
 func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
 	return &types.ChartDetails{
@@ -130,3 +235,113 @@ func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails
 func (r *Repo) Reload() error {
 	return nil
 }
+
+// FetchProvenance returns the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	f := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz.prov", name, version))
+	prov, err := ioutil.ReadFile(f)
+	if os.IsNotExist(err) {
+		return nil, errors.NotFoundf("provenance file for %s-%s", name, version)
+	}
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading %q", f)
+	}
+	return prov, nil
+}
+
+// UploadProvenance stores the provenance file of a chart next to it
+func (r *Repo) UploadProvenance(_ string, prov []byte, metadata *chart.Metadata) error {
+	out := path.Join(r.dir, fmt.Sprintf("%s-%s.tgz.prov", metadata.Name, metadata.Version))
+	if err := ioutil.WriteFile(out, prov, 0644); err != nil {
+		return errors.Annotatef(err, "creating %q", out)
+	}
+	return nil
+}
+
+// initIndex adds the charts of the repository missing from its index, e.g.
+// if the index was enabled after some charts were synced
+func (r *Repo) initIndex() error {
+	index, err := r.loadIndex()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	updated := false
+	for name, versions := range r.entries {
+		for _, version := range versions {
+			if index.Has(name, version) {
+				continue
+			}
+			if err := r.addToIndex(index, path.Join(r.dir, fmt.Sprintf("%s-%s.tgz", name, version))); err != nil {
+				return errors.Trace(err)
+			}
+			updated = true
+		}
+	}
+	if ok, err := utils.FileExists(path.Join(r.dir, indexFile)); err != nil {
+		return errors.Trace(err)
+	} else if ok && !updated {
+		return nil
+	}
+	return errors.Trace(r.writeIndex(index))
+}
+
+// updateIndex replaces a chart version in the index.yaml file, or removes it
+// if no package is given. The entries of other charts are kept, so the index
+// can be shared with other tools. The caller must hold the lock.
+func (r *Repo) updateIndex(name, version, tgz string) error {
+	if !r.index {
+		return nil
+	}
+	index, err := r.loadIndex()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var versions repo.ChartVersions
+	for _, cv := range index.Entries[name] {
+		if cv.Version != version {
+			versions = append(versions, cv)
+		}
+	}
+	index.Entries[name] = versions
+	if len(versions) == 0 {
+		delete(index.Entries, name)
+	}
+	if tgz != "" {
+		if err := r.addToIndex(index, tgz); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Annotatef(r.writeIndex(index), "updating %q", indexFile)
+}
+
+// loadIndex loads the index.yaml file, or returns an empty index if it does
+// not exist
+func (r *Repo) loadIndex() (*repo.IndexFile, error) {
+	f := path.Join(r.dir, indexFile)
+	if ok, err := utils.FileExists(f); err != nil {
+		return nil, errors.Trace(err)
+	} else if !ok {
+		return repo.NewIndexFile(), nil
+	}
+	index, err := repo.LoadIndexFile(f)
+	return index, errors.Annotatef(err, "loading %q", f)
+}
+
+// addToIndex adds a chart package to an index
+func (r *Repo) addToIndex(index *repo.IndexFile, tgz string) error {
+	ch, err := loader.Load(tgz)
+	if err != nil {
+		return errors.Annotatef(err, "loading %q", tgz)
+	}
+	digest, err := provenance.DigestFile(tgz)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(index.MustAdd(ch.Metadata, filepath.Base(tgz), r.baseURL, digest))
+}
+
+func (r *Repo) writeIndex(index *repo.IndexFile) error {
+	index.SortEntries()
+	index.Generated = time.Now()
+	return errors.Trace(index.WriteFile(path.Join(r.dir, indexFile), 0644))
+}
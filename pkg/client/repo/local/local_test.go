@@ -1,14 +1,18 @@
 package local_test
 
 import (
+	"io/ioutil"
 	"os"
+	"path"
 	"reflect"
 	"sort"
 	"testing"
 
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/local"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
 	"helm.sh/helm/v3/pkg/time"
 )
 
@@ -116,3 +120,116 @@ func TestUpload(t *testing.T) {
 		t.Errorf("error cleaning chart path from %q after successful upload", expectedChartPath)
 	}
 }
+
+func TestDelete(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := utils.CopyFile(path.Join(dir, "apache-7.3.15.tgz"), "../../../../testdata/apache-7.3.15.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(dir, "apache-7.3.15.tgz.prov"), []byte("prov"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := local.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := c.Has("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	} else if has {
+		t.Errorf("chart should not be listed after deleting it")
+	}
+	for _, f := range []string{"apache-7.3.15.tgz", "apache-7.3.15.tgz.prov"} {
+		if _, err := os.Stat(path.Join(dir, f)); !os.IsNotExist(err) {
+			t.Errorf("%q should not exist after deleting the chart", f)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// A chart synced before the index was enabled, and an entry of another
+	// tool
+	if err := utils.CopyFile(path.Join(dir, "zookeeper-5.14.3.tgz"), "../../../../testdata/zookeeper-5.14.3.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	index := repo.NewIndexFile()
+	if err := index.MustAdd(&chart.Metadata{APIVersion: "v2", Name: "nginx", Version: "1.0.0"}, "nginx-1.0.0.tgz", "https://example.com", "sha256:deadbeef"); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(path.Join(dir, "index.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := local.New(dir, local.WithIndex(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+		t.Fatal(err)
+	}
+	index, err = repo.LoadIndexFile(path.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []struct{ name, version, url string }{
+		{"apache", "7.3.15", "apache-7.3.15.tgz"},
+		{"zookeeper", "5.14.3", "zookeeper-5.14.3.tgz"},
+		{"nginx", "1.0.0", "https://example.com/nginx-1.0.0.tgz"},
+	} {
+		cv, err := index.Get(want.name, want.version)
+		if err != nil {
+			t.Errorf("%s-%s is not indexed: %v", want.name, want.version, err)
+			continue
+		}
+		if !reflect.DeepEqual(cv.URLs, []string{want.url}) {
+			t.Errorf("got %s-%s URLs: %v, want: [%s]", want.name, want.version, cv.URLs, want.url)
+		}
+		if cv.Digest == "" {
+			t.Errorf("%s-%s has no digest", want.name, want.version)
+		}
+	}
+
+	if err := c.Delete("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	}
+	index, err = repo.LoadIndexFile(path.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Has("apache", "7.3.15") {
+		t.Errorf("apache-7.3.15 should not be indexed after deleting it")
+	}
+	if !index.Has("nginx", "1.0.0") || !index.Has("zookeeper", "5.14.3") {
+		t.Errorf("other charts should be kept in the index")
+	}
+
+	// Chart URLs are absolute with a base URL
+	c, err = local.New(dir, local.WithIndex("https://charts.example.com/mirror"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+		t.Fatal(err)
+	}
+	index, err = repo.LoadIndexFile(path.Join(dir, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cv, err := index.Get("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	} else if got, want := cv.URLs, []string{"https://charts.example.com/mirror/apache-7.3.15.tgz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got URLs: %v, want: %v", got, want)
+	}
+}
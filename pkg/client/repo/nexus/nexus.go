@@ -0,0 +1,286 @@
+// Package nexus implements Sonatype Nexus Repository 3 hosted Helm
+// repositories. Charts are read through the index served by the repository,
+// and uploaded, looked up and removed through the components REST API.
+package nexus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/helmclassic"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+// Repo allows to operate a Nexus hosted Helm repository.
+type Repo struct {
+	// base is the URL of Nexus, like https://nexus.example.com
+	base *url.URL
+	// name is the name of the repository
+	name     string
+	username string
+	password string
+	insecure bool
+
+	helm *helmclassic.Repo
+
+	cache cache.Cacher
+}
+
+// Component is a component of a repository, as returned by the search API
+type Component struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// New creates a Repo object from an api.Repo object.
+func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
+	base, name, err := ParseURL(repo.GetUrl())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	mirrors, err := helmclassic.ParseMirrors(repo.GetMirrors())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return NewRaw(base, name, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, mirrors...)
+}
+
+// NewRaw creates a Repo object. Mirrors are only used to read from the repo.
+func NewRaw(base *url.URL, name string, user string, pass string, c cache.Cacher, insecure bool, mirrors ...*url.URL) (*Repo, error) {
+	helm, err := helmclassic.NewRaw(RepositoryURL(base, name), user, pass, c, insecure, mirrors...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Repo{base: base, name: name, username: user, password: pass, helm: helm, cache: c, insecure: insecure}, nil
+}
+
+// ParseURL returns the URL of Nexus and the name of the repository of a
+// repository URL, like https://nexus.example.com/repository/helm-hosted.
+func ParseURL(raw string) (*url.URL, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	segs := strings.Split(strings.Trim(u.Path, "/"), "/")
+	n := len(segs)
+	if n < 2 || segs[n-2] != "repository" || segs[n-1] == "" {
+		return nil, "", errors.NotValidf("Nexus repository URL %q, it should be like https://nexus.example.com/repository/<repository>", raw)
+	}
+	b := *u
+	b.Path = ""
+	if n > 2 {
+		b.Path = "/" + strings.Join(segs[:n-2], "/")
+	}
+	return &b, segs[n-1], nil
+}
+
+// RepositoryURL returns the URL of a repository, serving its index.yaml
+func RepositoryURL(base *url.URL, name string) *url.URL {
+	u := *base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/repository/" + name
+	return &u
+}
+
+// apiURL returns the URL of an endpoint of the REST API
+func (r *Repo) apiURL(endpoint string, query url.Values) string {
+	u := *r.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/service/rest/v1/" + endpoint
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// do sends an authenticated request to Nexus
+func (r *Repo) do(req *http.Request) (*http.Response, error) {
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	reqID := utils.EncodeSha1(req.Method + req.URL.String())
+	klog.V(4).Infof("[%s] %s %q", reqID, req.Method, req.URL)
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+	return res, nil
+}
+
+// Search returns the components of a chart version in the repository
+func (r *Repo) Search(name string, version string) ([]*Component, error) {
+	q := url.Values{"repository": {r.name}, "name": {name}, "version": {version}}
+	var components []*Component
+	for {
+		req, err := http.NewRequest(http.MethodGet, r.apiURL("search", q), nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		res, err := r.do(req)
+		if err != nil {
+			return nil, errors.Annotatef(err, "searching %s-%s chart", name, version)
+		}
+		if res.StatusCode != http.StatusOK {
+			defer res.Body.Close()
+			return nil, errors.Errorf("unable to search %s-%s chart, got HTTP Status: %s, Resp: %v", name, version, res.Status, utils.HTTPResponseBody(res))
+		}
+		var page struct {
+			Items             []*Component `json:"items"`
+			ContinuationToken string       `json:"continuationToken"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing the search results of %s-%s chart", name, version)
+		}
+		// The search matches names and versions by prefix
+		for _, c := range page.Items {
+			if c.Name == name && c.Version == version {
+				components = append(components, c)
+			}
+		}
+		if page.ContinuationToken == "" {
+			return components, nil
+		}
+		q.Set("continuationToken", page.ContinuationToken)
+	}
+}
+
+// chartFile returns the name of a chart package
+func chartFile(name, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+// Upload uploads a chart to the repo
+func (r *Repo) Upload(file string, metadata *chart.Metadata) error {
+	// Invalidate cache to avoid inconsistency between an old cache result and
+	// the chart repo
+	if err := r.cache.Invalidate(chartFile(metadata.Name, metadata.Version)); err != nil {
+		return errors.Trace(err)
+	}
+
+	// The chart is streamed from disk so big charts are not kept in memory
+	body, contentType, size, err := utils.NewMultipartFileBody("helm.asset", file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, r.apiURL("components", url.Values{"repository": {r.name}}), nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if req.Body, err = body(); err != nil {
+		return errors.Trace(err)
+	}
+	req.GetBody = body
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	res, err := r.do(req)
+	if err != nil {
+		return errors.Annotatef(err, "uploading %q chart", file)
+	}
+	defer res.Body.Close()
+	if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok {
+		bodyStr := utils.HTTPResponseBody(res)
+		return errors.Errorf("unable to upload %q chart, got HTTP Status: %s, Resp: %v", file, res.Status, bodyStr)
+	}
+	return nil
+}
+
+// ChartDigest downloads a stored chart and returns its SHA256 digest
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	u := RepositoryURL(r.base, r.name)
+	u.Path += "/" + chartFile(name, version)
+	return utils.RemoteSha256(u.String(), r.do)
+}
+
+// Fetch downloads a chart from the repo
+func (r *Repo) Fetch(name string, version string) (string, error) {
+	return r.helm.Fetch(name, version)
+}
+
+// List lists all chart names in the repo
+func (r *Repo) List() ([]string, error) {
+	return r.helm.List()
+}
+
+// ListChartVersions lists all versions of a chart
+func (r *Repo) ListChartVersions(name string) ([]string, error) {
+	return r.helm.ListChartVersions(name)
+}
+
+// Has checks if a repo has a specific chart. The index loaded by the client
+// does not include the charts uploaded since, so they are searched in the
+// repository as well.
+func (r *Repo) Has(name string, version string) (bool, error) {
+	if ok, err := r.helm.Has(name, version); err != nil || ok {
+		return ok, errors.Trace(err)
+	}
+	components, err := r.Search(name, version)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return len(components) > 0, nil
+}
+
+// GetChartDetails returns the details of a chart
+func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	return r.helm.GetChartDetails(name, version)
+}
+
+// GetIndexEntry returns the index entry of a chart, with absolute URLs
+func (r *Repo) GetIndexEntry(name string, version string) (*repo.ChartVersion, error) {
+	return r.helm.GetIndexEntry(name, version)
+}
+
+// Delete removes a chart from the repo
+func (r *Repo) Delete(name string, version string) error {
+	components, err := r.Search(name, version)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, c := range components {
+		req, err := http.NewRequest(http.MethodDelete, r.apiURL("components/"+url.PathEscape(c.ID), nil), nil)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		res, err := r.do(req)
+		if err != nil {
+			return errors.Annotatef(err, "deleting %s-%s chart", name, version)
+		}
+		res.Body.Close()
+		if ok := res.StatusCode >= 200 && res.StatusCode <= 299; !ok && res.StatusCode != http.StatusNotFound {
+			return errors.Errorf("unable to delete %s-%s chart, got HTTP Status: %s", name, version, res.Status)
+		}
+	}
+
+	// Invalidate cache to avoid inconsistency with the chart repo
+	return errors.Trace(r.cache.Invalidate(chartFile(name, version)))
+}
+
+// FetchProvenance fetches the provenance file of a chart
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	return r.helm.FetchProvenance(name, version)
+}
+
+// InvalidIndexEntries returns the index entries that could not be loaded
+func (r *Repo) InvalidIndexEntries() []string {
+	return r.helm.InvalidIndexEntries()
+}
+
+// Reload reloads the index
+func (r *Repo) Reload() error {
+	return r.helm.Reload()
+}
@@ -0,0 +1,137 @@
+package nexus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache/cachedisk"
+)
+
+// fakeNexus serves a "helm-hosted" repository. Its search results are split
+// in pages of one component.
+type fakeNexus struct {
+	mu         sync.Mutex
+	components map[string]*Component
+	t          *testing.T
+}
+
+func (f *fakeNexus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "admin123" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.URL.Path == "/nexus/repository/helm-hosted/index.yaml":
+		fmt.Fprint(w, "apiVersion: v1\nentries: {}\n")
+	case r.Method == http.MethodPost && r.URL.Path == "/nexus/service/rest/v1/components":
+		if got := r.URL.Query().Get("repository"); got != "helm-hosted" {
+			f.t.Errorf("got: %q repository, want: helm-hosted", got)
+		}
+		file, _, err := r.FormFile("helm.asset")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		ch, err := loader.LoadArchive(file)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id := fmt.Sprintf("aGVsbS1ob3N0ZWQ6%d", len(f.components))
+		f.components[id] = &Component{ID: id, Name: ch.Name(), Version: ch.Metadata.Version}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Path == "/nexus/service/rest/v1/search":
+		q := r.URL.Query()
+		var items []*Component
+		for _, c := range f.components {
+			if strings.HasPrefix(c.Name, q.Get("name")) && strings.HasPrefix(c.Version, q.Get("version")) {
+				items = append(items, c)
+			}
+		}
+		// A first page without the component
+		page := map[string]interface{}{"items": []*Component{}, "continuationToken": "next"}
+		if q.Get("continuationToken") == "next" {
+			page = map[string]interface{}{"items": items}
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/nexus/service/rest/v1/components/"):
+		id := strings.TrimPrefix(r.URL.Path, "/nexus/service/rest/v1/components/")
+		if _, ok := f.components[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.components, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	base, name, err := ParseURL("https://nexus.example.com/repository/helm-hosted/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.String() != "https://nexus.example.com" || name != "helm-hosted" {
+		t.Errorf("got: %q, %q, want: https://nexus.example.com, helm-hosted", base, name)
+	}
+	for _, u := range []string{"https://nexus.example.com/helm-hosted", "https://nexus.example.com/repository/"} {
+		if _, _, err := ParseURL(u); !errors.IsNotValid(err) {
+			t.Errorf("got: %v, want a not valid error for %q", err, u)
+		}
+	}
+}
+
+func TestRepo(t *testing.T) {
+	f := &fakeNexus{components: map[string]*Component{}, t: t}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	repo := &api.Repo{
+		Kind: api.Kind_NEXUS,
+		Url:  srv.URL + "/nexus/repository/helm-hosted",
+		Auth: &api.Auth{Username: "admin", Password: "admin123"},
+	}
+	c, err := cachedisk.New(filepath.Join(t.TempDir(), "cache"), repo.GetUrl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := New(repo, c, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Upload("../../../../testdata/apache-7.3.15.tgz", &chart.Metadata{Name: "apache", Version: "7.3.15"}); err != nil {
+		t.Fatal(err)
+	}
+	// The chart is not in the loaded index, but is found by the search API
+	if ok, err := r.Has("apache", "7.3.15"); err != nil || !ok {
+		t.Errorf("got: %v, %v, want apache-7.3.15 in the repository", ok, err)
+	}
+	// Versions are matched exactly
+	if ok, err := r.Has("apache", "7.3.1"); err != nil || ok {
+		t.Errorf("got: %v, %v, want no apache-7.3.1 in the repository", ok, err)
+	}
+
+	if err := r.Delete("apache", "7.3.15"); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.components) != 0 {
+		t.Errorf("got: %v, want apache-7.3.15 deleted", f.components)
+	}
+}
@@ -2,24 +2,32 @@ package oci
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/containerd/containerd/errdefs"
 	"github.com/containerd/containerd/remotes"
 	"github.com/containerd/containerd/remotes/docker"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/juju/errors"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 	"helm.sh/helm/v3/pkg/chart"
 	"k8s.io/klog"
 	"oras.land/oras-go/pkg/content"
@@ -30,6 +38,7 @@ import (
 	"github.com/bitnami-labs/charts-syncer/internal/cache"
 	"github.com/bitnami-labs/charts-syncer/internal/indexer"
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 )
 
@@ -42,20 +51,74 @@ const (
 	// HelmChartContentLayerMediaTypeDeprecated is the (deprecated) reserved media type for Helm
 	// chart package content
 	HelmChartContentLayerMediaTypeDeprecated = "application/tar+gzip"
+	// HelmChartProvenanceLayerMediaType is the reserved media type for Helm
+	// chart provenance files
+	HelmChartProvenanceLayerMediaType = "application/vnd.cncf.helm.chart.provenance.v1.prov"
 	// ImageManifestMediaType is the reserved media type for OCI manifests
 	ImageManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	// DockerManifestMediaType is the media type of Docker v2 manifests, which
+	// some registries convert OCI manifests to
+	DockerManifestMediaType = "application/vnd.docker.distribution.manifest.v2+json"
+	// InTotoMediaType is the media type of in-toto statements
+	InTotoMediaType = "application/vnd.in-toto+json"
+	// DSSEMediaType is the media type of the DSSE envelopes of the signed
+	// in-toto statements
+	DSSEMediaType = "application/vnd.dsse.envelope.v1+json"
+	// SimpleSigningMediaType is the media type of the payloads of cosign
+	// signatures
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
 )
 
-// Repo allows to operate a chart repository.
+// Annotations of the layers of cosign signatures
+const (
+	SignatureAnnotation   = "dev.cosignproject.cosign/signature"
+	CertificateAnnotation = "dev.sigstore.cosign/certificate"
+	ChainAnnotation       = "dev.sigstore.cosign/chain"
+	BundleAnnotation      = "dev.sigstore.cosign/bundle"
+	// PredicateTypeAnnotation is the predicate type of the attestations
+	PredicateTypeAnnotation = "predicateType"
+)
+
+const (
+	// tagsPageSize is the number of tags requested per page when listing the
+	// tags of a repository
+	tagsPageSize = 1000
+	// manifestWorkers is the number of tag manifests fetched concurrently to
+	// find the tags of chart versions
+	manifestWorkers = 8
+)
+
+// manifestAccept is the Accept header of manifest requests
+var manifestAccept = ImageManifestMediaType + ", " + DockerManifestMediaType
+
+// nextLinkRegex matches the link to the next page of a paginated response
+var nextLinkRegex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// Repo allows to operate a chart repository. It is safe for concurrent use.
 type Repo struct {
 	url      *url.URL
 	username string
 	password string
 	insecure bool
 
+	// entriesMu guards the entries
+	entriesMu      sync.RWMutex
 	entries        map[string][]string
 	cache          cache.Cacher
 	dockerResolver remotes.Resolver
+	// authorizer answers the authentication challenges of the requests that
+	// are not sent through the resolver, like the chunked uploads
+	authorizer docker.Authorizer
+
+	// uploadChunkSize is the size of the chunks used to upload chart
+	// packages. Packages are uploaded in a single request if it is zero.
+	uploadChunkSize int64
+
+	// Media types of the pushed charts, and whether their layers are pushed
+	// without title annotation
+	configMediaType  string
+	contentMediaType string
+	omitTitles       bool
 }
 
 // Tags contains the tags for a specific OCI artifact
@@ -78,18 +141,43 @@ func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
 	}
 	resolver := newDockerResolver(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), insecure)
 
-	return NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, entries, resolver)
+	r, err := NewRaw(u, repo.GetAuth().GetUsername(), repo.GetAuth().GetPassword(), c, insecure, entries, resolver)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r.uploadChunkSize = repo.GetUploadChunkSize()
+	if t := repo.GetOciMediaTypes().GetConfig(); t != "" {
+		r.configMediaType = t
+	}
+	if t := repo.GetOciMediaTypes().GetContent(); t != "" {
+		r.contentMediaType = t
+	}
+	r.omitTitles = repo.GetOciMediaTypes().GetOmitTitles()
+	return r, nil
 }
 
 // NewRaw creates a Repo object.
 func NewRaw(u *url.URL, user string, pass string, c cache.Cacher, insecure bool, entries map[string][]string, resolver remotes.Resolver) (*Repo, error) {
-	return &Repo{url: u, username: user, password: pass, cache: c, insecure: insecure, entries: entries, dockerResolver: resolver}, nil
+	return &Repo{
+		url:              u,
+		username:         user,
+		password:         pass,
+		cache:            c,
+		insecure:         insecure,
+		entries:          entries,
+		dockerResolver:   resolver,
+		authorizer:       newAuthorizer(user, pass, httpClient(insecure)),
+		configMediaType:  HelmChartConfigMediaType,
+		contentMediaType: HelmChartContentLayerMediaType,
+	}, nil
 }
 
 // List lists all chart names in a repo
 func (r *Repo) List() ([]string, error) {
 	// If entries is not populated, it means we couldn't load any index file, so we need the charts filter in the
 	// configuration file. The List() caller will handle this case
+	r.entriesMu.RLock()
+	defer r.entriesMu.RUnlock()
 	if len(r.entries) == 0 {
 		return []string{}, nil
 	}
@@ -101,6 +189,12 @@ func (r *Repo) List() ([]string, error) {
 	return names, nil
 }
 
+// ociTag returns the tag of a chart version. "+" is not allowed in OCI tags,
+// so it is replaced by "_" as Helm does.
+func ociTag(version string) string {
+	return strings.ReplaceAll(version, "+", "_")
+}
+
 // getTagManifest returns the manifests of a published tag
 func (r *Repo) getTagManifest(name, version string) (*ocispec.Manifest, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
@@ -108,9 +202,9 @@ func (r *Repo) getTagManifest(name, version string) (*ocispec.Manifest, error) {
 
 	u := *r.url
 	// Form API endpoint URL from repo url
-	u.Path = path.Join("v2", u.Path, name, "manifests", version)
+	u.Path = path.Join("v2", u.Path, name, "manifests", ociTag(version))
 	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	req.Header.Set("Accept", ImageManifestMediaType)
+	req.Header.Set("Accept", manifestAccept)
 
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -149,10 +243,8 @@ func (r *Repo) getChartDigest(name, version string) (string, error) {
 	if err != nil {
 		return "", errors.Trace(err)
 	}
-	for _, layer := range tm.Layers {
-		if isHelmChartContentLayerMediaType(layer.MediaType) {
-			return layer.Digest.String(), nil
-		}
+	if layer := chartLayer(tm); layer != nil {
+		return layer.Digest.String(), nil
 	}
 
 	return "", errors.NotFoundf("%s:%s digest", name, version)
@@ -163,18 +255,94 @@ func (r *Repo) ListChartVersions(name string) ([]string, error) {
 	// If entries is populated use it to list the chart versions
 	// Otherwise, we need the charts list to be defined in the config file, retrieve all the tags for those chart names
 	// and verify which tags are real charts by checking its mimeType.
-	if _, ok := r.entries[name]; ok {
-		return r.entries[name], nil
+	r.entriesMu.RLock()
+	versions, ok := r.entries[name]
+	r.entriesMu.RUnlock()
+	if ok {
+		return versions, nil
+	}
+	tags, err := r.listTags(name)
+	if errors.IsNotFound(err) {
+		// If status not found 404, it could mean the asset has no release yet
+		// TODO (tpizarro): Use the NotFound error instead of just returning nil and handle the case in the caller
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
-	defer cancel()
 
+	// Chart versions are semver, so the other tags, like the ones of
+	// signatures or attestations, are skipped without fetching their manifest
+	var candidates []string
+	for _, tag := range tags {
+		if _, err := semver.StrictNewVersion(strings.ReplaceAll(tag, "_", "+")); err != nil {
+			klog.V(5).Infof("Skipping %q tag as it is not a chart version", tag)
+			continue
+		}
+		candidates = append(candidates, tag)
+	}
+
+	isChart := make([]bool, len(candidates))
+	g := &errgroup.Group{}
+	g.SetLimit(manifestWorkers)
+	for i, tag := range candidates {
+		i, tag := i, tag
+		g.Go(func() error {
+			tm, err := r.getTagManifest(name, tag)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			isChart[i] = isChartManifest(tm)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	chartTags := []string{}
+	for i, tag := range candidates {
+		if isChart[i] {
+			chartTags = append(chartTags, strings.ReplaceAll(tag, "_", "+"))
+		} else {
+			klog.V(5).Infof("Skipping %q tag as it is not chart type", tag)
+		}
+	}
+	return chartTags, nil
+}
+
+// listTags lists every tag of a repository, following the pagination links
+// of the registry. It returns a NotFound error if the repository does not
+// exist.
+func (r *Repo) listTags(name string) ([]string, error) {
 	u := *r.url
 	// Form API endpoint URL from repository URL
 	u.Path = path.Join("v2", u.Path, name, "tags", "list")
+	u.RawQuery = fmt.Sprintf("n=%d", tagsPageSize)
+
+	var tags []string
+	for page := &u; page != nil; {
+		pageTags, next, err := r.getTagsPage(page)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		tags = append(tags, pageTags...)
+		// Stop if a registry keeps linking the same page
+		if next != nil && next.String() == page.String() {
+			break
+		}
+		page = next
+	}
+	return tags, nil
+}
+
+// getTagsPage returns a page of tags and the URL of the next page, if any
+func (r *Repo) getTagsPage(u *url.URL) ([]string, *url.URL, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	if r.username != "" && r.password != "" {
 		req.SetBasicAuth(r.username, r.password)
@@ -185,43 +353,90 @@ func (r *Repo) ListChartVersions(name string) ([]string, error) {
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, errors.Trace(err)
+		return nil, nil, errors.Trace(err)
 	}
 	defer resp.Body.Close()
 	status := resp.StatusCode
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.Errorf("unexpected response — %d %q — from %s", status, http.StatusText(status), u.String())
+		return nil, nil, errors.Errorf("unexpected response — %d %q — from %s", status, http.StatusText(status), u.String())
 	}
 	// Valid response codes from OCI registries are listed here:
 	// https://github.com/opencontainers/distribution-spec/blob/master/spec.md#endpoints
 	switch status {
 	case http.StatusNotFound:
-		// If status not found 404, it could mean the asset has no release yet
-		// TODO (tpizarro): Use the NotFound error instead of just returning nil and handle the case in the caller
-		return []string{}, nil
+		return nil, nil, errors.NotFoundf("%s", u.String())
 	case http.StatusOK:
 		// do nothing, just continue
 	default:
-		return nil, errors.Errorf("unexpected response — %d %q, %s — from %s", status, http.StatusText(status), string(body), u.String())
+		return nil, nil, errors.Errorf("unexpected response — %d %q, %s — from %s", status, http.StatusText(status), string(body), u.String())
 	}
 	ot := &Tags{}
 	if err := json.Unmarshal(body, ot); err != nil {
-		return nil, err
+		return nil, nil, errors.Trace(err)
 	}
-	chartTags := []string{}
-	for _, tag := range ot.Tags {
-		tm, err := r.getTagManifest(name, tag)
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		if tm.Config.MediaType == HelmChartConfigMediaType {
-			chartTags = append(chartTags, tag)
-		} else {
-			klog.V(5).Infof("Skipping %q tag as it is not chart type", tag)
+
+	// The next page is linked as in `</v2/name/tags/list?n=1000&last=tag>; rel="next"`
+	m := nextLinkRegex.FindStringSubmatch(resp.Header.Get("Link"))
+	if m == nil {
+		return ot.Tags, nil, nil
+	}
+	next, err := u.Parse(m[1])
+	if err != nil {
+		return nil, nil, errors.Annotatef(err, "parsing the next page link of %s", u.String())
+	}
+	return ot.Tags, next, nil
+}
+
+// FetchProvenance fetches the provenance file of a chart from its Helm
+// provenance layer
+func (r *Repo) FetchProvenance(name string, version string) ([]byte, error) {
+	tm, err := r.getTagManifest(name, version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var layer *ocispec.Descriptor
+	for i := range tm.Layers {
+		if tm.Layers[i].MediaType == HelmChartProvenanceLayerMediaType {
+			layer = &tm.Layers[i]
 		}
 	}
-	return chartTags, nil
+	if layer == nil {
+		return nil, errors.NotFoundf("%s:%s provenance file", name, version)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
+	defer cancel()
+	u := *r.url
+	u.Path = path.Join("v2", u.Path, name, "blobs", layer.Digest.String())
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyStr := utils.HTTPResponseBody(resp)
+		return nil, errors.Errorf("unable to fetch %s:%s provenance file, got HTTP Status: %s, Resp: %v", name, version, resp.Status, bodyStr)
+	}
+	prov, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if got := digest.FromBytes(prov); got != layer.Digest {
+		return nil, errors.Errorf("%s:%s provenance file digest mismatch (got: %s, want: %s)", name, version, got, layer.Digest)
+	}
+	return prov, nil
 }
 
 // GetDownloadURL returns the URL to download a chart
@@ -236,6 +451,17 @@ func (r *Repo) GetDownloadURL(name string, version string) (string, error) {
 	return u.String(), nil
 }
 
+// ChartDigest returns the digest of the chart layer of a pushed chart, read
+// from its manifest in the registry. It is the SHA256 digest of the chart
+// package.
+func (r *Repo) ChartDigest(name string, version string) (string, error) {
+	digest, err := r.getChartDigest(name, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return strings.TrimPrefix(digest, "sha256:"), nil
+}
+
 // Fetch fetches a chart
 func (r *Repo) Fetch(name string, version string) (string, error) {
 	statusHandlerFn := func(res *http.Response) error {
@@ -275,13 +501,13 @@ func (r *Repo) Has(name string, version string) (bool, error) {
 	// Form API endpoint URL from repo url as per the specification:
 	// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#checking-if-content-exists-in-the-registry
 	// The request should return 200 OK if the manifest exists.
-	u.Path = path.Join("v2", u.Path, name, "manifests", version)
+	u.Path = path.Join("v2", u.Path, name, "manifests", ociTag(version))
 	req, err := http.NewRequestWithContext(ctx, "HEAD", u.String(), nil)
 	if err != nil {
 		return false, errors.Trace(err)
 	}
 
-	req.Header.Set("Accept", ImageManifestMediaType)
+	req.Header.Set("Accept", manifestAccept)
 	if r.username != "" && r.password != "" {
 		req.SetBasicAuth(r.username, r.password)
 	}
@@ -303,8 +529,63 @@ func (r *Repo) Has(name string, version string) (bool, error) {
 	return false, nil
 }
 
+// getManifestDigest returns the digest of the manifest of a published tag, or
+// an empty string if the tag does not exist
+func (r *Repo) getManifestDigest(name, version string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
+	defer cancel()
+
+	u := *r.url
+	u.Path = path.Join("v2", u.Path, name, "manifests", ociTag(version))
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	switch status {
+	case http.StatusNotFound:
+		return "", nil
+	case http.StatusOK:
+		// do nothing, just continue
+	default:
+		return "", errors.Errorf("unexpected response — %d %q — from %s", status, http.StatusText(status), u.String())
+	}
+	// Not all registries return the Docker-Content-Digest header, so the
+	// digest is computed from the manifest itself
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(body)), nil
+}
+
 // Upload uploads a chart to the repo
 func (r *Repo) Upload(file string, metadata *chart.Metadata) error {
+	return r.upload(file, nil, metadata)
+}
+
+// UploadWithProvenance uploads a chart with its provenance file as the Helm
+// provenance layer, so `helm pull --verify` can verify it
+func (r *Repo) UploadWithProvenance(file string, prov []byte, metadata *chart.Metadata) error {
+	return r.upload(file, prov, metadata)
+}
+
+// upload pushes a chart, and its provenance file if any, to the repo
+func (r *Repo) upload(file string, prov []byte, metadata *chart.Metadata) error {
 	name := metadata.Name
 	version := metadata.Version
 	// Invalidate cache to avoid inconsistency between an old cache result and
@@ -323,51 +604,647 @@ func (r *Repo) Upload(file string, metadata *chart.Metadata) error {
 		return errors.Trace(err)
 	}
 
-	memoryStore := content.NewMemory()
-	resolver := r.dockerResolver
-
-	// Preparing layers
-	fileName := filepath.Base(file)
-	fileMediaType := HelmChartContentLayerMediaType
-	fileBuffer, err := ioutil.ReadFile(file)
+	// The package is streamed from disk, only the config and the manifest
+	// are kept in memory
+	absFile, err := filepath.Abs(file)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	blobDesc, err := memoryStore.Add(fileName, fileMediaType, fileBuffer)
+	fileStore := content.NewFile(filepath.Dir(absFile))
+	defer fileStore.Close()
+	blobDesc, _, err := r.storeManifest(fileStore, absFile, prov, metadata)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
+	// The registry skips blobs that already exist when pushing the manifest
+	if r.uploadChunkSize > 0 {
+		if err := r.uploadBlobInChunks(name, blobDesc, file); err != nil {
+			return errors.Annotatef(err, "uploading %q in chunks", file)
+		}
+	}
+
+	// Perform push
+	copyOpts := []oras.CopyOpt{
+		oras.WithAllowedMediaType(r.configMediaType, r.contentMediaType, HelmChartProvenanceLayerMediaType),
+		oras.WithNameValidation(nil),
+	}
+	chartRef := r.chartRef(name, version)
+	if _, err := oras.Copy(orascontext.Background(), fileStore, chartRef, r.dockerResolver, chartRef, copyOpts...); err != nil {
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// storeManifest adds a chart package, its config and its provenance file if
+// any to a file store, along with the manifest pushing them under the
+// reference of the chart. It returns the descriptors of the package and of
+// the manifest.
+func (r *Repo) storeManifest(fileStore *content.File, file string, prov []byte, metadata *chart.Metadata) (ocispec.Descriptor, ocispec.Descriptor, error) {
+	// Preparing layers
+	blobDesc, err := fileStore.Add(filepath.Base(file), r.contentMediaType, file)
+	if err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.Trace(err)
+	}
+	if r.omitTitles {
+		// The file store finds the package by the title of its own copy of
+		// the descriptor, which shares the annotations map
+		annotations := map[string]string{}
+		for k, v := range blobDesc.Annotations {
+			if k != ocispec.AnnotationTitle {
+				annotations[k] = v
+			}
+		}
+		blobDesc.Annotations = annotations
+	}
+
 	// Preparing Oras config
 	configBytes, err := json.Marshal(metadata)
 	if err != nil {
-		return err
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.Trace(err)
+	}
+	configDesc := ocispec.Descriptor{
+		MediaType: r.configMediaType,
+		Digest:    digest.FromBytes(configBytes),
+		Size:      int64(len(configBytes)),
+	}
+	if err := fileStore.Load(configDesc, configBytes); err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.Trace(err)
 	}
-	configDesc, err := memoryStore.Add("", HelmChartConfigMediaType, configBytes)
+
+	layers := []ocispec.Descriptor{blobDesc}
+	if prov != nil {
+		provDesc := ocispec.Descriptor{
+			MediaType: HelmChartProvenanceLayerMediaType,
+			Digest:    digest.FromBytes(prov),
+			Size:      int64(len(prov)),
+		}
+		if err := fileStore.Load(provDesc, prov); err != nil {
+			return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.Trace(err)
+		}
+		layers = append(layers, provDesc)
+	}
+
+	manifest, manifestDesc, err := content.GenerateManifest(&configDesc, nil, layers...)
 	if err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.Trace(err)
+	}
+	if err := fileStore.StoreManifest(r.chartRef(metadata.Name, metadata.Version), manifestDesc, manifest); err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.Trace(err)
+	}
+	return blobDesc, manifestDesc, nil
+}
+
+// ManifestDigest returns the digest of the manifest of a chart in the
+// registry, or an empty string if the chart does not exist
+func (r *Repo) ManifestDigest(name string, version string) (string, error) {
+	return r.getManifestDigest(name, version)
+}
+
+// UploadManifestDigest returns the digest of the manifest uploading a chart,
+// with its provenance file if any, would push. Charts are repackaged
+// deterministically, so the same chart always gets the same manifest.
+func (r *Repo) UploadManifestDigest(file string, prov []byte, metadata *chart.Metadata) (string, error) {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	fileStore := content.NewFile(filepath.Dir(absFile))
+	defer fileStore.Close()
+	_, manifestDesc, err := r.storeManifest(fileStore, absFile, prov, metadata)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return manifestDesc.Digest.String(), nil
+}
+
+// fetchOnlyResolver is a resolver that cannot push content, handed to the
+// clients copying charts from the registry
+type fetchOnlyResolver struct {
+	remotes.Resolver
+}
+
+// Pusher implements the remotes.Resolver interface
+func (fetchOnlyResolver) Pusher(_ context.Context, ref string) (remotes.Pusher, error) {
+	return nil, errors.NotSupportedf("pushing %q with a read-only resolver", ref)
+}
+
+// chartRef returns the reference of a chart in the repo
+func (r *Repo) chartRef(name, version string) string {
+	return fmt.Sprintf("%s%s/%s:%s", r.url.Host, r.url.Path, name, ociTag(version))
+}
+
+// OCIReference returns the reference of a chart and a resolver that can only
+// fetch it
+func (r *Repo) OCIReference(name string, version string) (string, remotes.Resolver, error) {
+	return r.chartRef(name, version), fetchOnlyResolver{r.dockerResolver}, nil
+}
+
+// Copy copies the manifest of a chart and its blobs from another OCI
+// registry as they are, so the chart keeps its manifest digest. Manifests
+// with other media types than the ones of the repo are not copied.
+func (r *Repo) Copy(src client.ChartsReader, name string, version string, keepProvenance bool) error {
+	fromRef, from, desc, err := r.copySource(src, name, version, keepProvenance)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	toRef := r.chartRef(name, version)
+	klog.V(3).Infof("Copying %q to %q (%s)...", fromRef, toRef, desc.Digest)
+	if _, err := oras.Copy(orascontext.Background(), from, fromRef, r.dockerResolver, toRef, oras.WithNameValidation(nil)); err != nil {
+		return errors.Annotatef(err, "copying %q to %q", fromRef, toRef)
+	}
+	if err := r.cache.Invalidate(fmt.Sprintf("%s-%s.tgz", name, version)); err != nil {
 		return errors.Trace(err)
 	}
+	return errors.Trace(r.copyArtifacts(src, from, fromRef, name, desc.Digest.String()))
+}
+
+// CopyManifestDigest returns the digest of the manifest copying a chart from
+// another OCI registry would push, i.e. the digest of its source manifest. It
+// returns a NotSupported error if the chart cannot be copied as is.
+func (r *Repo) CopyManifestDigest(src client.ChartsReader, name string, version string, keepProvenance bool) (string, error) {
+	_, _, desc, err := r.copySource(src, name, version, keepProvenance)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// copySource returns the reference, the resolver and the manifest descriptor
+// of a chart of another OCI registry, checking that it can be copied to the
+// repo as is
+func (r *Repo) copySource(src client.ChartsReader, name string, version string, keepProvenance bool) (string, remotes.Resolver, ocispec.Descriptor, error) {
+	rr, ok := src.(client.OCIReferenceReader)
+	if !ok {
+		return "", nil, ocispec.Descriptor{}, errors.NotSupportedf("copying charts from %T clients", src)
+	}
+	fromRef, from, err := rr.OCIReference(name, version)
+	if err != nil {
+		return "", nil, ocispec.Descriptor{}, errors.Trace(err)
+	}
 
-	manifest, manifestDesc, err := content.GenerateManifest(&configDesc, nil, blobDesc)
+	ctx := orascontext.Background()
+	_, desc, err := from.Resolve(ctx, fromRef)
+	if err != nil {
+		return "", nil, ocispec.Descriptor{}, errors.Annotatef(err, "resolving %q", fromRef)
+	}
+	fetcher, err := from.Fetcher(ctx, fromRef)
+	if err != nil {
+		return "", nil, ocispec.Descriptor{}, errors.Trace(err)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return "", nil, ocispec.Descriptor{}, errors.Annotatef(err, "fetching %q manifest", fromRef)
+	}
+	defer rc.Close()
+	tm := &ocispec.Manifest{}
+	if err := json.NewDecoder(rc).Decode(tm); err != nil {
+		return "", nil, ocispec.Descriptor{}, errors.Annotatef(err, "parsing %q manifest", fromRef)
+	}
+	layer := chartLayer(tm)
+	switch {
+	case layer == nil || tm.Config.MediaType != r.configMediaType || layer.MediaType != r.contentMediaType:
+		return "", nil, ocispec.Descriptor{}, errors.NotSupportedf("copying %q chart with other media types than the target ones", fromRef)
+	case r.omitTitles && layer.Annotations[ocispec.AnnotationTitle] != "":
+		return "", nil, ocispec.Descriptor{}, errors.NotSupportedf("copying %q chart with a title annotation", fromRef)
+	}
+	for _, l := range tm.Layers {
+		if l.MediaType == HelmChartProvenanceLayerMediaType && !keepProvenance {
+			return "", nil, ocispec.Descriptor{}, errors.NotSupportedf("copying %q chart without its provenance file", fromRef)
+		}
+	}
+	return fromRef, from, desc, nil
+}
+
+// attachedTagSuffixes are the suffixes of the tags the signatures,
+// attestations and SBOMs are attached to a manifest with
+var attachedTagSuffixes = []string{".sig", ".att", ".intoto", ".sbom"}
+
+// copyArtifacts copies the artifacts attached to a copied chart manifest, like
+// its cosign signatures and attestations and the OCI referrers of the
+// manifest. They are still valid as the chart keeps its digest.
+func (r *Repo) copyArtifacts(src client.ChartsReader, from remotes.Resolver, fromRef, name, manifestDigest string) error {
+	ctx := orascontext.Background()
+	// Chart references always have a tag
+	fromRepo := fromRef[:strings.LastIndex(fromRef, ":")]
+	toRepo := fmt.Sprintf("%s%s/%s", r.url.Host, r.url.Path, name)
+	digestTag := strings.Replace(manifestDigest, ":", "-", 1)
+
+	tags := []string{}
+	for _, suffix := range attachedTagSuffixes {
+		tags = append(tags, digestTag+suffix)
+	}
+	var referrers []ocispec.Descriptor
+	rr, ok := src.(client.OCIReferrersReader)
+	if ok {
+		var err error
+		if referrers, err = rr.Referrers(name, manifestDigest); err != nil && !errors.IsNotSupported(err) {
+			return errors.Annotatef(err, "listing referrers of %s@%s", fromRepo, manifestDigest)
+		}
+		ok = err == nil
+	}
+	if !ok {
+		// Registries without referrers API list them in an index tagged
+		// with the digest of the manifest
+		tags = append(tags, digestTag)
+	}
+
+	for _, tag := range tags {
+		ref := fmt.Sprintf("%s:%s", fromRepo, tag)
+		if _, _, err := from.Resolve(ctx, ref); errdefs.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return errors.Annotatef(err, "resolving %q", ref)
+		}
+		klog.V(3).Infof("Copying %q to %s:%s...", ref, toRepo, tag)
+		if _, err := oras.Copy(ctx, from, ref, r.dockerResolver, fmt.Sprintf("%s:%s", toRepo, tag), oras.WithNameValidation(nil)); err != nil {
+			return errors.Annotatef(err, "copying %q", ref)
+		}
+	}
+	for _, d := range referrers {
+		ref := fmt.Sprintf("%s@%s", fromRepo, d.Digest)
+		klog.V(3).Infof("Copying %q referrer (%s) to %q...", ref, d.MediaType, toRepo)
+		// Referrers are pushed by digest, they are found through their
+		// subject
+		if _, err := oras.Copy(ctx, from, ref, r.dockerResolver, toRepo, oras.WithNameValidation(nil)); err != nil {
+			return errors.Annotatef(err, "copying %q", ref)
+		}
+	}
+	return nil
+}
+
+// Referrers lists the artifacts referring to a manifest with the OCI
+// referrers API. It returns a NotSupported error if the registry does not
+// implement it.
+func (r *Repo) Referrers(name string, manifestDigest string) ([]ocispec.Descriptor, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
+	defer cancel()
+
+	u := *r.url
+	u.Path = path.Join("v2", u.Path, name, "referrers", manifestDigest)
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set("Accept", ocispec.MediaTypeImageIndex)
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	switch status {
+	case http.StatusOK:
+		// do nothing, just continue
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusBadRequest:
+		return nil, errors.NotSupportedf("referrers API of %s", r.url.Host)
+	default:
+		return nil, errors.Errorf("unexpected response — %d %q — from %s", status, http.StatusText(status), u.String())
+	}
+	// Registries implementing the API return an OCI index
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.HasPrefix(ct, ocispec.MediaTypeImageIndex) {
+		return nil, errors.NotSupportedf("referrers API of %s", r.url.Host)
+	}
+	index := &ocispec.Index{}
+	if err := json.NewDecoder(resp.Body).Decode(index); err != nil {
+		return nil, errors.Annotatef(err, "parsing referrers of %s@%s", name, manifestDigest)
+	}
+	return index.Manifests, nil
+}
+
+// UploadAttestation pushes the unsigned attestation of an uploaded chart. It
+// is attached to the chart manifest with a sha256-<manifest digest>.intoto
+// tag, as cosign expects signed envelopes under its .att tags.
+func (r *Repo) UploadAttestation(_ string, attestation []byte, metadata *chart.Metadata) error {
+	chartDigest, err := r.uploadedDigest(metadata)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	chartRef := fmt.Sprintf("%s%s/%s:%s", r.url.Host, r.url.Path, name, version)
-	if err := memoryStore.StoreManifest(chartRef, manifestDesc, manifest); err != nil {
+	return errors.Trace(r.attach(metadata.Name, statementTag(chartDigest), InTotoMediaType, nil, attestation))
+}
+
+// statementTag returns the tag of the unsigned attestation of a manifest
+func statementTag(manifestDigest string) string {
+	return strings.Replace(manifestDigest, ":", "-", 1) + ".intoto"
+}
+
+// UploadSignedAttestation signs the attestation of an uploaded chart and
+// pushes it like cosign attest does, as a DSSE envelope layer of the
+// sha256-<manifest digest>.att tag. Previous attestations are replaced.
+func (r *Repo) UploadSignedAttestation(attestation []byte, metadata *chart.Metadata, signer types.StatementSigner) error {
+	st := struct {
+		PredicateType string `json:"predicateType"`
+	}{}
+	if err := json.Unmarshal(attestation, &st); err != nil {
+		return errors.Annotatef(err, "parsing %s:%s attestation", metadata.Name, metadata.Version)
+	}
+	chartDigest, err := r.uploadedDigest(metadata)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sig, err := signer.SignStatement(attestation)
+	if err != nil {
+		return errors.Annotatef(err, "signing %s:%s attestation", metadata.Name, metadata.Version)
+	}
+	// The signature is in the envelope, cosign sets an empty annotation
+	annotations := signatureAnnotations(sig)
+	annotations[PredicateTypeAnnotation] = st.PredicateType
+	return errors.Trace(r.attach(metadata.Name, attestationTag(chartDigest), DSSEMediaType, annotations, sig.Payload))
+}
+
+// attestationTag returns the tag of the signed attestation of a manifest
+func attestationTag(manifestDigest string) string {
+	return strings.Replace(manifestDigest, ":", "-", 1) + ".att"
+}
+
+// UploadSBOM pushes the software bill of materials of an uploaded chart. Like
+// cosign attach sbom, it is attached to the chart manifest with the
+// sha256-<manifest digest>.sbom tag.
+func (r *Repo) UploadSBOM(_ string, sbom []byte, mediaType string, metadata *chart.Metadata) error {
+	chartDigest, err := r.uploadedDigest(metadata)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(r.attach(metadata.Name, sbomTag(chartDigest), mediaType, nil, sbom))
+}
+
+// sbomTag returns the tag of the software bill of materials of a manifest
+func sbomTag(manifestDigest string) string {
+	return strings.Replace(manifestDigest, ":", "-", 1) + ".sbom"
+}
+
+// uploadedDigest returns the manifest digest of an uploaded chart, or a not
+// found error
+func (r *Repo) uploadedDigest(metadata *chart.Metadata) (string, error) {
+	chartDigest, err := r.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if chartDigest == "" {
+		return "", errors.NotFoundf("%s:%s chart", metadata.Name, metadata.Version)
+	}
+	return chartDigest, nil
+}
+
+// attach pushes an artifact with a single layer, with the given annotations,
+// to a tag of the repository of a chart, like the ones derived from its
+// manifest digest
+func (r *Repo) attach(name, tag, mediaType string, annotations map[string]string, data []byte) error {
+	store := content.NewMemory()
+	layerDesc, err := store.Add("", mediaType, data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	layerDesc.Annotations = annotations
+	manifest, manifestDesc, config, configDesc, err := content.GenerateManifestAndConfig(nil, nil, layerDesc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	store.Set(configDesc, config)
+	ref := fmt.Sprintf("%s%s/%s:%s", r.url.Host, r.url.Path, name, tag)
+	if err := store.StoreManifest(ref, manifestDesc, manifest); err != nil {
 		return errors.Trace(err)
 	}
 
-	// Perform push
 	copyOpts := []oras.CopyOpt{
-		oras.WithAllowedMediaType(HelmChartConfigMediaType, HelmChartContentLayerMediaType),
+		oras.WithAllowedMediaType(configDesc.MediaType, mediaType),
 		oras.WithNameValidation(nil),
 	}
-	if _, err := oras.Copy(orascontext.Background(), memoryStore, chartRef, resolver, chartRef, copyOpts...); err != nil {
+	if _, err := oras.Copy(orascontext.Background(), store, ref, r.dockerResolver, ref, copyOpts...); err != nil {
 		return errors.Trace(err)
 	}
 	return nil
 }
 
+// UploadSignature signs the manifest of an uploaded chart and pushes the
+// signature like cosign does, as a simple signing layer of the
+// sha256-<manifest digest>.sig tag. Previous signatures are replaced.
+func (r *Repo) UploadSignature(metadata *chart.Metadata, signer types.ManifestSigner) error {
+	chartDigest, err := r.uploadedDigest(metadata)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	sig, err := signer.SignManifest(fmt.Sprintf("%s%s/%s", r.url.Host, r.url.Path, metadata.Name), chartDigest)
+	if err != nil {
+		return errors.Annotatef(err, "signing %s:%s chart", metadata.Name, metadata.Version)
+	}
+	return errors.Trace(r.attach(metadata.Name, signatureTag(chartDigest), SimpleSigningMediaType, signatureAnnotations(sig), sig.Payload))
+}
+
+// signatureAnnotations returns the annotations of the layer of a cosign
+// signature
+func signatureAnnotations(sig *types.Signature) map[string]string {
+	annotations := map[string]string{SignatureAnnotation: sig.Signature}
+	for k, v := range map[string]string{CertificateAnnotation: sig.Certificate, ChainAnnotation: sig.Chain, BundleAnnotation: sig.Bundle} {
+		if v != "" {
+			annotations[k] = v
+		}
+	}
+	return annotations
+}
+
+// signatureTag returns the tag of the cosign signature of a manifest
+func signatureTag(manifestDigest string) string {
+	return strings.Replace(manifestDigest, ":", "-", 1) + ".sig"
+}
+
+// Delete removes a chart from the repo by deleting its manifest, and the
+// signature, attestations and SBOM attached to it, if any
+func (r *Repo) Delete(name string, version string) error {
+	digest, err := r.getManifestDigest(name, version)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if digest == "" {
+		return nil
+	}
+
+	// The attached artifacts are deleted first, so they are not left behind
+	// if deleting the chart manifest fails
+	for _, tag := range []func(string) string{signatureTag, attestationTag, statementTag, sbomTag} {
+		artifactDigest, err := r.getManifestDigest(name, tag(digest))
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if artifactDigest == "" {
+			continue
+		}
+		if err := r.deleteManifest(name, artifactDigest); err != nil {
+			return errors.Annotatef(err, "deleting %s:%s", name, tag(digest))
+		}
+	}
+	if err := r.deleteManifest(name, digest); err != nil {
+		return errors.Trace(err)
+	}
+
+	r.entriesMu.Lock()
+	if entries, ok := r.entries[name]; ok {
+		versions := []string{}
+		for _, v := range entries {
+			if v != version {
+				versions = append(versions, v)
+			}
+		}
+		r.entries[name] = versions
+	}
+	r.entriesMu.Unlock()
+	return errors.Trace(r.cache.Invalidate(fmt.Sprintf("%s-%s.tgz", name, version)))
+}
+
+// deleteManifest deletes a manifest of a chart repository by digest. Missing
+// manifests are ignored.
+func (r *Repo) deleteManifest(name, digest string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), getTimeout)
+	defer cancel()
+
+	u := *r.url
+	u.Path = path.Join("v2", u.Path, name, "manifests", digest)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", u.String(), nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if r.username != "" && r.password != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	client := utils.DefaultClient
+	if r.insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	switch status {
+	case http.StatusAccepted, http.StatusOK, http.StatusNotFound:
+		// do nothing, just continue
+	default:
+		return errors.Errorf("unexpected response — %d %q — from %s", status, http.StatusText(status), u.String())
+	}
+	return nil
+}
+
+// uploadBlobInChunks uploads a blob using the chunked upload of the
+// distribution API, for registries or proxies limiting the size of a request.
+// The requests answer the basic or token authentication challenges of the
+// registry like the resolver does, scoped to pushing to the repository.
+func (r *Repo) uploadBlobInChunks(name string, desc ocispec.Descriptor, file string) error {
+	client := httpClient(r.insecure)
+	ctx := docker.WithScope(context.Background(), fmt.Sprintf("repository:%s:pull,push", strings.TrimPrefix(path.Join(r.url.Path, name), "/")))
+	// do performs an authorized request
+	do := func(method, u string, body func() (io.ReadCloser, error), size int64, header http.Header) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, u, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if body != nil {
+			if req.Body, err = body(); err != nil {
+				return nil, errors.Trace(err)
+			}
+			req.GetBody = body
+			req.ContentLength = size
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if err := r.authorizer.Authorize(ctx, req); err != nil {
+			return nil, errors.Annotatef(err, "authorizing %s %s", method, req.URL.Redacted())
+		}
+		klog.V(4).Infof("%s %q", method, u)
+		resp, err := client.Do(req)
+		return resp, errors.Trace(err)
+	}
+	// send performs a request, authenticating it again if the registry
+	// challenges it, and checks its response status. The response body is
+	// always closed.
+	send := func(method, u string, body func() (io.ReadCloser, error), size int64, header http.Header, want ...int) (*http.Response, error) {
+		resp, err := do(method, u, body, size, header)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			resp.Body.Close()
+			if err := r.authorizer.AddResponses(ctx, []*http.Response{resp}); err != nil {
+				return nil, errors.Annotatef(err, "authenticating %s %s", method, resp.Request.URL.Redacted())
+			}
+			if resp, err = do(method, u, body, size, header); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		defer resp.Body.Close()
+		for _, w := range want {
+			if resp.StatusCode == w {
+				return resp, nil
+			}
+		}
+		bodyStr := utils.HTTPResponseBody(resp)
+		return nil, errors.Errorf("unexpected response — %s — from %s %s: %s", resp.Status, method, resp.Request.URL.Redacted(), bodyStr)
+	}
+
+	u := *r.url
+	u.Path = path.Join("v2", r.url.Path, name, "blobs", desc.Digest.String())
+	resp, err := send("HEAD", u.String(), nil, 0, nil, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		klog.V(4).Infof("Blob %s already exists in %q", desc.Digest, name)
+		return nil
+	}
+
+	u.Path = path.Join("v2", r.url.Path, name, "blobs", "uploads") + "/"
+	resp, err = send("POST", u.String(), nil, 0, nil, http.StatusAccepted)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return errors.Annotatef(err, "starting upload")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer f.Close()
+	for offset := int64(0); offset < desc.Size; offset += r.uploadChunkSize {
+		off, size := offset, r.uploadChunkSize
+		if off+size > desc.Size {
+			size = desc.Size - off
+		}
+		chunk := func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(io.NewSectionReader(f, off, size)), nil
+		}
+		header := http.Header{}
+		header.Set("Content-Type", "application/octet-stream")
+		header.Set("Content-Range", fmt.Sprintf("%d-%d", off, off+size-1))
+		resp, err := send("PATCH", location.String(), chunk, size, header, http.StatusAccepted)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if location, err = resp.Location(); err != nil {
+			return errors.Annotatef(err, "uploading chunk at offset %d", off)
+		}
+	}
+
+	q := location.Query()
+	q.Set("digest", desc.Digest.String())
+	location.RawQuery = q.Encode()
+	_, err = send("PUT", location.String(), nil, 0, nil, http.StatusCreated)
+	return errors.Trace(err)
+}
+
 // GetChartDetails returns the details of a chart
 func (r *Repo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
 	digest, err := r.getChartDigest(name, version)
@@ -388,6 +1265,41 @@ func (r *Repo) Reload() error {
 	return errors.Errorf("reload method is not supported yet")
 }
 
+// isChartManifest returns whether a manifest holds a chart. Charts pushed
+// with generic tools like oras may have an OCI image or unknown config, in
+// which case their chart layer is looked up.
+func isChartManifest(tm *ocispec.Manifest) bool {
+	return tm.Config.MediaType == HelmChartConfigMediaType || chartLayer(tm) != nil
+}
+
+// chartLayer returns the layer of a manifest with the chart package, or nil
+// if there is none.
+//
+// Registries converting manifests may rewrite the media types of the layers,
+// so the only layer of a manifest with Helm config, besides the provenance
+// file, is taken as the chart package too.
+func chartLayer(tm *ocispec.Manifest) *ocispec.Descriptor {
+	for i, layer := range tm.Layers {
+		if isHelmChartContentLayerMediaType(layer.MediaType) {
+			return &tm.Layers[i]
+		}
+	}
+	if tm.Config.MediaType != HelmChartConfigMediaType {
+		return nil
+	}
+	var layer *ocispec.Descriptor
+	for i := range tm.Layers {
+		if tm.Layers[i].MediaType == HelmChartProvenanceLayerMediaType {
+			continue
+		}
+		if layer != nil {
+			return nil
+		}
+		layer = &tm.Layers[i]
+	}
+	return layer
+}
+
 func isHelmChartContentLayerMediaType(t string) bool {
 	if t == HelmChartContentLayerMediaType {
 		return true
@@ -458,19 +1370,31 @@ func populateEntries(repo *api.Repo) (map[string][]string, error) {
 	return entries, nil
 }
 
-func newDockerResolver(u *url.URL, username, password string, insecure bool) remotes.Resolver {
-	client := utils.DefaultClient
+// httpClient returns the client of the registry requests
+func httpClient(insecure bool) *http.Client {
 	if insecure {
-		client = utils.InsecureClient
+		return utils.InsecureClient
 	}
+	return utils.DefaultClient
+}
+
+// newAuthorizer returns an authorizer answering both the basic and the token
+// authentication challenges of a registry with the given credentials
+func newAuthorizer(username, password string, client *http.Client) docker.Authorizer {
+	return docker.NewDockerAuthorizer(
+		docker.WithAuthClient(client),
+		docker.WithAuthCreds(func(s string) (string, string, error) {
+			return username, password, nil
+		}))
+}
+
+func newDockerResolver(u *url.URL, username, password string, insecure bool) remotes.Resolver {
+	client := httpClient(insecure)
 	opts := docker.ResolverOptions{
 		Hosts: func(s string) ([]docker.RegistryHost, error) {
 			return []docker.RegistryHost{
 				{
-					Authorizer: docker.NewDockerAuthorizer(
-						docker.WithAuthCreds(func(s string) (string, string, error) {
-							return username, password, nil
-						})),
+					Authorizer:   newAuthorizer(username, password, client),
 					Host:         u.Host,
 					Scheme:       u.Scheme,
 					Path:         "/v2",
@@ -1,14 +1,29 @@
 package oci
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
 	"net/url"
+	"os"
+	"path"
 	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	_ "github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
+	"github.com/juju/errors"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"helm.sh/helm/v3/pkg/chart"
 )
 
 var (
@@ -83,3 +98,765 @@ func TestListWithEntries(t *testing.T) {
 		t.Errorf("unexpected list of charts names. got: %v, want: %v", got, want)
 	}
 }
+
+func TestManifestDigest(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+
+	got, err := c.ManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Fatalf("got: %q, want an empty digest for a missing tag", got)
+	}
+
+	want, err := c.UploadManifestDigest("../../../../testdata/apache-7.3.15.tgz", nil, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.ManifestDigest(metadata.Name, metadata.Version); err != nil {
+		t.Fatal(err)
+	} else if got != want {
+		t.Errorf("got: %q, want the manifest digest computed before the upload: %q", got, want)
+	}
+
+	// Another package or a provenance file produce other manifests
+	for _, prov := range [][]byte{nil, []byte("-----BEGIN PGP SIGNED MESSAGE-----\n")} {
+		file := "../../../../testdata/kafka-10.3.3.tgz"
+		if prov != nil {
+			file = "../../../../testdata/apache-7.3.15.tgz"
+		}
+		if other, err := c.UploadManifestDigest(file, prov, metadata); err != nil {
+			t.Fatal(err)
+		} else if other == want {
+			t.Errorf("got: %q for %s with provenance %t, want another manifest digest", other, file, prov != nil)
+		}
+	}
+}
+
+func TestUploadInChunks(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+		UploadChunkSize:    4096,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	// Fetch the chart from the registry instead of the cache
+	if err := c.cache.Invalidate("apache-7.3.15.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	chartPath, err := c.Fetch(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := utils.FileSha256(chartPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := utils.FileSha256("../../../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestUploadInChunksTokenAuth(t *testing.T) {
+	// The registry is behind a proxy requiring a token, issued by its realm
+	// for the credentials of the repository
+	backend := &api.Repo{}
+	PrepareOciServer(t, backend)
+	u, err := url.Parse(backend.GetUrl())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.Path = ""
+	proxy := httputil.NewSingleHostReverseProxy(u)
+	const scope = "repository:someproject/charts/apache:pull,push"
+	var tokens, challenged int32
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			if req.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			if user, pass, ok := req.BasicAuth(); !ok || user != "user" || pass != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if scopes := req.URL.Query()["scope"]; !reflect.DeepEqual(scopes, []string{scope}) {
+				t.Errorf("got token scopes: %v, want: %q", scopes, scope)
+			}
+			atomic.AddInt32(&tokens, 1)
+			fmt.Fprint(w, `{"token": "secret"}`)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			atomic.AddInt32(&challenged, 1)
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake",scope="%s"`, srv.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		proxy.ServeHTTP(w, req)
+	}))
+	defer srv.Close()
+
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Url:                srv.URL + "/someproject/charts",
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+		UploadChunkSize:    4096,
+	}
+	c := PrepareTest(t, repo)
+	file := "../../../../testdata/apache-7.3.15.tgz"
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := utils.FileSha256(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{Digest: digest.Digest("sha256:" + hash), Size: fi.Size()}
+	if err := c.uploadBlobInChunks("apache", desc, file); err != nil {
+		t.Fatal(err)
+	}
+	// The token is requested once, on the first challenge
+	if tokens != 1 || challenged != 1 {
+		t.Errorf("got %d token requests and %d challenges, want one of each", tokens, challenged)
+	}
+
+	resp, err := http.Head(fmt.Sprintf("%s/v2/someproject/charts/apache/blobs/%s", u, desc.Digest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got: %s, want the blob in the registry", resp.Status)
+	}
+}
+
+func TestUploadAttestation(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+
+	// Attestations are attached to pushed charts only
+	if err := c.UploadAttestation("", []byte("{}"), metadata); err == nil {
+		t.Errorf("expected an error for a missing chart")
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadAttestation("", []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`), metadata); err != nil {
+		t.Fatal(err)
+	}
+	chartDigest, err := c.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.getManifestDigest(metadata.Name, statementTag(chartDigest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "sha256:") {
+		t.Errorf("got: %q, want a sha256 digest for the statement tag", got)
+	}
+	// The cosign tag is left to signed attestations
+	if got, err := c.getManifestDigest(metadata.Name, attestationTag(chartDigest)); err != nil || got != "" {
+		t.Errorf("got: %q, %v, want no manifest for the cosign attestation tag", got, err)
+	}
+}
+
+func TestUploadSignedAttestation(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+	att := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2"}`)
+	signer := &fakeSigner{}
+	if err := c.UploadSignedAttestation(att, metadata, signer); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a not found error for a missing chart", err)
+	}
+	if signer.statement != nil {
+		t.Errorf("got: %s signed, want no statement signed for a missing chart", signer.statement)
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadSignedAttestation(att, metadata, signer); err != nil {
+		t.Fatal(err)
+	}
+	if string(signer.statement) != string(att) {
+		t.Errorf("got: %s signed, want: %s", signer.statement, att)
+	}
+	chartDigest, err := c.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm, err := c.getTagManifest(metadata.Name, attestationTag(chartDigest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tm.Layers), 1; got != want {
+		t.Fatalf("got: %d layers, want: %d", got, want)
+	}
+	layer := tm.Layers[0]
+	if got, want := layer.MediaType, DSSEMediaType; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	want := map[string]string{
+		SignatureAnnotation:     "",
+		CertificateAnnotation:   "-----BEGIN CERTIFICATE-----",
+		PredicateTypeAnnotation: "https://slsa.dev/provenance/v0.2",
+	}
+	if !reflect.DeepEqual(layer.Annotations, want) {
+		t.Errorf("got annotations: %v, want: %v", layer.Annotations, want)
+	}
+}
+
+func TestUploadSBOM(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+	if err := c.UploadSBOM("", []byte("{}"), types.SPDXMediaType, metadata); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a not found error for a missing chart", err)
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadSBOM("", []byte(`{"spdxVersion":"SPDX-2.3"}`), types.SPDXMediaType, metadata); err != nil {
+		t.Fatal(err)
+	}
+	chartDigest, err := c.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm, err := c.getTagManifest(metadata.Name, sbomTag(chartDigest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tm.Layers), 1; got != want {
+		t.Fatalf("got: %d layers, want: %d", got, want)
+	}
+	if got, want := tm.Layers[0].MediaType, types.SPDXMediaType; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+// fakeSigner returns a fixed signature of the manifests and statements
+type fakeSigner struct {
+	reference, digest string
+	statement         []byte
+}
+
+func (f *fakeSigner) SignManifest(reference, manifestDigest string) (*types.Signature, error) {
+	f.reference, f.digest = reference, manifestDigest
+	return &types.Signature{Payload: []byte(`{"critical":{}}`), Signature: "c2ln", Certificate: "-----BEGIN CERTIFICATE-----"}, nil
+}
+
+func (f *fakeSigner) SignStatement(statement []byte) (*types.Signature, error) {
+	f.statement = statement
+	return &types.Signature{Payload: []byte(`{"payloadType":"application/vnd.in-toto+json"}`), Certificate: "-----BEGIN CERTIFICATE-----"}, nil
+}
+
+func TestUploadSignature(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+	signer := &fakeSigner{}
+	if err := c.UploadSignature(metadata, signer); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a not found error for a missing chart", err)
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadSignature(metadata, signer); err != nil {
+		t.Fatal(err)
+	}
+	chartDigest, err := c.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%s%s/apache", c.url.Host, c.url.Path); signer.reference != want || signer.digest != chartDigest {
+		t.Errorf("got: %s@%s signed, want: %s@%s", signer.reference, signer.digest, want, chartDigest)
+	}
+	tm, err := c.getTagManifest(metadata.Name, signatureTag(chartDigest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tm.Layers), 1; got != want {
+		t.Fatalf("got: %d layers, want: %d", got, want)
+	}
+	layer := tm.Layers[0]
+	if got, want := layer.MediaType, SimpleSigningMediaType; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	want := map[string]string{SignatureAnnotation: "c2ln", CertificateAnnotation: "-----BEGIN CERTIFICATE-----"}
+	if !reflect.DeepEqual(layer.Annotations, want) {
+		t.Errorf("got annotations: %v, want: %v", layer.Annotations, want)
+	}
+}
+
+func TestDeleteAttachedArtifacts(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadSignature(metadata, &fakeSigner{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadAttestation("", []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`), metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadSignedAttestation([]byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`), metadata, &fakeSigner{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.UploadSBOM("", []byte(`{"spdxVersion":"SPDX-2.3"}`), types.SPDXMediaType, metadata); err != nil {
+		t.Fatal(err)
+	}
+	chartDigest, err := c.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Delete(metadata.Name, metadata.Version); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{metadata.Version, signatureTag(chartDigest), attestationTag(chartDigest), statementTag(chartDigest), sbomTag(chartDigest)} {
+		got, err := c.getManifestDigest(metadata.Name, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "" {
+			t.Errorf("got: %q manifest for the %q tag, want it deleted", got, tag)
+		}
+	}
+}
+
+func TestUploadWithProvenance(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.FetchProvenance(metadata.Name, metadata.Version); !errors.IsNotFound(err) {
+		t.Errorf("got: %v, want a not found error for a chart without provenance layer", err)
+	}
+
+	prov := []byte("-----BEGIN PGP SIGNED MESSAGE-----\n")
+	if err := c.UploadWithProvenance("../../../../testdata/apache-7.3.15.tgz", prov, metadata); err != nil {
+		t.Fatal(err)
+	}
+	tm, err := c.getTagManifest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mediaTypes []string
+	for _, l := range tm.Layers {
+		mediaTypes = append(mediaTypes, l.MediaType)
+	}
+	// Helm looks layers up by media type, their order does not matter
+	sort.Strings(mediaTypes)
+	if want := []string{HelmChartContentLayerMediaType, HelmChartProvenanceLayerMediaType}; !reflect.DeepEqual(mediaTypes, want) {
+		t.Errorf("got layers: %v, want: %v", mediaTypes, want)
+	}
+	got, err := c.FetchProvenance(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(prov) {
+		t.Errorf("got provenance: %q, want: %q", got, prov)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	src := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+	prov := []byte("-----BEGIN PGP SIGNED MESSAGE-----\n")
+	if err := src.UploadWithProvenance("../../../../testdata/apache-7.3.15.tgz", prov, metadata); err != nil {
+		t.Fatal(err)
+	}
+	want, err := src.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	targetRepo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Url:                strings.Replace(repo.GetUrl(), "/charts", "/mirror", 1),
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	dst := PrepareTest(t, targetRepo)
+	if err := dst.Copy(src, metadata.Name, metadata.Version, false); !errors.IsNotSupported(err) {
+		t.Fatalf("got: %v, want a not supported error when the provenance file is stripped", err)
+	}
+	if got, err := dst.CopyManifestDigest(src, metadata.Name, metadata.Version, true); err != nil {
+		t.Fatal(err)
+	} else if got != want {
+		t.Errorf("got: %q expected manifest digest, want the source one: %q", got, want)
+	}
+	if err := dst.Copy(src, metadata.Name, metadata.Version, true); err != nil {
+		t.Fatal(err)
+	}
+	got, err := dst.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got: %q manifest digest, want: %q", got, want)
+	}
+	chartDigest, err := utils.FileSha256("../../../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := dst.ChartDigest(metadata.Name, metadata.Version); err != nil {
+		t.Fatal(err)
+	} else if got != chartDigest {
+		t.Errorf("got: %q chart digest, want: %q", got, chartDigest)
+	}
+	if got, err := dst.FetchProvenance(metadata.Name, metadata.Version); err != nil {
+		t.Fatal(err)
+	} else if string(got) != string(prov) {
+		t.Errorf("got provenance: %q, want: %q", got, prov)
+	}
+
+	// Charts with other media types than the target ones are uploaded
+	legacyRepo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Url:                targetRepo.GetUrl(),
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+		OciMediaTypes:      &api.OCIMediaTypes{Content: HelmChartContentLayerMediaTypeDeprecated},
+	}
+	legacy := PrepareTest(t, legacyRepo)
+	if err := legacy.Copy(src, metadata.Name, metadata.Version, true); !errors.IsNotSupported(err) {
+		t.Errorf("got: %v, want a not supported error", err)
+	}
+}
+
+func TestCopyArtifacts(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	}
+	PrepareOciServer(t, repo)
+	src := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+	if err := src.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.UploadSignature(metadata, &fakeSigner{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.UploadAttestation("", []byte(`{"_type":"https://in-toto.io/Statement/v0.1"}`), metadata); err != nil {
+		t.Fatal(err)
+	}
+	chartDigest, err := src.getManifestDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := PrepareTest(t, &api.Repo{
+		Kind:               api.Kind_OCI,
+		Url:                strings.Replace(repo.GetUrl(), "/charts", "/mirror", 1),
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+	})
+	if err := dst.Copy(src, metadata.Name, metadata.Version, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{signatureTag(chartDigest), statementTag(chartDigest)} {
+		want, err := src.getManifestDigest(metadata.Name, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := dst.getManifestDigest(metadata.Name, tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got: %q manifest digest for %q tag, want: %q", got, tag, want)
+		}
+	}
+}
+
+func TestListChartVersionsPaginated(t *testing.T) {
+	// 2500 chart versions, an image and a signature, served in pages
+	var tags []string
+	for i := 0; i < 2500; i++ {
+		tags = append(tags, fmt.Sprintf("1.0.%d", i))
+	}
+	tags = append(tags, "2.0.0", "sha256-abc.sig", "1.0.0_build.1")
+	configs := map[string]string{"2.0.0": "application/vnd.oci.image.config.v1+json"}
+
+	var pages int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tags/list"):
+			pages++
+			n, err := strconv.Atoi(r.URL.Query().Get("n"))
+			if err != nil || n <= 0 {
+				t.Errorf("unexpected %q page size", r.URL.Query().Get("n"))
+				n = len(tags)
+			}
+			start := 0
+			if last := r.URL.Query().Get("last"); last != "" {
+				for i, tag := range tags {
+					if tag == last {
+						start = i + 1
+					}
+				}
+			}
+			end := start + n
+			if end < len(tags) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s?n=%d&last=%s>; rel="next"`, r.URL.Path, n, tags[end-1]))
+			} else {
+				end = len(tags)
+			}
+			json.NewEncoder(w).Encode(Tags{Name: "kafka", Tags: tags[start:end]})
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			tag := path.Base(r.URL.Path)
+			if tag == "sha256-abc.sig" {
+				t.Errorf("the manifest of %q tag was fetched", tag)
+			}
+			mediaType, ok := configs[tag]
+			if !ok {
+				mediaType = HelmChartConfigMediaType
+			}
+			json.NewEncoder(w).Encode(ocispec.Manifest{Config: ocispec.Descriptor{MediaType: mediaType}})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer s.Close()
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewRaw(u, "", "", nil, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.ListChartVersions("kafka")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pages, 3; got != want {
+		t.Errorf("got: %d pages, want: %d", got, want)
+	}
+	want := append(tags[:2500:2500], "1.0.0+build.1")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d versions, want %d", len(got), len(want))
+	}
+}
+
+func TestUploadLegacyMediaTypes(t *testing.T) {
+	repo := &api.Repo{
+		Kind:               api.Kind_OCI,
+		Auth:               ociRepo.GetAuth(),
+		DisableChartsIndex: true,
+		OciMediaTypes: &api.OCIMediaTypes{
+			Content:    HelmChartContentLayerMediaTypeDeprecated,
+			OmitTitles: true,
+		},
+	}
+	PrepareOciServer(t, repo)
+	c := PrepareTest(t, repo)
+	metadata := &chart.Metadata{
+		Name:    "apache",
+		Version: "7.3.15",
+	}
+
+	if err := c.Upload("../../../../testdata/apache-7.3.15.tgz", metadata); err != nil {
+		t.Fatal(err)
+	}
+	tm, err := c.getTagManifest(metadata.Name, metadata.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(tm.Layers), 1; got != want {
+		t.Fatalf("got: %d layers, want: %d", got, want)
+	}
+	if got, want := tm.Layers[0].MediaType, HelmChartContentLayerMediaTypeDeprecated; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if title, ok := tm.Layers[0].Annotations[ocispec.AnnotationTitle]; ok {
+		t.Errorf("got: %q title, want: none", title)
+	}
+
+	versions, err := c.ListChartVersions(metadata.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{metadata.Version}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("got: %v, want: %v", versions, want)
+	}
+	if _, err := c.Fetch(metadata.Name, metadata.Version); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChartLayer(t *testing.T) {
+	chartLayerDesc := ocispec.Descriptor{MediaType: HelmChartContentLayerMediaType, Digest: "sha256:chart"}
+	provLayerDesc := ocispec.Descriptor{MediaType: HelmChartProvenanceLayerMediaType, Digest: "sha256:prov"}
+	rootfsLayerDesc := ocispec.Descriptor{MediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip", Digest: "sha256:rootfs"}
+	testCases := []struct {
+		desc      string
+		config    string
+		layers    []ocispec.Descriptor
+		want      string
+		wantChart bool
+	}{
+		{
+			desc:      "helm chart",
+			config:    HelmChartConfigMediaType,
+			layers:    []ocispec.Descriptor{provLayerDesc, chartLayerDesc},
+			want:      "sha256:chart",
+			wantChart: true,
+		},
+		{
+			desc:      "legacy chart layer",
+			config:    HelmChartConfigMediaType,
+			layers:    []ocispec.Descriptor{{MediaType: HelmChartContentLayerMediaTypeDeprecated, Digest: "sha256:legacy"}},
+			want:      "sha256:legacy",
+			wantChart: true,
+		},
+		{
+			desc:      "layer rewritten by the registry",
+			config:    HelmChartConfigMediaType,
+			layers:    []ocispec.Descriptor{rootfsLayerDesc, provLayerDesc},
+			want:      "sha256:rootfs",
+			wantChart: true,
+		},
+		{
+			desc:      "chart pushed with oras",
+			config:    "application/vnd.unknown.config.v1+json",
+			layers:    []ocispec.Descriptor{chartLayerDesc},
+			want:      "sha256:chart",
+			wantChart: true,
+		},
+		{
+			desc:   "container image",
+			config: "application/vnd.oci.image.config.v1+json",
+			layers: []ocispec.Descriptor{rootfsLayerDesc},
+		},
+		{
+			desc:      "ambiguous layers",
+			config:    HelmChartConfigMediaType,
+			layers:    []ocispec.Descriptor{rootfsLayerDesc, {MediaType: "application/octet-stream", Digest: "sha256:other"}},
+			wantChart: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tm := &ocispec.Manifest{Config: ocispec.Descriptor{MediaType: tc.config}, Layers: tc.layers}
+			var got string
+			if layer := chartLayer(tm); layer != nil {
+				got = layer.Digest.String()
+			}
+			if got != tc.want {
+				t.Errorf("got: %q chart layer, want: %q", got, tc.want)
+			}
+			if got := isChartManifest(tm); got != tc.wantChart {
+				t.Errorf("got: %t, want: %t", got, tc.wantChart)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -130,12 +131,27 @@ func PrepareOciServer(t *testing.T, ociRepo *api.Repo) {
 	dockerRegistryHost := "http://" + addr
 	config.HTTP.Addr = fmt.Sprintf(addr)
 	config.HTTP.DrainTimeout = time.Duration(10) * time.Second
-	config.Storage = map[string]configuration.Parameters{"inmemory": map[string]interface{}{}}
+	config.Storage = map[string]configuration.Parameters{
+		"inmemory": map[string]interface{}{},
+		"delete":   map[string]interface{}{"enabled": true},
+	}
 	dockerRegistry, err := registry.NewRegistry(context.Background(), config)
 	if err != nil {
 		t.Fatal(err)
 	}
 	go dockerRegistry.ListenAndServe()
+	// Wait for the registry to accept connections
+	for i := 0; ; i++ {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if i == 50 {
+			t.Fatalf("docker registry not ready: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
 	ociRepo.Url = dockerRegistryHost + "/someproject/charts"
 }
 
@@ -0,0 +1,552 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/mitchellh/go-homedir"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+const (
+	// defaultEC2MetadataEndpoint is the address of the instance metadata
+	// service of EC2
+	defaultEC2MetadataEndpoint = "http://169.254.169.254"
+	// ecsCredentialsHost is the address of the credentials endpoint of the ECS
+	// containers
+	ecsCredentialsHost = "http://169.254.170.2"
+	// stsVersion is the version of the STS API
+	stsVersion = "2011-06-15"
+	// defaultSessionName is the name of the sessions of the assumed roles
+	defaultSessionName = "charts-syncer"
+	// credentialsRenewal is how long before their expiration temporary
+	// credentials are renewed
+	credentialsRenewal = 5 * time.Minute
+	// maxProfileDepth limits the chains of source profiles
+	maxProfileDepth = 5
+)
+
+// noCredentialsHint lists where the credentials are looked up, for the
+// requests denied without them
+const noCredentialsHint = "no AWS credentials found: set the repository auth, the AWS_ACCESS_KEY_ID and " +
+	"AWS_SECRET_ACCESS_KEY env vars, an AWS_PROFILE of the shared config files or a web identity token, or run in " +
+	"ECS or EC2 with an IAM role"
+
+// metadataClient reaches the metadata endpoints directly, failing fast outside
+// of AWS
+var metadataClient = &http.Client{Transport: &http.Transport{}, Timeout: 2 * time.Second}
+
+// credentials are the AWS credentials the requests are signed with
+type credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	// expiration is the time temporary credentials expire at, zero for the
+	// long-term ones
+	expiration time.Time
+}
+
+// credentialsSource returns the credentials of a provider of the chain, or nil
+// credentials if the requests are anonymous
+type credentialsSource func() (*credentials, error)
+
+// cachedCredentials returns the credentials of a source, renewing them before
+// they expire
+type cachedCredentials struct {
+	source credentialsSource
+
+	mu        sync.Mutex
+	retrieved bool
+	creds     *credentials
+}
+
+func newCachedCredentials(source credentialsSource) *cachedCredentials {
+	return &cachedCredentials{source: source}
+}
+
+// get returns the current credentials, or nil for anonymous requests
+func (c *cachedCredentials) get() (*credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.retrieved && (c.creds == nil || c.creds.expiration.IsZero() || time.Now().Add(credentialsRenewal).Before(c.creds.expiration)) {
+		return c.creds, nil
+	}
+	creds, err := c.source()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c.creds, c.retrieved = creds, true
+	return creds, nil
+}
+
+// staticCredentials returns a source of long-term credentials
+func staticCredentials(accessKeyID, secretAccessKey, sessionToken string) credentialsSource {
+	creds := &credentials{accessKeyID: accessKeyID, secretAccessKey: secretAccessKey, sessionToken: sessionToken}
+	return func() (*credentials, error) { return creds, nil }
+}
+
+// anonymous is the source of the anonymous requests
+func anonymous() (*credentials, error) {
+	return nil, nil
+}
+
+// defaultCredentials returns the source of the credentials of a repository and
+// describes them. Like the AWS SDKs, they are looked up, in order:
+//
+//   - in the username and password of the repository
+//   - in the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//     env vars
+//   - in the profile selected with the AWS_PROFILE env var
+//   - in the web identity token of the AWS_WEB_IDENTITY_TOKEN_FILE env var,
+//     exchanged for the credentials of the AWS_ROLE_ARN role, like in EKS pods
+//     with IAM roles for service accounts
+//   - in the default profile of the shared config and credentials files
+//   - from the credentials endpoint of ECS containers and EKS Pod Identity
+//   - from the instance profile of the EC2 instance metadata service
+//
+// Requests are anonymous if the instance metadata service cannot be reached
+// or is disabled with AWS_EC2_METADATA_DISABLED.
+func defaultCredentials(repo *api.Repo, region string, client *http.Client) (credentialsSource, string, error) {
+	if id := repo.GetAuth().GetUsername(); id != "" || repo.GetAuth().GetPassword() != "" {
+		return staticCredentials(id, repo.GetAuth().GetPassword(), ""), fmt.Sprintf("AWS signature as %q", id), nil
+	}
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return staticCredentials(id, os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")), fmt.Sprintf("AWS signature as %q from AWS_ACCESS_KEY_ID", id), nil
+	}
+	if profile := firstNonEmpty(os.Getenv("AWS_PROFILE"), os.Getenv("AWS_DEFAULT_PROFILE")); profile != "" {
+		source, err := profileCredentials(profile, region, client, 0)
+		if err != nil {
+			return nil, "", errors.Annotatef(err, "loading the %q AWS profile", profile)
+		}
+		return source, fmt.Sprintf("AWS signature with the %q profile", profile), nil
+	}
+	if file := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); file != "" {
+		role := os.Getenv("AWS_ROLE_ARN")
+		if role == "" {
+			return nil, "", errors.New("AWS_WEB_IDENTITY_TOKEN_FILE is set without AWS_ROLE_ARN")
+		}
+		source := webIdentityCredentials(role, firstNonEmpty(os.Getenv("AWS_ROLE_SESSION_NAME"), defaultSessionName), file, region, client)
+		return source, fmt.Sprintf("AWS signature as the %q role of the web identity token of AWS_WEB_IDENTITY_TOKEN_FILE", role), nil
+	}
+	source, err := profileCredentials("default", region, client, 0)
+	if err == nil {
+		return source, `AWS signature with the "default" profile`, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, "", errors.Annotate(err, `loading the "default" AWS profile`)
+	}
+	if source, ok, err := ecsCredentials(client); ok || err != nil {
+		return source, "AWS signature with the ECS container credentials", errors.Trace(err)
+	}
+	if strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		return anonymous, "anonymous", nil
+	}
+	return ec2Credentials(true), "AWS signature with the EC2 instance profile, if any", nil
+}
+
+// profileCredentials returns the source of the credentials of a profile of the
+// shared config and credentials files, or a not found error if it has none
+func profileCredentials(name, region string, client *http.Client, depth int) (credentialsSource, error) {
+	if depth > maxProfileDepth {
+		return nil, errors.NotValidf("chain of source profiles longer than %d", maxProfileDepth)
+	}
+	p, err := loadProfile(name)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if p == nil {
+		return nil, errors.NotFoundf("%q profile in the shared config files", name)
+	}
+	sessionName := firstNonEmpty(p["role_session_name"], defaultSessionName)
+
+	switch {
+	case p["role_arn"] != "" && p["web_identity_token_file"] != "":
+		return webIdentityCredentials(p["role_arn"], sessionName, p["web_identity_token_file"], region, client), nil
+	case p["role_arn"] != "":
+		var source credentialsSource
+		switch {
+		case p["source_profile"] != "" && p["source_profile"] != name:
+			if source, err = profileCredentials(p["source_profile"], region, client, depth+1); err != nil {
+				return nil, errors.Annotatef(err, "loading the %q source profile", p["source_profile"])
+			}
+		case p["source_profile"] != "":
+			// A profile can hold the keys assuming its role
+			source = staticCredentials(p["aws_access_key_id"], p["aws_secret_access_key"], p["aws_session_token"])
+		case p["credential_source"] == "Environment":
+			source = staticCredentials(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"))
+		case p["credential_source"] == "EcsContainer":
+			var ok bool
+			if source, ok, err = ecsCredentials(client); err != nil {
+				return nil, errors.Trace(err)
+			} else if !ok {
+				return nil, errors.New("the ECS container credentials are not set")
+			}
+		case p["credential_source"] == "Ec2InstanceMetadata":
+			source = ec2Credentials(false)
+		default:
+			return nil, errors.NotValidf("%q profile, its role has no source_profile or credential_source", name)
+		}
+		return assumeRoleCredentials(p["role_arn"], sessionName, p["external_id"], newCachedCredentials(source), region, client), nil
+	case p["credential_process"] != "":
+		return processCredentials(p["credential_process"]), nil
+	case p["aws_access_key_id"] != "":
+		return staticCredentials(p["aws_access_key_id"], p["aws_secret_access_key"], p["aws_session_token"]), nil
+	case p["sso_session"] != "" || p["sso_start_url"] != "":
+		return nil, errors.NotSupportedf("IAM Identity Center profiles, use a credential_process running `aws configure export-credentials --profile %s --format process`", name)
+	default:
+		return nil, errors.NotFoundf("credentials in the %q profile", name)
+	}
+}
+
+// loadProfile returns the settings of a profile of the shared config and
+// credentials files, the ones of the latter taking precedence, or nil if
+// neither has it
+func loadProfile(name string) (map[string]string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		home = ""
+	}
+	configFile := firstNonEmpty(os.Getenv("AWS_CONFIG_FILE"), filepath.Join(home, ".aws", "config"))
+	credentialsFile := firstNonEmpty(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), filepath.Join(home, ".aws", "credentials"))
+
+	var profile map[string]string
+	// Config file sections are prefixed, but the default one
+	section := "profile " + name
+	if name == "default" {
+		section = name
+	}
+	for _, f := range []struct{ file, section string }{{configFile, section}, {credentialsFile, name}} {
+		sections, err := parseINI(f.file)
+		if err != nil {
+			return nil, errors.Annotatef(err, "reading %q", f.file)
+		}
+		if s, ok := sections[f.section]; ok {
+			if profile == nil {
+				profile = map[string]string{}
+			}
+			for k, v := range s {
+				profile[k] = v
+			}
+		}
+	}
+	return profile, nil
+}
+
+// parseINI returns the keys of the sections of a shared config file. Missing
+// files have no section.
+func parseINI(file string) (map[string]map[string]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sections := map[string]map[string]string{}
+	var current map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+			if sections[name] == nil {
+				sections[name] = map[string]string{}
+			}
+			current = sections[name]
+		default:
+			// Nested settings, like the ones of s3, are indented
+			i := strings.IndexByte(line, '=')
+			if i < 0 || current == nil {
+				continue
+			}
+			current[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return sections, errors.Trace(scanner.Err())
+}
+
+// stsEndpoint returns the regional endpoint of STS, or the one of the
+// AWS_ENDPOINT_URL_STS env var
+func stsEndpoint(region string) string {
+	if e := os.Getenv("AWS_ENDPOINT_URL_STS"); e != "" {
+		return e
+	}
+	return fmt.Sprintf("https://sts.%s.amazonaws.com/", firstNonEmpty(region, defaultRegion))
+}
+
+// stsCredentials are the temporary credentials returned by STS
+type stsCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+// callSTS sends a query to STS, signed with the given credentials if any, and
+// returns the temporary credentials of the response
+func callSTS(params url.Values, creds *credentials, region string, client *http.Client) (*credentials, error) {
+	params.Set("Version", stsVersion)
+	body := params.Encode()
+	req, err := http.NewRequest(http.MethodPost, stsEndpoint(region), strings.NewReader(body))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/xml")
+	if creds != nil {
+		h := sha256.Sum256([]byte(body))
+		signV4(req, hex.EncodeToString(h[:]), creds, firstNonEmpty(region, defaultRegion), "sts", time.Now())
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s failed, got HTTP Status: %s, Resp: %v", params.Get("Action"), res.Status, utils.HTTPResponseBody(res))
+	}
+	// The result element is named after the action
+	var out struct {
+		AssumeRole                stsCredentials `xml:"AssumeRoleResult>Credentials"`
+		AssumeRoleWithWebIdentity stsCredentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, errors.Annotatef(err, "parsing %s response", params.Get("Action"))
+	}
+	c := out.AssumeRole
+	if c.AccessKeyID == "" {
+		c = out.AssumeRoleWithWebIdentity
+	}
+	if c.AccessKeyID == "" {
+		return nil, errors.Errorf("%s returned no credentials", params.Get("Action"))
+	}
+	return &credentials{accessKeyID: c.AccessKeyID, secretAccessKey: c.SecretAccessKey, sessionToken: c.SessionToken, expiration: c.Expiration}, nil
+}
+
+// webIdentityCredentials returns the source of the credentials of a role
+// assumed with a web identity token. The token file is read again for every
+// renewal, as projected tokens are rotated.
+func webIdentityCredentials(role, sessionName, tokenFile, region string, client *http.Client) credentialsSource {
+	return func() (*credentials, error) {
+		token, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, errors.Annotatef(err, "reading web identity token")
+		}
+		klog.V(3).Infof("Assuming %q role with the web identity token of %q...", role, tokenFile)
+		creds, err := callSTS(url.Values{
+			"Action":           {"AssumeRoleWithWebIdentity"},
+			"RoleArn":          {role},
+			"RoleSessionName":  {sessionName},
+			"WebIdentityToken": {strings.TrimSpace(string(token))},
+		}, nil, region, client)
+		return creds, errors.Annotatef(err, "assuming %q role with a web identity token", role)
+	}
+}
+
+// assumeRoleCredentials returns the source of the credentials of a role
+// assumed with the credentials of another source
+func assumeRoleCredentials(role, sessionName, externalID string, source *cachedCredentials, region string, client *http.Client) credentialsSource {
+	return func() (*credentials, error) {
+		sourceCreds, err := source.get()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if sourceCreds == nil || sourceCreds.accessKeyID == "" {
+			return nil, errors.Errorf("no source credentials to assume %q role", role)
+		}
+		params := url.Values{"Action": {"AssumeRole"}, "RoleArn": {role}, "RoleSessionName": {sessionName}}
+		if externalID != "" {
+			params.Set("ExternalId", externalID)
+		}
+		klog.V(3).Infof("Assuming %q role...", role)
+		creds, err := callSTS(params, sourceCreds, region, client)
+		return creds, errors.Annotatef(err, "assuming %q role", role)
+	}
+}
+
+// processOutput is the output of the credential_process commands
+type processOutput struct {
+	Version         int       `json:"Version"`
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// processCredentials returns the source of the credentials printed by a
+// credential_process command
+func processCredentials(command string) credentialsSource {
+	return func() (*credentials, error) {
+		cmd := exec.Command("sh", "-c", command)
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd.exe", "/C", command)
+		}
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Annotatef(err, "running credential_process")
+		}
+		var o processOutput
+		if err := json.Unmarshal(out, &o); err != nil {
+			return nil, errors.Annotatef(err, "parsing the output of credential_process")
+		}
+		if o.Version != 1 || o.AccessKeyID == "" {
+			return nil, errors.NotValidf("output of credential_process, it should be version 1 credentials")
+		}
+		return &credentials{accessKeyID: o.AccessKeyID, secretAccessKey: o.SecretAccessKey, sessionToken: o.SessionToken, expiration: o.Expiration}, nil
+	}
+}
+
+// metadataCredentials are the temporary credentials of the ECS and EC2
+// metadata endpoints
+type metadataCredentials struct {
+	Code            string    `json:"Code"`
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// getMetadataCredentials fetches the credentials of a metadata endpoint
+func getMetadataCredentials(req *http.Request) (*credentials, error) {
+	res, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("got HTTP Status: %s, Resp: %v", res.Status, utils.HTTPResponseBody(res))
+	}
+	var c metadataCredentials
+	if err := json.NewDecoder(res.Body).Decode(&c); err != nil {
+		return nil, errors.Annotate(err, "parsing credentials")
+	}
+	if c.Code != "" && c.Code != "Success" {
+		return nil, errors.Errorf("got %q credentials", c.Code)
+	}
+	return &credentials{accessKeyID: c.AccessKeyID, secretAccessKey: c.SecretAccessKey, sessionToken: c.Token, expiration: c.Expiration}, nil
+}
+
+// ecsCredentials returns the source of the credentials of the ECS container
+// or EKS pod, if the env vars of the credentials endpoint are set
+func ecsCredentials(client *http.Client) (credentialsSource, bool, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		endpoint = ecsCredentialsHost + rel
+	}
+	if endpoint == "" {
+		return nil, false, nil
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, true, errors.Annotatef(err, "parsing the ECS credentials endpoint")
+	}
+	return func() (*credentials, error) {
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+		if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+			// EKS Pod Identity tokens are rotated
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, errors.Annotatef(err, "reading the ECS authorization token")
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		creds, err := getMetadataCredentials(req)
+		return creds, errors.Annotatef(err, "fetching the ECS container credentials")
+	}, true, nil
+}
+
+// ec2Credentials returns the source of the credentials of the instance
+// profile of the EC2 instance, from the instance metadata service v2. If
+// optional is set, requests are anonymous when the service cannot be reached.
+func ec2Credentials(optional bool) credentialsSource {
+	return func() (*credentials, error) {
+		endpoint := strings.TrimSuffix(firstNonEmpty(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), defaultEC2MetadataEndpoint), "/")
+		req, err := http.NewRequest(http.MethodPut, endpoint+"/latest/api/token", nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+		res, err := metadataClient.Do(req)
+		if err != nil {
+			if optional {
+				klog.V(3).Infof("Sending anonymous S3 requests, the EC2 instance metadata service cannot be reached: %v", err)
+				return nil, nil
+			}
+			return nil, errors.Annotate(err, "reaching the EC2 instance metadata service")
+		}
+		token, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("unable to get an EC2 instance metadata token, got HTTP Status: %s", res.Status)
+		}
+
+		get := func(path string) (*http.Request, error) {
+			req, err := http.NewRequest(http.MethodGet, endpoint+"/latest/meta-data/iam/security-credentials/"+path, nil)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			req.Header.Set("X-Aws-Ec2-Metadata-Token", string(token))
+			return req, nil
+		}
+		req, err = get("")
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		res, err = metadataClient.Do(req)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		roles, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		role := strings.TrimSpace(strings.SplitN(string(roles), "\n", 2)[0])
+		if res.StatusCode == http.StatusNotFound || role == "" {
+			if optional {
+				klog.V(3).Infof("Sending anonymous S3 requests, the EC2 instance has no instance profile")
+				return nil, nil
+			}
+			return nil, errors.New("the EC2 instance has no instance profile")
+		}
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("unable to get the role of the EC2 instance profile, got HTTP Status: %s", res.Status)
+		}
+		if req, err = get(url.PathEscape(role)); err != nil {
+			return nil, errors.Trace(err)
+		}
+		creds, err := getMetadataCredentials(req)
+		return creds, errors.Annotatef(err, "fetching the credentials of the %q role of the EC2 instance profile", role)
+	}
+}
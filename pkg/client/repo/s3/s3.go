@@ -0,0 +1,328 @@
+// Package s3 implements static Helm repositories stored in Amazon S3 or
+// S3-compatible buckets, with the chart packages and their index.yaml file at
+// the root of the repository, like the repositories of the helm-s3 plugin.
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cache"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/bucket"
+)
+
+const (
+	// defaultRegion is the region used if none is configured
+	defaultRegion = "us-east-1"
+	// unsignedPayload is the payload hash of the requests whose body is not
+	// signed, so it can be streamed
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayload is the payload hash of the requests without body
+	emptyPayload  = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	amzDateFormat = "20060102T150405Z"
+)
+
+// Repo allows to operate a chart repository stored in an S3 bucket.
+type Repo struct {
+	*bucket.Repo
+}
+
+// New creates a Repo object from an api.Repo object. The URL of the
+// repository is like s3://bucket/prefix.
+func New(repo *api.Repo, c cache.Cacher, insecure bool) (*Repo, error) {
+	store, err := NewStore(repo, insecure)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	r, err := bucket.New(store, c)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Repo{Repo: r}, nil
+}
+
+// Store reads and writes the objects of a repository in an S3 bucket,
+// signing the requests with AWS Signature Version 4.
+type Store struct {
+	bucket string
+	prefix string
+	region string
+	// endpoint of S3-compatible services, reached with path-style requests
+	endpoint *url.URL
+
+	credentials *cachedCredentials
+	// credentialsDesc describes where the credentials come from
+	credentialsDesc string
+
+	client *http.Client
+	// now returns the time the requests are signed at
+	now func() time.Time
+}
+
+// NewStore returns the store of an S3 repository.
+//
+// The credentials are the username (access key ID) and password (secret
+// access key) of the repository or, like the AWS SDKs do, the ones of the
+// default credential chain. Requests are not signed without credentials, e.g.
+// to read public buckets.
+func NewStore(repo *api.Repo, insecure bool) (*Store, error) {
+	u, err := url.Parse(repo.GetUrl())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return nil, errors.NotValidf("S3 repository URL %q, it should be like s3://bucket/prefix", repo.GetUrl())
+	}
+	s := &Store{
+		bucket: u.Host,
+		prefix: strings.Trim(u.Path, "/"),
+		region: firstNonEmpty(repo.GetS3().GetRegion(), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), defaultRegion),
+		client: utils.DefaultClient,
+		now:    time.Now,
+	}
+	if e := repo.GetS3().GetEndpoint(); e != "" {
+		if s.endpoint, err = url.Parse(e); err != nil {
+			return nil, errors.Annotatef(err, "parsing S3 endpoint %q", e)
+		}
+	}
+	if insecure {
+		s.client = utils.InsecureClient
+	}
+	source, desc, err := defaultCredentials(repo, s.region, s.client)
+	if err != nil {
+		return nil, errors.Annotate(err, "loading the AWS credentials")
+	}
+	s.credentials, s.credentialsDesc = newCachedCredentials(source), desc
+	return s, nil
+}
+
+// firstNonEmpty returns the first non empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Credentials describes the credentials the requests are signed with
+func (s *Store) Credentials() string {
+	return s.credentialsDesc
+}
+
+// String returns the URL of the repository
+func (s *Store) String() string {
+	return strings.TrimSuffix(fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix), "/")
+}
+
+// objectURL returns the URL of an object. AWS buckets are reached with
+// virtual-hosted-style requests, S3-compatible services with path-style ones.
+func (s *Store) objectURL(key string) *url.URL {
+	p := key
+	if s.prefix != "" {
+		p = s.prefix + "/" + key
+	}
+	var u url.URL
+	if s.endpoint != nil {
+		u = *s.endpoint
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + p
+	} else {
+		u = url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", s.bucket, s.region), Path: "/" + p}
+	}
+	u.RawPath = escapePath(u.Path)
+	return &u
+}
+
+// escapePath URI-encodes every byte of a path but the unreserved characters
+// and slashes, as required to sign it
+func escapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// do sends a signed request for an object
+func (s *Store) do(method, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	payload := emptyPayload
+	if body != nil {
+		req.ContentLength = size
+		payload = unsignedPayload
+	}
+	if err := s.sign(req, payload, s.now()); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	reqID := utils.EncodeSha1(req.URL.String())
+	klog.V(4).Infof("[%s] %s %q", reqID, method, req.URL)
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	klog.V(4).Infof("[%s] HTTP Status: %s", reqID, res.Status)
+	return res, nil
+}
+
+// sign adds the AWS Signature Version 4 of a request to its headers, unless
+// the requests are anonymous
+func (s *Store) sign(req *http.Request, payload string, now time.Time) error {
+	creds, err := s.credentials.get()
+	if err != nil {
+		return errors.Annotatef(err, "getting the AWS credentials of the %s", s.credentialsDesc)
+	}
+	if creds == nil || creds.accessKeyID == "" {
+		return nil
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+	signV4(req, payload, creds, s.region, "s3", now)
+	return nil
+}
+
+// signV4 adds the AWS Signature Version 4 of a request to a service to its
+// headers
+func signV4(req *http.Request, payload string, creds *credentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", now.Format("20060102"), region, service)
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), now.Format("20060102"))
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery returns the query parameters sorted and encoded
+func canonicalQuery(q url.Values) string {
+	var params []string
+	for k, values := range q {
+		for _, v := range values {
+			params = append(params, escapePath(k)+"="+strings.ReplaceAll(escapePath(v), "/", "%2F"))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// statusError returns the error of a failed request for an object
+func (s *Store) statusError(res *http.Response, method, key string) error {
+	body := utils.HTTPResponseBody(res)
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return errors.NotFoundf("%q object", key)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		if creds, err := s.credentials.get(); err == nil && creds == nil {
+			return errors.Forbiddenf("%s %q anonymously (%s): got HTTP Status: %s, Resp: %v", method, key, noCredentialsHint, res.Status, body)
+		}
+		return errors.Forbiddenf("%s %q: got HTTP Status: %s, Resp: %v", method, key, res.Status, body)
+	default:
+		return errors.Errorf("unable to %s %q, got HTTP Status: %s, Resp: %v", method, key, res.Status, body)
+	}
+}
+
+// Get returns the content of an object
+func (s *Store) Get(key string) (io.ReadCloser, error) {
+	res, err := s.do(http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, s.statusError(res, http.MethodGet, key)
+	}
+	return res.Body, nil
+}
+
+// Put creates or replaces an object
+func (s *Store) Put(key string, r io.Reader, size int64, contentType string) error {
+	res, err := s.do(http.MethodPut, key, r, size, http.Header{"Content-Type": {contentType}})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return s.statusError(res, http.MethodPut, key)
+	}
+	return nil
+}
+
+// Delete removes an object
+func (s *Store) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return s.statusError(res, http.MethodDelete, key)
+	}
+}
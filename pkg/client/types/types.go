@@ -4,12 +4,47 @@ import (
 	"time"
 )
 
+// Media types of the software bills of materials of the charts
+const (
+	SPDXMediaType      = "text/spdx+json"
+	CycloneDXMediaType = "application/vnd.cyclonedx+json"
+)
+
 // ChartDetails contains details of a chart
 type ChartDetails struct {
 	PublishedAt time.Time
 	Digest      string
 }
 
+// Signature is a cosign signature of a chart manifest
+type Signature struct {
+	// Payload is the signed simple signing payload, or the DSSE envelope of
+	// a signed statement
+	Payload []byte
+	// Signature is the base64-encoded signature of the payload. Statements
+	// have it in their envelope.
+	Signature string
+	// Certificate and Chain are the PEM-encoded Fulcio certificate of a
+	// keyless signature and its chain
+	Certificate string
+	Chain       string
+	// Bundle is the JSON-encoded Rekor bundle of a signature uploaded to the
+	// transparency log
+	Bundle string
+}
+
+// ManifestSigner signs the manifest of a chart pushed to an OCI registry. The
+// reference is the repository of the chart, without tag.
+type ManifestSigner interface {
+	SignManifest(reference string, manifestDigest string) (*Signature, error)
+}
+
+// StatementSigner signs the in-toto statements attached to the charts pushed
+// to an OCI registry, in a DSSE envelope.
+type StatementSigner interface {
+	SignStatement(statement []byte) (*Signature, error)
+}
+
 // ClientOpts allows to configure a client
 type ClientOpts struct {
 	cacheDir string
@@ -0,0 +1,167 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+const (
+	// defaultBuilderID identifies charts-syncer as the builder of the
+	// repackaged charts
+	defaultBuilderID = "https://github.com/bitnami-labs/charts-syncer"
+	// inTotoStatementType is the type of in-toto v0.1 statements
+	inTotoStatementType = "https://in-toto.io/Statement/v0.1"
+	// slsaProvenancePredicateType is the type of SLSA v0.2 provenance predicates
+	slsaProvenancePredicateType = "https://slsa.dev/provenance/v0.2"
+	// attestationBuildType identifies how charts-syncer repackages charts
+	attestationBuildType = "https://github.com/bitnami-labs/charts-syncer/sync@v1"
+)
+
+// statement is an in-toto statement about a repackaged chart
+type statement struct {
+	Type          string     `json:"_type"`
+	PredicateType string     `json:"predicateType"`
+	Subject       []artifact `json:"subject"`
+	Predicate     provenance `json:"predicate"`
+}
+
+// artifact is a digested artifact of a statement
+type artifact struct {
+	Name   string            `json:"name,omitempty"`
+	URI    string            `json:"uri,omitempty"`
+	Digest map[string]string `json:"digest"`
+}
+
+// provenance is a SLSA v0.2 provenance predicate
+type provenance struct {
+	Builder struct {
+		ID string `json:"id"`
+	} `json:"builder"`
+	BuildType  string               `json:"buildType"`
+	Invocation provenanceInvocation `json:"invocation"`
+	Metadata   provenanceMetadata   `json:"metadata"`
+	Materials  []artifact           `json:"materials"`
+}
+
+// provenanceInvocation describes the sync run repackaging a chart
+type provenanceInvocation struct {
+	Parameters  provenanceParameters `json:"parameters"`
+	Environment map[string]string    `json:"environment,omitempty"`
+}
+
+// provenanceParameters are the changes made to a chart while it was
+// repackaged
+type provenanceParameters struct {
+	Transformations         []json.RawMessage `json:"transformations,omitempty"`
+	VersionSuffix           string            `json:"versionSuffix,omitempty"`
+	Name                    string            `json:"name,omitempty"`
+	RelocateContainerImages bool              `json:"relocateContainerImages,omitempty"`
+}
+
+// provenanceMetadata holds the timestamps of a sync run
+type provenanceMetadata struct {
+	BuildInvocationID string    `json:"buildInvocationId,omitempty"`
+	BuildStartedOn    time.Time `json:"buildStartedOn"`
+	BuildFinishedOn   time.Time `json:"buildFinishedOn"`
+}
+
+// buildAttestation returns the SLSA provenance attestation of a repackaged
+// chart, if enabled.
+func (s *Syncer) buildAttestation(ch *Chart, tgz string, metadata *helmchart.Metadata, startedOn time.Time) ([]byte, error) {
+	// Intermediate bundles are not pushed as charts
+	if !s.attestation.GetEnabled() || !strings.HasSuffix(tgz, ".tgz") {
+		return nil, nil
+	}
+	_, sourceRepo, err := s.sourceClient(ch.Name, ch.Version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	digest, err := utils.FileSha256(tgz)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	st := statement{
+		Type:          inTotoStatementType,
+		PredicateType: slsaProvenancePredicateType,
+		Subject:       []artifact{{Name: metadata.Name, Digest: map[string]string{"sha256": digest}}},
+	}
+	st.Predicate.Builder.ID = s.attestation.GetBuilderId()
+	if st.Predicate.Builder.ID == "" {
+		st.Predicate.Builder.ID = defaultBuilderID
+	}
+	st.Predicate.BuildType = attestationBuildType
+
+	params := &st.Predicate.Invocation.Parameters
+	if params.Transformations, err = s.appliedTransformations(ch.Name); err != nil {
+		return nil, errors.Trace(err)
+	}
+	params.VersionSuffix = s.versionSuffix
+	if metadata.Name != ch.Name {
+		params.Name = metadata.Name
+	}
+	params.RelocateContainerImages = s.relocateContainerImages
+	st.Predicate.Invocation.Environment = map[string]string{}
+	if s.syncerVersion != "" {
+		st.Predicate.Invocation.Environment["syncerVersion"] = s.syncerVersion
+	}
+	if s.runID != "" {
+		st.Predicate.Invocation.Environment["runId"] = s.runID
+	}
+
+	st.Predicate.Metadata = provenanceMetadata{
+		BuildInvocationID: s.runID,
+		BuildStartedOn:    startedOn.UTC(),
+		BuildFinishedOn:   time.Now().UTC(),
+	}
+	st.Predicate.Materials = []artifact{{
+		URI:    chartPackageURL(ch, sourceRepo.GetUrl()),
+		Digest: map[string]string{"sha256": ch.Digest},
+	}}
+	return json.MarshalIndent(st, "", "  ")
+}
+
+// chartPackageURL returns the package URL of a source chart
+func chartPackageURL(ch *Chart, repoURL string) string {
+	purl := fmt.Sprintf("pkg:helm/%s@%s", ch.Name, ch.Version)
+	if repoURL == "" {
+		return purl
+	}
+	return purl + "?repository_url=" + url.QueryEscape(repoURL)
+}
+
+// uploadAttestation pushes the attestation of a chart to the target
+// repository, signed with cosign if it is configured and the target supports
+// signed attestations
+func (s *Syncer) uploadAttestation(tgz string, att []byte, metadata *helmchart.Metadata, id string) error {
+	if signer, ok := s.cosign.(types.StatementSigner); ok {
+		if w, ok := s.cli.dst.(client.SignedAttestationWriter); ok {
+			klog.V(3).Infof("Uploading %q attestation signed with cosign...", id)
+			return errors.Annotatef(w.UploadSignedAttestation(att, metadata, signer), "uploading %q attestation", id)
+		}
+	}
+	w, ok := s.cli.dst.(client.AttestationWriter)
+	if !ok {
+		if s.strict {
+			return errors.Errorf("target repository does not support attestations, unable to push %q attestation", id)
+		}
+		klog.Warningf("Target repository does not support attestations, skipping %q attestation", id)
+		return nil
+	}
+	klog.V(3).Infof("Uploading %q attestation...", id)
+	if err := w.UploadAttestation(tgz, att, metadata); err != nil {
+		return errors.Annotatef(err, "uploading %q attestation", id)
+	}
+	return nil
+}
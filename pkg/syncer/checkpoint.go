@@ -0,0 +1,107 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+)
+
+// CheckpointFilename is the name of the file written to the workdir when a
+// sync is interrupted
+const CheckpointFilename = "checkpoint.json"
+
+// ErrInterrupted is returned when a sync is interrupted before all the pending
+// charts were synced
+var ErrInterrupted = errors.New("sync interrupted")
+
+// Checkpoint describes the state of an interrupted sync
+type Checkpoint struct {
+	InterruptedAt time.Time `json:"interruptedAt"`
+	// Synced contains the charts that were successfully synced
+	Synced []string `json:"synced"`
+	// Failed contains the charts that were processed but could not be synced
+	Failed []string `json:"failed"`
+	// Pending contains the charts that were not processed
+	Pending []string `json:"pending"`
+}
+
+// interrupt reports the state of an interrupted sync, writes it to the workdir
+// and returns ErrInterrupted
+func (s *Syncer) interrupt(synced, failed, pending []string, errs error) error {
+	klog.Warningf("Sync interrupted: %d charts synced, %d failed, %d pending", len(synced), len(failed), len(pending))
+	if errs != nil {
+		klog.Warningf("There were some errors before the interruption: %v", errs)
+	}
+
+	for _, id := range pending {
+		if ch := s.getIndex().Get(id); ch != nil {
+			s.lastPending = append(s.lastPending, ch)
+		}
+	}
+
+	cp := &Checkpoint{
+		InterruptedAt: time.Now().UTC(),
+		Synced:        synced,
+		Failed:        failed,
+		Pending:       pending,
+	}
+	if err := writeCheckpoint(s.workdir, cp); err != nil {
+		klog.Errorf("unable to write checkpoint: %+v", err)
+	}
+	return ErrInterrupted
+}
+
+// writeCheckpoint stores a checkpoint in the specified directory
+func writeCheckpoint(dir string, cp *Checkpoint) error {
+	if dir == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Write to a temporary file first so a second signal cannot leave a
+	// truncated checkpoint behind
+	f := filepath.Join(dir, CheckpointFilename)
+	tmp := f + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.Rename(tmp, f); err != nil {
+		return errors.Trace(err)
+	}
+	klog.Infof("Checkpoint written to %q", f)
+	return nil
+}
+
+// ReadCheckpoint reads the checkpoint stored in the specified directory, if
+// any
+func ReadCheckpoint(dir string) (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, CheckpointFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NotFoundf("checkpoint in %q", dir)
+		}
+		return nil, errors.Trace(err)
+	}
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, errors.Annotatef(err, "parsing checkpoint")
+	}
+	return cp, nil
+}
+
+// chartIDs returns the ids of the provided charts
+func chartIDs(charts []*Chart) []string {
+	var ids []string
+	for _, ch := range charts {
+		ids = append(ids, fmt.Sprintf("%s-%s", ch.Name, ch.Version))
+	}
+	return ids
+}
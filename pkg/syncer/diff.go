@@ -0,0 +1,170 @@
+package syncer
+
+import (
+	"sort"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// The differences between the source and the target
+const (
+	// DiffSourceOnly is the change of the chart versions only in the source,
+	// which would be pushed to the target
+	DiffSourceOnly = "source-only"
+	// DiffTargetOnly is the change of the chart versions only in the target
+	DiffTargetOnly = "target-only"
+	// DiffDigestMismatch is the change of the chart versions re-published
+	// upstream, which would be synced again
+	DiffDigestMismatch = "digest-mismatch"
+)
+
+// DiffEntry is a chart version differing between the source and the target
+type DiffEntry struct {
+	// Name and Version are the ones of the source chart, or of the target
+	// one if it is only in the target
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Change  string `json:"change"`
+	// SourceDigest is the SHA256 digest of the source package
+	SourceDigest string `json:"sourceDigest,omitempty"`
+	// TargetDigest is the SHA256 digest of the package in the target, or of
+	// the one recorded in the state store
+	TargetDigest string `json:"targetDigest,omitempty"`
+}
+
+// LastDiff returns the differences between the source and the target found by
+// the last sync run in dry-run mode, or by the last Diff call, sorted by name
+// and version. It returns nil if the last run was not a dry run.
+func (s *Syncer) LastDiff() []DiffEntry {
+	return s.lastDiff
+}
+
+// Diff compares the source and the target without syncing anything, returning
+// the differences sorted by name and version. As on SyncPendingCharts, the
+// problems loading some of the charts are only warned about, and returned
+// along with the differences of the others, unless the strict mode is
+// enabled.
+func (s *Syncer) Diff(names ...string) ([]DiffEntry, error) {
+	s.resetRun()
+	var errs error
+	if err := s.loadCharts(names...); err != nil {
+		if s.strict {
+			return nil, errors.Annotatef(err, "strict mode: unable to load all the requested charts")
+		}
+		klog.Warningf("There were some problems loading the information of the requested charts: %v", err)
+		errs = errors.Trace(err)
+	}
+	charts, err := s.topologicalSortCharts()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.lastDiff = s.diff(charts)
+	return s.lastDiff, errs
+}
+
+// recordDigestMismatch records a chart version whose digest in the target, or
+// in the state store, differs from the source one
+func (s *Syncer) recordDigestMismatch(id, targetDigest string) {
+	if s.mismatches == nil {
+		s.mismatches = map[string]string{}
+	}
+	s.mismatches[id] = targetDigest
+}
+
+// diff returns the differences between the source and the target, given the
+// charts out of sync. The chart versions only in the target are the ones of
+// the indexed charts, or of any chart of the target when the whole source is
+// synced, that do not match any source version.
+func (s *Syncer) diff(charts []*Chart) []DiffEntry {
+	entries := []DiffEntry{}
+	for _, ch := range charts {
+		id := ch.Name + "-" + ch.Version
+		e := DiffEntry{Name: ch.Name, Version: ch.Version, Change: DiffSourceOnly, SourceDigest: ch.Digest}
+		if digest, ok := s.mismatches[id]; ok {
+			e.Change, e.TargetDigest = DiffDigestMismatch, digest
+		}
+		entries = append(entries, e)
+	}
+
+	targetOnly, err := s.targetOnlyCharts()
+	if err != nil {
+		klog.Warningf("unable to list the charts only in the target: %v", err)
+	}
+	entries = append(entries, targetOnly...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return utils.CompareVersions(entries[i].Version, entries[j].Version) < 0
+	})
+	return entries
+}
+
+// targetOnlyCharts returns the chart versions of the target that do not match
+// any version of the source
+func (s *Syncer) targetOnlyCharts() ([]DiffEntry, error) {
+	return s.targetVersionsNotIn(s.expectedVersions(s.sourceVersions))
+}
+
+// expectedVersions returns the target versions of the given source versions,
+// by target chart name
+func (s *Syncer) expectedVersions(sourceVersions map[string][]string) map[string]map[string]bool {
+	expected := map[string]map[string]bool{}
+	for name, versions := range sourceVersions {
+		targetName := s.targetName(name)
+		if expected[targetName] == nil {
+			expected[targetName] = map[string]bool{}
+		}
+		for _, v := range versions {
+			expected[targetName][s.targetVersion(v)] = true
+		}
+	}
+	return expected
+}
+
+// targetVersionsNotIn returns the chart versions of the target that are not
+// expected, for the expected charts and, when the whole source is explored,
+// for the charts of the target not selected in the source
+func (s *Syncer) targetVersionsNotIn(expected map[string]map[string]bool) ([]DiffEntry, error) {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if s.discoveredAll {
+		all, err := s.cli.dst.List()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		// The charts skipped in the source are not compared
+		selected := map[string]bool{}
+		for _, name := range s.selectedCharts {
+			selected[s.targetName(name)] = true
+		}
+		for _, name := range all {
+			if !selected[name] {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var entries []DiffEntry
+	for _, name := range names {
+		versions, err := s.cli.dst.ListChartVersions(name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return entries, errors.Annotatef(err, "listing %q chart versions in the target", name)
+		}
+		for _, v := range versions {
+			if !expected[name][v] {
+				entries = append(entries, DiffEntry{Name: name, Version: v, Change: DiffTargetOnly})
+			}
+		}
+	}
+	return entries, nil
+}
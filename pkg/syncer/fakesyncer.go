@@ -1,6 +1,7 @@
 package syncer
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path"
@@ -15,6 +16,9 @@ import (
 type FakeSyncerOpts struct {
 	Destination string
 	skipCharts  []string
+	workdir     string
+	ctx         context.Context
+	workers     int
 }
 
 // FakeSyncerOption is an option value used to create a new fake syncer instance.
@@ -33,6 +37,27 @@ func WithFakeSkipCharts(charts []string) FakeSyncerOption {
 	}
 }
 
+// WithFakeWorkdir configures the syncer workdir
+func WithFakeWorkdir(dir string) FakeSyncerOption {
+	return func(s *FakeSyncerOpts) {
+		s.workdir = dir
+	}
+}
+
+// WithFakeContext configures the syncer context
+func WithFakeContext(ctx context.Context) FakeSyncerOption {
+	return func(s *FakeSyncerOpts) {
+		s.ctx = ctx
+	}
+}
+
+// WithFakeWorkers configures the number of charts synced concurrently
+func WithFakeWorkers(n int) FakeSyncerOption {
+	return func(s *FakeSyncerOpts) {
+		s.workers = n
+	}
+}
+
 // NewFake returns a fake Syncer
 func NewFake(t *testing.T, opts ...FakeSyncerOption) *Syncer {
 	sopts := &FakeSyncerOpts{}
@@ -40,6 +65,11 @@ func NewFake(t *testing.T, opts ...FakeSyncerOption) *Syncer {
 		o(sopts)
 	}
 
+	// The charts cache must not be left in the package directory
+	if sopts.workdir == "" {
+		sopts.workdir = t.TempDir()
+	}
+
 	srcTmp, err := ioutil.TempDir("", "charts-syncer-tests-src-fake")
 	if err != nil {
 		t.Fatalf("error creating temporary folder: %v", err)
@@ -91,5 +121,8 @@ func NewFake(t *testing.T, opts ...FakeSyncerOption) *Syncer {
 			dst: dstCli,
 		},
 		skipCharts: sopts.skipCharts,
+		workdir:    sopts.workdir,
+		ctx:        sopts.ctx,
+		workers:    sopts.workers,
 	}
 }
@@ -2,6 +2,8 @@ package syncer
 
 import (
 	"fmt"
+	"path"
+	"regexp"
 	"sort"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/philopon/go-toposort"
 	"k8s.io/klog"
 
+	"github.com/bitnami-labs/charts-syncer/api"
 	"github.com/bitnami-labs/charts-syncer/internal/chart"
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
 )
@@ -22,6 +25,11 @@ type Chart struct {
 	Dependencies []string
 
 	TgzPath string
+	// Digest is the SHA256 digest of the chart package
+	Digest string
+	// Priority of the source repository providing the chart. Lower values
+	// are preferred.
+	Priority int32
 }
 
 // ChartIndex is a map linking a chart reference with its Chart
@@ -55,6 +63,35 @@ func (i ChartIndex) Add(id string, chart *Chart) error {
 	return nil
 }
 
+// AddWithStrategy adds a chart in the index, using the specified strategy to
+// resolve conflicts with an already indexed chart with a different digest
+func (i ChartIndex) AddWithStrategy(id string, chart *Chart, strategy api.ConflictStrategy) error {
+	current, ok := i[id]
+	if !ok {
+		i[id] = chart
+		return nil
+	}
+	if current.Digest == chart.Digest {
+		klog.V(5).Infof("Skipping %q chart: Already indexed", id)
+		return nil
+	}
+
+	switch strategy {
+	case api.ConflictStrategy_CONFLICT_FIRST_WINS:
+		klog.Warningf("%q chart is provided with different digests (%s, %s). Keeping the first one...", id, current.Digest, chart.Digest)
+	case api.ConflictStrategy_CONFLICT_PREFER_PRIORITY:
+		klog.Warningf("%q chart is provided with different digests (%s, %s). Keeping the one with the lowest priority value...", id, current.Digest, chart.Digest)
+		if chart.Priority < current.Priority {
+			i[id] = chart
+		}
+	case api.ConflictStrategy_CONFLICT_FAIL:
+		return errors.Errorf("%q chart is provided with different digests (%s, %s)", id, current.Digest, chart.Digest)
+	default:
+		return errors.Errorf("unsupported conflict strategy %q", strategy)
+	}
+	return nil
+}
+
 // Get returns an index chart
 func (i ChartIndex) Get(id string) *Chart {
 	if c, ok := i[id]; ok {
@@ -63,23 +100,27 @@ func (i ChartIndex) Get(id string) *Chart {
 	return nil
 }
 
+// getConflictStrategy returns the strategy used to resolve indexing conflicts
+func (s *Syncer) getConflictStrategy() api.ConflictStrategy {
+	// Conflicts are always fatal in strict mode
+	if s.strict {
+		return api.ConflictStrategy_CONFLICT_FAIL
+	}
+	return s.conflictStrategy
+}
+
 // loadCharts loads the charts map into the index from the source repo
 func (s *Syncer) loadCharts(charts ...string) error {
-	if len(charts) == 0 {
-		if !s.autoDiscovery {
-			return errors.Errorf("unable to discover charts to sync")
-		}
-		srcCharts, err := s.cli.src.List()
-		if err != nil {
-			return errors.Trace(err)
-		}
-		if len(srcCharts) == 0 {
-			return errors.Errorf("not found charts to sync")
-		}
-		charts = srcCharts
+	s.discoveredAll = len(charts) == 0 && len(s.chartsRegex) == 0
+	charts, err := s.selectCharts(charts)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.selectedCharts = charts
+	skip, err := s.chartsSkipper()
+	if err != nil {
+		return errors.Trace(err)
 	}
-	// Sort chart names
-	sort.Strings(charts)
 
 	// Create basic layout for date and parse flag to time type
 	publishingThreshold, err := utils.GetDateThreshold(s.fromDate)
@@ -91,7 +132,13 @@ func (s *Syncer) loadCharts(charts ...string) error {
 	// Iterate over charts in source index
 	var errs error
 	for _, name := range charts {
-		if shouldSkipChart(name, s.skipCharts) {
+		// Indexing is cheap to redo, so there is no need to keep track of
+		// the charts that were not explored.
+		if s.context().Err() != nil {
+			klog.Warningf("Sync interrupted. Stopping charts indexing...")
+			break
+		}
+		if skip(name) {
 			klog.V(3).Infof("Indexing %q charts SKIPPED...", name)
 			continue
 		}
@@ -101,34 +148,23 @@ func (s *Syncer) loadCharts(charts ...string) error {
 			errs = multierror.Append(errs, errors.Trace(err))
 			continue
 		}
+		if s.sourceVersions == nil {
+			s.sourceVersions = map[string][]string{}
+		}
+		s.sourceVersions[name] = versions
+		if versions, err = s.filterVersions(name, versions); err != nil {
+			errs = multierror.Append(errs, errors.Trace(err))
+			continue
+		}
 
 		klog.V(5).Infof("Found %d versions for %q chart: %v", len(versions), name, versions)
 		klog.V(3).Infof("Indexing %q charts...", name)
-		if s.latestVersionOnly {
-			vs := make([]*semver.Version, len(versions))
-			for i, r := range versions {
-				v, err := semver.NewVersion(r)
-				if err != nil {
-					return errors.Trace(err)
-				}
-				vs[i] = v
-			}
-			sort.Sort(semver.Collection(vs))
-			// The last element of the array is the latest version
-			version := vs[len(vs)-1].String()
+		for _, version := range s.latestVersions(name, versions) {
 			if err := s.processVersion(name, version, publishingThreshold); err != nil {
 				klog.Warningf("Failed processing %s:%s chart. The index will remain incomplete.", name, version)
 				errs = multierror.Append(errs, errors.Trace(err))
 				continue
 			}
-		} else {
-			for _, version := range versions {
-				if err := s.processVersion(name, version, publishingThreshold); err != nil {
-					klog.Warningf("Failed processing %s:%s chart. The index will remain incomplete.", name, version)
-					errs = multierror.Append(errs, errors.Trace(err))
-					continue
-				}
-			}
 		}
 	}
 
@@ -139,6 +175,7 @@ func (s *Syncer) loadCharts(charts ...string) error {
 func (s *Syncer) processVersion(name, version string, publishingThreshold time.Time) error {
 	details, err := s.cli.src.GetChartDetails(name, version)
 	if err != nil {
+		s.skipChart(name, version, ActionFailed, err.Error(), "")
 		return err
 	}
 
@@ -146,14 +183,17 @@ func (s *Syncer) processVersion(name, version string, publishingThreshold time.T
 	klog.V(5).Infof("Details for %q chart: %+v", id, details)
 	if details.PublishedAt.Before(publishingThreshold) {
 		klog.V(5).Infof("Skipping %q chart: Published before %q", id, publishingThreshold.String())
+		s.skipChart(name, version, ActionSkipped, "published before "+publishingThreshold.Format(time.RFC3339), details.Digest)
 		return nil
 	}
 
-	if ok, err := s.cli.dst.Has(name, version); err != nil {
+	if ok, err := s.synced(name, version, details.Digest); err != nil {
 		klog.Errorf("unable to explore target repo to check %q chart: %v", id, err)
+		s.skipChart(name, version, ActionFailed, err.Error(), details.Digest)
 		return err
 	} else if ok {
 		klog.V(5).Infof("Skipping %q chart: Already synced", id)
+		s.skipChart(name, version, ActionSkipped, "already synced", details.Digest)
 		return nil
 	}
 
@@ -164,6 +204,7 @@ func (s *Syncer) processVersion(name, version string, publishingThreshold time.T
 
 	if err := s.loadChart(name, version); err != nil {
 		klog.Errorf("unable to load %q chart: %v", id, err)
+		s.skipChart(name, version, ActionFailed, err.Error(), details.Digest)
 		return err
 	}
 	return nil
@@ -190,13 +231,10 @@ func (s *Syncer) loadChart(name string, version string) error {
 		klog.V(5).Infof("Skipping %q chart: Already indexed", id)
 		return nil
 	}
-	// In the same way, dependencies may already exist in the target chart
-	// repository.
-	if ok, err := s.cli.dst.Has(name, version); err != nil {
-		return errors.Errorf("unable to explore target repo to check %q chart: %v", id, err)
-	} else if ok {
-		klog.V(5).Infof("Skipping %q chart: Already synced", id)
-		return nil
+	// Index-only syncs point at the source charts, which are not downloaded
+	if s.indexOnly {
+		klog.V(4).Infof("Indexing %q chart", id)
+		return errors.Trace(s.getIndex().AddWithStrategy(id, &Chart{Name: name, Version: version}, s.getConflictStrategy()))
 	}
 
 	tgz, err := s.cli.src.Fetch(name, version)
@@ -204,10 +242,23 @@ func (s *Syncer) loadChart(name string, version string) error {
 		return errors.Trace(err)
 	}
 
+	digest, err := utils.FileSha256(tgz)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := s.verifySourceChart(name, version, tgz, digest, id); err != nil {
+		return errors.Trace(err)
+	}
+	_, sourceRepo, err := s.sourceClient(name, version)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	ch := &Chart{
-		Name:    name,
-		Version: version,
-		TgzPath: tgz,
+		Name:     name,
+		Version:  version,
+		TgzPath:  tgz,
+		Digest:   digest,
+		Priority: sourceRepo.GetPriority(),
 	}
 
 	if !s.skipDependencies {
@@ -218,17 +269,26 @@ func (s *Syncer) loadChart(name string, version string) error {
 
 		if len(deps) == 0 {
 			klog.V(4).Infof("Indexing %q chart", id)
-			return errors.Trace(s.getIndex().Add(id, ch))
+			return errors.Trace(s.getIndex().AddWithStrategy(id, ch, s.getConflictStrategy()))
 		}
 
 		var errs error
 		for _, dep := range deps {
 			depID := fmt.Sprintf("%s-%s", dep.Name, dep.Version)
+			ch.Dependencies = append(ch.Dependencies, depID)
+			// In the same way, dependencies may already exist in the target
+			// chart repository. The charts loaded by processVersion were
+			// already checked.
+			if ok, err := s.synced(dep.Name, dep.Version, ""); err != nil {
+				errs = multierror.Append(errs, errors.Errorf("unable to explore target repo to check %q chart: %v", depID, err))
+				continue
+			} else if ok {
+				klog.V(5).Infof("Skipping %q chart: Already synced", depID)
+				continue
+			}
 			if err := s.loadChart(dep.Name, dep.Version); err != nil {
 				errs = multierror.Append(errs, errors.Annotatef(err, "invalid %q chart dependency", depID))
-				continue
 			}
-			ch.Dependencies = append(ch.Dependencies, depID)
 		}
 		if errs != nil {
 			return errors.Trace(errs)
@@ -236,7 +296,28 @@ func (s *Syncer) loadChart(name string, version string) error {
 	}
 
 	klog.V(4).Infof("Indexing %q chart", id)
-	return errors.Trace(s.getIndex().Add(id, ch))
+	return errors.Trace(s.getIndex().AddWithStrategy(id, ch, s.getConflictStrategy()))
+}
+
+// targetVersion returns the version of a chart once pushed to the target.
+//
+// Intermediate bundles keep the upstream version, the suffix is appended once
+// they are pushed to the target repository.
+func (s *Syncer) targetVersion(version string) string {
+	if s.target.GetIntermediateBundlesPath() != "" {
+		return version
+	}
+	return utils.AppendVersionSuffix(version, s.versionSuffix)
+}
+
+// targetName returns the name of a chart once pushed to the target.
+//
+// Like versions, intermediate bundles keep the upstream name.
+func (s *Syncer) targetName(name string) string {
+	if s.target.GetIntermediateBundlesPath() != "" {
+		return name
+	}
+	return utils.TargetChartName(name, s.rename)
 }
 
 // topologicalSortCharts returns the indexed charts, topologically sorted.
@@ -263,9 +344,177 @@ func (s *Syncer) topologicalSortCharts() ([]*Chart, error) {
 	return charts, nil
 }
 
-func shouldSkipChart(chartName string, skippedCharts []string) bool {
-	for _, s := range skippedCharts {
-		if s == chartName {
+// filterVersions returns the versions of a chart matching its filter. Versions
+// that are not semver do not match any constraint and are not pre-releases.
+func (s *Syncer) filterVersions(name string, versions []string) ([]string, error) {
+	filter := s.chartFilters[name]
+	constraint := filter.GetVersions()
+	skipPrereleases := s.skipPrereleases || filter.GetSkipPrereleases()
+	if constraint == "" && !skipPrereleases {
+		return versions, nil
+	}
+	var c *semver.Constraints
+	if constraint != "" {
+		var err error
+		if c, err = semver.NewConstraint(constraint); err != nil {
+			return nil, errors.Annotatef(err, "parsing %q versions constraint of %q chart", constraint, name)
+		}
+	}
+	var filtered []string
+	for _, version := range versions {
+		v, err := utils.ParseSemver(version)
+		if c != nil && (err != nil || !c.Check(v)) {
+			klog.V(5).Infof("Skipping %s:%s chart: Does not match %q", name, version, constraint)
+			continue
+		}
+		if skipPrereleases && err == nil && v.Prerelease() != "" {
+			klog.V(5).Infof("Skipping %s:%s chart: Pre-release version", name, version)
+			continue
+		}
+		filtered = append(filtered, version)
+	}
+	return filtered, nil
+}
+
+// latestVersions returns the most recent versions of a chart to sync: the
+// latest one with latestVersionOnly, or as many as its filter or the global
+// latestVersions allow. Every version is synced otherwise.
+func (s *Syncer) latestVersions(name string, versions []string) []string {
+	n := int(s.chartFilters[name].GetLatestVersions())
+	if n == 0 {
+		n = s.keepLatestVersions
+	}
+	if s.latestVersionOnly {
+		n = 1
+	}
+	if n == 0 || len(versions) <= n {
+		return versions
+	}
+	sorted := append([]string{}, versions...)
+	utils.SortVersions(sorted)
+	return sorted[len(sorted)-n:]
+}
+
+// selectCharts returns the sorted names of the charts to sync: the requested
+// ones and the discovered ones matching the charts regular expressions, or
+// every discovered chart if none is requested
+func (s *Syncer) selectCharts(charts []string) ([]string, error) {
+	if len(charts) > 0 && len(s.chartsRegex) == 0 {
+		sort.Strings(charts)
+		return charts, nil
+	}
+	if !s.autoDiscovery {
+		return nil, errors.Errorf("unable to discover charts to sync")
+	}
+	srcCharts, err := s.cli.src.List()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(s.chartsRegex) == 0 {
+		if len(srcCharts) == 0 {
+			return nil, errors.Errorf("not found charts to sync")
+		}
+		sort.Strings(srcCharts)
+		return srcCharts, nil
+	}
+
+	include, err := compileNameRegexps(s.chartsRegex)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	selected := map[string]bool{}
+	for _, name := range charts {
+		selected[name] = true
+	}
+	for _, name := range srcCharts {
+		if matchAny(include, name) {
+			selected[name] = true
+		}
+	}
+	if len(selected) == 0 {
+		return nil, errors.Errorf("not found charts to sync matching %q", s.chartsRegex)
+	}
+	charts = make([]string, 0, len(selected))
+	for name := range selected {
+		charts = append(charts, name)
+	}
+	sort.Strings(charts)
+	return charts, nil
+}
+
+// chartsSkipper returns a function reporting whether a chart is skipped: it is
+// listed in skipCharts or matches any of the exclude patterns or regular
+// expressions
+func (s *Syncer) chartsSkipper() (func(name string) bool, error) {
+	return newChartsSkipper(s.skipCharts, s.excludeCharts, s.excludeChartsRegex)
+}
+
+// SelectsChart returns whether a configuration syncs a chart, either listed in
+// its charts, matching its charts regular expressions or discovered, and not
+// skipped
+func SelectsChart(c *api.Config, name string) (bool, error) {
+	skip, err := newChartsSkipper(c.GetSkipCharts(), c.GetExcludeCharts(), c.GetExcludeChartsRegex())
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	if skip(name) {
+		return false, nil
+	}
+	if len(c.GetCharts()) == 0 && len(c.GetChartsRegex()) == 0 {
+		return true, nil
+	}
+	for _, chart := range c.GetCharts() {
+		if chart == name {
+			return true, nil
+		}
+	}
+	include, err := compileNameRegexps(c.GetChartsRegex())
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return matchAny(include, name), nil
+}
+
+// newChartsSkipper returns a function reporting whether a chart is listed in
+// skipCharts or matches any of the exclude patterns or regular expressions
+func newChartsSkipper(skipCharts, excludeCharts, excludeChartsRegex []string) (func(name string) bool, error) {
+	exclude, err := compileNameRegexps(excludeChartsRegex)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return func(name string) bool {
+		for _, c := range skipCharts {
+			if c == name {
+				return true
+			}
+		}
+		for _, pattern := range excludeCharts {
+			// Patterns are validated with the configuration
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return matchAny(exclude, name)
+	}, nil
+}
+
+// compileNameRegexps compiles regular expressions matching whole chart names
+func compileNameRegexps(regexps []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(regexps))
+	for _, re := range regexps {
+		r, err := regexp.Compile("^(?:" + re + ")$")
+		if err != nil {
+			return nil, errors.Annotatef(err, "parsing %q regular expression", re)
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+// matchAny returns whether a name matches any of the regular expressions
+func matchAny(regexps []*regexp.Regexp, name string) bool {
+	for _, r := range regexps {
+		if r.MatchString(name) {
 			return true
 		}
 	}
@@ -4,11 +4,14 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+
+	"github.com/bitnami-labs/charts-syncer/api"
 )
 
 func removeTgzPath(i ChartIndex) {
 	for _, c := range i {
 		c.TgzPath = ""
+		c.Digest = ""
 	}
 }
 
@@ -17,6 +20,10 @@ func TestLoadCharts(t *testing.T) {
 		desc           string
 		entries        []string
 		skippedEntries []string
+		chartsRegex    []string
+		excludeCharts  []string
+		excludeRegex   []string
+		filters        map[string]*api.ChartFilter
 		want           ChartIndex
 	}{
 		{
@@ -36,11 +43,58 @@ func TestLoadCharts(t *testing.T) {
 				"zookeeper-5.14.3": &Chart{Name: "zookeeper", Version: "5.14.3"},
 			},
 		},
+		{
+			desc:        "discover charts matching a regular expression",
+			entries:     []string{"zookeeper"},
+			chartsRegex: []string{"a.*"},
+			want: ChartIndex{
+				"apache-7.3.15":    &Chart{Name: "apache", Version: "7.3.15"},
+				"zookeeper-5.14.3": &Chart{Name: "zookeeper", Version: "5.14.3"},
+			},
+		},
+		{
+			desc:          "exclude charts matching a glob pattern",
+			excludeCharts: []string{"*che"},
+			want: ChartIndex{
+				"kafka-10.3.3":     &Chart{Name: "kafka", Version: "10.3.3", Dependencies: []string{"zookeeper-5.14.3"}},
+				"zookeeper-5.14.3": &Chart{Name: "zookeeper", Version: "5.14.3"},
+			},
+		},
+		{
+			desc:         "exclude charts matching a regular expression",
+			chartsRegex:  []string{".*"},
+			excludeRegex: []string{"k.*|apache"},
+			want: ChartIndex{
+				"zookeeper-5.14.3": &Chart{Name: "zookeeper", Version: "5.14.3"},
+			},
+		},
+		{
+			desc:    "filter apache versions",
+			entries: []string{"apache", "zookeeper"},
+			filters: map[string]*api.ChartFilter{"apache": {Versions: ">=8.0.0"}, "zookeeper": {Versions: "~5.14"}},
+			want: ChartIndex{
+				"zookeeper-5.14.3": &Chart{Name: "zookeeper", Version: "5.14.3"},
+			},
+		},
+		{
+			desc:    "filters do not apply to dependencies",
+			entries: []string{"kafka", "zookeeper"},
+			filters: map[string]*api.ChartFilter{"zookeeper": {Versions: "<5"}},
+			want: ChartIndex{
+				"kafka-10.3.3":     &Chart{Name: "kafka", Version: "10.3.3", Dependencies: []string{"zookeeper-5.14.3"}},
+				"zookeeper-5.14.3": &Chart{Name: "zookeeper", Version: "5.14.3"},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.desc, func(t *testing.T) {
 			s := NewFake(t, WithFakeSkipCharts(tc.skippedEntries))
+			s.autoDiscovery = true
+			s.chartsRegex = tc.chartsRegex
+			s.excludeCharts = tc.excludeCharts
+			s.excludeChartsRegex = tc.excludeRegex
+			s.chartFilters = tc.filters
 			if err := s.loadCharts(tc.entries...); err != nil {
 				t.Fatalf("unable to load charts: %v", err)
 			}
@@ -55,6 +109,147 @@ func TestLoadCharts(t *testing.T) {
 	}
 }
 
+func TestAddWithStrategy(t *testing.T) {
+	first := &Chart{Name: "apache", Version: "7.3.15", Digest: "aaa", Priority: 1}
+	testCases := []struct {
+		desc     string
+		chart    *Chart
+		strategy api.ConflictStrategy
+		want     *Chart
+		wantErr  bool
+	}{
+		{
+			desc:     "same digest",
+			chart:    &Chart{Name: "apache", Version: "7.3.15", Digest: "aaa"},
+			strategy: api.ConflictStrategy_CONFLICT_FAIL,
+			want:     first,
+		},
+		{
+			desc:     "first wins",
+			chart:    &Chart{Name: "apache", Version: "7.3.15", Digest: "bbb"},
+			strategy: api.ConflictStrategy_CONFLICT_FIRST_WINS,
+			want:     first,
+		},
+		{
+			desc:     "prefer lower priority value",
+			chart:    &Chart{Name: "apache", Version: "7.3.15", Digest: "bbb", Priority: 0},
+			strategy: api.ConflictStrategy_CONFLICT_PREFER_PRIORITY,
+			want:     &Chart{Name: "apache", Version: "7.3.15", Digest: "bbb", Priority: 0},
+		},
+		{
+			desc:     "keep lower priority value",
+			chart:    &Chart{Name: "apache", Version: "7.3.15", Digest: "bbb", Priority: 2},
+			strategy: api.ConflictStrategy_CONFLICT_PREFER_PRIORITY,
+			want:     first,
+		},
+		{
+			desc:     "fail",
+			chart:    &Chart{Name: "apache", Version: "7.3.15", Digest: "bbb"},
+			strategy: api.ConflictStrategy_CONFLICT_FAIL,
+			want:     first,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			i := ChartIndex{"apache-7.3.15": first}
+			err := i.AddWithStrategy("apache-7.3.15", tc.chart, tc.strategy)
+			if got, want := err != nil, tc.wantErr; got != want {
+				t.Fatalf("got error: %v, want error: %t", err, want)
+			}
+			if diff := cmp.Diff(tc.want, i.Get("apache-7.3.15")); diff != "" {
+				t.Errorf("want vs got diff:\n %+v", diff)
+			}
+		})
+	}
+}
+
+func TestFilterVersions(t *testing.T) {
+	versions := []string{"1.2.3", "1.3.0-beta.1", "0.0.0-dev", "v2.0.0", "2021-05-14"}
+	testCases := []struct {
+		desc            string
+		filter          *api.ChartFilter
+		skipPrereleases bool
+		want            []string
+	}{
+		{desc: "no filter", want: versions},
+		{desc: "semver constraint", filter: &api.ChartFilter{Versions: "^1.2"}, want: []string{"1.2.3"}},
+		{desc: "pre-release constraint", filter: &api.ChartFilter{Versions: "~1.3.0-0"}, want: []string{"1.3.0-beta.1"}},
+		{desc: "skip pre-releases", skipPrereleases: true, want: []string{"1.2.3", "v2.0.0", "2021-05-14"}},
+		{desc: "skip chart pre-releases", filter: &api.ChartFilter{SkipPrereleases: true}, want: []string{"1.2.3", "v2.0.0", "2021-05-14"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := &Syncer{chartFilters: map[string]*api.ChartFilter{"nginx": tc.filter}, skipPrereleases: tc.skipPrereleases}
+			got, err := s.filterVersions("nginx", versions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("want vs got diff:\n %+v", diff)
+			}
+		})
+	}
+}
+
+func TestLatestVersions(t *testing.T) {
+	versions := []string{"1.10.0", "1.2.3", "2.0.0", "1.9.1"}
+	testCases := []struct {
+		desc              string
+		latestVersionOnly bool
+		latestVersions    int
+		filter            *api.ChartFilter
+		want              []string
+	}{
+		{desc: "all versions", want: versions},
+		{desc: "latest version only", latestVersionOnly: true, latestVersions: 3, want: []string{"2.0.0"}},
+		{desc: "latest versions", latestVersions: 3, want: []string{"1.9.1", "1.10.0", "2.0.0"}},
+		{desc: "chart latest versions", latestVersions: 3, filter: &api.ChartFilter{LatestVersions: 2}, want: []string{"1.10.0", "2.0.0"}},
+		{desc: "fewer versions than requested", latestVersions: 5, want: versions},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := &Syncer{
+				latestVersionOnly:  tc.latestVersionOnly,
+				keepLatestVersions: tc.latestVersions,
+				chartFilters:       map[string]*api.ChartFilter{"nginx": tc.filter},
+			}
+			if diff := cmp.Diff(tc.want, s.latestVersions("nginx", versions)); diff != "" {
+				t.Errorf("want vs got diff:\n %+v", diff)
+			}
+		})
+	}
+}
+
+func TestSelectsChart(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *api.Config
+		want   bool
+	}{
+		{desc: "discovered", config: &api.Config{}, want: true},
+		{desc: "listed", config: &api.Config{Charts: []string{"redis", "nginx"}}, want: true},
+		{desc: "not listed", config: &api.Config{Charts: []string{"redis"}}},
+		{desc: "matching a regular expression", config: &api.Config{ChartsRegex: []string{"ng.*"}}, want: true},
+		{desc: "matching a regular expression partially", config: &api.Config{ChartsRegex: []string{"ng"}}},
+		{desc: "skipped", config: &api.Config{Charts: []string{"nginx"}, SkipCharts: []string{"nginx"}}},
+		{desc: "excluded", config: &api.Config{ExcludeCharts: []string{"ngi*"}}},
+		{desc: "excluded by a regular expression", config: &api.Config{ExcludeChartsRegex: []string{"n.*x"}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := SelectsChart(tc.config, "nginx")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestTopologicalSortCharts(t *testing.T) {
 	testCases := []struct {
 		desc  string
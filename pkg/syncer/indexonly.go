@@ -0,0 +1,64 @@
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+)
+
+// writeIndex adds the index entries of the source charts to the index.yaml
+// file of the LOCAL target, keeping their source URLs. Entries already in the
+// index are replaced.
+func (s *Syncer) writeIndex(charts []*Chart) error {
+	r, ok := s.cli.src.(client.IndexEntryReader)
+	if !ok {
+		return errors.Errorf("source repository has no index to read the chart entries from")
+	}
+	file := filepath.Join(s.target.GetRepo().GetPath(), "index.yaml")
+	index := repo.NewIndexFile()
+	if _, err := os.Stat(file); err == nil {
+		if index, err = repo.LoadIndexFile(file); err != nil {
+			return errors.Annotatef(err, "loading %q", file)
+		}
+	} else if !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+
+	for _, ch := range charts {
+		entry, err := r.GetIndexEntry(ch.Name, ch.Version)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		versions := index.Entries[ch.Name]
+		for i, cv := range versions {
+			if cv.Version == ch.Version {
+				versions = append(versions[:i], versions[i+1:]...)
+				break
+			}
+		}
+		klog.V(3).Infof("Indexing %q chart from %v", fmt.Sprintf("%s-%s", ch.Name, ch.Version), entry.URLs)
+		index.Entries[ch.Name] = append(versions, entry)
+	}
+
+	if s.dryRun {
+		klog.Infof("dry-run: Writing %d charts to %q", len(charts), file)
+		return nil
+	}
+	index.SortEntries()
+	index.Generated = time.Now()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.Trace(err)
+	}
+	if err := index.WriteFile(file, 0644); err != nil {
+		return errors.Annotatef(err, "writing %q", file)
+	}
+	klog.Infof("Wrote %d charts to %q", len(charts), file)
+	return nil
+}
@@ -0,0 +1,250 @@
+package syncer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	helmchart "helm.sh/helm/v3/pkg/chart"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+)
+
+// verifySourceChart checks a fetched source chart against its digest in the
+// source repository and its provenance file, as configured in the source
+// verification.
+func (s *Syncer) verifySourceChart(name, version, tgz, digest, id string) error {
+	v := s.source.GetVerify()
+	if v == nil {
+		return nil
+	}
+	if v.GetDigest() {
+		details, err := s.cli.src.GetChartDetails(name, version)
+		if err != nil {
+			return errors.Annotatef(err, "getting %q chart digest", id)
+		}
+		want := strings.ToLower(strings.TrimPrefix(details.Digest, "sha256:"))
+		if want == "" {
+			return errors.Errorf("refusing to sync %q chart: the source repository does not provide its digest", id)
+		}
+		if want != digest {
+			return errors.Errorf("refusing to sync %q chart: digest mismatch (got: %s, want: %s)", id, digest, want)
+		}
+		klog.V(4).Infof("Verified %q chart digest", id)
+	}
+	if keyring := v.GetKeyring(); keyring != "" {
+		src, _, err := s.sourceClient(name, version)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		r, ok := src.(client.ProvenanceReader)
+		if !ok {
+			return errors.Errorf("refusing to sync %q chart: the source repository does not support provenance files", id)
+		}
+		prov, err := r.FetchProvenance(name, version)
+		if errors.IsNotFound(err) {
+			return errors.Errorf("refusing to sync %q chart: it has no provenance file", id)
+		}
+		if err != nil {
+			return errors.Annotatef(err, "fetching %q provenance file", id)
+		}
+		if err := chart.Verify(tgz, prov, keyring); err != nil {
+			return errors.Annotatef(err, "refusing to sync %q chart", id)
+		}
+		klog.V(4).Infof("Verified %q chart provenance file", id)
+	}
+	return nil
+}
+
+// verifyUpload reads a pushed chart back from the target repository and checks
+// that it has the digest of the uploaded package, so truncated uploads and
+// corrupting proxies do not go unnoticed.
+func (s *Syncer) verifyUpload(tgz string, metadata *helmchart.Metadata, id string) error {
+	// Intermediate bundles are not pushed as charts
+	if !strings.HasSuffix(tgz, ".tgz") {
+		return nil
+	}
+	r, ok := s.cli.dst.(client.ChartDigestReader)
+	if !ok {
+		if s.strict {
+			return errors.Errorf("target repository does not support reading charts back, unable to verify %q upload", id)
+		}
+		klog.Warningf("Target repository does not support reading charts back, skipping %q upload verification", id)
+		return nil
+	}
+	want, err := utils.FileSha256(tgz)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	got, err := r.ChartDigest(metadata.Name, metadata.Version)
+	if err != nil {
+		return errors.Annotatef(err, "reading %q chart back from the target", id)
+	}
+	if got != want {
+		return errors.Errorf("%q chart is corrupted in the target: digest mismatch (got: %s, want: %s)", id, got, want)
+	}
+	klog.V(4).Infof("Verified %q chart upload", id)
+	return nil
+}
+
+// sameTargetDigest returns whether a chart already in the target has the
+// upstream digest. Only the charts pushed unmodified are compared with the
+// target package: the repackaged ones never have the upstream digest, they are
+// compared with the digest recorded in the state store instead.
+func (s *Syncer) sameTargetDigest(name, version, digest, id string) (bool, error) {
+	if s.target.GetIntermediateBundlesPath() != "" || s.rewritten(name, version) {
+		klog.V(4).Infof("Unable to compare %q chart digest with the target: it is repackaged", id)
+		return true, nil
+	}
+	if !s.skipDependencies {
+		// Charts with dependencies are repackaged to update them
+		deps, err := s.dependencies(name, version)
+		if err != nil {
+			return false, errors.Annotatef(err, "getting %q chart dependencies", id)
+		}
+		if deps > 0 {
+			klog.V(4).Infof("Unable to compare %q chart digest with the target: it is repackaged", id)
+			return true, nil
+		}
+	}
+
+	targetName, targetVersion := s.targetName(name), s.targetVersion(version)
+	details, err := s.cli.dst.GetChartDetails(targetName, targetVersion)
+	if err != nil {
+		return false, errors.Annotatef(err, "getting %q chart digest in the target", id)
+	}
+	got := strings.ToLower(strings.TrimPrefix(details.Digest, "sha256:"))
+	// Some clients report placeholder digests, the package is read instead
+	if !sha256Digest.MatchString(got) {
+		r, ok := s.cli.dst.(client.ChartDigestReader)
+		if !ok {
+			klog.V(4).Infof("Unable to compare %q chart digest with the target: it does not report digests", id)
+			return true, nil
+		}
+		if got, err = r.ChartDigest(targetName, targetVersion); err != nil {
+			return false, errors.Annotatef(err, "reading %q chart digest in the target", id)
+		}
+	}
+	if got == digest {
+		return true, nil
+	}
+	klog.Infof("%q chart changed upstream (%s in the target, %s in the source), syncing it again", id, got, digest)
+	s.recordDigestMismatch(id, got)
+	return false, nil
+}
+
+// sameTargetManifest returns whether a chart already in an OCI target has the
+// manifest pushing it again would produce. Charts are repackaged
+// deterministically, so the expected manifest is computed from the package the
+// chart would be pushed with, and the chart is synced again if the target has
+// another one. Charts whose transformations run commands or publish icons are
+// not repackaged to be compared, since they are not pushed.
+func (s *Syncer) sameTargetManifest(r client.ManifestDigestReader, name, version, id string) (bool, error) {
+	if s.relocateContainerImages || s.source.GetIntermediateBundlesPath() != "" {
+		klog.V(4).Infof("Unable to compare %q chart manifest with the target: relok8s does not repackage it reproducibly", id)
+		return true, nil
+	}
+	for _, t := range chart.SelectTransformations(s.transformations, name) {
+		if t.GetExec() != nil || t.GetIcon().GetUpload() || t.GetIcon().GetDownloadDir() != "" {
+			klog.V(4).Infof("Unable to compare %q chart manifest with the target: its transformations have side effects", id)
+			return true, nil
+		}
+	}
+	src, _, err := s.sourceClient(name, version)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	tgz, err := src.Fetch(name, version)
+	if err != nil {
+		return false, errors.Annotatef(err, "fetching %q chart", id)
+	}
+	ch := &Chart{Name: name, Version: version, TgzPath: tgz}
+	if !s.skipDependencies {
+		deps, err := chart.GetChartDependencies(tgz, name)
+		if err != nil {
+			return false, errors.Annotatef(err, "getting %q chart dependencies", id)
+		}
+		for _, dep := range deps {
+			ch.Dependencies = append(ch.Dependencies, fmt.Sprintf("%s-%s", dep.Name, dep.Version))
+		}
+	}
+	policy := s.chartProvenancePolicy(!s.streamable(ch))
+	if policy == api.ProvenancePolicy_PROVENANCE_REGENERATE {
+		klog.V(4).Infof("Unable to compare %q chart manifest with the target: its provenance file is signed again", id)
+		return true, nil
+	}
+
+	outdir, err := ioutil.TempDir("", "charts-syncer")
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer os.RemoveAll(outdir)
+	workdir, err := ioutil.TempDir("", "charts-syncer")
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	defer os.RemoveAll(workdir)
+	packagedChartPath, streamed, err := s.packageChart(ch, id, workdir, outdir)
+	if err != nil {
+		return false, errors.Annotatef(err, "packaging %q chart", id)
+	}
+
+	// Unmodified charts are copied with their source manifest if possible
+	var want string
+	if streamed {
+		want, err = r.CopyManifestDigest(src, name, version, policy == api.ProvenancePolicy_PROVENANCE_KEEP)
+		if err != nil && !errors.IsNotSupported(err) {
+			return false, errors.Annotatef(err, "getting %q chart source manifest digest", id)
+		}
+	}
+	if want == "" {
+		prov, err := s.provenance(ch, packagedChartPath, id, !streamed)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		metadata := &helmchart.Metadata{Name: s.targetName(name), Version: s.targetVersion(version)}
+		if want, err = r.UploadManifestDigest(packagedChartPath, prov, metadata); err != nil {
+			return false, errors.Annotatef(err, "computing %q chart manifest digest", id)
+		}
+	}
+
+	got, err := r.ManifestDigest(s.targetName(name), s.targetVersion(version))
+	if err != nil {
+		return false, errors.Annotatef(err, "getting %q chart manifest digest in the target", id)
+	}
+	if got == want {
+		return true, nil
+	}
+	klog.Infof("%q chart manifest differs in the target (%s in the target, %s expected), syncing it again", id, got, want)
+	s.recordDigestMismatch(id, got)
+	return false, nil
+}
+
+// dependencies returns the number of dependencies of a source chart, from its
+// index entry if the source has an index, or from the chart package otherwise
+func (s *Syncer) dependencies(name, version string) (int, error) {
+	src, _, err := s.sourceClient(name, version)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if r, ok := src.(client.IndexEntryReader); ok {
+		entry, err := r.GetIndexEntry(name, version)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		return len(entry.Dependencies), nil
+	}
+	tgz, err := src.Fetch(name, version)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	deps, err := chart.GetChartDependencies(tgz, name)
+	return len(deps), errors.Trace(err)
+}
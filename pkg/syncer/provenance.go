@@ -0,0 +1,97 @@
+package syncer
+
+import (
+	"strings"
+
+	"github.com/juju/errors"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+)
+
+// provenance applies the provenance policy to a chart and returns the content
+// of the provenance file to push along with it, if any.
+//
+// Repackaging a chart invalidates the signature of its upstream provenance
+// file, so the upstream file is only pushed if explicitly requested. Charts
+// pushed as they are in the source keep a valid signature.
+func (s *Syncer) provenance(ch *Chart, tgz, id string, repackaged bool) ([]byte, error) {
+	// Intermediate bundles do not support provenance files
+	if !strings.HasSuffix(tgz, ".tgz") {
+		return nil, nil
+	}
+
+	switch s.chartProvenancePolicy(repackaged) {
+	case api.ProvenancePolicy_PROVENANCE_KEEP:
+		src, _, err := s.sourceClient(ch.Name, ch.Version)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		r, ok := src.(client.ProvenanceReader)
+		if !ok {
+			klog.V(3).Infof("Source repository does not support provenance files, skipping %q provenance", id)
+			return nil, nil
+		}
+		prov, err := r.FetchProvenance(ch.Name, ch.Version)
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Annotatef(err, "fetching %q provenance file", id)
+		}
+		if !repackaged {
+			klog.V(3).Infof("Keeping upstream provenance file for %q chart", id)
+			return prov, nil
+		}
+		klog.V(3).Infof("Keeping upstream provenance file for %q chart, its signature is no longer valid", id)
+		if err := chart.Annotate(tgz, map[string]string{chart.ProvenanceAnnotation: chart.ProvenanceInvalidated}); err != nil {
+			return nil, errors.Annotatef(err, "annotating %q chart", id)
+		}
+		return prov, nil
+	case api.ProvenancePolicy_PROVENANCE_REGENERATE:
+		klog.V(3).Infof("Signing %q chart...", id)
+		key := s.signingKey
+		if k := s.target.GetSigningKey(); k != nil {
+			key = k
+		}
+		prov, err := chart.Sign(tgz, key)
+		if err != nil {
+			return nil, errors.Annotatef(err, "signing %q chart", id)
+		}
+		return prov, nil
+	default:
+		klog.V(4).Infof("Stripping provenance file of %q chart", id)
+		return nil, nil
+	}
+}
+
+// chartProvenancePolicy returns the provenance policy applied to a chart.
+// Targets with their own signing key get every chart signed, except the
+// unmodified ones keeping their upstream provenance file.
+func (s *Syncer) chartProvenancePolicy(repackaged bool) api.ProvenancePolicy {
+	if s.target.GetSigningKey() == nil || (!repackaged && s.provenancePolicy == api.ProvenancePolicy_PROVENANCE_KEEP) {
+		return s.provenancePolicy
+	}
+	return api.ProvenancePolicy_PROVENANCE_REGENERATE
+}
+
+// uploadProvenance pushes the provenance file of a chart to the target
+// repository
+func (s *Syncer) uploadProvenance(tgz string, prov []byte, metadata *helmchart.Metadata, id string) error {
+	w, ok := s.cli.dst.(client.ProvenanceWriter)
+	if !ok {
+		if s.strict {
+			return errors.Errorf("target repository does not support provenance files, unable to push %q provenance file", id)
+		}
+		klog.Warningf("Target repository does not support provenance files, skipping %q provenance file", id)
+		return nil
+	}
+	klog.V(3).Infof("Uploading %q provenance file...", id)
+	if err := w.UploadProvenance(tgz, prov, metadata); err != nil {
+		return errors.Annotatef(err, "uploading %q provenance file", id)
+	}
+	return nil
+}
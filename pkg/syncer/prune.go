@@ -0,0 +1,159 @@
+package syncer
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+)
+
+// The reasons of the chart versions pruned from the target
+const (
+	// PruneNotInSource is the reason of the chart versions removed from the
+	// source
+	PruneNotInSource = "not in the source"
+	// PruneFiltered is the reason of the chart versions of the source that no
+	// longer match the chart filters
+	PruneFiltered = "excluded by the filters"
+)
+
+// PruneEntry is a chart version of the target pruned, or kept by the
+// keep-list
+type PruneEntry struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Reason  string `json:"reason"`
+	// Kept is set if the chart version matches the keep-list
+	Kept bool `json:"kept,omitempty"`
+	// Error is the error deleting the chart version, if any
+	Error string `json:"error,omitempty"`
+}
+
+// Prune deletes the chart versions of the target that are no longer in the
+// source, or that no longer match the chart filters, for the given charts or
+// for every chart of the target when the whole source is synced. The chart
+// versions matching a keep pattern are never deleted: the patterns are
+// matched against the chart name, or against "name:version" if they have a
+// colon, with the syntax of path.Match. In dry-run mode, the chart versions
+// are only listed.
+//
+// To protect the target from a source that lost its index, nothing is pruned
+// if any selected chart cannot be listed in the source, or if the source has
+// no chart versions at all. The failed deletions are reported in the entries
+// and returned as a single error. The entries are sorted by name and version.
+func (s *Syncer) Prune(keep []string, names ...string) ([]PruneEntry, error) {
+	for _, p := range keep {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, errors.NotValidf("%q keep pattern", p)
+		}
+	}
+	d, ok := s.cli.dst.(client.ChartsDeleter)
+	if !ok && !s.dryRun {
+		return nil, errors.NotSupportedf("deleting charts from the target repository")
+	}
+
+	s.resetRun()
+	s.discoveredAll = len(names) == 0 && len(s.chartsRegex) == 0
+	charts, err := s.selectCharts(names)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.selectedCharts = charts
+	skip, err := s.chartsSkipper()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.sourceVersions = map[string][]string{}
+	matching := map[string][]string{}
+	total := 0
+	for _, name := range charts {
+		if skip(name) {
+			continue
+		}
+		versions, err := s.cli.src.ListChartVersions(name)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, errors.Annotatef(err, "listing %q chart versions in the source", name)
+		}
+		s.sourceVersions[name] = versions
+		if matching[name], err = s.filterVersions(name, versions); err != nil {
+			return nil, errors.Trace(err)
+		}
+		total += len(versions)
+	}
+	if total == 0 {
+		return nil, errors.New("refusing to prune the target: the source has no chart versions")
+	}
+
+	pruned, err := s.targetVersionsNotIn(s.expectedVersions(matching))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sort.SliceStable(pruned, func(i, j int) bool {
+		if pruned[i].Name != pruned[j].Name {
+			return pruned[i].Name < pruned[j].Name
+		}
+		return utils.CompareVersions(pruned[i].Version, pruned[j].Version) < 0
+	})
+	inSource := s.expectedVersions(s.sourceVersions)
+	entries := make([]PruneEntry, 0, len(pruned))
+	var errs error
+	for _, p := range pruned {
+		if s.context().Err() != nil {
+			return entries, errors.Trace(ErrInterrupted)
+		}
+		e := PruneEntry{Name: p.Name, Version: p.Version, Reason: PruneNotInSource}
+		if inSource[p.Name][p.Version] {
+			e.Reason = PruneFiltered
+		}
+		id := fmt.Sprintf("%s-%s", p.Name, p.Version)
+		switch {
+		case matchKeep(keep, p.Name, p.Version):
+			klog.Infof("Keeping %q chart: it matches the keep-list", id)
+			e.Kept = true
+		case s.dryRun:
+			klog.Infof("dry-run: Pruning %q chart (%s)", id, e.Reason)
+		default:
+			klog.Infof("Pruning %q chart (%s)...", id, e.Reason)
+			if err := s.pruneChart(d, p.Name, p.Version); err != nil {
+				klog.Errorf("unable to prune %q chart: %v", id, err)
+				e.Error = err.Error()
+				errs = multierror.Append(errs, errors.Annotatef(err, "pruning %q chart", id))
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, errors.Trace(errs)
+}
+
+// pruneChart deletes a chart version from the target and its record from the
+// state store, if any
+func (s *Syncer) pruneChart(d client.ChartsDeleter, name, version string) error {
+	if err := d.Delete(name, version); err != nil {
+		return errors.Trace(err)
+	}
+	if s.store != nil {
+		return errors.Annotatef(s.store.Delete(name, version), "deleting state")
+	}
+	return nil
+}
+
+// matchKeep returns whether a chart version matches any keep pattern
+func matchKeep(keep []string, name, version string) bool {
+	for _, p := range keep {
+		subject := name
+		if strings.Contains(p, ":") {
+			subject = name + ":" + version
+		}
+		if ok, _ := path.Match(p, subject); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package syncer
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// The actions taken on the chart versions considered by a sync run
+const (
+	ActionSynced  = "synced"
+	ActionSkipped = "skipped"
+	ActionFailed  = "failed"
+	// ActionPending is the action of the charts not synced because the sync
+	// run was interrupted
+	ActionPending = "pending"
+)
+
+// ChartReport is a chart version considered by a sync run, with the action
+// taken on it
+type ChartReport struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Action  string `json:"action"`
+	// Reason is why the chart was skipped, or the error it failed with
+	Reason string `json:"reason,omitempty"`
+	// SourceDigest is the SHA256 digest of the source package
+	SourceDigest string `json:"sourceDigest,omitempty"`
+	// TargetDigest is the SHA256 digest of the pushed package
+	TargetDigest string `json:"targetDigest,omitempty"`
+	// Size is the size in bytes of the pushed package
+	Size int64 `json:"size,omitempty"`
+	// Duration is the time spent syncing the chart, in seconds
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// LastReport returns every chart version considered by the last sync run,
+// sorted by name and semver precedence: the ones processed, the ones skipped because
+// they were already synced or published before the date threshold, and the
+// ones that could not be loaded or were left pending.
+func (s *Syncer) LastReport() []ChartReport {
+	report := append([]ChartReport(nil), s.lastSkipped...)
+	for _, r := range s.lastResults {
+		cr := ChartReport{
+			Name:         r.Name,
+			Version:      r.Version,
+			Action:       ActionSynced,
+			SourceDigest: r.SourceDigest,
+			TargetDigest: r.TargetDigest,
+			Size:         r.Size,
+			Duration:     r.Duration.Seconds(),
+		}
+		if r.Err != nil {
+			cr.Action, cr.Reason = ActionFailed, r.Err.Error()
+		}
+		report = append(report, cr)
+	}
+	for _, ch := range s.lastPending {
+		report = append(report, ChartReport{Name: ch.Name, Version: ch.Version, Action: ActionPending, Reason: "the sync run was interrupted", SourceDigest: ch.Digest})
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		if report[i].Name != report[j].Name {
+			return report[i].Name < report[j].Name
+		}
+		return utils.CompareVersions(report[i].Version, report[j].Version) < 0
+	})
+	return report
+}
+
+// skipChart records a chart version skipped or failed before being synced
+func (s *Syncer) skipChart(name, version, action, reason, digest string) {
+	s.lastSkipped = append(s.lastSkipped, ChartReport{
+		Name:         name,
+		Version:      version,
+		Action:       action,
+		Reason:       reason,
+		SourceDigest: strings.TrimPrefix(digest, "sha256:"),
+	})
+}
+
+// recordPackage records the digest and size of the package pushed for a chart
+func recordPackage(r *ChartResult, packagedChartPath string) error {
+	info, err := os.Stat(packagedChartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	digest, err := utils.FileSha256(packagedChartPath)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r.TargetDigest, r.Size = digest, info.Size()
+	return nil
+}
@@ -0,0 +1,204 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/mkmik/multierror"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/logging"
+	"github.com/bitnami-labs/charts-syncer/internal/notify"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/telemetry"
+)
+
+// newRun returns the summary of a sync run starting now
+func (s *Syncer) newRun() *state.Run {
+	return &state.Run{
+		ID:         s.runID,
+		StartedAt:  time.Now().UTC(),
+		SourceKind: endpointKind(s.source.GetRepo().GetKind().String(), s.source.GetIntermediateBundlesPath()),
+		TargetKind: endpointKind(s.target.GetRepo().GetKind().String(), s.target.GetIntermediateBundlesPath()),
+	}
+}
+
+// LastRun returns the summary of the last sync run, or nil if SyncPendingCharts
+// was not called yet
+func (s *Syncer) LastRun() *state.Run {
+	return s.lastRun
+}
+
+// ChartResult is the outcome of the sync of a chart version
+type ChartResult struct {
+	Name    string
+	Version string
+	// Err is the reason the chart could not be synced, if it failed
+	Err error
+	// Duration is the time spent syncing the chart
+	Duration time.Duration
+	// SourceDigest and TargetDigest are the SHA256 digests of the source
+	// and pushed packages
+	SourceDigest string
+	TargetDigest string
+	// Size is the size in bytes of the pushed package
+	Size int64
+}
+
+// LastResults returns the outcome of the charts processed by the last sync
+// run, in the order they finished. The charts already in sync are not
+// included.
+func (s *Syncer) LastResults() []ChartResult {
+	return s.lastResults
+}
+
+// logResult logs the outcome of the sync of a chart version, with the
+// structured fields of the JSON logs
+func (s *Syncer) logResult(r ChartResult) {
+	fields := logging.Fields{
+		"chart":      r.Name,
+		"version":    r.Version,
+		"sourceRepo": endpointLocation(s.source.GetRepo(), s.source.GetIntermediateBundlesPath()),
+		"targetRepo": endpointLocation(s.target.GetRepo(), s.target.GetIntermediateBundlesPath()),
+		"duration":   r.Duration,
+		"dryRun":     s.dryRun,
+	}
+	if r.Err != nil {
+		fields["error"] = r.Err
+		fields["errorClass"] = errorClass(r.Err)
+		logging.Error("Chart sync failed", fields)
+		return
+	}
+	logging.Info("Chart synced", fields)
+}
+
+// endpointLocation returns the location of a source or target repository
+func endpointLocation(repo *api.Repo, intermediateBundlesPath string) string {
+	if intermediateBundlesPath != "" {
+		return intermediateBundlesPath
+	}
+	if repo.GetUrl() != "" {
+		return repo.GetUrl()
+	}
+	return repo.GetPath()
+}
+
+// endpointKind returns the kind of a source or target
+func endpointKind(repoKind, intermediateBundlesPath string) string {
+	if intermediateBundlesPath != "" {
+		return "INTERMEDIATE_BUNDLES"
+	}
+	return repoKind
+}
+
+// finishRun records a sync run in the state store and sends its telemetry
+// report. Failures are only warned about, they never fail the sync.
+func (s *Syncer) finishRun(run *state.Run, err error) {
+	run.Duration = time.Since(run.StartedAt)
+	if errors.Cause(err) == ErrInterrupted {
+		run.Interrupted = true
+	} else if err != nil && len(run.ErrorClasses) == 0 {
+		addRunError(run, err)
+	}
+	// Nothing was pushed in dry-run mode
+	if s.dryRun {
+		return
+	}
+
+	if s.store != nil {
+		if err := s.store.PutRun(run); err != nil {
+			klog.Warningf("unable to record the run in the state store: %v", err)
+		}
+	}
+	if s.telemetry.GetEnabled() {
+		klog.V(3).Infof("Sending telemetry report to %q", s.telemetry.GetEndpoint())
+		if err := telemetry.Send(s.telemetry, telemetry.NewReport(run, s.syncerVersion), s.insecure); err != nil {
+			klog.Warningf("unable to send telemetry report: %v", err)
+		}
+	}
+	if len(s.notifications) > 0 {
+		klog.V(3).Infof("Sending %d run notifications", len(s.notifications))
+		if err := notify.Send(s.notifications, s.notification(run, err), s.insecure); err != nil {
+			klog.Warningf("unable to notify the end of the run: %v", err)
+		}
+	}
+}
+
+// notification returns the notification of a finished sync run, listing
+// the errors of its failed charts first
+func (s *Syncer) notification(run *state.Run, err error) *notify.Event {
+	event := &notify.Event{
+		RunID:     run.ID,
+		Status:    notify.StatusSucceeded,
+		Source:    endpointLocation(s.source.GetRepo(), s.source.GetIntermediateBundlesPath()),
+		Target:    endpointLocation(s.target.GetRepo(), s.target.GetIntermediateBundlesPath()),
+		StartedAt: run.StartedAt,
+		Duration:  run.Duration.Seconds(),
+		Charts:    run.Charts,
+		Synced:    run.Synced,
+		Failed:    run.Failed,
+	}
+	switch {
+	case run.Interrupted:
+		event.Status = notify.StatusInterrupted
+	case err != nil:
+		event.Status = notify.StatusFailed
+	}
+
+	charts := map[string]bool{}
+	for _, r := range s.lastResults {
+		if r.Err != nil {
+			event.Errors = append(event.Errors, fmt.Sprintf("%s-%s: %v", r.Name, r.Version, r.Err))
+			charts[r.Err.Error()] = true
+		}
+	}
+	if err != nil && !run.Interrupted {
+		for _, e := range multierror.Split(errors.Cause(err)) {
+			if !charts[e.Error()] {
+				event.Errors = append(event.Errors, e.Error())
+			}
+		}
+	}
+	return event
+}
+
+// addRunError counts an error of a sync run by class
+func addRunError(run *state.Run, err error) {
+	if run.ErrorClasses == nil {
+		run.ErrorClasses = map[string]int{}
+	}
+	run.ErrorClasses[errorClass(err)]++
+}
+
+// errorClass returns the class of an error, which does not include any detail
+// identifying the charts or repositories involved
+func errorClass(err error) string {
+	cause := errors.Cause(err)
+	if netErr, ok := cause.(net.Error); ok && netErr.Timeout() {
+		return "timeout"
+	}
+	switch {
+	case errors.IsNotFound(err):
+		return "not_found"
+	case errors.IsUnauthorized(err):
+		return "unauthorized"
+	case errors.IsForbidden(err):
+		return "forbidden"
+	case errors.IsNotValid(err):
+		return "not_valid"
+	case errors.IsNotSupported(err):
+		return "not_supported"
+	case errors.IsTimeout(err), cause == context.DeadlineExceeded:
+		return "timeout"
+	case cause == context.Canceled:
+		return "canceled"
+	}
+	if _, ok := cause.(net.Error); ok {
+		return "network"
+	}
+	return "other"
+}
@@ -0,0 +1,616 @@
+package syncer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/juju/errors"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+// sbomFile is a file of a chart package described by a software bill of
+// materials
+type sbomFile struct {
+	path   string
+	sha1   string
+	sha256 string
+}
+
+// sbomPackage is a subchart or a container image of a chart
+type sbomPackage struct {
+	name    string
+	version string
+	purl    string
+	// purl of the chart depending on the package
+	parent string
+	image  bool
+}
+
+// sbomContents are the contents of a chart described by its software bill
+// of materials
+type sbomContents struct {
+	name     string
+	version  string
+	digest   string
+	purl     string
+	created  time.Time
+	files    []sbomFile
+	packages []sbomPackage
+}
+
+// buildSBOM returns the software bill of materials of a pushed chart and its
+// media type, if enabled.
+func (s *Syncer) buildSBOM(tgz string, metadata *helmchart.Metadata) ([]byte, string, error) {
+	// Intermediate bundles are not pushed as charts
+	if !s.sbom.GetEnabled() || !strings.HasSuffix(tgz, ".tgz") {
+		return nil, "", nil
+	}
+	c, err := s.sbomContents(tgz, metadata)
+	if err != nil {
+		return nil, "", errors.Trace(err)
+	}
+	if s.sbom.GetFormat() == api.SbomFormat_SBOM_CYCLONEDX {
+		bom, err := s.cycloneDX(c)
+		return bom, types.CycloneDXMediaType, errors.Trace(err)
+	}
+	doc, err := s.spdx(c)
+	return doc, types.SPDXMediaType, errors.Trace(err)
+}
+
+// sbomContents lists the files, subcharts and, if enabled, the container
+// images of a chart package
+func (s *Syncer) sbomContents(tgz string, metadata *helmchart.Metadata) (*sbomContents, error) {
+	digest, err := utils.FileSha256(tgz)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ch, err := loader.LoadFile(tgz)
+	if err != nil {
+		return nil, errors.Annotatef(err, "loading %q", tgz)
+	}
+	files, err := packageFiles(tgz)
+	if err != nil {
+		return nil, errors.Annotatef(err, "reading %q", tgz)
+	}
+	c := &sbomContents{
+		name:    metadata.Name,
+		version: metadata.Version,
+		digest:  digest,
+		purl:    fmt.Sprintf("pkg:helm/%s@%s", metadata.Name, metadata.Version),
+		created: time.Now().UTC(),
+		files:   files,
+	}
+	if repoURL := s.target.GetRepo().GetUrl(); repoURL != "" {
+		c.purl += "?repository_url=" + url.QueryEscape(repoURL)
+	}
+	collectSubcharts(ch, c.purl, &c.packages)
+	if s.sbom.GetIncludeImages() {
+		for _, image := range chart.Images(ch) {
+			p, err := imagePackage(image)
+			if err != nil {
+				klog.Warningf("Skipping %q image of %q chart in its SBOM: %v", image, metadata.Name, err)
+				continue
+			}
+			p.parent = c.purl
+			c.packages = append(c.packages, p)
+		}
+	}
+	return c, nil
+}
+
+// packageFiles returns the regular files of a chart package with their
+// digests, sorted by path
+func packageFiles(tgz string) ([]sbomFile, error) {
+	f, err := os.Open(tgz)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer gz.Close()
+
+	var files []sbomFile
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		h1, h256 := sha1.New(), sha256.New()
+		if _, err := io.Copy(io.MultiWriter(h1, h256), tr); err != nil {
+			return nil, errors.Trace(err)
+		}
+		files = append(files, sbomFile{
+			path:   hdr.Name,
+			sha1:   fmt.Sprintf("%x", h1.Sum(nil)),
+			sha256: fmt.Sprintf("%x", h256.Sum(nil)),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+// collectSubcharts adds the subcharts of a chart, and theirs, to packages
+func collectSubcharts(ch *helmchart.Chart, parent string, packages *[]sbomPackage) {
+	for _, dep := range ch.Dependencies() {
+		p := sbomPackage{
+			name:    dep.Name(),
+			version: dep.Metadata.Version,
+			purl:    fmt.Sprintf("pkg:helm/%s@%s", dep.Name(), dep.Metadata.Version),
+			parent:  parent,
+		}
+		*packages = append(*packages, p)
+		collectSubcharts(dep, p.purl, packages)
+	}
+}
+
+// imagePackage describes a container image with its OCI package URL
+func imagePackage(image string) (sbomPackage, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return sbomPackage{}, errors.Trace(err)
+	}
+	repo := ref.Context()
+	path := strings.Split(repo.RepositoryStr(), "/")
+	p := sbomPackage{name: repo.Name(), version: ref.Identifier(), image: true}
+	purl := "pkg:oci/" + path[len(path)-1]
+	if d, ok := ref.(name.Digest); ok {
+		purl += "@" + url.PathEscape(d.DigestStr())
+	}
+	purl += "?repository_url=" + url.QueryEscape(repo.Name())
+	if t, ok := ref.(name.Tag); ok {
+		purl += "&tag=" + url.QueryEscape(t.TagStr())
+	}
+	p.purl = purl
+	return p, nil
+}
+
+// creator returns the name of the tool creating the software bills of
+// materials
+func (s *Syncer) creator() string {
+	if s.syncerVersion == "" {
+		return "charts-syncer"
+	}
+	return "charts-syncer-" + s.syncerVersion
+}
+
+// spdxDocument is an SPDX 2.3 document
+type spdxDocument struct {
+	SPDXVersion       string `json:"spdxVersion"`
+	DataLicense       string `json:"dataLicense"`
+	SPDXID            string `json:"SPDXID"`
+	Name              string `json:"name"`
+	DocumentNamespace string `json:"documentNamespace"`
+	CreationInfo      struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	} `json:"creationInfo"`
+	Packages      []spdxPackage      `json:"packages"`
+	Files         []spdxFile         `json:"files,omitempty"`
+	Relationships []spdxRelationship `json:"relationships"`
+}
+
+type spdxPackage struct {
+	SPDXID                  string         `json:"SPDXID"`
+	Name                    string         `json:"name"`
+	VersionInfo             string         `json:"versionInfo,omitempty"`
+	DownloadLocation        string         `json:"downloadLocation"`
+	FilesAnalyzed           bool           `json:"filesAnalyzed"`
+	PackageVerificationCode *spdxCode      `json:"packageVerificationCode,omitempty"`
+	Checksums               []spdxChecksum `json:"checksums,omitempty"`
+	PrimaryPackagePurpose   string         `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs            []spdxRef      `json:"externalRefs,omitempty"`
+}
+
+type spdxCode struct {
+	PackageVerificationCodeValue string `json:"packageVerificationCodeValue"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxFile struct {
+	SPDXID    string         `json:"SPDXID"`
+	FileName  string         `json:"fileName"`
+	Checksums []spdxChecksum `json:"checksums"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// spdx returns the SPDX 2.3 JSON document of a chart
+func (s *Syncer) spdx(c *sbomContents) ([]byte, error) {
+	const chartID = "SPDXRef-Package-chart"
+	doc := spdxDocument{
+		SPDXVersion: "SPDX-2.3",
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        fmt.Sprintf("%s-%s", c.name, c.version),
+		// Documents of the same package share their namespace
+		DocumentNamespace: fmt.Sprintf("%s/sbom/%s-%s-%s", defaultBuilderID, c.name, c.version, c.digest),
+	}
+	doc.CreationInfo.Created = c.created.Format(time.RFC3339)
+	doc.CreationInfo.Creators = []string{"Tool: " + s.creator()}
+	doc.Relationships = []spdxRelationship{{doc.SPDXID, "DESCRIBES", chartID}}
+
+	// The verification code is the SHA1 digest of the sorted SHA1 digests of
+	// the files of the package
+	var sums []string
+	for i, f := range c.files {
+		id := fmt.Sprintf("SPDXRef-File-%d", i)
+		doc.Files = append(doc.Files, spdxFile{
+			SPDXID:    id,
+			FileName:  "./" + f.path,
+			Checksums: []spdxChecksum{{"SHA1", f.sha1}, {"SHA256", f.sha256}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{chartID, "CONTAINS", id})
+		sums = append(sums, f.sha1)
+	}
+	sort.Strings(sums)
+	code := sha1.Sum([]byte(strings.Join(sums, "")))
+
+	doc.Packages = []spdxPackage{{
+		SPDXID:                  chartID,
+		Name:                    c.name,
+		VersionInfo:             c.version,
+		DownloadLocation:        "NOASSERTION",
+		FilesAnalyzed:           true,
+		PackageVerificationCode: &spdxCode{fmt.Sprintf("%x", code)},
+		Checksums:               []spdxChecksum{{"SHA256", c.digest}},
+		PrimaryPackagePurpose:   "APPLICATION",
+		ExternalRefs:            []spdxRef{{"PACKAGE-MANAGER", "purl", c.purl}},
+	}}
+	ids := map[string]string{c.purl: chartID}
+	for i, p := range c.packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i)
+		purpose := "APPLICATION"
+		if p.image {
+			purpose = "CONTAINER"
+		}
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:                id,
+			Name:                  p.name,
+			VersionInfo:           p.version,
+			DownloadLocation:      "NOASSERTION",
+			PrimaryPackagePurpose: purpose,
+			ExternalRefs:          []spdxRef{{"PACKAGE-MANAGER", "purl", p.purl}},
+		})
+		ids[p.purl] = id
+		doc.Relationships = append(doc.Relationships, spdxRelationship{ids[p.parent], "DEPENDS_ON", id})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// cycloneDXBOM is a CycloneDX 1.4 BOM
+type cycloneDXBOM struct {
+	BOMFormat    string `json:"bomFormat"`
+	SpecVersion  string `json:"specVersion"`
+	SerialNumber string `json:"serialNumber"`
+	Version      int    `json:"version"`
+	Metadata     struct {
+		Timestamp string             `json:"timestamp"`
+		Tools     []cycloneDXTool    `json:"tools"`
+		Component cycloneDXComponent `json:"component"`
+	} `json:"metadata"`
+	Components   []cycloneDXComponent  `json:"components,omitempty"`
+	Dependencies []cycloneDXDependency `json:"dependencies,omitempty"`
+}
+
+type cycloneDXTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef  string          `json:"bom-ref"`
+	Type    string          `json:"type"`
+	Name    string          `json:"name"`
+	Version string          `json:"version,omitempty"`
+	PURL    string          `json:"purl,omitempty"`
+	Hashes  []cycloneDXHash `json:"hashes,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// cycloneDX returns the CycloneDX 1.4 JSON BOM of a chart
+func (s *Syncer) cycloneDX(c *sbomContents) ([]byte, error) {
+	// The serial number is a UUID derived from the package digest
+	uuid := []byte(c.digest[:32])
+	uuid[12] = '4'
+	uuid[16] = "89ab"[strings.IndexByte("0123456789abcdef", uuid[16])%4]
+	bom := cycloneDXBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.4",
+		SerialNumber: fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s", uuid[:8], uuid[8:12], uuid[12:16], uuid[16:20], uuid[20:]),
+		Version:      1,
+	}
+	bom.Metadata.Timestamp = c.created.Format(time.RFC3339)
+	bom.Metadata.Tools = []cycloneDXTool{{"charts-syncer", s.syncerVersion}}
+	bom.Metadata.Component = cycloneDXComponent{
+		BOMRef:  c.purl,
+		Type:    "application",
+		Name:    c.name,
+		Version: c.version,
+		PURL:    c.purl,
+		Hashes:  []cycloneDXHash{{"SHA-256", c.digest}},
+	}
+
+	for _, f := range c.files {
+		bom.Components = append(bom.Components, cycloneDXComponent{
+			BOMRef: "file:" + f.path,
+			Type:   "file",
+			Name:   f.path,
+			Hashes: []cycloneDXHash{{"SHA-1", f.sha1}, {"SHA-256", f.sha256}},
+		})
+	}
+	// Every component is listed once in the dependency graph
+	dependsOn := map[string][]string{c.purl: {}}
+	refs := []string{c.purl}
+	for _, p := range c.packages {
+		typ := "application"
+		if p.image {
+			typ = "container"
+		}
+		bom.Components = append(bom.Components, cycloneDXComponent{
+			BOMRef:  p.purl,
+			Type:    typ,
+			Name:    p.name,
+			Version: p.version,
+			PURL:    p.purl,
+		})
+		if _, ok := dependsOn[p.purl]; !ok {
+			refs = append(refs, p.purl)
+			dependsOn[p.purl] = []string{}
+		}
+		dependsOn[p.parent] = append(dependsOn[p.parent], p.purl)
+	}
+	for _, ref := range refs {
+		bom.Dependencies = append(bom.Dependencies, cycloneDXDependency{Ref: ref, DependsOn: dependsOn[ref]})
+	}
+	return json.MarshalIndent(bom, "", "  ")
+}
+
+// uploadSBOM pushes the software bill of materials of a chart to the target
+// repository
+func (s *Syncer) uploadSBOM(tgz string, sbom []byte, mediaType string, metadata *helmchart.Metadata, id string) error {
+	w, ok := s.cli.dst.(client.SBOMWriter)
+	if !ok {
+		if s.strict {
+			return errors.Errorf("target repository does not support SBOMs, unable to push %q SBOM", id)
+		}
+		klog.Warningf("Target repository does not support SBOMs, skipping %q SBOM", id)
+		return nil
+	}
+	klog.V(3).Infof("Uploading %q SBOM...", id)
+	if err := w.UploadSBOM(tgz, sbom, mediaType, metadata); err != nil {
+		return errors.Annotatef(err, "uploading %q SBOM", id)
+	}
+	return nil
+}
+
+// defaultDependencyTrackTimeout is the time to wait for Dependency-Track to
+// analyze an SBOM if no timeout is configured
+const defaultDependencyTrackTimeout = 5 * time.Minute
+
+// dependencyTrackPollInterval is the interval the processing of a submitted
+// SBOM is checked at
+var dependencyTrackPollInterval = 2 * time.Second
+
+// severities ranks the severities of the Dependency-Track findings
+var severities = map[string]int{"INFO": 1, "LOW": 2, "MEDIUM": 3, "HIGH": 4, "CRITICAL": 5}
+
+// dependencyTrackBOM is the body of the SBOM uploads to Dependency-Track
+type dependencyTrackBOM struct {
+	ProjectName    string `json:"projectName"`
+	ProjectVersion string `json:"projectVersion"`
+	AutoCreate     bool   `json:"autoCreate"`
+	// BOM is the base64-encoded CycloneDX BOM
+	BOM string `json:"bom"`
+}
+
+// dependencyTrackFinding is a vulnerability of a component of a project found
+// by Dependency-Track
+type dependencyTrackFinding struct {
+	Component struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		PURL    string `json:"purl"`
+	} `json:"component"`
+	Vulnerability struct {
+		VulnID   string `json:"vulnId"`
+		Severity string `json:"severity"`
+	} `json:"vulnerability"`
+}
+
+// scanSBOM submits the SBOM of a chart to Dependency-Track, if configured, and
+// returns an error if any finding reaches the severity threshold.
+// Dependency-Track only reads CycloneDX BOMs, so the contents of SPDX
+// documents are submitted as CycloneDX.
+func (s *Syncer) scanSBOM(tgz string, sbom []byte, mediaType string, metadata *helmchart.Metadata, id string) error {
+	dt := s.sbom.GetDependencyTrack()
+	if dt == nil || sbom == nil {
+		return nil
+	}
+	if mediaType != types.CycloneDXMediaType {
+		c, err := s.sbomContents(tgz, metadata)
+		if err != nil {
+			return errors.Annotatef(err, "generating %q SBOM", id)
+		}
+		if sbom, err = s.cycloneDX(c); err != nil {
+			return errors.Annotatef(err, "generating %q SBOM", id)
+		}
+	}
+
+	klog.V(3).Infof("Submitting %q SBOM to Dependency-Track...", id)
+	findings, err := s.dependencyTrackFindings(dt, sbom, metadata)
+	if err != nil {
+		if dt.GetFailOpen() {
+			klog.Warningf("unable to scan %q SBOM with Dependency-Track, pushing the chart anyway: %v", id, err)
+			return nil
+		}
+		return errors.Annotatef(err, "scanning %q SBOM with Dependency-Track", id)
+	}
+	threshold := dt.GetSeverityThreshold()
+	var denied []string
+	for _, f := range findings {
+		component := f.Component.PURL
+		if component == "" {
+			component = fmt.Sprintf("%s@%s", f.Component.Name, f.Component.Version)
+		}
+		finding := fmt.Sprintf("%s (%s) in %s", f.Vulnerability.VulnID, f.Vulnerability.Severity, component)
+		if threshold != "" && severities[f.Vulnerability.Severity] >= severities[threshold] {
+			denied = append(denied, finding)
+		}
+		klog.V(3).Infof("Dependency-Track finding of %q chart: %s", id, finding)
+	}
+	if len(denied) > 0 {
+		return errors.Errorf("%q chart has %d findings of %s severity or higher in Dependency-Track: %s", id, len(denied), threshold, strings.Join(denied, ", "))
+	}
+	if len(findings) > 0 {
+		klog.Warningf("Dependency-Track found %d vulnerabilities in %q chart", len(findings), id)
+	}
+	return nil
+}
+
+// dependencyTrackFindings uploads a CycloneDX BOM to the Dependency-Track
+// project of a chart version, creating it if needed, and returns the findings
+// of the project once the BOM is analyzed
+func (s *Syncer) dependencyTrackFindings(dt *api.DependencyTrack, bom []byte, metadata *helmchart.Metadata) ([]dependencyTrackFinding, error) {
+	timeout := defaultDependencyTrackTimeout
+	if t := dt.GetTimeout(); t != "" {
+		var err error
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return nil, errors.Annotatef(err, "parsing Dependency-Track timeout")
+		}
+	}
+	// Charts being processed are allowed to finish when the sync is
+	// interrupted, so the requests do not use the syncer context
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	client := utils.DefaultClient
+	if s.insecure {
+		client = utils.InsecureClient
+	}
+	call := func(method, path string, in, out interface{}) error {
+		var body io.Reader
+		if in != nil {
+			data, err := json.Marshal(in)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(dt.GetUrl(), "/")+path, body)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Api-Key", dt.GetApiKey())
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return errors.Errorf("Dependency-Track answered %s %s with HTTP status %s: %s", method, path, resp.Status, utils.HTTPResponseBody(resp))
+		}
+		return errors.Annotatef(json.NewDecoder(resp.Body).Decode(out), "parsing Dependency-Track answer to %s %s", method, path)
+	}
+
+	var upload struct {
+		Token string `json:"token"`
+	}
+	b := dependencyTrackBOM{
+		ProjectName:    metadata.Name,
+		ProjectVersion: metadata.Version,
+		AutoCreate:     true,
+		BOM:            base64.StdEncoding.EncodeToString(bom),
+	}
+	if err := call(http.MethodPut, "/api/v1/bom", b, &upload); err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The token is processed until the components of the BOM are analyzed
+	for {
+		var status struct {
+			Processing bool `json:"processing"`
+		}
+		if err := call(http.MethodGet, "/api/v1/bom/token/"+url.PathEscape(upload.Token), nil, &status); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !status.Processing {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Errorf("timed out after %s waiting for the SBOM to be analyzed", timeout)
+		case <-time.After(dependencyTrackPollInterval):
+		}
+	}
+
+	var project struct {
+		UUID string `json:"uuid"`
+	}
+	q := url.Values{"name": {metadata.Name}, "version": {metadata.Version}}
+	if err := call(http.MethodGet, "/api/v1/project/lookup?"+q.Encode(), nil, &project); err != nil {
+		return nil, errors.Trace(err)
+	}
+	var findings []dependencyTrackFinding
+	if err := call(http.MethodGet, "/api/v1/finding/project/"+url.PathEscape(project.UUID), nil, &findings); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return findings, nil
+}
@@ -0,0 +1,27 @@
+package syncer
+
+import (
+	"github.com/juju/errors"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+)
+
+// uploadSignature signs the manifest of a pushed chart with cosign and
+// attaches the signature to it
+func (s *Syncer) uploadSignature(metadata *helmchart.Metadata, id string) error {
+	w, ok := s.cli.dst.(client.SignatureWriter)
+	if !ok {
+		if s.strict {
+			return errors.Errorf("target repository does not support cosign signatures, unable to sign %q chart", id)
+		}
+		klog.Warningf("Target repository does not support cosign signatures, skipping %q signature", id)
+		return nil
+	}
+	klog.V(3).Infof("Signing %q chart with cosign...", id)
+	if err := w.UploadSignature(metadata, s.cosign); err != nil {
+		return errors.Annotatef(err, "uploading %q signature", id)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package syncer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/juju/errors"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
+)
+
+// sha256Digest matches hex encoded SHA256 digests. Some clients report
+// placeholder digests, which are not comparable.
+var sha256Digest = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// sources reads the charts of the source repository and of its additional
+// repositories. A chart version provided by several of them is read from the
+// one chosen by the conflict strategy when their packages differ, or from the
+// first one otherwise.
+type sources struct {
+	repos    []*api.Repo
+	readers  []client.ChartsReader
+	strategy api.ConflictStrategy
+
+	mu sync.Mutex
+	// providers are the indexes of the repositories providing the chart
+	// versions already resolved
+	providers map[string]int
+}
+
+// newSources returns a client reading the charts of several repositories, in
+// the order of their readers
+func newSources(repos []*api.Repo, readers []client.ChartsReader, strategy api.ConflictStrategy) *sources {
+	return &sources{repos: repos, readers: readers, strategy: strategy, providers: map[string]int{}}
+}
+
+// provider returns the client and the repository providing a chart version
+func (s *sources) provider(name string, version string) (client.ChartsReader, *api.Repo, error) {
+	id := fmt.Sprintf("%s-%s", name, version)
+	s.mu.Lock()
+	i, ok := s.providers[id]
+	s.mu.Unlock()
+	if !ok {
+		var err error
+		if i, err = s.resolve(name, version, id); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		s.mu.Lock()
+		s.providers[id] = i
+		s.mu.Unlock()
+	}
+	return s.readers[i], s.repos[i], nil
+}
+
+// resolve returns the index of the repository providing a chart version
+func (s *sources) resolve(name string, version string, id string) (int, error) {
+	var candidates []int
+	for i, r := range s.readers {
+		ok, err := r.Has(name, version)
+		if err != nil {
+			return 0, errors.Annotatef(err, "looking up %q chart in %q", id, s.repos[i].GetUrl())
+		}
+		if ok {
+			candidates = append(candidates, i)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return 0, errors.NotFoundf("%q chart in the source repositories", id)
+	case 1:
+		return candidates[0], nil
+	}
+
+	digests := make([]string, len(candidates))
+	conflict := false
+	for j, i := range candidates {
+		digest, err := s.digest(i, name, version)
+		if err != nil {
+			return 0, errors.Annotatef(err, "getting %q chart digest in %q", id, s.repos[i].GetUrl())
+		}
+		digests[j] = digest
+		conflict = conflict || digest != digests[0]
+	}
+	if !conflict {
+		return candidates[0], nil
+	}
+
+	var providers []string
+	for j, i := range candidates {
+		providers = append(providers, fmt.Sprintf("%s in %q", digests[j], s.repos[i].GetUrl()))
+	}
+	switch s.strategy {
+	case api.ConflictStrategy_CONFLICT_FIRST_WINS:
+		klog.Warningf("%q chart is provided with different digests (%s). Keeping the first one...", id, strings.Join(providers, ", "))
+		return candidates[0], nil
+	case api.ConflictStrategy_CONFLICT_PREFER_PRIORITY:
+		chosen := candidates[0]
+		for _, i := range candidates[1:] {
+			if s.repos[i].GetPriority() < s.repos[chosen].GetPriority() {
+				chosen = i
+			}
+		}
+		klog.Warningf("%q chart is provided with different digests (%s). Keeping the one of %q, with the lowest priority value...", id, strings.Join(providers, ", "), s.repos[chosen].GetUrl())
+		return chosen, nil
+	case api.ConflictStrategy_CONFLICT_FAIL:
+		return 0, errors.Errorf("%q chart is provided with different digests (%s)", id, strings.Join(providers, ", "))
+	default:
+		return 0, errors.Errorf("unsupported conflict strategy %q", s.strategy)
+	}
+}
+
+// digest returns the SHA256 digest of a chart package in a repository, from
+// its index if it has a valid one or from the package otherwise
+func (s *sources) digest(i int, name string, version string) (string, error) {
+	details, err := s.readers[i].GetChartDetails(name, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if d := strings.ToLower(strings.TrimPrefix(details.Digest, "sha256:")); sha256Digest.MatchString(d) {
+		return d, nil
+	}
+	tgz, err := s.readers[i].Fetch(name, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return utils.FileSha256(tgz)
+}
+
+// Fetch fetches a chart from the repository providing it
+func (s *sources) Fetch(name string, version string) (string, error) {
+	r, _, err := s.provider(name, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return r.Fetch(name, version)
+}
+
+// List lists the charts of all the repositories
+func (s *sources) List() ([]string, error) {
+	var names []string
+	seen := map[string]bool{}
+	for i, r := range s.readers {
+		charts, err := r.List()
+		if err != nil {
+			return nil, errors.Annotatef(err, "listing the charts of %q", s.repos[i].GetUrl())
+		}
+		for _, name := range charts {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// ListChartVersions lists the versions of a chart in all the repositories
+func (s *sources) ListChartVersions(name string) ([]string, error) {
+	versions := []string{}
+	seen := map[string]bool{}
+	for i, r := range s.readers {
+		vs, err := r.ListChartVersions(name)
+		if err != nil {
+			return nil, errors.Annotatef(err, "listing the versions of %q chart in %q", name, s.repos[i].GetUrl())
+		}
+		for _, v := range vs {
+			if !seen[v] {
+				seen[v] = true
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// Has checks if any of the repositories has a specific chart
+func (s *sources) Has(name string, version string) (bool, error) {
+	for _, r := range s.readers {
+		if ok, err := r.Has(name, version); err != nil || ok {
+			return ok, errors.Trace(err)
+		}
+	}
+	return false, nil
+}
+
+// GetChartDetails returns the details of a chart in the repository providing
+// it
+func (s *sources) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	r, _, err := s.provider(name, version)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return r.GetChartDetails(name, version)
+}
+
+// Reload reloads the indexes of all the repositories. The chart versions are
+// resolved again.
+func (s *sources) Reload() error {
+	for i, r := range s.readers {
+		if err := r.Reload(); err != nil {
+			return errors.Annotatef(err, "reloading %q", s.repos[i].GetUrl())
+		}
+	}
+	s.mu.Lock()
+	s.providers = map[string]int{}
+	s.mu.Unlock()
+	return nil
+}
+
+// InvalidIndexEntries returns the index entries the repositories based on an
+// index were unable to load
+func (s *sources) InvalidIndexEntries() []string {
+	var entries []string
+	for _, r := range s.readers {
+		if ir, ok := r.(client.IndexReporter); ok {
+			entries = append(entries, ir.InvalidIndexEntries()...)
+		}
+	}
+	return entries
+}
+
+// sourceClient returns the client of the source repository providing a chart
+// version, and the repository
+func (s *Syncer) sourceClient(name string, version string) (client.ChartsReader, *api.Repo, error) {
+	if src, ok := s.cli.src.(*sources); ok {
+		return src.provider(name, version)
+	}
+	return s.cli.src, s.source.GetRepo(), nil
+}
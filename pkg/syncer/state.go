@@ -0,0 +1,108 @@
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bitnami-labs/pbjson"
+	"github.com/juju/errors"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+)
+
+// synced returns whether a chart version was already synced to the target.
+//
+// Charts recorded in the state store are not looked up in the target. Their
+// upstream digest, if known, is compared with the recorded one to detect the
+// charts modified upstream since they were synced. With digest comparison,
+// those charts, and the unrecorded ones whose digest differs from the target
+// package, are synced again. The charts in OCI targets are synced again if
+// their manifest differs from the one pushing them would produce instead.
+func (s *Syncer) synced(name, version, digest string) (bool, error) {
+	targetName, targetVersion := s.targetName(name), s.targetVersion(version)
+	id := fmt.Sprintf("%s-%s", name, version)
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if s.store != nil {
+		r, err := s.store.Get(targetName, targetVersion)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, errors.Annotatef(err, "reading state")
+		}
+		if err == nil {
+			if !sha256Digest.MatchString(digest) || r.SourceDigest == "" || digest == r.SourceDigest {
+				return true, nil
+			}
+			if s.compareDigest {
+				klog.Infof("%q chart changed upstream since it was synced (%s, %s), syncing it again", id, r.SourceDigest, digest)
+				s.recordDigestMismatch(id, r.TargetDigest)
+				return false, nil
+			}
+			if s.strict {
+				return false, errors.Errorf("%q chart changed upstream since it was synced (%s, %s)", id, r.SourceDigest, digest)
+			}
+			klog.Warningf("%q chart changed upstream since it was synced (%s, %s)", id, r.SourceDigest, digest)
+			return true, nil
+		}
+	}
+
+	ok, err := s.cli.dst.Has(targetName, targetVersion)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if !s.compareDigest {
+		return true, nil
+	}
+	if r, ok := s.cli.dst.(client.ManifestDigestReader); ok {
+		return s.sameTargetManifest(r, name, version, id)
+	}
+	if !sha256Digest.MatchString(digest) {
+		return true, nil
+	}
+	return s.sameTargetDigest(name, version, digest, id)
+}
+
+// recordSync records a pushed chart in the state store, if any
+func (s *Syncer) recordSync(ch *Chart, pushed string, metadata *helmchart.Metadata, id string) error {
+	if s.store == nil {
+		return nil
+	}
+	digest, err := utils.FileSha256(pushed)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	transformations, err := s.appliedTransformations(ch.Name)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	klog.V(4).Infof("Recording %q chart in the state store", id)
+	return errors.Trace(s.store.Put(&state.Record{
+		Name:            metadata.Name,
+		Version:         metadata.Version,
+		SourceName:      ch.Name,
+		SourceVersion:   ch.Version,
+		SourceDigest:    ch.Digest,
+		TargetDigest:    digest,
+		Transformations: transformations,
+		SyncedAt:        time.Now().UTC(),
+	}))
+}
+
+// appliedTransformations returns the JSON representation of the
+// transformations applied to a chart
+func (s *Syncer) appliedTransformations(name string) ([]json.RawMessage, error) {
+	var transformations []json.RawMessage
+	for _, t := range chart.SelectTransformations(s.transformations, name) {
+		data, err := pbjson.Marshal(t)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		transformations = append(transformations, data)
+	}
+	return transformations, nil
+}
@@ -4,35 +4,66 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/bitnami-labs/charts-syncer/api"
 
 	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
 	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
 	"github.com/juju/errors"
 	"github.com/mkmik/multierror"
 	"github.com/vmware-tanzu/asset-relocation-tool-for-kubernetes/pkg/mover"
-	"gopkg.in/yaml.v2"
-	helm "helm.sh/helm/v3/pkg/action"
 	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
 	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
 )
 
 // SyncPendingCharts syncs the charts not found in the target
 //
 // It uses topological sort to sync dependencies first.
 func (s *Syncer) SyncPendingCharts(names ...string) error {
+	run := s.newRun()
+	s.resetRun()
+	err := s.syncPendingCharts(run, names...)
+	s.finishRun(run, err)
+	s.lastRun = run
+	return err
+}
+
+// resetRun forgets the outcome of the previous run
+func (s *Syncer) resetRun() {
+	s.lastResults, s.lastSkipped, s.lastPending, s.lastDiff = nil, nil, nil, nil
+	s.selectedCharts, s.discoveredAll, s.sourceVersions, s.mismatches = nil, false, nil, nil
+}
+
+// syncPendingCharts syncs the charts not found in the target, summarizing
+// the sync in run
+func (s *Syncer) syncPendingCharts(run *state.Run, names ...string) error {
 	var errs error
+	defer s.recordThrottling(run, throttleStats())
 
 	// There might be problems loading all the charts due to missing dependencies,
 	// invalid/wrong charts in the repository, etc. Therefore, let's warn about
 	// them instead of blocking the whole sync.
+	if s.strict {
+		if r, ok := s.cli.src.(client.IndexReporter); ok && len(r.InvalidIndexEntries()) > 0 {
+			return errors.Errorf("strict mode: the source index contains invalid entries: %s", strings.Join(r.InvalidIndexEntries(), ", "))
+		}
+	}
 	err := s.loadCharts(names...)
 	if err != nil {
+		// In strict mode, an incomplete index must not be synced
+		if s.strict {
+			return errors.Annotatef(err, "strict mode: unable to load all the requested charts")
+		}
 		klog.Warningf("There were some problems loading the information of the requested charts: %v", err)
 		errs = multierror.Append(errs, errors.Trace(err))
+		addRunError(run, err)
 	}
 	// NOTE: We are not checking `errs` in purpose. See the comment above.
 
@@ -40,84 +71,478 @@ func (s *Syncer) SyncPendingCharts(names ...string) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if s.indexOnly {
+		if err := s.writeIndex(charts); err != nil {
+			return errors.Trace(err)
+		}
+		return errors.Trace(errs)
+	}
+
+	// The index may be incomplete if the sync was interrupted while loading
+	// the charts
+	if s.context().Err() != nil {
+		return errors.Trace(s.interrupt(nil, nil, chartIDs(charts), errs))
+	}
+	if s.dryRun {
+		s.lastDiff = s.diff(charts)
+	}
 
+	run.Charts = len(charts)
 	if len(charts) > 1 {
 		klog.Infof("There are %d charts out of sync!", len(charts))
 	} else if len(charts) == 1 {
 		klog.Infof("There is %d chart out of sync!", len(charts))
 	} else {
 		klog.Info("There are no charts out of sync!")
-		return nil
+		// Charts that could not be loaded, e.g. failing verification, are
+		// not out of sync but were not synced either
+		return errors.Trace(errs)
 	}
 
-	for _, ch := range charts {
-		id := fmt.Sprintf("%s-%s", ch.Name, ch.Version)
-		klog.Infof("Syncing %q chart...", id)
+	synced, failed, pending, errs := s.syncCharts(run, charts, errs)
+	if len(pending) > 0 {
+		return errors.Trace(s.interrupt(synced, failed, pending, errs))
+	}
+	return errors.Trace(errs)
+}
 
-		klog.V(3).Infof("Processing %q chart...", id)
-		outdir, err := ioutil.TempDir("", "charts-syncer")
-		if err != nil {
-			klog.Errorf("unable to create output directory for %q chart: %+v", id, err)
-			errs = multierror.Append(errs, errors.Trace(err))
-			continue
+// syncCharts syncs the topologically sorted charts with the configured number
+// of workers, summarizing the sync in run. A chart is only picked up once the
+// dependencies synced along with it are done. It returns the charts that were
+// not picked up because the sync was interrupted as pending, and the sync
+// errors appended to errs.
+func (s *Syncer) syncCharts(run *state.Run, charts []*Chart, errs error) (synced, failed, pending []string, _ error) {
+	type result struct {
+		ch  *Chart
+		id  string
+		res ChartResult
+	}
+	queued := map[string]bool{}
+	for _, id := range chartIDs(charts) {
+		queued[id] = true
+	}
+	done := map[string]bool{}
+	ready := func(ch *Chart) bool {
+		for _, dep := range ch.Dependencies {
+			if queued[dep] && !done[dep] {
+				return false
+			}
 		}
-		defer os.RemoveAll(outdir)
+		return true
+	}
 
-		hasDeps := len(ch.Dependencies) > 0
+	workers := s.workers
+	if workers < 1 {
+		workers = 1
+	}
+	limit := &concurrencyLimit{max: workers, current: workers, throttled: throttleStats().Throttled}
+	results := make(chan result)
+	running := 0
+	for {
+		// Stop picking up new charts if the sync has been interrupted. Charts
+		// already being processed are allowed to finish.
+		for running < limit.current && s.context().Err() == nil {
+			i := 0
+			for i < len(charts) && !ready(charts[i]) {
+				i++
+			}
+			if i == len(charts) {
+				break
+			}
+			ch := charts[i]
+			charts = append(charts[:i:i], charts[i+1:]...)
+			running++
+			go func() {
+				startedOn := time.Now()
+				r := ChartResult{Name: ch.Name, Version: ch.Version, SourceDigest: ch.Digest}
+				r.Err = s.syncChart(ch, &r)
+				r.Duration = time.Since(startedOn)
+				results <- result{ch: ch, id: fmt.Sprintf("%s-%s", ch.Name, ch.Version), res: r}
+			}()
+		}
+		if running == 0 {
+			return synced, failed, chartIDs(charts), errs
+		}
 
-		workdir, err := ioutil.TempDir("", "charts-syncer")
-		if err != nil {
-			klog.Errorf("unable to create work directory for %q chart: %+v", id, err)
-			errs = multierror.Append(errs, errors.Trace(err))
+		r := <-results
+		running--
+		done[r.id] = true
+		limit.update(throttleStats().Throttled)
+		s.lastResults = append(s.lastResults, r.res)
+		s.logResult(r.res)
+		if r.res.Err != nil {
+			errs = multierror.Append(errs, r.res.Err)
+			failed = append(failed, r.id)
+			run.Failed++
+			addRunError(run, r.res.Err)
 			continue
 		}
-		defer os.RemoveAll(workdir)
+		synced = append(synced, r.id)
+		run.Synced++
+	}
+}
 
-		// Some client Upload() methods needs this info
-		metadata := &helmchart.Metadata{
-			Name:    ch.Name,
-			Version: ch.Version,
-		}
-		var packagedChartPath string
+// throttleStats returns the throttling statistics of the HTTP clients. Tests
+// replace it to simulate throttling.
+var throttleStats = utils.GetThrottleStats
+
+// concurrencyLimit is the number of charts synced concurrently. It is halved
+// whenever the remote servers throttle requests, and increased again by one
+// for every chart synced without throttling, up to the number of workers.
+type concurrencyLimit struct {
+	max, current int
+	// throttled is the number of throttled requests at the last update
+	throttled int
+}
 
-		// If any of the source or target objects contains an intermediate bundles path it means we are running a partial
-		// sync. Either from a repo to an intermediate dir, or from an intermediate dir to a repo.
-		intermediateScenario := s.source.GetIntermediateBundlesPath() != "" || s.target.GetIntermediateBundlesPath() != ""
-		if s.relocateContainerImages || intermediateScenario {
-			packagedChartPath, err = s.SyncWithRelok8s(ch, outdir)
-			if err != nil {
-				errs = multierror.Append(errs, errors.Annotatef(err, "unable to move chart %q with relok8s", id))
-				continue
-			}
-		} else {
-			packagedChartPath, err = s.SyncWithChartsSyncer(ch, id, workdir, outdir, hasDeps)
-			if err != nil {
-				errs = multierror.Append(errs, errors.Annotatef(err, "unable to move chart %q with charts-syncer", id))
-				continue
-			}
+// update adjusts the limit once a chart is synced, given the number of
+// requests throttled so far
+func (l *concurrencyLimit) update(throttled int) {
+	switch {
+	case throttled > l.throttled:
+		if l.current > 1 {
+			l.current /= 2
+			klog.Warningf("Remote servers are throttling requests, syncing up to %d charts concurrently", l.current)
 		}
+	case l.current < l.max:
+		l.current++
+		klog.V(3).Infof("Remote servers stopped throttling requests, syncing up to %d charts concurrently", l.current)
+	}
+	l.throttled = throttled
+}
 
-		if s.dryRun {
-			klog.Infof("dry-run: Uploading %q chart", id)
-			continue
+// recordThrottling records in run the requests throttled by the remote
+// servers since the given statistics, warning about them
+func (s *Syncer) recordThrottling(run *state.Run, before utils.ThrottleStats) {
+	stats := throttleStats()
+	run.Throttled, run.ThrottleWait = stats.Throttled-before.Throttled, stats.Waited-before.Waited
+	if run.Throttled > 0 {
+		klog.Warningf("Remote servers throttled %d requests. Time spent waiting: %s", run.Throttled, run.ThrottleWait.Round(time.Second))
+	}
+}
+
+// syncChart processes a chart and uploads it to the target repo, recording
+// the pushed package in r
+func (s *Syncer) syncChart(ch *Chart, r *ChartResult) error {
+	id := fmt.Sprintf("%s-%s", ch.Name, ch.Version)
+	klog.Infof("Syncing %q chart...", id)
+	startedOn := time.Now()
+
+	klog.V(3).Infof("Processing %q chart...", id)
+	outdir, err := ioutil.TempDir("", "charts-syncer")
+	if err != nil {
+		klog.Errorf("unable to create output directory for %q chart: %+v", id, err)
+		return errors.Trace(err)
+	}
+	defer os.RemoveAll(outdir)
+
+	workdir, err := ioutil.TempDir("", "charts-syncer")
+	if err != nil {
+		klog.Errorf("unable to create work directory for %q chart: %+v", id, err)
+		return errors.Trace(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	// Some client Upload() methods needs this info
+	metadata := &helmchart.Metadata{
+		Name:    s.targetName(ch.Name),
+		Version: s.targetVersion(ch.Version),
+	}
+	packagedChartPath, streamed, err := s.packageChart(ch, id, workdir, outdir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// Intermediate bundles are plain tarballs and are validated by relok8s
+	if strings.HasSuffix(packagedChartPath, ".tgz") {
+		if err := utils.ValidateTarball(packagedChartPath); err != nil {
+			return errors.Annotatef(err, "refusing to upload %q chart", id)
+		}
+		if err := s.lintChart(packagedChartPath, id); err != nil {
+			return errors.Trace(err)
+		}
+		if err := s.verifyChart(ch, packagedChartPath, metadata, id); err != nil {
+			return errors.Trace(err)
 		}
+		if err := recordPackage(r, packagedChartPath); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	prov, err := s.provenance(ch, packagedChartPath, id, !streamed)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	att, err := s.buildAttestation(ch, packagedChartPath, metadata, startedOn)
+	if err != nil {
+		return errors.Annotatef(err, "generating %q attestation", id)
+	}
+	sbom, sbomMediaType, err := s.buildSBOM(packagedChartPath, metadata)
+	if err != nil {
+		return errors.Annotatef(err, "generating %q SBOM", id)
+	}
+
+	if s.dryRun {
+		klog.Infof("dry-run: Uploading %q chart", id)
+		return nil
+	}
+	if err := s.scanSBOM(packagedChartPath, sbom, sbomMediaType, metadata, id); err != nil {
+		return errors.Trace(err)
+	}
 
+	if s.forceOverwrite {
+		if err := s.overwrite(metadata, id); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	copied := false
+	if streamed {
+		if copied, err = s.copyChart(ch, id); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if copied {
+		// The provenance file, if kept, is copied along with the chart
+	} else if w, ok := s.cli.dst.(client.SignedChartsWriter); ok && prov != nil {
+		// Targets that can store the chart and its provenance file at once
+		// never hold the chart without it
+		klog.V(3).Infof("Uploading %q chart with its provenance file...", id)
+		if err := w.UploadWithProvenance(packagedChartPath, prov, metadata); err != nil {
+			klog.Errorf("unable to upload %q chart: %+v", id, err)
+			return errors.Trace(err)
+		}
+	} else {
 		klog.V(3).Infof("Uploading %q chart...", id)
 		if err := s.cli.dst.Upload(packagedChartPath, metadata); err != nil {
 			klog.Errorf("unable to upload %q chart: %+v", id, err)
-			errs = multierror.Append(errs, errors.Trace(err))
-			continue
+			return errors.Trace(err)
+		}
+		if prov != nil {
+			if err := s.uploadProvenance(packagedChartPath, prov, metadata, id); err != nil {
+				return s.rollbackUpload(metadata, id, err)
+			}
+		}
+	}
+	if s.verifyUploads {
+		if err := s.verifyUpload(packagedChartPath, metadata, id); err != nil {
+			return s.rollbackUpload(metadata, id, err)
+		}
+	}
+	if att != nil {
+		if err := s.uploadAttestation(packagedChartPath, att, metadata, id); err != nil {
+			return s.rollbackUpload(metadata, id, err)
+		}
+	}
+	if sbom != nil {
+		if err := s.uploadSBOM(packagedChartPath, sbom, sbomMediaType, metadata, id); err != nil {
+			return s.rollbackUpload(metadata, id, err)
+		}
+	}
+	if s.cosign != nil {
+		if err := s.uploadSignature(metadata, id); err != nil {
+			return s.rollbackUpload(metadata, id, err)
 		}
 	}
+	// The chart is published at this point, it is explored in the target
+	// again if it could not be recorded
+	if err := s.recordSync(ch, packagedChartPath, metadata, id); err != nil {
+		if s.strict {
+			return errors.Annotatef(err, "recording %q chart", id)
+		}
+		klog.Warningf("unable to record %q chart in the state store: %v", id, err)
+	}
+	return nil
+}
 
-	return errors.Trace(errs)
+// packageChart writes the package of a chart pushed to the target to outdir,
+// repackaging it in workdir if it has to be rewritten. It returns the path of
+// the package and whether it is the source package, pushed as is.
+func (s *Syncer) packageChart(ch *Chart, id, workdir, outdir string) (string, bool, error) {
+	// If any of the source or target objects contains an intermediate bundles path it means we are running a partial
+	// sync. Either from a repo to an intermediate dir, or from an intermediate dir to a repo.
+	intermediateScenario := s.source.GetIntermediateBundlesPath() != "" || s.target.GetIntermediateBundlesPath() != ""
+	if !intermediateScenario && s.streamable(ch) {
+		// The source package is pushed as is, so it keeps its digest and
+		// the signature of its provenance file
+		klog.V(3).Infof("%q chart does not need to be rewritten, pushing the source package", id)
+		packagedChartPath := filepath.Join(outdir, fmt.Sprintf("%s-%s.tgz", ch.Name, ch.Version))
+		if err := utils.CopyFile(packagedChartPath, ch.TgzPath); err != nil {
+			return "", false, errors.Trace(err)
+		}
+		return packagedChartPath, true, nil
+	}
+	if s.relocateContainerImages || intermediateScenario {
+		packagedChartPath, err := s.SyncWithRelok8s(ch, outdir)
+		if err != nil {
+			return "", false, errors.Annotatef(err, "unable to move chart %q with relok8s", id)
+		}
+		// Intermediate bundles are transformed once they are pushed to the
+		// target repository
+		if strings.HasSuffix(packagedChartPath, ".tgz") && (len(s.transformations) > 0 || s.versionSuffix != "" || s.rename != nil || len(s.ignore) > 0) {
+			if err := chart.Rewrite(packagedChartPath, func(chartPath string) error {
+				return s.transform(ch, chartPath, id)
+			}, s.ignore...); err != nil {
+				return "", false, errors.Trace(err)
+			}
+		}
+		return packagedChartPath, false, nil
+	}
+	packagedChartPath, err := s.SyncWithChartsSyncer(ch, id, workdir, outdir, len(ch.Dependencies) > 0)
+	if err != nil {
+		return "", false, errors.Annotatef(err, "unable to move chart %q with charts-syncer", id)
+	}
+	return packagedChartPath, false, nil
+}
+
+// streamable returns whether a chart is pushed to the target as it is in the
+// source: it has no dependencies to update, no container images to relocate
+// and no transformation, renaming, version suffix or ignore rules to apply.
+func (s *Syncer) streamable(ch *Chart) bool {
+	return len(ch.Dependencies) == 0 && !s.rewritten(ch.Name, ch.Version)
+}
+
+// rewritten returns whether the configuration rewrites a chart, i.e. it has
+// container images to relocate or transformations, renaming, version suffix
+// or ignore rules to apply, regardless of its dependencies.
+func (s *Syncer) rewritten(name, version string) bool {
+	return s.relocateContainerImages ||
+		s.target.GetContainerRegistry() != "" || s.target.GetContainerRepository() != "" ||
+		len(chart.SelectTransformations(s.transformations, name)) > 0 ||
+		s.targetName(name) != name || s.targetVersion(version) != version ||
+		len(s.ignore) > 0
+}
+
+// copyChart copies a streamed chart verbatim to the target if both
+// repositories support it, e.g. OCI registries, so it keeps its manifest
+// digest. It returns false if the chart has to be uploaded instead.
+func (s *Syncer) copyChart(ch *Chart, id string) (bool, error) {
+	c, ok := s.cli.dst.(client.ChartsCopier)
+	// A regenerated provenance file is not part of the source manifest
+	policy := s.chartProvenancePolicy(false)
+	if !ok || policy == api.ProvenancePolicy_PROVENANCE_REGENERATE {
+		return false, nil
+	}
+	src, _, err := s.sourceClient(ch.Name, ch.Version)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	klog.V(3).Infof("Copying %q chart...", id)
+	err = c.Copy(src, ch.Name, ch.Version, policy == api.ProvenancePolicy_PROVENANCE_KEEP)
+	if errors.IsNotSupported(err) {
+		klog.V(3).Infof("Unable to copy %q chart as is, uploading it: %v", id, err)
+		return false, nil
+	}
+	if err != nil {
+		klog.Errorf("unable to copy %q chart: %+v", id, err)
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+// rollbackUpload deletes a pushed chart from the target if rollback is
+// enabled, so the target does not hold half-published charts. It returns the
+// error that caused the rollback.
+func (s *Syncer) rollbackUpload(metadata *helmchart.Metadata, id string, cause error) error {
+	if !s.rollback {
+		return errors.Trace(cause)
+	}
+	d, ok := s.cli.dst.(client.ChartsDeleter)
+	if !ok {
+		klog.Warningf("Target repository does not support deleting charts, %q chart is only partially published", id)
+		return errors.Trace(cause)
+	}
+	klog.Warningf("Rolling back %q chart: %v", id, cause)
+	if err := d.Delete(metadata.Name, metadata.Version); err != nil {
+		klog.Errorf("unable to roll back %q chart, it is only partially published: %+v", id, err)
+		return errors.Annotatef(cause, "rolling back %q chart failed: %v", id, err)
+	}
+	return errors.Annotatef(cause, "%q chart was rolled back", id)
+}
+
+// overwrite deletes a chart already in the target before it is pushed again,
+// for the repositories refusing to overwrite a chart version.
+func (s *Syncer) overwrite(metadata *helmchart.Metadata, id string) error {
+	ok, err := s.cli.dst.Has(metadata.Name, metadata.Version)
+	if err != nil || !ok {
+		return errors.Trace(err)
+	}
+	d, ok := s.cli.dst.(client.ChartsDeleter)
+	if !ok {
+		if s.strict {
+			return errors.Errorf("target repository does not support deleting charts, unable to overwrite %q chart", id)
+		}
+		klog.Warningf("Target repository does not support deleting charts, pushing %q chart over the existing one", id)
+		return nil
+	}
+	klog.V(3).Infof("Deleting %q chart from the target before pushing it again...", id)
+	return errors.Annotatef(d.Delete(metadata.Name, metadata.Version), "deleting %q chart from the target", id)
+}
+
+// transform applies the configured transformations, name and version suffix
+// to an uncompressed chart
+func (s *Syncer) transform(ch *Chart, chartPath, id string) error {
+	// The name and version are set first so the transformations see the
+	// target ones
+	if name := s.targetName(ch.Name); name != ch.Name {
+		klog.V(3).Infof("Renaming %q chart to %q", id, name)
+		if err := chart.SetName(chartPath, name); err != nil {
+			return errors.Annotatef(err, "renaming %q chart", id)
+		}
+	}
+	if version := s.targetVersion(ch.Version); version != ch.Version {
+		klog.V(3).Infof("Setting %q chart version to %q", id, version)
+		if err := chart.SetVersion(chartPath, version); err != nil {
+			return errors.Annotatef(err, "setting %q chart version", id)
+		}
+	}
+	if len(s.transformations) > 0 {
+		klog.V(3).Infof("Transforming %q chart...", id)
+		_, sourceRepo, err := s.sourceClient(ch.Name, ch.Version)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		opts := []chart.TransformOption{
+			chart.WithTransformSourceRepo(sourceRepo.GetUrl()),
+			chart.WithTransformTargetRepo(s.target.GetRepo().GetUrl()),
+			chart.WithTransformDryRun(s.dryRun),
+		}
+		if err := chart.Transform(chartPath, ch.Name, s.transformations, opts...); err != nil {
+			return errors.Annotatef(err, "transforming %q chart", id)
+		}
+	}
+	return nil
+}
+
+// lintChart validates a repackaged chart according to the lint policy
+func (s *Syncer) lintChart(tgz, id string) error {
+	if s.lintPolicy == api.LintPolicy_LINT_SKIP {
+		return nil
+	}
+	klog.V(3).Infof("Linting %q chart...", id)
+	err := chart.Lint(tgz)
+	if err == nil {
+		return nil
+	}
+	if s.lintPolicy == api.LintPolicy_LINT_FAIL || s.strict {
+		return errors.Annotatef(err, "%q chart does not pass the Helm linter", id)
+	}
+	klog.Warningf("%q chart does not pass the Helm linter: %v", id, err)
+	return nil
 }
 
 // SyncWithRelok8s will take a local packaged chart, a container registry and a container repository and will rewrite the chart
-// updating the images in values.yaml. The local chart must include an image hints file so relok8s library knows how to
-// update the images
+// updating the images in values.yaml. The image hints file included in the local chart tells relok8s library how to
+// update the images. Charts without one are relocated with the images found in their values.
 func (s *Syncer) SyncWithRelok8s(chart *Chart, outdir string) (string, error) {
 	req, packagedChartPath := getRelok8sMoveRequest(s.source, s.target, chart, outdir)
+	// Intermediate bundles already include their image hints
+	if req.Source.Chart.Local != nil {
+		hintsFile, err := imageHintsFile(chart, outdir)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		req.Source.ImageHintsFile = hintsFile
+	}
 	chartMover, err := mover.NewChartMover(req)
 	if err != nil {
 		klog.Errorf("unable to create chart mover: %+v", err)
@@ -132,46 +557,80 @@ func (s *Syncer) SyncWithRelok8s(chart *Chart, outdir string) (string, error) {
 	return packagedChartPath, nil
 }
 
+// imageHintsFile returns the image hints file relok8s relocates a chart with.
+// It is empty for charts including a hints file, otherwise it is written to
+// outdir with the images found in the values of the chart, restricted to the
+// images listed in its annotations if any.
+func imageHintsFile(ch *Chart, outdir string) (string, error) {
+	c, err := loader.Load(ch.TgzPath)
+	if err != nil {
+		return "", errors.Annotatef(err, "loading %q chart", ch.TgzPath)
+	}
+	for _, f := range c.Files {
+		if f.Name == mover.EmbeddedHintsFilename {
+			return "", nil
+		}
+	}
+	hints, unmatched, err := chart.ImageHints(c)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	for _, image := range unmatched {
+		klog.Warningf("%q image annotated by %s-%s chart is not referenced by its values, it is not relocated", image, ch.Name, ch.Version)
+	}
+	if len(hints) == 0 {
+		klog.Warningf("%s-%s chart has no %s file and no images were found in its values", ch.Name, ch.Version, mover.EmbeddedHintsFilename)
+	} else {
+		klog.V(3).Infof("%s-%s chart has no %s file, relocating the images found in its values: %v", ch.Name, ch.Version, mover.EmbeddedHintsFilename, hints)
+	}
+	data, err := yaml.Marshal(hints)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	hintsFile := filepath.Join(outdir, fmt.Sprintf("%s-%s.relok8s-images.yaml", ch.Name, ch.Version))
+	if err := ioutil.WriteFile(hintsFile, data, 0644); err != nil {
+		return "", errors.Annotatef(err, "writing %q image hints file", hintsFile)
+	}
+	return hintsFile, nil
+}
+
 func (s *Syncer) SyncWithChartsSyncer(ch *Chart, id, workdir, outdir string, hasDeps bool) (string, error) {
 	if err := utils.Untar(ch.TgzPath, workdir); err != nil {
 		klog.Errorf("unable to uncompress %q chart: %+v", id, err)
 		return "", errors.Trace(errors.Annotatef(err, "uncompressing %q chart", id))
 	}
 
-	chartPath := path.Join(workdir, ch.Name)
+	chartPath, err := chart.FindChartPath(workdir, ch.Name)
+	if err != nil {
+		klog.Errorf("unable to find %q chart root: %+v", id, err)
+		return "", errors.Trace(err)
+	}
 	if err := chart.ChangeReferences(chartPath, ch.Name, ch.Version, s.source, s.target); err != nil {
 		klog.Errorf("unable to process %q chart: %+v", id, err)
 		return "", errors.Trace(err)
 
 	}
+	if err := s.transform(ch, chartPath, id); err != nil {
+		klog.Errorf("unable to transform %q chart: %+v", id, err)
+		return "", errors.Trace(err)
+	}
 
 	// Update deps
 	if hasDeps {
 		klog.V(3).Infof("Building %q dependencies", id)
-		if err := chart.BuildDependencies(chartPath, s.cli.dst, s.source.GetRepo(), s.target.GetRepo()); err != nil {
+		_, sourceRepo, err := s.sourceClient(ch.Name, ch.Version)
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if err := chart.BuildDependencies(chartPath, s.cli.dst, sourceRepo, s.target.GetRepo(), s.versionSuffix, s.rename); err != nil {
 			klog.Errorf("unable to build %q chart dependencies: %+v", id, err)
 			return "", errors.Trace(err)
 		}
 	}
 
-	// Read final chart metadata
-	configFilePath := fmt.Sprintf("%s/Chart.yaml", chartPath)
-	chartConfig, err := ioutil.ReadFile(configFilePath)
-	if err != nil {
-		klog.Errorf("unable to read %q metadata: %+v", id, err)
-		return "", errors.Trace(err)
-	}
-	metadata := &helmchart.Metadata{}
-	if err = yaml.Unmarshal(chartConfig, metadata); err != nil {
-		klog.Errorf("unable to decode %q metadata: %+v", id, err)
-		return "", errors.Trace(err)
-	}
-
 	// Package chart again
 	klog.V(3).Infof("Packaging %q", id)
-	pkgCli := helm.NewPackage()
-	pkgCli.Destination = outdir
-	packagedChartPath, err := pkgCli.Run(chartPath, nil)
+	packagedChartPath, err := chart.Package(chartPath, outdir, s.ignore...)
 	if err != nil {
 		klog.Errorf("unable to package %q chart: %+v", id, err)
 		return "", errors.Trace(err)
@@ -1,12 +1,36 @@
 package syncer
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+	"github.com/bitnami-labs/charts-syncer/pkg/client"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/local"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 
+	"github.com/juju/errors"
 	"github.com/vmware-tanzu/asset-relocation-tool-for-kubernetes/pkg/mover"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
 )
 
 func TestGetRelok8sMoveRequest(t *testing.T) {
@@ -195,6 +219,24 @@ func TestRelok8sMoveReq(t *testing.T) {
 	}
 }
 
+func TestImageHintsFile(t *testing.T) {
+	outdir := t.TempDir()
+	hintsFile, err := imageHintsFile(&Chart{Name: "apache", Version: "7.3.15", TgzPath: "../../testdata/apache-7.3.15.tgz"}, outdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(outdir, "apache-7.3.15.relok8s-images.yaml"); hintsFile != want {
+		t.Errorf("got: %q hints file, want: %q", hintsFile, want)
+	}
+	data, err := ioutil.ReadFile(hintsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- '{{ .image.registry }}/{{ .image.repository }}:{{ .image.tag }}'\n"; !strings.Contains(string(data), want) {
+		t.Errorf("got: %q hints, want them to include %q", data, want)
+	}
+}
+
 func TestRelok8sBundleSaveReq(t *testing.T) {
 	want := &mover.ChartMoveRequest{
 		Source: mover.Source{
@@ -247,3 +289,1782 @@ func TestRelok8sBundleLoadReq(t *testing.T) {
 		t.Errorf("unexpected relok8s bundle load request. got: %v, want: %v", got, want)
 	}
 }
+
+func TestSyncPendingChartsStrict(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		strict bool
+		want   []string
+	}{
+		{
+			desc: "sync the charts that could be indexed",
+			want: []string{"apache-7.3.15.tgz"},
+		},
+		{
+			desc:   "do not sync an incomplete index in strict mode",
+			strict: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+			if err != nil {
+				t.Fatalf("error creating temporary folder: %v", err)
+			}
+			defer os.RemoveAll(dstTmp)
+
+			// The broken chart makes the index incomplete
+			srcTmp, err := ioutil.TempDir("", "charts-syncer-tests-src-fake")
+			if err != nil {
+				t.Fatalf("error creating temporary folder: %v", err)
+			}
+			defer os.RemoveAll(srcTmp)
+			if err := utils.CopyFile(filepath.Join(srcTmp, "apache-7.3.15.tgz"), "../../testdata/apache-7.3.15.tgz"); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(srcTmp, "broken-1.0.0.tgz"), []byte("broken"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			if s.cli.src, err = local.New(srcTmp); err != nil {
+				t.Fatal(err)
+			}
+			s.strict = tc.strict
+
+			if err := s.SyncPendingCharts("apache", "broken"); err == nil {
+				t.Errorf("expected an error for the broken chart")
+			}
+
+			gotFiles, err := filepath.Glob(filepath.Join(dstTmp, "*.tgz"))
+			if err != nil {
+				t.Fatalf("error listing tgz files: %v", err)
+			}
+			var got []string
+			for _, file := range gotFiles {
+				got = append(got, filepath.Base(file))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSyncPendingChartsProvenance(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		policy        api.ProvenancePolicy
+		ignore        []string
+		targetKey     bool
+		wantProv      bool
+		wantSigned    bool
+		wantAnnotated bool
+		wantStreamed  bool
+	}{
+		{
+			desc:         "strip provenance files by default",
+			policy:       api.ProvenancePolicy_PROVENANCE_STRIP,
+			wantStreamed: true,
+		},
+		{
+			desc:          "keep upstream provenance files of repackaged charts",
+			policy:        api.ProvenancePolicy_PROVENANCE_KEEP,
+			ignore:        []string{"*.orig"},
+			wantProv:      true,
+			wantAnnotated: true,
+		},
+		{
+			desc:         "keep upstream provenance files of unmodified charts",
+			policy:       api.ProvenancePolicy_PROVENANCE_KEEP,
+			wantProv:     true,
+			wantStreamed: true,
+		},
+		{
+			desc:         "regenerate provenance files",
+			policy:       api.ProvenancePolicy_PROVENANCE_REGENERATE,
+			wantProv:     true,
+			wantSigned:   true,
+			wantStreamed: true,
+		},
+		{
+			desc:       "sign repackaged charts with the target key",
+			policy:     api.ProvenancePolicy_PROVENANCE_KEEP,
+			ignore:     []string{"*.orig"},
+			targetKey:  true,
+			wantProv:   true,
+			wantSigned: true,
+		},
+		{
+			desc:         "keep upstream provenance files of unmodified charts with a target key",
+			policy:       api.ProvenancePolicy_PROVENANCE_KEEP,
+			targetKey:    true,
+			wantProv:     true,
+			wantStreamed: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+			if err != nil {
+				t.Fatalf("error creating temporary folder: %v", err)
+			}
+			defer os.RemoveAll(dstTmp)
+
+			srcTmp, err := ioutil.TempDir("", "charts-syncer-tests-src-fake")
+			if err != nil {
+				t.Fatalf("error creating temporary folder: %v", err)
+			}
+			defer os.RemoveAll(srcTmp)
+			if err := utils.CopyFile(filepath.Join(srcTmp, "apache-7.3.15.tgz"), "../../testdata/apache-7.3.15.tgz"); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(srcTmp, "apache-7.3.15.tgz.prov"), []byte("upstream"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			if s.cli.src, err = local.New(srcTmp); err != nil {
+				t.Fatal(err)
+			}
+			s.provenancePolicy = tc.policy
+			s.ignore = tc.ignore
+			s.signingKey = &api.SigningKey{Keyring: "../../testdata/signing/helm-test-key.secret", Name: "helm-testing@helm.sh"}
+			if tc.targetKey {
+				s.target.SigningKey, s.signingKey = s.signingKey, nil
+			}
+
+			if err := s.SyncPendingCharts("apache"); err != nil {
+				t.Fatal(err)
+			}
+
+			tgz := filepath.Join(dstTmp, "apache-7.3.15.tgz")
+			ok, err := utils.FileExists(tgz + ".prov")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tc.wantProv {
+				t.Errorf("got provenance file: %t, want: %t", ok, tc.wantProv)
+			}
+			if ok {
+				prov, err := ioutil.ReadFile(tgz + ".prov")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if signed := string(prov) != "upstream"; signed != tc.wantSigned {
+					t.Errorf("got signed chart: %t, want: %t", signed, tc.wantSigned)
+				}
+			}
+			c, err := loader.LoadFile(tgz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := c.Metadata.Annotations[chart.ProvenanceAnnotation]; ok != tc.wantAnnotated {
+				t.Errorf("got annotation: %t, want: %t", ok, tc.wantAnnotated)
+			}
+			// Unmodified charts are pushed as is
+			want, err := utils.FileSha256("../../testdata/apache-7.3.15.tgz")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := utils.FileSha256(tgz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if streamed := got == want; streamed != tc.wantStreamed {
+				t.Errorf("got streamed chart: %t, want: %t", streamed, tc.wantStreamed)
+			}
+		})
+	}
+}
+
+func TestSyncPendingChartsRollback(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		rollback bool
+		want     []string
+	}{
+		{
+			desc: "keep the chart when its provenance file cannot be pushed",
+			want: []string{"apache-7.3.15.tgz"},
+		},
+		{
+			desc:     "roll back the chart when its provenance file cannot be pushed",
+			rollback: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+			if err != nil {
+				t.Fatalf("error creating temporary folder: %v", err)
+			}
+			defer os.RemoveAll(dstTmp)
+			// A directory in place of the provenance file makes its upload fail
+			if err := os.Mkdir(filepath.Join(dstTmp, "apache-7.3.15.tgz.prov"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			s.provenancePolicy = api.ProvenancePolicy_PROVENANCE_REGENERATE
+			s.signingKey = &api.SigningKey{Keyring: "../../testdata/signing/helm-test-key.secret", Name: "helm-testing@helm.sh"}
+			s.rollback = tc.rollback
+
+			if err := s.SyncPendingCharts("apache"); err == nil {
+				t.Errorf("expected an error pushing the provenance file")
+			}
+
+			gotFiles, err := filepath.Glob(filepath.Join(dstTmp, "*.tgz"))
+			if err != nil {
+				t.Fatalf("error listing tgz files: %v", err)
+			}
+			var got []string
+			for _, file := range gotFiles {
+				got = append(got, filepath.Base(file))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got: %v, want: %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// fakeManifestSigner signs no manifest
+type fakeManifestSigner struct{}
+
+func (fakeManifestSigner) SignManifest(_, _ string) (*types.Signature, error) {
+	return nil, errors.New("unexpected signature")
+}
+
+func TestSyncPendingChartsCosign(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		strict    bool
+		shouldErr bool
+	}{
+		{desc: "skip signatures of targets not supporting them"},
+		{desc: "fail on targets not supporting signatures in strict mode", strict: true, shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			s := NewFake(t)
+			s.cosign = fakeManifestSigner{}
+			s.strict = tc.strict
+
+			err := s.SyncPendingCharts("apache")
+			if tc.shouldErr != (err != nil) {
+				t.Errorf("got: %v, want error: %t", err, tc.shouldErr)
+			}
+		})
+	}
+}
+
+// fakeStatementSigner signs no manifest but any statement
+type fakeStatementSigner struct {
+	fakeManifestSigner
+}
+
+func (fakeStatementSigner) SignStatement(statement []byte) (*types.Signature, error) {
+	return &types.Signature{Payload: statement}, nil
+}
+
+// signedAttestationRepo is a local repository recording the signed
+// attestations pushed to it
+type signedAttestationRepo struct {
+	*local.Repo
+	signed []string
+}
+
+func (r *signedAttestationRepo) UploadSignedAttestation(attestation []byte, metadata *helmchart.Metadata, signer types.StatementSigner) error {
+	if _, err := signer.SignStatement(attestation); err != nil {
+		return err
+	}
+	r.signed = append(r.signed, metadata.Name+"-"+metadata.Version)
+	return nil
+}
+
+func (r *signedAttestationRepo) UploadSignature(_ *helmchart.Metadata, _ types.ManifestSigner) error {
+	return nil
+}
+
+func TestSyncPendingChartsSignedAttestation(t *testing.T) {
+	dstTmp := t.TempDir()
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	dst := &signedAttestationRepo{Repo: s.cli.dst.(*local.Repo)}
+	s.cli.dst = dst
+	s.attestation = &api.Attestation{Enabled: true}
+	s.versionSuffix = "-mirror.1"
+	s.cosign = fakeStatementSigner{}
+
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"apache-7.3.15-mirror.1"}; !reflect.DeepEqual(dst.signed, want) {
+		t.Errorf("got: %v signed attestations, want: %v", dst.signed, want)
+	}
+	// The unsigned attestation is not pushed
+	if _, err := os.Stat(filepath.Join(dstTmp, "apache-7.3.15-mirror.1.tgz.intoto.json")); !os.IsNotExist(err) {
+		t.Errorf("got: %v, want no unsigned attestation", err)
+	}
+}
+
+func TestSyncPendingChartsTransformations(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.transformations = []*api.Transformation{
+		{
+			Values: []*api.ValuesPatch{
+				{Path: ".image.pullSecrets[0]", Op: &api.ValuesPatch_Set{Set: "internal-pull-secret"}},
+			},
+			Annotations: map[string]string{"example.com/owner": "platform", "category": "Other"},
+		},
+		{
+			Charts: []string{"apache"},
+			Values: []*api.ValuesPatch{
+				{Path: ".image.registry", Op: &api.ValuesPatch_Set{Set: "registry.example.com"}},
+				{Path: ".ingress", Op: &api.ValuesPatch_Delete{Delete: true}},
+			},
+			Annotations: map[string]string{"category": "Infrastructure"},
+		},
+		{
+			Charts: []string{"zookeeper"},
+			Values: []*api.ValuesPatch{
+				{Path: ".image.tag", Op: &api.ValuesPatch_Set{Set: "latest"}},
+			},
+		},
+	}
+
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loader.LoadFile(filepath.Join(dstTmp, "apache-7.3.15.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	image, _ := c.Values["image"].(map[string]interface{})
+	if got, want := image["registry"], "registry.example.com"; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := image["pullSecrets"], []interface{}{"internal-pull-secret"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	// The zookeeper transformation does not select the chart
+	if got, want := image["tag"], "2.4.43-debian-10-r25"; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if _, ok := c.Values["ingress"]; ok {
+		t.Errorf("ingress values should have been deleted")
+	}
+	// Later transformations override the annotations of the previous ones
+	want := map[string]string{"example.com/owner": "platform", "category": "Infrastructure"}
+	if got := c.Metadata.Annotations; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestSyncPendingChartsIconDryRun(t *testing.T) {
+	var uploads int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			atomic.AddInt32(&uploads, 1)
+			w.WriteHeader(http.StatusCreated)
+		case "HEAD":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte("icon"))
+		}
+	}))
+	defer srv.Close()
+
+	dstTmp := t.TempDir()
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.transformations = []*api.Transformation{
+		{ChartPatch: []*api.JSONPatchOperation{{Op: "replace", Path: "/icon", Value: srv.URL + "/apache.png"}}},
+		{Icon: &api.IconMirror{BaseUrl: srv.URL + "/icons", Upload: true}},
+	}
+
+	// Icons are only published along with the charts
+	s.dryRun = true
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&uploads); got != 0 {
+		t.Errorf("got: %d icon uploads in dry-run mode, want: 0", got)
+	}
+
+	s.dryRun = false
+	s.index = nil
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&uploads); got != 1 {
+		t.Errorf("got: %d icon uploads, want: 1", got)
+	}
+	c, err := loader.LoadFile(filepath.Join(dstTmp, "apache-7.3.15.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Metadata.Icon, srv.URL+"/icons/apache/apache.png"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestSyncPendingChartsConvertToV2(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Url: "https://charts.bitnami.com/bitnami"}}}
+	s.transformations = []*api.Transformation{{Charts: []string{"kafka"}, ConvertToV2: true}}
+
+	if err := s.SyncPendingCharts("kafka"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loader.LoadFile(filepath.Join(dstTmp, "kafka-10.3.3.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Metadata.APIVersion, chart.APIV2; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	// The dependencies are built from the target once converted
+	if got := c.Metadata.Dependencies; len(got) != 1 || got[0].Name != "zookeeper" || got[0].Repository != s.target.GetRepo().GetUrl() {
+		t.Errorf("got: %+v, want zookeeper from the target repository", got)
+	}
+	if c.Lock == nil {
+		t.Fatalf("got no %s file", chart.ChartLockFilename)
+	}
+	for _, f := range c.Raw {
+		if f.Name == chart.RequirementsFilename || f.Name == chart.RequirementsLockFilename {
+			t.Errorf("got: %s file, want it removed", f.Name)
+		}
+	}
+}
+
+func TestSyncPendingChartsVersionSuffix(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Url: "https://charts.bitnami.com/bitnami"}}}
+	s.versionSuffix = "+mirror.1"
+
+	if err := s.SyncPendingCharts("kafka"); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loader.LoadFile(filepath.Join(dstTmp, "kafka-10.3.3+mirror.1.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Metadata.Version, "10.3.3+mirror.1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	// The dependency is locked to the version pushed to the target
+	if got, want := c.Lock.Dependencies[0].Version, "5.14.3+mirror.1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := len(c.Dependencies()), 1; got != want {
+		t.Fatalf("got: %d dependencies, want: %d", got, want)
+	}
+	if got, want := c.Dependencies()[0].Metadata.Version, "5.14.3+mirror.1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	// Suffixed charts are not synced again
+	s.index = nil
+	if err := s.loadCharts("kafka", "zookeeper"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.getIndex()); got != 0 {
+		t.Errorf("got: %d charts out of sync, want: 0", got)
+	}
+}
+
+func TestSyncPendingChartsRename(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Url: "https://charts.bitnami.com/bitnami"}}}
+	s.rename = &api.Rename{Prefix: "internal-", Charts: map[string]string{"zookeeper": "mirrored-zookeeper"}}
+
+	if err := s.SyncPendingCharts("kafka"); err != nil {
+		t.Fatal(err)
+	}
+
+	tgz := filepath.Join(dstTmp, "internal-kafka-10.3.3.tgz")
+	c, err := loader.LoadFile(tgz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Name(), "internal-kafka"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	// The chart is packaged in a folder named after it
+	chartDir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(chartDir)
+	if err := utils.Untar(tgz, chartDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(chartDir, "internal-kafka", chart.ChartFilename)); err != nil {
+		t.Error(err)
+	}
+
+	// The dependency is renamed and aliased to its upstream name, so the
+	// values of the parent chart still apply to it
+	wantDep := &helmchart.Dependency{Name: "mirrored-zookeeper", Alias: "zookeeper", Version: "5.x.x", Condition: "zookeeper.enabled"}
+	if got := c.Metadata.Dependencies; len(got) != 1 || got[0].Name != wantDep.Name || got[0].Alias != wantDep.Alias || got[0].Version != wantDep.Version || got[0].Condition != wantDep.Condition {
+		t.Errorf("got: %+v, want: %+v", got, wantDep)
+	}
+	if got, want := c.Lock.Dependencies[0].Name, "mirrored-zookeeper"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if err := chartutil.ProcessDependencies(c, c.Values); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(c.Dependencies()), 1; got != want {
+		t.Fatalf("got: %d dependencies, want: %d", got, want)
+	}
+	if got, want := c.Dependencies()[0].Name(), "zookeeper"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	// Renamed charts are not synced again
+	s.index = nil
+	if err := s.loadCharts("kafka", "zookeeper"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.getIndex()); got != 0 {
+		t.Errorf("got: %d charts out of sync, want: 0", got)
+	}
+}
+
+func TestSyncPendingChartsAttestation(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Url: "https://charts.bitnami.com/bitnami"}}}
+	s.attestation = &api.Attestation{Enabled: true}
+	s.syncerVersion = "v1.0.0"
+	s.runID = "run-1"
+	s.versionSuffix = "-mirror.1"
+	s.transformations = []*api.Transformation{
+		{Charts: []string{"apache"}, Annotations: map[string]string{"mirror": "true"}},
+		{Charts: []string{"kafka"}, Strip: []string{"*.md"}},
+	}
+
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+
+	tgz := filepath.Join(dstTmp, "apache-7.3.15-mirror.1.tgz")
+	data, err := ioutil.ReadFile(tgz + ".intoto.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got statement
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := utils.FileSha256(tgz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []artifact{{Name: "apache", Digest: map[string]string{"sha256": digest}}}; !reflect.DeepEqual(got.Subject, want) {
+		t.Errorf("got: %+v, want: %+v", got.Subject, want)
+	}
+	sourceDigest, err := utils.FileSha256("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMaterials := []artifact{{
+		URI:    "pkg:helm/apache@7.3.15?repository_url=https%3A%2F%2Fcharts.bitnami.com%2Fbitnami",
+		Digest: map[string]string{"sha256": sourceDigest},
+	}}
+	if !reflect.DeepEqual(got.Predicate.Materials, wantMaterials) {
+		t.Errorf("got: %+v, want: %+v", got.Predicate.Materials, wantMaterials)
+	}
+	if got, want := got.Predicate.Builder.ID, defaultBuilderID; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	// Only the transformations applied to the chart are recorded
+	params := got.Predicate.Invocation.Parameters
+	if got, want := len(params.Transformations), 1; got != want {
+		t.Fatalf("got: %d transformations, want: %d", got, want)
+	}
+	var transformation bytes.Buffer
+	if err := json.Compact(&transformation, params.Transformations[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := transformation.String(), `{"charts":["apache"],"annotations":{"mirror":"true"}}`; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := params.VersionSuffix, "-mirror.1"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	wantEnv := map[string]string{"syncerVersion": "v1.0.0", "runId": "run-1"}
+	if !reflect.DeepEqual(got.Predicate.Invocation.Environment, wantEnv) {
+		t.Errorf("got: %v, want: %v", got.Predicate.Invocation.Environment, wantEnv)
+	}
+	if got.Predicate.Metadata.BuildFinishedOn.Before(got.Predicate.Metadata.BuildStartedOn) {
+		t.Errorf("got: build finished on %s before it started on %s", got.Predicate.Metadata.BuildFinishedOn, got.Predicate.Metadata.BuildStartedOn)
+	}
+}
+
+// digestedRepo is a local repository providing the digests of its charts
+type digestedRepo struct {
+	*local.Repo
+	digest string
+}
+
+func (r *digestedRepo) GetChartDetails(name string, version string) (*types.ChartDetails, error) {
+	return &types.ChartDetails{PublishedAt: time.Now(), Digest: r.digest}, nil
+}
+
+func TestSyncPendingChartsSourceVerification(t *testing.T) {
+	key := &api.SigningKey{Keyring: "../../testdata/signing/helm-test-key.secret", Name: "helm-testing@helm.sh"}
+	signed, err := chart.Sign("../../testdata/apache-7.3.15.tgz", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := utils.FileSha256("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		desc      string
+		verify    *api.SourceVerification
+		prov      []byte
+		digest    string
+		shouldErr bool
+	}{
+		{
+			desc:   "signed chart",
+			verify: &api.SourceVerification{Keyring: key.GetKeyring()},
+			prov:   signed,
+		},
+		{
+			desc:      "chart without provenance file",
+			verify:    &api.SourceVerification{Keyring: key.GetKeyring()},
+			shouldErr: true,
+		},
+		{
+			desc:      "chart with an invalid provenance file",
+			verify:    &api.SourceVerification{Keyring: key.GetKeyring()},
+			prov:      []byte("upstream"),
+			shouldErr: true,
+		},
+		{
+			desc:   "matching digest",
+			verify: &api.SourceVerification{Digest: true},
+			digest: "sha256:" + digest,
+		},
+		{
+			desc:      "digest mismatch",
+			verify:    &api.SourceVerification{Digest: true},
+			digest:    "sha256:deadbeef",
+			shouldErr: true,
+		},
+		{
+			desc:      "missing digest",
+			verify:    &api.SourceVerification{Digest: true},
+			shouldErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			srcTmp := t.TempDir()
+			if err := utils.CopyFile(filepath.Join(srcTmp, "apache-7.3.15.tgz"), "../../testdata/apache-7.3.15.tgz"); err != nil {
+				t.Fatal(err)
+			}
+			if tc.prov != nil {
+				if err := ioutil.WriteFile(filepath.Join(srcTmp, "apache-7.3.15.tgz.prov"), tc.prov, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dstTmp := t.TempDir()
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			src, err := local.New(srcTmp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.cli.src = &digestedRepo{Repo: src, digest: tc.digest}
+			s.source.Verify = tc.verify
+
+			err = s.SyncPendingCharts("apache")
+			if tc.shouldErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := utils.FileExists(filepath.Join(dstTmp, "apache-7.3.15.tgz"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok == tc.shouldErr {
+				t.Errorf("got chart pushed: %t, want: %t", ok, !tc.shouldErr)
+			}
+		})
+	}
+}
+
+// corruptingRepo is a local repository storing the charts with another digest
+type corruptingRepo struct {
+	*local.Repo
+}
+
+func (r *corruptingRepo) ChartDigest(name string, version string) (string, error) {
+	return "deadbeef", nil
+}
+
+func TestSyncPendingChartsUploadVerification(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		corrupted bool
+		rollback  bool
+		wantChart bool
+	}{
+		{desc: "verified upload", wantChart: true},
+		{desc: "corrupted upload", corrupted: true, wantChart: true},
+		{desc: "corrupted upload rolled back", corrupted: true, rollback: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp := t.TempDir()
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			s.verifyUploads = true
+			s.rollback = tc.rollback
+			if tc.corrupted {
+				s.cli.dst = &corruptingRepo{Repo: s.cli.dst.(*local.Repo)}
+			}
+
+			err := s.SyncPendingCharts("apache")
+			if tc.corrupted {
+				if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+					t.Errorf("got: %v, want a digest mismatch error", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := utils.FileExists(filepath.Join(dstTmp, "apache-7.3.15.tgz"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tc.wantChart {
+				t.Errorf("got chart in the target: %t, want: %t", ok, tc.wantChart)
+			}
+		})
+	}
+}
+
+// immutableRepo is a local repository refusing to overwrite a chart version
+type immutableRepo struct {
+	*local.Repo
+}
+
+func (r *immutableRepo) Upload(file string, metadata *helmchart.Metadata) error {
+	if ok, err := r.Has(metadata.Name, metadata.Version); err != nil || ok {
+		return errors.AlreadyExistsf("%s-%s chart", metadata.Name, metadata.Version)
+	}
+	return r.Repo.Upload(file, metadata)
+}
+
+func TestSyncPendingChartsDigestComparison(t *testing.T) {
+	digest, err := utils.FileSha256("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		desc           string
+		target         string
+		compare        bool
+		forceOverwrite bool
+		shouldErr      bool
+		wantSource     bool
+	}{
+		{desc: "unchanged chart", target: "../../testdata/apache-7.3.15.tgz", compare: true, wantSource: true},
+		{desc: "changed chart without comparison", target: "../../testdata/zookeeper-5.14.3.tgz"},
+		{desc: "changed chart", target: "../../testdata/zookeeper-5.14.3.tgz", compare: true, shouldErr: true},
+		{desc: "changed chart with force overwrite", target: "../../testdata/zookeeper-5.14.3.tgz", compare: true, forceOverwrite: true, wantSource: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp := t.TempDir()
+			pushed := filepath.Join(dstTmp, "apache-7.3.15.tgz")
+			if err := utils.CopyFile(pushed, tc.target); err != nil {
+				t.Fatal(err)
+			}
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			s.cli.src = &digestedRepo{Repo: s.cli.src.(*local.Repo), digest: "sha256:" + digest}
+			s.cli.dst = &immutableRepo{Repo: s.cli.dst.(*local.Repo)}
+			s.compareDigest = tc.compare
+			s.forceOverwrite = tc.forceOverwrite
+
+			err := s.SyncPendingCharts("apache")
+			if tc.shouldErr {
+				if err == nil || !strings.Contains(err.Error(), "already exists") {
+					t.Errorf("got: %v, want an already exists error", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			got, err := utils.FileSha256(pushed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok := got == digest; ok != tc.wantSource {
+				t.Errorf("got target chart with the source digest: %t, want: %t", ok, tc.wantSource)
+			}
+		})
+	}
+}
+
+// manifestRepo is a local repository overwriting the pushed charts and
+// recording their manifest digests, like an OCI registry. The manifest digest
+// of a chart is the digest of its package.
+type manifestRepo struct {
+	*local.Repo
+	manifests map[string]string
+}
+
+func (r *manifestRepo) Upload(file string, metadata *helmchart.Metadata) error {
+	digest, err := r.UploadManifestDigest(file, nil, metadata)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if ok, err := r.Has(metadata.Name, metadata.Version); err != nil {
+		return errors.Trace(err)
+	} else if ok {
+		if err := r.Delete(metadata.Name, metadata.Version); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	r.manifests[metadata.Name+"-"+metadata.Version] = digest
+	return r.Repo.Upload(file, metadata)
+}
+
+func (r *manifestRepo) ManifestDigest(name string, version string) (string, error) {
+	return r.manifests[name+"-"+version], nil
+}
+
+func (r *manifestRepo) UploadManifestDigest(file string, prov []byte, metadata *helmchart.Metadata) (string, error) {
+	digest, err := utils.FileSha256(file)
+	return "sha256:" + digest, errors.Trace(err)
+}
+
+func (r *manifestRepo) CopyManifestDigest(src client.ChartsReader, name string, version string, keepProvenance bool) (string, error) {
+	return "", errors.NotSupportedf("copying charts")
+}
+
+func TestSyncPendingChartsManifestComparison(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		versionSuffix string
+	}{
+		{desc: "unmodified chart"},
+		{desc: "repackaged chart", versionSuffix: "+mirror.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp := t.TempDir()
+			newSyncer := func(dst *manifestRepo) *Syncer {
+				s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+				s.versionSuffix = tc.versionSuffix
+				s.compareDigest = true
+				if dst.Repo == nil {
+					dst.Repo = s.cli.dst.(*local.Repo)
+				}
+				s.cli.dst = dst
+				return s
+			}
+			dst := &manifestRepo{manifests: map[string]string{}}
+			if err := newSyncer(dst).SyncPendingCharts("apache"); err != nil {
+				t.Fatal(err)
+			}
+			id := "apache-" + utils.AppendVersionSuffix("7.3.15", tc.versionSuffix)
+			pushed, ok := dst.manifests[id]
+			if !ok {
+				t.Fatalf("got: %v manifests, want %s pushed", dst.manifests, id)
+			}
+
+			// Charts are repackaged reproducibly, so the chart is not synced
+			// again while the target has the expected manifest
+			s := newSyncer(dst)
+			if err := s.loadCharts("apache"); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(s.getIndex()); got != 0 {
+				t.Errorf("got: %d charts out of sync, want: 0", got)
+			}
+
+			// A tag with another manifest is only synced again with digest
+			// comparison
+			dst.manifests[id] = "sha256:" + strings.Repeat("0", 64)
+			s = newSyncer(dst)
+			s.compareDigest = false
+			if err := s.loadCharts("apache"); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(s.getIndex()); got != 0 {
+				t.Errorf("got: %d charts out of sync without digest comparison, want: 0", got)
+			}
+			s = newSyncer(dst)
+			if err := s.SyncPendingCharts("apache"); err != nil {
+				t.Fatal(err)
+			}
+			if got := dst.manifests[id]; got != pushed {
+				t.Errorf("got: %q manifest in the target, want the chart synced again with %q", got, pushed)
+			}
+			if got := s.LastReport(); len(got) != 1 || got[0].Action != ActionSynced {
+				t.Errorf("got: %+v report, want apache-7.3.15 synced again", got)
+			}
+		})
+	}
+}
+
+func TestSyncPendingChartsManifestComparisonDryRun(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte("icon"))
+	}))
+	defer srv.Close()
+
+	dstTmp := t.TempDir()
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	dst := &manifestRepo{Repo: s.cli.dst.(*local.Repo), manifests: map[string]string{}}
+	s.cli.dst = dst
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	dst.manifests["apache-7.3.15"] = "sha256:" + strings.Repeat("0", 64)
+
+	// Comparing the manifest of a chart does not run the commands of its
+	// transformations nor publish its icon
+	marker := filepath.Join(t.TempDir(), "exec")
+	s = NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.cli.dst = dst
+	s.dryRun = true
+	s.compareDigest = true
+	s.transformations = []*api.Transformation{
+		{ChartPatch: []*api.JSONPatchOperation{{Op: "replace", Path: "/icon", Value: srv.URL + "/apache.png"}}},
+		{
+			Icon: &api.IconMirror{BaseUrl: srv.URL + "/icons", Upload: true},
+			Exec: &api.Exec{Command: []string{"touch", marker}},
+		},
+	}
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 0 {
+		t.Errorf("got: %d requests to the icons server, want: 0", got)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("got: %v, want the exec transformation not run", err)
+	}
+	if got, want := dst.manifests["apache-7.3.15"], "sha256:"+strings.Repeat("0", 64); got != want {
+		t.Errorf("got: %q manifest in the target, want: %q", got, want)
+	}
+}
+
+func TestSyncPendingChartsSBOM(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		sbom   *api.Sbom
+		chart  string
+		suffix string
+		want   []string
+	}{
+		{
+			desc:   "spdx",
+			sbom:   &api.Sbom{Enabled: true},
+			chart:  "apache",
+			suffix: ".spdx.json",
+			want:   []string{`"spdxVersion": "SPDX-2.3"`, `"fileName": "./apache/values.yaml"`, `"referenceLocator": "pkg:helm/apache@7.3.15"`},
+		},
+		{
+			desc:   "cyclonedx with images",
+			sbom:   &api.Sbom{Enabled: true, Format: api.SbomFormat_SBOM_CYCLONEDX, IncludeImages: true},
+			chart:  "apache",
+			suffix: ".cdx.json",
+			want:   []string{`"bomFormat": "CycloneDX"`, `"name": "apache/values.yaml"`, `"type": "container"`, `"purl": "pkg:oci/apache?repository_url=`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp := t.TempDir()
+			s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+			s.sbom = tc.sbom
+			if err := s.SyncPendingCharts(tc.chart); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(dstTmp, "apache-7.3.15.tgz"+tc.suffix))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid(data) {
+				t.Fatalf("got an invalid JSON document: %s", data)
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(string(data), w) {
+					t.Errorf("got: %s, want it to contain %s", data, w)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncPendingChartsState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := state.New(&api.State{Backend: &api.State_File{File: filepath.Join(dir, "state.db")}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	s := NewFake(t, WithFakeSyncerDestination(filepath.Join(dir, "first")))
+	s.store = store
+	s.versionSuffix = "+mirror.1"
+	s.transformations = []*api.Transformation{{Annotations: map[string]string{"mirror": "true"}}}
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := store.Get("apache", "7.3.15+mirror.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceDigest, err := utils.FileSha256("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetDigest, err := utils.FileSha256(filepath.Join(dir, "first", "apache-7.3.15+mirror.1.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.SourceName != "apache" || r.SourceVersion != "7.3.15" || r.SourceDigest != sourceDigest || r.TargetDigest != targetDigest {
+		t.Errorf("got: %+v, want apache-7.3.15 with %s source and %s target digests", r, sourceDigest, targetDigest)
+	}
+	if got, want := len(r.Transformations), 1; got != want {
+		t.Errorf("got: %d transformations, want: %d", got, want)
+	}
+	runs, err := store.ListRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Charts != 1 || runs[0].Synced != 1 || runs[0].Failed != 0 {
+		t.Errorf("got: %+v, want a run with 1 chart synced", runs)
+	}
+	if got := s.LastResults(); len(got) != 1 || got[0].Name != "apache" || got[0].Version != "7.3.15" || got[0].Err != nil {
+		t.Errorf("got: %+v results, want apache-7.3.15 synced", got)
+	}
+	info, err := os.Stat(filepath.Join(dir, "first", "apache-7.3.15+mirror.1.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.LastReport(); len(got) != 1 || got[0].Action != ActionSynced || got[0].SourceDigest != sourceDigest || got[0].TargetDigest != targetDigest || got[0].Size != info.Size() {
+		t.Errorf("got: %+v report, want apache-7.3.15 synced with %s source and %s target digests", got, sourceDigest, targetDigest)
+	}
+
+	// Recorded charts are not looked up in the target again
+	s = NewFake(t, WithFakeSyncerDestination(filepath.Join(dir, "second")))
+	s.store = store
+	s.versionSuffix = "+mirror.1"
+	if err := s.loadCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s.getIndex()); got != 0 {
+		t.Errorf("got: %d charts out of sync, want: 0", got)
+	}
+	if got := s.LastReport(); len(got) != 1 || got[0].Action != ActionSkipped || got[0].Reason != "already synced" {
+		t.Errorf("got: %+v report, want apache-7.3.15 skipped", got)
+	}
+
+	// Charts modified upstream since they were synced are detected
+	s.strict = true
+	if _, err := s.synced("apache", "7.3.15", "sha256:"+strings.Repeat("0", 64)); err == nil {
+		t.Errorf("expected an error for a chart modified upstream")
+	}
+	if _, err := s.synced("apache", "7.3.15", "sha256:"+sourceDigest); err != nil {
+		t.Error(err)
+	}
+	// and synced again with digest comparison
+	s.compareDigest = true
+	if ok, err := s.synced("apache", "7.3.15", "sha256:"+strings.Repeat("0", 64)); err != nil || ok {
+		t.Errorf("got: %t, %v, want the chart modified upstream to be synced again", ok, err)
+	}
+}
+
+func TestSyncPendingChartsTelemetry(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	var reports []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Error(err)
+		}
+		reports = append(reports, report)
+	}))
+	defer srv.Close()
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/secret"}}}
+	s.syncerVersion = "v1.0.0"
+	s.telemetry = &api.Telemetry{Endpoint: srv.URL}
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 0 {
+		t.Fatalf("got: %d reports, want none if telemetry is not enabled", len(reports))
+	}
+
+	s = NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.source = &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: "https://charts.example.com/secret"}}}
+	s.syncerVersion = "v1.0.0"
+	s.telemetry = &api.Telemetry{Enabled: true, Endpoint: srv.URL}
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("got: %d reports, want: 1", len(reports))
+	}
+	data, err := json.Marshal(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reports do not identify the repositories or charts
+	for _, s := range []string{"example.com", "apache"} {
+		if strings.Contains(string(data), s) {
+			t.Errorf("report %s contains %q", data, s)
+		}
+	}
+	if got, want := reports[0]["sourceKind"], "HELM"; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if got, want := reports[0]["version"], "v1.0.0"; got != want {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestSyncPendingChartsNotifications(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	var events []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		events = append(events, event)
+	}))
+	defer srv.Close()
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.runID = "run-1"
+	s.notifications = []*api.Notification{{Url: srv.URL}, {Url: srv.URL, OnlyFailures: true}}
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got: %d notifications, want: 1", len(events))
+	}
+	for k, want := range map[string]interface{}{"runId": "run-1", "status": "succeeded", "charts": float64(1), "synced": float64(1), "failed": float64(0)} {
+		if got := events[0][k]; got != want {
+			t.Errorf("got: %v %s, want: %v", got, k, want)
+		}
+	}
+}
+
+func TestSyncPendingChartsVerificationWebhook(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	var requests []verificationRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer token"; got != want {
+			t.Errorf("got: %q Authorization header, want: %q", got, want)
+		}
+		var req verificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, req)
+		res := verificationResponse{Allowed: true}
+		if req.SourceName == "kafka" {
+			res = verificationResponse{Reason: "CVE-2021-44228"}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.verificationWebhook = &api.VerificationWebhook{Url: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}}
+
+	err = s.SyncPendingCharts("apache", "kafka")
+	if err == nil || !strings.Contains(err.Error(), "denied by the verification webhook: CVE-2021-44228") {
+		t.Errorf("got: %v, want: kafka chart denied", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstTmp, "apache-7.3.15.tgz")); err != nil {
+		t.Errorf("allowed chart was not pushed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstTmp, "kafka-10.3.3.tgz")); !os.IsNotExist(err) {
+		t.Errorf("denied chart was pushed: %v", err)
+	}
+
+	var apache *verificationRequest
+	for i, req := range requests {
+		if req.Name == "apache" {
+			apache = &requests[i]
+		}
+	}
+	if apache == nil {
+		t.Fatalf("apache chart was not verified: %+v", requests)
+	}
+	digest, err := utils.FileSha256(filepath.Join(dstTmp, "apache-7.3.15.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := apache.Digest, "sha256:"+digest; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := apache.Metadata.Version, "7.3.15"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if len(apache.Images) == 0 || !strings.Contains(apache.Images[0], "bitnami/") {
+		t.Errorf("unexpected images: %v", apache.Images)
+	}
+
+	// The webhook is not called in dry-run mode
+	requests = nil
+	s = NewFake(t, WithFakeSyncerDestination(t.TempDir()))
+	s.verificationWebhook = &api.VerificationWebhook{Url: srv.URL}
+	s.dryRun = true
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Errorf("got: %v, want: apache chart not verified in dry-run mode", err)
+	}
+	if len(requests) != 0 {
+		t.Errorf("got: %d webhook requests in dry-run mode, want: none", len(requests))
+	}
+
+	// The charts are not pushed if the webhook fails, unless it fails open
+	srv.Close()
+	for _, failOpen := range []bool{false, true} {
+		dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+		if err != nil {
+			t.Fatalf("error creating temporary folder: %v", err)
+		}
+		defer os.RemoveAll(dstTmp)
+
+		s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+		s.verificationWebhook = &api.VerificationWebhook{Url: srv.URL, FailOpen: failOpen}
+		err = s.SyncPendingCharts("apache")
+		if failOpen && err != nil {
+			t.Errorf("got: %v, want: chart pushed despite the webhook failure", err)
+		} else if !failOpen && err == nil {
+			t.Errorf("got: chart pushed despite the webhook failure, want: error")
+		}
+	}
+}
+
+func TestSyncPendingChartsDryRunDiff(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	// The target has versions unknown to the source and a zookeeper package
+	// different from the source one
+	apache, err := loader.Load("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"10.0.0", "9.9.9", "10.0.0-rc.1"} {
+		apache.Metadata.Version = v
+		if _, err := chartutil.Save(apache, dstTmp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	zookeeper, err := loader.Load("../../testdata/zookeeper-5.14.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chartutil.Save(zookeeper, dstTmp); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := utils.FileSha256("../../testdata/zookeeper-5.14.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.cli.src = &digestedRepo{Repo: s.cli.src.(*local.Repo), digest: "sha256:" + digest}
+	s.dryRun = true
+	s.compareDigest = true
+	if err := s.SyncPendingCharts("apache", "zookeeper"); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range s.LastDiff() {
+		got = append(got, e.Name+"-"+e.Version+" "+e.Change)
+		if e.Change == DiffDigestMismatch && (e.SourceDigest == "" || e.TargetDigest == "" || e.SourceDigest == e.TargetDigest) {
+			t.Errorf("got: %+v, want different source and target digests", e)
+		}
+	}
+	// Versions are sorted by semver precedence
+	want := []string{"apache-7.3.15 source-only", "apache-9.9.9 target-only", "apache-10.0.0-rc.1 target-only", "apache-10.0.0 target-only", "zookeeper-5.14.3 digest-mismatch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	// Nothing is compared outside of dry-run mode
+	s.dryRun = false
+	s.index = nil
+	if err := s.SyncPendingCharts("apache"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := s.LastDiff(); diff != nil {
+		t.Errorf("got: %v, want no differences", diff)
+	}
+}
+
+func TestLastReport(t *testing.T) {
+	s := &Syncer{
+		lastSkipped: []ChartReport{{Name: "apache", Version: "10.0.0", Action: ActionSkipped}, {Name: "apache", Version: "9.9.9", Action: ActionSkipped}},
+		lastResults: []ChartResult{{Name: "apache", Version: "10.0.0-rc.1"}, {Name: "zookeeper", Version: "5.14.3"}},
+		lastPending: []*Chart{{Name: "apache", Version: "7.3.15"}},
+	}
+	var got []string
+	for _, r := range s.LastReport() {
+		got = append(got, r.Name+"-"+r.Version+" "+r.Action)
+	}
+	// Versions are sorted by semver precedence
+	want := []string{"apache-7.3.15 pending", "apache-9.9.9 skipped", "apache-10.0.0-rc.1 synced", "apache-10.0.0 skipped", "zookeeper-5.14.3 synced"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	zookeeper, err := loader.Load("../../testdata/zookeeper-5.14.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chartutil.Save(zookeeper, dstTmp); err != nil {
+		t.Fatal(err)
+	}
+
+	digest, err := utils.FileSha256("../../testdata/zookeeper-5.14.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.cli.src = &digestedRepo{Repo: s.cli.src.(*local.Repo), digest: "sha256:" + digest}
+	s.compareDigest = true
+	diff, err := s.Diff("apache", "zookeeper")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, e := range diff {
+		got = append(got, e.Name+"-"+e.Version+" "+e.Change)
+	}
+	want := []string{"apache-7.3.15 source-only", "zookeeper-5.14.3 digest-mismatch"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if !reflect.DeepEqual(s.LastDiff(), diff) {
+		t.Errorf("got: %v, want: %v", s.LastDiff(), diff)
+	}
+
+	// Nothing is written to the target
+	if _, err := os.Stat(filepath.Join(dstTmp, "apache-7.3.15.tgz")); !os.IsNotExist(err) {
+		t.Errorf("got: %v, want the apache chart not to be synced", err)
+	}
+	if results := s.LastResults(); len(results) != 0 {
+		t.Errorf("got: %v, want no synced charts", results)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "charts-syncer-tests-verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := state.New(&api.State{Backend: &api.State_File{File: filepath.Join(dir, "state.db")}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	dst := filepath.Join(dir, "target")
+	s := NewFake(t, WithFakeSyncerDestination(dst))
+	s.store = store
+	if err := s.SyncPendingCharts("kafka"); err != nil {
+		t.Fatal(err)
+	}
+	results, err := s.Verify(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Name+"-"+r.Version)
+		if !r.OK() || r.ExpectedDigest == "" || r.Digest != r.ExpectedDigest {
+			t.Errorf("got: %+v, want a verified chart with the recorded digest", r)
+		}
+	}
+	if want := []string{"kafka-10.3.3", "zookeeper-5.14.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	// A package modified in the target does not have the recorded digest, and
+	// the dependencies missing in the target are reported
+	zookeeper, err := loader.Load(filepath.Join(dst, "zookeeper-5.14.3.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zookeeper.Metadata.Description = "modified"
+	if _, err := chartutil.Save(zookeeper, dst); err != nil {
+		t.Fatal(err)
+	}
+	results, err = s.Verify(true, "zookeeper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || len(results[0].Problems) != 1 || !strings.Contains(results[0].Problems[0], "digest mismatch") {
+		t.Errorf("got: %+v, want a digest mismatch", results)
+	}
+
+	if err := os.Remove(filepath.Join(dst, "zookeeper-5.14.3.tgz")); err != nil {
+		t.Fatal(err)
+	}
+	if s.cli.dst, err = local.New(dst); err != nil {
+		t.Fatal(err)
+	}
+	results, err = s.Verify(true, "kafka", "zookeeper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, r := range results {
+		got = append(got, r.Name+"-"+r.Version+": "+strings.Join(r.Problems, "; "))
+	}
+	want := []string{"kafka-10.3.3: the zookeeper-5.14.3 dependency is not in the target", "zookeeper-: chart not found in the target"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+// overlappingRepo is a local repository whose uploads wait for another one
+// in flight
+type overlappingRepo struct {
+	*local.Repo
+	uploads int32
+	overlap chan struct{}
+}
+
+func (r *overlappingRepo) Upload(file string, metadata *helmchart.Metadata) error {
+	if atomic.AddInt32(&r.uploads, 1) == 2 {
+		close(r.overlap)
+	}
+	select {
+	case <-r.overlap:
+	case <-time.After(10 * time.Second):
+		return errors.Errorf("%s-%s upload did not overlap with another one", metadata.Name, metadata.Version)
+	}
+	return r.Repo.Upload(file, metadata)
+}
+
+func TestSyncPendingChartsConcurrentUploads(t *testing.T) {
+	dstTmp := t.TempDir()
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp), WithFakeWorkers(2))
+	dst := &overlappingRepo{Repo: s.cli.dst.(*local.Repo), overlap: make(chan struct{})}
+	s.cli.dst = dst
+
+	if err := s.SyncPendingCharts("apache", "zookeeper"); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"apache-7.3.15.tgz", "zookeeper-5.14.3.tgz"} {
+		if ok, err := utils.FileExists(filepath.Join(dstTmp, file)); err != nil || !ok {
+			t.Errorf("%q was not uploaded: %v", file, err)
+		}
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	l := &concurrencyLimit{max: 8, current: 8}
+	var got []int
+	// Requests are throttled while the first three charts are synced
+	for _, throttled := range []int{2, 3, 5, 5, 5, 5, 5, 5, 5, 5, 5} {
+		l.update(throttled)
+		got = append(got, l.current)
+	}
+	if want := []int{4, 2, 1, 2, 3, 4, 5, 6, 7, 8, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+// throttledRepo is a local repository whose uploads are throttled
+type throttledRepo struct {
+	*local.Repo
+	mu    sync.Mutex
+	stats utils.ThrottleStats
+}
+
+func (r *throttledRepo) Upload(file string, metadata *helmchart.Metadata) error {
+	r.mu.Lock()
+	r.stats.Throttled++
+	r.stats.Waited += time.Second
+	r.mu.Unlock()
+	return r.Repo.Upload(file, metadata)
+}
+
+func (r *throttledRepo) throttleStats() utils.ThrottleStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+func TestSyncPendingChartsThrottling(t *testing.T) {
+	s := NewFake(t, WithFakeWorkers(3))
+	dst := &throttledRepo{Repo: s.cli.dst.(*local.Repo), stats: utils.ThrottleStats{Throttled: 10}}
+	s.cli.dst = dst
+	defer func(f func() utils.ThrottleStats) { throttleStats = f }(throttleStats)
+	throttleStats = dst.throttleStats
+
+	if err := s.SyncPendingCharts("apache", "kafka"); err != nil {
+		t.Fatal(err)
+	}
+	// Only the requests throttled during the run are counted
+	run := s.LastRun()
+	if run.Throttled != 3 || run.ThrottleWait != 3*time.Second {
+		t.Errorf("got: %d throttled requests and %s waited, want: 3 and 3s", run.Throttled, run.ThrottleWait)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+
+	// The target has versions removed from the source, a chart not in the
+	// source and a version excluded by the filters
+	apache, err := loader.Load("../../testdata/apache-7.3.15.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chartutil.Save(apache, dstTmp); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{"10.0.0", "9.9.9"} {
+		apache.Metadata.Version = v
+		if _, err := chartutil.Save(apache, dstTmp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	apache.Metadata.Name, apache.Metadata.Version = "mysql", "1.0.0"
+	if _, err := chartutil.Save(apache, dstTmp); err != nil {
+		t.Fatal(err)
+	}
+	zookeeper, err := loader.Load("../../testdata/zookeeper-5.14.3.tgz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chartutil.Save(zookeeper, dstTmp); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.autoDiscovery = true
+	s.chartFilters = map[string]*api.ChartFilter{"zookeeper": {Versions: ">=6"}}
+	s.dryRun = true
+	entries, err := s.Prune([]string{"mysql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, fmt.Sprintf("%s-%s %s kept=%v", e.Name, e.Version, e.Reason, e.Kept))
+	}
+	// Versions are sorted by semver precedence
+	want := []string{
+		"apache-9.9.9 not in the source kept=false",
+		"apache-10.0.0 not in the source kept=false",
+		"mysql-1.0.0 not in the source kept=true",
+		"zookeeper-5.14.3 excluded by the filters kept=false",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	files := func() []string {
+		matches, err := filepath.Glob(filepath.Join(dstTmp, "*.tgz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, m := range matches {
+			names = append(names, filepath.Base(m))
+		}
+		return names
+	}
+	if got, want := files(), []string{"apache-10.0.0.tgz", "apache-7.3.15.tgz", "apache-9.9.9.tgz", "mysql-1.0.0.tgz", "zookeeper-5.14.3.tgz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want nothing deleted in dry-run mode: %v", got, want)
+	}
+
+	s.dryRun = false
+	if _, err := s.Prune([]string{"mysql:1.*"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := files(), []string{"apache-7.3.15.tgz", "mysql-1.0.0.tgz"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	if _, err := s.Prune([]string{"["}); !errors.IsNotValid(err) {
+		t.Errorf("got: %v, want an invalid keep pattern error", err)
+	}
+}
+
+func TestSyncPendingChartsDependencyTrack(t *testing.T) {
+	defer func(interval time.Duration) { dependencyTrackPollInterval = interval }(dependencyTrackPollInterval)
+	dependencyTrackPollInterval = time.Millisecond
+
+	// The fake server finds a LOW vulnerability in apache and a CRITICAL one
+	// in kafka, once the token of their BOM was processing once. Its
+	// zookeeper dependency has none
+	var mu sync.Mutex
+	polled := map[string]bool{}
+	var submitted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if got, want := r.Header.Get("X-Api-Key"), "key"; got != want {
+			t.Errorf("got: %q API key, want: %q", got, want)
+		}
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/api/v1/bom":
+			var b dependencyTrackBOM
+			if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+				t.Error(err)
+			}
+			bom, err := base64.StdEncoding.DecodeString(b.BOM)
+			if err != nil {
+				t.Error(err)
+			}
+			if !b.AutoCreate || !bytes.Contains(bom, []byte(`"bomFormat": "CycloneDX"`)) {
+				t.Errorf("got: %+v, want a CycloneDX BOM creating the project", b)
+			}
+			submitted = append(submitted, b.ProjectName+"@"+b.ProjectVersion)
+			fmt.Fprintf(w, `{"token": %q}`, b.ProjectName)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/bom/token/"):
+			token := strings.TrimPrefix(r.URL.Path, "/api/v1/bom/token/")
+			fmt.Fprintf(w, `{"processing": %t}`, !polled[token])
+			polled[token] = true
+		case r.URL.Path == "/api/v1/project/lookup":
+			fmt.Fprintf(w, `{"uuid": "uuid-%s"}`, r.URL.Query().Get("name"))
+		case r.URL.Path == "/api/v1/finding/project/uuid-apache":
+			fmt.Fprint(w, `[{"component": {"name": "apache", "version": "2.4.41"}, "vulnerability": {"vulnId": "CVE-2020-1927", "severity": "LOW"}}]`)
+		case r.URL.Path == "/api/v1/finding/project/uuid-kafka":
+			fmt.Fprint(w, `[{"component": {"purl": "pkg:oci/kafka@2.5.0"}, "vulnerability": {"vulnId": "CVE-2021-44228", "severity": "CRITICAL"}}]`)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/finding/project/"):
+			fmt.Fprint(w, `[]`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	dstTmp := t.TempDir()
+	s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+	s.sbom = &api.Sbom{Enabled: true, DependencyTrack: &api.DependencyTrack{Url: srv.URL, ApiKey: "key", SeverityThreshold: "HIGH"}}
+	err := s.SyncPendingCharts("apache", "kafka")
+	if err == nil || !strings.Contains(err.Error(), "findings of HIGH severity or higher in Dependency-Track: CVE-2021-44228 (CRITICAL) in pkg:oci/kafka@2.5.0") {
+		t.Errorf("got: %v, want: kafka chart denied", err)
+	}
+	// The SPDX document is pushed along with the allowed chart
+	if _, err := os.Stat(filepath.Join(dstTmp, "apache-7.3.15.tgz.spdx.json")); err != nil {
+		t.Errorf("allowed chart was not pushed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstTmp, "kafka-10.3.3.tgz")); !os.IsNotExist(err) {
+		t.Errorf("denied chart was pushed: %v", err)
+	}
+	sort.Strings(submitted)
+	if want := []string{"apache@7.3.15", "kafka@10.3.3", "zookeeper@5.14.3"}; !reflect.DeepEqual(submitted, want) {
+		t.Errorf("got submitted SBOMs: %v, want: %v", submitted, want)
+	}
+
+	// The charts are not pushed if Dependency-Track fails, unless it fails
+	// open
+	srv.Close()
+	for _, failOpen := range []bool{false, true} {
+		dstTmp := t.TempDir()
+		s := NewFake(t, WithFakeSyncerDestination(dstTmp))
+		s.sbom = &api.Sbom{Enabled: true, DependencyTrack: &api.DependencyTrack{Url: srv.URL, FailOpen: failOpen}}
+		err := s.SyncPendingCharts("apache")
+		if failOpen && err != nil {
+			t.Errorf("got: %v, want: chart pushed despite the Dependency-Track failure", err)
+		} else if !failOpen && err == nil {
+			t.Errorf("got: chart pushed despite the Dependency-Track failure, want: error")
+		}
+	}
+}
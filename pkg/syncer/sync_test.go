@@ -1,10 +1,12 @@
 package syncer_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -17,6 +19,8 @@ import (
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/helmclassic"
 	"github.com/bitnami-labs/charts-syncer/pkg/syncer"
+	"github.com/juju/errors"
+	helmrepo "helm.sh/helm/v3/pkg/repo"
 )
 
 func getChartIndex(t *testing.T, name string, targetRepo *api.Target, tester repo.ClientTester) []*helmclassic.ChartVersion {
@@ -46,6 +50,7 @@ func TestFakeSyncPendingCharts(t *testing.T) {
 		desc           string
 		entries        []string
 		skippedEntries []string
+		workers        int
 		want           []string
 	}{
 		{
@@ -60,6 +65,13 @@ func TestFakeSyncPendingCharts(t *testing.T) {
 			skippedEntries: []string{"apache"},
 			want:           []string{"kafka-10.3.3.tgz", "zookeeper-5.14.3.tgz"},
 		},
+		{
+			desc:    "concurrent workers",
+			entries: []string{"apache", "kafka"},
+			workers: 3,
+			// kafka is synced once zookeeper is in the target
+			want: []string{"apache-7.3.15.tgz", "kafka-10.3.3.tgz", "zookeeper-5.14.3.tgz"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -70,7 +82,7 @@ func TestFakeSyncPendingCharts(t *testing.T) {
 			}
 			defer os.RemoveAll(dstTmp)
 
-			s := syncer.NewFake(t, syncer.WithFakeSyncerDestination(dstTmp), syncer.WithFakeSkipCharts(tc.skippedEntries))
+			s := syncer.NewFake(t, syncer.WithFakeSyncerDestination(dstTmp), syncer.WithFakeSkipCharts(tc.skippedEntries), syncer.WithFakeWorkers(tc.workers))
 
 			if err := s.SyncPendingCharts(tc.entries...); err != nil {
 				t.Error(err)
@@ -97,6 +109,44 @@ func TestFakeSyncPendingCharts(t *testing.T) {
 	}
 }
 
+func TestFakeSyncPendingChartsInterrupted(t *testing.T) {
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst-fake")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dstTmp)
+	workdir, err := ioutil.TempDir("", "charts-syncer-tests-workdir")
+	if err != nil {
+		t.Fatalf("error creating temporary folder: %v", err)
+	}
+	defer os.RemoveAll(workdir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := syncer.NewFake(t, syncer.WithFakeSyncerDestination(dstTmp), syncer.WithFakeWorkdir(workdir), syncer.WithFakeContext(ctx))
+
+	err = s.SyncPendingCharts("apache", "kafka")
+	if got, want := errors.Cause(err), syncer.ErrInterrupted; got != want {
+		t.Fatalf("got: %v, want: %v", got, want)
+	}
+
+	gotFiles, err := filepath.Glob(fmt.Sprintf("%s/*.tgz", dstTmp))
+	if err != nil {
+		t.Fatalf("error listing tgz files: %v", err)
+	}
+	if len(gotFiles) != 0 {
+		t.Errorf("got: %v, want no synced charts", gotFiles)
+	}
+
+	cp, err := syncer.ReadCheckpoint(workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.Synced) != 0 || len(cp.Failed) != 0 {
+		t.Errorf("got: %+v, want an empty checkpoint", cp)
+	}
+}
+
 func TestSyncPendingChartsChartMuseum(t *testing.T) {
 	testCases := []struct {
 		desc              string
@@ -345,6 +395,7 @@ func TestSyncPendingChartsChartMuseum(t *testing.T) {
 
 			// Create new syncer
 			syncerOptions := []syncer.Option{
+				syncer.WithWorkdir(t.TempDir()),
 				syncer.WithSkipDependencies(tc.skipDependencies),
 				syncer.WithLatestVersionOnly(tc.latestVersionOnly),
 			}
@@ -380,3 +431,145 @@ func TestSyncPendingChartsChartMuseum(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncPendingChartsIndexOnly(t *testing.T) {
+	index, err := ioutil.ReadFile("../../testdata/index-relative.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			t.Errorf("unexpected request to %q, the charts must not be downloaded", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(index)
+	}))
+	defer srv.Close()
+
+	dstTmp, err := ioutil.TempDir("", "charts-syncer-tests-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dstTmp)
+	// Entries already in the target index are kept
+	existing := "apiVersion: v1\nentries:\n  foo:\n  - name: foo\n    version: 1.0.0\n    urls:\n    - https://example.com/foo-1.0.0.tgz\n"
+	if err := ioutil.WriteFile(filepath.Join(dstTmp, "index.yaml"), []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &api.Source{Spec: &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_HELM, Url: srv.URL}}}
+	target := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: dstTmp}}}
+	s, err := syncer.New(source, target,
+		syncer.WithWorkdir(t.TempDir()),
+		syncer.WithIndexOnly(true),
+		syncer.WithLatestVersionOnly(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SyncPendingCharts("common"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := helmrepo.LoadIndexFile(filepath.Join(dstTmp, "index.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cv, err := got.Get("common", "0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{srv.URL + "/charts/common-0.2.1.tgz"}; !reflect.DeepEqual(cv.URLs, want) {
+		t.Errorf("got: %v, want: %v", cv.URLs, want)
+	}
+	if got, want := len(got.Entries["common"]), 1; got != want {
+		t.Errorf("got: %d common charts, want: %d", got, want)
+	}
+	if !got.Has("foo", "1.0.0") {
+		t.Errorf("existing index entry was removed")
+	}
+	if tgzs, _ := filepath.Glob(filepath.Join(dstTmp, "*.tgz")); len(tgzs) > 0 {
+		t.Errorf("charts were copied to the target: %v", tgzs)
+	}
+}
+
+func TestSyncPendingChartsAdditionalRepos(t *testing.T) {
+	// The vendor repository and the internal one provide different
+	// apache-7.3.15 packages, zookeeper is only in the internal one
+	vendor, internal := t.TempDir(), t.TempDir()
+	if err := utils.CopyFile(filepath.Join(vendor, "apache-7.3.15.tgz"), "../../testdata/apache-7.3.15.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.CopyFile(filepath.Join(internal, "zookeeper-5.14.3.tgz"), "../../testdata/zookeeper-5.14.3.tgz"); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := utils.Untar("../../testdata/apache-7.3.15.tgz", dir); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, "apache", "values.yaml"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("# Internal build\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := utils.Tar(dir, "", filepath.Join(internal, "apache-7.3.15.tgz"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		desc     string
+		strategy api.ConflictStrategy
+		// internal is whether the internal apache package is synced
+		internal bool
+		errMsg   string
+	}{
+		{desc: "first wins", strategy: api.ConflictStrategy_CONFLICT_FIRST_WINS},
+		{desc: "prefer priority", strategy: api.ConflictStrategy_CONFLICT_PREFER_PRIORITY, internal: true},
+		{desc: "fail", strategy: api.ConflictStrategy_CONFLICT_FAIL, errMsg: `"apache-7.3.15" chart is provided with different digests`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			dstTmp := t.TempDir()
+			source := &api.Source{
+				Spec:            &api.Source_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: vendor, Priority: 2}},
+				AdditionalRepos: []*api.Repo{{Kind: api.Kind_LOCAL, Path: internal, Priority: 1}},
+			}
+			target := &api.Target{Spec: &api.Target_Repo{Repo: &api.Repo{Kind: api.Kind_LOCAL, Path: dstTmp}}}
+			s, err := syncer.New(source, target, syncer.WithWorkdir(t.TempDir()), syncer.WithConflictStrategy(tc.strategy))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.SyncPendingCharts("apache", "zookeeper")
+			if tc.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+					t.Fatalf("got: %v, want an error containing %q", err, tc.errMsg)
+				}
+				if ok, _ := utils.FileExists(filepath.Join(dstTmp, "apache-7.3.15.tgz")); ok {
+					t.Errorf("conflicting apache chart was pushed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := utils.FileExists(filepath.Join(dstTmp, "zookeeper-5.14.3.tgz")); err != nil || !ok {
+				t.Errorf("got: %v, %v, want zookeeper chart of the additional repository pushed", ok, err)
+			}
+			pushed := t.TempDir()
+			if err := utils.Untar(filepath.Join(dstTmp, "apache-7.3.15.tgz"), pushed); err != nil {
+				t.Fatal(err)
+			}
+			values, err := ioutil.ReadFile(filepath.Join(pushed, "apache", "values.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(string(values), "# Internal build"); got != tc.internal {
+				t.Errorf("got internal apache chart pushed: %t, want: %t", got, tc.internal)
+			}
+		})
+	}
+}
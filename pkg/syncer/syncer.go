@@ -1,12 +1,18 @@
 package syncer
 
 import (
+	"context"
 	"os"
+	"path/filepath"
 
 	"github.com/bitnami-labs/charts-syncer/api"
+	"github.com/bitnami-labs/charts-syncer/internal/cosign"
+	"github.com/bitnami-labs/charts-syncer/internal/state"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
 	"github.com/bitnami-labs/charts-syncer/pkg/client"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/intermediate"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/repo"
+	"github.com/bitnami-labs/charts-syncer/pkg/client/repo/harbor"
 	"github.com/bitnami-labs/charts-syncer/pkg/client/types"
 	"github.com/juju/errors"
 	"k8s.io/klog"
@@ -14,7 +20,8 @@ import (
 
 // Clients holds the source and target chart repo clients
 type Clients struct {
-	src client.ChartsReaderWriter
+	// src can only read, charts-syncer never writes to the source
+	src client.ChartsReader
 	dst client.ChartsReaderWriter
 }
 
@@ -32,8 +39,69 @@ type Syncer struct {
 	relocateContainerImages bool
 	skipDependencies        bool
 	latestVersionOnly       bool
+	// number of most recent versions of every chart to sync, all if 0
+	keepLatestVersions int
 	// list of charts to skip
 	skipCharts []string
+	// regular expressions of the discovered charts to sync
+	chartsRegex []string
+	// glob patterns and regular expressions of the charts to skip
+	excludeCharts      []string
+	excludeChartsRegex []string
+	// filters of the versions synced, by chart name
+	chartFilters map[string]*api.ChartFilter
+	// whether to skip the pre-release versions of every chart
+	skipPrereleases bool
+	// how to proceed when a chart version is provided with different digests
+	conflictStrategy api.ConflictStrategy
+	// whether to fail on conditions that are otherwise only warned about
+	strict bool
+	// how to proceed when a repackaged chart does not pass the Helm linter
+	lintPolicy api.LintPolicy
+	// what to do with the provenance files of the repackaged charts
+	provenancePolicy api.ProvenancePolicy
+	// key used to sign the repackaged charts
+	signingKey *api.SigningKey
+	// whether to delete pushed charts whose publication could not be completed
+	rollback bool
+	// whether to read the pushed charts back to check their digest
+	verifyUploads bool
+	// whether to sync the charts whose digest changed upstream again
+	compareDigest bool
+	// whether to delete the charts already in the target before pushing them
+	forceOverwrite bool
+	// changes made to the charts while they are repackaged
+	transformations []*api.Transformation
+	// suffix appended to the version of the charts pushed to the target
+	versionSuffix string
+	// new names of the charts pushed to the target
+	rename *api.Rename
+	// additional .helmignore rules applied when repackaging the charts
+	ignore []string
+	// records of the synced charts
+	store state.Store
+	// SLSA provenance attestations of the repackaged charts
+	attestation *api.Attestation
+	// software bills of materials of the pushed charts
+	sbom *api.Sbom
+	// version of charts-syncer and identifier of the sync run, recorded in
+	// the attestations
+	syncerVersion string
+	runID         string
+	// signs the manifests of the charts pushed to an OCI target with cosign
+	cosign types.ManifestSigner
+	// external service allowing or denying the push of the repackaged charts
+	verificationWebhook *api.VerificationWebhook
+	// whether to only write the target index, pointing at the source charts
+	indexOnly bool
+	// anonymous usage reports sent after every run
+	telemetry *api.Telemetry
+
+	// notifications are the endpoints notified when a run completes
+	notifications []*api.Notification
+	// number of charts synced concurrently. The repository clients and the
+	// state store are safe for concurrent use.
+	workers int
 
 	// TODO(jdrios): Cache index in local filesystem to speed
 	// up re-runs
@@ -41,6 +109,31 @@ type Syncer struct {
 
 	// Storage directory for required artifacts
 	workdir string
+
+	// ctx allows to interrupt the sync gracefully
+	ctx context.Context
+
+	// summary of the last sync run
+	lastRun *state.Run
+	// lastResults are the charts processed by the last sync run
+	lastResults []ChartResult
+	// lastSkipped are the charts skipped by the last sync run, or failed
+	// before being processed
+	lastSkipped []ChartReport
+	// lastPending are the charts left pending by the last sync run
+	lastPending []*Chart
+	// lastDiff are the differences between the source and the target found
+	// by the last dry run
+	lastDiff []DiffEntry
+
+	// Charts explored by the last run, to compare the source and the target
+	// in dry-run mode: the selected ones, whether the whole source was
+	// selected, the versions of the ones not skipped and the ones whose
+	// digest differs in the target
+	selectedCharts []string
+	discoveredAll  bool
+	sourceVersions map[string][]string
+	mismatches     map[string]string
 }
 
 // Option is an option value used to create a new syncer instance.
@@ -76,6 +169,14 @@ func WithWorkdir(dir string) Option {
 	}
 }
 
+// WithContext configures the syncer to stop syncing new charts once the
+// context is done. Charts being synced at that moment are not interrupted.
+func WithContext(ctx context.Context) Option {
+	return func(s *Syncer) {
+		s.ctx = ctx
+	}
+}
+
 // WithInsecure configures the syncer to allow insecure SSL connections
 func WithInsecure(enable bool) Option {
 	return func(s *Syncer) {
@@ -105,6 +206,188 @@ func WithLatestVersionOnly(latestVersionOnly bool) Option {
 	}
 }
 
+// WithConflictStrategy configures how the syncer proceeds when the same chart
+// version is provided with different contents
+func WithConflictStrategy(strategy api.ConflictStrategy) Option {
+	return func(s *Syncer) {
+		s.conflictStrategy = strategy
+	}
+}
+
+// WithLintPolicy configures how the syncer proceeds when a repackaged chart
+// does not pass the Helm linter
+func WithLintPolicy(policy api.LintPolicy) Option {
+	return func(s *Syncer) {
+		s.lintPolicy = policy
+	}
+}
+
+// WithProvenancePolicy configures what the syncer does with the provenance
+// files of the charts it repackages
+func WithProvenancePolicy(policy api.ProvenancePolicy) Option {
+	return func(s *Syncer) {
+		s.provenancePolicy = policy
+	}
+}
+
+// WithSigningKey configures the key used to sign the repackaged charts
+func WithSigningKey(key *api.SigningKey) Option {
+	return func(s *Syncer) {
+		s.signingKey = key
+	}
+}
+
+// WithRollback configures the syncer to delete a pushed chart from the target
+// if the rest of its artifacts cannot be pushed.
+func WithRollback(enable bool) Option {
+	return func(s *Syncer) {
+		s.rollback = enable
+	}
+}
+
+// WithUploadVerification configures the syncer to read every pushed chart back
+// from the target and fail it if its digest differs from the uploaded package.
+func WithUploadVerification(enable bool) Option {
+	return func(s *Syncer) {
+		s.verifyUploads = enable
+	}
+}
+
+// WithDigestComparison configures the syncer to compare the digest of the
+// charts already in the target with the source one, and to sync the charts
+// re-published upstream with different contents again.
+func WithDigestComparison(enable bool) Option {
+	return func(s *Syncer) {
+		s.compareDigest = enable
+	}
+}
+
+// WithForceOverwrite configures the syncer to delete the charts already in the
+// target before pushing them again.
+func WithForceOverwrite(enable bool) Option {
+	return func(s *Syncer) {
+		s.forceOverwrite = enable
+	}
+}
+
+// WithTransformations configures the changes made to the charts while they
+// are repackaged
+func WithTransformations(transformations []*api.Transformation) Option {
+	return func(s *Syncer) {
+		s.transformations = transformations
+	}
+}
+
+// WithVersionSuffix configures the syncer to append a suffix to the version
+// of the charts pushed to the target
+func WithVersionSuffix(suffix string) Option {
+	return func(s *Syncer) {
+		s.versionSuffix = suffix
+	}
+}
+
+// WithRename configures the syncer to rename the charts pushed to the target
+func WithRename(rename *api.Rename) Option {
+	return func(s *Syncer) {
+		s.rename = rename
+	}
+}
+
+// WithStateStore configures the syncer to record the synced charts in a state
+// store, and to skip the recorded ones without exploring the target
+func WithStateStore(store state.Store) Option {
+	return func(s *Syncer) {
+		s.store = store
+	}
+}
+
+// WithIgnore configures additional .helmignore rules applied when the charts
+// are repackaged
+func WithIgnore(rules []string) Option {
+	return func(s *Syncer) {
+		s.ignore = rules
+	}
+}
+
+// WithAttestation configures the syncer to push SLSA provenance attestations
+// along with the repackaged charts
+func WithAttestation(attestation *api.Attestation) Option {
+	return func(s *Syncer) {
+		s.attestation = attestation
+	}
+}
+
+// WithSBOM configures the syncer to push a software bill of materials along
+// with every pushed chart, and submit it to a vulnerability scanner
+func WithSBOM(sbom *api.Sbom) Option {
+	return func(s *Syncer) {
+		s.sbom = sbom
+	}
+}
+
+// WithSyncerVersion configures the version of charts-syncer recorded in the
+// attestations
+func WithSyncerVersion(version string) Option {
+	return func(s *Syncer) {
+		s.syncerVersion = version
+	}
+}
+
+// WithRunID configures the identifier of the sync run recorded in the
+// attestations
+func WithRunID(id string) Option {
+	return func(s *Syncer) {
+		s.runID = id
+	}
+}
+
+// WithVerificationWebhook configures the syncer to ask an external service
+// whether every repackaged chart can be pushed to the target
+func WithVerificationWebhook(webhook *api.VerificationWebhook) Option {
+	return func(s *Syncer) {
+		s.verificationWebhook = webhook
+	}
+}
+
+// WithIndexOnly configures the syncer to only write the index of the target,
+// with entries pointing at the charts in the source repository
+func WithIndexOnly(enable bool) Option {
+	return func(s *Syncer) {
+		s.indexOnly = enable
+	}
+}
+
+// WithTelemetry configures the syncer to send an anonymous usage report
+// after every run, if enabled.
+func WithTelemetry(t *api.Telemetry) Option {
+	return func(s *Syncer) {
+		s.telemetry = t
+	}
+}
+
+// WithNotifications configures the endpoints notified when a run completes
+func WithNotifications(n []*api.Notification) Option {
+	return func(s *Syncer) {
+		s.notifications = n
+	}
+}
+
+// WithWorkers configures the number of charts synced concurrently. Charts are
+// always synced after their dependencies.
+func WithWorkers(n int) Option {
+	return func(s *Syncer) {
+		s.workers = n
+	}
+}
+
+// WithStrict configures the syncer to fail on conditions that are otherwise
+// only logged as warnings, like charts that could not be indexed.
+func WithStrict(enable bool) Option {
+	return func(s *Syncer) {
+		s.strict = enable
+	}
+}
+
 // New creates a new syncer using Client
 func New(source *api.Source, target *api.Target, opts ...Option) (*Syncer, error) {
 	s := &Syncer{
@@ -126,38 +409,77 @@ func New(source *api.Source, target *api.Target, opts ...Option) (*Syncer, error
 	if err := os.MkdirAll(s.workdir, 0755); err != nil {
 		return nil, errors.Trace(err)
 	}
+	// The packages fetched from any repository are shared by digest, so
+	// common dependencies are only downloaded once
+	utils.SetDigestCache(filepath.Join(s.workdir, DigestCacheDirname))
 
 	s.cli = &Clients{}
 	if source.GetRepo() != nil {
-		srcCli, err := repo.NewClient(source.GetRepo(), types.WithCache(s.workdir), types.WithInsecure(s.insecure))
+		srcCli, err := repo.NewReader(source.GetRepo(), types.WithCache(s.workdir), types.WithInsecure(s.insecure))
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		s.cli.src = srcCli
+		if additional := source.GetAdditionalRepos(); len(additional) > 0 {
+			repos := append([]*api.Repo{source.GetRepo()}, additional...)
+			readers := []client.ChartsReader{srcCli}
+			for _, r := range additional {
+				cli, err := repo.NewReader(r, types.WithCache(s.workdir), types.WithInsecure(s.insecure))
+				if err != nil {
+					return nil, errors.Annotatef(err, "creating the client of %q", r.GetUrl())
+				}
+				readers = append(readers, cli)
+			}
+			s.cli.src = newSources(repos, readers, s.getConflictStrategy())
+		}
 	} else if source.GetIntermediateBundlesPath() != "" {
 		// Specifically disable dependencies sync for intermediate scenarios
-		disableDependencySync(s)
+		if err := disableDependencySync(s); err != nil {
+			return nil, errors.Trace(err)
+		}
 		// Create new intermediate bundles client
-		srcCli, err := intermediate.NewIntermediateClient(source.GetIntermediateBundlesPath())
+		srcCli, err := intermediate.NewIntermediateClient(source.GetIntermediateBundlesPath(),
+			intermediate.WithToolchainVersion(s.syncerVersion),
+			intermediate.WithDecryption(source.GetBundleDecryption(), s.workdir))
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		s.cli.src = srcCli
+		s.cli.src = client.ReadOnly(srcCli)
 	} else {
 		return nil, errors.New("no source info defined in config file")
 	}
 
 	if target.GetRepo() != nil {
+		// The Harbor project has to exist before the target index is loaded
+		if target.GetHarbor() != nil {
+			if s.dryRun {
+				klog.Infof("dry-run: Configuring the Harbor project of %q", target.GetRepo().GetUrl())
+			} else if err := harbor.SetupProject(target.GetRepo(), target.GetHarbor(), s.insecure); err != nil {
+				return nil, errors.Annotatef(err, "configuring the Harbor project")
+			}
+		}
 		dstCli, err := repo.NewClient(target.GetRepo(), types.WithCache(s.workdir), types.WithInsecure(s.insecure))
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		s.cli.dst = dstCli
+		if c := target.GetCosign(); c != nil {
+			signer, err := cosign.NewSigner(c, s.insecure)
+			if err != nil {
+				return nil, errors.Annotatef(err, "configuring cosign signatures")
+			}
+			s.cosign = signer
+		}
 	} else if target.GetIntermediateBundlesPath() != "" {
 		// Specifically disable dependencies sync for intermediate scenarios
-		disableDependencySync(s)
+		if err := disableDependencySync(s); err != nil {
+			return nil, errors.Trace(err)
+		}
 		// Create new intermediate bundles client
-		dstCli, err := intermediate.NewIntermediateClient(target.GetIntermediateBundlesPath())
+		dstCli, err := intermediate.NewIntermediateClient(target.GetIntermediateBundlesPath(),
+			intermediate.WithToolchainVersion(s.syncerVersion),
+			intermediate.WithEncryption(target.GetBundleEncryption()),
+			intermediate.WithPartSize(target.GetBundlePartSize()))
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -168,7 +490,9 @@ func New(source *api.Source, target *api.Target, opts ...Option) (*Syncer, error
 
 	if s.relocateContainerImages {
 		// Specifically disable dependencies sync for relok8s scenario
-		disableDependencySync(s)
+		if err := disableDependencySync(s); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 	return s, nil
 }
@@ -181,9 +505,70 @@ func WithSkipCharts(charts []string) Option {
 	}
 }
 
-func disableDependencySync(syncer *Syncer) {
+// WithChartsRegex configures the syncer to sync the charts discovered in the
+// source whose whole name matches any of the regular expressions, in addition
+// to the charts explicitly requested.
+func WithChartsRegex(regexps []string) Option {
+	return func(s *Syncer) {
+		s.chartsRegex = regexps
+	}
+}
+
+// WithExcludeCharts configures the syncer to skip the charts whose name
+// matches any of the glob patterns.
+func WithExcludeCharts(patterns []string) Option {
+	return func(s *Syncer) {
+		s.excludeCharts = patterns
+	}
+}
+
+// WithExcludeChartsRegex configures the syncer to skip the charts whose whole
+// name matches any of the regular expressions.
+func WithExcludeChartsRegex(regexps []string) Option {
+	return func(s *Syncer) {
+		s.excludeChartsRegex = regexps
+	}
+}
+
+// WithChartFilters configures the filters of the versions synced, by chart
+// name.
+func WithChartFilters(filters map[string]*api.ChartFilter) Option {
+	return func(s *Syncer) {
+		s.chartFilters = filters
+	}
+}
+
+// WithLatestVersions configures the syncer to only sync the n most recent
+// versions of every chart. Every version is synced if n is 0.
+func WithLatestVersions(n uint32) Option {
+	return func(s *Syncer) {
+		s.keepLatestVersions = int(n)
+	}
+}
+
+// WithSkipPrereleases configures the syncer to skip the pre-release versions
+// of every chart.
+func WithSkipPrereleases(enable bool) Option {
+	return func(s *Syncer) {
+		s.skipPrereleases = enable
+	}
+}
+
+// context returns the syncer context
+func (s *Syncer) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+func disableDependencySync(syncer *Syncer) error {
 	if syncer.skipDependencies == false {
+		if syncer.strict {
+			return errors.Errorf("dependency sync is not supported if container image relocation is true or syncing from/to intermediate directory. Please, skip dependencies explicitly")
+		}
 		klog.Warningf("Ignoring skipDependencies option as dependency sync is not supported if container image relocation is true or syncing from/to intermediate directory ")
 	}
 	syncer.skipDependencies = true
+	return nil
 }
@@ -0,0 +1,140 @@
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/juju/errors"
+	helmchart "helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// defaultVerificationTimeout is the time to wait for the verification webhook
+// to answer if no timeout is configured
+const defaultVerificationTimeout = time.Minute
+
+// verificationRequest is the body of the requests sent to the verification
+// webhook
+type verificationRequest struct {
+	// Name and Version are the ones of the chart pushed to the target
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	SourceName    string `json:"sourceName"`
+	SourceVersion string `json:"sourceVersion"`
+	// Digest is the sha256 digest of the repackaged chart
+	Digest string `json:"digest"`
+	// SourceDigest is the sha256 digest of the source chart
+	SourceDigest string              `json:"sourceDigest,omitempty"`
+	Images       []string            `json:"images"`
+	Metadata     *helmchart.Metadata `json:"metadata"`
+}
+
+// verificationResponse is the answer of the verification webhook
+type verificationResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+// verifyChart asks the verification webhook whether a repackaged chart can
+// be pushed to the target. It returns an error if the chart is denied.
+func (s *Syncer) verifyChart(ch *Chart, tgz string, metadata *helmchart.Metadata, id string) error {
+	if s.verificationWebhook == nil {
+		return nil
+	}
+	if s.dryRun {
+		klog.Infof("dry-run: Verifying %q chart", id)
+		return nil
+	}
+	klog.V(3).Infof("Verifying %q chart...", id)
+	res, err := s.callVerificationWebhook(ch, tgz, metadata)
+	if err != nil {
+		if s.verificationWebhook.GetFailOpen() {
+			klog.Warningf("unable to verify %q chart, pushing it anyway: %v", id, err)
+			return nil
+		}
+		return errors.Annotatef(err, "verifying %q chart", id)
+	}
+	if !res.Allowed {
+		if res.Reason == "" {
+			return errors.Errorf("%q chart was denied by the verification webhook", id)
+		}
+		return errors.Errorf("%q chart was denied by the verification webhook: %s", id, res.Reason)
+	}
+	return nil
+}
+
+// callVerificationWebhook sends the description of a repackaged chart to the
+// verification webhook and returns its answer
+func (s *Syncer) callVerificationWebhook(ch *Chart, tgz string, metadata *helmchart.Metadata) (*verificationResponse, error) {
+	c, err := loader.LoadFile(tgz)
+	if err != nil {
+		return nil, errors.Annotatef(err, "loading %q", tgz)
+	}
+	digest, err := utils.FileSha256(tgz)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	vr := verificationRequest{
+		Name:          metadata.Name,
+		Version:       metadata.Version,
+		SourceName:    ch.Name,
+		SourceVersion: ch.Version,
+		Digest:        "sha256:" + digest,
+		Images:        chart.Images(c),
+		Metadata:      c.Metadata,
+	}
+	if ch.Digest != "" {
+		vr.SourceDigest = "sha256:" + ch.Digest
+	}
+	body, err := json.Marshal(vr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	timeout := defaultVerificationTimeout
+	if t := s.verificationWebhook.GetTimeout(); t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return nil, errors.Annotatef(err, "parsing verification webhook timeout")
+		}
+	}
+	// Charts being processed are allowed to finish when the sync is
+	// interrupted, so the request does not use the syncer context
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.verificationWebhook.GetUrl(), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.verificationWebhook.GetHeaders() {
+		req.Header.Set(k, v)
+	}
+	if auth := s.verificationWebhook.GetAuth(); auth != nil {
+		req.SetBasicAuth(auth.GetUsername(), auth.GetPassword())
+	}
+
+	client := utils.DefaultClient
+	if s.insecure {
+		client = utils.InsecureClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("verification webhook answered with HTTP status %s: %s", resp.Status, utils.HTTPResponseBody(resp))
+	}
+	res := &verificationResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, errors.Annotatef(err, "parsing verification webhook answer")
+	}
+	return res, nil
+}
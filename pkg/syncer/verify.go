@@ -0,0 +1,187 @@
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/juju/errors"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"k8s.io/klog"
+
+	"github.com/bitnami-labs/charts-syncer/internal/chart"
+	"github.com/bitnami-labs/charts-syncer/internal/utils"
+)
+
+// VerifyResult is the outcome of the verification of a chart version of the
+// target repository
+type VerifyResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	// Digest is the SHA256 digest of the fetched package
+	Digest string `json:"digest,omitempty"`
+	// ExpectedDigest is the digest recorded in the state store or, if the
+	// chart was not recorded, the one reported by the target repository
+	ExpectedDigest string `json:"expectedDigest,omitempty"`
+	// Problems are the checks the chart version failed
+	Problems []string `json:"problems,omitempty"`
+}
+
+// OK returns whether the chart version passed all the checks
+func (r VerifyResult) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify checks that every chart version of the target repository, or of the
+// given charts of the source, can be fetched and loaded, and has the expected
+// digest. If dependencies is set, the locked dependencies of the charts,
+// other than the local ones, must also be found in the target. The results
+// are sorted by name and version.
+//
+// The problems of the chart versions are reported in their results, the
+// errors returned are the ones listing the target charts.
+func (s *Syncer) Verify(dependencies bool, names ...string) ([]VerifyResult, error) {
+	if s.target.GetIntermediateBundlesPath() != "" {
+		return nil, errors.NotSupportedf("verifying intermediate bundles")
+	}
+	if len(names) == 0 {
+		var err error
+		if names, err = s.cli.dst.List(); err != nil {
+			return nil, errors.Annotatef(err, "listing the target charts")
+		}
+	} else {
+		targetNames := make([]string, 0, len(names))
+		for _, name := range names {
+			targetNames = append(targetNames, s.targetName(name))
+		}
+		names = targetNames
+	}
+	sort.Strings(names)
+
+	results := []VerifyResult{}
+	// Target versions, by chart name, to resolve the dependencies
+	versions := map[string]map[string]bool{}
+	for _, name := range names {
+		if s.context().Err() != nil {
+			return results, errors.Trace(ErrInterrupted)
+		}
+		vs, err := s.cli.dst.ListChartVersions(name)
+		if errors.IsNotFound(err) || (err == nil && len(vs) == 0) {
+			results = append(results, VerifyResult{Name: name, Problems: []string{"chart not found in the target"}})
+			continue
+		}
+		if err != nil {
+			return results, errors.Annotatef(err, "listing %q chart versions in the target", name)
+		}
+		utils.SortVersions(vs)
+		for _, version := range vs {
+			if s.context().Err() != nil {
+				return results, errors.Trace(ErrInterrupted)
+			}
+			results = append(results, s.verifyTargetChart(name, version, dependencies, versions))
+		}
+	}
+	return results, nil
+}
+
+// verifyTargetChart checks a chart version of the target repository
+func (s *Syncer) verifyTargetChart(name, version string, dependencies bool, versions map[string]map[string]bool) VerifyResult {
+	id := fmt.Sprintf("%s-%s", name, version)
+	r := VerifyResult{Name: name, Version: version}
+	problem := func(format string, args ...interface{}) {
+		r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+	}
+
+	want, err := s.expectedDigest(name, version)
+	if err != nil {
+		problem("unable to get the expected digest: %v", err)
+	}
+	r.ExpectedDigest = want
+
+	tgz, err := s.cli.dst.Fetch(name, version)
+	if err != nil {
+		problem("unable to fetch the chart: %v", err)
+		return r
+	}
+	if r.Digest, err = utils.FileSha256(tgz); err != nil {
+		problem("unable to read the fetched chart: %v", err)
+		return r
+	}
+	if want != "" && r.Digest != want {
+		problem("digest mismatch (got: %s, want: %s)", r.Digest, want)
+	}
+	ch, err := loader.Load(tgz)
+	if err != nil {
+		problem("unable to load the chart: %v", err)
+		return r
+	}
+	if ch.Metadata.Name != name || ch.Metadata.Version != version {
+		problem("the package is the %s-%s chart", ch.Metadata.Name, ch.Metadata.Version)
+	}
+
+	if dependencies {
+		deps, err := chart.GetChartDependencies(tgz, name)
+		if err != nil {
+			problem("unable to read the dependencies: %v", err)
+		}
+		for _, dep := range deps {
+			// Dependencies shipped with the chart are not looked up
+			if dep.Repository == "" || strings.HasPrefix(dep.Repository, "file://") {
+				continue
+			}
+			ok, err := s.targetHas(dep.Name, dep.Version, versions)
+			if err != nil {
+				problem("unable to resolve the %s-%s dependency: %v", dep.Name, dep.Version, err)
+			} else if !ok {
+				problem("the %s-%s dependency is not in the target", dep.Name, dep.Version)
+			}
+		}
+	}
+
+	if r.OK() {
+		klog.V(4).Infof("Verified %q chart", id)
+	} else {
+		klog.Warningf("%q chart failed the verification: %s", id, strings.Join(r.Problems, "; "))
+	}
+	return r
+}
+
+// expectedDigest returns the digest a target chart should have: the one of
+// the pushed package recorded in the state store or, if the chart was not
+// recorded, the one reported by the target repository, if any
+func (s *Syncer) expectedDigest(name, version string) (string, error) {
+	if s.store != nil {
+		r, err := s.store.Get(name, version)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", errors.Annotatef(err, "reading state")
+		}
+		if err == nil && r.TargetDigest != "" {
+			return r.TargetDigest, nil
+		}
+	}
+	details, err := s.cli.dst.GetChartDetails(name, version)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	// Some clients report placeholder digests
+	if digest := strings.ToLower(strings.TrimPrefix(details.Digest, "sha256:")); sha256Digest.MatchString(digest) {
+		return digest, nil
+	}
+	return "", nil
+}
+
+// targetHas returns whether the target has a chart version, caching the
+// versions of every chart looked up
+func (s *Syncer) targetHas(name, version string, versions map[string]map[string]bool) (bool, error) {
+	if _, ok := versions[name]; !ok {
+		vs, err := s.cli.dst.ListChartVersions(name)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, errors.Trace(err)
+		}
+		versions[name] = map[string]bool{}
+		for _, v := range vs {
+			versions[name][v] = true
+		}
+	}
+	return versions[name][version], nil
+}
@@ -10,6 +10,10 @@ import (
 // WorkdirName is the default name for a workdir
 const WorkdirName = ".charts-syncer"
 
+// DigestCacheDirname is the name of the workdir folder storing the fetched
+// chart packages keyed by their digest
+const DigestCacheDirname = "digests"
+
 // DefaultWorkdir returns the default workdir path
 func DefaultWorkdir() string {
 	// We are ignoring errors here as they don't really matter for the purpose